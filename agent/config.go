@@ -2,6 +2,7 @@ package agent
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	
 	"gopkg.in/yaml.v3"
@@ -14,6 +15,10 @@ type Config struct {
 	TLSCertFile string `yaml:"tls_cert_file"`
 	TLSKeyFile  string `yaml:"tls_key_file"`
 	
+	// CA whose certificates controllers must present (mutual TLS). Empty
+	// accepts any client; jobs are still authenticated by HMAC.
+	ClientCAFile string `yaml:"client_ca_file"`
+	
 	// Authentication
 	HMACSecret         string   `yaml:"hmac_secret"`
 	AllowedControllers []string `yaml:"allowed_controllers"`
@@ -23,6 +28,14 @@ type Config struct {
 	AllowedImages     []string `yaml:"allowed_images"`
 	DefaultImage      string   `yaml:"default_image"`
 	
+	// Warm Docker containers kept per sandbox configuration, so jobs skip
+	// container creation; 0 disables the pool
+	SandboxPoolSize int `yaml:"sandbox_pool_size"`
+	
+	// Job submissions allowed per controller per minute; 0 disables the
+	// limit
+	RateLimitPerMinute int `yaml:"rate_limit_per_minute"`
+	
 	// Security
 	RunAsUser  string `yaml:"run_as_user"`
 	RunAsGroup string `yaml:"run_as_group"`
@@ -34,6 +47,25 @@ type Config struct {
 	
 	// Audit
 	AuditDBPath string `yaml:"audit_db_path"`
+	
+	// Job status and results, kept across restarts. Empty keeps jobs in
+	// memory only.
+	JobsDBPath string `yaml:"jobs_db_path"`
+	
+	// Per-plugin settings keyed by plugin name, passed to plugins through
+	// their Context (see docs/PLUGINS.md for the keys each plugin reads)
+	PluginConfig map[string]map[string]interface{} `yaml:"plugins"`
+	
+	// External policy decision point asked about each job before it runs
+	PolicyWebhook PolicyWebhookConfig `yaml:"policy_webhook"`
+}
+
+// PolicyWebhookConfig sets up an external policy decision point. Commands
+// are POSTed to URL, signed with Secret; an empty URL disables it.
+type PolicyWebhookConfig struct {
+	URL            string `yaml:"url"`
+	Secret         string `yaml:"secret"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
 }
 
 // DefaultConfig returns a default configuration
@@ -43,12 +75,14 @@ func DefaultConfig() *Config {
 		MaxConcurrentJobs:  5,
 		AllowedImages:      []string{"alpine:latest", "ubuntu:latest"},
 		DefaultImage:       "alpine:latest",
+		RateLimitPerMinute: 60,
 		RunAsUser:          "quickcmd",
 		RunAsGroup:         "quickcmd",
 		DefaultCPULimit:    0.5,
 		DefaultMemoryLimit: 256 * 1024 * 1024,
 		DefaultTimeout:     300,
 		AuditDBPath:        "/var/lib/quickcmd/agent-audit.db",
+		JobsDBPath:         "/var/lib/quickcmd/agent-jobs.db",
 	}
 }
 
@@ -82,6 +116,10 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("hmac_secret is required")
 	}
 	
+	if c.ClientCAFile != "" && (c.TLSCertFile == "" || c.TLSKeyFile == "") {
+		return fmt.Errorf("client_ca_file requires tls_cert_file and tls_key_file")
+	}
+	
 	if len(c.AllowedControllers) == 0 {
 		return fmt.Errorf("at least one allowed controller is required")
 	}
@@ -90,6 +128,27 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("max_concurrent_jobs must be at least 1")
 	}
 	
+	if c.SandboxPoolSize < 0 {
+		return fmt.Errorf("sandbox_pool_size must not be negative")
+	}
+	
+	if c.RateLimitPerMinute < 0 {
+		return fmt.Errorf("rate_limit_per_minute must not be negative")
+	}
+	
+	if webhook := c.PolicyWebhook; webhook.URL != "" {
+		parsed, err := url.Parse(webhook.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("policy_webhook.url: invalid URL %q", webhook.URL)
+		}
+		if webhook.Secret == "" {
+			return fmt.Errorf("policy_webhook.secret must not be empty")
+		}
+	}
+	if c.PolicyWebhook.TimeoutSeconds < 0 {
+		return fmt.Errorf("policy_webhook.timeout_seconds must not be negative")
+	}
+	
 	return nil
 }
 
@@ -14,7 +14,7 @@ import (
 // JobExecutor executes jobs using the sandbox runner
 type JobExecutor struct {
 	config       *Config
-	dockerRunner *executor.DockerRunner
+	runner       executor.SandboxRunner
 	policyEngine *policy.Engine
 	auditStore   *audit.SQLiteStore
 	snapshotter  *executor.Snapshotter
@@ -22,16 +22,18 @@ type JobExecutor struct {
 
 // NewJobExecutor creates a new job executor
 func NewJobExecutor(config *Config) (*JobExecutor, error) {
-	// Create Docker runner
-	dockerRunner, err := executor.NewDockerRunner()
+	// Create sandbox runner (Docker, or Podman on rootless hosts). The
+	// agent is long-running, so warm containers pay off.
+	runner, err := executor.NewSandboxRunner(executor.WithPool(config.SandboxPoolSize))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Docker runner: %w", err)
+		return nil, fmt.Errorf("failed to create sandbox runner: %w", err)
 	}
 	
 	// Load policy engine
-	policyEngine, err := policy.NewEngine(policy.DefaultPolicy())
-	if err != nil {
-		return nil, fmt.Errorf("failed to create policy engine: %w", err)
+	policyEngine := policy.NewEngine()
+	if webhook := config.PolicyWebhook; webhook.URL != "" {
+		timeout := time.Duration(webhook.TimeoutSeconds) * time.Second
+		policyEngine.SetDecisionPoint(policy.NewDecisionPoint(webhook.URL, webhook.Secret, timeout))
 	}
 	
 	// Create audit store
@@ -45,7 +47,7 @@ func NewJobExecutor(config *Config) (*JobExecutor, error) {
 	
 	return &JobExecutor{
 		config:       config,
-		dockerRunner: dockerRunner,
+		runner:       runner,
 		policyEngine: policyEngine,
 		auditStore:   auditStore,
 		snapshotter:  snapshotter,
@@ -64,7 +66,17 @@ func (e *JobExecutor) Execute(ctx context.Context, payload *JobPayload, logChan
 	
 	// Validate against policy engine
 	e.sendLog(logChan, payload.JobID, "stdout", "Validating command against policy...")
-	if err := e.policyEngine.Validate(payload.Command); err != nil {
+	validation := e.policyEngine.Validate(payload.Command, payload.RiskLevel(), payload.Destructive())
+	if !validation.Allowed {
+		err := fmt.Errorf("command blocked by policy: %s", validation.Reason)
+		e.sendLog(logChan, payload.JobID, "stderr", fmt.Sprintf("Policy validation failed: %v", err))
+		result.Error = err.Error()
+		return result, err
+	}
+	
+	// Jobs needing an approver must come from the approvals workflow
+	if validation.RequiresApprover && payload.ApprovalID() == 0 {
+		err := fmt.Errorf("command requires approval: %s", validation.Reason)
 		e.sendLog(logChan, payload.JobID, "stderr", fmt.Sprintf("Policy validation failed: %v", err))
 		result.Error = err.Error()
 		return result, err
@@ -73,10 +85,11 @@ func (e *JobExecutor) Execute(ctx context.Context, payload *JobPayload, logChan
 	// Plugin pre-run checks
 	e.sendLog(logChan, payload.JobID, "stdout", "Running plugin safety checks...")
 	pluginCtx := plugins.Context{
-		WorkingDir: "/workspace",
-		User:       "agent",
-		Timestamp:  time.Now(),
-		Metadata:   payload.PluginMetadata,
+		WorkingDir:   "/workspace",
+		User:         "agent",
+		Timestamp:    time.Now(),
+		Metadata:     payload.PluginMetadata,
+		PluginConfig: e.config.PluginConfig,
 	}
 	
 	candidate := &plugins.Candidate{
@@ -130,11 +143,16 @@ func (e *JobExecutor) Execute(ctx context.Context, payload *JobPayload, logChan
 	
 	// Execute in sandbox
 	e.sendLog(logChan, payload.JobID, "stdout", "Executing command in sandbox...")
-	sandboxResult, err := e.dockerRunner.RunInSandbox(payload.Command, opts)
+	stdout := &logWriter{executor: e, logChan: logChan, jobID: payload.JobID, stream: "stdout"}
+	stderr := &logWriter{executor: e, logChan: logChan, jobID: payload.JobID, stream: "stderr"}
+	sandboxResult, err := e.runner.RunInSandboxStreaming(payload.Command, opts, stdout, stderr)
 	
 	result.EndTime = time.Now()
 	result.DurationMs = result.EndTime.Sub(result.StartTime).Milliseconds()
 	
+	result.MaxMemoryBytes = sandboxResult.MaxMemoryBytes
+	result.CPUTimeMs = sandboxResult.CPUTimeMs
+	
 	if err != nil {
 		e.sendLog(logChan, payload.JobID, "stderr", fmt.Sprintf("Execution failed: %v", err))
 		result.Error = err.Error()
@@ -148,16 +166,6 @@ func (e *JobExecutor) Execute(ctx context.Context, payload *JobPayload, logChan
 		result.Stdout = string(sandboxResult.Stdout)
 		result.Stderr = string(sandboxResult.Stderr)
 		
-		// Stream stdout
-		if len(sandboxResult.Stdout) > 0 {
-			e.sendLog(logChan, payload.JobID, "stdout", string(sandboxResult.Stdout))
-		}
-		
-		// Stream stderr
-		if len(sandboxResult.Stderr) > 0 {
-			e.sendLog(logChan, payload.JobID, "stderr", string(sandboxResult.Stderr))
-		}
-		
 		if result.ExitCode == 0 {
 			e.sendLog(logChan, payload.JobID, "stdout", "Command executed successfully")
 		} else {
@@ -171,7 +179,18 @@ func (e *JobExecutor) Execute(ctx context.Context, payload *JobPayload, logChan
 	}
 	
 	// Log to audit database
-	auditRecord := &audit.RunRecord{
+	auditRecord := newAuditRecord(payload, result)
+	
+	if err := e.auditStore.LogExecution(auditRecord); err != nil {
+		e.sendLog(logChan, payload.JobID, "stderr", fmt.Sprintf("Failed to log execution: %v", err))
+	}
+	
+	return result, nil
+}
+
+// newAuditRecord builds the audit record for an executed job
+func newAuditRecord(payload *JobPayload, result *JobResult) *audit.RunRecord {
+	return &audit.RunRecord{
 		Timestamp:       time.Now().Format(time.RFC3339),
 		User:            "agent",
 		Prompt:          payload.Prompt,
@@ -180,17 +199,11 @@ func (e *JobExecutor) Execute(ctx context.Context, payload *JobPayload, logChan
 		ExitCode:        result.ExitCode,
 		Stdout:          []byte(result.Stdout),
 		Stderr:          []byte(result.Stderr),
-		RiskLevel:       "medium", // TODO: Get from candidate
+		RiskLevel:       payload.RiskLevel(),
 		Snapshot:        result.Snapshot,
 		Executed:        true,
 		DurationMs:      result.DurationMs,
 	}
-	
-	if err := e.auditStore.LogExecution(auditRecord); err != nil {
-		e.sendLog(logChan, payload.JobID, "stderr", fmt.Sprintf("Failed to log execution: %v", err))
-	}
-	
-	return result, nil
 }
 
 // sendLog sends a log frame to the channel
@@ -208,10 +221,23 @@ func (e *JobExecutor) sendLog(logChan chan<- *LogFrame, jobID, stream, data stri
 	}
 }
 
+// logWriter sends command output to the log channel as it is written
+type logWriter struct {
+	executor *JobExecutor
+	logChan  chan<- *LogFrame
+	jobID    string
+	stream   string
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.executor.sendLog(w.logChan, w.jobID, w.stream, string(p))
+	return len(p), nil
+}
+
 // Close closes the executor resources
 func (e *JobExecutor) Close() error {
-	if e.dockerRunner != nil {
-		e.dockerRunner.Close()
+	if e.runner != nil {
+		e.runner.Close()
 	}
 	if e.auditStore != nil {
 		e.auditStore.Close()
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	
+	"github.com/SagheerAkram/QuickCmd/core/audit"
+	"github.com/SagheerAkram/QuickCmd/core/policy"
+)
+
+func TestNewAuditRecord_RoundTrip(t *testing.T) {
+	store, err := audit.NewSQLiteStore(filepath.Join(t.TempDir(), "audit.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	
+	payload := &JobPayload{
+		JobID:   "job-1",
+		Prompt:  "list files in workspace",
+		Command: "ls -la /workspace",
+		CandidateMetadata: map[string]interface{}{
+			"risk_level": "safe",
+		},
+	}
+	result := &JobResult{
+		JobID:     "job-1",
+		SandboxID: "sandbox-1",
+		Stdout:    "total 0",
+	}
+	
+	record := newAuditRecord(payload, result)
+	if err := store.LogExecution(record); err != nil {
+		t.Fatalf("LogExecution() error: %v", err)
+	}
+	
+	retrieved, err := store.GetRecordByID(record.ID)
+	if err != nil {
+		t.Fatalf("GetRecordByID() error: %v", err)
+	}
+	
+	if retrieved.Prompt != payload.Prompt {
+		t.Errorf("Retrieved prompt = %q, want %q", retrieved.Prompt, payload.Prompt)
+	}
+	if retrieved.RiskLevel != "safe" {
+		t.Errorf("Retrieved risk level = %q, want %q", retrieved.RiskLevel, "safe")
+	}
+}
+
+func TestExecute_RequiresApproval(t *testing.T) {
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(policy.DecisionResponse{Decision: policy.DecisionRequireApproval, Reason: "production change"})
+	}))
+	defer webhook.Close()
+	
+	engine := policy.NewEngine()
+	engine.SetDecisionPoint(policy.NewDecisionPoint(webhook.URL, "secret", 0))
+	e := &JobExecutor{config: DefaultConfig(), policyEngine: engine}
+	
+	payload := &JobPayload{
+		JobID:             "job-1",
+		Command:           "kubectl rollout restart deployment/api",
+		CandidateMetadata: map[string]interface{}{"risk_level": "medium"},
+	}
+	
+	_, err := e.Execute(context.Background(), payload, make(chan *LogFrame, 10))
+	if err == nil || !strings.Contains(err.Error(), "requires approval") {
+		t.Errorf("Execute() without an approval error = %v, want approval required", err)
+	}
+}
@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// agentMetrics are the Prometheus metrics served on /metrics. Each server
+// has its own registry, so they start from zero when the agent restarts.
+type agentMetrics struct {
+	jobsTotal   prometheus.Counter
+	jobsRunning prometheus.Gauge
+	completed   prometheus.Counter
+	failed      prometheus.Counter
+	duration    *prometheus.HistogramVec
+	exitCodes   *prometheus.CounterVec
+	handler     http.Handler
+}
+
+// newAgentMetrics creates the metrics of s, reading the queue depth from
+// s.queued at scrape time
+func newAgentMetrics(s *Server) *agentMetrics {
+	m := &agentMetrics{
+		jobsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "quickcmd_agent_jobs_total",
+			Help: "Total number of jobs",
+		}),
+		jobsRunning: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "quickcmd_agent_jobs_running",
+			Help: "Currently running jobs",
+		}),
+		completed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "quickcmd_agent_jobs_completed",
+			Help: "Completed jobs",
+		}),
+		failed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "quickcmd_agent_jobs_failed",
+			Help: "Failed jobs",
+		}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "quickcmd_agent_job_duration_seconds",
+			Help:    "Job execution time, by final status",
+			Buckets: []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600},
+		}, []string{"status"}),
+		exitCodes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "quickcmd_agent_job_exit_codes_total",
+			Help: "Executed jobs by the exit code of their command",
+		}, []string{"exit_code"}),
+	}
+
+	queued := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "quickcmd_agent_jobs_queued",
+		Help: "Jobs waiting for a free execution slot",
+	}, func() float64 {
+		return float64(atomic.LoadInt64(&s.queued))
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(m.jobsTotal, m.jobsRunning, queued, m.completed, m.failed, m.duration, m.exitCodes)
+	m.handler = promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+
+	return m
+}
+
+// observeResult records a finished job's status, duration and exit code. A
+// job cancelled before it started has no duration or exit code.
+func (m *agentMetrics) observeResult(result *JobResult, executed bool) {
+	if result.Status == JobStatusCompleted {
+		m.completed.Inc()
+	} else {
+		m.failed.Inc()
+	}
+
+	if !executed {
+		return
+	}
+	duration := time.Duration(result.DurationMs) * time.Millisecond
+	m.duration.WithLabelValues(string(result.Status)).Observe(duration.Seconds())
+	m.exitCodes.WithLabelValues(strconv.Itoa(result.ExitCode)).Inc()
+}
@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimiterCleanupInterval is how often idle buckets are dropped
+const rateLimiterCleanupInterval = 5 * time.Minute
+
+// rateLimiter is a token bucket rate limiter with a bucket per controller.
+// Each bucket holds up to a minute's worth of requests and refills
+// continuously.
+type rateLimiter struct {
+	mu          sync.Mutex
+	perMinute   float64
+	buckets     map[string]*tokenBucket
+	lastCleanup time.Time
+	now         func() time.Time
+}
+
+// tokenBucket is one controller's remaining requests
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns a limiter allowing perMinute requests a minute per
+// key, or nil if perMinute is not positive
+func newRateLimiter(perMinute int) *rateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		perMinute:   float64(perMinute),
+		buckets:     make(map[string]*tokenBucket),
+		lastCleanup: time.Now(),
+		now:         time.Now,
+	}
+}
+
+// Allow takes a token from key's bucket. If the bucket is empty it reports
+// false and how long until the next token.
+func (l *rateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastCleanup) >= rateLimiterCleanupInterval {
+		l.cleanup(now)
+	}
+
+	b := l.buckets[key]
+	if b == nil {
+		b = &tokenBucket{tokens: l.perMinute, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.perMinute, b.tokens+now.Sub(b.last).Minutes()*l.perMinute)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.perMinute * float64(time.Minute))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// cleanup drops buckets that have refilled completely, since they are no
+// different from a new bucket. Callers must hold l.mu.
+func (l *rateLimiter) cleanup(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Minutes()*l.perMinute >= l.perMinute {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastCleanup = now
+}
+
+// retryAfterSeconds formats wait for a Retry-After header, rounding up to
+// at least a second
+func retryAfterSeconds(wait time.Duration) int {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
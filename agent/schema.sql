@@ -0,0 +1,14 @@
+-- QuickCMD Agent Job Schema
+-- This schema stores submitted jobs so their status survives an agent restart
+
+CREATE TABLE IF NOT EXISTS jobs (
+    job_id TEXT PRIMARY KEY,
+    status TEXT NOT NULL,
+    payload TEXT NOT NULL,   -- JSON-encoded JobPayload
+    result TEXT,             -- JSON-encoded JobResult, NULL until the job ends
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status);
+CREATE INDEX IF NOT EXISTS idx_jobs_created_at ON jobs(created_at);
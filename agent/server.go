@@ -4,16 +4,25 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
+	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 	
 	"github.com/gorilla/websocket"
+	"github.com/SagheerAkram/QuickCmd/core/executor"
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
 )
 
+// errDuplicateJob is returned by startJob for a job ID the agent has
+// already seen, so a replayed signed payload isn't executed twice
+var errDuplicateJob = errors.New("job ID already submitted")
+
 // Server represents the agent HTTP server
 type Server struct {
 	config    *Config
@@ -21,7 +30,21 @@ type Server struct {
 	jobsMu    sync.RWMutex
 	upgrader  websocket.Upgrader
 	executor  *JobExecutor
+	store     *JobStore // nil when jobs are kept in memory only
 	httpServer *http.Server
+	
+	// runJob executes a job's command, normally executor.Execute
+	runJob func(ctx context.Context, payload *JobPayload, logChan chan<- *LogFrame) (*JobResult, error)
+	
+	// slots limits the jobs running at once to MaxConcurrentJobs; jobs
+	// waiting for a slot are counted in queued
+	slots  chan struct{}
+	queued int64
+	
+	// limiter rate limits job submissions per controller; nil disables it
+	limiter *rateLimiter
+	
+	metrics *agentMetrics
 }
 
 // Job represents a job being executed
@@ -29,7 +52,7 @@ type Job struct {
 	Payload   *JobPayload
 	Status    JobStatus
 	Result    *JobResult
-	LogChan   chan *LogFrame
+	LogChan   chan *LogFrame // nil for jobs restored from the job store
 	CancelFunc context.CancelFunc
 	CreatedAt time.Time
 }
@@ -45,6 +68,9 @@ func NewServer(config *Config) (*Server, error) {
 		config:   config,
 		jobs:     make(map[string]*Job),
 		executor: executor,
+		runJob:   executor.Execute,
+		slots:    make(chan struct{}, maxConcurrentJobs(config)),
+		limiter:  newRateLimiter(config.RateLimitPerMinute),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				// Check if origin is in allowed controllers
@@ -58,10 +84,49 @@ func NewServer(config *Config) (*Server, error) {
 			},
 		},
 	}
+	server.metrics = newAgentMetrics(server)
+	
+	if config.JobsDBPath != "" {
+		store, err := NewJobStore(config.JobsDBPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create job store: %w", err)
+		}
+		server.store = store
+		
+		if err := server.restoreJobs(); err != nil {
+			store.Close()
+			return nil, err
+		}
+	}
 	
 	return server, nil
 }
 
+// restoreJobs loads the jobs of previous runs from the job store, failing
+// those the last agent process left unfinished
+func (s *Server) restoreJobs() error {
+	interrupted, err := s.store.MarkInterrupted()
+	if err != nil {
+		return fmt.Errorf("failed to mark interrupted jobs: %w", err)
+	}
+	if interrupted > 0 {
+		log.Printf("Marked %d unfinished job(s) from the previous run as failed", interrupted)
+	}
+	
+	jobs, err := s.store.ListJobs("")
+	if err != nil {
+		return fmt.Errorf("failed to load jobs: %w", err)
+	}
+	
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+	for _, job := range jobs {
+		s.jobs[job.Payload.JobID] = job
+	}
+	
+	return nil
+}
+
 // Start starts the HTTPS server
 func (s *Server) Start() error {
 	mux := http.NewServeMux()
@@ -70,16 +135,14 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/api/v1/jobs", s.handleSubmitJob)
 	mux.HandleFunc("/api/v1/jobs/", s.handleJobStatus)
 	mux.HandleFunc("/api/v1/stream/", s.handleLogStream)
+	mux.HandleFunc("/api/v1/capabilities", s.handleCapabilities)
 	mux.HandleFunc("/health", s.handleHealth)
 	mux.HandleFunc("/metrics", s.handleMetrics)
 	
 	// Configure TLS
-	tlsConfig := &tls.Config{
-		MinVersion: tls.VersionTLS12,
-		CipherSuites: []uint16{
-			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
-			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
-		},
+	tlsConfig, err := s.tlsConfig()
+	if err != nil {
+		return err
 	}
 	
 	s.httpServer = &http.Server{
@@ -112,7 +175,14 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	}
 	s.jobsMu.Unlock()
 	
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+	
+	// Jobs still running are marked failed on the next start
+	if s.store != nil {
+		s.store.Close()
+	}
+	
+	return err
 }
 
 // handleSubmitJob handles job submission
@@ -135,6 +205,12 @@ func (s *Server) handleSubmitJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	// Validate fields before anything else looks at them
+	if err := signedJob.Payload.Validate(); err != nil {
+		s.writeInvalidPayload(w, err)
+		return
+	}
+	
 	// Validate TTL
 	if err := ValidateTTL(&signedJob.Payload); err != nil {
 		s.writeError(w, http.StatusUnauthorized, "Job expired or too old", err)
@@ -142,36 +218,88 @@ func (s *Server) handleSubmitJob(w http.ResponseWriter, r *http.Request) {
 	}
 	
 	// Validate controller
-	if !s.isAllowedController(signedJob.Payload.ControllerID) {
+	if !s.isAllowedController(signedJob.Payload.ControllerID, r.TLS) {
 		s.writeError(w, http.StatusForbidden, "Controller not allowed", nil)
 		return
 	}
 	
-	// Create job
+	// Rate limit authenticated controllers
+	if s.limiter != nil {
+		if ok, wait := s.limiter.Allow(signedJob.Payload.ControllerID); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(wait)))
+			s.writeError(w, http.StatusTooManyRequests, "Rate limit exceeded", nil)
+			return
+		}
+	}
+	
+	if _, err := s.startJob(&signedJob.Payload); err != nil {
+		if errors.Is(err, errDuplicateJob) {
+			s.writeError(w, http.StatusConflict, "Job already submitted", err)
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, "Failed to start job", err)
+		return
+	}
+	
+	// Return job ID
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"job_id": signedJob.Payload.JobID,
+		"status": JobStatusPending,
+	})
+}
+
+// startJob records a job and executes it asynchronously once a slot is free.
+// Job IDs already known to the agent, in memory or in the job store, are
+// rejected with errDuplicateJob.
+func (s *Server) startJob(payload *JobPayload) (*Job, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	job := &Job{
-		Payload:    &signedJob.Payload,
+		Payload:    payload,
 		Status:     JobStatusPending,
 		LogChan:    make(chan *LogFrame, 100),
 		CancelFunc: cancel,
 		CreatedAt:  time.Now(),
 	}
 	
-	// Store job
+	// Store job, holding the lock across the lookup so two submissions of
+	// the same ID can't both start
 	s.jobsMu.Lock()
-	s.jobs[signedJob.Payload.JobID] = job
+	if err := s.checkNewJobID(payload.JobID); err != nil {
+		s.jobsMu.Unlock()
+		cancel()
+		return nil, err
+	}
+	s.jobs[payload.JobID] = job
 	s.jobsMu.Unlock()
+	s.saveJob(job)
+	s.metrics.jobsTotal.Inc()
 	
 	// Execute job asynchronously
 	go s.executeJob(ctx, job)
 	
-	// Return job ID
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusAccepted)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"job_id": signedJob.Payload.JobID,
-		"status": JobStatusPending,
-	})
+	return job, nil
+}
+
+// checkNewJobID returns errDuplicateJob if jobID was submitted before. The
+// caller must hold jobsMu.
+func (s *Server) checkNewJobID(jobID string) error {
+	if _, exists := s.jobs[jobID]; exists {
+		return fmt.Errorf("%w: %s", errDuplicateJob, jobID)
+	}
+	if s.store == nil {
+		return nil
+	}
+	
+	stored, err := s.store.Get(jobID)
+	if err != nil {
+		return fmt.Errorf("failed to look up job %s: %w", jobID, err)
+	}
+	if stored != nil {
+		return fmt.Errorf("%w: %s", errDuplicateJob, jobID)
+	}
+	return nil
 }
 
 // handleJobStatus returns the status of a job
@@ -215,6 +343,12 @@ func (s *Server) handleLogStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	// Logs are only streamed live, not persisted
+	if job.LogChan == nil {
+		http.Error(w, "Job logs are not available after an agent restart", http.StatusGone)
+		return
+	}
+	
 	// Upgrade to WebSocket
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -236,6 +370,51 @@ func (s *Server) handleLogStream(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleCapabilities returns what the agent supports
+func (s *Server) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.capabilities())
+}
+
+// capabilities describes the agent's API version, sandbox backend, enabled
+// plugins and resource limits
+func (s *Server) capabilities() *Capabilities {
+	caps := &Capabilities{
+		APIVersion:        APIVersion,
+		SandboxBackend:    executor.RunnerBackend(s.executor.runner),
+		Plugins:           []PluginCapability{},
+		AllowedImages:     s.config.AllowedImages,
+		DefaultImage:      s.config.DefaultImage,
+		MaxConcurrentJobs: s.config.MaxConcurrentJobs,
+		MaxResources: ResourceLimits{
+			CPULimit:       s.config.DefaultCPULimit,
+			MemoryLimit:    s.config.DefaultMemoryLimit,
+			TimeoutSeconds: s.config.DefaultTimeout,
+		},
+	}
+	
+	registry := plugins.DefaultRegistry()
+	for _, plugin := range registry.ListEnabled() {
+		pc := PluginCapability{
+			Name:       plugin.Name(),
+			Scopes:     plugin.Scopes(),
+			Configured: s.config.PluginConfig[plugin.Name()] != nil,
+		}
+		if metadata, err := registry.GetMetadata(plugin.Name()); err == nil {
+			pc.Version = metadata.Version
+		}
+		caps.Plugins = append(caps.Plugins, pc)
+	}
+	sort.Slice(caps.Plugins, func(i, j int) bool { return caps.Plugins[i].Name < caps.Plugins[j].Name })
+	
+	return caps
+}
+
 // handleHealth returns health status
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -247,45 +426,35 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 
 // handleMetrics returns Prometheus metrics
 func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
-	s.jobsMu.RLock()
-	totalJobs := len(s.jobs)
-	
-	var running, completed, failed int
-	for _, job := range s.jobs {
-		switch job.Status {
-		case JobStatusRunning:
-			running++
-		case JobStatusCompleted:
-			completed++
-		case JobStatusFailed:
-			failed++
-		}
-	}
-	s.jobsMu.RUnlock()
-	
-	w.Header().Set("Content-Type", "text/plain")
-	fmt.Fprintf(w, "# HELP quickcmd_agent_jobs_total Total number of jobs\n")
-	fmt.Fprintf(w, "# TYPE quickcmd_agent_jobs_total counter\n")
-	fmt.Fprintf(w, "quickcmd_agent_jobs_total %d\n", totalJobs)
-	
-	fmt.Fprintf(w, "# HELP quickcmd_agent_jobs_running Currently running jobs\n")
-	fmt.Fprintf(w, "# TYPE quickcmd_agent_jobs_running gauge\n")
-	fmt.Fprintf(w, "quickcmd_agent_jobs_running %d\n", running)
-	
-	fmt.Fprintf(w, "# HELP quickcmd_agent_jobs_completed Completed jobs\n")
-	fmt.Fprintf(w, "# TYPE quickcmd_agent_jobs_completed counter\n")
-	fmt.Fprintf(w, "quickcmd_agent_jobs_completed %d\n", completed)
-	
-	fmt.Fprintf(w, "# HELP quickcmd_agent_jobs_failed Failed jobs\n")
-	fmt.Fprintf(w, "# TYPE quickcmd_agent_jobs_failed counter\n")
-	fmt.Fprintf(w, "quickcmd_agent_jobs_failed %d\n", failed)
+	s.metrics.handler.ServeHTTP(w, r)
 }
 
-// executeJob executes a job in the background
+// executeJob executes a job in the background. The job stays pending until
+// one of the MaxConcurrentJobs slots is free; if it is cancelled before
+// then, it fails without running.
 func (s *Server) executeJob(ctx context.Context, job *Job) {
+	if !s.acquireSlot(ctx) {
+		job.Status = JobStatusFailed
+		job.Result = &JobResult{
+			JobID:   job.Payload.JobID,
+			Status:  JobStatusFailed,
+			Error:   "cancelled before it started",
+			EndTime: time.Now(),
+		}
+		s.saveJob(job)
+		s.metrics.observeResult(job.Result, false)
+		s.finishJob(job)
+		return
+	}
 	job.Status = JobStatusRunning
+	s.saveJob(job)
+	
+	s.metrics.jobsRunning.Inc()
+	result, err := s.runJob(ctx, job.Payload, job.LogChan)
+	s.metrics.jobsRunning.Dec()
 	
-	result, err := s.executor.Execute(ctx, job.Payload, job.LogChan)
+	// Free the slot before finishing, so a slow log consumer can't hold it
+	s.releaseSlot()
 	if err != nil {
 		job.Status = JobStatusFailed
 		result.Status = JobStatusFailed
@@ -296,19 +465,79 @@ func (s *Server) executeJob(ctx context.Context, job *Job) {
 	}
 	
 	job.Result = result
+	s.saveJob(job)
+	s.metrics.observeResult(result, true)
+	s.finishJob(job)
+}
+
+// acquireSlot waits for a free execution slot, reporting false if ctx is
+// cancelled first
+func (s *Server) acquireSlot(ctx context.Context) bool {
+	atomic.AddInt64(&s.queued, 1)
+	defer atomic.AddInt64(&s.queued, -1)
+	
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		return false
+	}
 	
-	// Send final log frame
-	job.LogChan <- &LogFrame{
+	// A slot and the cancellation may have been ready at once
+	if ctx.Err() != nil {
+		s.releaseSlot()
+		return false
+	}
+	return true
+}
+
+// releaseSlot frees a slot taken by acquireSlot
+func (s *Server) releaseSlot() {
+	<-s.slots
+}
+
+// finishJob sends the final log frame and closes the job's log stream. The
+// final frame is dropped if nobody has drained the stream; closing it still
+// ends the stream for a late consumer.
+func (s *Server) finishJob(job *Job) {
+	select {
+	case job.LogChan <- &LogFrame{
 		JobID:     job.Payload.JobID,
 		Timestamp: time.Now(),
 		Final:     true,
+	}:
+	default:
 	}
 	close(job.LogChan)
 }
 
+// maxConcurrentJobs returns the number of jobs that may run at once
+func maxConcurrentJobs(config *Config) int {
+	if config.MaxConcurrentJobs < 1 {
+		return 1
+	}
+	return config.MaxConcurrentJobs
+}
+
 // Helper functions
 
-func (s *Server) isAllowedController(controllerID string) bool {
+// saveJob persists job, if the agent has a job store
+func (s *Server) saveJob(job *Job) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.Save(job); err != nil {
+		log.Printf("Failed to save job %s: %v", job.Payload.JobID, err)
+	}
+}
+
+// isAllowedController reports whether controllerID may submit jobs over the
+// connection state. With mutual TLS, the client certificate must also name
+// the controller.
+func (s *Server) isAllowedController(controllerID string, state *tls.ConnectionState) bool {
+	if s.config.ClientCAFile != "" && !certMatchesController(state, controllerID) {
+		return false
+	}
+	
 	for _, allowed := range s.config.AllowedControllers {
 		if allowed == controllerID {
 			return true
@@ -317,6 +546,24 @@ func (s *Server) isAllowedController(controllerID string) bool {
 	return false
 }
 
+// writeInvalidPayload writes a 400 response for a payload that failed
+// Validate, listing each invalid field under "fields"
+func (s *Server) writeInvalidPayload(w http.ResponseWriter, err error) {
+	response := map[string]interface{}{
+		"error":   "Invalid job payload",
+		"details": err.Error(),
+	}
+	
+	var payloadErr *PayloadError
+	if errors.As(err, &payloadErr) {
+		response["fields"] = payloadErr.Fields
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(response)
+}
+
 func (s *Server) writeError(w http.ResponseWriter, status int, message string, err error) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
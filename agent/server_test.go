@@ -0,0 +1,483 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	
+	"github.com/SagheerAkram/QuickCmd/core/executor"
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
+)
+
+// fakePlugin is a plugin that handles nothing
+type fakePlugin struct{ name string }
+
+func (p *fakePlugin) Name() string { return p.name }
+
+func (p *fakePlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Candidate, error) {
+	return nil, nil
+}
+
+func (p *fakePlugin) PreRunCheck(ctx plugins.Context, candidate *plugins.Candidate) (*plugins.CheckResult, error) {
+	return &plugins.CheckResult{Allowed: true}, nil
+}
+
+func (p *fakePlugin) RequiresApproval(candidate *plugins.Candidate) bool { return false }
+
+func (p *fakePlugin) Scopes() []string { return []string{"k8s:read"} }
+
+func TestHandleCapabilities(t *testing.T) {
+	plugin := &fakePlugin{name: "capabilities-test"}
+	if err := plugins.Register(plugin, &plugins.PluginMetadata{Name: plugin.name, Version: "1.2.0", Enabled: true}); err != nil {
+		t.Fatalf("Register() error: %v", err)
+	}
+	defer plugins.DefaultRegistry().Unregister(plugin.name)
+	
+	config := DefaultConfig()
+	config.PluginConfig = map[string]map[string]interface{}{
+		plugin.name: {"context": "staging"},
+	}
+	server := &Server{
+		config:   config,
+		executor: &JobExecutor{config: config, runner: &executor.PodmanRunner{}},
+	}
+	
+	rec := httptest.NewRecorder()
+	server.handleCapabilities(rec, httptest.NewRequest(http.MethodGet, "/api/v1/capabilities", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	
+	var caps Capabilities
+	if err := json.Unmarshal(rec.Body.Bytes(), &caps); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, rec.Body.String())
+	}
+	
+	if caps.APIVersion != APIVersion {
+		t.Errorf("api_version = %q, want %q", caps.APIVersion, APIVersion)
+	}
+	if caps.SandboxBackend != "podman" {
+		t.Errorf("sandbox_backend = %q, want podman", caps.SandboxBackend)
+	}
+	if caps.MaxResources.MemoryLimit != config.DefaultMemoryLimit || caps.MaxConcurrentJobs != config.MaxConcurrentJobs {
+		t.Errorf("limits = %+v, max jobs %d", caps.MaxResources, caps.MaxConcurrentJobs)
+	}
+	
+	var found *PluginCapability
+	for i := range caps.Plugins {
+		if caps.Plugins[i].Name == plugin.name {
+			found = &caps.Plugins[i]
+		}
+	}
+	if found == nil {
+		t.Fatalf("plugins = %+v, want %s", caps.Plugins, plugin.name)
+	}
+	if found.Version != "1.2.0" || !found.Configured || len(found.Scopes) != 1 {
+		t.Errorf("plugin capability = %+v", found)
+	}
+	
+	rec = httptest.NewRecorder()
+	server.handleCapabilities(rec, httptest.NewRequest(http.MethodPost, "/api/v1/capabilities", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestExecuteJob_ConcurrencyLimit(t *testing.T) {
+	const limit, total = 2, 5
+	
+	var running, maxRunning int64
+	var started sync.Map
+	release := make(chan struct{})
+	
+	config := DefaultConfig()
+	config.MaxConcurrentJobs = limit
+	server := &Server{
+		config: config,
+		jobs:   make(map[string]*Job),
+		slots:  make(chan struct{}, maxConcurrentJobs(config)),
+		runJob: func(ctx context.Context, payload *JobPayload, logChan chan<- *LogFrame) (*JobResult, error) {
+			started.Store(payload.JobID, true)
+			n := atomic.AddInt64(&running, 1)
+			for {
+				max := atomic.LoadInt64(&maxRunning)
+				if n <= max || atomic.CompareAndSwapInt64(&maxRunning, max, n) {
+					break
+				}
+			}
+			
+			<-release
+			atomic.AddInt64(&running, -1)
+			return &JobResult{JobID: payload.JobID}, nil
+		},
+	}
+	
+	server.metrics = newAgentMetrics(server)
+	
+	var jobs []*Job
+	for i := 0; i < total; i++ {
+		jobs = append(jobs, mustStartJob(t, server, &JobPayload{JobID: fmt.Sprintf("job-%d", i)}))
+	}
+	
+	waitFor(t, "jobs beyond the limit to queue", func() bool {
+		return atomic.LoadInt64(&running) == limit && atomic.LoadInt64(&server.queued) == total-limit
+	})
+	
+	rec := httptest.NewRecorder()
+	server.handleMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if want := fmt.Sprintf("quickcmd_agent_jobs_queued %d\n", total-limit); !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("metrics missing %q:\n%s", want, rec.Body.String())
+	}
+	
+	// Cancelling a queued job removes it before it starts
+	var cancelled *Job
+	for _, job := range jobs {
+		if _, ok := started.Load(job.Payload.JobID); !ok {
+			cancelled = job
+			break
+		}
+	}
+	cancelled.CancelFunc()
+	waitFor(t, "the cancelled job to leave the queue", func() bool {
+		return atomic.LoadInt64(&server.queued) == total-limit-1
+	})
+	
+	close(release)
+	for _, job := range jobs {
+		for range job.LogChan {
+		}
+	}
+	
+	if maxRunning != limit {
+		t.Errorf("at most %d jobs ran at once, want %d", maxRunning, limit)
+	}
+	if _, ok := started.Load(cancelled.Payload.JobID); ok {
+		t.Error("cancelled job was executed")
+	}
+	if cancelled.Status != JobStatusFailed || cancelled.Result == nil || cancelled.Result.Error != "cancelled before it started" {
+		t.Errorf("cancelled job status = %s, result %+v", cancelled.Status, cancelled.Result)
+	}
+	for _, job := range jobs {
+		if job != cancelled && job.Status != JobStatusCompleted {
+			t.Errorf("%s status = %s, want completed", job.Payload.JobID, job.Status)
+		}
+	}
+}
+
+func TestExecuteJob_NoLogConsumer(t *testing.T) {
+	ran := make(chan string, 2)
+	
+	config := DefaultConfig()
+	config.MaxConcurrentJobs = 1
+	server := &Server{
+		config: config,
+		jobs:   make(map[string]*Job),
+		slots:  make(chan struct{}, maxConcurrentJobs(config)),
+		runJob: func(ctx context.Context, payload *JobPayload, logChan chan<- *LogFrame) (*JobResult, error) {
+			// Fill the log stream, which nobody reads
+			for i := 0; i < cap(logChan); i++ {
+				logChan <- &LogFrame{JobID: payload.JobID, Stream: "stdout", Data: "line"}
+			}
+			ran <- payload.JobID
+			return &JobResult{JobID: payload.JobID}, nil
+		},
+	}
+	server.metrics = newAgentMetrics(server)
+	
+	first := mustStartJob(t, server, &JobPayload{JobID: "job-1"})
+	mustStartJob(t, server, &JobPayload{JobID: "job-2"})
+	
+	// The first job finishes and frees the only slot with its stream full
+	for i := 0; i < 2; i++ {
+		select {
+		case <-ran:
+		case <-time.After(5 * time.Second):
+			t.Fatal("a job whose logs nobody read kept its slot")
+		}
+	}
+	
+	// A late consumer still sees the stream end
+	frames := 0
+	for range first.LogChan {
+		frames++
+	}
+	if frames != cap(first.LogChan) {
+		t.Errorf("read %d frames, want the %d buffered ones", frames, cap(first.LogChan))
+	}
+}
+
+func TestHandleSubmitJob_RateLimit(t *testing.T) {
+	const limit = 3
+	
+	config := DefaultConfig()
+	config.HMACSecret = "secret"
+	config.AllowedControllers = []string{"controller-a", "controller-b"}
+	config.RateLimitPerMinute = limit
+	server := &Server{
+		config:  config,
+		jobs:    make(map[string]*Job),
+		slots:   make(chan struct{}, maxConcurrentJobs(config)),
+		limiter: newRateLimiter(config.RateLimitPerMinute),
+		runJob: func(ctx context.Context, payload *JobPayload, logChan chan<- *LogFrame) (*JobResult, error) {
+			return &JobResult{JobID: payload.JobID}, nil
+		},
+	}
+	server.metrics = newAgentMetrics(server)
+	now := time.Now()
+	server.limiter.now = func() time.Time { return now }
+	
+	submit := func(controllerID, secret string, n int) *httptest.ResponseRecorder {
+		payload := JobPayload{
+			JobID:        fmt.Sprintf("%s-%d", controllerID, n),
+			Command:      "ls",
+			TTL:          time.Now().Add(time.Minute).Unix(),
+			Timestamp:    time.Now().Unix(),
+			ControllerID: controllerID,
+		}
+		signature, _ := SignPayload(&payload, secret)
+		body, _ := json.Marshal(SignedJob{Payload: payload, Signature: JobSignature{Signature: signature, Algorithm: "HMAC-SHA256"}})
+		
+		rec := httptest.NewRecorder()
+		server.handleSubmitJob(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(body))))
+		return rec
+	}
+	
+	limited := 0
+	for i := 0; i < limit+2; i++ {
+		rec := submit("controller-a", "secret", i)
+		switch {
+		case i < limit && rec.Code != http.StatusAccepted:
+			t.Errorf("request %d status = %d, want %d", i+1, rec.Code, http.StatusAccepted)
+		case i >= limit && rec.Code == http.StatusTooManyRequests:
+			limited++
+			if rec.Header().Get("Retry-After") != "20" {
+				t.Errorf("Retry-After = %q, want 20", rec.Header().Get("Retry-After"))
+			}
+		}
+	}
+	if limited != 2 {
+		t.Errorf("%d requests rate limited, want 2", limited)
+	}
+	
+	// Buckets are per controller, and unauthenticated requests don't count
+	if rec := submit("controller-b", "wrong", 0); rec.Code != http.StatusUnauthorized {
+		t.Errorf("badly signed request status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := submit("controller-b", "secret", 1); rec.Code != http.StatusAccepted {
+		t.Errorf("other controller status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	
+	// Tokens refill over time
+	now = now.Add(20 * time.Second)
+	if rec := submit("controller-a", "secret", limit+2); rec.Code != http.StatusAccepted {
+		t.Errorf("status after refill = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	
+	// Full buckets are cleaned up
+	now = now.Add(rateLimiterCleanupInterval)
+	submit("controller-b", "secret", 2)
+	if n := len(server.limiter.buckets); n != 1 {
+		t.Errorf("%d buckets after cleanup, want 1", n)
+	}
+}
+
+func TestHandleSubmitJob_InvalidPayload(t *testing.T) {
+	config := DefaultConfig()
+	config.HMACSecret = "secret"
+	started := false
+	server := &Server{
+		config: config,
+		jobs:   make(map[string]*Job),
+		slots:  make(chan struct{}, maxConcurrentJobs(config)),
+		runJob: func(ctx context.Context, payload *JobPayload, logChan chan<- *LogFrame) (*JobResult, error) {
+			started = true
+			return &JobResult{JobID: payload.JobID}, nil
+		},
+	}
+	server.metrics = newAgentMetrics(server)
+	
+	// Correctly signed, but with no command
+	payload := JobPayload{
+		JobID:        "job-1",
+		TTL:          time.Now().Add(time.Minute).Unix(),
+		Timestamp:    time.Now().Unix(),
+		ControllerID: "controller-a",
+	}
+	signature, _ := SignPayload(&payload, "secret")
+	body, _ := json.Marshal(SignedJob{Payload: payload, Signature: JobSignature{Signature: signature, Algorithm: "HMAC-SHA256"}})
+	
+	rec := httptest.NewRecorder()
+	server.handleSubmitJob(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(body))))
+	
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	
+	var response struct {
+		Error   string       `json:"error"`
+		Details string       `json:"details"`
+		Fields  []FieldError `json:"fields"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Fields) != 1 || response.Fields[0].Field != "command" || response.Fields[0].Message != "is required" {
+		t.Errorf("fields = %+v, want command is required", response.Fields)
+	}
+	if !strings.Contains(response.Details, "command is required") {
+		t.Errorf("details = %q, want it to name the missing command", response.Details)
+	}
+	
+	server.jobsMu.RLock()
+	defer server.jobsMu.RUnlock()
+	if len(server.jobs) != 0 || started {
+		t.Errorf("invalid payload created %d jobs, want none", len(server.jobs))
+	}
+}
+
+func TestHandleSubmitJob_DuplicateJobID(t *testing.T) {
+	config := DefaultConfig()
+	config.HMACSecret = "secret"
+	config.AllowedControllers = []string{"controller-a"}
+	
+	store, err := NewJobStore(filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatalf("NewJobStore() error: %v", err)
+	}
+	defer store.Close()
+	
+	var runs int64
+	server := &Server{
+		config: config,
+		jobs:   make(map[string]*Job),
+		store:  store,
+		slots:  make(chan struct{}, maxConcurrentJobs(config)),
+		runJob: func(ctx context.Context, payload *JobPayload, logChan chan<- *LogFrame) (*JobResult, error) {
+			atomic.AddInt64(&runs, 1)
+			return &JobResult{JobID: payload.JobID}, nil
+		},
+	}
+	server.metrics = newAgentMetrics(server)
+	
+	submit := func(jobID string) *httptest.ResponseRecorder {
+		payload := JobPayload{
+			JobID:        jobID,
+			Command:      "ls",
+			TTL:          time.Now().Add(time.Minute).Unix(),
+			Timestamp:    time.Now().Unix(),
+			ControllerID: "controller-a",
+		}
+		signature, _ := SignPayload(&payload, "secret")
+		body, _ := json.Marshal(SignedJob{Payload: payload, Signature: JobSignature{Signature: signature, Algorithm: "HMAC-SHA256"}})
+		
+		rec := httptest.NewRecorder()
+		server.handleSubmitJob(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(string(body))))
+		return rec
+	}
+	
+	if rec := submit("job-1"); rec.Code != http.StatusAccepted {
+		t.Fatalf("first submission status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	
+	// Replaying the signed payload must not run the command again
+	if rec := submit("job-1"); rec.Code != http.StatusConflict {
+		t.Errorf("replayed submission status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	waitFor(t, "the first job to run", func() bool { return atomic.LoadInt64(&runs) == 1 })
+	
+	// Jobs only in the store, such as those of a previous run, count too
+	if err := store.Save(&Job{Payload: &JobPayload{JobID: "job-0"}, Status: JobStatusCompleted, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if rec := submit("job-0"); rec.Code != http.StatusConflict {
+		t.Errorf("stored job submission status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt64(&runs); n != 1 {
+		t.Errorf("runJob called %d times, want 1", n)
+	}
+}
+
+func TestHandleMetrics(t *testing.T) {
+	config := DefaultConfig()
+	server := &Server{
+		config: config,
+		jobs:   make(map[string]*Job),
+		slots:  make(chan struct{}, maxConcurrentJobs(config)),
+		runJob: func(ctx context.Context, payload *JobPayload, logChan chan<- *LogFrame) (*JobResult, error) {
+			if payload.Command == "false" {
+				return &JobResult{JobID: payload.JobID, ExitCode: 1, DurationMs: 40}, fmt.Errorf("command failed")
+			}
+			return &JobResult{JobID: payload.JobID, DurationMs: 2500}, nil
+		},
+	}
+	server.metrics = newAgentMetrics(server)
+	
+	for i, command := range []string{"true", "true", "false"} {
+		job := mustStartJob(t, server, &JobPayload{JobID: fmt.Sprintf("job-%d", i), Command: command})
+		for range job.LogChan {
+		}
+	}
+	
+	rec := httptest.NewRecorder()
+	server.handleMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	
+	body := rec.Body.String()
+	for _, want := range []string{
+		"quickcmd_agent_jobs_total 3\n",
+		"quickcmd_agent_jobs_running 0\n",
+		"quickcmd_agent_jobs_queued 0\n",
+		"quickcmd_agent_jobs_completed 2\n",
+		"quickcmd_agent_jobs_failed 1\n",
+		"# TYPE quickcmd_agent_job_duration_seconds histogram\n",
+		`quickcmd_agent_job_duration_seconds_bucket{status="completed",le="1"} 0` + "\n",
+		`quickcmd_agent_job_duration_seconds_bucket{status="completed",le="5"} 2` + "\n",
+		`quickcmd_agent_job_duration_seconds_bucket{status="failed",le="0.1"} 1` + "\n",
+		`quickcmd_agent_job_duration_seconds_count{status="completed"} 2` + "\n",
+		`quickcmd_agent_job_exit_codes_total{exit_code="0"} 2` + "\n",
+		`quickcmd_agent_job_exit_codes_total{exit_code="1"} 1` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q", want)
+		}
+	}
+	if t.Failed() {
+		t.Logf("metrics:\n%s", body)
+	}
+}
+
+// mustStartJob starts a job on server, failing the test if it's rejected
+func mustStartJob(t *testing.T, server *Server, payload *JobPayload) *Job {
+	t.Helper()
+	
+	job, err := server.startJob(payload)
+	if err != nil {
+		t.Fatalf("startJob(%s) error: %v", payload.JobID, err)
+	}
+	return job
+}
+
+// waitFor polls cond until it holds, failing the test after a few seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
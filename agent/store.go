@@ -0,0 +1,213 @@
+package agent
+
+import (
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+//go:embed schema.sql
+var schemaSQL string
+
+// interruptedError is the error recorded for jobs that were still pending or
+// running when the agent stopped
+const interruptedError = "interrupted: the agent restarted before the job finished"
+
+// JobStore persists jobs, so their status and results survive an agent
+// restart
+type JobStore struct {
+	db *sql.DB
+}
+
+// NewJobStore opens the job database at dbPath, creating it if needed
+func NewJobStore(dbPath string) (*JobStore, error) {
+	// Ensure directory exists
+	dir := filepath.Dir(dbPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create job store directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open job database: %w", err)
+	}
+
+	// Every connection to ":memory:" opens a separate, empty database
+	if dbPath == ":memory:" {
+		db.SetMaxOpenConns(1)
+	}
+
+	// Enable WAL mode for better concurrency
+	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to enable WAL mode: %w", err)
+	}
+
+	if _, err := db.Exec(schemaSQL); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	return &JobStore{db: db}, nil
+}
+
+// Save inserts or updates a job
+func (s *JobStore) Save(job *Job) error {
+	payload, err := json.Marshal(job.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	var result []byte
+	if job.Result != nil {
+		if result, err = json.Marshal(job.Result); err != nil {
+			return fmt.Errorf("failed to encode job result: %w", err)
+		}
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO jobs (job_id, status, payload, result, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(job_id) DO UPDATE SET
+			status = excluded.status,
+			result = excluded.result,
+			updated_at = excluded.updated_at
+	`, job.Payload.JobID, string(job.Status), string(payload), nullableString(result), job.CreatedAt, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save job: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the job with the given ID, or nil if there is none
+func (s *JobStore) Get(jobID string) (*Job, error) {
+	rows, err := s.db.Query(`
+		SELECT status, payload, result, created_at
+		FROM jobs
+		WHERE job_id = ?
+	`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	jobs, err := scanJobs(rows)
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return jobs[0], nil
+}
+
+// ListJobs returns the jobs with the given status, or all jobs if status is
+// empty, oldest first
+func (s *JobStore) ListJobs(status JobStatus) ([]*Job, error) {
+	query := `
+		SELECT status, payload, result, created_at
+		FROM jobs
+	`
+	args := []interface{}{}
+
+	if status != "" {
+		query += " WHERE status = ?"
+		args = append(args, string(status))
+	}
+	query += " ORDER BY created_at"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	return scanJobs(rows)
+}
+
+// MarkInterrupted fails every pending or running job. Called on startup,
+// these are jobs whose execution ended with the previous agent process.
+// It returns the number of jobs marked.
+func (s *JobStore) MarkInterrupted() (int, error) {
+	marked := 0
+	for _, status := range []JobStatus{JobStatusPending, JobStatusRunning} {
+		jobs, err := s.ListJobs(status)
+		if err != nil {
+			return marked, err
+		}
+
+		for _, job := range jobs {
+			now := time.Now()
+			if job.Result == nil {
+				job.Result = &JobResult{JobID: job.Payload.JobID, StartTime: job.CreatedAt}
+			}
+			job.Status = JobStatusFailed
+			job.Result.Status = JobStatusFailed
+			job.Result.Error = interruptedError
+			job.Result.EndTime = now
+
+			if err := s.Save(job); err != nil {
+				return marked, err
+			}
+			marked++
+		}
+	}
+
+	return marked, nil
+}
+
+// Close closes the database connection
+func (s *JobStore) Close() error {
+	if s.db != nil {
+		return s.db.Close()
+	}
+	return nil
+}
+
+// scanJobs reads jobs from rows of status, payload, result and created_at,
+// closing rows
+func scanJobs(rows *sql.Rows) ([]*Job, error) {
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		var (
+			status  string
+			payload string
+			result  sql.NullString
+			job     = &Job{}
+		)
+		if err := rows.Scan(&status, &payload, &result, &job.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+
+		job.Status = JobStatus(status)
+		job.Payload = &JobPayload{}
+		if err := json.Unmarshal([]byte(payload), job.Payload); err != nil {
+			return nil, fmt.Errorf("failed to decode job payload: %w", err)
+		}
+		if result.Valid {
+			job.Result = &JobResult{}
+			if err := json.Unmarshal([]byte(result.String), job.Result); err != nil {
+				return nil, fmt.Errorf("failed to decode job result: %w", err)
+			}
+		}
+
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// nullableString converts b to a string, or NULL if b is nil
+func nullableString(b []byte) interface{} {
+	if b == nil {
+		return nil
+	}
+	return string(b)
+}
@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newStoreServer returns a server backed by the job store at dbPath
+func newStoreServer(t *testing.T, dbPath string) *Server {
+	t.Helper()
+
+	store, err := NewJobStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewJobStore() error: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	server := &Server{jobs: make(map[string]*Job), store: store}
+	if err := server.restoreJobs(); err != nil {
+		t.Fatalf("restoreJobs() error: %v", err)
+	}
+	return server
+}
+
+func TestServer_JobsSurviveRestart(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "jobs.db")
+
+	before := newStoreServer(t, dbPath)
+	before.saveJob(&Job{
+		Payload:   &JobPayload{JobID: "job-done", Command: "ls -la"},
+		Status:    JobStatusCompleted,
+		Result:    &JobResult{JobID: "job-done", Status: JobStatusCompleted, Stdout: "total 0"},
+		CreatedAt: time.Now().Add(-time.Minute),
+	})
+	before.saveJob(&Job{
+		Payload:   &JobPayload{JobID: "job-running", Command: "sleep 600"},
+		Status:    JobStatusRunning,
+		CreatedAt: time.Now(),
+	})
+	before.store.Close()
+
+	// Simulated restart: a new server on the same database
+	after := newStoreServer(t, dbPath)
+
+	status := func(jobID string) (int, map[string]json.RawMessage) {
+		rec := httptest.NewRecorder()
+		after.handleJobStatus(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+jobID, nil))
+		var body map[string]json.RawMessage
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body
+	}
+
+	code, body := status("job-done")
+	if code != http.StatusOK {
+		t.Fatalf("completed job status code = %d, want %d", code, http.StatusOK)
+	}
+	var result JobResult
+	if err := json.Unmarshal(body["result"], &result); err != nil || result.Stdout != "total 0" {
+		t.Errorf("completed job result = %s (%v)", body["result"], err)
+	}
+	if string(body["status"]) != `"completed"` {
+		t.Errorf("completed job status = %s", body["status"])
+	}
+
+	code, body = status("job-running")
+	if code != http.StatusOK {
+		t.Fatalf("interrupted job status code = %d, want %d", code, http.StatusOK)
+	}
+	result = JobResult{}
+	json.Unmarshal(body["result"], &result)
+	if string(body["status"]) != `"failed"` || result.Error != interruptedError {
+		t.Errorf("interrupted job = %s, result %+v", body["status"], result)
+	}
+
+	if code, _ := status("job-unknown"); code != http.StatusNotFound {
+		t.Errorf("unknown job status code = %d, want %d", code, http.StatusNotFound)
+	}
+
+	// Logs of restored jobs were never persisted
+	rec := httptest.NewRecorder()
+	after.handleLogStream(rec, httptest.NewRequest(http.MethodGet, "/api/v1/stream/job-done", nil))
+	if rec.Code != http.StatusGone {
+		t.Errorf("log stream status code = %d, want %d", rec.Code, http.StatusGone)
+	}
+
+	failed, err := after.store.ListJobs(JobStatusFailed)
+	if err != nil || len(failed) != 1 || failed[0].Payload.JobID != "job-running" {
+		t.Errorf("ListJobs(failed) = %v, %v", failed, err)
+	}
+	all, err := after.store.ListJobs("")
+	if err != nil || len(all) != 2 || all[0].Payload.JobID != "job-done" {
+		t.Errorf("ListJobs(\"\") = %v, %v", all, err)
+	}
+}
@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// LoadCertPool reads PEM-encoded CA certificates from path
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", path)
+	}
+	return pool, nil
+}
+
+// tlsConfig returns the server's TLS settings. With a client CA configured,
+// controllers must present a certificate signed by it (mutual TLS).
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		},
+	}
+
+	if s.config.ClientCAFile != "" {
+		pool, err := LoadCertPool(s.config.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client CA: %w", err)
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.ClientCAs = pool
+	}
+
+	return config, nil
+}
+
+// certMatchesController reports whether the verified client certificate of
+// state names controllerID as its common name or a DNS or URI SAN
+func certMatchesController(state *tls.ConnectionState, controllerID string) bool {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return false
+	}
+
+	cert := state.PeerCertificates[0]
+	if cert.Subject.CommonName == controllerID {
+		return true
+	}
+	for _, name := range cert.DNSNames {
+		if name == controllerID {
+			return true
+		}
+	}
+	for _, uri := range cert.URIs {
+		if uri.String() == controllerID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA is a self-signed CA for issuing client certificates in tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a client certificate for commonName signed by the CA
+func (ca *testCA) issue(t *testing.T, commonName string) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error: %v", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestHandleSubmitJob_MutualTLS(t *testing.T) {
+	ca := newTestCA(t, "QuickCMD Test CA")
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, ca.pem, 0600); err != nil {
+		t.Fatalf("Failed to write CA: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.HMACSecret = "secret"
+	config.AllowedControllers = []string{"controller-1", "controller-2"}
+	config.ClientCAFile = caFile
+	server := &Server{
+		config: config,
+		jobs:   make(map[string]*Job),
+		slots:  make(chan struct{}, maxConcurrentJobs(config)),
+		runJob: func(ctx context.Context, payload *JobPayload, logChan chan<- *LogFrame) (*JobResult, error) {
+			return &JobResult{JobID: payload.JobID}, nil
+		},
+	}
+	server.metrics = newAgentMetrics(server)
+
+	tlsConfig, err := server.tlsConfig()
+	if err != nil {
+		t.Fatalf("tlsConfig() error: %v", err)
+	}
+	if tlsConfig.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("ClientAuth = %v, want RequireAndVerifyClientCert", tlsConfig.ClientAuth)
+	}
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(server.handleSubmitJob))
+	ts.TLS = tlsConfig
+	ts.StartTLS()
+	defer ts.Close()
+
+	submit := func(cert *tls.Certificate, controllerID string) (int, error) {
+		clientTLS := &tls.Config{InsecureSkipVerify: true}
+		if cert != nil {
+			clientTLS.Certificates = []tls.Certificate{*cert}
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLS}}
+
+		payload := JobPayload{
+			JobID:        "job-" + controllerID,
+			Command:      "ls",
+			TTL:          time.Now().Add(time.Minute).Unix(),
+			Timestamp:    time.Now().Unix(),
+			ControllerID: controllerID,
+		}
+		signature, _ := SignPayload(&payload, config.HMACSecret)
+		body, _ := json.Marshal(SignedJob{Payload: payload, Signature: JobSignature{Signature: signature, Algorithm: "HMAC-SHA256"}})
+
+		resp, err := client.Post(ts.URL+"/api/v1/jobs", "application/json", bytes.NewReader(body))
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	known := ca.issue(t, "controller-1")
+	if code, err := submit(&known, "controller-1"); err != nil || code != http.StatusAccepted {
+		t.Errorf("known client cert: status %d, error %v", code, err)
+	}
+
+	// A valid certificate can't submit as another controller
+	if code, err := submit(&known, "controller-2"); err != nil || code != http.StatusForbidden {
+		t.Errorf("mismatched controller: status %d, error %v, want %d", code, err, http.StatusForbidden)
+	}
+
+	// Certificates from an unknown CA, and no certificate, fail the handshake
+	unknown := newTestCA(t, "Rogue CA").issue(t, "controller-1")
+	if code, err := submit(&unknown, "controller-1"); err == nil {
+		t.Errorf("unknown client cert was accepted with status %d", code)
+	}
+	if code, err := submit(nil, "controller-1"); err == nil {
+		t.Errorf("missing client cert was accepted with status %d", code)
+	}
+}
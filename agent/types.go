@@ -5,6 +5,8 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -22,6 +24,35 @@ type JobPayload struct {
 	ControllerID   string                 `json:"controller_id"`
 }
 
+// RiskLevel returns the candidate's risk level from the candidate metadata,
+// defaulting to "medium" when the controller did not send one
+func (p *JobPayload) RiskLevel() string {
+	if level, ok := p.CandidateMetadata["risk_level"].(string); ok && level != "" {
+		return level
+	}
+	return "medium"
+}
+
+// Destructive reports whether the candidate metadata marks the command as
+// destructive
+func (p *JobPayload) Destructive() bool {
+	destructive, _ := p.CandidateMetadata["destructive"].(bool)
+	return destructive
+}
+
+// ApprovalID returns the ID of the approval the job was submitted for, or 0
+// if it wasn't submitted through the approvals workflow
+func (p *JobPayload) ApprovalID() int {
+	// JSON decodes numbers as float64
+	switch id := p.CandidateMetadata["approval_id"].(type) {
+	case float64:
+		return int(id)
+	case int:
+		return id
+	}
+	return 0
+}
+
 // JobSignature contains the HMAC signature for a job payload
 type JobSignature struct {
 	Signature string `json:"signature"`
@@ -58,6 +89,10 @@ type JobResult struct {
 	DurationMs int64     `json:"duration_ms"`
 	Error      string    `json:"error,omitempty"`
 	Snapshot   string    `json:"snapshot,omitempty"` // JSON-encoded snapshot metadata
+	
+	// Resource usage, omitted when the sandbox couldn't measure it
+	MaxMemoryBytes int64 `json:"max_memory_bytes,omitempty"`
+	CPUTimeMs      int64 `json:"cpu_time_ms,omitempty"`
 }
 
 // LogFrame represents a single log message during streaming
@@ -118,6 +153,59 @@ func ValidateTTL(payload *JobPayload) error {
 	return nil
 }
 
+// maxJobIDLength bounds job IDs, which appear in URLs and log lines
+const maxJobIDLength = 128
+
+// FieldError describes why one payload field is invalid
+type FieldError struct {
+	Field   string `json:"field"` // JSON name of the field
+	Message string `json:"message"`
+}
+
+// PayloadError lists every invalid field of a job payload
+type PayloadError struct {
+	Fields []FieldError
+}
+
+func (e *PayloadError) Error() string {
+	problems := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		problems[i] = field.Field + " " + field.Message
+	}
+	return "invalid job payload: " + strings.Join(problems, "; ")
+}
+
+// Validate checks that the payload has everything needed to run it. It
+// returns a *PayloadError listing each invalid field, or nil.
+func (p *JobPayload) Validate() error {
+	var fields []FieldError
+	invalid := func(field, message string) {
+		fields = append(fields, FieldError{Field: field, Message: message})
+	}
+	
+	switch {
+	case strings.TrimSpace(p.JobID) == "":
+		invalid("job_id", "is required")
+	case len(p.JobID) > maxJobIDLength:
+		invalid("job_id", fmt.Sprintf("must be at most %d characters", maxJobIDLength))
+	case strings.ContainsAny(p.JobID, "/?# \t\r\n"):
+		invalid("job_id", "must not contain '/', '?', '#' or whitespace")
+	}
+	
+	if strings.TrimSpace(p.Command) == "" {
+		invalid("command", "is required")
+	}
+	
+	if strings.TrimSpace(p.ControllerID) == "" {
+		invalid("controller_id", "is required")
+	}
+	
+	if len(fields) > 0 {
+		return &PayloadError{Fields: fields}
+	}
+	return nil
+}
+
 // Errors
 var (
 	ErrInvalidSignature = &AgentError{Code: "INVALID_SIGNATURE", Message: "Invalid job signature"}
@@ -135,3 +223,33 @@ type AgentError struct {
 func (e *AgentError) Error() string {
 	return e.Code + ": " + e.Message
 }
+
+// APIVersion is the version of the agent API served under /api/v1
+const APIVersion = "v1"
+
+// Capabilities describes what an agent can run, so controllers can route
+// jobs to agents that support them
+type Capabilities struct {
+	APIVersion        string             `json:"api_version"`
+	SandboxBackend    string             `json:"sandbox_backend"` // "docker" or "podman"
+	Plugins           []PluginCapability `json:"plugins"`
+	AllowedImages     []string           `json:"allowed_images"`
+	DefaultImage      string             `json:"default_image"`
+	MaxConcurrentJobs int                `json:"max_concurrent_jobs"`
+	MaxResources      ResourceLimits     `json:"max_resources"`
+}
+
+// PluginCapability is a plugin enabled on the agent
+type PluginCapability struct {
+	Name       string   `json:"name"`
+	Version    string   `json:"version,omitempty"`
+	Scopes     []string `json:"scopes,omitempty"`
+	Configured bool     `json:"configured"` // The agent config has settings for it
+}
+
+// ResourceLimits are the sandbox limits a job runs with
+type ResourceLimits struct {
+	CPULimit       float64 `json:"cpu_limit"`
+	MemoryLimit    int64   `json:"memory_limit_bytes"`
+	TimeoutSeconds int     `json:"timeout_seconds"`
+}
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -160,3 +161,92 @@ func TestJobPayloadSerialization(t *testing.T) {
 		t.Errorf("ValidateTTL() error = %v", err)
 	}
 }
+
+func TestJobPayloadRiskLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		want     string
+	}{
+		{"from candidate metadata", map[string]interface{}{"risk_level": "high"}, "high"},
+		{"missing metadata", nil, "medium"},
+		{"wrong type", map[string]interface{}{"risk_level": 3}, "medium"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := &JobPayload{CandidateMetadata: tt.metadata}
+			if got := payload.RiskLevel(); got != tt.want {
+				t.Errorf("RiskLevel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobPayloadApprovalID(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		want     int
+	}{
+		{"decoded from JSON", map[string]interface{}{"approval_id": float64(7)}, 7},
+		{"set by the controller", map[string]interface{}{"approval_id": 7}, 7},
+		{"missing metadata", nil, 0},
+		{"wrong type", map[string]interface{}{"approval_id": "7"}, 0},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := &JobPayload{CandidateMetadata: tt.metadata}
+			if got := payload.ApprovalID(); got != tt.want {
+				t.Errorf("ApprovalID() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobPayloadValidate(t *testing.T) {
+	valid := func() *JobPayload {
+		return &JobPayload{JobID: "job-1", Command: "ls -la", ControllerID: "controller-a"}
+	}
+	
+	tests := []struct {
+		name   string
+		modify func(p *JobPayload)
+		fields []string
+	}{
+		{"valid", func(p *JobPayload) {}, nil},
+		{"missing command", func(p *JobPayload) { p.Command = "  " }, []string{"command"}},
+		{"missing job ID", func(p *JobPayload) { p.JobID = "" }, []string{"job_id"}},
+		{"job ID with slash", func(p *JobPayload) { p.JobID = "../job" }, []string{"job_id"}},
+		{"missing controller", func(p *JobPayload) { p.ControllerID = "" }, []string{"controller_id"}},
+		{"everything missing", func(p *JobPayload) { *p = JobPayload{} }, []string{"job_id", "command", "controller_id"}},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := valid()
+			tt.modify(payload)
+			
+			err := payload.Validate()
+			if tt.fields == nil {
+				if err != nil {
+					t.Errorf("Validate() error: %v", err)
+				}
+				return
+			}
+			
+			payloadErr, ok := err.(*PayloadError)
+			if !ok {
+				t.Fatalf("Validate() = %v, want *PayloadError", err)
+			}
+			var fields []string
+			for _, field := range payloadErr.Fields {
+				fields = append(fields, field.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("Validate() invalid fields = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 	
 	"github.com/SagheerAkram/QuickCmd/agent"
 )
@@ -0,0 +1,10 @@
+package main
+
+import (
+	// Built-in plugins register themselves on import, so the agent can
+	// advertise them from /api/v1/capabilities
+	_ "github.com/SagheerAkram/QuickCmd/plugins/aws"
+	_ "github.com/SagheerAkram/QuickCmd/plugins/git"
+	_ "github.com/SagheerAkram/QuickCmd/plugins/k8s"
+	_ "github.com/SagheerAkram/QuickCmd/plugins/terraform"
+)
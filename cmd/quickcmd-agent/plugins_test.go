@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
+)
+
+func TestBuiltinPluginsRegistered(t *testing.T) {
+	// handleCapabilities lists the default registry, so the agent binary
+	// must register the built-ins itself
+	enabled := map[string]bool{}
+	for _, plugin := range plugins.DefaultRegistry().ListEnabled() {
+		enabled[plugin.Name()] = true
+	}
+
+	for _, name := range []string{"aws", "git", "k8s", "terraform"} {
+		if !enabled[name] {
+			t.Errorf("plugin %q is not registered in the agent binary", name)
+		}
+	}
+}
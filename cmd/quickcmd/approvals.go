@@ -0,0 +1,394 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+	"github.com/SagheerAkram/QuickCmd/integrations"
+	"github.com/SagheerAkram/QuickCmd/web"
+	"github.com/spf13/cobra"
+)
+
+var approvalsCmd = &cobra.Command{
+	Use:   "approvals",
+	Short: "Review pending approvals",
+	Long: `List, inspect, approve, and reject approval requests from the terminal.
+
+Approvals are shared with the web UI through the approval database.`,
+}
+
+var approvalsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List pending approvals",
+	RunE:  listApprovals,
+}
+
+var approvalsShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show details of an approval",
+	Args:  cobra.ExactArgs(1),
+	RunE:  showApproval,
+}
+
+var approvalsApproveCmd = &cobra.Command{
+	Use:   "approve <id>",
+	Short: "Approve a pending approval",
+	Long: `Approves a pending approval as the current user. You must type the
+confirmation phrase for the approval's risk level (APPROVE <id>, or
+APPROVE CRITICAL <id> for critical approvals by default), or pass it with
+--confirm. The requester is notified if notifications are configured.`,
+	Args: cobra.ExactArgs(1),
+	RunE: approveApproval,
+}
+
+var approvalsRejectCmd = &cobra.Command{
+	Use:   "reject <id>",
+	Short: "Reject a pending approval",
+	Long: `Rejects a pending approval as the current user. The requester is notified
+if notifications are configured.`,
+	Args: cobra.ExactArgs(1),
+	RunE: rejectApproval,
+}
+
+func init() {
+	rootCmd.AddCommand(approvalsCmd)
+	approvalsCmd.AddCommand(approvalsListCmd)
+	approvalsCmd.AddCommand(approvalsShowCmd)
+	approvalsCmd.AddCommand(approvalsApproveCmd)
+	approvalsCmd.AddCommand(approvalsRejectCmd)
+
+	approvalsApproveCmd.Flags().String("confirm", "", "confirmation phrase (e.g. APPROVE <id>)")
+	approvalsApproveCmd.Flags().String("note", "", "note recorded with the approval")
+	approvalsRejectCmd.Flags().String("reason", "", "reason for the rejection (required)")
+}
+
+func listApprovals(cmd *cobra.Command, args []string) error {
+	store, err := openApprovalStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	approvals, err := store.GetPendingApprovals()
+	if err != nil {
+		return fmt.Errorf("failed to get approvals: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if len(approvals) == 0 {
+		fmt.Fprintln(out, "No pending approvals.")
+		return nil
+	}
+
+	fmt.Fprintf(out, "%sPending Approvals%s\n\n", colorBold, colorReset)
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "ID\tRISK\tREQUESTED BY\tAGE\tCOMMAND")
+	fmt.Fprintln(w, "--\t----\t------------\t---\t-------")
+	for _, approval := range approvals {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+			approval.ID,
+			approval.RiskLevel,
+			approval.RequestedBy,
+			time.Since(approval.RequestedAt).Round(time.Minute),
+			approval.Command)
+	}
+
+	return w.Flush()
+}
+
+func showApproval(cmd *cobra.Command, args []string) error {
+	id, err := parseApprovalID(args[0])
+	if err != nil {
+		return err
+	}
+
+	store, err := openApprovalStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	approval, err := getApproval(store, id)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "%sApproval #%d%s\n\n", colorBold, approval.ID, colorReset)
+	fmt.Fprintf(out, "Status:       %s\n", approval.Status)
+	fmt.Fprintf(out, "Risk:         %s\n", approval.RiskLevel)
+	fmt.Fprintf(out, "Prompt:       %s\n", approval.Prompt)
+	fmt.Fprintf(out, "Command:      %s%s%s\n", colorCyan, approval.Command, colorReset)
+	fmt.Fprintf(out, "Requested by: %s at %s\n", approval.RequestedBy, approval.RequestedAt.Format("2006-01-02 15:04:05"))
+	if len(approval.RequiredScopes) > 0 {
+		fmt.Fprintf(out, "Scopes:       %s\n", strings.Join(approval.RequiredScopes, ", "))
+	}
+
+	switch approval.Status {
+	case web.ApprovalStatusApproved:
+		if approval.AutoApproved {
+			fmt.Fprintln(out, "Approved by:  auto-approval rule (no human review)")
+		} else {
+			fmt.Fprintf(out, "Approved by:  %s\n", approval.ApprovedBy)
+		}
+		if approval.ApprovalNote != "" {
+			fmt.Fprintf(out, "Note:         %s\n", approval.ApprovalNote)
+		}
+	case web.ApprovalStatusRejected:
+		fmt.Fprintf(out, "Rejected by:  %s\n", approval.RejectedBy)
+		fmt.Fprintf(out, "Reason:       %s\n", approval.RejectionReason)
+	}
+
+	return nil
+}
+
+func approveApproval(cmd *cobra.Command, args []string) error {
+	id, err := parseApprovalID(args[0])
+	if err != nil {
+		return err
+	}
+	confirmation, _ := cmd.Flags().GetString("confirm")
+	note, _ := cmd.Flags().GetString("note")
+
+	store, err := openApprovalStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	approval, err := getApproval(store, id)
+	if err != nil {
+		return err
+	}
+	if approval.Status != web.ApprovalStatusPending {
+		return fmt.Errorf("approval %d is already %s", id, approval.Status)
+	}
+	approver := currentUserID()
+	if approval.RequestedBy == approver {
+		return fmt.Errorf("approval %d was requested by %s, who can't approve it; ask another approver", id, approver)
+	}
+
+	out := cmd.OutOrStdout()
+	expected := confirmationPhrases().Phrase(approval.RiskLevel, id)
+	if confirmation == "" {
+		fmt.Fprintf(out, "Command: %s%s%s\n", colorCyan, approval.Command, colorReset)
+		fmt.Fprintf(out, "\n%sType %s to confirm%s\n", colorYellow, expected, colorReset)
+		fmt.Fprint(out, "Confirmation: ")
+
+		input, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		confirmation = strings.TrimSpace(input)
+	}
+	if confirmation != expected {
+		return fmt.Errorf("invalid confirmation, type: %s", expected)
+	}
+
+	if err := store.ApproveApproval(id, approver, confirmation, note); err != nil {
+		return fmt.Errorf("failed to approve: %w", err)
+	}
+
+	fmt.Fprintf(out, "%s✓ Approval %d granted by %s%s\n", colorGreen, id, approver, colorReset)
+	notifyRequester(out, store, id)
+	return nil
+}
+
+func rejectApproval(cmd *cobra.Command, args []string) error {
+	id, err := parseApprovalID(args[0])
+	if err != nil {
+		return err
+	}
+	reason, _ := cmd.Flags().GetString("reason")
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("rejection reason required (--reason)")
+	}
+
+	store, err := openApprovalStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if _, err := getApproval(store, id); err != nil {
+		return err
+	}
+
+	approver := currentUserID()
+	if err := store.RejectApproval(id, approver, reason); err != nil {
+		return fmt.Errorf("failed to reject: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "%s✗ Approval %d rejected by %s%s\n", colorRed, id, approver, colorReset)
+	notifyRequester(out, store, id)
+	return nil
+}
+
+// approvalNotifier returns the configured notifier for approval outcomes,
+// or nil if none is configured
+var approvalNotifier = func() web.Notifier {
+	if cfg == nil || cfg.Notifications.SlackWebhookURL == "" {
+		return nil
+	}
+	return integrations.NewSlackIntegration(cfg.Notifications.SlackWebhookURL, cfg.Notifications.SlackBotToken)
+}
+
+// notifyRequester tells the requester of approval id how it was decided.
+// The decision is already recorded, so failures are only warned about on w.
+func notifyRequester(w io.Writer, store *web.ApprovalStore, id int) {
+	notifier := approvalNotifier()
+	if notifier == nil {
+		return
+	}
+
+	approval, err := getApproval(store, id)
+	if err == nil {
+		err = web.NotifyRequester(notifier, approval, "")
+	}
+	if err != nil {
+		fmt.Fprintf(w, colorYellow+"%s  Failed to notify the requester: %v\n"+colorReset, translator.Symbol(translator.IconWarning), err)
+	}
+}
+
+// obtainApproval makes sure an approver has granted candidate before it
+// runs. It uses up an approval granted earlier; otherwise it requests one and
+// returns an error asking the user to run the command again once granted.
+func obtainApproval(w io.Writer, prompt string, candidate *translator.Candidate, reason string) error {
+	store, err := openApprovalStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	user := currentUserID()
+	approval, err := store.ConsumeApproval(candidate.Command, user)
+	if err != nil {
+		return fmt.Errorf("failed to check approvals: %w", err)
+	}
+
+	if approval == nil {
+		pending, err := store.FindPendingApproval(candidate.Command, user)
+		if err != nil {
+			return fmt.Errorf("failed to check approvals: %w", err)
+		}
+		if pending != nil {
+			return fmt.Errorf("%s: approval %d is still pending", reason, pending.ID)
+		}
+
+		id, err := store.CreateApproval(&web.Approval{
+			Prompt:      prompt,
+			Command:     candidate.Command,
+			RiskLevel:   string(candidate.RiskLevel),
+			RequestedBy: user,
+			RequestedAt: time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to request approval: %w", err)
+		}
+
+		// An auto-approval rule may have granted it straight away
+		approval, err = store.ConsumeApproval(candidate.Command, user)
+		if err != nil {
+			return fmt.Errorf("failed to check approvals: %w", err)
+		}
+		if approval == nil {
+			return fmt.Errorf("%s: requested approval %d; once an approver runs `quickcmd approvals approve %d`, run the command again", reason, id, id)
+		}
+	}
+
+	grantedBy := approval.ApprovedBy
+	if approval.AutoApproved {
+		grantedBy = "auto-approval rule"
+	}
+	fmt.Fprintf(w, "%s Using approval %d granted by %s\n", translator.Symbol(translator.IconCheck), approval.ID, grantedBy)
+	return nil
+}
+
+// openApprovalStore opens the approval database, creating its directory if
+// needed
+func openApprovalStore() (*web.ApprovalStore, error) {
+	dbPath := getApprovalDBPath()
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create approval directory: %w", err)
+	}
+
+	store, err := web.NewApprovalStore(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open approval database: %w", err)
+	}
+	if err := store.SetAutoApprovalRules(autoApprovalRules()); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("invalid auto-approval rules: %w", err)
+	}
+	return store, nil
+}
+
+// confirmationPhrases returns the configured confirmation phrases merged over
+// the web UI's defaults
+func confirmationPhrases() web.ConfirmationPhrases {
+	if cfg == nil {
+		return web.DefaultConfirmationPhrases()
+	}
+	return web.ConfirmationPhrases(cfg.ConfirmationPhrases).WithDefaults()
+}
+
+// autoApprovalRules returns the configured auto-approval rules
+func autoApprovalRules() []web.AutoApprovalRule {
+	if cfg == nil {
+		return nil
+	}
+
+	var rules []web.AutoApprovalRule
+	for _, rule := range cfg.AutoApprovalRules {
+		rules = append(rules, web.AutoApprovalRule{
+			Name:             rule.Name,
+			RiskLevels:       rule.RiskLevels,
+			MaxCost:          rule.MaxCost,
+			AllowUnestimated: rule.AllowUnestimated,
+			AllowPatterns:    rule.AllowPatterns,
+		})
+	}
+	return rules
+}
+
+// getApproval fetches an approval, reporting a missing one by id
+func getApproval(store *web.ApprovalStore, id int) (*web.Approval, error) {
+	approval, err := store.GetApproval(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("approval %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval: %w", err)
+	}
+	return approval, nil
+}
+
+func parseApprovalID(arg string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid approval ID: %q", arg)
+	}
+	return id, nil
+}
+
+// getApprovalDBPath returns the path to the approval database
+func getApprovalDBPath() string {
+	if cfg != nil && cfg.ApprovalDBPath != "" {
+		return cfg.ApprovalDBPath
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "quickcmd", "approvals.db")
+	}
+	return filepath.Join(homeDir, ".quickcmd", "approvals.db")
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SagheerAkram/QuickCmd/core/config"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+	"github.com/SagheerAkram/QuickCmd/web"
+	"github.com/spf13/cobra"
+)
+
+// setupApprovals points the CLI at a temp approval DB seeded with two
+// pending approvals and returns their IDs
+func setupApprovals(t *testing.T) (int, int) {
+	t.Helper()
+
+	cfg = config.DefaultConfig()
+	cfg.ApprovalDBPath = filepath.Join(t.TempDir(), "approvals.db")
+	t.Cleanup(func() { cfg = nil })
+
+	store, err := web.NewApprovalStore(cfg.ApprovalDBPath)
+	if err != nil {
+		t.Fatalf("NewApprovalStore() error: %v", err)
+	}
+	defer store.Close()
+
+	var ids []int
+	for _, command := range []string{"kubectl delete pod api-0", "terraform apply"} {
+		id, err := store.CreateApproval(&web.Approval{
+			Prompt:      "run " + command,
+			Command:     command,
+			RiskLevel:   "high",
+			RequestedBy: "alice",
+			RequestedAt: time.Now().Add(-time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("CreateApproval() error: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids[0], ids[1]
+}
+
+// runApprovals executes an approvals subcommand and returns its output
+func runApprovals(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{Use: "quickcmd"}
+	approvals := &cobra.Command{Use: "approvals"}
+	cmd.AddCommand(approvals)
+
+	list := &cobra.Command{Use: "list", RunE: listApprovals}
+	show := &cobra.Command{Use: "show", Args: cobra.ExactArgs(1), RunE: showApproval}
+	approve := &cobra.Command{Use: "approve", Args: cobra.ExactArgs(1), RunE: approveApproval}
+	approve.Flags().String("confirm", "", "")
+	approve.Flags().String("note", "", "")
+	reject := &cobra.Command{Use: "reject", Args: cobra.ExactArgs(1), RunE: rejectApproval}
+	reject.Flags().String("reason", "", "")
+	approvals.AddCommand(list, show, approve, reject)
+
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetArgs(append([]string{"approvals"}, args...))
+
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestApprovals_List(t *testing.T) {
+	first, second := setupApprovals(t)
+
+	out, err := runApprovals(t, "", "list")
+	if err != nil {
+		t.Fatalf("approvals list error: %v", err)
+	}
+	for _, want := range []string{"kubectl delete pod api-0", "terraform apply", "alice", fmt.Sprint(first), fmt.Sprint(second)} {
+		if !strings.Contains(out, want) {
+			t.Errorf("approvals list output missing %q:\n%s", want, out)
+		}
+	}
+
+	out, err = runApprovals(t, "", "show", fmt.Sprint(second))
+	if err != nil {
+		t.Fatalf("approvals show error: %v", err)
+	}
+	if !strings.Contains(out, "terraform apply") || !strings.Contains(out, "pending") {
+		t.Errorf("approvals show output:\n%s", out)
+	}
+
+	if _, err := runApprovals(t, "", "show", "999"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("show of missing approval error = %v, want not found", err)
+	}
+}
+
+// fakeNotifier records the messages sent to each recipient
+type fakeNotifier map[string][]string
+
+func (n fakeNotifier) SendMessage(recipient, text string) error {
+	n[recipient] = append(n[recipient], text)
+	return nil
+}
+
+func TestApprovals_ApproveAndReject(t *testing.T) {
+	first, second := setupApprovals(t)
+
+	sent := fakeNotifier{}
+	defaultNotifier := approvalNotifier
+	approvalNotifier = func() web.Notifier { return sent }
+	t.Cleanup(func() { approvalNotifier = defaultNotifier })
+	me := currentUserID()
+
+	// Wrong confirmation leaves the approval pending
+	if _, err := runApprovals(t, "APPROVE\n", "approve", fmt.Sprint(first)); err == nil {
+		t.Error("approve with wrong confirmation succeeded")
+	}
+
+	// Confirmation typed at the prompt
+	out, err := runApprovals(t, web.DefaultConfirmationPhrases().Phrase("high", first)+"\n", "approve", fmt.Sprint(first), "--note", "ok")
+	if err != nil {
+		t.Fatalf("approve error: %v", err)
+	}
+	if !strings.Contains(out, "granted by "+me) {
+		t.Errorf("approve output:\n%s", out)
+	}
+
+	// Already processed
+	if _, err := runApprovals(t, "", "approve", fmt.Sprint(first), "--confirm", web.DefaultConfirmationPhrases().Phrase("high", first)); err == nil {
+		t.Error("approving a processed approval succeeded")
+	}
+
+	if _, err := runApprovals(t, "", "reject", fmt.Sprint(second)); err == nil {
+		t.Error("reject without a reason succeeded")
+	}
+	if _, err := runApprovals(t, "", "reject", fmt.Sprint(second), "--reason", "outside change window"); err != nil {
+		t.Fatalf("reject error: %v", err)
+	}
+
+	store, err := web.NewApprovalStore(cfg.ApprovalDBPath)
+	if err != nil {
+		t.Fatalf("NewApprovalStore() error: %v", err)
+	}
+	defer store.Close()
+
+	approved, err := store.GetApproval(first)
+	if err != nil {
+		t.Fatalf("GetApproval() error: %v", err)
+	}
+	if approved.Status != web.ApprovalStatusApproved || approved.ApprovedBy != me || approved.ApprovalNote != "ok" {
+		t.Errorf("approved record = %+v", approved)
+	}
+
+	rejected, err := store.GetApproval(second)
+	if err != nil {
+		t.Fatalf("GetApproval() error: %v", err)
+	}
+	if rejected.Status != web.ApprovalStatusRejected || rejected.RejectedBy != me {
+		t.Errorf("rejected record = %+v", rejected)
+	}
+
+	// The requester hears about both decisions
+	if messages := sent["alice"]; len(messages) != 2 ||
+		!strings.Contains(messages[0], "approved by "+me) || !strings.Contains(messages[1], "outside change window") {
+		t.Errorf("notifications to alice = %q", messages)
+	}
+
+	out, err = runApprovals(t, "", "list")
+	if err != nil {
+		t.Fatalf("approvals list error: %v", err)
+	}
+	if !strings.Contains(out, "No pending approvals") {
+		t.Errorf("approvals list after decisions:\n%s", out)
+	}
+}
+
+func TestApprovals_ApproveOwnRequest(t *testing.T) {
+	setupApprovals(t)
+
+	store, err := web.NewApprovalStore(cfg.ApprovalDBPath)
+	if err != nil {
+		t.Fatalf("NewApprovalStore() error: %v", err)
+	}
+	defer store.Close()
+
+	id, err := store.CreateApproval(&web.Approval{
+		Command:     "terraform destroy",
+		RiskLevel:   "high",
+		RequestedBy: currentUserID(),
+		RequestedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("CreateApproval() error: %v", err)
+	}
+
+	_, err = runApprovals(t, "", "approve", fmt.Sprint(id), "--confirm", web.DefaultConfirmationPhrases().Phrase("high", id))
+	if err == nil || !strings.Contains(err.Error(), "can't approve it") {
+		t.Fatalf("approving own request should fail, got %v", err)
+	}
+
+	approval, err := store.GetApproval(id)
+	if err != nil {
+		t.Fatalf("GetApproval() error: %v", err)
+	}
+	if approval.Status != web.ApprovalStatusPending {
+		t.Errorf("status = %s, want pending", approval.Status)
+	}
+}
+
+func TestConfirmationPhrases_MergesDefaults(t *testing.T) {
+	cfg = config.DefaultConfig()
+	cfg.ConfirmationPhrases = map[string]string{"high": "I APPROVE RUN {id}"}
+	t.Cleanup(func() { cfg = nil })
+
+	phrases := confirmationPhrases()
+	if got := phrases.Phrase("high", 7); got != "I APPROVE RUN 7" {
+		t.Errorf("Phrase(high) = %q, want the configured phrase", got)
+	}
+	if got, want := phrases.Phrase("critical", 7), web.DefaultConfirmationPhrases().Phrase("critical", 7); got != want {
+		t.Errorf("Phrase(critical) = %q, want default %q", got, want)
+	}
+}
+
+func TestObtainApproval(t *testing.T) {
+	setupApprovals(t)
+	candidate := &translator.Candidate{Command: "terraform destroy", RiskLevel: translator.RiskHigh}
+	reason := "The policy webhook requires approval"
+
+	var out bytes.Buffer
+	err := obtainApproval(&out, "destroy staging", candidate, reason)
+	if err == nil || !strings.Contains(err.Error(), "requested approval 3") {
+		t.Fatalf("first run should request approval 3, got %v", err)
+	}
+
+	err = obtainApproval(&out, "destroy staging", candidate, reason)
+	if err == nil || !strings.Contains(err.Error(), "approval 3 is still pending") {
+		t.Fatalf("second run should wait for approval 3, got %v", err)
+	}
+
+	store, err := openApprovalStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := store.ApproveApproval(3, "bob", "APPROVE 3", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := obtainApproval(&out, "destroy staging", candidate, reason); err != nil {
+		t.Fatalf("approved run failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Using approval 3 granted by bob") {
+		t.Errorf("output should name the approval, got %q", out.String())
+	}
+
+	// The approval was used up, so the next run needs a new one
+	err = obtainApproval(&out, "destroy staging", candidate, reason)
+	if err == nil || !strings.Contains(err.Error(), "requested approval 4") {
+		t.Errorf("approval should be single-use, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os/user"
+	"path/filepath"
+
+	"github.com/SagheerAkram/QuickCmd/core/policy"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+)
+
+// breakerUser returns the user destructive runs are counted against
+func breakerUser() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return "unknown"
+}
+
+// openCircuitBreaker opens the destructive command circuit breaker shared
+// by all invocations of the current user
+func openCircuitBreaker() (*policy.CircuitBreaker, error) {
+	path := storagePath(filepath.Join(quickcmdDir(), "breaker.json"))
+	return policy.NewCircuitBreaker(path, breakerConfig())
+}
+
+// checkCircuitBreaker returns an error if a destructive run must not go
+// ahead because the breaker is open. While it is open, runs need typed
+// confirmation; with --yes or --json they are refused.
+func checkCircuitBreaker(breaker *policy.CircuitBreaker) error {
+	err := breaker.Check(breakerUser())
+	if err == nil {
+		return nil
+	}
+
+	if yes || jsonOutput {
+		return fmt.Errorf("%w (wait for the cool-down, or rerun without --yes to confirm)", err)
+	}
+	if !promptConfirmation(fmt.Sprintf("%s. Type 'I UNDERSTAND' to run another destructive command:", err)) {
+		return fmt.Errorf("destructive command not confirmed: %w", err)
+	}
+	return nil
+}
+
+// recordDestructiveRun counts a destructive execution, warning if it trips
+// the circuit breaker
+func recordDestructiveRun(breaker *policy.CircuitBreaker) {
+	tripped, err := breaker.Record(breakerUser())
+	if err != nil {
+		fmt.Fprintf(humanOut, colorYellow+"%s  Failed to record destructive run: %v\n"+colorReset, translator.Symbol(translator.IconWarning), err)
+		return
+	}
+	if tripped {
+		fmt.Fprintf(humanOut, colorYellow+"%s  Too many destructive commands in a short time: further ones need confirmation for the next %v\n"+colorReset,
+			translator.Symbol(translator.IconWarning), breakerConfig().Cooldown)
+	}
+}
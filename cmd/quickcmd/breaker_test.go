@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/SagheerAkram/QuickCmd/core/config"
+	"github.com/SagheerAkram/QuickCmd/core/policy"
+)
+
+func TestCheckCircuitBreaker_RefusedWithYes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg = config.DefaultConfig()
+	cfg.CircuitBreaker.Threshold = 2
+	oldYes := yes
+	yes = true
+	defer func() { cfg, yes = nil, oldYes }()
+
+	breaker, err := openCircuitBreaker()
+	if err != nil {
+		t.Fatalf("openCircuitBreaker() error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := checkCircuitBreaker(breaker); err != nil {
+			t.Fatalf("run %d: checkCircuitBreaker() error: %v", i+1, err)
+		}
+		recordDestructiveRun(breaker)
+	}
+
+	// A later invocation sees the open breaker and refuses to run unattended
+	reopened, err := openCircuitBreaker()
+	if err != nil {
+		t.Fatalf("openCircuitBreaker() error: %v", err)
+	}
+	if err := checkCircuitBreaker(reopened); !errors.Is(err, policy.ErrBreakerOpen) {
+		t.Errorf("checkCircuitBreaker() = %v, want ErrBreakerOpen", err)
+	}
+}
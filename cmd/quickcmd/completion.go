@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/SagheerAkram/QuickCmd/core/audit"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+	"github.com/spf13/cobra"
+)
+
+// completionHistoryLimit is how many recent runs are scanned for prompts
+const completionHistoryLimit = 50
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate shell completion scripts",
+	Long: `Generates a tab-completion script for the given shell.
+
+Prompts complete from your recent history and the built-in template
+categories.
+
+  # Bash (current session)
+  source <(quickcmd completion bash)
+
+  # Zsh
+  quickcmd completion zsh > "${fpath[1]}/_quickcmd"
+
+  # Fish
+  quickcmd completion fish > ~/.config/fish/completions/quickcmd.fish`,
+	DisableFlagsInUseLine: true,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			return rootCmd.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			return rootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+		return fmt.Errorf("unsupported shell: %s", args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+
+	rootCmd.ValidArgsFunction = completePrompt
+	runCmd.ValidArgsFunction = completePrompt
+}
+
+// completePrompt completes the prompt argument. It offers prompts from recent
+// runs, newest first, followed by the translator's template categories, all
+// filtered by what has been typed so far. Only the first argument is
+// completed, and file names are never offered since prompts are not paths.
+func completePrompt(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	seen := make(map[string]bool)
+	add := func(value, description string) {
+		if seen[value] || !strings.HasPrefix(strings.ToLower(value), strings.ToLower(toComplete)) {
+			return
+		}
+		seen[value] = true
+		completions = append(completions, value+"\t"+description)
+	}
+
+	// History is best effort; completion must never fail loudly
+	if store, err := audit.NewSQLiteStore(getAuditDBPath()); err == nil {
+		if records, err := store.GetHistory(completionHistoryLimit, ""); err == nil {
+			for _, record := range records {
+				if record.Prompt != "" {
+					add(record.Prompt, "recent prompt")
+				}
+			}
+		}
+		store.Close()
+	}
+
+	for _, category := range translator.New().ListCategories() {
+		add(category, "template category")
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SagheerAkram/QuickCmd/core/audit"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+	"github.com/spf13/cobra"
+)
+
+func TestCompletePrompt(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	store, err := audit.NewSQLiteStore(getAuditDBPath())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	for _, prompt := range []string{"find large files", "show git status", "find large files"} {
+		record := &audit.RunRecord{
+			Timestamp:       time.Now().Format(time.RFC3339),
+			Prompt:          prompt,
+			SelectedCommand: "true",
+			RiskLevel:       "safe",
+		}
+		if err := store.LogExecution(record); err != nil {
+			t.Fatalf("LogExecution() error: %v", err)
+		}
+	}
+	store.Close()
+
+	if _, err := os.Stat(filepath.Join(homeDir, ".quickcmd", "audit.db")); err != nil {
+		t.Fatalf("audit database not under test HOME: %v", err)
+	}
+
+	categories := translator.New().ListCategories()
+	if len(categories) == 0 {
+		t.Fatal("translator has no categories")
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		toComplete string
+		want       []string
+		notWant    []string
+	}{
+		{
+			name: "everything",
+			want: append([]string{"find large files", "show git status"}, categories...),
+		},
+		{
+			name:       "prefix filter",
+			toComplete: "FIND",
+			want:       []string{"find large files"},
+			notWant:    []string{"show git status"},
+		},
+		{
+			name:       "category",
+			toComplete: categories[0],
+			want:       []string{categories[0]},
+		},
+		{
+			name:    "second argument",
+			args:    []string{"find large files"},
+			notWant: []string{"find large files", categories[0]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completions, directive := completePrompt(runCmd, tt.args, tt.toComplete)
+
+			if directive != cobra.ShellCompDirectiveNoFileComp {
+				t.Errorf("directive = %v, want NoFileComp", directive)
+			}
+
+			counts := make(map[string]int)
+			for _, c := range completions {
+				value := strings.SplitN(c, "\t", 2)[0]
+				counts[value]++
+			}
+
+			for _, want := range tt.want {
+				if counts[want] != 1 {
+					t.Errorf("completion %q appears %d times in %v", want, counts[want], completions)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if counts[notWant] != 0 {
+					t.Errorf("unexpected completion %q in %v", notWant, completions)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/SagheerAkram/QuickCmd/core/config"
+	"github.com/SagheerAkram/QuickCmd/core/executor"
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
+	"github.com/SagheerAkram/QuickCmd/core/policy"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+	"github.com/SagheerAkram/QuickCmd/plugins/aws"
+	"github.com/spf13/cobra"
+)
+
+// cfg is the loaded configuration, or nil if no config file was found
+var cfg *config.Config
+
+// loadConfig loads the file named by --config, or the default config file if
+// it exists. Without either, the built-in defaults apply.
+func loadConfig(cmd *cobra.Command, args []string) error {
+	path, _ := cmd.Flags().GetString("config")
+	if path == "" {
+		path = config.DefaultPath()
+		if _, err := os.Stat(path); err != nil {
+			return nil
+		}
+	}
+
+	loaded, err := config.Load(path)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	cfg = loaded
+	aws.SetCostThreshold(cfg.CostThreshold)
+	if err := translator.SetTheme(translator.Theme(cfg.Theme)); err != nil {
+		return err
+	}
+	loadExternalPlugins(cmd.ErrOrStderr())
+
+	return nil
+}
+
+// loadExternalPlugins registers the plugins in the configured plugin_dir.
+// Failures are only warned about on w, since the built-in plugins and any
+// other external plugins still work.
+func loadExternalPlugins(w io.Writer) {
+	if cfg.PluginDir == "" || pluginsDisabled() {
+		return
+	}
+
+	err := plugins.LoadFromDir(cfg.PluginDir)
+	switch {
+	case errors.Is(err, plugins.ErrDynamicPluginsUnsupported):
+		fmt.Fprintf(w, colorYellow+"%s  plugin_dir is set, but %v; rebuild with -tags dynamicplugins to load %s\n"+colorReset,
+			translator.Symbol(translator.IconWarning), err, cfg.PluginDir)
+	case err != nil:
+		fmt.Fprintf(w, colorYellow+"%s  Failed to load external plugins: %v\n"+colorReset, translator.Symbol(translator.IconWarning), err)
+	}
+}
+
+// newPolicyEngine creates the policy engine from the configured policy file,
+// falling back to the default policy, and the configured policy webhook
+func newPolicyEngine() (*policy.Engine, error) {
+	if cfg == nil {
+		return policy.NewEngine(), nil
+	}
+
+	engine := policy.NewEngine()
+	if cfg.PolicyFile != "" {
+		var err error
+		if engine, err = policy.NewEngineFromFile(cfg.PolicyFile); err != nil {
+			return nil, fmt.Errorf("failed to load policy %s: %w", cfg.PolicyFile, err)
+		}
+	}
+
+	if webhook := cfg.PolicyWebhook; webhook.URL != "" {
+		timeout := time.Duration(webhook.TimeoutSeconds) * time.Second
+		engine.SetDecisionPoint(policy.NewDecisionPoint(webhook.URL, webhook.Secret, timeout))
+	}
+	return engine, nil
+}
+
+// sandboxImage returns the configured image for sandbox runs, or "" to
+// select one from the command
+func sandboxImage() string {
+	if cfg != nil {
+		return cfg.SandboxImage
+	}
+	return ""
+}
+
+// sandboxImageOverrides returns the configured images by executable
+func sandboxImageOverrides() map[string]string {
+	if cfg != nil {
+		return cfg.SandboxImages
+	}
+	return nil
+}
+
+// applySandboxProfile sets the resource limits of the sandbox profile for
+// the candidate's risk level and returns the profile name. Options are left
+// unchanged if the risk level has no profile.
+func applySandboxProfile(opts *executor.SandboxOptions, risk translator.Risk) string {
+	c := cfg
+	if c == nil {
+		c = config.DefaultConfig()
+	}
+
+	name, profile, ok := c.SandboxProfileFor(string(risk))
+	if !ok {
+		return ""
+	}
+
+	opts.CPULimit = profile.CPUs
+	opts.MemoryLimit = profile.MemoryMB * 1024 * 1024
+	opts.PidsLimit = profile.Pids
+	opts.NetworkAccess = profile.Network
+	opts.ReadOnly = profile.ReadOnly
+	opts.Timeout = time.Duration(profile.TimeoutSeconds) * time.Second
+	return name
+}
+
+// checkNetworkTargets returns an error if the sandbox profile for the risk
+// level cuts off the network, since a network allowlist would turn it back on
+func checkNetworkTargets(risk translator.Risk, targets []string) error {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	c := cfg
+	if c == nil {
+		c = config.DefaultConfig()
+	}
+
+	name, profile, ok := c.SandboxProfileFor(string(risk))
+	if !ok || profile.Network || profile.NetworkAllowlist {
+		return nil
+	}
+	return fmt.Errorf("sandbox profile %s (%s risk) has no network access, but the command reaches %s", name, risk, strings.Join(targets, ", "))
+}
+
+// newUndoEngine creates the undo engine, storing backups with the
+// configured backend
+func newUndoEngine() *executor.UndoEngine {
+	c := cfg
+	if c == nil {
+		c = config.DefaultConfig()
+	}
+
+	if c.Backup.Backend == "s3" {
+		return executor.NewUndoEngineWithBackend(c.Backup.Dir, executor.NewS3Backend(c.Backup.Bucket, c.Backup.Prefix))
+	}
+	return executor.NewUndoEngine(c.Backup.Dir)
+}
+
+// breakerConfig returns the configured destructive command circuit breaker
+// settings
+func breakerConfig() policy.BreakerConfig {
+	if cfg == nil {
+		return policy.DefaultBreakerConfig()
+	}
+
+	return policy.BreakerConfig{
+		Threshold: cfg.CircuitBreaker.Threshold,
+		Window:    time.Duration(cfg.CircuitBreaker.WindowSeconds) * time.Second,
+		Cooldown:  time.Duration(cfg.CircuitBreaker.CooldownSeconds) * time.Second,
+	}
+}
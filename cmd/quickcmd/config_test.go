@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SagheerAkram/QuickCmd/core/config"
+	"github.com/SagheerAkram/QuickCmd/core/executor"
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
+	"github.com/SagheerAkram/QuickCmd/core/policy"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+	"github.com/spf13/cobra"
+)
+
+func TestLoadConfig(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	defer func() { cfg = nil }()
+
+	newCmd := func(path string) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("config", path, "")
+		return cmd
+	}
+
+	// No flag and no default file keeps the built-in defaults
+	if err := loadConfig(newCmd(""), nil); err != nil {
+		t.Fatalf("loadConfig() error: %v", err)
+	}
+	if cfg != nil {
+		t.Fatalf("cfg = %+v, want nil", cfg)
+	}
+	if want := filepath.Join(homeDir, ".quickcmd", "audit.db"); getAuditDBPath() != want {
+		t.Errorf("getAuditDBPath() = %q, want %q", getAuditDBPath(), want)
+	}
+	if sandboxImage() != "" {
+		t.Errorf("sandboxImage() = %q, want empty to select by command", sandboxImage())
+	}
+
+	policyPath := filepath.Join(homeDir, "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte("denylist:\n  - pattern: 'rm -rf'\n"), 0644); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+	configPath := filepath.Join(homeDir, "quickcmd.yaml")
+	sample := "policy_file: \"~/policy.yaml\"\naudit_db_path: \"~/audit/runs.db\"\nsandbox_image: \"ubuntu:22.04\"\n"
+	if err := os.WriteFile(configPath, []byte(sample), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := loadConfig(newCmd(configPath), nil); err != nil {
+		t.Fatalf("loadConfig() error: %v", err)
+	}
+	if want := filepath.Join(homeDir, "audit", "runs.db"); getAuditDBPath() != want {
+		t.Errorf("getAuditDBPath() = %q, want %q", getAuditDBPath(), want)
+	}
+	if sandboxImage() != "ubuntu:22.04" {
+		t.Errorf("sandboxImage() = %q, want ubuntu:22.04", sandboxImage())
+	}
+
+	engine, err := newPolicyEngine()
+	if err != nil {
+		t.Fatalf("newPolicyEngine() error: %v", err)
+	}
+	if result := engine.Validate("rm -rf build", "high", true); result.Allowed {
+		t.Error("configured policy not applied")
+	}
+
+	if err := loadConfig(newCmd(filepath.Join(homeDir, "missing.yaml")), nil); err == nil {
+		t.Error("Expected error for missing --config file")
+	}
+}
+
+func TestLoadConfig_PluginDir(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	defer func() { cfg = nil }()
+
+	pluginDir := filepath.Join(homeDir, "plugins")
+	if err := os.Mkdir(pluginDir, 0755); err != nil {
+		t.Fatalf("Failed to create plugin dir: %v", err)
+	}
+	if err := plugins.LoadFromDir(pluginDir); !errors.Is(err, plugins.ErrDynamicPluginsUnsupported) {
+		t.Skip("built with dynamic plugin support")
+	}
+
+	configPath := filepath.Join(homeDir, "quickcmd.yaml")
+	if err := os.WriteFile(configPath, []byte("plugin_dir: \"~/plugins\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	// A build without plugin support still starts, but says why the
+	// configured plugins are missing
+	var stderr bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.Flags().String("config", configPath, "")
+	cmd.SetErr(&stderr)
+	if err := loadConfig(cmd, nil); err != nil {
+		t.Fatalf("loadConfig() error: %v", err)
+	}
+	if !strings.Contains(stderr.String(), pluginDir) || !strings.Contains(stderr.String(), "dynamicplugins") {
+		t.Errorf("warning = %q, want it to name the plugin dir and the build tag", stderr.String())
+	}
+}
+
+func TestCheckNetworkTargets(t *testing.T) {
+	cfg = config.DefaultConfig()
+	defer func() { cfg = nil }()
+
+	targets := []string{"api.github.com"}
+	if err := checkNetworkTargets(translator.RiskSafe, targets); err != nil {
+		t.Errorf("permissive profile rejected a network allowlist: %v", err)
+	}
+	if err := checkNetworkTargets(translator.RiskHigh, nil); err != nil {
+		t.Errorf("restrictive profile rejected a command without network targets: %v", err)
+	}
+
+	// The restrictive profile has no network, so an allowlist can't reopen it
+	if err := checkNetworkTargets(translator.RiskHigh, targets); err == nil {
+		t.Error("Expected restrictive profile to reject a network allowlist")
+	}
+
+	cfg.SandboxProfiles["restrictive"] = config.SandboxProfile{Network: true}
+	if err := checkNetworkTargets(translator.RiskHigh, targets); err != nil {
+		t.Errorf("profile with network rejected a network allowlist: %v", err)
+	}
+}
+
+func TestLoadConfig_Example(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	defer func() { cfg = nil }()
+
+	// The example config works as-is, before any policy file is installed
+	cmd := &cobra.Command{}
+	cmd.Flags().String("config", filepath.Join("..", "..", "examples", "config.yaml"), "")
+	if err := loadConfig(cmd, nil); err != nil {
+		t.Fatalf("loadConfig() error: %v", err)
+	}
+	if _, err := newPolicyEngine(); err != nil {
+		t.Fatalf("newPolicyEngine() error: %v", err)
+	}
+
+	// The policy it tells users to copy loads too
+	if _, err := policy.NewEngineFromFile(filepath.Join("..", "..", "examples", "policies", "default.yaml")); err != nil {
+		t.Errorf("NewEngineFromFile(default.yaml) error: %v", err)
+	}
+}
+
+func TestApplySandboxProfile(t *testing.T) {
+	cfg = config.DefaultConfig()
+	defer func() { cfg = nil }()
+	cfg.SandboxProfiles["permissive"] = config.SandboxProfile{CPUs: 1, MemoryMB: 512, Pids: 128, Network: true, TimeoutSeconds: 600}
+	cfg.SandboxProfiles["restrictive"] = config.SandboxProfile{CPUs: 0.25, MemoryMB: 64, Pids: 16, ReadOnly: true, TimeoutSeconds: 30}
+
+	high := &translator.Candidate{Command: "rm -rf build", RiskLevel: translator.RiskHigh}
+	var opts executor.SandboxOptions
+	if profile := applySandboxProfile(&opts, high.RiskLevel); profile != "restrictive" {
+		t.Errorf("high risk profile = %q, want restrictive", profile)
+	}
+	if opts.CPULimit != 0.25 || opts.MemoryLimit != 64*1024*1024 || opts.PidsLimit != 16 ||
+		opts.NetworkAccess || !opts.ReadOnly || opts.Timeout != 30*time.Second {
+		t.Errorf("high risk options = %+v", opts)
+	}
+
+	safe := &translator.Candidate{Command: "ls -la", RiskLevel: translator.RiskSafe}
+	opts = executor.SandboxOptions{}
+	if profile := applySandboxProfile(&opts, safe.RiskLevel); profile != "permissive" {
+		t.Errorf("safe profile = %q, want permissive", profile)
+	}
+	if opts.CPULimit != 1 || opts.MemoryLimit != 512*1024*1024 || opts.PidsLimit != 128 ||
+		!opts.NetworkAccess || opts.ReadOnly || opts.Timeout != 10*time.Minute {
+		t.Errorf("safe options = %+v", opts)
+	}
+
+	// Unmapped risk levels keep the options they have
+	opts = executor.SandboxOptions{CPULimit: 0.5}
+	if profile := applySandboxProfile(&opts, "unknown"); profile != "" || opts.CPULimit != 0.5 {
+		t.Errorf("unknown risk profile = %q, options = %+v", profile, opts)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	
+	"gopkg.in/yaml.v3"
+	
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+)
+
+var updateCorpus = flag.Bool("update", false, "rewrite testdata/corpus.yaml with the current top commands")
+
+// corpusPath is the regression corpus of prompt to top command mappings
+var corpusPath = filepath.Join("testdata", "corpus.yaml")
+
+// corpusEntry is a prompt and the top command expected for it. An empty
+// command means the prompt must not match.
+type corpusEntry struct {
+	Prompt  string `yaml:"prompt"`
+	Command string `yaml:"command"`
+}
+
+// TestCorpus runs every prompt in the corpus through the full translate
+// pipeline, so template and plugin changes can't silently change known
+// translations. Run with -update to accept the current results.
+func TestCorpus(t *testing.T) {
+	data, err := os.ReadFile(corpusPath)
+	if err != nil {
+		t.Fatalf("failed to read corpus: %v", err)
+	}
+	
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("failed to parse corpus: %v", err)
+	}
+	var entries []corpusEntry
+	if err := doc.Decode(&entries); err != nil {
+		t.Fatalf("failed to decode corpus: %v", err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("corpus is empty")
+	}
+	
+	trans := translator.New()
+	for i, entry := range entries {
+		got := ""
+		candidates, err := translateCandidates(context.Background(), trans, entry.Prompt, true)
+		if err != nil && !errors.Is(err, translator.ErrNoMatch) {
+			t.Errorf("translateCandidates(%q) error: %v", entry.Prompt, err)
+			continue
+		}
+		if len(candidates) > 0 {
+			got = candidates[0].Command
+		}
+		
+		if got == entry.Command {
+			continue
+		}
+		if *updateCorpus {
+			t.Logf("prompt %q: updating %q to %q", entry.Prompt, entry.Command, got)
+			setCorpusCommand(&doc, i, got)
+			continue
+		}
+		t.Errorf("prompt %q:\n  got:  %q\n  want: %q", entry.Prompt, got, entry.Command)
+	}
+	
+	if *updateCorpus {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&doc); err != nil {
+			t.Fatalf("failed to encode corpus: %v", err)
+		}
+		if err := os.WriteFile(corpusPath, buf.Bytes(), 0644); err != nil {
+			t.Fatalf("failed to write corpus: %v", err)
+		}
+	}
+}
+
+// setCorpusCommand sets the command of the i-th corpus entry in place, so
+// -update keeps the file's comments and ordering
+func setCorpusCommand(doc *yaml.Node, i int, command string) {
+	entry := doc.Content[0].Content[i]
+	for j := 0; j+1 < len(entry.Content); j += 2 {
+		if entry.Content[j].Value == "command" {
+			entry.Content[j+1].Value = command
+			entry.Content[j+1].Style = 0
+			return
+		}
+	}
+	entry.Content = append(entry.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: "command"},
+		&yaml.Node{Kind: yaml.ScalarNode, Value: command})
+}
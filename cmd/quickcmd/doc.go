@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+	"github.com/spf13/cobra"
+)
+
+var docCmd = &cobra.Command{
+	Use:   "doc <prompt>",
+	Short: "Print a Markdown runbook entry for a prompt",
+	Long: `Translates a prompt and prints the top candidate as Markdown: the command,
+its explanation and risk, the numbered breakdown steps with their
+sub-commands, and documentation links. Nothing is executed.
+
+Use --all to include every candidate, e.g. to document alternatives.`,
+	Example: `  quickcmd doc "find files larger than 100MB" >> RUNBOOK.md
+  quickcmd doc "show disk usage" --all`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runDoc,
+}
+
+func init() {
+	rootCmd.AddCommand(docCmd)
+
+	docCmd.Flags().Bool("all", false, "document every candidate, not just the top one")
+}
+
+func runDoc(cmd *cobra.Command, args []string) error {
+	prompt := strings.Join(args, " ")
+
+	candidates, err := translateCandidates(cmd.Context(), translator.New(), prompt, !pluginsDisabled())
+	if err != nil {
+		return translationError(prompt, err)
+	}
+
+	if all, _ := cmd.Flags().GetBool("all"); !all {
+		candidates = candidates[:1]
+	}
+
+	writeDoc(cmd.OutOrStdout(), prompt, candidates)
+	return nil
+}
+
+// writeDoc writes a Markdown document for prompt's candidates
+func writeDoc(w io.Writer, prompt string, candidates []*translator.Candidate) {
+	fmt.Fprintf(w, "# %s\n", strings.TrimSpace(prompt))
+	for _, c := range candidates {
+		fmt.Fprintf(w, "\n%s", c.Markdown())
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// runDocCommand executes doc and returns its output
+func runDocCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{Use: "quickcmd"}
+	doc := &cobra.Command{Use: "doc", Args: cobra.MinimumNArgs(1), SilenceUsage: true, RunE: runDoc}
+	doc.Flags().Bool("all", false, "")
+	cmd.AddCommand(doc)
+
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(append([]string{"doc"}, args...))
+
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestDoc(t *testing.T) {
+	noPlugins = true
+	t.Cleanup(func() { noPlugins = false })
+
+	out, err := runDocCommand(t, "find files larger than 100MB")
+	if err != nil {
+		t.Fatalf("doc error: %v", err)
+	}
+	if !strings.HasPrefix(out, "# find files larger than 100MB\n\n## `find . -type f -size +100M`\n") {
+		t.Errorf("doc output:\n%s", out)
+	}
+	if strings.Count(out, "\n## ") != 1 {
+		t.Errorf("doc without --all should document one candidate:\n%s", out)
+	}
+
+	if _, err := runDocCommand(t, "make a fork bomb"); err == nil || !strings.Contains(err.Error(), "Security note") {
+		t.Errorf("doc of a blocked prompt error = %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/SagheerAkram/QuickCmd/core/config"
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
+	"github.com/SagheerAkram/QuickCmd/core/policy"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+)
+
+// envName is the environment selected with --env
+var envName string
+
+// environment is the environment commands are generated for, or nil. It is
+// set from --env before translating and passed to plugins by pluginContext.
+var environment *plugins.Environment
+
+// selectEnvironment looks up the named environment in the config. An empty
+// name selects none.
+func selectEnvironment(name string) (*plugins.Environment, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	conf := cfg
+	if conf == nil {
+		conf = config.DefaultConfig()
+	}
+	env, err := conf.Environment(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &plugins.Environment{
+		Name:        name,
+		KubeContext: env.KubeContext,
+		Namespace:   env.Namespace,
+		AWSProfile:  env.AWSProfile,
+		Production:  env.Production,
+	}, nil
+}
+
+// applyEnvironmentPolicy makes candidates for a production environment
+// stricter: any candidate that isn't safe becomes high risk and needs typed
+// confirmation. Other environments change nothing.
+func applyEnvironmentPolicy(candidates []*translator.Candidate, env *plugins.Environment) {
+	if env == nil || !env.Production {
+		return
+	}
+
+	for _, c := range candidates {
+		if c.RiskLevel == translator.RiskSafe {
+			continue
+		}
+		c.RiskLevel = translator.RiskHigh
+		c.RequiresConfirm = true
+	}
+}
+
+// requirePluginApproval escalates validation to approval when a plugin
+// requires it for candidate, such as any change in a production
+// environment. Like other approvals, --yes can't give it in advance. Plugins
+// are asked even with --no-plugins, so the flag can't skip an approval.
+func requirePluginApproval(validation *policy.ValidationResult, candidate *translator.Candidate) {
+	if !validation.Allowed || validation.RequiresApproval {
+		return
+	}
+	if !plugins.CheckApprovalRequired(pluginContext(), toPluginCandidate(candidate)) {
+		return
+	}
+
+	validation.RequiresConfirm = true
+	validation.RequiresApproval = true
+	if environment != nil && environment.Production {
+		validation.ConfirmMessage = fmt.Sprintf("Changes in production environment %q require approval. Type 'I UNDERSTAND' to proceed", environment.Name)
+	} else {
+		validation.ConfirmMessage = "A plugin requires approval for this command. Type 'I UNDERSTAND' to proceed"
+	}
+}
+
+// environmentLabel describes env for display, e.g. "prod (production)"
+func environmentLabel(env *plugins.Environment) string {
+	if env.Production {
+		return fmt.Sprintf("%s%s (production)%s", colorRed, env.Name, colorReset)
+	}
+	return env.Name
+}
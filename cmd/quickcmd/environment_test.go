@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/SagheerAkram/QuickCmd/core/config"
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
+	"github.com/SagheerAkram/QuickCmd/core/policy"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+)
+
+// useEnvironments configures dev and prod environments for the test
+func useEnvironments(t *testing.T) {
+	t.Helper()
+
+	cfg = config.DefaultConfig()
+	cfg.Environments = map[string]config.Environment{
+		"dev":  {KubeContext: "dev-cluster", Namespace: "dev"},
+		"prod": {KubeContext: "prod-cluster", Namespace: "payments", Production: true},
+	}
+	t.Cleanup(func() {
+		cfg = nil
+		environment = nil
+	})
+}
+
+// translateFor translates prompt as quickcmd run --env name would
+func translateFor(t *testing.T, name, prompt string) *translator.Candidate {
+	t.Helper()
+
+	var err error
+	if environment, err = selectEnvironment(name); err != nil {
+		t.Fatalf("selectEnvironment(%q) error: %v", name, err)
+	}
+	candidates, err := translateInCategory(context.Background(), translator.New(), prompt, true, "k8s")
+	if err != nil {
+		t.Fatalf("translateInCategory(%q) error: %v", prompt, err)
+	}
+	applyEnvironmentPolicy(candidates, environment)
+	return candidates[0]
+}
+
+func TestEnvironment_Prod(t *testing.T) {
+	useEnvironments(t)
+	prompt := "scale deployment api to 5 replicas"
+
+	dev := translateFor(t, "dev", prompt)
+	if dev.Command != "kubectl --context dev-cluster -n dev scale deployment api --replicas=5" {
+		t.Errorf("dev command = %q, want it pinned to dev-cluster", dev.Command)
+	}
+	if dev.RiskLevel != translator.RiskMedium {
+		t.Errorf("dev RiskLevel = %q, want unchanged %q", dev.RiskLevel, translator.RiskMedium)
+	}
+
+	prod := translateFor(t, "prod", prompt)
+	if !strings.HasPrefix(prod.Command, "kubectl --context prod-cluster -n payments ") {
+		t.Errorf("prod command = %q, want it pinned to prod-cluster and payments", prod.Command)
+	}
+	if prod.RiskLevel != translator.RiskHigh || !prod.RequiresConfirm {
+		t.Errorf("prod RiskLevel = %q, RequiresConfirm = %v, want high and true", prod.RiskLevel, prod.RequiresConfirm)
+	}
+
+	// The escalated risk needs the high-risk confirmation phrase
+	validation := policy.NewEngine().Validate(prod.Command, string(prod.RiskLevel), prod.Destructive)
+	if !validation.RequiresConfirm || !strings.Contains(validation.ConfirmMessage, "I UNDERSTAND") {
+		t.Errorf("prod validation = %+v, want high-risk confirmation", validation)
+	}
+
+	// Plugins require approval for changes in production
+	plugin, err := plugins.Get("k8s")
+	if err != nil {
+		t.Fatalf("plugins.Get(k8s) error: %v", err)
+	}
+	approver := plugin.(plugins.ContextApprover)
+	candidate := &plugins.Candidate{Command: "kubectl get deployments", RiskLevel: plugins.RiskMedium}
+	if !approver.RequiresApprovalWithContext(pluginContext(), candidate) {
+		t.Error("RequiresApprovalWithContext() in prod = false, want true")
+	}
+	environment, _ = selectEnvironment("dev")
+	if approver.RequiresApprovalWithContext(pluginContext(), candidate) {
+		t.Error("RequiresApprovalWithContext() in dev = true, want false")
+	}
+}
+
+func TestSelectEnvironment_Unknown(t *testing.T) {
+	useEnvironments(t)
+
+	if env, err := selectEnvironment(""); env != nil || err != nil {
+		t.Errorf("selectEnvironment(\"\") = %+v, %v, want none", env, err)
+	}
+	if _, err := selectEnvironment("staging"); err == nil || !strings.Contains(err.Error(), "dev, prod") {
+		t.Errorf("selectEnvironment(staging) error = %v, want the configured environments", err)
+	}
+}
+
+func TestRunCommand_ProdApproval(t *testing.T) {
+	useEnvironments(t)
+	t.Setenv("HOME", t.TempDir())
+
+	// --yes can't approve a production change in advance
+	if _, err := executeCommand(t, "", "run", "--env", "prod", "--yes", "scale deployment api to 5 replicas"); err == nil || !strings.Contains(err.Error(), "requires approval") {
+		t.Errorf("run --env prod --yes error = %v, want an approval refusal", err)
+	}
+
+	// Nor can --json
+	stdout := captureStdout(t, func() {
+		executeCommand(t, "", "run", "--env", "prod", "--yes", "--json", "scale deployment api to 5 replicas")
+	})
+	if !strings.Contains(stdout, "approval required") || strings.Contains(stdout, `"executed":true`) {
+		t.Errorf("run --env prod --yes --json output = %s, want an approval refusal", stdout)
+	}
+}
+
+// captureStdout returns what fn writes to os.Stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := os.Stdout
+	os.Stdout = w
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	fn()
+	os.Stdout = original
+	w.Close()
+	return <-output
+}
@@ -1,14 +1,14 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
-	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 	
+	"github.com/SagheerAkram/QuickCmd/core/audit"
 	"github.com/spf13/cobra"
-	"github.com/yourusername/quickcmd/core/audit"
 )
 
 var historyCmd = &cobra.Command{
@@ -20,15 +20,53 @@ Shows timestamp, prompt, command, exit code, and undo options where available.`,
 	RunE: showHistory,
 }
 
+var historySimilarCmd = &cobra.Command{
+	Use:   "similar <command>",
+	Short: "Find past runs similar to a command",
+	Long: `Ranks previously executed commands by how many tokens they share with
+the given command.
+
+Example:
+  quickcmd history similar "find . -name '*.log' -delete"`,
+	Args: cobra.ExactArgs(1),
+	RunE: showSimilarHistory,
+}
+
+var historyPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Delete old runs from the audit log",
+	Long: `Deletes runs older than --older-than, or all but the --keep-last most
+recent, then compacts the database. The hash chain is re-anchored, so
+verification still covers the runs kept.
+
+You must type PURGE to confirm, or pass --yes.
+
+Example:
+  quickcmd history purge --older-than 90d
+  quickcmd history purge --keep-last 1000 --yes`,
+	Args: cobra.NoArgs,
+	RunE: purgeHistory,
+}
+
 func init() {
 	historyCmd.Flags().IntP("limit", "n", 20, "number of entries to show")
 	historyCmd.Flags().StringP("filter", "f", "", "filter by command or prompt")
+	historyCmd.Flags().StringP("search", "s", "", "search prompts, commands and output for all of these words")
 	historyCmd.Flags().Bool("stats", false, "show statistics instead of history")
+	
+	historyCmd.AddCommand(historySimilarCmd)
+	historySimilarCmd.Flags().IntP("limit", "n", 10, "number of matches to show")
+	
+	historyCmd.AddCommand(historyPurgeCmd)
+	historyPurgeCmd.Flags().String("older-than", "", "delete runs older than this age, e.g. 90d or 12h")
+	historyPurgeCmd.Flags().Int("keep-last", -1, "delete all but this many of the most recent runs")
+	historyPurgeCmd.Flags().Bool("yes", false, "purge without confirmation")
 }
 
 func showHistory(cmd *cobra.Command, args []string) error {
 	limit, _ := cmd.Flags().GetInt("limit")
 	filter, _ := cmd.Flags().GetString("filter")
+	search, _ := cmd.Flags().GetString("search")
 	showStats, _ := cmd.Flags().GetBool("stats")
 	
 	// Open audit database
@@ -44,7 +82,13 @@ func showHistory(cmd *cobra.Command, args []string) error {
 	}
 	
 	// Get history
-	records, err := store.GetHistory(limit, filter)
+	var records []*audit.RunRecord
+	if search != "" {
+		records, err = store.Search(search, limit)
+		filter = search
+	} else {
+		records, err = store.GetHistory(limit, filter)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get history: %w", err)
 	}
@@ -76,6 +120,103 @@ func showHistory(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func showSimilarHistory(cmd *cobra.Command, args []string) error {
+	limit, _ := cmd.Flags().GetInt("limit")
+	
+	store, err := audit.NewSQLiteStore(getAuditDBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open audit database: %w", err)
+	}
+	defer store.Close()
+	
+	matches, err := store.FindSimilar(args[0], limit)
+	if err != nil {
+		return fmt.Errorf("failed to search history: %w", err)
+	}
+	
+	if len(matches) == 0 {
+		fmt.Println("No similar commands found.")
+		return nil
+	}
+	
+	fmt.Printf("%sSimilar Commands%s (to: %s)\n\n", colorBold, colorReset, args[0])
+	
+	for i, match := range matches {
+		fmt.Printf("%s%3.0f%%%s ", colorCyan, match.Score*100, colorReset)
+		displayRecord(i+1, match.Record)
+	}
+	
+	return nil
+}
+
+func purgeHistory(cmd *cobra.Command, args []string) error {
+	olderThan, _ := cmd.Flags().GetString("older-than")
+	keepLast, _ := cmd.Flags().GetInt("keep-last")
+	
+	if (olderThan == "") == (keepLast < 0) {
+		return fmt.Errorf("pass exactly one of --older-than or --keep-last")
+	}
+	
+	var age time.Duration
+	description := fmt.Sprintf("all but the %d most recent runs", keepLast)
+	if olderThan != "" {
+		var err error
+		if age, err = parseAge(olderThan); err != nil {
+			return err
+		}
+		description = "runs older than " + olderThan
+	}
+	
+	out := cmd.OutOrStdout()
+	if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+		fmt.Fprintf(out, "%sThis permanently deletes %s from the audit log. Type PURGE to confirm%s\n", colorYellow, description, colorReset)
+		fmt.Fprint(out, "Confirmation: ")
+		
+		input, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		if strings.TrimSpace(input) != "PURGE" {
+			fmt.Fprintln(out, "Cancelled.")
+			return nil
+		}
+	}
+	
+	store, err := audit.NewSQLiteStore(getAuditDBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open audit database: %w", err)
+	}
+	defer store.Close()
+	
+	var deleted int
+	if olderThan != "" {
+		deleted, err = store.Purge(age)
+	} else {
+		deleted, err = store.PurgeKeepLast(keepLast)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to purge history: %w", err)
+	}
+	
+	fmt.Fprintf(out, "%s✓ Purged %d runs%s\n", colorGreen, deleted, colorReset)
+	return nil
+}
+
+// parseAge parses a duration such as 90d, 12h or 1h30m. Days are accepted
+// on their own, since time.ParseDuration has no unit for them.
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q: want a number of days, e.g. 90d", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q: want e.g. 90d or 12h", value)
+	}
+	return age, nil
+}
+
 func displayRecord(num int, record *audit.RunRecord) {
 	// Parse timestamp
 	timestamp, _ := time.Parse(time.RFC3339, record.Timestamp)
@@ -182,11 +323,3 @@ func displayStats(store *audit.SQLiteStore) error {
 	
 	return nil
 }
-
-func getAuditDBPath() string {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return filepath.Join(os.TempDir(), "quickcmd", "audit.db")
-	}
-	return filepath.Join(homeDir, ".quickcmd", "audit.db")
-}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"90d", 90 * 24 * time.Hour},
+		{"0d", 0},
+		{"12h", 12 * time.Hour},
+		{"1h30m", 90 * time.Minute},
+	}
+	for _, tt := range tests {
+		got, err := parseAge(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("parseAge(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "d", "ninety days", "-5d", "-1h", "1.5d"} {
+		if _, err := parseAge(value); err == nil {
+			t.Errorf("parseAge(%q) expected error", value)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/SagheerAkram/QuickCmd/core/analytics"
+	"github.com/SagheerAkram/QuickCmd/core/learning/store"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+)
+
+// Parts of the learning store written by the run command
+const (
+	timingsNamespace     = "timings"     // the time predictor's execution history
+	calibrationNamespace = "calibration" // which offered commands were run
+)
+
+// openLearningStore opens the learning store. If it can't be read a warning
+// is printed and an in-memory store is used, so learning never stops a run
+// and a damaged file is left alone.
+func openLearningStore(w io.Writer) *store.Store {
+	learned, err := store.Open(getLearningStorePath())
+	if err != nil {
+		fmt.Fprintf(w, colorYellow+"%s  %v\n"+colorReset, translator.Symbol(translator.IconWarning), err)
+		learned, _ = store.Open("")
+	}
+	return learned
+}
+
+// calibrateCandidates adjusts the confidence of candidates by how often
+// the user has run each command when offered it, and re-sorts them. It
+// returns the calibration for recordSelection.
+func calibrateCandidates(w io.Writer, learned *store.Store, candidates []*translator.Candidate) *analytics.Calibration {
+	calibration := analytics.NewCalibration()
+	if err := calibration.LoadFrom(learned.Namespace(calibrationNamespace)); err != nil {
+		fmt.Fprintf(w, colorYellow+"%s  %v\n"+colorReset, translator.Symbol(translator.IconWarning), err)
+		return calibration
+	}
+
+	for _, c := range candidates {
+		c.Confidence = calibration.Adjust(c.Command, c.Confidence)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	return calibration
+}
+
+// recordSelection records that selected was run out of candidates
+func recordSelection(w io.Writer, learned *store.Store, calibration *analytics.Calibration, candidates []*translator.Candidate, selected *translator.Candidate) {
+	offered := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		offered = append(offered, c.Command)
+	}
+	calibration.Record(offered, selected.Command)
+
+	if err := calibration.SaveTo(learned.Namespace(calibrationNamespace)); err != nil {
+		fmt.Fprintf(w, colorYellow+"%s  Failed to save calibration: %v\n"+colorReset, translator.Symbol(translator.IconWarning), err)
+	}
+}
+
+// predictDuration loads the execution history and shows how long command
+// is expected to take, if it has run before
+func predictDuration(w io.Writer, learned *store.Store, command string) *analytics.TimePredictor {
+	predictor := analytics.NewTimePredictor()
+	if err := predictor.LoadFrom(learned.Namespace(timingsNamespace)); err != nil {
+		fmt.Fprintf(w, colorYellow+"%s  %v\n"+colorReset, translator.Symbol(translator.IconWarning), err)
+		return predictor
+	}
+
+	if prediction := predictor.Predict(command); prediction.Confidence > 0 {
+		fmt.Fprintln(w, prediction.Format())
+	}
+	return predictor
+}
+
+// recordDuration adds a run of command to the execution history, warning
+// if it was unusually slow
+func recordDuration(w io.Writer, learned *store.Store, predictor *analytics.TimePredictor, command string, duration time.Duration) {
+	if slow := predictor.WarnIfSlow(command, duration); slow != nil {
+		fmt.Fprintf(w, colorYellow+"%s  %s\n"+colorReset, translator.Symbol(translator.IconWarning), slow.Message)
+	}
+
+	predictor.RecordExecution(command, duration)
+	if err := predictor.SaveTo(learned.Namespace(timingsNamespace)); err != nil {
+		fmt.Fprintf(w, colorYellow+"%s  Failed to save execution time: %v\n"+colorReset, translator.Symbol(translator.IconWarning), err)
+	}
+}
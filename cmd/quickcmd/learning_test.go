@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+)
+
+// tempHome points HOME at a fresh directory for the test
+func tempHome(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	writableDirs = map[string]bool{}
+	t.Cleanup(func() { writableDirs = map[string]bool{} })
+}
+
+func TestLearning_Timings(t *testing.T) {
+	tempHome(t)
+
+	// Nothing is predicted for a command never run
+	var out bytes.Buffer
+	predictor := predictDuration(&out, openLearningStore(&out), "find . -name '*.log'")
+	if out.Len() != 0 {
+		t.Errorf("prediction without history: %q", out.String())
+	}
+	recordDuration(&out, openLearningStore(&out), predictor, "find . -name '*.log'", 2*time.Second)
+
+	// A later run, with a freshly opened store, predicts from it
+	out.Reset()
+	predictDuration(&out, openLearningStore(&out), "find . -name '*.txt'")
+	if !strings.Contains(out.String(), "Estimated: 2s") {
+		t.Errorf("prediction = %q, want the recorded 2s", out.String())
+	}
+}
+
+func TestLearning_Calibration(t *testing.T) {
+	tempHome(t)
+
+	offered := func() []*translator.Candidate {
+		return []*translator.Candidate{
+			{Command: "ls -la", Confidence: 90},
+			{Command: "find . -type f", Confidence: 80},
+		}
+	}
+
+	// The user keeps picking find over ls
+	var out bytes.Buffer
+	for i := 0; i < 5; i++ {
+		learned := openLearningStore(&out)
+		candidates := offered()
+		calibration := calibrateCandidates(&out, learned, candidates)
+		for _, c := range candidates {
+			if strings.HasPrefix(c.Command, "find") {
+				recordSelection(&out, learned, calibration, candidates, c)
+			}
+		}
+	}
+	if out.Len() != 0 {
+		t.Errorf("unexpected warnings: %q", out.String())
+	}
+
+	candidates := offered()
+	calibrateCandidates(&out, openLearningStore(&out), candidates)
+	if candidates[0].Command != "find . -type f" || candidates[1].Confidence != 45 {
+		t.Errorf("calibrated candidates = %s (%d%%), %s (%d%%), want find first and ls at 45%%",
+			candidates[0].Command, candidates[0].Confidence, candidates[1].Command, candidates[1].Confidence)
+	}
+}
@@ -51,6 +51,8 @@ func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringP("config", "c", "", "config file (default: $HOME/.quickcmd/config.yaml)")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
+	
+	rootCmd.PersistentPreRunE = preRun
 }
 
 func main() {
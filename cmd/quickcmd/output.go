@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/SagheerAkram/QuickCmd/core/executor"
+	"github.com/SagheerAkram/QuickCmd/core/policy"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+)
+
+// candidateJSON is the machine-readable form of a candidate
+type candidateJSON struct {
+	Command       string     `json:"command"`
+	Explanation   string     `json:"explanation"`
+	Confidence    int        `json:"confidence"`
+	RiskLevel     string     `json:"risk_level"`
+	Destructive   bool       `json:"destructive"`
+	Breakdown     []stepJSON `json:"breakdown"`
+	AffectedPaths []string   `json:"affected_paths"`
+	Source        string     `json:"source"`
+	Category      string     `json:"category"`
+}
+
+// stepJSON is the machine-readable form of a breakdown step
+type stepJSON struct {
+	Description string `json:"description"`
+	Command     string `json:"command,omitempty"`
+}
+
+// resultJSON is the machine-readable form of an execution result
+type resultJSON struct {
+	Command    string `json:"command"`
+	Executed   bool   `json:"executed"`
+	SandboxID  string `json:"sandbox_id,omitempty"`
+	ExitCode   int    `json:"exit_code"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// runJSON handles the run command in --json mode. It writes the candidates as
+// a JSON array on one line and, if --sandbox or --yes was given, the top
+// candidate's execution result as a JSON object on the next line. Nothing is
+// prompted for; commands that need confirmation require --yes. With a
+// baseline, a diff goes to stderr and differing output is an error.
+func runJSON(prompt string, candidates []*translator.Candidate, policyEngine *policy.Engine, baseline []byte) error {
+	humanOut = os.Stderr
+	defer func() { humanOut = os.Stdout }()
+
+	if err := writeCandidatesJSON(os.Stdout, candidates); err != nil {
+		return err
+	}
+
+	if !sandbox && !yes {
+		return nil
+	}
+
+	selected := candidates[0]
+	warnPlaintextSecrets(humanOut, prompt, selected.Command)
+
+	validation := policyEngine.Validate(selected.Command, string(selected.RiskLevel), selected.Destructive)
+	if !validation.Allowed {
+		return writeResultJSON(os.Stdout, selected, nil, fmt.Errorf("command blocked by policy: %s", validation.Reason))
+	}
+	requirePluginApproval(validation, selected)
+	if validation.RequiresApproval && !validation.RequiresApprover {
+		return writeResultJSON(os.Stdout, selected, nil, fmt.Errorf("approval required, rerun without --json to confirm"))
+	}
+	if validation.RequiresConfirm && !yes {
+		return writeResultJSON(os.Stdout, selected, nil, fmt.Errorf("confirmation required, rerun with --yes"))
+	}
+	if validation.RequiresApprover {
+		if err := obtainApproval(humanOut, prompt, selected, validation.Reason); err != nil {
+			return writeResultJSON(os.Stdout, selected, nil, err)
+		}
+	}
+	if !sandbox {
+		if err := checkRootExecution(selected); err != nil {
+			return writeResultJSON(os.Stdout, selected, nil, err)
+		}
+	}
+	if sandbox {
+		result, err := executeInSandbox(prompt, selected, policyEngine)
+		if err == nil && result == nil {
+			err = fmt.Errorf("docker is not available")
+		}
+		if writeErr := writeResultJSON(os.Stdout, selected, result, err); writeErr != nil || baseline == nil {
+			return writeErr
+		}
+		return verifyBaseline(humanOut, expectFile, baseline, result)
+	}
+
+	if err := simulateCandidate(context.Background(), humanOut, selected, policyEngine); err != nil {
+		return writeResultJSON(os.Stdout, selected, nil, err)
+	}
+	err := executeDirect(prompt, selected, policyEngine)
+	if err == nil {
+		err = fmt.Errorf("direct execution not yet implemented")
+	}
+	return writeResultJSON(os.Stdout, selected, nil, err)
+}
+
+// writeCandidatesJSON writes candidates as a JSON array
+func writeCandidatesJSON(w io.Writer, candidates []*translator.Candidate) error {
+	out := make([]candidateJSON, 0, len(candidates))
+	for _, c := range candidates {
+		steps := make([]stepJSON, 0, len(c.Breakdown))
+		for _, step := range c.Breakdown {
+			steps = append(steps, stepJSON{Description: step.Description, Command: step.Command})
+		}
+
+		affected := c.AffectedPaths
+		if affected == nil {
+			affected = []string{}
+		}
+
+		out = append(out, candidateJSON{
+			Command:       c.Command,
+			Explanation:   c.Explanation,
+			Confidence:    c.Confidence,
+			RiskLevel:     string(c.RiskLevel),
+			Destructive:   c.Destructive,
+			Breakdown:     steps,
+			AffectedPaths: affected,
+			Source:        c.Source,
+			Category:      c.Category,
+		})
+	}
+
+	return json.NewEncoder(w).Encode(out)
+}
+
+// writeResultJSON writes an execution result as a JSON object. A nil result
+// means the command was not executed.
+func writeResultJSON(w io.Writer, candidate *translator.Candidate, result *executor.SandboxResult, execErr error) error {
+	out := resultJSON{Command: candidate.Command}
+
+	if result != nil {
+		out.Executed = true
+		out.SandboxID = result.SandboxID
+		out.ExitCode = result.ExitCode
+		out.Stdout = string(result.Stdout)
+		out.Stderr = string(result.Stderr)
+		out.DurationMs = result.EndTime.Sub(result.StartTime).Milliseconds()
+	}
+	if execErr != nil {
+		out.Error = execErr.Error()
+	}
+
+	return json.NewEncoder(w).Encode(out)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SagheerAkram/QuickCmd/core/executor"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+)
+
+func TestWriteCandidatesJSON(t *testing.T) {
+	candidates := []*translator.Candidate{
+		{
+			Command:       "find . -name '*.log' -delete",
+			Explanation:   "Delete log files",
+			Confidence:    90,
+			RiskLevel:     translator.RiskHigh,
+			Destructive:   true,
+			Breakdown:     []translator.Step{{Description: "find log files", Command: "find . -name '*.log'"}},
+			AffectedPaths: []string{"./app.log"},
+		},
+		{
+			Command:    "ls -la",
+			Confidence: 80,
+			RiskLevel:  translator.RiskSafe,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeCandidatesJSON(&buf, candidates); err != nil {
+		t.Fatalf("writeCandidatesJSON() error = %v", err)
+	}
+
+	if strings.Contains(buf.String(), "\033") {
+		t.Errorf("JSON output contains ANSI escape codes: %q", buf.String())
+	}
+
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not a JSON array: %v", err)
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("decoded %d candidates, want 2", len(decoded))
+	}
+
+	first := decoded[0]
+	for _, key := range []string{"command", "explanation", "confidence", "risk_level", "destructive", "breakdown", "affected_paths", "source"} {
+		if _, ok := first[key]; !ok {
+			t.Errorf("candidate missing %q field", key)
+		}
+	}
+	if first["risk_level"] != "high" || first["destructive"] != true {
+		t.Errorf("unexpected candidate: %v", first)
+	}
+
+	// Empty lists are arrays, not null
+	if paths, ok := decoded[1]["affected_paths"].([]interface{}); !ok || len(paths) != 0 {
+		t.Errorf("affected_paths = %v, want empty array", decoded[1]["affected_paths"])
+	}
+}
+
+func TestWriteResultJSON(t *testing.T) {
+	candidate := &translator.Candidate{Command: "echo hi"}
+	start := time.Now()
+	result := &executor.SandboxResult{
+		Stdout:    []byte("hi\n"),
+		ExitCode:  0,
+		SandboxID: "abc123",
+		StartTime: start,
+		EndTime:   start.Add(1500 * time.Millisecond),
+	}
+
+	var buf bytes.Buffer
+	if err := writeResultJSON(&buf, candidate, result, nil); err != nil {
+		t.Fatalf("writeResultJSON() error = %v", err)
+	}
+
+	var decoded resultJSON
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if !decoded.Executed || decoded.SandboxID != "abc123" || decoded.Stdout != "hi\n" || decoded.DurationMs != 1500 {
+		t.Errorf("unexpected result: %+v", decoded)
+	}
+
+	buf.Reset()
+	if err := writeResultJSON(&buf, candidate, nil, fmt.Errorf("docker is not available")); err != nil {
+		t.Fatalf("writeResultJSON() error = %v", err)
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if decoded.Executed || decoded.Error != "docker is not available" {
+		t.Errorf("unexpected result: %+v", decoded)
+	}
+}
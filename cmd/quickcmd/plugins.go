@@ -5,8 +5,13 @@ import (
 	"os"
 	"text/tabwriter"
 	
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
 	"github.com/spf13/cobra"
-	"github.com/yourusername/quickcmd/core/plugins"
+	
+	// Built-in plugins register themselves on import
+	_ "github.com/SagheerAkram/QuickCmd/plugins/git"
+	_ "github.com/SagheerAkram/QuickCmd/plugins/k8s"
+	_ "github.com/SagheerAkram/QuickCmd/plugins/terraform"
 )
 
 var pluginsCmd = &cobra.Command{
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
+	"github.com/SagheerAkram/QuickCmd/core/policy"
+	"github.com/spf13/cobra"
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Inspect the command policy",
+}
+
+var policyCheckCmd = &cobra.Command{
+	Use:   "check <command>",
+	Short: "Check whether a command would be allowed",
+	Long: `Runs a shell command through the policy engine and plugin pre-run checks
+without translating or executing it, and prints the decision, the matched
+rule, and any confirmation or approval that would be required. Quote the
+command, or put it after --, so its flags aren't read as quickcmd's.
+
+Exits non-zero if the command would be blocked.`,
+	Example: `  quickcmd policy check "rm -rf /"
+  quickcmd policy check "kubectl delete pod api-0" --risk high --destructive
+  quickcmd policy check --risk high -- find . -name '*.tmp' -delete`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE:         checkPolicy,
+}
+
+func init() {
+	rootCmd.AddCommand(policyCmd)
+	policyCmd.AddCommand(policyCheckCmd)
+
+	policyCheckCmd.Flags().String("risk", "medium", "risk level to evaluate the command at (safe, medium, high)")
+	policyCheckCmd.Flags().Bool("destructive", false, "evaluate the command as destructive")
+
+	// An unquoted command's own flags are read as ours
+	policyCheckCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return fmt.Errorf("%w (quote the command, or put it after --, to pass its flags)", err)
+	})
+}
+
+// policyDecision is the combined policy and plugin verdict for a command
+type policyDecision struct {
+	Policy  *policy.ValidationResult
+	Plugins *plugins.CheckResult // nil if plugins were not consulted
+}
+
+// Allowed reports whether both the policy and the plugins allow the command
+func (d *policyDecision) Allowed() bool {
+	return d.Policy.Allowed && (d.Plugins == nil || d.Plugins.Allowed)
+}
+
+func checkPolicy(cmd *cobra.Command, args []string) error {
+	command := strings.TrimSpace(strings.Join(args, " "))
+	if command == "" {
+		return fmt.Errorf("command is empty")
+	}
+
+	risk, _ := cmd.Flags().GetString("risk")
+	switch plugins.Risk(risk) {
+	case plugins.RiskSafe, plugins.RiskMedium, plugins.RiskHigh:
+	default:
+		return fmt.Errorf("invalid risk level %q (want safe, medium, or high)", risk)
+	}
+	destructive, _ := cmd.Flags().GetBool("destructive")
+
+	policyEngine, err := newPolicyEngine()
+	if err != nil {
+		return err
+	}
+
+	decision := &policyDecision{
+		Policy: policyEngine.Validate(command, risk, destructive),
+	}
+
+	// Plugins only weigh in on commands the policy lets through
+	if decision.Policy.Allowed && !pluginsDisabled() {
+		pluginCtx := pluginContext()
+		pluginCtx.Ctx = cmd.Context()
+		decision.Plugins, err = plugins.PreRunCheckWithPlugins(pluginCtx, &plugins.Candidate{
+			Command:     command,
+			RiskLevel:   plugins.Risk(risk),
+			Destructive: destructive,
+		})
+		if err != nil {
+			return fmt.Errorf("plugin check failed: %w", err)
+		}
+	}
+
+	printPolicyDecision(cmd.OutOrStdout(), command, decision)
+
+	if !decision.Allowed() {
+		return fmt.Errorf("command would be blocked")
+	}
+	return nil
+}
+
+// printPolicyDecision writes a human-readable report of decision
+func printPolicyDecision(out io.Writer, command string, decision *policyDecision) {
+	fmt.Fprintf(out, "%sCommand:%s %s\n\n", colorBold, colorReset, command)
+
+	if decision.Allowed() {
+		fmt.Fprintf(out, "Decision:     %sallowed%s\n", colorGreen, colorReset)
+	} else {
+		fmt.Fprintf(out, "Decision:     %sblocked%s\n", colorRed, colorReset)
+	}
+
+	result := decision.Policy
+	if result.Reason != "" {
+		fmt.Fprintf(out, "Reason:       %s\n", result.Reason)
+	}
+	if result.MatchedRule != "" {
+		fmt.Fprintf(out, "Matched rule: %s\n", result.MatchedRule)
+	}
+	if result.RequiresConfirm {
+		fmt.Fprintf(out, "Confirmation: %s\n", result.ConfirmMessage)
+	}
+
+	check := decision.Plugins
+	if check == nil {
+		return
+	}
+	if !check.Allowed {
+		fmt.Fprintf(out, "Plugin denial: %s\n", check.Reason)
+	}
+	if check.RequiresApproval {
+		fmt.Fprintf(out, "Approval:     %s\n", check.ApprovalMessage)
+	}
+	if len(check.AdditionalChecks) > 0 {
+		fmt.Fprintf(out, "Checks:       %s\n", strings.Join(check.AdditionalChecks, ", "))
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPolicyCheck_Blocked(t *testing.T) {
+	cfg = nil
+
+	out, err := executeCommand(t, "", "policy", "check", "rm -rf /")
+	if err == nil {
+		t.Error("policy check of rm -rf / succeeded, want error")
+	}
+	for _, want := range []string{"blocked", "Prevent deletion of root directory", `rm\s+-rf\s+/`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("policy check output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPolicyCheck_Allowed(t *testing.T) {
+	cfg = nil
+	chdir(t, t.TempDir())
+
+	// The command's own flags follow --, and plugins for other tools don't
+	// weigh in, e.g. git outside a repository
+	out, err := executeCommand(t, "", "policy", "check", "--", "ls", "-la")
+	if err != nil {
+		t.Fatalf("policy check error: %v", err)
+	}
+	if !strings.Contains(out, "allowed") || !strings.Contains(out, "ls -la") {
+		t.Errorf("policy check output:\n%s", out)
+	}
+
+	out, err = executeCommand(t, "", "policy", "check", "find . -delete", "--risk", "high", "--destructive")
+	if err != nil {
+		t.Fatalf("policy check error: %v", err)
+	}
+	if !strings.Contains(out, "HIGH RISK") {
+		t.Errorf("policy check of high-risk command missing confirmation:\n%s", out)
+	}
+}
+
+func TestPolicyCheck_InvalidRisk(t *testing.T) {
+	cfg = nil
+
+	if _, err := executeCommand(t, "", "policy", "check", "ls", "--risk", "extreme"); err == nil || !strings.Contains(err.Error(), "invalid risk") {
+		t.Errorf("policy check --risk extreme error = %v, want invalid risk", err)
+	}
+}
+
+func TestPolicyCheck_PluginDenial(t *testing.T) {
+	cfg = nil
+	chdir(t, t.TempDir())
+
+	out, err := executeCommand(t, "", "policy", "check", "git status")
+	if err == nil {
+		t.Error("policy check of git status outside a repository succeeded, want error")
+	}
+	if !strings.Contains(out, "Plugin denial: Not in a Git repository") {
+		t.Errorf("policy check output missing the git plugin's denial:\n%s", out)
+	}
+}
+
+func TestPolicyCheck_UnquotedFlags(t *testing.T) {
+	cfg = nil
+
+	_, err := executeCommand(t, "", "policy", "check", "ls", "-la")
+	if err == nil || !strings.Contains(err.Error(), "after --") {
+		t.Errorf("policy check ls -la error = %v, want a hint to quote the command", err)
+	}
+}
+
+// chdir changes to dir for the rest of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	original, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(original) })
+}
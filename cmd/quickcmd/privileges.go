@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+	"github.com/spf13/cobra"
+)
+
+// allowRoot permits destructive commands to run directly on the host when
+// quickcmd itself runs as root
+var allowRoot bool
+
+// currentUID returns the effective user ID. Tests replace it to simulate
+// running as root.
+var currentUID = os.Geteuid
+
+// runningAsRoot reports whether quickcmd runs as uid 0. It is always false
+// on Windows, where Geteuid returns -1.
+func runningAsRoot() bool {
+	return currentUID() == 0
+}
+
+// preRun runs before every command
+func preRun(cmd *cobra.Command, args []string) error {
+	warnIfRoot(cmd.ErrOrStderr())
+	return loadConfig(cmd, args)
+}
+
+// warnIfRoot prints a warning to w if quickcmd runs as root, and reports
+// whether it did
+func warnIfRoot(w io.Writer) bool {
+	if !runningAsRoot() {
+		return false
+	}
+
+	fmt.Fprintf(w, "%s%s  RUNNING AS ROOT%s\n", colorRed+colorBold, translator.Symbol(translator.IconWarning), colorReset)
+	fmt.Fprintf(w, "%sEvery command runs with full privileges, beyond what sudo would grant a single command.\n", colorRed)
+	fmt.Fprintf(w, "Run quickcmd as a regular user unless you need root.%s\n\n", colorReset)
+	return true
+}
+
+// checkRootExecution refuses to run a destructive candidate directly on the
+// host as root, unless --allow-root is set. Sandboxed execution is unaffected.
+func checkRootExecution(candidate *translator.Candidate) error {
+	if !runningAsRoot() || !candidate.Destructive || allowRoot {
+		return nil
+	}
+	return fmt.Errorf("refusing to run a destructive command directly on the host as root: %s\n\nUse --sandbox, run as a regular user, or pass --allow-root", candidate.Command)
+}
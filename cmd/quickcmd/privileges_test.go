@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+)
+
+// simulateUID makes quickcmd see uid as its effective user ID for the test
+func simulateUID(t *testing.T, uid int) {
+	t.Helper()
+	original := currentUID
+	currentUID = func() int { return uid }
+	t.Cleanup(func() {
+		currentUID = original
+		allowRoot = false
+	})
+}
+
+func TestWarnIfRoot(t *testing.T) {
+	var buf bytes.Buffer
+	simulateUID(t, 1000)
+	if warnIfRoot(&buf) || buf.Len() != 0 {
+		t.Errorf("warnIfRoot() as uid 1000 printed %q", buf.String())
+	}
+
+	simulateUID(t, 0)
+	if !warnIfRoot(&buf) {
+		t.Fatal("warnIfRoot() as root = false, want true")
+	}
+	if !strings.Contains(buf.String(), "RUNNING AS ROOT") {
+		t.Errorf("warnIfRoot() as root printed %q, want a root warning", buf.String())
+	}
+}
+
+func TestCheckRootExecution(t *testing.T) {
+	destructive := &translator.Candidate{Command: "rm -rf ./build", Destructive: true}
+	safe := &translator.Candidate{Command: "ls -la"}
+
+	simulateUID(t, 1000)
+	if err := checkRootExecution(destructive); err != nil {
+		t.Errorf("checkRootExecution() as uid 1000 error: %v", err)
+	}
+
+	simulateUID(t, 0)
+	err := checkRootExecution(destructive)
+	if err == nil || !strings.Contains(err.Error(), "--allow-root") {
+		t.Errorf("checkRootExecution() of destructive command as root = %v, want refusal mentioning --allow-root", err)
+	}
+	if err := checkRootExecution(safe); err != nil {
+		t.Errorf("checkRootExecution() of safe command as root error: %v", err)
+	}
+
+	allowRoot = true
+	if err := checkRootExecution(destructive); err != nil {
+		t.Errorf("checkRootExecution() with --allow-root error: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/SagheerAkram/QuickCmd/core/learning/store"
+	"github.com/spf13/cobra"
+)
+
+var resetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Clear learned data",
+	Long: `Clears what quickcmd has learned from your usage, for a clean slate.
+
+  --timings      command execution times used to predict durations
+  --calibration  which offered commands you ran, used to rank candidates
+  --suggestions  suggestion feedback, so rejected suggestion types show again
+  --all          all of the above
+
+The audit log is never cleared. You must type RESET to confirm, or pass --yes.`,
+	Args: cobra.NoArgs,
+	RunE: resetRun,
+}
+
+func init() {
+	rootCmd.AddCommand(resetCmd)
+
+	resetCmd.Flags().Bool("timings", false, "clear learned execution times")
+	resetCmd.Flags().Bool("calibration", false, "clear learned candidate rankings")
+	resetCmd.Flags().Bool("suggestions", false, "clear suggestion feedback")
+	resetCmd.Flags().Bool("all", false, "clear all learned data")
+	resetCmd.Flags().Bool("yes", false, "reset without confirmation")
+}
+
+func resetRun(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	timings, _ := cmd.Flags().GetBool("timings")
+	calibration, _ := cmd.Flags().GetBool("calibration")
+	suggestionFeedback, _ := cmd.Flags().GetBool("suggestions")
+
+	var namespaces []string
+	if all || timings {
+		namespaces = append(namespaces, timingsNamespace)
+	}
+	if all || calibration {
+		namespaces = append(namespaces, calibrationNamespace)
+	}
+	if all || suggestionFeedback {
+		namespaces = append(namespaces, suggestionsNamespace)
+	}
+	if len(namespaces) == 0 {
+		return fmt.Errorf("nothing to reset: pass --timings, --calibration, --suggestions or --all")
+	}
+
+	out := cmd.OutOrStdout()
+	if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+		fmt.Fprintf(out, "%sThis clears learned %s. Type RESET to confirm%s\n", colorYellow, strings.Join(namespaces, ", "), colorReset)
+		fmt.Fprint(out, "Confirmation: ")
+
+		input, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		if strings.TrimSpace(input) != "RESET" {
+			fmt.Fprintln(out, "Cancelled.")
+			return nil
+		}
+	}
+
+	learned, err := store.Open(getLearningStorePath())
+	if err != nil {
+		return fmt.Errorf("failed to open learning store: %w", err)
+	}
+	for _, name := range namespaces {
+		if err := learned.Namespace(name).Clear(); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", name, err)
+		}
+		fmt.Fprintf(out, "%s✓ Cleared %s%s\n", colorGreen, name, colorReset)
+	}
+
+	return nil
+}
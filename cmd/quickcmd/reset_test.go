@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SagheerAkram/QuickCmd/core/learning/store"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+)
+
+func TestReset_Timings(t *testing.T) {
+	tempHome(t)
+
+	// Record a run the way a sandboxed execution does
+	var out bytes.Buffer
+	predictor := predictDuration(&out, openLearningStore(&out), "find . -name '*.log'")
+	recordDuration(&out, openLearningStore(&out), predictor, "find . -name '*.log'", 2*time.Second)
+
+	// Offer and run a command, as the run command does
+	candidates := []*translator.Candidate{{Command: "ls -la"}, {Command: "find ."}}
+	learned := openLearningStore(&out)
+	calibration := calibrateCandidates(&out, learned, candidates)
+	recordSelection(&out, learned, calibration, candidates, candidates[1])
+	if out.Len() != 0 {
+		t.Fatalf("unexpected warnings: %q", out.String())
+	}
+
+	// predicted is what the next run would show before executing
+	predicted := func() string {
+		t.Helper()
+		var out bytes.Buffer
+		predictDuration(&out, openLearningStore(&out), "find . -name '*.txt'")
+		return out.String()
+	}
+	if !strings.Contains(predicted(), "Estimated") {
+		t.Fatal("timings not saved before reset")
+	}
+
+	// Without confirmation nothing is cleared
+	if _, err := executeCommand(t, "no\n", "reset", "--timings"); err != nil {
+		t.Fatalf("reset --timings error: %v", err)
+	}
+	if !strings.Contains(predicted(), "Estimated") {
+		t.Error("cancelled reset cleared timings")
+	}
+
+	output, err := executeCommand(t, "RESET\n", "reset", "--timings")
+	if err != nil {
+		t.Fatalf("reset --timings error: %v", err)
+	}
+	if !strings.Contains(output, "Cleared timings") {
+		t.Errorf("reset output = %q", output)
+	}
+	if got := predicted(); got != "" {
+		t.Errorf("prediction after reset = %q, want none", got)
+	}
+
+	// Only the requested data is cleared
+	learned, _ = store.Open(getLearningStorePath())
+	if keys := learned.Namespace(calibrationNamespace).Keys(); len(keys) != 1 {
+		t.Errorf("reset --timings cleared calibration: keys = %v", keys)
+	}
+
+	if _, err := executeCommand(t, "", "reset", "--all", "--yes"); err != nil {
+		t.Fatalf("reset --all error: %v", err)
+	}
+	learned, _ = store.Open(getLearningStorePath())
+	if keys := learned.Namespace(calibrationNamespace).Keys(); len(keys) != 0 {
+		t.Errorf("reset --all left calibration: keys = %v", keys)
+	}
+
+	if _, err := executeCommand(t, "", "reset"); err == nil {
+		t.Error("reset with no flags should fail")
+	}
+}
@@ -2,25 +2,44 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/user"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 	
+	"github.com/SagheerAkram/QuickCmd/core/audit"
+	"github.com/SagheerAkram/QuickCmd/core/executor"
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
+	"github.com/SagheerAkram/QuickCmd/core/policy"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
 	"github.com/spf13/cobra"
-	"github.com/yourusername/quickcmd/core/audit"
-	"github.com/yourusername/quickcmd/core/executor"
-	"github.com/yourusername/quickcmd/core/policy"
-	"github.com/yourusername/quickcmd/core/translator"
 )
 
 var (
-	dryRun  bool
-	sandbox bool
-	yes     bool
+	dryRun        bool
+	sandbox       bool
+	yes           bool
+	noSuggestions bool
+	noPlugins     bool
+	noSimulate    bool
+	jsonOutput    bool
+	mountSpecs    []string
+	headLines     int
+	filterNames   []string
+	expectFile    string
+	categoryName  string
 )
 
+// humanOut receives human-readable progress output. In --json mode it is
+// redirected to stderr so stdout only carries JSON.
+var humanOut io.Writer = os.Stdout
+
 var runCmd = &cobra.Command{
 	Use:   "run [prompt]",
 	Short: "Translate and optionally execute a command",
@@ -36,6 +55,17 @@ func init() {
 	runCmd.Flags().BoolVar(&dryRun, "dry-run", true, "show commands without executing")
 	runCmd.Flags().BoolVar(&sandbox, "sandbox", false, "execute in isolated sandbox")
 	runCmd.Flags().BoolVar(&yes, "yes", false, "execute without confirmation (dangerous!)")
+	runCmd.Flags().StringArrayVar(&mountSpecs, "mount", nil, "extra sandbox mount as source:target[:ro] (repeatable)")
+	runCmd.Flags().IntVar(&headLines, "head", 0, "show only the first N lines of command output (0 shows all)")
+	runCmd.Flags().StringSliceVar(&filterNames, "filter", nil, "transform command output with filters, applied in order: "+outputFilterList())
+	runCmd.Flags().BoolVar(&noSimulate, "no-simulate", false, "skip the dry run before --yes runs a command that supports one, e.g. kubectl apply")
+	runCmd.Flags().BoolVar(&allowRoot, "allow-root", false, "allow destructive commands to run directly on the host when quickcmd runs as root")
+	runCmd.Flags().StringVar(&envName, "env", "", "generate commands for a configured environment, e.g. dev or prod")
+	runCmd.Flags().StringVar(&categoryName, "category", "", "only show candidates in this category, e.g. git, file or k8s")
+	runCmd.Flags().StringVar(&expectFile, "expect", "", "compare command output with a baseline file, failing if it differs (requires --sandbox)")
+	rootCmd.PersistentFlags().BoolVar(&noSuggestions, "no-suggestions", false, "don't show command suggestions")
+	rootCmd.PersistentFlags().BoolVar(&noPlugins, "no-plugins", false, "translate with core templates only, skipping all plugins")
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "print candidates and execution results as JSON")
 	
 	// Make run the default command
 	rootCmd.RunE = func(cmd *cobra.Command, args []string) error {
@@ -47,29 +77,65 @@ func init() {
 }
 
 func runCommand(cmd *cobra.Command, args []string) error {
-	prompt := strings.Join(args, " ")
+	prompt := strings.TrimSpace(strings.Join(args, " "))
+	
+	// Reject unknown filters before anything runs
+	if _, err := executor.LookupOutputFilters(filterNames); err != nil {
+		return err
+	}
+	
+	// Read the baseline before anything runs
+	baseline, err := loadBaseline()
+	if err != nil {
+		return err
+	}
 	
 	// Initialize translator and policy engine
 	trans := translator.New()
-	policyEngine := policy.NewEngine()
+	policyEngine, err := newPolicyEngine()
+	if err != nil {
+		return err
+	}
+	
+	// Plugins pin their candidates to the selected environment
+	if environment, err = selectEnvironment(envName); err != nil {
+		return err
+	}
 	
 	// Translate prompt to candidates
-	candidates, err := trans.Translate(prompt)
+	candidates, err := translateInCategory(cmd.Context(), trans, prompt, !pluginsDisabled(), categoryName)
+	if err == translator.ErrNoMatch && categoryName != "" {
+		return fmt.Errorf("no %s commands found for: %q\n\nTry a different --category, or leave it out to see every match", categoryName, prompt)
+	}
 	if err != nil {
-		if err == translator.ErrNoMatch {
-			return fmt.Errorf("no matching commands found for: %q\n\nTry being more specific or use different keywords", prompt)
-		}
-		return fmt.Errorf("translation error: %w", err)
+		return translationError(prompt, err)
 	}
+	applyEnvironmentPolicy(candidates, environment)
+	
+	if jsonOutput {
+		return runJSON(prompt, candidates, policyEngine, baseline)
+	}
+	
+	// Rank commands the user rarely runs lower
+	learned := openLearningStore(humanOut)
+	calibration := calibrateCandidates(humanOut, learned, candidates)
 	
 	// Display candidates
-	fmt.Printf("\n%s Candidates for: %s%s\n\n", colorBold, prompt, colorReset)
+	fmt.Printf("\n%s Candidates for: %s%s\n", colorBold, prompt, colorReset)
+	if environment != nil {
+		fmt.Printf("Environment: %s\n", environmentLabel(environment))
+	}
+	fmt.Println()
 	
 	for i, candidate := range candidates {
 		displayCandidate(i+1, candidate)
 		fmt.Println()
 	}
 	
+	if !noSuggestions {
+		printRunSuggestions(humanOut, learned, prompt)
+	}
+	
 	// Interactive selection
 	if dryRun && !sandbox && !yes {
 		fmt.Println(colorYellow + "ℹ️  Dry-run mode: commands will not be executed" + colorReset)
@@ -98,6 +164,21 @@ func runCommand(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("❌ Command blocked by policy: %s", result.Reason)
 	}
 	
+	// Approval can't be given in advance
+	requirePluginApproval(result, selected)
+	if result.RequiresApproval && !result.RequiresApprover && yes {
+		return fmt.Errorf("❌ Command requires approval (rerun without --yes to confirm)")
+	}
+	
+	warnPlaintextSecrets(humanOut, prompt, selected.Command)
+	
+	// Direct execution as root has nothing to contain a mistake
+	if yes && !sandbox {
+		if err := checkRootExecution(selected); err != nil {
+			return err
+		}
+	}
+	
 	// Check if confirmation required
 	if result.RequiresConfirm && !yes {
 		if !promptConfirmation(result.ConfirmMessage) {
@@ -106,18 +187,37 @@ func runCommand(cmd *cobra.Command, args []string) error {
 		}
 	}
 	
+	// An approver has to grant it before it runs
+	if result.RequiresApprover && (sandbox || yes) {
+		if err := obtainApproval(humanOut, prompt, selected, result.Reason); err != nil {
+			return fmt.Errorf("❌ %w", err)
+		}
+	}
+	
+	if sandbox || yes {
+		recordSelection(humanOut, learned, calibration, candidates, selected)
+	}
+	
 	// Execute command
 	if sandbox {
-		if err := executeInSandbox(selected, policyEngine); err != nil {
+		result, err := executeInSandbox(prompt, selected, policyEngine)
+		if err != nil {
 			return err
 		}
+		if baseline != nil {
+			return verifyBaseline(humanOut, expectFile, baseline, result)
+		}
 	} else if yes {
-		if err := executeDirect(selected, policyEngine); err != nil {
+		// Simulate on the host only when the command itself will run there
+		if err := simulateCandidate(cmd.Context(), humanOut, selected, policyEngine); err != nil {
+			return err
+		}
+		if err := executeDirect(prompt, selected, policyEngine); err != nil {
 			return err
 		}
 	} else {
 		// Dry-run mode - just show what would be executed
-		fmt.Println(colorGreen + "✓ Command validated and ready to execute" + colorReset)
+		fmt.Println(colorGreen + translator.Symbol(translator.IconCheck) + " Command validated and ready to execute" + colorReset)
 		fmt.Println("\nTo execute:")
 		fmt.Println("  --sandbox : Run in isolated Docker container (recommended)")
 		fmt.Println("  --yes     : Run directly on host (dangerous!)")
@@ -126,57 +226,60 @@ func runCommand(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// executeInSandbox executes a command in a Docker sandbox
-func executeInSandbox(candidate *translator.Candidate, policyEngine *policy.Engine) error {
-	fmt.Println(colorCyan + "🐳 Preparing sandbox environment..." + colorReset)
-	
-	// Check if Docker is available
-	if !executor.IsDockerAvailable() {
-		fmt.Println(colorRed + "❌ Docker is not available" + colorReset)
-		fmt.Println("\nDocker is required for sandbox execution.")
-		fmt.Println("Please install Docker: https://docs.docker.com/get-docker/")
-		fmt.Println("\nFalling back to dry-run mode.")
-		return nil
+// executeInSandbox executes a command in a Docker or Podman sandbox. It
+// returns a nil result if neither is available.
+func executeInSandbox(prompt string, candidate *translator.Candidate, policyEngine *policy.Engine) (*executor.SandboxResult, error) {
+	fmt.Fprintln(humanOut, colorCyan + "🐳 Preparing sandbox environment..." + colorReset)
+	
+	// Find a container runtime
+	runner, err := executor.NewSandboxRunner(executor.WithPullProgress(pullProgressPrinter(humanOut)))
+	if err != nil {
+		fmt.Fprintln(humanOut, colorRed + "❌ No container runtime is available" + colorReset)
+		fmt.Fprintln(humanOut, "\nDocker or Podman is required for sandbox execution.")
+		fmt.Fprintln(humanOut, "Install Docker (https://docs.docker.com/get-docker/) or Podman (https://podman.io/docs/installation)")
+		fmt.Fprintln(humanOut, "\nFalling back to dry-run mode.")
+		return nil, nil
 	}
+	defer runner.Close()
 	
-	// Get Docker info
-	if info, err := executor.GetDockerInfo(); err == nil {
-		fmt.Printf("Using: %s\n", info)
+	if info, err := executor.DescribeRunner(runner); err == nil {
+		fmt.Fprintf(humanOut, "Using: %s\n", info)
 	}
 	
-	// Create snapshotter
-	snapshotter := executor.NewSnapshotter()
+	// Validate extra mounts before touching anything
+	extraMounts, err := sandboxMounts(mountSpecs, executor.DefaultSensitiveMounts(), mountConfirmer(yes, jsonOutput, os.Stderr))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNetworkTargets(candidate.RiskLevel, candidate.NetworkTargets); err != nil {
+		return nil, err
+	}
 	
-	// Create snapshot if destructive
-	var snapshot *executor.SnapshotMetadata
+	// Stop a burst of destructive commands, e.g. from a runaway script
+	var breaker *policy.CircuitBreaker
 	if candidate.Destructive {
-		fmt.Println(colorYellow + "📸 Creating pre-run snapshot..." + colorReset)
-		
-		workingDir, _ := os.Getwd()
-		snap, err := snapshotter.CreateSnapshot(workingDir, candidate.AffectedPaths)
+		breaker, err = openCircuitBreaker()
 		if err != nil {
-			fmt.Printf(colorYellow+"⚠️  Snapshot creation failed: %v\n"+colorReset, err)
-		} else {
-			snapshot = snap
-			if snap.Reversible {
-				fmt.Printf(colorGreen+"✓ Snapshot created: %s\n"+colorReset, snap.Location)
-			}
+			return nil, err
+		}
+		if err := checkCircuitBreaker(breaker); err != nil {
+			return nil, err
 		}
 	}
 	
-	// Create Docker runner
-	runner, err := executor.NewDockerRunner()
-	if err != nil {
-		return fmt.Errorf("failed to create Docker runner: %w", err)
-	}
-	defer runner.Close()
-	
 	// Get working directory
 	workingDir, _ := os.Getwd()
 	
+	// Create snapshot if destructive
+	var snapshot *executor.SnapshotMetadata
+	if candidate.Destructive {
+		snapshot = createSnapshot(humanOut, workingDir, candidate)
+	}
+	
 	// Configure sandbox options
 	opts := executor.SandboxOptions{
-		Image:         "alpine:latest",
+		Image:         sandboxImage(),
+		Images:        sandboxImageOverrides(),
 		CPULimit:      0.5,
 		MemoryLimit:   256 * 1024 * 1024,
 		PidsLimit:     64,
@@ -191,84 +294,455 @@ func executeInSandbox(candidate *translator.Candidate, policyEngine *policy.Engi
 			},
 		},
 	}
+	opts.Mounts = append(opts.Mounts, extraMounts...)
+	if opts.Image == "" {
+		opts.Image = opts.SelectImage(candidate.Command)
+	}
+	fmt.Fprintf(humanOut, "Sandbox image: %s\n", opts.Image)
+	if profile := applySandboxProfile(&opts, candidate.RiskLevel); profile != "" {
+		fmt.Fprintf(humanOut, "Sandbox profile: %s (%s risk)\n", profile, candidate.RiskLevel)
+	}
+	if len(candidate.NetworkTargets) > 0 {
+		opts.NetworkAllowlist = candidate.NetworkTargets
+		fmt.Fprintf(humanOut, "Network allowlist: %s\n", strings.Join(opts.NetworkAllowlist, ", "))
+	}
+	
+	learned := openLearningStore(humanOut)
+	predictor := predictDuration(humanOut, learned, candidate.Command)
 	
-	fmt.Println(colorCyan + "🚀 Executing in sandbox..." + colorReset)
+	fmt.Fprintln(humanOut, colorCyan + "🚀 Executing in sandbox..." + colorReset)
 	startTime := time.Now()
 	
 	// Execute in sandbox
 	result, err := runner.RunInSandbox(candidate.Command, opts)
 	duration := time.Since(startTime)
+	if result == nil {
+		return nil, fmt.Errorf("sandbox execution failed: %w", err)
+	}
+	if breaker != nil {
+		recordDestructiveRun(breaker)
+	}
+	recordDuration(humanOut, learned, predictor, candidate.Command, duration)
+	
+	// Filtered output is what is shown and audited
+	if len(filterNames) > 0 {
+		filters, _ := executor.LookupOutputFilters(filterNames)
+		filtered, filterErr := executor.ApplyOutputFilters(filters, result.Stdout)
+		if filterErr != nil {
+			fmt.Fprintf(humanOut, colorYellow+"%s  %v, showing unfiltered output\n"+colorReset, translator.Symbol(translator.IconWarning), filterErr)
+		} else {
+			result.Stdout = filtered
+		}
+	}
 	
 	// Log to audit database
 	auditStore, auditErr := audit.NewSQLiteStore(getAuditDBPath())
 	if auditErr == nil {
 		defer auditStore.Close()
 		
-		record := &audit.RunRecord{
-			Timestamp:       time.Now().Format(time.RFC3339),
-			Prompt:          "", // TODO: Pass prompt from caller
-			SelectedCommand: candidate.Command,
-			SandboxID:       result.SandboxID,
-			ExitCode:        result.ExitCode,
-			Stdout:          result.Stdout,
-			Stderr:          result.Stderr,
-			RiskLevel:       string(candidate.RiskLevel),
-			Snapshot:        audit.EncodeSnapshot(snapshot),
-			Executed:        true,
-			DurationMs:      duration.Milliseconds(),
-		}
+		record := newRunRecord(prompt, candidate, result, snapshot, duration)
 		
 		if logErr := auditStore.LogExecution(record); logErr != nil {
-			fmt.Printf(colorYellow+"⚠️  Failed to log execution: %v\n"+colorReset, logErr)
+			fmt.Fprintf(humanOut, colorYellow+"%s  Failed to log execution: %v\n"+colorReset, translator.Symbol(translator.IconWarning), logErr)
 		}
 	}
 	
 	// Display results
-	fmt.Printf("\n%s Execution completed in %v%s\n", colorBold, duration.Round(time.Millisecond), colorReset)
-	fmt.Printf("Sandbox ID: %s\n", result.SandboxID)
-	fmt.Printf("Exit Code: %d\n", result.ExitCode)
+	fmt.Fprintf(humanOut, "\n%s Execution completed in %v%s\n", colorBold, duration.Round(time.Millisecond), colorReset)
+	fmt.Fprintf(humanOut, "Sandbox ID: %s\n", result.SandboxID)
+	fmt.Fprintf(humanOut, "Exit Code: %d\n", result.ExitCode)
+	if usage := result.ResourceUsage(); usage != "" {
+		fmt.Fprintf(humanOut, "Resources: %s\n", usage)
+	}
 	
 	if len(result.Stdout) > 0 {
-		fmt.Printf("\n%sOutput:%s\n%s\n", colorBold, colorReset, string(result.Stdout))
+		fmt.Fprintf(humanOut, "\n%sOutput:%s\n", colorBold, colorReset)
+		writeHead(humanOut, result.Stdout, headLines)
 	}
 	
 	if len(result.Stderr) > 0 {
-		fmt.Printf("\n%sErrors:%s\n%s\n", colorRed, colorReset, string(result.Stderr))
+		fmt.Fprintf(humanOut, "\n%sErrors:%s\n", colorRed, colorReset)
+		writeHead(humanOut, result.Stderr, headLines)
 	}
 	
 	if result.ExitCode == 0 {
-		fmt.Println(colorGreen + "✓ Command executed successfully" + colorReset)
+		fmt.Fprintln(humanOut, colorGreen + translator.Symbol(translator.IconCheck) + " Command executed successfully" + colorReset)
 	} else {
-		fmt.Printf(colorRed+"❌ Command failed with exit code %d\n"+colorReset, result.ExitCode)
+		fmt.Fprintf(humanOut, colorRed+"❌ Command failed with exit code %d\n"+colorReset, result.ExitCode)
 	}
 	
 	// Show undo option if snapshot was created
 	if snapshot != nil && snapshot.Reversible {
-		fmt.Printf("\n%sUndo available:%s %s\n", colorYellow, colorReset, snapshot.RestoreCmd)
+		fmt.Fprintf(humanOut, "\n%sUndo available:%s %s\n", colorYellow, colorReset, snapshot.RestoreCmd)
 	}
 	
-	return nil
+	return result, nil
+}
+
+// loadBaseline reads the --expect baseline, or returns nil without one
+func loadBaseline() ([]byte, error) {
+	if expectFile == "" {
+		return nil, nil
+	}
+	if !sandbox {
+		return nil, fmt.Errorf("--expect requires --sandbox to execute the command")
+	}
+	
+	baseline, err := os.ReadFile(expectFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	return baseline, nil
+}
+
+// verifyBaseline compares the command's output with the baseline read from
+// path, writing a diff to w and returning an error if they differ
+func verifyBaseline(w io.Writer, path string, baseline []byte, result *executor.SandboxResult) error {
+	if result == nil {
+		return fmt.Errorf("cannot compare with baseline %s: the command was not executed", path)
+	}
+	
+	diff := executor.DiffOutput(baseline, result.Stdout)
+	if diff == "" {
+		fmt.Fprintf(w, colorGreen+"%s Output matches baseline %s\n"+colorReset, translator.Symbol(translator.IconCheck), path)
+		return nil
+	}
+	
+	fmt.Fprintf(w, "\n%sOutput differs from baseline %s:%s\n%s", colorRed, path, colorReset, diff)
+	return fmt.Errorf("output differs from baseline %s", path)
+}
+
+// pullProgressPrinter returns a sink that shows image pull progress on a
+// single line of w, redrawn as the pull advances
+func pullProgressPrinter(w io.Writer) func(executor.PullProgress) {
+	return func(p executor.PullProgress) {
+		bar := translator.Bar(0, 20)
+		if percent := p.Percent(); percent >= 0 {
+			bar = translator.Bar(percent/5, 20)
+		}
+		
+		// Clear what's left of a longer previous line
+		fmt.Fprintf(w, "\r\033[K%s %s", bar, p)
+		if p.Done {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// outputFilterList describes the available output filters for --filter help
+func outputFilterList() string {
+	var names []string
+	for _, filter := range executor.OutputFilters() {
+		names = append(names, filter.Name())
+	}
+	return strings.Join(names, ", ")
+}
+
+// writeHead writes the first n lines of output, followed by a notice of how
+// many lines were cut. n <= 0 writes all of it.
+func writeHead(w io.Writer, output []byte, n int) {
+	text := strings.TrimSuffix(string(output), "\n")
+	lines := strings.Split(text, "\n")
+	if n <= 0 || len(lines) <= n {
+		fmt.Fprintln(w, text)
+		return
+	}
+	
+	fmt.Fprintln(w, strings.Join(lines[:n], "\n"))
+	fmt.Fprintf(w, "%s... %d more lines not shown (full output is saved in the audit log)%s\n", colorYellow, len(lines)-n, colorReset)
+}
+
+// warnPlaintextSecrets warns if any of texts embeds what looks like a
+// credential. It reports whether a warning was shown.
+func warnPlaintextSecrets(w io.Writer, texts ...string) bool {
+	redactor := policy.NewSecretRedactor()
+	
+	var findings []string
+	for _, text := range texts {
+		findings = append(findings, redactor.Scan(text)...)
+	}
+	if len(findings) == 0 {
+		return false
+	}
+	
+	fmt.Fprintf(w, "%s%s  Possible plaintext credentials: %s%s\n", colorYellow, translator.Symbol(translator.IconWarning), strings.Join(findings, ", "), colorReset)
+	fmt.Fprintln(w, "   Credentials in commands end up in shell history and process lists.")
+	fmt.Fprintln(w, "   Use environment variables or your tool's credential config (e.g. ~/.aws/credentials) instead.")
+	fmt.Fprintln(w)
+	return true
+}
+
+// mountConfirmer returns how sensitive mounts are confirmed: by prompting,
+// refused with --json, and accepted with --yes, which still prints the
+// warning to stderr so the risk isn't taken silently
+func mountConfirmer(yes, jsonOutput bool, stderr io.Writer) func(warning string) bool {
+	switch {
+	case yes:
+		return func(warning string) bool {
+			fmt.Fprintf(stderr, "%s\nMounting it anyway because of --yes.\n", warning)
+			return true
+		}
+	case jsonOutput:
+		return func(string) bool { return false }
+	default:
+		return func(warning string) bool {
+			return promptConfirmation(warning + "\nType 'CONFIRM' to mount it anyway:")
+		}
+	}
+}
+
+// sandboxMounts parses --mount specs. Docker socket mounts are rejected and
+// sensitive host paths are only mounted if confirm approves them.
+func sandboxMounts(specs []string, sensitivePaths []string, confirm func(warning string) bool) ([]executor.Mount, error) {
+	var mounts []executor.Mount
+	for _, spec := range specs {
+		mount, err := executor.ParseMount(spec)
+		if err != nil {
+			return nil, err
+		}
+		
+		check, err := executor.CheckMount(mount, sensitivePaths)
+		if err != nil {
+			return nil, err
+		}
+		
+		if check.Sensitive {
+			warning := fmt.Sprintf("%s  %s. Mounting it breaks sandbox isolation.", translator.Symbol(translator.IconWarning), check.Reason)
+			if !confirm(warning) {
+				return nil, fmt.Errorf("mount of %s not confirmed: %s, so mounting it would give the sandboxed command access to it on the host", mount.Source, check.Reason)
+			}
+		}
+		
+		mounts = append(mounts, mount)
+	}
+	return mounts, nil
+}
+
+// newRunRecord builds the audit record for a sandboxed execution
+func newRunRecord(prompt string, candidate *translator.Candidate, result *executor.SandboxResult, snapshot *executor.SnapshotMetadata, duration time.Duration) *audit.RunRecord {
+	return &audit.RunRecord{
+		Timestamp:       time.Now().Format(time.RFC3339),
+		Prompt:          prompt,
+		SelectedCommand: candidate.Command,
+		SandboxID:       result.SandboxID,
+		ExitCode:        result.ExitCode,
+		Stdout:          result.Stdout,
+		Stderr:          result.Stderr,
+		RiskLevel:       string(candidate.RiskLevel),
+		Snapshot:        audit.EncodeSnapshot(snapshot),
+		Executed:        true,
+		DurationMs:      duration.Milliseconds(),
+	}
+}
+
+// createSnapshot backs up what a destructive candidate is about to change.
+// Commands the undo engine knows are backed up with the configured backup
+// backend; others get a git or filesystem snapshot. It returns nil if no
+// snapshot could be taken.
+func createSnapshot(w io.Writer, workingDir string, candidate *translator.Candidate) *executor.SnapshotMetadata {
+	fmt.Fprintln(w, colorYellow + "📸 Creating pre-run snapshot..." + colorReset)
+	
+	undo, err := newUndoEngine().CreateUndo(candidate.Command, candidate.AffectedPaths)
+	if err != nil {
+		fmt.Fprintf(w, colorYellow+"%s  Undo backup failed: %v\n"+colorReset, translator.Symbol(translator.IconWarning), err)
+	} else if undo.CanUndo {
+		location := undo.BackupLocation
+		if location == "" {
+			location = undo.UndoCommand
+		}
+		fmt.Fprintf(w, colorGreen+"%s Backup created: %s\n"+colorReset, translator.Symbol(translator.IconCheck), location)
+		return &executor.SnapshotMetadata{
+			Type:          "undo",
+			Location:      location,
+			Timestamp:     undo.CreatedAt,
+			AffectedPaths: undo.AffectedPaths,
+			Reversible:    true,
+			RestoreCmd:    undo.UndoCommand,
+			WorkingDir:    workingDir,
+			Undo:          undo,
+		}
+	}
+	
+	snapshot, err := executor.NewSnapshotter().CreateSnapshot(workingDir, candidate.AffectedPaths)
+	if err != nil {
+		fmt.Fprintf(w, colorYellow+"%s  Snapshot creation failed: %v\n"+colorReset, translator.Symbol(translator.IconWarning), err)
+		return nil
+	}
+	if snapshot.Reversible {
+		fmt.Fprintf(w, colorGreen+"%s Snapshot created: %s\n"+colorReset, translator.Symbol(translator.IconCheck), snapshot.Location)
+	}
+	return snapshot
 }
 
 // executeDirect executes a command directly on the host (dangerous!)
-func executeDirect(candidate *translator.Candidate, policyEngine *policy.Engine) error {
-	fmt.Println(colorRed + "⚠️  EXECUTING DIRECTLY ON HOST" + colorReset)
-	fmt.Println(colorRed + "This bypasses sandbox isolation!" + colorReset)
+func executeDirect(prompt string, candidate *translator.Candidate, policyEngine *policy.Engine) error {
+	fmt.Fprintln(humanOut, colorRed + translator.Symbol(translator.IconWarning) + "  EXECUTING DIRECTLY ON HOST" + colorReset)
+	fmt.Fprintln(humanOut, colorRed + "This bypasses sandbox isolation!" + colorReset)
 	
 	// TODO: Implement direct execution
-	fmt.Println(colorYellow + "Direct execution not yet implemented" + colorReset)
-	fmt.Printf("Would execute: %s\n", candidate.Command)
+	fmt.Fprintln(humanOut, colorYellow + "Direct execution not yet implemented" + colorReset)
+	fmt.Fprintf(humanOut, "Would execute: %s\n", candidate.Command)
 	
 	return nil
 }
 
-// getAuditDBPath returns the path to the audit database
+// getAuditDBPath returns the path to the audit database. If its directory
+// is not writable it falls back to a temporary directory, and then to an
+// in-memory database, rather than failing.
 func getAuditDBPath() string {
-	homeDir, err := os.UserHomeDir()
+	path := filepath.Join(quickcmdDir(), "audit.db")
+	if cfg != nil && cfg.AuditDBPath != "" {
+		path = cfg.AuditDBPath
+	}
+	
+	if path = storagePath(path); path == "" {
+		return memoryAuditDB
+	}
+	return path
+}
+
+// pluginsDisabled reports whether plugins are turned off by --no-plugins or
+// the no_plugins config option
+func pluginsDisabled() bool {
+	return noPlugins || (cfg != nil && cfg.NoPlugins)
+}
+
+// translateCandidates translates prompt with the core templates and, if
+// usePlugins is set, the enabled plugins. Candidates are ordered by
+// confidence; ErrNoMatch is returned only if neither produced any. Cancelling
+// ctx stops translation with ctx.Err().
+func translateCandidates(ctx context.Context, trans *translator.Translator, prompt string, usePlugins bool) ([]*translator.Candidate, error) {
+	candidates, err := trans.TranslateContext(ctx, prompt)
+	if !usePlugins || err == translator.ErrEmptyPrompt {
+		return candidates, err
+	}
+	if err != nil && err != translator.ErrNoMatch {
+		return nil, err
+	}
+	
+	pluginCtx := pluginContext()
+	pluginCtx.Ctx = ctx
+	pluginCandidates, err := plugins.TranslateWithPlugins(pluginCtx, prompt, nil)
 	if err != nil {
-		return filepath.Join(os.TempDir(), "quickcmd", "audit.db")
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("plugin translation failed: %w", err)
+	}
+	for _, pc := range pluginCandidates {
+		candidates = append(candidates, fromPluginCandidate(pc))
 	}
-	return filepath.Join(homeDir, ".quickcmd", "audit.db")
+	
+	if len(candidates) == 0 {
+		return nil, translator.ErrNoMatch
+	}
+	
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	
+	return candidates, nil
+}
+
+// translateInCategory is translateCandidates keeping only candidates in
+// category, matched case-insensitively. An empty category keeps them all.
+func translateInCategory(ctx context.Context, trans *translator.Translator, prompt string, usePlugins bool, category string) ([]*translator.Candidate, error) {
+	if category == "" {
+		return translateCandidates(ctx, trans, prompt, usePlugins)
+	}
+	category = strings.ToLower(category)
+	
+	// Narrow the templates first, so matches from other categories can't
+	// push this category's out of the translator's top three
+	trans = translator.NewWithTemplates(trans.GetTemplatesByCategory(category))
+	
+	candidates, err := translateCandidates(ctx, trans, prompt, usePlugins)
+	if err != nil {
+		return nil, err
+	}
+	
+	var filtered []*translator.Candidate
+	for _, c := range candidates {
+		if strings.ToLower(c.Category) == category {
+			filtered = append(filtered, c)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil, translator.ErrNoMatch
+	}
+	return filtered, nil
+}
+
+// translationError explains why prompt produced no candidates
+func translationError(prompt string, err error) error {
+	if err == translator.ErrEmptyPrompt {
+		return fmt.Errorf("prompt is empty\n\nDescribe what you want to do, e.g. quickcmd \"find large files\"")
+	}
+	if err == translator.ErrControlCharacters {
+		return fmt.Errorf("prompt contains control characters\n\nDescribe what you want to do in plain text")
+	}
+	if err == translator.ErrNoMatch {
+		return fmt.Errorf("no matching commands found for: %q\n\nTry being more specific or use different keywords", prompt)
+	}
+	var blocked *translator.BlockedPromptError
+	if errors.As(err, &blocked) {
+		return fmt.Errorf("%w\n\n%s Security note: %s", err, translator.Symbol(translator.IconWarning), blocked.Blocked.SecurityNote)
+	}
+	return fmt.Errorf("translation error: %w", err)
+}
+
+// pluginContext describes the current invocation to plugins
+func pluginContext() plugins.Context {
+	ctx := plugins.Context{Timestamp: time.Now(), Environment: environment}
+	ctx.WorkingDir, _ = os.Getwd()
+	if current, err := user.Current(); err == nil {
+		ctx.User = current.Username
+	}
+	return ctx
+}
+
+// toPluginCandidate converts a candidate for plugin checks. The plugin that
+// produced it, if any, is recovered from its Source.
+func toPluginCandidate(c *translator.Candidate) *plugins.Candidate {
+	pc := &plugins.Candidate{
+		Command:         c.Command,
+		Explanation:     c.Explanation,
+		Confidence:      c.Confidence,
+		RiskLevel:       plugins.Risk(c.RiskLevel),
+		AffectedPaths:   c.AffectedPaths,
+		NetworkTargets:  c.NetworkTargets,
+		Destructive:     c.Destructive,
+		RequiresConfirm: c.RequiresConfirm,
+		Category:        c.Category,
+		SimulateCommand: c.SimulateCommand,
+	}
+	if name, ok := strings.CutPrefix(c.Source, translator.PluginSource("")); ok {
+		pc.PluginName = name
+	}
+	return pc
+}
+
+// fromPluginCandidate converts a plugin candidate for display and execution
+func fromPluginCandidate(pc *plugins.Candidate) *translator.Candidate {
+	c := &translator.Candidate{
+		Command:         pc.Command,
+		Explanation:     pc.Explanation,
+		Confidence:      pc.Confidence,
+		RiskLevel:       translator.Risk(pc.RiskLevel),
+		AffectedPaths:   pc.AffectedPaths,
+		NetworkTargets:  pc.NetworkTargets,
+		Destructive:     pc.Destructive,
+		RequiresConfirm: pc.RequiresConfirm,
+		DocLinks:        pc.DocLinks,
+		Source:          translator.PluginSource(pc.PluginName),
+		Category:        pc.Category,
+		SimulateCommand: pc.SimulateCommand,
+	}
+	for _, step := range pc.Breakdown {
+		c.Breakdown = append(c.Breakdown, translator.Step{Description: step.Description, Command: step.Command})
+	}
+	c.AssessRedirections()
+	return c
 }
 
 func displayCandidate(num int, c *translator.Candidate) {
@@ -279,6 +753,13 @@ func displayCandidate(num int, c *translator.Candidate) {
 	// Command (copyable)
 	fmt.Printf("   %s%s%s\n", colorCyan, c.Command, colorReset)
 	
+	if c.Source != "" {
+		fmt.Printf("   Source: %s\n", c.Source)
+	}
+	if c.Category != "" {
+		fmt.Printf("   Category: %s\n", c.Category)
+	}
+	
 	// Confidence with detailed breakdown
 	confidenceBar := makeProgressBar(c.Confidence, 20)
 	fmt.Printf("   Confidence: %s %d%%\n", confidenceBar, c.Confidence)
@@ -306,24 +787,24 @@ func displayCandidate(num int, c *translator.Candidate) {
 	// Warnings from breakdown
 	if len(breakdown.Warnings) > 0 {
 		for _, warning := range breakdown.Warnings {
-			fmt.Printf("   %s⚠️  %s%s\n", colorYellow, warning, colorReset)
+			fmt.Printf("   %s%s  %s%s\n", colorYellow, translator.Symbol(translator.IconWarning), warning, colorReset)
 		}
 	}
 	
 	// Tips from breakdown
 	if len(breakdown.Tips) > 0 {
 		for _, tip := range breakdown.Tips {
-			fmt.Printf("   %s💡 %s%s\n", colorCyan, tip, colorReset)
+			fmt.Printf("   %s%s %s%s\n", colorCyan, translator.Symbol(translator.IconTip), tip, colorReset)
 		}
 	}
 	
 	// Warnings
 	if c.Destructive {
-		fmt.Printf("   %s⚠️  DESTRUCTIVE OPERATION%s\n", colorRed, colorReset)
+		fmt.Printf("   %s%s  DESTRUCTIVE OPERATION%s\n", colorRed, translator.Symbol(translator.IconWarning), colorReset)
 	}
 	
 	if c.RequiresConfirm {
-		fmt.Printf("   %s🔒 Requires confirmation%s\n", colorYellow, colorReset)
+		fmt.Printf("   %s%s Requires confirmation%s\n", colorYellow, translator.Symbol(translator.IconLock), colorReset)
 	}
 	
 	// Breakdown
@@ -389,10 +870,7 @@ func promptConfirmation(message string) bool {
 }
 
 func makeProgressBar(value, width int) string {
-	filled := (value * width) / 100
-	empty := width - filled
-	
-	bar := strings.Repeat("█", filled) + strings.Repeat("░", empty)
+	bar := translator.Bar((value*width)/100, width)
 	
 	// Color based on value
 	if value >= 80 {
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/SagheerAkram/QuickCmd/core/cmdparse"
+	"github.com/SagheerAkram/QuickCmd/core/policy"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+)
+
+// runSimulation runs a candidate's simulation on the host, where tools like
+// kubectl find their credentials. argv is executed directly, never through
+// a shell. Tests replace it.
+var runSimulation = func(ctx context.Context, argv []string) ([]byte, error) {
+	return exec.CommandContext(ctx, argv[0], argv[1:]...).CombinedOutput()
+}
+
+// simulationArgv splits a simulation command into the argv it runs. A
+// simulation is a single plain command: control operators, redirections
+// and expansions are refused, so it can't run anything but its tool.
+func simulationArgv(command string) ([]string, error) {
+	var argv []string
+	for _, token := range cmdparse.Tokenize(command) {
+		if token.Operator {
+			return nil, fmt.Errorf("simulation %q contains the shell operator %q", command, token.Value)
+		}
+		if strings.ContainsAny(token.Value, "$`<>*?") {
+			return nil, fmt.Errorf("simulation %q contains shell syntax in %q", command, token.Value)
+		}
+		argv = append(argv, token.Value)
+	}
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("simulation command is empty")
+	}
+	return argv, nil
+}
+
+// simulateCandidate runs candidate's simulation, such as a kubectl
+// server-side dry run, and shows its output. It runs on the host, so it is
+// only called once the command itself has been confirmed to run there. The
+// simulation is checked against policy like any other command, and a
+// failed simulation stops the run, since the real command would most
+// likely fail too.
+func simulateCandidate(ctx context.Context, w io.Writer, candidate *translator.Candidate, policyEngine *policy.Engine) error {
+	if candidate.SimulateCommand == "" || noSimulate {
+		return nil
+	}
+
+	argv, err := simulationArgv(candidate.SimulateCommand)
+	if err != nil {
+		return fmt.Errorf("refusing to simulate: %w", err)
+	}
+	if validation := policyEngine.Validate(candidate.SimulateCommand, string(candidate.RiskLevel), false); !validation.Allowed {
+		return fmt.Errorf("simulation blocked by policy: %s", validation.Reason)
+	}
+
+	fmt.Fprintf(w, colorCyan+"🔍 Simulating: %s\n"+colorReset, candidate.SimulateCommand)
+	output, err := runSimulation(ctx, argv)
+	if len(output) > 0 {
+		fmt.Fprintf(w, "\n%sSimulation output:%s\n", colorBold, colorReset)
+		writeHead(w, output, 0)
+	}
+	if err != nil {
+		return fmt.Errorf("simulation failed, not running the command (use --no-simulate to skip it): %w", err)
+	}
+
+	fmt.Fprintln(w, colorGreen+translator.Symbol(translator.IconCheck)+" Simulation succeeded"+colorReset)
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
+	"github.com/SagheerAkram/QuickCmd/core/policy"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+	"github.com/SagheerAkram/QuickCmd/plugins/k8s"
+)
+
+// stubSimulation replaces runSimulation for the test, recording the argv
+// it is asked to run
+func stubSimulation(t *testing.T, output string, err error) *[][]string {
+	t.Helper()
+
+	var commands [][]string
+	original := runSimulation
+	runSimulation = func(ctx context.Context, argv []string) ([]byte, error) {
+		commands = append(commands, argv)
+		return []byte(output), err
+	}
+	t.Cleanup(func() { runSimulation = original })
+
+	return &commands
+}
+
+func TestSimulateCandidate_KubectlDryRun(t *testing.T) {
+	pluginCandidates, err := (&k8s.K8sPlugin{}).Translate(plugins.Context{}, "apply manifest deploy.yaml")
+	if err != nil || len(pluginCandidates) != 1 {
+		t.Fatalf("Translate() = %d candidates, error %v", len(pluginCandidates), err)
+	}
+	candidate := fromPluginCandidate(pluginCandidates[0])
+
+	commands := stubSimulation(t, "deployment.apps/api configured (server dry run)\n", nil)
+
+	var out bytes.Buffer
+	if err := simulateCandidate(context.Background(), &out, candidate, policy.NewEngine()); err != nil {
+		t.Fatalf("simulateCandidate() error: %v", err)
+	}
+	want := [][]string{{"kubectl", "apply", "-f", "deploy.yaml", "--dry-run=server"}}
+	if !reflect.DeepEqual(*commands, want) {
+		t.Errorf("simulated %q, want %q", *commands, want)
+	}
+	if !strings.Contains(out.String(), "configured (server dry run)") {
+		t.Errorf("dry run output not shown:\n%s", out.String())
+	}
+
+	// A failed dry run stops the run
+	stubSimulation(t, "error: the server could not find the requested resource\n", errors.New("exit status 1"))
+	out.Reset()
+	if err := simulateCandidate(context.Background(), &out, candidate, policy.NewEngine()); err == nil {
+		t.Error("expected an error for a failed dry run")
+	}
+	if !strings.Contains(out.String(), "could not find the requested resource") {
+		t.Errorf("failed dry run output not shown:\n%s", out.String())
+	}
+}
+
+func TestSimulateCandidate_Refused(t *testing.T) {
+	tests := []struct {
+		name     string
+		simulate string
+		wantErr  string
+	}{
+		{"command separator", "kubectl delete pod x;touch /tmp/pwned --dry-run=server", "shell operator"},
+		{"expansion", "kubectl delete pod x$(id) --dry-run=server", "shell syntax"},
+		{"redirection", "kubectl delete pod x --dry-run=server >/tmp/out", "shell syntax"},
+		{"denied by policy", "rm -rf /", "blocked by policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands := stubSimulation(t, "", nil)
+			candidate := &translator.Candidate{Command: "kubectl delete pod x", SimulateCommand: tt.simulate, RiskLevel: translator.RiskHigh}
+
+			err := simulateCandidate(context.Background(), &bytes.Buffer{}, candidate, policy.NewEngine())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("simulateCandidate() error = %v, want %q", err, tt.wantErr)
+			}
+			if len(*commands) != 0 {
+				t.Errorf("ran %q", *commands)
+			}
+		})
+	}
+}
+
+func TestSimulateCandidate_MissingTool(t *testing.T) {
+	// A real run with the tool missing from PATH fails rather than succeeds
+	t.Setenv("PATH", t.TempDir())
+
+	var out bytes.Buffer
+	candidate := &translator.Candidate{Command: "kubectl delete pod x", SimulateCommand: "kubectl delete pod x --dry-run=server"}
+	if err := simulateCandidate(context.Background(), &out, candidate, policy.NewEngine()); err == nil {
+		t.Error("expected an error when kubectl is missing")
+	}
+	if strings.Contains(out.String(), "Simulation succeeded") {
+		t.Errorf("reported success without kubectl:\n%s", out.String())
+	}
+}
+
+func TestRunCommand_NoSimulationBeforeConfirmation(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	commands := stubSimulation(t, "", nil)
+
+	// A previewed or sandboxed command never runs its simulation on the host
+	for _, args := range [][]string{{"run", "apply manifest deploy.yaml"}, {"run", "--sandbox", "apply manifest deploy.yaml"}} {
+		// Decline the confirmation prompt
+		stdinPath := filepath.Join(t.TempDir(), "stdin")
+		os.WriteFile(stdinPath, nil, 0600)
+		stdin, err := os.Open(stdinPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		original := os.Stdin
+		os.Stdin = stdin
+		executeCommand(t, "", args...)
+		os.Stdin = original
+		stdin.Close()
+
+		if len(*commands) != 0 {
+			t.Errorf("%q simulated %q", args, *commands)
+		}
+	}
+}
+
+func TestSimulateCandidate_NoSimulation(t *testing.T) {
+	pluginCandidates, _ := (&k8s.K8sPlugin{}).Translate(plugins.Context{}, "get pods")
+	candidate := fromPluginCandidate(pluginCandidates[0])
+
+	commands := stubSimulation(t, "", nil)
+	if err := simulateCandidate(context.Background(), &bytes.Buffer{}, candidate, policy.NewEngine()); err != nil {
+		t.Errorf("simulateCandidate() error: %v", err)
+	}
+	if len(*commands) != 0 {
+		t.Errorf("simulated %q for a read-only command", *commands)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	
+	"github.com/SagheerAkram/QuickCmd/core/config"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+)
+
+// memoryAuditDB is the SQLite path used when no directory is writable. The
+// audit log then only lasts for the current invocation.
+const memoryAuditDB = ":memory:"
+
+// storageWarnings receives warnings about degraded persistence. They go to
+// stderr so they never mix with command, JSON or completion output.
+var storageWarnings io.Writer = os.Stderr
+
+// writableDirs caches whether each directory could be created and written
+var writableDirs = map[string]bool{}
+
+// storagePath returns path if its directory can be created and written.
+// Otherwise, as in read-only or locked-down home directories, it returns the
+// same file name in a per-user temporary directory, or "" if that is not
+// writable either. A warning is printed the first time a directory is found
+// unwritable.
+func storagePath(path string) string {
+	if dirWritable(filepath.Dir(path)) {
+		return path
+	}
+	
+	fallback := filepath.Join(fallbackDir(), filepath.Base(path))
+	if fallbackWritable() {
+		return fallback
+	}
+	return ""
+}
+
+// quickcmdDir is the directory holding quickcmd's user files
+func quickcmdDir() string {
+	return filepath.Dir(config.DefaultPath())
+}
+
+// fallbackDir is the per-user temporary directory used when the quickcmd
+// directory is not writable
+func fallbackDir() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("quickcmd-%d", os.Getuid()))
+}
+
+// fallbackWritable reports whether the fallback directory is private to the
+// current user and writable. It lives in the shared temporary directory, so
+// another user could have created it first to read or plant history; such a
+// directory is refused with a warning.
+func fallbackWritable() bool {
+	dir := fallbackDir()
+	if writable, ok := writableDirs[dir]; ok {
+		return writable
+	}
+	
+	if err := privateDir(dir); err != nil {
+		writableDirs[dir] = false
+		fmt.Fprintf(storageWarnings, "%s  %v; history and feedback will not be saved there\n",
+			translator.Symbol(translator.IconWarning), err)
+		return false
+	}
+	return dirWritable(dir)
+}
+
+// privateDir creates dir with access for the current user only, or checks
+// that an existing dir is a real directory, owned by the current user and
+// closed to everyone else
+func privateDir(dir string) error {
+	if err := os.Mkdir(dir, 0700); err != nil && !errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s can't be created: %w", dir, err)
+	}
+	
+	// Lstat, so a symlink planted in its place isn't followed
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("%s can't be checked: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	if !ownedByCurrentUser(info) {
+		return fmt.Errorf("%s is owned by another user", dir)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%s is accessible by other users (mode %o)", dir, info.Mode().Perm())
+	}
+	return nil
+}
+
+// dirWritable reports whether dir exists or can be created, and a file can
+// be written in it
+func dirWritable(dir string) bool {
+	if writable, ok := writableDirs[dir]; ok {
+		return writable
+	}
+	
+	err := os.MkdirAll(dir, 0755)
+	if err == nil {
+		var f *os.File
+		if f, err = os.CreateTemp(dir, ".write-test-*"); err == nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}
+	
+	writableDirs[dir] = err == nil
+	if err != nil {
+		fmt.Fprintf(storageWarnings, "%s  %s is not writable (%v); history and feedback will not be saved there\n",
+			translator.Symbol(translator.IconWarning), dir, err)
+	}
+	return err == nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	
+	"github.com/SagheerAkram/QuickCmd/core/audit"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+	"github.com/spf13/cobra"
+)
+
+func TestUnwritableHome(t *testing.T) {
+	// A regular file as HOME makes ~/.quickcmd impossible to create, even
+	// when the tests run as root
+	home := filepath.Join(t.TempDir(), "home")
+	if err := os.WriteFile(home, nil, 0644); err != nil {
+		t.Fatalf("Failed to create home file: %v", err)
+	}
+	tmp := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("TMPDIR", tmp)
+	
+	var warnings bytes.Buffer
+	storageWarnings = &warnings
+	writableDirs = map[string]bool{}
+	t.Cleanup(func() {
+		storageWarnings = os.Stderr
+		writableDirs = map[string]bool{}
+		cfg = nil
+	})
+	
+	cmd := &cobra.Command{}
+	cmd.Flags().String("config", "", "")
+	if err := loadConfig(cmd, nil); err != nil {
+		t.Fatalf("loadConfig() error: %v", err)
+	}
+	
+	// Translation still works
+	candidates, err := translateCandidates(context.Background(), translator.New(), "show git changes", false)
+	if err != nil || len(candidates) == 0 {
+		t.Fatalf("translateCandidates() = %v, %v", candidates, err)
+	}
+	
+	// History falls back to a temporary directory, with a warning
+	path := getAuditDBPath()
+	if want := filepath.Join(fallbackDir(), "audit.db"); path != want {
+		t.Errorf("getAuditDBPath() = %q, want %q", path, want)
+	}
+	if !strings.HasPrefix(path, tmp) {
+		t.Errorf("getAuditDBPath() = %q, want it under %q", path, tmp)
+	}
+	if !strings.Contains(warnings.String(), "not writable") {
+		t.Errorf("no warning about the unwritable directory, got %q", warnings.String())
+	}
+	if got := getLearningStorePath(); !strings.HasPrefix(got, tmp) {
+		t.Errorf("getLearningStorePath() = %q, want it under %q", got, tmp)
+	}
+	
+	// With no writable temporary directory either, the audit log is kept
+	// in memory for this invocation
+	tmpFile := filepath.Join(tmp, "not-a-dir")
+	if err := os.WriteFile(tmpFile, nil, 0644); err != nil {
+		t.Fatalf("Failed to create tmp file: %v", err)
+	}
+	t.Setenv("TMPDIR", tmpFile)
+	writableDirs = map[string]bool{}
+	
+	if path := getAuditDBPath(); path != memoryAuditDB {
+		t.Fatalf("getAuditDBPath() = %q, want %q", path, memoryAuditDB)
+	}
+	store, err := audit.NewSQLiteStore(getAuditDBPath())
+	if err != nil {
+		t.Fatalf("NewSQLiteStore(%q) error: %v", memoryAuditDB, err)
+	}
+	defer store.Close()
+	
+	if err := store.LogExecution(&audit.RunRecord{Prompt: "show git changes", SelectedCommand: "git status --short"}); err != nil {
+		t.Fatalf("LogExecution() error: %v", err)
+	}
+	records, err := store.GetHistory(10, "")
+	if err != nil || len(records) != 1 {
+		t.Errorf("GetHistory() = %d records, %v; want 1", len(records), err)
+	}
+}
+
+func TestFallbackDir_Private(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permissions")
+	}
+	home := filepath.Join(t.TempDir(), "home")
+	if err := os.WriteFile(home, nil, 0644); err != nil {
+		t.Fatalf("Failed to create home file: %v", err)
+	}
+	t.Setenv("HOME", home)
+	
+	var warnings bytes.Buffer
+	storageWarnings = &warnings
+	t.Cleanup(func() {
+		storageWarnings = os.Stderr
+		writableDirs = map[string]bool{}
+	})
+	
+	// storagePathIn falls back to the temporary directory tmp
+	storagePathIn := func(tmp string) string {
+		t.Setenv("TMPDIR", tmp)
+		writableDirs = map[string]bool{}
+		warnings.Reset()
+		return storagePath(filepath.Join(quickcmdDir(), "audit.db"))
+	}
+	
+	// A fresh fallback directory is created for the current user only
+	tmp := t.TempDir()
+	if got, want := storagePathIn(tmp), filepath.Join(fallbackDir(), "audit.db"); got != want {
+		t.Fatalf("storagePath() = %q, want %q", got, want)
+	}
+	info, err := os.Lstat(fallbackDir())
+	if err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("fallback directory mode = %v, %v; want 0700", info.Mode().Perm(), err)
+	}
+	
+	// One another user could have planted is refused
+	tmp = t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmp, filepath.Base(fallbackDir())), 0777); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(filepath.Join(tmp, filepath.Base(fallbackDir())), 0777)
+	if got := storagePathIn(tmp); got != "" {
+		t.Errorf("storagePath() with a world-writable fallback = %q, want none", got)
+	}
+	if !strings.Contains(warnings.String(), "accessible by other users") {
+		t.Errorf("warning = %q", warnings.String())
+	}
+	
+	tmp = t.TempDir()
+	if err := os.Symlink(t.TempDir(), filepath.Join(tmp, filepath.Base(fallbackDir()))); err != nil {
+		t.Fatal(err)
+	}
+	if got := storagePathIn(tmp); got != "" {
+		t.Errorf("storagePath() with a symlinked fallback = %q, want none", got)
+	}
+	if !strings.Contains(warnings.String(), "not a directory") {
+		t.Errorf("warning = %q", warnings.String())
+	}
+}
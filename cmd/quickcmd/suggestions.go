@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/SagheerAkram/QuickCmd/core/audit"
+	"github.com/SagheerAkram/QuickCmd/core/learning/store"
+	"github.com/SagheerAkram/QuickCmd/core/suggestions"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+	"github.com/spf13/cobra"
+)
+
+// suggestionsNamespace is the suggestion engine's part of the learning store
+const suggestionsNamespace = "suggestions"
+
+var suggestionsCmd = &cobra.Command{
+	Use:   "suggestions",
+	Short: "Show personalized command suggestions",
+	Long: `Analyzes your command history and shows suggestions such as aliases,
+optimizations, and predicted next commands.
+
+Each suggestion can be accepted, rejected, or skipped. Rejected suggestion
+types are hidden for a while so they stop appearing.`,
+	RunE: showSuggestions,
+}
+
+func init() {
+	rootCmd.AddCommand(suggestionsCmd)
+
+	suggestionsCmd.Flags().Bool("no-prompt", false, "list suggestions without asking for feedback")
+}
+
+func showSuggestions(cmd *cobra.Command, args []string) error {
+	noPrompt, _ := cmd.Flags().GetBool("no-prompt")
+
+	out := cmd.OutOrStdout()
+	userID := currentUserID()
+	learned := openLearningStore(out)
+	engine, err := loadSuggestionEngine(out, learned, userID)
+	if err != nil {
+		return err
+	}
+
+	results := engine.GetSuggestions(userID, "")
+	if len(results) == 0 {
+		fmt.Fprintln(out, "No suggestions right now.")
+		return nil
+	}
+
+	fmt.Fprintf(out, "%sSuggestions%s\n\n", colorBold, colorReset)
+
+	reader := bufio.NewReader(cmd.InOrStdin())
+	shown := 0
+	for _, s := range results {
+		// A type rejected earlier in this session stays hidden
+		if !engine.ShouldSuggest(userID, s.Type) {
+			continue
+		}
+		shown++
+
+		fmt.Fprintf(out, "%s%d. %s%s (%s, %d%%)\n", colorBold, shown, s.Title, colorReset, s.Type, s.Confidence)
+		fmt.Fprintf(out, "   %s\n", s.Description)
+		if s.Command != "" {
+			fmt.Fprintf(out, "   %s%s%s\n", colorCyan, s.Command, colorReset)
+		}
+
+		if noPrompt {
+			fmt.Fprintln(out)
+			continue
+		}
+
+		fmt.Fprint(out, "   [a]ccept, [r]eject, [s]kip: ")
+		input, _ := reader.ReadString('\n')
+
+		switch strings.ToLower(strings.TrimSpace(input)) {
+		case "a", "accept":
+			engine.RecordFeedback(userID, s.Type, suggestions.FeedbackAccepted)
+		case "r", "reject":
+			engine.RecordFeedback(userID, s.Type, suggestions.FeedbackRejected)
+			fmt.Fprintf(out, "   %s%s suggestions hidden for now%s\n", colorYellow, s.Type, colorReset)
+		default:
+			engine.RecordFeedback(userID, s.Type, suggestions.FeedbackIgnored)
+		}
+		fmt.Fprintln(out)
+	}
+
+	if !noPrompt {
+		if err := engine.SaveFeedbackTo(learned.Namespace(suggestionsNamespace)); err != nil {
+			return fmt.Errorf("failed to save feedback: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// printRunSuggestions shows a few suggestions related to the prompt on w.
+// Failures are only warned about since suggestions are optional.
+func printRunSuggestions(w io.Writer, learned *store.Store, prompt string) {
+	// Don't read the history at all if nothing would be shown
+	if cfg != nil && cfg.Suggestions.Disabled {
+		return
+	}
+
+	userID := currentUserID()
+	engine, err := loadSuggestionEngine(w, learned, userID)
+	if err != nil {
+		fmt.Fprintf(w, colorYellow+"%s  %v\n"+colorReset, translator.Symbol(translator.IconWarning), err)
+		return
+	}
+
+	results := engine.GetSuggestions(userID, prompt)
+	if len(results) == 0 {
+		return
+	}
+	if len(results) > 3 {
+		results = results[:3]
+	}
+
+	fmt.Fprintf(w, "%s💡 Suggestions%s\n", colorBold, colorReset)
+	for _, s := range results {
+		if s.Command != "" {
+			fmt.Fprintf(w, "   %s: %s%s%s\n", s.Title, colorCyan, s.Command, colorReset)
+		} else {
+			fmt.Fprintf(w, "   %s: %s\n", s.Title, s.Description)
+		}
+	}
+	fmt.Fprintln(w, "   (disable with --no-suggestions)")
+	fmt.Fprintln(w)
+}
+
+// loadSuggestionEngine creates a suggestion engine with the configured
+// filters, primed with the user's command history and the feedback saved in
+// learned. Warnings go to w.
+func loadSuggestionEngine(w io.Writer, learned *store.Store, userID string) (*suggestions.SuggestionEngine, error) {
+	engine := suggestions.NewSuggestionEngine()
+	if cfg != nil {
+		engine.SetConfig(cfg.Suggestions)
+	}
+
+	if err := engine.LoadFeedbackFrom(learned.Namespace(suggestionsNamespace)); err != nil {
+		fmt.Fprintf(w, colorYellow+"%s  %v\n"+colorReset, translator.Symbol(translator.IconWarning), err)
+	}
+
+	// Feed history into the engine, oldest first
+	auditStore, err := audit.NewSQLiteStore(getAuditDBPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit database: %w", err)
+	}
+	defer auditStore.Close()
+
+	records, err := auditStore.GetHistory(100, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history: %w", err)
+	}
+
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].User == userID {
+			engine.AnalyzeCommand(userID, records[i].SelectedCommand)
+		}
+	}
+
+	return engine, nil
+}
+
+// currentUserID returns the name of the user running quickcmd
+func currentUserID() string {
+	if currentUser, err := user.Current(); err == nil {
+		return currentUser.Username
+	}
+	return "unknown"
+}
+
+// getLearningStorePath returns the path to the learning store shared by
+// suggestions and other modules that learn from usage, in a temporary
+// directory if the quickcmd directory is not writable
+func getLearningStorePath() string {
+	path := storagePath(filepath.Join(quickcmdDir(), "learning.json"))
+	if path == "" {
+		// Nothing is writable; saving fails with a warning
+		return filepath.Join(fallbackDir(), "learning.json")
+	}
+	return path
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/SagheerAkram/QuickCmd/core/audit"
+	"github.com/SagheerAkram/QuickCmd/core/config"
+	"github.com/SagheerAkram/QuickCmd/core/suggestions"
+)
+
+func TestRunSuggestions_Config(t *testing.T) {
+	tempHome(t)
+	t.Cleanup(func() { cfg = nil })
+
+	// Disabled suggestions don't touch the audit database
+	cfg = &config.Config{Suggestions: suggestions.Config{Disabled: true}}
+	var out bytes.Buffer
+	printRunSuggestions(&out, openLearningStore(&out), "show status")
+	if out.Len() != 0 {
+		t.Errorf("disabled suggestions printed %q", out.String())
+	}
+	if _, err := os.Stat(getAuditDBPath()); !os.IsNotExist(err) {
+		t.Errorf("disabled suggestions opened the audit database: %v", err)
+	}
+
+	// A command run often enough is offered as an alias
+	auditStore, err := audit.NewSQLiteStore(getAuditDBPath())
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error: %v", err)
+	}
+	for i := 0; i < 6; i++ {
+		if err := auditStore.LogExecution(&audit.RunRecord{
+			User:            currentUserID(),
+			Prompt:          "show status",
+			SelectedCommand: "git status",
+			RiskLevel:       "safe",
+		}); err != nil {
+			t.Fatalf("LogExecution() error: %v", err)
+		}
+	}
+	auditStore.Close()
+
+	cfg = nil
+	out.Reset()
+	printRunSuggestions(&out, openLearningStore(&out), "show status")
+	if !strings.Contains(out.String(), "Create Alias") {
+		t.Errorf("suggestions = %q, want an alias", out.String())
+	}
+
+	// Disabled types are filtered out
+	cfg = &config.Config{Suggestions: suggestions.Config{DisabledTypes: []string{"alias"}}}
+	out.Reset()
+	printRunSuggestions(&out, openLearningStore(&out), "show status")
+	if strings.Contains(out.String(), "Create Alias") {
+		t.Errorf("disabled alias suggestion printed: %q", out.String())
+	}
+}
+
+func TestShowSuggestions_Numbering(t *testing.T) {
+	tempHome(t)
+	t.Cleanup(func() { cfg = nil })
+
+	auditStore, err := audit.NewSQLiteStore(getAuditDBPath())
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error: %v", err)
+	}
+	for _, command := range []string{"git status", "git log --oneline", "docker ps"} {
+		for i := 0; i < 6; i++ {
+			if err := auditStore.LogExecution(&audit.RunRecord{
+				User:            currentUserID(),
+				Prompt:          "run " + command,
+				SelectedCommand: command,
+				RiskLevel:       "safe",
+			}); err != nil {
+				t.Fatalf("LogExecution() error: %v", err)
+			}
+		}
+	}
+	auditStore.Close()
+
+	out, err := executeCommand(t, "r\ns\ns\ns\ns\n", "suggestions")
+	if err != nil {
+		t.Fatalf("suggestions error: %v\n%s", err, out)
+	}
+
+	// Rejecting the first alias hides the other two, so the pattern
+	// suggestion that follows is listed second
+	if strings.Count(out, "Create Alias") != 1 {
+		t.Errorf("expected the remaining alias suggestions to be hidden, got:\n%s", out)
+	}
+	if !strings.Contains(out, "2. Predicted Next Command") {
+		t.Errorf("expected suggestions numbered consecutively, got:\n%s", out)
+	}
+}
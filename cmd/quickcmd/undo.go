@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SagheerAkram/QuickCmd/core/audit"
+	"github.com/SagheerAkram/QuickCmd/core/executor"
+	"github.com/spf13/cobra"
+)
+
+// undoPrompt is the audit prompt recorded for an undo of a run. It also
+// marks the run as undone when looking for the most recent reversible run.
+const undoPrompt = "undo run #%d"
+
+// undoHistoryLimit bounds how far back undo looks for a reversible run
+const undoHistoryLimit = 100
+
+var undoCmd = &cobra.Command{
+	Use:   "undo [run-id]",
+	Short: "Roll back a run using its pre-run snapshot",
+	Long: `Restores the snapshot taken before a destructive run. Without a run ID,
+the most recent reversible run that hasn't been undone is rolled back.
+
+Run IDs are shown by quickcmd history. You must type UNDO to confirm, or pass
+--yes. The undo is recorded in the audit log.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: undoRun,
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+
+	undoCmd.Flags().Bool("yes", false, "undo without confirmation")
+}
+
+func undoRun(cmd *cobra.Command, args []string) error {
+	store, err := audit.NewSQLiteStore(getAuditDBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open audit database: %w", err)
+	}
+	defer store.Close()
+
+	var record *audit.RunRecord
+	if len(args) == 1 {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid run ID: %q", args[0])
+		}
+		if record, err = store.GetRecordByID(id); err != nil {
+			return fmt.Errorf("run %d: %w", id, err)
+		}
+	} else {
+		if record, err = lastReversibleRun(store); err != nil {
+			return err
+		}
+	}
+
+	snapshot, err := audit.DecodeSnapshot(record.Snapshot)
+	if err != nil {
+		return fmt.Errorf("run %d has an unreadable snapshot: %w", record.ID, err)
+	}
+	if err := checkReversible(record, snapshot); err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "%sUndo run #%d%s\n\n", colorBold, record.ID, colorReset)
+	fmt.Fprintf(out, "Command:  %s\n", record.SelectedCommand)
+	fmt.Fprintf(out, "Ran at:   %s\n", record.Timestamp)
+	fmt.Fprintf(out, "Snapshot: %s %s\n", snapshot.Type, snapshot.Location)
+	fmt.Fprintf(out, "Restore:  %s%s%s\n", colorCyan, snapshot.RestoreCmd, colorReset)
+
+	if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+		fmt.Fprintf(out, "\n%sType UNDO to restore the snapshot%s\n", colorYellow, colorReset)
+		fmt.Fprint(out, "Confirmation: ")
+
+		input, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		if strings.TrimSpace(input) != "UNDO" {
+			fmt.Fprintln(out, "Cancelled.")
+			return nil
+		}
+	}
+
+	workingDir := snapshot.WorkingDir
+	if workingDir == "" {
+		// Snapshots recorded before the working directory was stored
+		workingDir, _ = os.Getwd()
+	}
+
+	startTime := time.Now()
+	var restoreErr error
+	if snapshot.Undo != nil {
+		restoreErr = newUndoEngine().Undo(snapshot.Undo)
+	} else {
+		restoreErr = executor.NewSnapshotter().RestoreSnapshot(snapshot, workingDir)
+	}
+	logUndo(store, out, record, snapshot, restoreErr, time.Since(startTime))
+
+	if restoreErr != nil {
+		return fmt.Errorf("undo of run %d failed: %w", record.ID, restoreErr)
+	}
+
+	fmt.Fprintf(out, "%s✓ Run #%d undone%s\n", colorGreen, record.ID, colorReset)
+	return nil
+}
+
+// lastReversibleRun returns the most recent run with a reversible snapshot
+// that hasn't already been undone
+func lastReversibleRun(store *audit.SQLiteStore) (*audit.RunRecord, error) {
+	records, err := store.GetHistory(undoHistoryLimit, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history: %w", err)
+	}
+
+	// History is newest first, so an undo is seen before the run it undid
+	undone := make(map[int64]bool)
+	for _, record := range records {
+		var id int64
+		if _, err := fmt.Sscanf(record.Prompt, undoPrompt, &id); err == nil {
+			undone[id] = true
+			continue
+		}
+		if undone[record.ID] {
+			continue
+		}
+
+		snapshot, err := audit.DecodeSnapshot(record.Snapshot)
+		if err == nil && snapshot != nil && snapshot.Reversible {
+			return record, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no reversible runs found in the last %d runs", undoHistoryLimit)
+}
+
+// checkReversible explains why a run can't be undone, or returns nil
+func checkReversible(record *audit.RunRecord, snapshot *executor.SnapshotMetadata) error {
+	if snapshot == nil {
+		return fmt.Errorf("run %d cannot be undone: no snapshot was taken (only destructive commands run with --sandbox are snapshotted)", record.ID)
+	}
+	if snapshot.Reversible {
+		return nil
+	}
+	if snapshot.Type == "filesystem" && snapshot.Location == "" {
+		return fmt.Errorf("run %d cannot be undone: none of its affected paths existed when the snapshot was taken, so nothing was backed up", record.ID)
+	}
+	return fmt.Errorf("run %d cannot be undone: its %s snapshot is not reversible", record.ID, snapshot.Type)
+}
+
+// logUndo records the undo of record in the audit log
+func logUndo(store *audit.SQLiteStore, out io.Writer, record *audit.RunRecord, snapshot *executor.SnapshotMetadata, restoreErr error, duration time.Duration) {
+	undoRecord := &audit.RunRecord{
+		Timestamp:       time.Now().Format(time.RFC3339),
+		Prompt:          fmt.Sprintf(undoPrompt, record.ID),
+		SelectedCommand: snapshot.RestoreCmd,
+		RiskLevel:       record.RiskLevel,
+		Executed:        true,
+		DurationMs:      duration.Milliseconds(),
+	}
+	if restoreErr != nil {
+		undoRecord.ExitCode = 1
+		undoRecord.Stderr = []byte(restoreErr.Error())
+	}
+
+	if err := store.LogExecution(undoRecord); err != nil {
+		fmt.Fprintf(out, "%sFailed to log undo: %v%s\n", colorYellow, err, colorReset)
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/SagheerAkram/QuickCmd/core/audit"
+	"github.com/SagheerAkram/QuickCmd/core/config"
+	"github.com/SagheerAkram/QuickCmd/core/executor"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+)
+
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// setupUndo creates a git repo whose tracked file was deleted by a run with
+// a git snapshot, and an audit database holding that run. It returns the
+// repo and the audit store.
+func setupUndo(t *testing.T) (string, *audit.SQLiteStore, *audit.RunRecord) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	cfg = config.DefaultConfig()
+	cfg.AuditDBPath = filepath.Join(t.TempDir(), "audit.db")
+	t.Cleanup(func() { cfg = nil })
+
+	repo := t.TempDir()
+	gitCmd(t, repo, "init", "-q")
+	gitCmd(t, repo, "config", "user.email", "test@example.com")
+	gitCmd(t, repo, "config", "user.name", "Test")
+	if err := os.WriteFile(filepath.Join(repo, "data.txt"), []byte("important"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitCmd(t, repo, "add", "data.txt")
+	gitCmd(t, repo, "commit", "-q", "-m", "add data")
+
+	snapshot, err := executor.NewSnapshotter().CreateSnapshot(repo, []string{"data.txt"})
+	if err != nil {
+		t.Fatalf("CreateSnapshot() error: %v", err)
+	}
+	if snapshot.Type != "git" || !snapshot.Reversible {
+		t.Fatalf("snapshot = %+v, want reversible git snapshot", snapshot)
+	}
+
+	// The destructive run
+	gitCmd(t, repo, "rm", "-q", "data.txt")
+	gitCmd(t, repo, "commit", "-q", "-m", "remove data")
+
+	store, err := audit.NewSQLiteStore(getAuditDBPath())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	record := &audit.RunRecord{
+		Prompt:          "delete data.txt and commit",
+		SelectedCommand: "git rm data.txt && git commit -m 'remove data'",
+		RiskLevel:       "high",
+		Snapshot:        audit.EncodeSnapshot(snapshot),
+		Executed:        true,
+	}
+	if err := store.LogExecution(record); err != nil {
+		t.Fatalf("LogExecution() error: %v", err)
+	}
+
+	return repo, store, record
+}
+
+func TestUndo_GitSnapshot(t *testing.T) {
+	repo, store, record := setupUndo(t)
+	branch := gitCmd(t, repo, "symbolic-ref", "--short", "HEAD")
+
+	// Declining leaves the repo alone
+	out, err := executeCommand(t, "no\n", "undo", fmt.Sprint(record.ID))
+	if err != nil {
+		t.Fatalf("undo error: %v", err)
+	}
+	if !strings.Contains(out, "git reset --hard quickcmd/backup/") || !strings.Contains(out, "Cancelled") {
+		t.Errorf("undo output should show the restore command and cancel:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "data.txt")); !os.IsNotExist(err) {
+		t.Fatal("declined undo restored the file")
+	}
+
+	out, err = executeCommand(t, "UNDO\n", "undo", fmt.Sprint(record.ID))
+	if err != nil {
+		t.Fatalf("undo error: %v\n%s", err, out)
+	}
+
+	data, err := os.ReadFile(filepath.Join(repo, "data.txt"))
+	if err != nil || string(data) != "important" {
+		t.Errorf("data.txt after undo = %q, %v; want restored", data, err)
+	}
+	if current := gitCmd(t, repo, "symbolic-ref", "--short", "HEAD"); current != branch {
+		t.Errorf("undo left the repo on %q, want %q", current, branch)
+	}
+
+	history, err := store.GetHistory(1, "")
+	if err != nil || len(history) != 1 {
+		t.Fatalf("GetHistory() = %v, %v", history, err)
+	}
+	undo := history[0]
+	if undo.Prompt != fmt.Sprintf("undo run #%d", record.ID) || undo.ExitCode != 0 || !undo.Executed {
+		t.Errorf("undo audit record = %+v", undo)
+	}
+	if want := "git checkout " + branch + " && git reset --hard quickcmd/backup/"; !strings.HasPrefix(undo.SelectedCommand, want) {
+		t.Errorf("undo audit command = %q", undo.SelectedCommand)
+	}
+
+	// The run is now undone, so there's nothing left to undo by default
+	if _, err := executeCommand(t, "", "undo", "--yes"); err == nil || !strings.Contains(err.Error(), "no reversible runs") {
+		t.Errorf("second undo error = %v, want no reversible runs", err)
+	}
+}
+
+func TestUndo_MostRecent(t *testing.T) {
+	repo, store, record := setupUndo(t)
+
+	// A later run without a snapshot is skipped
+	if err := store.LogExecution(&audit.RunRecord{Prompt: "list files", SelectedCommand: "ls", RiskLevel: "safe"}); err != nil {
+		t.Fatalf("LogExecution() error: %v", err)
+	}
+
+	out, err := executeCommand(t, "", "undo", "--yes")
+	if err != nil {
+		t.Fatalf("undo error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, fmt.Sprintf("Run #%d undone", record.ID)) {
+		t.Errorf("undo output:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "data.txt")); err != nil {
+		t.Errorf("data.txt not restored: %v", err)
+	}
+}
+
+func TestUndo_NotReversible(t *testing.T) {
+	_, store, _ := setupUndo(t)
+
+	plain := &audit.RunRecord{Prompt: "list files", SelectedCommand: "ls", RiskLevel: "safe"}
+	empty := &audit.RunRecord{
+		Prompt:          "delete missing file",
+		SelectedCommand: "rm missing.txt",
+		RiskLevel:       "high",
+		Snapshot:        audit.EncodeSnapshot(&executor.SnapshotMetadata{Type: "filesystem"}),
+	}
+	for _, record := range []*audit.RunRecord{plain, empty} {
+		if err := store.LogExecution(record); err != nil {
+			t.Fatalf("LogExecution() error: %v", err)
+		}
+	}
+
+	if _, err := executeCommand(t, "", "undo", "--yes", fmt.Sprint(plain.ID)); err == nil || !strings.Contains(err.Error(), "no snapshot") {
+		t.Errorf("undo of run without snapshot error = %v", err)
+	}
+	if _, err := executeCommand(t, "", "undo", "--yes", fmt.Sprint(empty.ID)); err == nil || !strings.Contains(err.Error(), "nothing was backed up") {
+		t.Errorf("undo of irreversible snapshot error = %v", err)
+	}
+	if _, err := executeCommand(t, "", "undo", "--yes", "999"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("undo of missing run error = %v", err)
+	}
+}
+
+// testUndoBackup snapshots the deletion of a file with createSnapshot, as a
+// sandboxed run does, deletes it, and undoes the run. It returns the
+// snapshot.
+func testUndoBackup(t *testing.T) *executor.SnapshotMetadata {
+	t.Helper()
+
+	dir := t.TempDir()
+	chdir(t, dir)
+	if err := os.WriteFile("notes.txt", []byte("keep me"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	candidate := &translator.Candidate{Command: "rm notes.txt", AffectedPaths: []string{"notes.txt"}, Destructive: true}
+	snapshot := createSnapshot(&out, dir, candidate)
+	if snapshot == nil || snapshot.Undo == nil || !snapshot.Reversible {
+		t.Fatalf("createSnapshot() = %+v, want an undo backup\n%s", snapshot, out.String())
+	}
+
+	// The destructive run
+	if err := os.Remove("notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := audit.NewSQLiteStore(getAuditDBPath())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	record := &audit.RunRecord{
+		Prompt:          "delete notes.txt",
+		SelectedCommand: candidate.Command,
+		RiskLevel:       "high",
+		Snapshot:        audit.EncodeSnapshot(snapshot),
+		Executed:        true,
+	}
+	if err := store.LogExecution(record); err != nil {
+		t.Fatalf("LogExecution() error: %v", err)
+	}
+
+	if output, err := executeCommand(t, "", "undo", "--yes"); err != nil {
+		t.Fatalf("undo error: %v\n%s", err, output)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "notes.txt"))
+	if err != nil || string(data) != "keep me" {
+		t.Errorf("notes.txt after undo = %q, %v; want restored", data, err)
+	}
+
+	return snapshot
+}
+
+func TestUndo_LocalBackup(t *testing.T) {
+	tempHome(t)
+	cfg = config.DefaultConfig()
+	cfg.AuditDBPath = filepath.Join(t.TempDir(), "audit.db")
+	cfg.Backup.Dir = t.TempDir()
+	t.Cleanup(func() { cfg = nil })
+
+	snapshot := testUndoBackup(t)
+	if !strings.HasPrefix(snapshot.Location, "file://"+filepath.ToSlash(cfg.Backup.Dir)) {
+		t.Errorf("backup location = %q, want in %s", snapshot.Location, cfg.Backup.Dir)
+	}
+}
+
+func TestUndo_S3Backup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake aws CLI is a shell script")
+	}
+	tempHome(t)
+	cfg = config.DefaultConfig()
+	cfg.AuditDBPath = filepath.Join(t.TempDir(), "audit.db")
+	cfg.Backup = config.BackupConfig{Backend: "s3", Dir: t.TempDir(), Bucket: "ci-backups", Prefix: "undo"}
+	t.Cleanup(func() { cfg = nil })
+
+	// A fake aws CLI keeping objects in a directory
+	bin, bucketDir := t.TempDir(), t.TempDir()
+	script := `#!/bin/sh
+case "$3" in
+-) mkdir -p "$(dirname "$FAKE_S3/${4#s3://}")" && cat > "$FAKE_S3/${4#s3://}" ;;
+*) cat "$FAKE_S3/${3#s3://}" ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "aws"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("FAKE_S3", bucketDir)
+
+	snapshot := testUndoBackup(t)
+	if !strings.HasPrefix(snapshot.Location, "s3://ci-backups/undo/") {
+		t.Errorf("backup location = %q, want in s3://ci-backups/undo/", snapshot.Location)
+	}
+	if entries, _ := os.ReadDir(cfg.Backup.Dir); len(entries) != 0 {
+		t.Errorf("backup written locally too: %v", entries)
+	}
+}
@@ -5,42 +5,102 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 	
 	"github.com/gorilla/websocket"
 	"github.com/SagheerAkram/QuickCmd/agent"
+	"github.com/SagheerAkram/QuickCmd/core/policy"
 )
 
+// capabilitiesTTL is how long a client reuses an agent's capabilities
+// before fetching them again
+const capabilitiesTTL = 5 * time.Minute
+
+// ErrDeniedByPolicy is returned by SubmitJob when the client's local policy
+// blocks the command
+var ErrDeniedByPolicy = errors.New("command denied by local policy")
+
 // Client represents a controller client for submitting jobs to agents
 type Client struct {
 	agentURL   string
 	hmacSecret string
 	httpClient *http.Client
+	tlsConfig  *tls.Config // shared by API requests and log streams
 	maxRetries int
+	policy     *policy.Engine // optional local pre-validation
+	
+	capsMu        sync.Mutex
+	capabilities  *agent.Capabilities
+	capsFetchedAt time.Time
 }
 
 // NewClient creates a new controller client
 func NewClient(agentURL, hmacSecret string) *Client {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true, // For development with self-signed certs
+	}
+	
 	return &Client{
 		agentURL:   agentURL,
 		hmacSecret: hmacSecret,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					InsecureSkipVerify: true, // For development with self-signed certs
-				},
+				TLSClientConfig: tlsConfig,
 			},
 		},
+		tlsConfig:  tlsConfig,
 		maxRetries: 3,
 	}
 }
 
+// SetClientCertificate makes the client present the certificate in certFile
+// and keyFile, for agents that require mutual TLS. If caFile is set, the
+// agent's certificate is verified against it instead of being trusted
+// blindly.
+func (c *Client) SetClientCertificate(certFile, keyFile, caFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	
+	c.tlsConfig.Certificates = []tls.Certificate{cert}
+	if caFile != "" {
+		pool, err := agent.LoadCertPool(caFile)
+		if err != nil {
+			return err
+		}
+		c.tlsConfig.RootCAs = pool
+		c.tlsConfig.InsecureSkipVerify = false
+	}
+	
+	return nil
+}
+
+// SetPolicy makes SubmitJob check commands against engine before sending
+// them, so that commands the agent would reject fail without a round trip.
+// The agent still enforces its own policy; a nil engine disables the check.
+func (c *Client) SetPolicy(engine *policy.Engine) {
+	c.policy = engine
+}
+
 // SubmitJob submits a job to the agent with retry logic
 func (c *Client) SubmitJob(ctx context.Context, payload *agent.JobPayload) (string, error) {
+	if c.policy != nil {
+		result := c.policy.Validate(payload.Command, payload.RiskLevel(), payload.Destructive())
+		if !result.Allowed {
+			return "", fmt.Errorf("%w: %s", ErrDeniedByPolicy, result.Reason)
+		}
+	}
+	
 	// Sign the payload
 	signature, err := agent.SignPayload(payload, c.hmacSecret)
 	if err != nil {
@@ -146,43 +206,178 @@ func (c *Client) GetJobStatus(ctx context.Context, jobID string) (*agent.JobResu
 	return response.Result, nil
 }
 
-// StreamLogs streams logs from a job via WebSocket
+// Capabilities returns what the agent supports, fetching it at most once
+// per capabilitiesTTL
+func (c *Client) Capabilities(ctx context.Context) (*agent.Capabilities, error) {
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+	
+	if c.capabilities != nil && time.Since(c.capsFetchedAt) < capabilitiesTTL {
+		return c.capabilities, nil
+	}
+	
+	caps, err := c.fetchCapabilities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	
+	c.capabilities = caps
+	c.capsFetchedAt = time.Now()
+	return caps, nil
+}
+
+// RefreshCapabilities drops the cached capabilities, e.g. after the agent
+// was reconfigured
+func (c *Client) RefreshCapabilities() {
+	c.capsMu.Lock()
+	c.capabilities = nil
+	c.capsMu.Unlock()
+}
+
+// fetchCapabilities retrieves the agent's capabilities
+func (c *Client) fetchCapabilities(ctx context.Context) (*agent.Capabilities, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.agentURL+"/api/v1/capabilities", nil)
+	if err != nil {
+		return nil, err
+	}
+	
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch agent capabilities: %w", err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("agent returned status %d: %s", resp.StatusCode, string(body))
+	}
+	
+	var caps agent.Capabilities
+	if err := json.NewDecoder(resp.Body).Decode(&caps); err != nil {
+		return nil, fmt.Errorf("failed to decode agent capabilities: %w", err)
+	}
+	
+	return &caps, nil
+}
+
+// StreamLogs streams logs from a job via WebSocket, calling logHandler for
+// each frame until the final frame arrives, the agent closes the stream or
+// ctx is done. Dropped connections are re-established up to maxRetries
+// times; frames sent while disconnected are not replayed.
 func (c *Client) StreamLogs(ctx context.Context, jobID string, logHandler func(*agent.LogFrame) error) error {
-	// Create WebSocket connection
-	wsURL := "wss://" + c.agentURL[8:] + "/api/v1/stream/" + jobID // Replace https:// with wss://
+	wsURL, err := c.streamURL(jobID)
+	if err != nil {
+		return err
+	}
 	
 	dialer := websocket.Dialer{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true, // For development
-		},
+		TLSClientConfig:  c.tlsConfig,
+		HandshakeTimeout: 30 * time.Second,
+	}
+	
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			// Exponential backoff
+			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+		
+		retry, err := c.streamOnce(ctx, &dialer, wsURL, logHandler)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !retry {
+			return err
+		}
+		lastErr = err
 	}
 	
-	conn, _, err := dialer.DialContext(ctx, wsURL, nil)
+	return fmt.Errorf("failed after %d retries: %w", c.maxRetries, lastErr)
+}
+
+// streamOnce reads log frames over a single connection. It reports whether
+// a failure was a transient network error worth reconnecting after.
+func (c *Client) streamOnce(ctx context.Context, dialer *websocket.Dialer, wsURL string, logHandler func(*agent.LogFrame) error) (bool, error) {
+	conn, resp, err := dialer.DialContext(ctx, wsURL, nil)
 	if err != nil {
-		return fmt.Errorf("failed to connect to WebSocket: %w", err)
+		if resp != nil {
+			// The agent answered but refused the stream, e.g. unknown job
+			return resp.StatusCode >= 500, fmt.Errorf("failed to connect to WebSocket: agent returned status %d", resp.StatusCode)
+		}
+		return true, fmt.Errorf("failed to connect to WebSocket: %w", err)
 	}
 	defer conn.Close()
 	
-	// Read log frames
+	// Unblock ReadJSON when ctx is cancelled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	
 	for {
 		var frame agent.LogFrame
 		if err := conn.ReadJSON(&frame); err != nil {
-			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
-				return nil
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return false, nil
 			}
-			return fmt.Errorf("failed to read log frame: %w", err)
+			return isTransientStreamError(err), fmt.Errorf("failed to read log frame: %w", err)
 		}
 		
 		if err := logHandler(&frame); err != nil {
-			return err
+			return false, err
 		}
 		
 		if frame.Final {
-			return nil
+			return false, nil
 		}
 	}
 }
 
+// streamURL returns the WebSocket URL for jobID's logs, using wss for an
+// https agent and ws for a plain http one
+func (c *Client) streamURL(jobID string) (string, error) {
+	u, err := url.Parse(c.agentURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse agent URL: %w", err)
+	}
+	
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	default:
+		return "", fmt.Errorf("unsupported agent URL scheme %q", u.Scheme)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/v1/stream/" + url.PathEscape(jobID)
+	
+	return u.String(), nil
+}
+
+// isTransientStreamError reports whether err is a dropped connection rather
+// than, say, a malformed frame
+func isTransientStreamError(err error) bool {
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		return closeErr.Code == websocket.CloseAbnormalClosure
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
 // WaitForCompletion waits for a job to complete and returns the result
 func (c *Client) WaitForCompletion(ctx context.Context, jobID string, pollInterval time.Duration) (*agent.JobResult, error) {
 	ticker := time.NewTicker(pollInterval)
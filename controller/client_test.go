@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/SagheerAkram/QuickCmd/agent"
+	"github.com/SagheerAkram/QuickCmd/core/policy"
+)
+
+func TestSubmitJob_LocalPolicy(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"job_id": "job-1"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "secret")
+	client.SetPolicy(policy.NewEngine())
+
+	_, err := client.SubmitJob(context.Background(), &agent.JobPayload{JobID: "job-0", Command: "rm -rf /"})
+	if !errors.Is(err, ErrDeniedByPolicy) {
+		t.Fatalf("SubmitJob() error = %v, want ErrDeniedByPolicy", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("denied command made %d requests to the agent, want 0", n)
+	}
+
+	jobID, err := client.SubmitJob(context.Background(), &agent.JobPayload{JobID: "job-1", Command: "ls -la"})
+	if err != nil {
+		t.Fatalf("SubmitJob() error: %v", err)
+	}
+	if n := atomic.LoadInt32(&requests); jobID != "job-1" || n != 1 {
+		t.Errorf("allowed command: job ID = %q, requests = %d", jobID, n)
+	}
+}
+
+// streamServer serves log streams, calling serve for each connection with
+// its 1-based index
+func streamServer(t *testing.T, serve func(conn *websocket.Conn, n int32)) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var connections int32
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/stream/job-1" {
+			http.Error(w, "Job not found", http.StatusNotFound)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Upgrade() error: %v", err)
+			return
+		}
+		defer conn.Close()
+		serve(conn, atomic.AddInt32(&connections, 1))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &connections
+}
+
+func TestStreamLogs(t *testing.T) {
+	server, _ := streamServer(t, func(conn *websocket.Conn, n int32) {
+		conn.WriteJSON(agent.LogFrame{JobID: "job-1", Stream: "stdout", Data: "hello\n"})
+		conn.WriteJSON(agent.LogFrame{JobID: "job-1", Stream: "stderr", Data: "warning\n"})
+		conn.WriteJSON(agent.LogFrame{JobID: "job-1", Final: true})
+		// Anything after the final frame is ignored
+		conn.WriteJSON(agent.LogFrame{JobID: "job-1", Data: "late\n"})
+	})
+
+	client := NewClient(server.URL, "secret")
+
+	var data []string
+	err := client.StreamLogs(context.Background(), "job-1", func(frame *agent.LogFrame) error {
+		data = append(data, frame.Data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamLogs() error: %v", err)
+	}
+	if got := strings.Join(data, ""); got != "hello\nwarning\n" || len(data) != 3 {
+		t.Errorf("StreamLogs() frames = %q, want hello, warning and the final frame", data)
+	}
+}
+
+func TestStreamLogs_Reconnect(t *testing.T) {
+	server, connections := streamServer(t, func(conn *websocket.Conn, n int32) {
+		if n == 1 {
+			// Drop the connection without a close message
+			conn.WriteJSON(agent.LogFrame{JobID: "job-1", Data: "first\n"})
+			conn.UnderlyingConn().Close()
+			return
+		}
+		conn.WriteJSON(agent.LogFrame{JobID: "job-1", Data: "second\n", Final: true})
+	})
+
+	client := NewClient(server.URL, "secret")
+
+	var data []string
+	err := client.StreamLogs(context.Background(), "job-1", func(frame *agent.LogFrame) error {
+		data = append(data, frame.Data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamLogs() error: %v", err)
+	}
+	if got := strings.Join(data, ""); got != "first\nsecond\n" {
+		t.Errorf("StreamLogs() data = %q, want both connections' frames", got)
+	}
+	if n := atomic.LoadInt32(connections); n != 2 {
+		t.Errorf("connections = %d, want 2", n)
+	}
+}
+
+func TestStreamLogs_Errors(t *testing.T) {
+	server, connections := streamServer(t, func(conn *websocket.Conn, n int32) {
+		conn.WriteJSON(agent.LogFrame{JobID: "job-1", Data: "running\n"})
+		// Keep the stream open until the client goes away
+		conn.ReadMessage()
+	})
+
+	client := NewClient(server.URL, "secret")
+
+	// An unknown job is refused by the agent and not retried
+	err := client.StreamLogs(context.Background(), "job-2", func(*agent.LogFrame) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("unknown job: error = %v, want status 404", err)
+	}
+
+	// Cancelling ctx ends an idle stream
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- client.StreamLogs(ctx, "job-1", func(*agent.LogFrame) error {
+			cancel()
+			return nil
+		})
+	}()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("cancelled stream: error = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("StreamLogs() did not return after ctx was cancelled")
+	}
+
+	// A handler error stops the stream without reconnecting
+	handlerErr := errors.New("stop")
+	before := atomic.LoadInt32(connections)
+	err = client.StreamLogs(context.Background(), "job-1", func(*agent.LogFrame) error { return handlerErr })
+	if !errors.Is(err, handlerErr) {
+		t.Errorf("handler error: error = %v, want %v", err, handlerErr)
+	}
+	if n := atomic.LoadInt32(connections) - before; n != 1 {
+		t.Errorf("handler error made %d connections, want 1", n)
+	}
+}
@@ -1,15 +1,29 @@
 package analytics
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 	"time"
+	
+	"github.com/SagheerAkram/QuickCmd/web"
 )
 
+// slowApproverThreshold is the average response time above which an
+// approver is considered a bottleneck
+const slowApproverThreshold = 1 * time.Hour
+
+// slaWarningFraction is the fraction of its SLA a pending approval may wait
+// before it is reported as at risk
+const slaWarningFraction = 0.8
+
 // ApprovalAnalytics analyzes approval workflow patterns
 type ApprovalAnalytics struct {
 	approvals []*ApprovalRecord
+	slas      SLAConfig
+	now       func() time.Time
 }
 
 // ApprovalRecord represents an approval record
@@ -29,6 +43,39 @@ type ApprovalRecord struct {
 func NewApprovalAnalytics() *ApprovalAnalytics {
 	return &ApprovalAnalytics{
 		approvals: []*ApprovalRecord{},
+		slas:      DefaultSLAs(),
+		now:       time.Now,
+	}
+}
+
+// SLAConfig maps risk levels to the time within which their approvals must
+// be answered. Risk levels without an entry have no SLA.
+type SLAConfig map[string]time.Duration
+
+// DefaultSLAs returns the default approval response time SLA per risk level
+func DefaultSLAs() SLAConfig {
+	return SLAConfig{
+		"high":   30 * time.Minute,
+		"medium": 4 * time.Hour,
+		"low":    24 * time.Hour,
+	}
+}
+
+// SetSLA sets the response time SLA for a risk level. A zero window removes
+// the SLA for that level.
+func (aa *ApprovalAnalytics) SetSLA(riskLevel string, window time.Duration) {
+	if window <= 0 {
+		delete(aa.slas, riskLevel)
+		return
+	}
+	aa.slas[riskLevel] = window
+}
+
+// SetSLAConfig replaces all SLAs with config
+func (aa *ApprovalAnalytics) SetSLAConfig(config SLAConfig) {
+	aa.slas = SLAConfig{}
+	for riskLevel, window := range config {
+		aa.SetSLA(riskLevel, window)
 	}
 }
 
@@ -37,6 +84,51 @@ func (aa *ApprovalAnalytics) AddApproval(record *ApprovalRecord) {
 	aa.approvals = append(aa.approvals, record)
 }
 
+// LoadFromStore adds every approval in store requested at or after since.
+// Response times are measured from the request to the approval or rejection;
+// pending approvals have no response time.
+func (aa *ApprovalAnalytics) LoadFromStore(store *web.ApprovalStore, since time.Time) error {
+	approvals, err := store.ListApprovals(since)
+	if err != nil {
+		return fmt.Errorf("failed to load approvals: %w", err)
+	}
+	
+	for _, approval := range approvals {
+		aa.AddApproval(recordFromApproval(approval))
+	}
+	
+	return nil
+}
+
+// recordFromApproval converts a stored approval to an ApprovalRecord
+func recordFromApproval(approval *web.Approval) *ApprovalRecord {
+	record := &ApprovalRecord{
+		ID:          fmt.Sprintf("%d", approval.ID),
+		Command:     approval.Command,
+		Requester:   approval.RequestedBy,
+		Status:      string(approval.Status),
+		RequestedAt: approval.RequestedAt,
+		RiskLevel:   approval.RiskLevel,
+	}
+	
+	var respondedAt *time.Time
+	switch approval.Status {
+	case web.ApprovalStatusApproved:
+		record.Approver = approval.ApprovedBy
+		respondedAt = approval.ApprovedAt
+	case web.ApprovalStatusRejected:
+		record.Approver = approval.RejectedBy
+		respondedAt = approval.RejectedAt
+	}
+	
+	if respondedAt != nil && !respondedAt.IsZero() {
+		record.RespondedAt = *respondedAt
+		record.ResponseTime = respondedAt.Sub(approval.RequestedAt)
+	}
+	
+	return record
+}
+
 // GetMetrics calculates approval metrics
 func (aa *ApprovalAnalytics) GetMetrics() *ApprovalMetrics {
 	if len(aa.approvals) == 0 {
@@ -48,8 +140,16 @@ func (aa *ApprovalAnalytics) GetMetrics() *ApprovalMetrics {
 	}
 	
 	responseTimes := []time.Duration{}
+	now := aa.now()
 	
 	for _, approval := range aa.approvals {
+		if sla, elapsed, ok := aa.slaElapsed(approval, now); ok {
+			metrics.SLATracked++
+			if elapsed > sla {
+				metrics.SLABreaches++
+			}
+		}
+		
 		switch approval.Status {
 		case "approved":
 			metrics.Approved++
@@ -83,6 +183,11 @@ func (aa *ApprovalAnalytics) GetMetrics() *ApprovalMetrics {
 				metrics.MaxResponseTime = rt
 			}
 		}
+		
+		sorted := append([]time.Duration(nil), responseTimes...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		metrics.MedianResponseTime = median(sorted)
+		metrics.P95ResponseTime = percentile(sorted, 95)
 	}
 	
 	// Calculate approval rate
@@ -90,19 +195,59 @@ func (aa *ApprovalAnalytics) GetMetrics() *ApprovalMetrics {
 		metrics.ApprovalRate = float64(metrics.Approved) / float64(metrics.TotalApprovals) * 100
 	}
 	
+	if metrics.SLATracked > 0 {
+		metrics.SLABreachRate = float64(metrics.SLABreaches) / float64(metrics.SLATracked) * 100
+	}
+	
 	return metrics
 }
 
+// median returns the middle of sorted durations, averaging the two middle
+// values for an even count
+func median(sorted []time.Duration) time.Duration {
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted durations:
+// the smallest value with at least p% of values at or below it. With fewer
+// than 100/(100-p) values (20 for p95) this is the maximum.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(n)))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > n {
+		rank = n
+	}
+	return sorted[rank-1]
+}
+
 // ApprovalMetrics represents approval workflow metrics
 type ApprovalMetrics struct {
-	TotalApprovals  int
-	Approved        int
-	Rejected        int
-	Pending         int
-	ApprovalRate    float64
-	AvgResponseTime time.Duration
-	MinResponseTime time.Duration
-	MaxResponseTime time.Duration
+	TotalApprovals     int           `json:"total_approvals"`
+	Approved           int           `json:"approved"`
+	Rejected           int           `json:"rejected"`
+	Pending            int           `json:"pending"`
+	ApprovalRate       float64       `json:"approval_rate"`
+	AvgResponseTime    time.Duration `json:"avg_response_time_ns"`
+	MinResponseTime    time.Duration `json:"min_response_time_ns"`
+	MaxResponseTime    time.Duration `json:"max_response_time_ns"`
+	MedianResponseTime time.Duration `json:"median_response_time_ns"`
+	P95ResponseTime    time.Duration `json:"p95_response_time_ns"`
+	SLATracked         int           `json:"sla_tracked"`     // Approvals with an SLA for their risk level
+	SLABreaches        int           `json:"sla_breaches"`    // Tracked approvals over their SLA
+	SLABreachRate      float64       `json:"sla_breach_rate"` // Percentage of tracked approvals over SLA
 }
 
 // Format formats metrics for display
@@ -119,10 +264,17 @@ func (am *ApprovalMetrics) Format() string {
 	if am.AvgResponseTime > 0 {
 		sb.WriteString("Response Times:\n")
 		sb.WriteString(fmt.Sprintf("  Average:         %v\n", am.AvgResponseTime.Round(time.Second)))
+		sb.WriteString(fmt.Sprintf("  Median:          %v\n", am.MedianResponseTime.Round(time.Second)))
+		sb.WriteString(fmt.Sprintf("  95th percentile: %v\n", am.P95ResponseTime.Round(time.Second)))
 		sb.WriteString(fmt.Sprintf("  Fastest:         %v\n", am.MinResponseTime.Round(time.Second)))
 		sb.WriteString(fmt.Sprintf("  Slowest:         %v\n", am.MaxResponseTime.Round(time.Second)))
 	}
 	
+	if am.SLATracked > 0 {
+		sb.WriteString(fmt.Sprintf("\nSLA Breaches:      %d of %d (%.1f%%)\n",
+			am.SLABreaches, am.SLATracked, am.SLABreachRate))
+	}
+	
 	return sb.String()
 }
 
@@ -175,13 +327,13 @@ func (aa *ApprovalAnalytics) GetApproverStats() map[string]*ApproverStats {
 
 // ApproverStats represents statistics for an approver
 type ApproverStats struct {
-	Approver        string
-	TotalReviewed   int
-	Approved        int
-	Rejected        int
-	ApprovalRate    float64
-	AvgResponseTime time.Duration
-	ResponseTimes   []time.Duration
+	Approver        string          `json:"approver"`
+	TotalReviewed   int             `json:"total_reviewed"`
+	Approved        int             `json:"approved"`
+	Rejected        int             `json:"rejected"`
+	ApprovalRate    float64         `json:"approval_rate"`
+	AvgResponseTime time.Duration   `json:"avg_response_time_ns"`
+	ResponseTimes   []time.Duration `json:"-"`
 }
 
 // GetBottlenecks identifies approval bottlenecks
@@ -191,7 +343,7 @@ func (aa *ApprovalAnalytics) GetBottlenecks() []*Bottleneck {
 	// Find slow approvers
 	approverStats := aa.GetApproverStats()
 	for approver, stats := range approverStats {
-		if stats.AvgResponseTime > 1*time.Hour {
+		if stats.AvgResponseTime > slowApproverThreshold {
 			bottlenecks = append(bottlenecks, &Bottleneck{
 				Type:        "slow_approver",
 				Description: fmt.Sprintf("%s takes avg %v to respond", approver, stats.AvgResponseTime.Round(time.Minute)),
@@ -235,10 +387,213 @@ func (aa *ApprovalAnalytics) GetBottlenecks() []*Bottleneck {
 
 // Bottleneck represents an approval workflow bottleneck
 type Bottleneck struct {
-	Type        string
-	Description string
-	Severity    string
-	Suggestion  string
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+	Suggestion  string `json:"suggestion"`
+}
+
+// SuggestRebalancing recommends moving pending approvals away from slow or
+// overloaded approvers. Slow approvers should hand off everything; the rest
+// share the pending load evenly, with the fastest approvers taking the
+// excess first.
+func (aa *ApprovalAnalytics) SuggestRebalancing() []*Rebalance {
+	approverStats := aa.GetApproverStats()
+	
+	pending := make(map[string]int)
+	totalPending := 0
+	for _, approval := range aa.approvals {
+		if approval.Status == "pending" && approval.Approver != "" {
+			pending[approval.Approver]++
+			totalPending++
+		}
+	}
+	
+	var fast []*ApproverStats
+	for _, stats := range approverStats {
+		if stats.AvgResponseTime <= slowApproverThreshold {
+			fast = append(fast, stats)
+		}
+	}
+	if totalPending == 0 || len(fast) == 0 {
+		return []*Rebalance{}
+	}
+	
+	fairShare := (totalPending + len(fast) - 1) / len(fast)
+	
+	// Work out who has too many and who has room
+	type load struct {
+		stats  *ApproverStats
+		amount int
+	}
+	var donors, receivers []*load
+	for name, stats := range approverStats {
+		target := fairShare
+		if stats.AvgResponseTime > slowApproverThreshold {
+			target = 0
+		}
+		
+		if excess := pending[name] - target; excess > 0 {
+			donors = append(donors, &load{stats: stats, amount: excess})
+		} else if excess < 0 {
+			receivers = append(receivers, &load{stats: stats, amount: -excess})
+		}
+	}
+	
+	sort.Slice(donors, func(i, j int) bool {
+		if donors[i].amount != donors[j].amount {
+			return donors[i].amount > donors[j].amount
+		}
+		return donors[i].stats.Approver < donors[j].stats.Approver
+	})
+	
+	// Fastest first; approvers with no response history go last
+	sort.Slice(receivers, func(i, j int) bool {
+		a, b := receivers[i].stats, receivers[j].stats
+		if (len(a.ResponseTimes) == 0) != (len(b.ResponseTimes) == 0) {
+			return len(a.ResponseTimes) > 0
+		}
+		if a.AvgResponseTime != b.AvgResponseTime {
+			return a.AvgResponseTime < b.AvgResponseTime
+		}
+		return a.Approver < b.Approver
+	})
+	
+	suggestions := []*Rebalance{}
+	for _, donor := range donors {
+		reason := fmt.Sprintf("%s has %d pending approvals", donor.stats.Approver, pending[donor.stats.Approver])
+		if donor.stats.AvgResponseTime > slowApproverThreshold {
+			reason = fmt.Sprintf("%s takes avg %v to respond", donor.stats.Approver, donor.stats.AvgResponseTime.Round(time.Minute))
+		}
+		
+		for _, receiver := range receivers {
+			if donor.amount == 0 {
+				break
+			}
+			if receiver.amount == 0 {
+				continue
+			}
+			
+			count := donor.amount
+			if receiver.amount < count {
+				count = receiver.amount
+			}
+			donor.amount -= count
+			receiver.amount -= count
+			
+			suggestions = append(suggestions, &Rebalance{
+				From:   donor.stats.Approver,
+				To:     receiver.stats.Approver,
+				Count:  count,
+				Reason: reason,
+			})
+		}
+	}
+	
+	return suggestions
+}
+
+// Rebalance is a suggestion to reassign pending approvals
+type Rebalance struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Count  int    `json:"count"`
+	Reason string `json:"reason"`
+}
+
+// String describes the suggestion
+func (r *Rebalance) String() string {
+	return fmt.Sprintf("reassign %d approvals from %s to %s", r.Count, r.From, r.To)
+}
+
+// DetectSLABreaches reports approvals that took longer than the SLA for
+// their risk level, and pending approvals that have used most of it
+func (aa *ApprovalAnalytics) DetectSLABreaches() []*SLABreach {
+	breaches := []*SLABreach{}
+	now := aa.now()
+	
+	for _, approval := range aa.approvals {
+		sla, elapsed, ok := aa.slaElapsed(approval, now)
+		if !ok {
+			continue
+		}
+		
+		status := ""
+		switch {
+		case elapsed > sla:
+			status = "breached"
+		case approval.Status == "pending" && float64(elapsed) >= float64(sla)*slaWarningFraction:
+			status = "at_risk"
+		default:
+			continue
+		}
+		
+		breaches = append(breaches, &SLABreach{
+			ApprovalID:  approval.ID,
+			Command:     approval.Command,
+			Approver:    approval.Approver,
+			RiskLevel:   approval.RiskLevel,
+			RequestedAt: approval.RequestedAt,
+			Pending:     approval.Status == "pending",
+			SLA:         sla,
+			Elapsed:     elapsed,
+			Overrun:     elapsed - sla,
+			Status:      status,
+		})
+	}
+	
+	// Worst overruns first
+	sort.SliceStable(breaches, func(i, j int) bool {
+		return breaches[i].Overrun > breaches[j].Overrun
+	})
+	
+	return breaches
+}
+
+// GetSLABreaches returns approvals that have exceeded the SLA for their risk
+// level: answered late, or still pending past the deadline. Worst overruns
+// come first.
+func (aa *ApprovalAnalytics) GetSLABreaches() []*SLABreach {
+	breaches := []*SLABreach{}
+	for _, b := range aa.DetectSLABreaches() {
+		if b.Status == "breached" {
+			breaches = append(breaches, b)
+		}
+	}
+	return breaches
+}
+
+// slaElapsed returns the SLA for approval's risk level and how long the
+// approval took, or has been waiting if pending. ok is false if there is no
+// SLA or the approval was answered without a recorded response time.
+func (aa *ApprovalAnalytics) slaElapsed(approval *ApprovalRecord, now time.Time) (sla, elapsed time.Duration, ok bool) {
+	sla, ok = aa.slas[approval.RiskLevel]
+	if !ok {
+		return 0, 0, false
+	}
+	
+	if approval.Status == "pending" {
+		return sla, now.Sub(approval.RequestedAt), true
+	}
+	if approval.RespondedAt.IsZero() {
+		return 0, 0, false
+	}
+	return sla, approval.RespondedAt.Sub(approval.RequestedAt), true
+}
+
+// SLABreach represents an approval that breached or is close to breaching
+// its SLA
+type SLABreach struct {
+	ApprovalID  string        `json:"approval_id"`
+	Command     string        `json:"command"`
+	Approver    string        `json:"approver"`
+	RiskLevel   string        `json:"risk_level"`
+	RequestedAt time.Time     `json:"requested_at"`
+	Pending     bool          `json:"pending"`
+	SLA         time.Duration `json:"sla_ns"`
+	Elapsed     time.Duration `json:"elapsed_ns"`
+	Overrun     time.Duration `json:"overrun_ns"` // Elapsed - SLA; negative while at risk
+	Status      string        `json:"status"`     // "breached" or "at_risk"
 }
 
 // GetTrends analyzes approval trends over time
@@ -290,10 +645,10 @@ func (aa *ApprovalAnalytics) GetTrends() *ApprovalTrends {
 
 // ApprovalTrends represents approval workflow trends
 type ApprovalTrends struct {
-	ApprovalRateChange  float64
-	ResponseTimeChange  time.Duration
-	ApprovalTrend       string
-	ResponseTimeTrend   string
+	ApprovalRateChange  float64       `json:"approval_rate_change"`
+	ResponseTimeChange  time.Duration `json:"response_time_change_ns"`
+	ApprovalTrend       string        `json:"approval_trend"`
+	ResponseTimeTrend   string        `json:"response_time_trend"`
 }
 
 // Helper function
@@ -332,40 +687,109 @@ func calculateMetricsForSlice(approvals []*ApprovalRecord) *ApprovalMetrics {
 	return metrics
 }
 
-// GenerateReport generates a comprehensive approval analytics report
-func (aa *ApprovalAnalytics) GenerateReport() string {
+// Report is a structured approval analytics report
+type Report struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	Metrics     *ApprovalMetrics `json:"metrics"`
+	Approvers   []*ApproverStats `json:"approvers"` // Sorted by approver name
+	Bottlenecks []*Bottleneck    `json:"bottlenecks"`
+	Rebalancing []*Rebalance     `json:"rebalancing"`
+	SLABreaches []*SLABreach     `json:"sla_breaches"`
+	Trends      *ApprovalTrends  `json:"trends"`
+}
+
+// Report builds a structured approval analytics report
+func (aa *ApprovalAnalytics) Report() *Report {
+	approverStats := aa.GetApproverStats()
+	approvers := make([]*ApproverStats, 0, len(approverStats))
+	for _, stats := range approverStats {
+		approvers = append(approvers, stats)
+	}
+	sort.Slice(approvers, func(i, j int) bool {
+		return approvers[i].Approver < approvers[j].Approver
+	})
+	
+	return &Report{
+		GeneratedAt: time.Now(),
+		Metrics:     aa.GetMetrics(),
+		Approvers:   approvers,
+		Bottlenecks: aa.GetBottlenecks(),
+		Rebalancing: aa.SuggestRebalancing(),
+		SLABreaches: aa.DetectSLABreaches(),
+		Trends:      aa.GetTrends(),
+	}
+}
+
+// JSON returns the report as indented JSON
+func (r *Report) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal report: %w", err)
+	}
+	return data, nil
+}
+
+// Format formats the report for display
+func (r *Report) Format() string {
 	var sb strings.Builder
 	
 	// Overall metrics
-	metrics := aa.GetMetrics()
-	sb.WriteString(metrics.Format())
+	sb.WriteString(r.Metrics.Format())
 	sb.WriteString("\n")
 	
 	// Approver stats
 	sb.WriteString("👥 Approver Performance:\n\n")
-	approverStats := aa.GetApproverStats()
-	for approver, stats := range approverStats {
-		sb.WriteString(fmt.Sprintf("  %s:\n", approver))
+	for _, stats := range r.Approvers {
+		sb.WriteString(fmt.Sprintf("  %s:\n", stats.Approver))
 		sb.WriteString(fmt.Sprintf("    Reviewed: %d\n", stats.TotalReviewed))
 		sb.WriteString(fmt.Sprintf("    Approval Rate: %.1f%%\n", stats.ApprovalRate))
 		sb.WriteString(fmt.Sprintf("    Avg Response: %v\n\n", stats.AvgResponseTime.Round(time.Minute)))
 	}
 	
 	// Bottlenecks
-	bottlenecks := aa.GetBottlenecks()
-	if len(bottlenecks) > 0 {
+	if len(r.Bottlenecks) > 0 {
 		sb.WriteString("⚠️  Bottlenecks Detected:\n\n")
-		for _, b := range bottlenecks {
+		for _, b := range r.Bottlenecks {
 			sb.WriteString(fmt.Sprintf("  • %s\n", b.Description))
 			sb.WriteString(fmt.Sprintf("    💡 %s\n\n", b.Suggestion))
 		}
 	}
 	
+	// SLAs
+	if len(r.SLABreaches) > 0 {
+		sb.WriteString("⏰ SLA Breaches:\n\n")
+		for _, b := range r.SLABreaches {
+			state := "responded in"
+			if b.Pending {
+				state = "pending for"
+			}
+			sb.WriteString(fmt.Sprintf("  • %s %s (%s risk) %s %v, SLA %v\n",
+				b.ApprovalID, b.Status, b.RiskLevel, state, b.Elapsed.Round(time.Minute), b.SLA))
+			if b.Command != "" {
+				sb.WriteString(fmt.Sprintf("    %s\n", b.Command))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	
+	// Workload
+	if len(r.Rebalancing) > 0 {
+		sb.WriteString("⚖️  Workload Rebalancing:\n\n")
+		for _, rb := range r.Rebalancing {
+			sb.WriteString(fmt.Sprintf("  • %s (%s)\n", rb, rb.Reason))
+		}
+		sb.WriteString("\n")
+	}
+	
 	// Trends
-	trends := aa.GetTrends()
 	sb.WriteString("📈 Trends:\n\n")
-	sb.WriteString(fmt.Sprintf("  Approval Rate: %s\n", trends.ApprovalTrend))
-	sb.WriteString(fmt.Sprintf("  Response Time: %s\n", trends.ResponseTimeTrend))
+	sb.WriteString(fmt.Sprintf("  Approval Rate: %s\n", r.Trends.ApprovalTrend))
+	sb.WriteString(fmt.Sprintf("  Response Time: %s\n", r.Trends.ResponseTimeTrend))
 	
 	return sb.String()
 }
+
+// GenerateReport generates a comprehensive approval analytics report
+func (aa *ApprovalAnalytics) GenerateReport() string {
+	return aa.Report().Format()
+}
@@ -0,0 +1,409 @@
+package analytics
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SagheerAkram/QuickCmd/web"
+)
+
+func newTestApprovals() *ApprovalAnalytics {
+	aa := NewApprovalAnalytics()
+	base := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	add := func(approver, status string, offset, response time.Duration) {
+		record := &ApprovalRecord{
+			Approver:    approver,
+			Status:      status,
+			RequestedAt: base.Add(offset),
+		}
+		if status != "pending" {
+			record.RespondedAt = record.RequestedAt.Add(response)
+			record.ResponseTime = response
+		}
+		aa.AddApproval(record)
+	}
+
+	add("bob", "approved", 0, 10*time.Minute)
+	add("alice", "approved", time.Hour, 20*time.Minute)
+	add("bob", "rejected", 2*time.Hour, 3*time.Hour)
+	add("", "pending", 3*time.Hour, 0)
+
+	return aa
+}
+
+func TestApprovalAnalytics_Report(t *testing.T) {
+	aa := newTestApprovals()
+	report := aa.Report()
+
+	metrics := aa.GetMetrics()
+	if *report.Metrics != *metrics {
+		t.Errorf("Report().Metrics = %+v, want %+v", report.Metrics, metrics)
+	}
+	if report.Metrics.TotalApprovals != 4 || report.Metrics.Approved != 2 || report.Metrics.Pending != 1 {
+		t.Errorf("unexpected metrics: %+v", report.Metrics)
+	}
+
+	if len(report.Approvers) != 2 {
+		t.Fatalf("Report().Approvers has %d entries, want 2", len(report.Approvers))
+	}
+	if report.Approvers[0].Approver != "alice" || report.Approvers[1].Approver != "bob" {
+		t.Errorf("approvers not sorted by name: %s, %s", report.Approvers[0].Approver, report.Approvers[1].Approver)
+	}
+	bob := report.Approvers[1]
+	if bob.TotalReviewed != 2 || bob.ApprovalRate != 50 || bob.AvgResponseTime != 95*time.Minute {
+		t.Errorf("unexpected stats for bob: %+v", bob)
+	}
+
+	if len(report.Bottlenecks) != 1 || report.Bottlenecks[0].Type != "slow_approver" {
+		t.Errorf("Report().Bottlenecks = %+v, want one slow_approver", report.Bottlenecks)
+	}
+
+	if *report.Trends != *aa.GetTrends() {
+		t.Errorf("Report().Trends = %+v, want %+v", report.Trends, aa.GetTrends())
+	}
+}
+
+func TestReport_JSON(t *testing.T) {
+	data, err := newTestApprovals().Report().JSON()
+	if err != nil {
+		t.Fatalf("JSON() error: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("JSON() output is not valid JSON: %v", err)
+	}
+
+	for _, key := range []string{"generated_at", "metrics", "approvers", "bottlenecks", "trends"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("JSON report missing %q", key)
+		}
+	}
+
+	metrics := decoded["metrics"].(map[string]interface{})
+	if metrics["total_approvals"] != float64(4) {
+		t.Errorf("metrics.total_approvals = %v, want 4", metrics["total_approvals"])
+	}
+
+	if strings.Contains(string(data), "ResponseTimes") {
+		t.Error("JSON report exposes raw response times")
+	}
+}
+
+func TestApprovalAnalytics_GenerateReport(t *testing.T) {
+	report := newTestApprovals().GenerateReport()
+
+	for _, want := range []string{"Approval Workflow Analytics", "alice:", "bob:", "Bottlenecks Detected", "Trends"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("GenerateReport() missing %q", want)
+		}
+	}
+
+	// Approvers are listed in a stable order
+	if strings.Index(report, "alice:") > strings.Index(report, "bob:") {
+		t.Error("GenerateReport() approvers not sorted")
+	}
+}
+
+func TestApprovalAnalytics_SuggestRebalancing(t *testing.T) {
+	aa := NewApprovalAnalytics()
+	base := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	addDone := func(approver string, response time.Duration) {
+		aa.AddApproval(&ApprovalRecord{
+			Approver:     approver,
+			Status:       "approved",
+			RequestedAt:  base,
+			RespondedAt:  base.Add(response),
+			ResponseTime: response,
+		})
+	}
+	addPending := func(approver string, n int) {
+		for i := 0; i < n; i++ {
+			aa.AddApproval(&ApprovalRecord{Approver: approver, Status: "pending", RequestedAt: base})
+		}
+	}
+
+	// alice is fast but swamped, bob is fast and idle, carol is slow
+	addDone("alice", 5*time.Minute)
+	addDone("bob", 10*time.Minute)
+	addDone("carol", 4*time.Hour)
+	addPending("alice", 8)
+	addPending("carol", 2)
+
+	suggestions := aa.SuggestRebalancing()
+
+	moved := make(map[string]int)
+	for _, s := range suggestions {
+		if s.To != "bob" {
+			t.Errorf("unexpected suggestion: %s", s)
+		}
+		moved[s.From] += s.Count
+	}
+
+	if moved["alice"] != 3 {
+		t.Errorf("moved %d approvals from alice, want 3", moved["alice"])
+	}
+	if moved["carol"] != 2 {
+		t.Errorf("moved %d approvals from slow carol, want 2", moved["carol"])
+	}
+
+	if len(suggestions) > 0 && suggestions[0].String() != "reassign 3 approvals from alice to bob" {
+		t.Errorf("String() = %q", suggestions[0].String())
+	}
+}
+
+func TestApprovalAnalytics_SuggestRebalancing_Balanced(t *testing.T) {
+	aa := NewApprovalAnalytics()
+	for _, approver := range []string{"alice", "bob", "alice", "bob"} {
+		aa.AddApproval(&ApprovalRecord{Approver: approver, Status: "pending"})
+	}
+
+	if suggestions := aa.SuggestRebalancing(); len(suggestions) != 0 {
+		t.Errorf("SuggestRebalancing() = %v, want none for a balanced workload", suggestions)
+	}
+}
+
+func TestApprovalAnalytics_DetectSLABreaches(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	aa := NewApprovalAnalytics()
+	aa.now = func() time.Time { return now }
+	aa.SetSLA("medium", 2*time.Hour)
+
+	aa.AddApproval(&ApprovalRecord{ID: "old-high", RiskLevel: "high", Status: "pending", RequestedAt: now.Add(-3 * time.Hour)})
+	aa.AddApproval(&ApprovalRecord{ID: "near-medium", RiskLevel: "medium", Status: "pending", RequestedAt: now.Add(-100 * time.Minute)})
+	aa.AddApproval(&ApprovalRecord{ID: "fresh-high", RiskLevel: "high", Status: "pending", RequestedAt: now.Add(-10 * time.Minute)})
+	aa.AddApproval(&ApprovalRecord{
+		ID:          "slow-high",
+		RiskLevel:   "high",
+		Status:      "approved",
+		RequestedAt: now.Add(-5 * time.Hour),
+		RespondedAt: now.Add(-3 * time.Hour),
+	})
+	aa.AddApproval(&ApprovalRecord{ID: "no-sla", RiskLevel: "safe", Status: "pending", RequestedAt: now.Add(-48 * time.Hour)})
+
+	breaches := aa.DetectSLABreaches()
+
+	got := make(map[string]*SLABreach)
+	for _, b := range breaches {
+		got[b.ApprovalID] = b
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("DetectSLABreaches() reported %d approvals, want 3: %v", len(got), breaches)
+	}
+	if b := got["old-high"]; b == nil || b.Status != "breached" || !b.Pending || b.Elapsed != 3*time.Hour {
+		t.Errorf("old-high = %+v, want pending breach", b)
+	}
+	if b := got["near-medium"]; b == nil || b.Status != "at_risk" || b.SLA != 2*time.Hour {
+		t.Errorf("near-medium = %+v, want at_risk", b)
+	}
+	if b := got["slow-high"]; b == nil || b.Status != "breached" || b.Pending {
+		t.Errorf("slow-high = %+v, want responded breach", b)
+	}
+
+	// Largest overrun first
+	if breaches[0].ApprovalID != "old-high" {
+		t.Errorf("first breach = %s, want old-high", breaches[0].ApprovalID)
+	}
+
+	// Removing the SLA stops reporting
+	aa.SetSLA("high", 0)
+	for _, b := range aa.DetectSLABreaches() {
+		if b.RiskLevel == "high" {
+			t.Errorf("high risk approval reported after SLA removed: %+v", b)
+		}
+	}
+}
+
+func TestApprovalAnalytics_ResponseTimePercentiles(t *testing.T) {
+	base := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	newAnalytics := func(minutes ...int) *ApprovalAnalytics {
+		aa := NewApprovalAnalytics()
+		for _, m := range minutes {
+			response := time.Duration(m) * time.Minute
+			aa.AddApproval(&ApprovalRecord{
+				Status:       "approved",
+				RequestedAt:  base,
+				RespondedAt:  base.Add(response),
+				ResponseTime: response,
+			})
+		}
+		return aa
+	}
+
+	// 1..99 minutes plus one approver asleep for 10 hours
+	minutes := []int{600}
+	for m := 99; m >= 1; m-- {
+		minutes = append(minutes, m)
+	}
+	metrics := newAnalytics(minutes...).GetMetrics()
+	if metrics.MedianResponseTime != 50*time.Minute+30*time.Second {
+		t.Errorf("MedianResponseTime = %v, want 50m30s", metrics.MedianResponseTime)
+	}
+	if metrics.P95ResponseTime != 95*time.Minute {
+		t.Errorf("P95ResponseTime = %v, want 95m", metrics.P95ResponseTime)
+	}
+	if metrics.AvgResponseTime <= metrics.MedianResponseTime {
+		t.Errorf("AvgResponseTime = %v, want it skewed above the median", metrics.AvgResponseTime)
+	}
+
+	// Small samples fall back to the extremes instead of indexing out of range
+	tests := []struct {
+		minutes []int
+		median  time.Duration
+		p95     time.Duration
+	}{
+		{[]int{7}, 7 * time.Minute, 7 * time.Minute},
+		{[]int{10, 2}, 6 * time.Minute, 10 * time.Minute},
+		{[]int{5, 1, 30}, 5 * time.Minute, 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		metrics := newAnalytics(tt.minutes...).GetMetrics()
+		if metrics.MedianResponseTime != tt.median || metrics.P95ResponseTime != tt.p95 {
+			t.Errorf("%v: median/p95 = %v/%v, want %v/%v", tt.minutes,
+				metrics.MedianResponseTime, metrics.P95ResponseTime, tt.median, tt.p95)
+		}
+	}
+
+	if out := metrics.Format(); !strings.Contains(out, "Median:") || !strings.Contains(out, "95th percentile:") {
+		t.Errorf("Format() missing percentiles:\n%s", out)
+	}
+}
+
+func TestApprovalAnalytics_GetSLABreaches(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	aa := NewApprovalAnalytics()
+	aa.now = func() time.Time { return now }
+	aa.SetSLAConfig(SLAConfig{"high": 30 * time.Minute})
+
+	// Pending past the deadline
+	aa.AddApproval(&ApprovalRecord{
+		ID:          "overdue",
+		Command:     "terraform destroy",
+		RiskLevel:   "high",
+		Status:      "pending",
+		RequestedAt: now.Add(-45 * time.Minute),
+	})
+	// Answered, but late
+	aa.AddApproval(&ApprovalRecord{
+		ID:          "late",
+		Command:     "rm -rf /var/cache/app",
+		Approver:    "bob",
+		RiskLevel:   "high",
+		Status:      "approved",
+		RequestedAt: now.Add(-2 * time.Hour),
+		RespondedAt: now.Add(-2*time.Hour + 40*time.Minute),
+	})
+	// Answered in time
+	aa.AddApproval(&ApprovalRecord{
+		ID:          "on-time",
+		RiskLevel:   "high",
+		Status:      "rejected",
+		RequestedAt: now.Add(-time.Hour),
+		RespondedAt: now.Add(-time.Hour + 10*time.Minute),
+	})
+	// At risk but not yet breached
+	aa.AddApproval(&ApprovalRecord{ID: "at-risk", RiskLevel: "high", Status: "pending", RequestedAt: now.Add(-25 * time.Minute)})
+	// SLAConfig replaced the defaults, so medium has no SLA
+	aa.AddApproval(&ApprovalRecord{ID: "untracked", RiskLevel: "medium", Status: "pending", RequestedAt: now.Add(-48 * time.Hour)})
+
+	breaches := aa.GetSLABreaches()
+	if len(breaches) != 2 {
+		t.Fatalf("GetSLABreaches() = %d breaches, want 2: %v", len(breaches), breaches)
+	}
+
+	overdue, late := breaches[0], breaches[1]
+	if overdue.ApprovalID != "overdue" || !overdue.Pending || overdue.Overrun != 15*time.Minute {
+		t.Errorf("breaches[0] = %+v, want overdue pending approval 15m over SLA", overdue)
+	}
+	if overdue.Command != "terraform destroy" || !overdue.RequestedAt.Equal(now.Add(-45*time.Minute)) {
+		t.Errorf("breaches[0] command/requested = %q/%v", overdue.Command, overdue.RequestedAt)
+	}
+	if late.ApprovalID != "late" || late.Pending || late.Approver != "bob" || late.Overrun != 10*time.Minute {
+		t.Errorf("breaches[1] = %+v, want late approval by bob 10m over SLA", late)
+	}
+
+	metrics := aa.GetMetrics()
+	if metrics.SLATracked != 4 || metrics.SLABreaches != 2 || metrics.SLABreachRate != 50 {
+		t.Errorf("GetMetrics() SLA = %d/%d (%.1f%%), want 2/4 (50%%)",
+			metrics.SLABreaches, metrics.SLATracked, metrics.SLABreachRate)
+	}
+}
+
+func TestApprovalAnalytics_LoadFromStore(t *testing.T) {
+	store, err := web.NewApprovalStore(filepath.Join(t.TempDir(), "approvals.db"))
+	if err != nil {
+		t.Fatalf("NewApprovalStore() error: %v", err)
+	}
+	defer store.Close()
+	
+	requestedAt := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	create := func(command string, at time.Time) int {
+		id, err := store.CreateApproval(&web.Approval{
+			Command:     command,
+			RiskLevel:   "high",
+			RequestedBy: "carol",
+			RequestedAt: at,
+		})
+		if err != nil {
+			t.Fatalf("CreateApproval() error: %v", err)
+		}
+		return id
+	}
+	
+	approved := create("rm -rf ./build", requestedAt)
+	rejected := create("terraform destroy", requestedAt)
+	create("kubectl delete pod web", requestedAt)
+	create("aws s3 rb s3://old", requestedAt.Add(-30*24*time.Hour))
+	
+	if err := store.ApproveApproval(approved, "bob", "APPROVE", ""); err != nil {
+		t.Fatalf("ApproveApproval() error: %v", err)
+	}
+	if err := store.RejectApproval(rejected, "alice", "too risky"); err != nil {
+		t.Fatalf("RejectApproval() error: %v", err)
+	}
+	
+	aa := NewApprovalAnalytics()
+	if err := aa.LoadFromStore(store, requestedAt.Add(-time.Minute)); err != nil {
+		t.Fatalf("LoadFromStore() error: %v", err)
+	}
+	
+	metrics := aa.GetMetrics()
+	if metrics.TotalApprovals != 3 || metrics.Approved != 1 || metrics.Rejected != 1 || metrics.Pending != 1 {
+		t.Errorf("GetMetrics() = %+v, want 1 approved, 1 rejected, 1 pending", metrics)
+	}
+	if metrics.AvgResponseTime < 2*time.Hour || metrics.AvgResponseTime > 2*time.Hour+time.Minute {
+		t.Errorf("AvgResponseTime = %v, want about 2h", metrics.AvgResponseTime)
+	}
+	
+	stats := aa.GetApproverStats()
+	if stats["bob"] == nil || stats["bob"].Approved != 1 {
+		t.Errorf("bob stats = %+v, want 1 approval", stats["bob"])
+	}
+	if stats["alice"] == nil || stats["alice"].Rejected != 1 {
+		t.Errorf("alice stats = %+v, want 1 rejection", stats["alice"])
+	}
+	
+	if report := aa.GenerateReport(); !strings.Contains(report, "bob") {
+		t.Errorf("GenerateReport() missing approver stats:\n%s", report)
+	}
+}
+
+func TestRecordFromApproval_NilTimestamps(t *testing.T) {
+	// An approved row whose approved_at was never written
+	record := recordFromApproval(&web.Approval{
+		ID:          7,
+		Status:      web.ApprovalStatusApproved,
+		ApprovedBy:  "bob",
+		RequestedAt: time.Now(),
+	})
+	
+	if record.Approver != "bob" || !record.RespondedAt.IsZero() || record.ResponseTime != 0 {
+		t.Errorf("recordFromApproval() = %+v, want approver without response time", record)
+	}
+}
@@ -0,0 +1,126 @@
+package analytics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// budgetPeriodFormat identifies a billing period (calendar month, UTC)
+const budgetPeriodFormat = "2006-01"
+
+// BudgetTracker accumulates the estimated monthly cost of commands executed
+// in the current billing period, so budgets are checked against the running
+// total rather than one command at a time. Spend is persisted as JSON and
+// resets at month boundaries.
+type BudgetTracker struct {
+	mu         sync.Mutex
+	path       string
+	calculator *CostCalculator
+	now        func() time.Time
+	state      budgetState
+}
+
+// budgetState is the persisted spend for one billing period
+type budgetState struct {
+	Period  string         `json:"period"`
+	Spent   float64        `json:"spent"`
+	Entries []*BudgetEntry `json:"entries"`
+}
+
+// BudgetEntry is a command recorded against the budget
+type BudgetEntry struct {
+	Command     string    `json:"command"`
+	MonthlyCost float64   `json:"monthly_cost"`
+	RecordedAt  time.Time `json:"recorded_at"`
+}
+
+// NewBudgetTracker creates a tracker persisted at path, loading any spend
+// already recorded there
+func NewBudgetTracker(path string, calculator *CostCalculator) (*BudgetTracker, error) {
+	bt := &BudgetTracker{
+		path:       path,
+		calculator: calculator,
+		now:        time.Now,
+	}
+	
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return bt, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read budget file: %w", err)
+	}
+	
+	if err := json.Unmarshal(data, &bt.state); err != nil {
+		return nil, fmt.Errorf("failed to parse budget file: %w", err)
+	}
+	
+	return bt, nil
+}
+
+// Spent returns the estimated monthly cost recorded this billing period
+func (bt *BudgetTracker) Spent() float64 {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	
+	bt.rollover()
+	return bt.state.Spent
+}
+
+// Record adds an executed command's estimated monthly cost to this billing
+// period and returns that cost
+func (bt *BudgetTracker) Record(command string) (float64, error) {
+	cost := bt.calculator.EstimateCost(command).MonthlyCost
+	
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	
+	bt.rollover()
+	bt.state.Spent += cost
+	bt.state.Entries = append(bt.state.Entries, &BudgetEntry{
+		Command:     command,
+		MonthlyCost: cost,
+		RecordedAt:  bt.now(),
+	})
+	
+	return cost, bt.save()
+}
+
+// CheckBudgetCumulative checks whether running command would take this
+// billing period's spend over monthlyBudget. The command is not recorded.
+func (bt *BudgetTracker) CheckBudgetCumulative(command string, monthlyBudget float64) *BudgetAlert {
+	cost := bt.calculator.EstimateCost(command).MonthlyCost
+	return newBudgetAlert(monthlyBudget, bt.Spent(), cost)
+}
+
+// rollover starts a new billing period if the month has changed. Callers
+// must hold bt.mu.
+func (bt *BudgetTracker) rollover() {
+	period := bt.now().UTC().Format(budgetPeriodFormat)
+	if bt.state.Period != period {
+		bt.state = budgetState{Period: period}
+	}
+}
+
+// save writes the current period's spend to disk. Callers must hold bt.mu.
+func (bt *BudgetTracker) save() error {
+	data, err := json.MarshalIndent(bt.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal budget: %w", err)
+	}
+	
+	if err := os.MkdirAll(filepath.Dir(bt.path), 0755); err != nil {
+		return fmt.Errorf("failed to create budget directory: %w", err)
+	}
+	
+	if err := os.WriteFile(bt.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write budget file: %w", err)
+	}
+	
+	return nil
+}
@@ -0,0 +1,69 @@
+package analytics
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBudgetTracker_CheckBudgetCumulative(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "budget.json")
+	bt, err := NewBudgetTracker(path, NewCostCalculator())
+	if err != nil {
+		t.Fatalf("NewBudgetTracker() error: %v", err)
+	}
+	
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	bt.now = func() time.Time { return now }
+	
+	// One t3.medium costs 0.0416 * 730 = $30.368/month
+	const command = "aws ec2 run-instances --instance-type t3.medium --count 1"
+	const cost = 30.368
+	
+	for i := 0; i < 3; i++ {
+		alert := bt.CheckBudgetCumulative(command, 100)
+		if alert.Exceeded {
+			t.Fatalf("command %d exceeded budget with $%.2f spent", i+1, alert.Spent)
+		}
+		if _, err := bt.Record(command); err != nil {
+			t.Fatalf("Record() error: %v", err)
+		}
+	}
+	
+	// A single command still fits the budget on its own...
+	if NewCostCalculator().CheckBudget(command, 100).Exceeded {
+		t.Fatal("CheckBudget() exceeded for a single command")
+	}
+	
+	// ...but not on top of what was already spent
+	alert := bt.CheckBudgetCumulative(command, 100)
+	if !alert.Exceeded {
+		t.Errorf("CheckBudgetCumulative() not exceeded with $%.2f spent", alert.Spent)
+	}
+	if math.Abs(alert.Spent-3*cost) > 1e-6 {
+		t.Errorf("Spent = %v, want %v", alert.Spent, 3*cost)
+	}
+	if math.Abs(alert.Remaining-(100-4*cost)) > 1e-6 {
+		t.Errorf("Remaining = %v, want %v", alert.Remaining, 100-4*cost)
+	}
+	
+	// Spend persists across trackers
+	reloaded, err := NewBudgetTracker(path, NewCostCalculator())
+	if err != nil {
+		t.Fatalf("NewBudgetTracker() reload error: %v", err)
+	}
+	reloaded.now = bt.now
+	if math.Abs(reloaded.Spent()-3*cost) > 1e-6 {
+		t.Errorf("reloaded Spent() = %v, want %v", reloaded.Spent(), 3*cost)
+	}
+	
+	// A new month starts from zero
+	now = time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	if spent := reloaded.Spent(); spent != 0 {
+		t.Errorf("Spent() after month boundary = %v, want 0", spent)
+	}
+	if alert := reloaded.CheckBudgetCumulative(command, 100); alert.Exceeded {
+		t.Error("CheckBudgetCumulative() exceeded at start of new month")
+	}
+}
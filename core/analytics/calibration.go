@@ -0,0 +1,93 @@
+package analytics
+
+import (
+	"github.com/SagheerAkram/QuickCmd/core/learning/store"
+)
+
+// calibrationKey is the learning store key holding calibration outcomes
+const calibrationKey = "outcomes"
+
+// minCalibrationSamples is how often a command must have been offered
+// before its confidence is adjusted
+const minCalibrationSamples = 5
+
+// Calibration tracks how often the user goes on to run each command they
+// are offered, and lowers the confidence of commands they rarely pick, so
+// a template that keeps matching the wrong prompts stops being ranked
+// first.
+type Calibration struct {
+	outcomes map[string]*CalibrationOutcome // command pattern -> outcome
+}
+
+// CalibrationOutcome counts how often a command was offered and run
+type CalibrationOutcome struct {
+	Offered int `json:"offered"`
+	Run     int `json:"run"`
+}
+
+// NewCalibration creates an empty calibration
+func NewCalibration() *Calibration {
+	return &Calibration{
+		outcomes: make(map[string]*CalibrationOutcome),
+	}
+}
+
+// Record counts the commands offered for a prompt and the one run
+func (c *Calibration) Record(offered []string, run string) {
+	// A command offered twice for one prompt counts once
+	seen := make(map[string]bool)
+	for _, command := range offered {
+		pattern := extractPattern(command)
+		if seen[pattern] {
+			continue
+		}
+		seen[pattern] = true
+		c.outcome(pattern).Offered++
+	}
+	c.outcome(extractPattern(run)).Run++
+}
+
+// Adjust returns confidence scaled by how often command has been run when
+// offered. Commands with too few samples, or run at least half the time,
+// keep their confidence; one never run is halved.
+func (c *Calibration) Adjust(command string, confidence int) int {
+	outcome := c.outcomes[extractPattern(command)]
+	if outcome == nil || outcome.Offered < minCalibrationSamples {
+		return confidence
+	}
+
+	rate := float64(outcome.Run) / float64(outcome.Offered)
+	if rate >= 0.5 {
+		return confidence
+	}
+	return int(float64(confidence) * (0.5 + rate))
+}
+
+// SaveTo persists the calibration outcomes in ns
+func (c *Calibration) SaveTo(ns *store.Namespace) error {
+	return ns.Put(calibrationKey, c.outcomes)
+}
+
+// LoadFrom restores outcomes saved by SaveTo, replacing what has been
+// recorded so far. An empty namespace is not an error.
+func (c *Calibration) LoadFrom(ns *store.Namespace) error {
+	outcomes := make(map[string]*CalibrationOutcome)
+	if _, err := ns.Get(calibrationKey, &outcomes); err != nil {
+		return err
+	}
+	if outcomes == nil {
+		outcomes = make(map[string]*CalibrationOutcome)
+	}
+	c.outcomes = outcomes
+	return nil
+}
+
+// outcome returns pattern's outcome, creating it if needed
+func (c *Calibration) outcome(pattern string) *CalibrationOutcome {
+	o := c.outcomes[pattern]
+	if o == nil {
+		o = &CalibrationOutcome{}
+		c.outcomes[pattern] = o
+	}
+	return o
+}
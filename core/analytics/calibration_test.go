@@ -0,0 +1,62 @@
+package analytics
+
+import (
+	"testing"
+
+	"github.com/SagheerAkram/QuickCmd/core/learning/store"
+)
+
+func TestCalibration_Adjust(t *testing.T) {
+	c := NewCalibration()
+
+	// The user keeps running find over the offered ls
+	for i := 0; i < minCalibrationSamples; i++ {
+		c.Record([]string{"find . -name '*.log'", "ls -la", "ls -l"}, "find . -name '*.log'")
+	}
+
+	if got := c.Adjust("find . -type f", 90); got != 90 {
+		t.Errorf("Adjust(find) = %d, want 90", got)
+	}
+	if got := c.Adjust("ls", 90); got != 45 {
+		t.Errorf("Adjust(ls) = %d, want 45 for a command never run", got)
+	}
+	if got := c.Adjust("du -sh .", 90); got != 90 {
+		t.Errorf("Adjust(du) = %d, want 90 without samples", got)
+	}
+
+	// Too few samples leave confidence alone
+	c = NewCalibration()
+	c.Record([]string{"ls -la"}, "find .")
+	if got := c.Adjust("ls -la", 90); got != 90 {
+		t.Errorf("Adjust() after one sample = %d, want 90", got)
+	}
+}
+
+func TestCalibration_SaveLoad(t *testing.T) {
+	learned, err := store.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns := learned.Namespace("calibration")
+
+	c := NewCalibration()
+	for i := 0; i < minCalibrationSamples; i++ {
+		c.Record([]string{"ls -la", "find ."}, "find .")
+	}
+	if err := c.SaveTo(ns); err != nil {
+		t.Fatalf("SaveTo() error: %v", err)
+	}
+
+	loaded := NewCalibration()
+	if err := loaded.LoadFrom(ns); err != nil {
+		t.Fatalf("LoadFrom() error: %v", err)
+	}
+	if got := loaded.Adjust("ls -la", 80); got != 40 {
+		t.Errorf("Adjust() after reload = %d, want 40", got)
+	}
+
+	// An empty namespace is not an error
+	if err := NewCalibration().LoadFrom(learned.Namespace("empty")); err != nil {
+		t.Errorf("LoadFrom(empty) error: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxTopContributors is the number of most expensive commands an aggregate
+// reports
+const maxTopContributors = 3
+
+// CostAggregate is the combined cost of several commands run together, such
+// as the steps of a macro or a batch, across all cloud providers
+type CostAggregate struct {
+	Estimates   []*CostEstimate // one per command, in order
+	TotalCost   float64         // per hour
+	MonthlyCost float64
+	Currency    string
+
+	// TopContributors are the priced commands, most expensive first, up to
+	// maxTopContributors
+	TopContributors []*CostEstimate
+
+	// Savings is the combined monthly saving from taking the best suggestion
+	// for each command
+	Savings float64
+}
+
+// AggregateCosts estimates each command and sums the results
+func (cc *CostCalculator) AggregateCosts(commands []string) *CostAggregate {
+	aggregate := &CostAggregate{
+		Estimates: make([]*CostEstimate, 0, len(commands)),
+		Currency:  "USD",
+	}
+
+	var priced []*CostEstimate
+	for _, command := range commands {
+		estimate := cc.EstimateCost(command)
+		aggregate.Estimates = append(aggregate.Estimates, estimate)
+
+		aggregate.TotalCost += estimate.TotalCost
+		aggregate.MonthlyCost += estimate.MonthlyCost
+		aggregate.Savings += estimate.PotentialSavings
+		if estimate.MonthlyCost > 0 {
+			priced = append(priced, estimate)
+		}
+	}
+
+	// Stable, so equal costs keep command order
+	sort.SliceStable(priced, func(i, j int) bool { return priced[i].MonthlyCost > priced[j].MonthlyCost })
+	if len(priced) > maxTopContributors {
+		priced = priced[:maxTopContributors]
+	}
+	aggregate.TopContributors = priced
+
+	return aggregate
+}
+
+// Format formats the aggregate for display
+func (ca *CostAggregate) Format() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("\n💰 Combined Cost Estimate (%d commands)\n\n", len(ca.Estimates)))
+
+	if ca.MonthlyCost == 0 {
+		sb.WriteString("No cost information available for these commands\n")
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("Total: $%.2f/hour ($%.2f/month)\n", ca.TotalCost, ca.MonthlyCost))
+
+	sb.WriteString("\nBiggest contributors:\n")
+	for _, estimate := range ca.TopContributors {
+		share := estimate.MonthlyCost / ca.MonthlyCost * 100
+		sb.WriteString(fmt.Sprintf("  • $%.2f/month (%.0f%%) %s\n", estimate.MonthlyCost, share, estimate.Command))
+	}
+
+	if ca.Savings > 0 {
+		sb.WriteString(fmt.Sprintf("\n💰 Up to $%.2f/month could be saved across these commands\n", ca.Savings))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,42 @@
+package analytics
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCostCalculator_AggregateCosts(t *testing.T) {
+	large := "aws ec2 run-instances --instance-type t3.large --count 10"
+	micro := "aws ec2 run-instances --instance-type t3.micro --count 2"
+
+	aggregate := NewCostCalculator().AggregateCosts([]string{micro, "ls -la", large})
+
+	if len(aggregate.Estimates) != 3 {
+		t.Fatalf("got %d estimates, want 3", len(aggregate.Estimates))
+	}
+	if want := (0.832 + 0.0208) * 730; math.Abs(aggregate.MonthlyCost-want) > 1e-6 {
+		t.Errorf("MonthlyCost = %v, want %v", aggregate.MonthlyCost, want)
+	}
+	if math.Abs(aggregate.TotalCost-0.8528) > 1e-9 {
+		t.Errorf("TotalCost = %v, want 0.8528", aggregate.TotalCost)
+	}
+
+	// The unpriced command isn't a contributor; the large instances lead
+	if len(aggregate.TopContributors) != 2 {
+		t.Fatalf("TopContributors = %d, want 2", len(aggregate.TopContributors))
+	}
+	if aggregate.TopContributors[0].Command != large || aggregate.TopContributors[1].Command != micro {
+		t.Errorf("TopContributors = %q, %q", aggregate.TopContributors[0].Command, aggregate.TopContributors[1].Command)
+	}
+
+	// Spot instances are the best suggestion for both commands
+	if want := 0.7 * (0.832 + 0.0208) * 730; math.Abs(aggregate.Savings-want) > 1e-6 {
+		t.Errorf("Savings = %v, want %v", aggregate.Savings, want)
+	}
+
+	formatted := aggregate.Format()
+	if !strings.Contains(formatted, "Total: $0.85/hour ($622.54/month)") || !strings.Contains(formatted, "(98%) "+large) {
+		t.Errorf("Format() =\n%s", formatted)
+	}
+}
@@ -2,7 +2,11 @@ package analytics
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
+	
+	"github.com/SagheerAkram/QuickCmd/core/cmdparse"
 )
 
 // CostCalculator estimates cloud operation costs
@@ -22,16 +26,22 @@ type ResourcePricing struct {
 // CostEstimate represents a cost estimation
 type CostEstimate struct {
 	Command      string
+	Provider     string // cloud of the priced resources, "multi-cloud" if mixed
 	Resources    []*ResourceCost
 	TotalCost    float64
 	MonthlyCost  float64
 	Currency     string
 	Breakdown    []string
 	Savings      []string
+	
+	// PotentialSavings is the largest monthly saving among Savings. The
+	// suggestions are alternatives, so their savings don't add up.
+	PotentialSavings float64
 }
 
 // ResourceCost represents cost for a specific resource
 type ResourceCost struct {
+	Provider string // aws, gcp, azure or kubernetes
 	Type     string
 	Quantity int
 	Unit     string
@@ -65,6 +75,21 @@ func (cc *CostCalculator) loadPricing() {
 		PricePerHour: 0.0416,
 		Currency:     "USD",
 	}
+	cc.pricing["ec2-t3.large"] = &ResourcePricing{
+		ResourceType: "EC2 t3.large",
+		PricePerHour: 0.0832,
+		Currency:     "USD",
+	}
+	cc.pricing["ec2-t3.xlarge"] = &ResourcePricing{
+		ResourceType: "EC2 t3.xlarge",
+		PricePerHour: 0.1664,
+		Currency:     "USD",
+	}
+	cc.pricing["ec2-t3.2xlarge"] = &ResourcePricing{
+		ResourceType: "EC2 t3.2xlarge",
+		PricePerHour: 0.3328,
+		Currency:     "USD",
+	}
 	
 	// AWS S3 pricing
 	cc.pricing["s3-storage"] = &ResourcePricing{
@@ -80,6 +105,65 @@ func (cc *CostCalculator) loadPricing() {
 		Currency:     "USD",
 	}
 	
+	// GCP Compute Engine pricing (on-demand, us-central1)
+	cc.pricing["gce-e2-micro"] = &ResourcePricing{
+		ResourceType: "GCE e2-micro",
+		PricePerHour: 0.0084,
+		Currency:     "USD",
+	}
+	cc.pricing["gce-e2-small"] = &ResourcePricing{
+		ResourceType: "GCE e2-small",
+		PricePerHour: 0.0168,
+		Currency:     "USD",
+	}
+	cc.pricing["gce-e2-medium"] = &ResourcePricing{
+		ResourceType: "GCE e2-medium",
+		PricePerHour: 0.0335,
+		Currency:     "USD",
+	}
+	cc.pricing["gce-e2-standard-2"] = &ResourcePricing{
+		ResourceType: "GCE e2-standard-2",
+		PricePerHour: 0.067,
+		Currency:     "USD",
+	}
+	cc.pricing["gce-e2-standard-4"] = &ResourcePricing{
+		ResourceType: "GCE e2-standard-4",
+		PricePerHour: 0.134,
+		Currency:     "USD",
+	}
+	cc.pricing["gce-n1-standard-1"] = &ResourcePricing{
+		ResourceType: "GCE n1-standard-1",
+		PricePerHour: 0.0475,
+		Currency:     "USD",
+	}
+	
+	// Azure VM pricing (pay-as-you-go, East US, Linux)
+	cc.pricing["azure-Standard_B1s"] = &ResourcePricing{
+		ResourceType: "Azure Standard_B1s",
+		PricePerHour: 0.0104,
+		Currency:     "USD",
+	}
+	cc.pricing["azure-Standard_B2s"] = &ResourcePricing{
+		ResourceType: "Azure Standard_B2s",
+		PricePerHour: 0.0416,
+		Currency:     "USD",
+	}
+	cc.pricing["azure-Standard_DS1_v2"] = &ResourcePricing{
+		ResourceType: "Azure Standard_DS1_v2",
+		PricePerHour: 0.073,
+		Currency:     "USD",
+	}
+	cc.pricing["azure-Standard_D2s_v3"] = &ResourcePricing{
+		ResourceType: "Azure Standard_D2s_v3",
+		PricePerHour: 0.096,
+		Currency:     "USD",
+	}
+	cc.pricing["azure-Standard_D4s_v3"] = &ResourcePricing{
+		ResourceType: "Azure Standard_D4s_v3",
+		PricePerHour: 0.192,
+		Currency:     "USD",
+	}
+	
 	// Kubernetes node pricing (approximate)
 	cc.pricing["k8s-node"] = &ResourcePricing{
 		ResourceType: "Kubernetes Node",
@@ -100,28 +184,17 @@ func (cc *CostCalculator) EstimateCost(command string) *CostEstimate {
 	
 	// AWS EC2 operations
 	if contains(command, "aws ec2 run-instances") {
-		instanceType := extractInstanceType(command)
-		count := extractCount(command)
-		
-		pricing := cc.pricing["ec2-"+instanceType]
-		if pricing != nil {
-			hourlyCost := pricing.PricePerHour * float64(count)
-			monthlyCost := hourlyCost * 730 // Average hours per month
-			
-			estimate.Resources = append(estimate.Resources, &ResourceCost{
-				Type:     instanceType,
-				Quantity: count,
-				Unit:     "instances",
-				Cost:     monthlyCost,
-			})
-			
-			estimate.TotalCost += hourlyCost
-			estimate.MonthlyCost += monthlyCost
-			
-			estimate.Breakdown = append(estimate.Breakdown,
-				fmt.Sprintf("%d x %s: $%.2f/hour ($%.2f/month)",
-					count, instanceType, hourlyCost, monthlyCost))
-		}
+		cc.addInstanceCost(estimate, "aws", "ec2-", extractInstanceType(command), extractCount(command))
+	}
+	
+	// GCP Compute Engine instances
+	if contains(command, "gcloud compute instances create") {
+		cc.addInstanceCost(estimate, "gcp", "gce-", extractMachineType(command), countGCPInstances(command))
+	}
+	
+	// Azure virtual machines
+	if contains(command, "az vm create") {
+		cc.addInstanceCost(estimate, "azure", "azure-", extractVMSize(command), extractCount(command))
 	}
 	
 	// Kubernetes scaling
@@ -131,6 +204,7 @@ func (cc *CostCalculator) EstimateCost(command string) *CostEstimate {
 		monthlyCost := nodeCost * 730
 		
 		estimate.Resources = append(estimate.Resources, &ResourceCost{
+			Provider: "kubernetes",
 			Type:     "Kubernetes Pods",
 			Quantity: replicas,
 			Unit:     "replicas",
@@ -141,16 +215,58 @@ func (cc *CostCalculator) EstimateCost(command string) *CostEstimate {
 		estimate.MonthlyCost += monthlyCost
 		
 		estimate.Breakdown = append(estimate.Breakdown,
-			fmt.Sprintf("%d replicas: $%.2f/hour ($%.2f/month)",
+			fmt.Sprintf("[kubernetes] %d replicas: $%.2f/hour ($%.2f/month)",
 				replicas, nodeCost, monthlyCost))
 	}
 	
+	estimate.Provider = estimateProvider(estimate.Resources)
+	
 	// Add savings suggestions
 	estimate.Savings = cc.suggestSavings(command, estimate)
 	
 	return estimate
 }
 
+// addInstanceCost adds count instances of instanceType, priced under
+// keyPrefix+instanceType, to the estimate. Unpriced types are skipped.
+func (cc *CostCalculator) addInstanceCost(estimate *CostEstimate, provider, keyPrefix, instanceType string, count int) {
+	pricing := cc.pricing[keyPrefix+instanceType]
+	if pricing == nil {
+		return
+	}
+	
+	hourlyCost := pricing.PricePerHour * float64(count)
+	monthlyCost := hourlyCost * 730 // Average hours per month
+	
+	estimate.Resources = append(estimate.Resources, &ResourceCost{
+		Provider: provider,
+		Type:     instanceType,
+		Quantity: count,
+		Unit:     "instances",
+		Cost:     monthlyCost,
+	})
+	
+	estimate.TotalCost += hourlyCost
+	estimate.MonthlyCost += monthlyCost
+	
+	estimate.Breakdown = append(estimate.Breakdown,
+		fmt.Sprintf("[%s] %d x %s: $%.2f/hour ($%.2f/month)",
+			provider, count, instanceType, hourlyCost, monthlyCost))
+}
+
+// estimateProvider returns the provider shared by all resources, or
+// "multi-cloud" if they differ
+func estimateProvider(resources []*ResourceCost) string {
+	provider := ""
+	for _, resource := range resources {
+		if provider != "" && resource.Provider != provider {
+			return "multi-cloud"
+		}
+		provider = resource.Provider
+	}
+	return provider
+}
+
 // suggestSavings suggests cost optimization opportunities
 func (cc *CostCalculator) suggestSavings(command string, estimate *CostEstimate) []string {
 	savings := []string{}
@@ -158,13 +274,31 @@ func (cc *CostCalculator) suggestSavings(command string, estimate *CostEstimate)
 	// Suggest spot instances for EC2
 	if contains(command, "aws ec2 run-instances") && !contains(command, "spot") {
 		potentialSavings := estimate.MonthlyCost * 0.7 // 70% savings
+		estimate.PotentialSavings = math.Max(estimate.PotentialSavings, potentialSavings)
 		savings = append(savings,
 			fmt.Sprintf("💰 Use spot instances to save ~$%.2f/month (70%% discount)", potentialSavings))
 	}
 	
+	// Suggest preemptible VMs for GCP
+	if contains(command, "gcloud compute instances create") && !contains(command, "--preemptible") && !contains(command, "SPOT") {
+		potentialSavings := estimate.MonthlyCost * 0.8 // 80% savings
+		estimate.PotentialSavings = math.Max(estimate.PotentialSavings, potentialSavings)
+		savings = append(savings,
+			fmt.Sprintf("💰 Use preemptible VMs (--preemptible) to save ~$%.2f/month (80%% discount)", potentialSavings))
+	}
+	
+	// Suggest spot VMs for Azure
+	if contains(command, "az vm create") && !contains(strings.ToLower(command), "spot") {
+		potentialSavings := estimate.MonthlyCost * 0.9 // 90% savings
+		estimate.PotentialSavings = math.Max(estimate.PotentialSavings, potentialSavings)
+		savings = append(savings,
+			fmt.Sprintf("💰 Use Azure spot VMs (--priority Spot) to save ~$%.2f/month (up to 90%% discount)", potentialSavings))
+	}
+	
 	// Suggest reserved instances for long-running
 	if estimate.MonthlyCost > 100 {
 		potentialSavings := estimate.MonthlyCost * 0.4 // 40% savings
+		estimate.PotentialSavings = math.Max(estimate.PotentialSavings, potentialSavings)
 		savings = append(savings,
 			fmt.Sprintf("💰 Use reserved instances to save ~$%.2f/month (40%% discount)", potentialSavings))
 	}
@@ -182,7 +316,11 @@ func (cc *CostCalculator) suggestSavings(command string, estimate *CostEstimate)
 func (ce *CostEstimate) Format() string {
 	var sb strings.Builder
 	
-	sb.WriteString(fmt.Sprintf("\n💰 Cost Estimate: %s\n\n", ce.Command))
+	sb.WriteString(fmt.Sprintf("\n💰 Cost Estimate: %s\n", ce.Command))
+	if ce.Provider != "" {
+		sb.WriteString(fmt.Sprintf("Provider: %s\n", ce.Provider))
+	}
+	sb.WriteString("\n")
 	
 	if ce.TotalCost == 0 {
 		sb.WriteString("No cost information available for this command\n")
@@ -238,36 +376,84 @@ type CostComparison struct {
 // Helper functions
 
 func extractInstanceType(command string) string {
-	// Extract instance type from command
-	// Simplified - would need proper parsing
-	if contains(command, "t3.micro") {
-		return "t3.micro"
+	if instanceType, ok := cmdparse.Parse(command).FlagValue("--instance-type"); ok && instanceType != "" {
+		return instanceType
 	}
-	if contains(command, "t3.small") {
-		return "t3.small"
+	return "t3.micro" // default
+}
+
+func extractMachineType(command string) string {
+	if machineType, ok := cmdparse.Parse(command).FlagValue("--machine-type"); ok && machineType != "" {
+		return machineType
 	}
-	if contains(command, "t3.medium") {
-		return "t3.medium"
+	return "e2-medium" // gcloud default
+}
+
+func extractVMSize(command string) string {
+	if size, ok := cmdparse.Parse(command).FlagValue("--size"); ok && size != "" {
+		return size
 	}
-	return "t3.micro" // default
+	return "Standard_DS1_v2" // az default
+}
+
+// countGCPInstances counts the instance names given to
+// "gcloud compute instances create", which creates one VM per name
+func countGCPInstances(command string) int {
+	tokens := cmdparse.Parse(command).Words
+	
+	count := 0
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i] != "create" {
+			continue
+		}
+		for _, token := range tokens[i+1:] {
+			if strings.HasPrefix(token, "-") {
+				break
+			}
+			count++
+		}
+		break
+	}
+	
+	if count == 0 {
+		return 1
+	}
+	return count
 }
 
 func extractCount(command string) int {
-	// Extract count from --count flag
-	// Simplified - would need proper parsing
-	return 1
+	// --count accepts N or MIN:MAX; estimate for the maximum
+	value, ok := cmdparse.Parse(command).FlagValue("--count")
+	if !ok {
+		return 1
+	}
+	if i := strings.Index(value, ":"); i >= 0 {
+		value = value[i+1:]
+	}
+	
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 1 {
+		return 1
+	}
+	return count
 }
 
 func extractReplicas(command string) int {
-	// Extract replicas from --replicas flag
-	// Simplified - would need proper parsing
-	return 3
+	value, _ := cmdparse.Parse(command).FlagValue("--replicas")
+	
+	replicas, err := strconv.Atoi(value)
+	if err != nil || replicas < 0 {
+		return 0
+	}
+	return replicas
 }
 
 // BudgetAlert checks if operation exceeds budget
 type BudgetAlert struct {
 	Budget      float64
 	EstimatedCost float64
+	Spent       float64 // Already spent this period (cumulative checks only)
+	Remaining   float64 // Budget left after this operation; negative if exceeded
 	Exceeded    bool
 	Message     string
 }
@@ -275,25 +461,30 @@ type BudgetAlert struct {
 // CheckBudget checks if cost exceeds budget
 func (cc *CostCalculator) CheckBudget(command string, monthlyBudget float64) *BudgetAlert {
 	estimate := cc.EstimateCost(command)
-	
-	exceeded := estimate.MonthlyCost > monthlyBudget
+	return newBudgetAlert(monthlyBudget, 0, estimate.MonthlyCost)
+}
+
+// newBudgetAlert compares spent plus estimatedCost to monthlyBudget
+func newBudgetAlert(monthlyBudget, spent, estimatedCost float64) *BudgetAlert {
+	total := spent + estimatedCost
 	
 	alert := &BudgetAlert{
 		Budget:        monthlyBudget,
-		EstimatedCost: estimate.MonthlyCost,
-		Exceeded:      exceeded,
+		EstimatedCost: estimatedCost,
+		Spent:         spent,
+		Remaining:     monthlyBudget - total,
+		Exceeded:      total > monthlyBudget,
 	}
 	
-	if exceeded {
-		overage := estimate.MonthlyCost - monthlyBudget
+	if alert.Exceeded {
+		overage := total - monthlyBudget
 		alert.Message = fmt.Sprintf(
 			"⚠️  This operation will exceed monthly budget by $%.2f (%.1f%%)",
 			overage, (overage/monthlyBudget)*100)
 	} else {
-		remaining := monthlyBudget - estimate.MonthlyCost
 		alert.Message = fmt.Sprintf(
 			"✓ Within budget - $%.2f remaining (%.1f%% of budget)",
-			remaining, (estimate.MonthlyCost/monthlyBudget)*100)
+			alert.Remaining, (total/monthlyBudget)*100)
 	}
 	
 	return alert
@@ -0,0 +1,142 @@
+package analytics
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCostCalculator_EstimateCost(t *testing.T) {
+	cc := NewCostCalculator()
+	
+	tests := []struct {
+		name         string
+		command      string
+		wantProvider string
+		wantQuantity int
+		wantHourly   float64
+	}{
+		{
+			name:         "multiple large instances",
+			command:      "aws ec2 run-instances --image-id ami-123 --count 10 --instance-type t3.large",
+			wantProvider: "aws",
+			wantQuantity: 10,
+			wantHourly:   0.832,
+		},
+		{
+			name:         "equals form",
+			command:      "aws ec2 run-instances --instance-type=t3.xlarge --count=4",
+			wantProvider: "aws",
+			wantQuantity: 4,
+			wantHourly:   0.6656,
+		},
+		{
+			name:         "count range uses maximum",
+			command:      "aws ec2 run-instances --instance-type t3.medium --count 2:5",
+			wantProvider: "aws",
+			wantQuantity: 5,
+			wantHourly:   0.208,
+		},
+		{
+			name:         "defaults to one micro instance",
+			command:      "aws ec2 run-instances --image-id ami-123",
+			wantProvider: "aws",
+			wantQuantity: 1,
+			wantHourly:   0.0104,
+		},
+		{
+			name:         "kubernetes replicas",
+			command:      "kubectl scale deployment api --replicas=8",
+			wantProvider: "kubernetes",
+			wantQuantity: 8,
+			wantHourly:   0.4,
+		},
+		{
+			name:         "gcp instances",
+			command:      "gcloud compute instances create web-1 web-2 web-3 --machine-type=e2-standard-4 --zone us-central1-a",
+			wantProvider: "gcp",
+			wantQuantity: 3,
+			wantHourly:   0.402,
+		},
+		{
+			name:         "gcp default machine type",
+			command:      "gcloud compute instances create worker",
+			wantProvider: "gcp",
+			wantQuantity: 1,
+			wantHourly:   0.0335,
+		},
+		{
+			name:         "azure vms",
+			command:      "az vm create --resource-group rg --name app --image Ubuntu2204 --size Standard_D2s_v3 --count 5",
+			wantProvider: "azure",
+			wantQuantity: 5,
+			wantHourly:   0.48,
+		},
+		{
+			name:         "azure default size",
+			command:      "az vm create -g rg -n app --image Ubuntu2204",
+			wantProvider: "azure",
+			wantQuantity: 1,
+			wantHourly:   0.073,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimate := cc.EstimateCost(tt.command)
+			
+			if len(estimate.Resources) != 1 {
+				t.Fatalf("EstimateCost() returned %d resources, want 1", len(estimate.Resources))
+			}
+			if estimate.Provider != tt.wantProvider || estimate.Resources[0].Provider != tt.wantProvider {
+				t.Errorf("Provider = %q/%q, want %q", estimate.Provider, estimate.Resources[0].Provider, tt.wantProvider)
+			}
+			if !strings.HasPrefix(estimate.Breakdown[0], "["+tt.wantProvider+"]") {
+				t.Errorf("Breakdown = %q, want %s prefix", estimate.Breakdown[0], tt.wantProvider)
+			}
+			if got := estimate.Resources[0].Quantity; got != tt.wantQuantity {
+				t.Errorf("Quantity = %d, want %d", got, tt.wantQuantity)
+			}
+			if math.Abs(estimate.TotalCost-tt.wantHourly) > 1e-9 {
+				t.Errorf("TotalCost = %v, want %v", estimate.TotalCost, tt.wantHourly)
+			}
+			if math.Abs(estimate.MonthlyCost-tt.wantHourly*730) > 1e-6 {
+				t.Errorf("MonthlyCost = %v, want %v", estimate.MonthlyCost, tt.wantHourly*730)
+			}
+		})
+	}
+}
+
+func TestCostCalculator_UnknownInstanceType(t *testing.T) {
+	estimate := NewCostCalculator().EstimateCost("aws ec2 run-instances --instance-type p4d.24xlarge --count 2")
+	
+	if estimate.TotalCost != 0 || len(estimate.Resources) != 0 {
+		t.Errorf("EstimateCost() = %v, want no cost for unpriced instance type", estimate.TotalCost)
+	}
+}
+
+func TestCostCalculator_SuggestSavings(t *testing.T) {
+	cc := NewCostCalculator()
+	
+	tests := []struct {
+		name    string
+		command string
+		want    string
+	}{
+		{"gcp preemptible", "gcloud compute instances create web-1 --machine-type e2-standard-2", "preemptible"},
+		{"azure spot", "az vm create -n app --size Standard_B2s", "spot VMs"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			savings := strings.Join(cc.EstimateCost(tt.command).Savings, "\n")
+			if !strings.Contains(savings, tt.want) {
+				t.Errorf("Savings = %q, want mention of %q", savings, tt.want)
+			}
+		})
+	}
+	
+	if savings := cc.EstimateCost("gcloud compute instances create web-1 --preemptible").Savings; len(savings) != 0 {
+		t.Errorf("Savings = %v, want none for preemptible instance", savings)
+	}
+}
@@ -0,0 +1,138 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// HeatmapCell is a single count behind a heatmap visualization
+type HeatmapCell struct {
+	Granularity string `json:"granularity,omitempty"` // "hour", "day" or "week"; timeline only
+	Bucket      string `json:"bucket,omitempty"`      // e.g. "09", "2024-03-01", "2024-W09"
+	Category    string `json:"category"`
+	RiskLevel   string `json:"risk_level"`
+	Count       int    `json:"count"`
+}
+
+// Cells returns the heatmap counts sorted by category and risk level
+func (rh *RiskHeatmap) Cells() []HeatmapCell {
+	cells := []HeatmapCell{}
+	for category, risks := range rh.data {
+		for riskLevel, count := range risks {
+			cells = append(cells, HeatmapCell{
+				Category:  category,
+				RiskLevel: riskLevel,
+				Count:     count,
+			})
+		}
+	}
+
+	sortCells(cells)
+	return cells
+}
+
+// ExportCSV exports the heatmap counts as CSV with a header row
+func (rh *RiskHeatmap) ExportCSV() (string, error) {
+	header := []string{"category", "risk_level", "count"}
+
+	var rows [][]string
+	for _, cell := range rh.Cells() {
+		rows = append(rows, []string{cell.Category, cell.RiskLevel, strconv.Itoa(cell.Count)})
+	}
+
+	return writeCSV(header, rows)
+}
+
+// ExportJSON exports the heatmap counts as a JSON array
+func (rh *RiskHeatmap) ExportJSON() ([]byte, error) {
+	return marshalCells(rh.Cells())
+}
+
+// Cells returns the timeline counts for every hourly, daily and weekly bucket
+func (th *TimelineHeatmap) Cells() []HeatmapCell {
+	cells := []HeatmapCell{}
+
+	add := func(granularity, bucket string, heatmap *RiskHeatmap) {
+		for _, cell := range heatmap.Cells() {
+			cell.Granularity = granularity
+			cell.Bucket = bucket
+			cells = append(cells, cell)
+		}
+	}
+
+	for hour, heatmap := range th.hourly {
+		add("hour", fmt.Sprintf("%02d", hour), heatmap)
+	}
+	for day, heatmap := range th.daily {
+		add("day", day, heatmap)
+	}
+	for week, heatmap := range th.weekly {
+		add("week", week, heatmap)
+	}
+
+	sortCells(cells)
+	return cells
+}
+
+// ExportCSV exports the timeline counts as CSV with a header row
+func (th *TimelineHeatmap) ExportCSV() (string, error) {
+	header := []string{"granularity", "bucket", "category", "risk_level", "count"}
+
+	var rows [][]string
+	for _, cell := range th.Cells() {
+		rows = append(rows, []string{cell.Granularity, cell.Bucket, cell.Category, cell.RiskLevel, strconv.Itoa(cell.Count)})
+	}
+
+	return writeCSV(header, rows)
+}
+
+// ExportJSON exports the timeline counts as a JSON array
+func (th *TimelineHeatmap) ExportJSON() ([]byte, error) {
+	return marshalCells(th.Cells())
+}
+
+// granularityOrder orders timeline cells from finest to coarsest bucket
+var granularityOrder = map[string]int{"hour": 0, "day": 1, "week": 2}
+
+// sortCells sorts cells by granularity, bucket, category and risk level
+func sortCells(cells []HeatmapCell) {
+	sort.Slice(cells, func(i, j int) bool {
+		a, b := cells[i], cells[j]
+		if a.Granularity != b.Granularity {
+			return granularityOrder[a.Granularity] < granularityOrder[b.Granularity]
+		}
+		if a.Bucket != b.Bucket {
+			return a.Bucket < b.Bucket
+		}
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		return a.RiskLevel < b.RiskLevel
+	})
+}
+
+func writeCSV(header []string, rows [][]string) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(header); err != nil {
+		return "", fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return "", fmt.Errorf("failed to write CSV rows: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+func marshalCells(cells []HeatmapCell) ([]byte, error) {
+	data, err := json.MarshalIndent(cells, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal heatmap: %w", err)
+	}
+	return data, nil
+}
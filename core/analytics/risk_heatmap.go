@@ -5,6 +5,9 @@ import (
 	"sort"
 	"strings"
 	"time"
+	
+	"github.com/SagheerAkram/QuickCmd/core/audit"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
 )
 
 // RiskHeatmap provides visual risk analysis
@@ -27,6 +30,25 @@ func (rh *RiskHeatmap) AddCommand(category, riskLevel string) {
 	rh.data[category][riskLevel]++
 }
 
+// PopulateFromAudit adds the selected command of every audited run to the
+// heatmap, categorized with CategorizeCommand. Runs without a selected
+// command are skipped.
+func (rh *RiskHeatmap) PopulateFromAudit(records []*audit.RunRecord) {
+	for _, record := range records {
+		if strings.TrimSpace(record.SelectedCommand) == "" {
+			continue
+		}
+		rh.AddCommand(CategorizeCommand(record.SelectedCommand), record.RiskLevel)
+	}
+}
+
+// CategorizeCommand returns the heatmap category of a shell command, using
+// the translator's categories: file, search, git, docker, k8s, aws, system,
+// network or other
+func CategorizeCommand(command string) string {
+	return translator.CommandCategory(command)
+}
+
 // Visualize creates a visual heatmap
 func (rh *RiskHeatmap) Visualize() string {
 	var sb strings.Builder
@@ -119,25 +141,40 @@ type RiskTrend struct {
 
 // TimelineHeatmap shows risk over time
 type TimelineHeatmap struct {
-	hourly  map[int]*RiskHeatmap
-	daily   map[string]*RiskHeatmap
-	weekly  map[string]*RiskHeatmap
+	hourly   map[int]*RiskHeatmap
+	daily    map[string]*RiskHeatmap
+	weekly   map[string]*RiskHeatmap
+	location *time.Location // display timezone used for bucketing
 }
 
 // NewTimelineHeatmap creates a timeline heatmap
 func NewTimelineHeatmap() *TimelineHeatmap {
 	return &TimelineHeatmap{
-		hourly: make(map[int]*RiskHeatmap),
-		daily:  make(map[string]*RiskHeatmap),
-		weekly: make(map[string]*RiskHeatmap),
+		hourly:   make(map[int]*RiskHeatmap),
+		daily:    make(map[string]*RiskHeatmap),
+		weekly:   make(map[string]*RiskHeatmap),
+		location: time.Local,
+	}
+}
+
+// SetLocation sets the display timezone. Commands added afterwards are
+// bucketed by their hour and day in this timezone.
+func (th *TimelineHeatmap) SetLocation(loc *time.Location) {
+	if loc == nil {
+		loc = time.Local
 	}
+	th.location = loc
 }
 
 // AddCommand adds a command with timestamp
 func (th *TimelineHeatmap) AddCommand(timestamp time.Time, category, riskLevel string) {
+	// Bucket in the display timezone so mixed-zone data lines up
+	timestamp = timestamp.In(th.location)
+	
 	hour := timestamp.Hour()
 	day := timestamp.Format("2006-01-02")
-	week := timestamp.Format("2006-W01")
+	year, isoWeek := timestamp.ISOWeek()
+	week := fmt.Sprintf("%d-W%02d", year, isoWeek)
 	
 	// Hourly
 	if th.hourly[hour] == nil {
@@ -242,6 +279,7 @@ func (rh *RiskHeatmap) GetHighRiskCategories() []string {
 		}
 	}
 	
+	sort.Strings(highRisk)
 	return highRisk
 }
 
@@ -0,0 +1,194 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SagheerAkram/QuickCmd/core/audit"
+)
+
+func TestTimelineHeatmap_Location(t *testing.T) {
+	th := NewTimelineHeatmap()
+	th.SetLocation(time.FixedZone("IST", 5*60*60+30*60))
+
+	// 10:00 UTC is 15:30 IST; 20:00 UTC is 01:30 IST the next day
+	th.AddCommand(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), "file", "safe")
+	th.AddCommand(time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC), "file", "high")
+
+	// The same instant expressed in another zone lands in the same bucket
+	ny := time.FixedZone("EST", -5*60*60)
+	th.AddCommand(time.Date(2024, 3, 1, 5, 0, 0, 0, ny), "git", "medium")
+
+	if th.hourly[10] != nil || th.hourly[20] != nil {
+		t.Error("commands bucketed by UTC hour")
+	}
+	if got := th.hourly[15]; got == nil || got.data["file"]["safe"] != 1 || got.data["git"]["medium"] != 1 {
+		t.Errorf("hour 15 bucket = %v, want file/safe and git/medium", got)
+	}
+	if got := th.hourly[1]; got == nil || got.data["file"]["high"] != 1 {
+		t.Errorf("hour 1 bucket = %v, want file/high", got)
+	}
+
+	if th.daily["2024-03-02"] == nil {
+		t.Error("20:00 UTC command not bucketed on the next local day")
+	}
+}
+
+func TestTimelineHeatmap_DefaultLocation(t *testing.T) {
+	th := NewTimelineHeatmap()
+	ts := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	th.AddCommand(ts, "file", "safe")
+
+	if th.hourly[ts.In(time.Local).Hour()] == nil {
+		t.Error("default location is not the local timezone")
+	}
+}
+
+func TestRiskHeatmap_ExportCSV(t *testing.T) {
+	rh := NewRiskHeatmap()
+	rh.AddCommand("git", "safe")
+	rh.AddCommand("git", "safe")
+	rh.AddCommand("git", "high")
+	rh.AddCommand("docker", "medium")
+
+	out, err := rh.ExportCSV()
+	if err != nil {
+		t.Fatalf("ExportCSV() error: %v", err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("ExportCSV() output is not valid CSV: %v", err)
+	}
+
+	want := [][]string{
+		{"category", "risk_level", "count"},
+		{"docker", "medium", "1"},
+		{"git", "high", "1"},
+		{"git", "safe", "2"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("ExportCSV() = %v, want %v", records, want)
+	}
+}
+
+func TestTimelineHeatmap_ExportCSV(t *testing.T) {
+	th := NewTimelineHeatmap()
+	th.SetLocation(time.UTC)
+	th.AddCommand(time.Date(2024, 3, 1, 9, 15, 0, 0, time.UTC), "file", "safe")
+	th.AddCommand(time.Date(2024, 3, 1, 9, 45, 0, 0, time.UTC), "file", "safe")
+	th.AddCommand(time.Date(2024, 3, 4, 14, 0, 0, 0, time.UTC), "git", "high")
+
+	out, err := th.ExportCSV()
+	if err != nil {
+		t.Fatalf("ExportCSV() error: %v", err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("ExportCSV() output is not valid CSV: %v", err)
+	}
+
+	want := [][]string{
+		{"granularity", "bucket", "category", "risk_level", "count"},
+		{"hour", "09", "file", "safe", "2"},
+		{"hour", "14", "git", "high", "1"},
+		{"day", "2024-03-01", "file", "safe", "2"},
+		{"day", "2024-03-04", "git", "high", "1"},
+		{"week", "2024-W09", "file", "safe", "2"},
+		{"week", "2024-W10", "git", "high", "1"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("ExportCSV() =\n%v\nwant\n%v", records, want)
+	}
+}
+
+func TestTimelineHeatmap_ExportJSON(t *testing.T) {
+	th := NewTimelineHeatmap()
+	th.AddCommand(time.Now(), "file", "medium")
+
+	data, err := th.ExportJSON()
+	if err != nil {
+		t.Fatalf("ExportJSON() error: %v", err)
+	}
+
+	var cells []HeatmapCell
+	if err := json.Unmarshal(data, &cells); err != nil {
+		t.Fatalf("ExportJSON() output is not valid JSON: %v", err)
+	}
+	if len(cells) != 3 {
+		t.Errorf("ExportJSON() returned %d cells, want one per granularity", len(cells))
+	}
+	for _, cell := range cells {
+		if cell.Category != "file" || cell.RiskLevel != "medium" || cell.Count != 1 {
+			t.Errorf("unexpected cell: %+v", cell)
+		}
+	}
+}
+
+func TestCategorizeCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"find . -type f -size +100M", "file"},
+		{`find . -name "*.log" -type f -mtime +7 -print0 | tar -czvf logs.tar.gz --null -T -`, "file"},
+		{`grep -rn "TODO" .`, "search"},
+		{"git status --short", "git"},
+		{"docker system prune -a --volumes", "docker"},
+		{"kubectl delete pod nginx-123", "k8s"},
+		{"helm upgrade api ./chart", "k8s"},
+		{"aws ec2 run-instances --instance-type t3.medium", "aws"},
+		{"du -sh * | sort -hr | head -20", "system"},
+		{"sudo systemctl restart nginx", "system"},
+		{"sudo -u bob systemctl restart nginx", "system"},
+		{"cd /srv/app && git pull", "git"},
+		{`"/usr/bin/docker" ps`, "docker"},
+		{"curl -fsSL https://example.com", "network"},
+		{"AWS_PROFILE=prod aws s3 ls", "aws"},
+		{"/usr/bin/git log", "git"},
+		{"terraform apply", "other"},
+		{"", "other"},
+	}
+
+	for _, tt := range tests {
+		if got := CategorizeCommand(tt.command); got != tt.want {
+			t.Errorf("CategorizeCommand(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestRiskHeatmap_PopulateFromAudit(t *testing.T) {
+	records := []*audit.RunRecord{
+		{SelectedCommand: "git status --short", RiskLevel: "safe"},
+		{SelectedCommand: "git push --force", RiskLevel: "high"},
+		{SelectedCommand: "kubectl delete pod web", RiskLevel: "high"},
+		{SelectedCommand: "kubectl delete deployment api", RiskLevel: "high"},
+		{SelectedCommand: "ls -la", RiskLevel: "safe"},
+		{Prompt: "no match", RiskLevel: "safe"},
+	}
+
+	rh := NewRiskHeatmap()
+	rh.PopulateFromAudit(records)
+
+	if got := rh.AnalyzeCategory("git"); got == nil || got.SafeCount != 1 || got.HighCount != 1 {
+		t.Errorf("git analysis = %+v, want 1 safe and 1 high", got)
+	}
+	if got := rh.AnalyzeCategory("k8s"); got == nil || got.HighCount != 2 {
+		t.Errorf("k8s analysis = %+v, want 2 high", got)
+	}
+	if got := rh.AnalyzeCategory("other"); got != nil {
+		t.Errorf("run without a command was counted: %+v", got)
+	}
+
+	if got := rh.GetHighRiskCategories(); !reflect.DeepEqual(got, []string{"k8s"}) {
+		t.Errorf("GetHighRiskCategories() = %v, want [k8s]", got)
+	}
+	if recs := rh.Recommendations(); len(recs) == 0 || !strings.Contains(recs[0], "k8s") {
+		t.Errorf("Recommendations() = %v, want k8s flagged", recs)
+	}
+}
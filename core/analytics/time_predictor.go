@@ -4,8 +4,14 @@ import (
 	"fmt"
 	"math"
 	"time"
+	
+	"github.com/SagheerAkram/QuickCmd/core/cmdparse"
+	"github.com/SagheerAkram/QuickCmd/core/learning/store"
 )
 
+// timingsKey is the learning store key holding execution history
+const timingsKey = "history"
+
 // TimePredictor predicts command execution time
 type TimePredictor struct {
 	history map[string][]int64 // command pattern -> durations (ms)
@@ -29,6 +35,25 @@ func (tp *TimePredictor) RecordExecution(command string, duration time.Duration)
 	}
 }
 
+// SaveTo persists the execution history in ns
+func (tp *TimePredictor) SaveTo(ns *store.Namespace) error {
+	return ns.Put(timingsKey, tp.history)
+}
+
+// LoadFrom restores execution history saved by SaveTo, replacing what has
+// been recorded so far. An empty namespace is not an error.
+func (tp *TimePredictor) LoadFrom(ns *store.Namespace) error {
+	history := make(map[string][]int64)
+	if _, err := ns.Get(timingsKey, &history); err != nil {
+		return err
+	}
+	if history == nil {
+		history = make(map[string][]int64)
+	}
+	tp.history = history
+	return nil
+}
+
 // Predict predicts execution time for a command
 func (tp *TimePredictor) Predict(command string) *TimePrediction {
 	pattern := extractPattern(command)
@@ -190,39 +215,12 @@ func (tp *TimePredictor) SuggestOptimizations(command string, duration time.Dura
 func extractPattern(command string) string {
 	// Extract base command pattern
 	// e.g., "find . -name *.log" -> "find"
-	parts := splitCommand(command)
-	if len(parts) > 0 {
-		return parts[0]
+	if name := cmdparse.Parse(command).Name; name != "" {
+		return name
 	}
 	return command
 }
 
-func splitCommand(command string) []string {
-	// Simple split by space
-	result := []string{}
-	current := ""
-	inQuote := false
-	
-	for _, char := range command {
-		if char == '"' || char == '\'' {
-			inQuote = !inQuote
-		} else if char == ' ' && !inQuote {
-			if current != "" {
-				result = append(result, current)
-				current = ""
-			}
-		} else {
-			current += string(char)
-		}
-	}
-	
-	if current != "" {
-		result = append(result, current)
-	}
-	
-	return result
-}
-
 func average(values []int64) float64 {
 	if len(values) == 0 {
 		return 0
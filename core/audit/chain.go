@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Each run is chained to the one logged before it: record_hash is the
+// SHA-256 of the previous run's record_hash followed by the run's canonical
+// encoding, and prev_hash keeps the previous hash. Editing a stored run
+// changes its hash; deleting one breaks the link to the run after it.
+// Purging old runs records the last purged hash in chain_anchor, which the
+// oldest remaining run then chains from.
+
+// chainRecord is the canonical encoding hashed for a run. Field order is
+// fixed by the struct, and output is hashed as text so a nil and an empty
+// stream hash the same. The id and created_at are assigned by SQLite after
+// hashing, so they are left out.
+type chainRecord struct {
+	Timestamp       string `json:"timestamp"`
+	User            string `json:"user"`
+	Prompt          string `json:"prompt"`
+	SelectedCommand string `json:"selected_command"`
+	SandboxID       string `json:"sandbox_id"`
+	ExitCode        int    `json:"exit_code"`
+	Stdout          string `json:"stdout"`
+	Stderr          string `json:"stderr"`
+	RiskLevel       string `json:"risk_level"`
+	Snapshot        string `json:"snapshot"`
+	Executed        bool   `json:"executed"`
+	DurationMs      int64  `json:"duration_ms"`
+}
+
+// hashRecord returns the hex-encoded chain hash of record following prev
+func hashRecord(prev string, record *RunRecord) string {
+	// Marshalling a struct of strings and numbers can't fail
+	canonical, _ := json.Marshal(chainRecord{
+		Timestamp:       record.Timestamp,
+		User:            record.User,
+		Prompt:          record.Prompt,
+		SelectedCommand: record.SelectedCommand,
+		SandboxID:       record.SandboxID,
+		ExitCode:        record.ExitCode,
+		Stdout:          string(record.Stdout),
+		Stderr:          string(record.Stderr),
+		RiskLevel:       record.RiskLevel,
+		Snapshot:        record.Snapshot,
+		Executed:        record.Executed,
+		DurationMs:      record.DurationMs,
+	})
+
+	h := sha256.New()
+	h.Write([]byte(prev))
+	h.Write(canonical)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// migrateHashChain adds the hash columns to databases created before the
+// chain existed. Their existing runs are left unhashed.
+func (s *SQLiteStore) migrateHashChain() error {
+	rows, err := s.db.Query("PRAGMA table_info(runs)")
+	if err != nil {
+		return fmt.Errorf("failed to read runs schema: %w", err)
+	}
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			defaultValue     sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read runs schema: %w", err)
+		}
+		columns[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read runs schema: %w", err)
+	}
+
+	for _, column := range []string{"prev_hash", "record_hash"} {
+		if columns[column] {
+			continue
+		}
+		if _, err := s.db.Exec("ALTER TABLE runs ADD COLUMN " + column + " TEXT"); err != nil {
+			return fmt.Errorf("failed to add %s column: %w", column, err)
+		}
+	}
+
+	return nil
+}
+
+// lastRecordHash returns the record_hash the next run chains from: the most
+// recent run's, the chain anchor's if every run was purged, or "" if neither
+// exists or the last run predates the chain. tx must hold the write lock
+// (the store opens the database with _txlock=immediate), or a concurrent
+// writer could chain from the same run.
+func lastRecordHash(tx *sql.Tx) (string, error) {
+	var hash string
+	err := tx.QueryRow(`
+		SELECT COALESCE(
+			(SELECT record_hash FROM runs ORDER BY id DESC LIMIT 1),
+			(SELECT hash FROM chain_anchor WHERE id = 1),
+			''
+		)
+	`).Scan(&hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to read last audit hash: %w", err)
+	}
+	return hash, nil
+}
+
+// chainAnchor returns the hash the oldest remaining run chains from, which
+// is "" until runs have been purged
+func (s *SQLiteStore) chainAnchor() (string, error) {
+	var hash string
+	err := s.db.QueryRow("SELECT COALESCE((SELECT hash FROM chain_anchor WHERE id = 1), '')").Scan(&hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to read chain anchor: %w", err)
+	}
+	return hash, nil
+}
+
+// VerifyChain recomputes the hash chain over every run and returns the IDs
+// of runs that don't match: runs whose contents were changed since they
+// were logged, and runs whose predecessor was changed or deleted. Runs
+// logged before the chain existed are skipped. An empty result means the
+// log is intact.
+func (s *SQLiteStore) VerifyChain() ([]int64, error) {
+	prev, err := s.chainAnchor()
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := s.queryRecords("SELECT " + runColumns + " FROM runs ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	var broken []int64
+	started := false
+	for _, record := range records {
+		if !started {
+			if record.RecordHash == "" {
+				continue
+			}
+			started = true
+		}
+
+		if record.PrevHash != prev || record.RecordHash != hashRecord(record.PrevHash, record) {
+			broken = append(broken, record.ID)
+		}
+		prev = record.RecordHash
+	}
+
+	return broken, nil
+}
@@ -0,0 +1,153 @@
+package audit
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSQLiteStore_VerifyChain(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test_audit.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	records := seedHistory(t, store)
+
+	if records[0].PrevHash != "" {
+		t.Errorf("first run PrevHash = %q, want empty", records[0].PrevHash)
+	}
+	for i := 1; i < len(records); i++ {
+		if records[i].PrevHash != records[i-1].RecordHash {
+			t.Errorf("run %d PrevHash = %q, want %q", i, records[i].PrevHash, records[i-1].RecordHash)
+		}
+	}
+
+	broken, err := store.VerifyChain()
+	if err != nil {
+		t.Fatalf("VerifyChain() error: %v", err)
+	}
+	if len(broken) != 0 {
+		t.Fatalf("VerifyChain() = %v on an untouched log, want none", broken)
+	}
+
+	// Rewriting a logged command is caught at that run only
+	if _, err := store.db.Exec("UPDATE runs SET selected_command = ? WHERE id = ?", "echo innocent", records[2].ID); err != nil {
+		t.Fatalf("Failed to tamper with run: %v", err)
+	}
+
+	broken, err = store.VerifyChain()
+	if err != nil {
+		t.Fatalf("VerifyChain() error: %v", err)
+	}
+	if want := []int64{records[2].ID}; !reflect.DeepEqual(broken, want) {
+		t.Errorf("VerifyChain() after edit = %v, want %v", broken, want)
+	}
+}
+
+func TestSQLiteStore_VerifyChain_DeletedRun(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test_audit.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	records := seedHistory(t, store)
+
+	// Deleting a run breaks the link from the run after it
+	if _, err := store.db.Exec("DELETE FROM runs WHERE id = ?", records[1].ID); err != nil {
+		t.Fatalf("Failed to delete run: %v", err)
+	}
+
+	broken, err := store.VerifyChain()
+	if err != nil {
+		t.Fatalf("VerifyChain() error: %v", err)
+	}
+	if want := []int64{records[2].ID}; !reflect.DeepEqual(broken, want) {
+		t.Errorf("VerifyChain() after delete = %v, want %v", broken, want)
+	}
+}
+
+func TestSQLiteStore_VerifyChain_LegacyRuns(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test_audit.db")
+
+	// A database from before the chain, with one run already logged
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp TEXT NOT NULL,
+			user TEXT,
+			prompt TEXT NOT NULL,
+			selected_command TEXT NOT NULL,
+			sandbox_id TEXT,
+			exit_code INTEGER,
+			stdout BLOB,
+			stderr BLOB,
+			risk_level TEXT NOT NULL,
+			snapshot TEXT,
+			executed BOOLEAN DEFAULT 0,
+			duration_ms INTEGER,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		INSERT INTO runs (timestamp, user, prompt, selected_command, sandbox_id, exit_code, risk_level, snapshot, duration_ms)
+		VALUES ('2024-01-01T00:00:00Z', 'old', 'list', 'ls', '', 0, 'safe', '', 0);
+	`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("Failed to create legacy database: %v", err)
+	}
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open legacy database: %v", err)
+	}
+	defer store.Close()
+
+	records := seedHistory(t, store)
+	if records[0].PrevHash != "" {
+		t.Errorf("first chained run PrevHash = %q, want empty", records[0].PrevHash)
+	}
+
+	broken, err := store.VerifyChain()
+	if err != nil {
+		t.Fatalf("VerifyChain() error: %v", err)
+	}
+	if len(broken) != 0 {
+		t.Errorf("VerifyChain() = %v, want legacy runs skipped", broken)
+	}
+}
+
+func TestSQLiteStore_TransactionsTakeWriteLock(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test_audit.db")
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	tx, err := store.db.Begin()
+	if err != nil {
+		t.Fatalf("Begin() error: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := lastRecordHash(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process can't log a run between reading the last hash and
+	// inserting the next record
+	other, err := sql.Open("sqlite3", dbPath+"?_busy_timeout=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if _, err := other.Exec("INSERT INTO runs (timestamp, prompt, selected_command, risk_level) VALUES ('', '', '', '')"); err == nil {
+		t.Error("write from another connection succeeded while a log transaction was open")
+	}
+}
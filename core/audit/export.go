@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// exportColumns is the header row of ExportCSV. Columns are only ever
+// appended so that existing consumers keep working.
+var exportColumns = []string{
+	"id", "timestamp", "user", "prompt", "command", "sandbox_id",
+	"exit_code", "stdout", "stderr", "risk_level", "executed",
+	"duration_ms", "snapshot", "created_at", "prev_hash", "record_hash",
+}
+
+// base64Prefix marks exported output that was base64-encoded
+const base64Prefix = "base64:"
+
+// exportRecord is a run as written by ExportJSONL
+type exportRecord struct {
+	ID         int64  `json:"id"`
+	Timestamp  string `json:"timestamp"`
+	User       string `json:"user"`
+	Prompt     string `json:"prompt"`
+	Command    string `json:"command"`
+	SandboxID  string `json:"sandbox_id"`
+	ExitCode   int    `json:"exit_code"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	RiskLevel  string `json:"risk_level"`
+	Executed   bool   `json:"executed"`
+	DurationMs int64  `json:"duration_ms"`
+	Snapshot   string `json:"snapshot"`
+	CreatedAt  string `json:"created_at"`
+	PrevHash   string `json:"prev_hash"`
+	RecordHash string `json:"record_hash"`
+}
+
+func newExportRecord(record *RunRecord) *exportRecord {
+	return &exportRecord{
+		ID:         record.ID,
+		Timestamp:  record.Timestamp,
+		User:       record.User,
+		Prompt:     record.Prompt,
+		Command:    record.SelectedCommand,
+		SandboxID:  record.SandboxID,
+		ExitCode:   record.ExitCode,
+		Stdout:     exportOutput(record.Stdout),
+		Stderr:     exportOutput(record.Stderr),
+		RiskLevel:  record.RiskLevel,
+		Executed:   record.Executed,
+		DurationMs: record.DurationMs,
+		Snapshot:   record.Snapshot,
+		CreatedAt:  record.CreatedAt.UTC().Format(time.RFC3339),
+		PrevHash:   record.PrevHash,
+		RecordHash: record.RecordHash,
+	}
+}
+
+// exportOutput returns captured output as text. Output that isn't UTF-8
+// text, or that would be mistaken for encoded output, is base64-encoded
+// behind base64Prefix.
+func exportOutput(output []byte) string {
+	if utf8.Valid(output) && bytes.IndexByte(output, 0) < 0 && !bytes.HasPrefix(output, []byte(base64Prefix)) {
+		return string(output)
+	}
+	return base64Prefix + base64.StdEncoding.EncodeToString(output)
+}
+
+// ExportCSV writes the runs matching q to w as CSV with a header row. Unlike
+// GetHistoryPage, a zero Limit exports every matching run. Records are
+// streamed from the database rather than loaded at once.
+func (s *SQLiteStore) ExportCSV(w io.Writer, q HistoryQuery) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(exportColumns); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	err := s.eachRecord(q, func(record *RunRecord) error {
+		r := newExportRecord(record)
+		return cw.Write([]string{
+			strconv.FormatInt(r.ID, 10), r.Timestamp, csvText(r.User), csvText(r.Prompt), csvText(r.Command),
+			csvText(r.SandboxID), strconv.Itoa(r.ExitCode), csvText(r.Stdout), csvText(r.Stderr),
+			csvText(r.RiskLevel), strconv.FormatBool(r.Executed), strconv.FormatInt(r.DurationMs, 10),
+			csvText(r.Snapshot), r.CreatedAt, r.PrevHash, r.RecordHash,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return nil
+}
+
+// csvText returns a free-text CSV cell. Prompts, commands and output are
+// user-controlled, and a spreadsheet would run a cell starting with = + - @
+// (or a tab or carriage return before one) as a formula, so such cells are
+// prefixed with a single quote.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// ExportJSONL writes the runs matching q to w as one JSON object per line,
+// selected as by ExportCSV
+func (s *SQLiteStore) ExportJSONL(w io.Writer, q HistoryQuery) error {
+	encoder := json.NewEncoder(w)
+	return s.eachRecord(q, func(record *RunRecord) error {
+		return encoder.Encode(newExportRecord(record))
+	})
+}
+
+// eachRecord calls fn with each run matching q, in q's order, stopping at
+// the first error
+func (s *SQLiteStore) eachRecord(q HistoryQuery, fn func(*RunRecord) error) error {
+	if q.Limit < 0 || q.Offset < 0 {
+		return fmt.Errorf("invalid page: limit %d, offset %d", q.Limit, q.Offset)
+	}
+	limit := q.Limit
+	if limit == 0 {
+		limit = -1 // no limit
+	}
+
+	order, err := q.order()
+	if err != nil {
+		return err
+	}
+	where, args := q.where()
+
+	query := "SELECT " + runColumns + " FROM runs" + where + " ORDER BY id " + order + " LIMIT ? OFFSET ?"
+	rows, err := s.db.Query(query, append(args, limit, q.Offset)...)
+	if err != nil {
+		return fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record, err := scanRecord(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan record: %w", err)
+		}
+		if err := fn(record); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to query history: %w", err)
+	}
+	return nil
+}
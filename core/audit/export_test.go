@@ -0,0 +1,168 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSQLiteStore_ExportCSV(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test_audit.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	seedHistory(t, store)
+
+	binary := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}
+	if err := store.LogExecution(&RunRecord{
+		Prompt:          "dump image",
+		SelectedCommand: "cat logo.png",
+		Stdout:          binary,
+		Stderr:          []byte("done\n"),
+		RiskLevel:       "safe",
+		Executed:        true,
+	}); err != nil {
+		t.Fatalf("LogExecution() error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := store.ExportCSV(&buf, HistoryQuery{Order: OldestFirst}); err != nil {
+		t.Fatalf("ExportCSV() error: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("export is not valid CSV: %v", err)
+	}
+	if len(rows) != 7 {
+		t.Fatalf("export has %d rows, want a header and 6 runs", len(rows))
+	}
+	if strings.Join(rows[0], ",") != strings.Join(exportColumns, ",") {
+		t.Errorf("header = %v, want %v", rows[0], exportColumns)
+	}
+
+	column := func(name string) int {
+		for i, c := range rows[0] {
+			if c == name {
+				return i
+			}
+		}
+		t.Fatalf("no %s column", name)
+		return -1
+	}
+	last := rows[6]
+	if want := base64Prefix + base64.StdEncoding.EncodeToString(binary); last[column("stdout")] != want {
+		t.Errorf("binary stdout = %q, want %q", last[column("stdout")], want)
+	}
+	if last[column("stderr")] != "done\n" {
+		t.Errorf("text stderr = %q, want it unencoded", last[column("stderr")])
+	}
+
+	// Filters apply as for GetHistoryPage
+	buf.Reset()
+	if err := store.ExportCSV(&buf, HistoryQuery{RiskLevel: "high"}); err != nil {
+		t.Fatalf("ExportCSV() error: %v", err)
+	}
+	rows, _ = csv.NewReader(&buf).ReadAll()
+	if len(rows) != 2 {
+		t.Errorf("high risk export has %d rows, want a header and 1 run", len(rows))
+	}
+}
+
+func TestSQLiteStore_ExportCSV_Formulas(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test_audit.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.LogExecution(&RunRecord{
+		Prompt:          "=HYPERLINK(\"http://evil\")",
+		SelectedCommand: "@SUM(A1)",
+		Stdout:          []byte("-1\n"),
+		Stderr:          []byte("+cmd|' /C calc'!A0"),
+		RiskLevel:       "safe",
+	}); err != nil {
+		t.Fatalf("LogExecution() error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := store.ExportCSV(&buf, HistoryQuery{}); err != nil {
+		t.Fatalf("ExportCSV() error: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("export is not valid CSV: %v", err)
+	}
+	for i, name := range rows[0] {
+		switch name {
+		case "prompt", "command", "stdout", "stderr":
+			if !strings.HasPrefix(rows[1][i], "'") {
+				t.Errorf("%s = %q, want it quoted", name, rows[1][i])
+			}
+		case "risk_level":
+			if rows[1][i] != "safe" {
+				t.Errorf("risk_level = %q, want it unchanged", rows[1][i])
+			}
+		}
+	}
+
+	// JSONL keeps the text exact
+	buf.Reset()
+	if err := store.ExportJSONL(&buf, HistoryQuery{}); err != nil {
+		t.Fatalf("ExportJSONL() error: %v", err)
+	}
+	var record exportRecord
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("export is not JSON: %v", err)
+	}
+	if record.Command != "@SUM(A1)" {
+		t.Errorf("JSONL command = %q, want it unchanged", record.Command)
+	}
+}
+
+func TestSQLiteStore_ExportJSONL(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test_audit.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	runs := seedHistory(t, store)
+
+	var buf bytes.Buffer
+	if err := store.ExportJSONL(&buf, HistoryQuery{Order: OldestFirst}); err != nil {
+		t.Fatalf("ExportJSONL() error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(runs) {
+		t.Fatalf("export has %d lines, want %d", len(lines), len(runs))
+	}
+	for i, line := range lines {
+		var record exportRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line %d is not JSON: %v", i+1, err)
+		}
+		if record.ID != runs[i].ID || record.Command != "echo day" {
+			t.Errorf("line %d = run %d %q, want run %d", i+1, record.ID, record.Command, runs[i].ID)
+		}
+	}
+
+	// Limit and Offset select a slice of the history
+	buf.Reset()
+	if err := store.ExportJSONL(&buf, HistoryQuery{Limit: 2, Offset: 1}); err != nil {
+		t.Fatalf("ExportJSONL() error: %v", err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Errorf("export has %d lines, want 2", n)
+	}
+
+	if err := store.ExportJSONL(&buf, HistoryQuery{Order: "sideways"}); err == nil {
+		t.Error("expected an error for an unknown order")
+	}
+}
@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ftsSchemaSQL creates the full-text index over runs. It is contentless:
+// matches are joined back to runs by rowid, so output isn't stored twice.
+const ftsSchemaSQL = `
+	CREATE VIRTUAL TABLE IF NOT EXISTS runs_fts USING fts5(
+		prompt, selected_command, stdout, stderr,
+		content=''
+	)
+`
+
+// migrateFTS creates the full-text index and indexes every run missing from
+// it: every existing run the first time, then runs logged by a build
+// without FTS5 or whose indexing failed, even if later runs were indexed.
+// It fails if SQLite was built without FTS5 (the sqlite_fts5 build tag).
+func (s *SQLiteStore) migrateFTS() error {
+	if _, err := s.db.Exec(ftsSchemaSQL); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO runs_fts (rowid, prompt, selected_command, stdout, stderr)
+		SELECT id, prompt, selected_command, CAST(stdout AS TEXT), CAST(stderr AS TEXT)
+		FROM runs
+		WHERE id NOT IN (SELECT rowid FROM runs_fts)
+		ORDER BY id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to build search index: %w", err)
+	}
+
+	return nil
+}
+
+// indexRun adds record to the full-text index
+func (s *SQLiteStore) indexRun(record *RunRecord) error {
+	_, err := s.db.Exec(
+		"INSERT INTO runs_fts (rowid, prompt, selected_command, stdout, stderr) VALUES (?, ?, ?, ?, ?)",
+		record.ID, record.Prompt, record.SelectedCommand, string(record.Stdout), string(record.Stderr),
+	)
+	return err
+}
+
+// Search returns up to limit runs whose prompt, command or output contain
+// every word of query, best matches first. Without FTS5 it falls back to a
+// slower substring match, newest first.
+func (s *SQLiteStore) Search(query string, limit int) ([]*RunRecord, error) {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return s.GetHistory(limit, "")
+	}
+
+	if s.fts {
+		return s.queryRecords(`
+			SELECT `+runColumns+`
+			FROM runs_fts
+			JOIN runs ON runs.id = runs_fts.rowid
+			WHERE runs_fts MATCH ?
+			ORDER BY rank
+			LIMIT ?
+		`, ftsQuery(words), limit)
+	}
+
+	sql := "SELECT " + runColumns + " FROM runs WHERE 1=1"
+	args := []interface{}{}
+	for _, word := range words {
+		sql += ` AND (prompt LIKE ? OR selected_command LIKE ?
+			OR CAST(stdout AS TEXT) LIKE ? OR CAST(stderr AS TEXT) LIKE ?)`
+		pattern := "%" + word + "%"
+		args = append(args, pattern, pattern, pattern, pattern)
+	}
+	sql += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	return s.queryRecords(sql, args...)
+}
+
+// ftsQuery quotes each word as an FTS5 string, so punctuation such as "-"
+// or "." in a search isn't parsed as query syntax. The words must all
+// match.
+func ftsQuery(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+	}
+	return strings.Join(quoted, " ")
+}
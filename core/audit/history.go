@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultHistoryPageSize is the page size of a HistoryQuery without a Limit
+const defaultHistoryPageSize = 20
+
+// HistoryOrder is the order GetHistoryPage returns runs in
+type HistoryOrder string
+
+const (
+	NewestFirst HistoryOrder = "newest"
+	OldestFirst HistoryOrder = "oldest"
+)
+
+// HistoryQuery selects a page of runs. Zero fields don't filter.
+type HistoryQuery struct {
+	Limit        int    // page size, defaultHistoryPageSize if 0
+	Offset       int    // runs to skip
+	Filter       string // substring of the prompt or command
+	RiskLevel    string
+	ExecutedOnly bool
+	From         time.Time    // runs at or after From
+	To           time.Time    // runs before To
+	Order        HistoryOrder // NewestFirst if empty
+}
+
+// HistoryPage is one page of runs matching a HistoryQuery
+type HistoryPage struct {
+	Records []*RunRecord
+	Total   int // runs matching the query across all pages
+}
+
+// GetHistoryPage returns the page of runs selected by q and how many runs
+// match in total
+func (s *SQLiteStore) GetHistoryPage(q HistoryQuery) (*HistoryPage, error) {
+	if q.Limit < 0 || q.Offset < 0 {
+		return nil, fmt.Errorf("invalid page: limit %d, offset %d", q.Limit, q.Offset)
+	}
+	if q.Limit == 0 {
+		q.Limit = defaultHistoryPageSize
+	}
+
+	order, err := q.order()
+	if err != nil {
+		return nil, err
+	}
+	where, args := q.where()
+
+	page := &HistoryPage{}
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM runs"+where, args...).Scan(&page.Total); err != nil {
+		return nil, fmt.Errorf("failed to count history: %w", err)
+	}
+
+	query := "SELECT " + runColumns + " FROM runs" + where + " ORDER BY id " + order + " LIMIT ? OFFSET ?"
+	records, err := s.queryRecords(query, append(args, q.Limit, q.Offset)...)
+	if err != nil {
+		return nil, err
+	}
+	page.Records = records
+
+	return page, nil
+}
+
+// order returns the SQL sort direction of q.Order
+func (q HistoryQuery) order() (string, error) {
+	switch q.Order {
+	case NewestFirst, "":
+		return "DESC", nil
+	case OldestFirst:
+		return "ASC", nil
+	default:
+		return "", fmt.Errorf("unknown history order %q", q.Order)
+	}
+}
+
+// where returns the WHERE clause selecting the runs matching q's filters,
+// and its arguments
+func (q HistoryQuery) where() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if q.Filter != "" {
+		conditions = append(conditions, "(prompt LIKE ? OR selected_command LIKE ?)")
+		pattern := "%" + q.Filter + "%"
+		args = append(args, pattern, pattern)
+	}
+	if q.RiskLevel != "" {
+		conditions = append(conditions, "risk_level = ?")
+		args = append(args, q.RiskLevel)
+	}
+	if q.ExecutedOnly {
+		conditions = append(conditions, "executed = 1")
+	}
+	// Timestamps are RFC 3339 in the local zone of whoever logged the run,
+	// so they are compared as instants rather than as strings
+	if !q.From.IsZero() {
+		conditions = append(conditions, "julianday(timestamp) >= julianday(?)")
+		args = append(args, q.From.UTC().Format(time.RFC3339))
+	}
+	if !q.To.IsZero() {
+		conditions = append(conditions, "julianday(timestamp) < julianday(?)")
+		args = append(args, q.To.UTC().Format(time.RFC3339))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
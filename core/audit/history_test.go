@@ -0,0 +1,131 @@
+package audit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// seedHistory logs one run per day from 2024-03-01, the last one unexecuted
+// and high risk. It returns the runs in logging order.
+func seedHistory(t *testing.T, store *SQLiteStore) []*RunRecord {
+	t.Helper()
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	// Runs logged from another time zone compare by instant
+	zone := time.FixedZone("UTC+5", 5*60*60)
+
+	var records []*RunRecord
+	for i := 0; i < 5; i++ {
+		record := &RunRecord{
+			Timestamp:       start.AddDate(0, 0, i).In(zone).Format(time.RFC3339),
+			Prompt:          "run",
+			SelectedCommand: "echo day",
+			RiskLevel:       "safe",
+			Executed:        true,
+		}
+		if i == 4 {
+			record.RiskLevel = "high"
+			record.Executed = false
+		}
+		if err := store.LogExecution(record); err != nil {
+			t.Fatalf("LogExecution() error: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func recordIDs(records []*RunRecord) []int64 {
+	out := make([]int64, len(records))
+	for i, record := range records {
+		out[i] = record.ID
+	}
+	return out
+}
+
+func TestSQLiteStore_GetHistoryPage_Offset(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test_audit.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	runs := seedHistory(t, store)
+
+	var seen []int64
+	for offset := 0; offset < 6; offset += 2 {
+		page, err := store.GetHistoryPage(HistoryQuery{Limit: 2, Offset: offset})
+		if err != nil {
+			t.Fatalf("GetHistoryPage() error: %v", err)
+		}
+		if page.Total != 5 {
+			t.Errorf("offset %d: Total = %d, want 5", offset, page.Total)
+		}
+		seen = append(seen, recordIDs(page.Records)...)
+	}
+
+	want := []int64{runs[4].ID, runs[3].ID, runs[2].ID, runs[1].ID, runs[0].ID}
+	if len(seen) != len(want) {
+		t.Fatalf("pages returned runs %v, want %v", seen, want)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Fatalf("pages returned runs %v, want %v", seen, want)
+		}
+	}
+
+	page, err := store.GetHistoryPage(HistoryQuery{Limit: 2, Offset: 1, Order: OldestFirst})
+	if err != nil {
+		t.Fatalf("GetHistoryPage() oldest first error: %v", err)
+	}
+	if got := recordIDs(page.Records); len(got) != 2 || got[0] != runs[1].ID || got[1] != runs[2].ID {
+		t.Errorf("oldest first page = %v, want runs 2 and 3", got)
+	}
+
+	if _, err := store.GetHistoryPage(HistoryQuery{Order: "sideways"}); err == nil {
+		t.Error("GetHistoryPage() accepted an unknown order")
+	}
+	if _, err := store.GetHistoryPage(HistoryQuery{Offset: -1}); err == nil {
+		t.Error("GetHistoryPage() accepted a negative offset")
+	}
+}
+
+func TestSQLiteStore_GetHistoryPage_DateRange(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test_audit.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	runs := seedHistory(t, store)
+
+	// March 2nd to 3rd inclusive, in UTC
+	page, err := store.GetHistoryPage(HistoryQuery{
+		From: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("GetHistoryPage() error: %v", err)
+	}
+	if got := recordIDs(page.Records); page.Total != 2 || len(got) != 2 || got[0] != runs[2].ID || got[1] != runs[1].ID {
+		t.Errorf("date range = %v (total %d), want runs 3 and 2", got, page.Total)
+	}
+
+	// A run exactly at From is included, one exactly at To is not
+	page, _ = store.GetHistoryPage(HistoryQuery{
+		From: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC),
+	})
+	if got := recordIDs(page.Records); len(got) != 1 || got[0] != runs[0].ID {
+		t.Errorf("range bounds = %v, want only run 1", got)
+	}
+
+	// Filters combine
+	page, _ = store.GetHistoryPage(HistoryQuery{From: time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), ExecutedOnly: true})
+	if page.Total != 2 {
+		t.Errorf("executed runs from March 3rd = %d, want 2", page.Total)
+	}
+	page, _ = store.GetHistoryPage(HistoryQuery{RiskLevel: "high"})
+	if got := recordIDs(page.Records); page.Total != 1 || got[0] != runs[4].ID {
+		t.Errorf("high risk runs = %v, want run 5", got)
+	}
+}
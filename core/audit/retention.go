@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Purge deletes runs created more than olderThan ago and returns how many
+// were deleted. Runs are purged oldest first, stopping at the first run
+// newer than the cutoff, so the runs kept are always an unbroken tail of the
+// log. The database is vacuumed afterwards to reclaim the space.
+func (s *SQLiteStore) Purge(olderThan time.Duration) (int, error) {
+	cutoff := time.Now().Add(-olderThan).UTC().Format("2006-01-02 15:04:05")
+
+	return s.purge(func(tx *sql.Tx) (int64, error) {
+		var first sql.NullInt64
+		err := tx.QueryRow(
+			"SELECT MIN(id) FROM runs WHERE julianday(created_at) >= julianday(?)", cutoff,
+		).Scan(&first)
+		if err != nil {
+			return 0, err
+		}
+		if !first.Valid {
+			// Every run is older than the cutoff
+			return math.MaxInt64, nil
+		}
+		return first.Int64, nil
+	})
+}
+
+// PurgeKeepLast deletes all but the n most recent runs and returns how many
+// were deleted. The database is vacuumed afterwards to reclaim the space.
+func (s *SQLiteStore) PurgeKeepLast(n int) (int, error) {
+	if n < 0 {
+		return 0, fmt.Errorf("cannot keep %d runs", n)
+	}
+
+	return s.purge(func(tx *sql.Tx) (int64, error) {
+		if n == 0 {
+			return math.MaxInt64, nil
+		}
+
+		var first int64
+		err := tx.QueryRow("SELECT id FROM runs ORDER BY id DESC LIMIT 1 OFFSET ?", n-1).Scan(&first)
+		if err == sql.ErrNoRows {
+			// Fewer than n runs
+			return 0, nil
+		}
+		return first, err
+	})
+}
+
+// purge deletes every run with an ID below the one returned by keepFrom,
+// which runs in the same transaction. The hash of the last run deleted
+// becomes the chain anchor, so VerifyChain still checks the first run kept
+// and runs logged after purging everything continue the chain.
+func (s *SQLiteStore) purge(keepFrom func(tx *sql.Tx) (int64, error)) (int, error) {
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin purge: %w", err)
+	}
+	defer tx.Rollback()
+
+	boundary, err := keepFrom(tx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find runs to purge: %w", err)
+	}
+
+	var anchor string
+	err = tx.QueryRow(
+		"SELECT COALESCE(record_hash, '') FROM runs WHERE id < ? ORDER BY id DESC LIMIT 1", boundary,
+	).Scan(&anchor)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read chain anchor: %w", err)
+	}
+
+	// The index is contentless, so its entries are deleted by repeating the
+	// values they were indexed with
+	if s.fts {
+		_, err := tx.Exec(`
+			INSERT INTO runs_fts (runs_fts, rowid, prompt, selected_command, stdout, stderr)
+			SELECT 'delete', id, prompt, selected_command, CAST(stdout AS TEXT), CAST(stderr AS TEXT)
+			FROM runs
+			WHERE id < ?
+		`, boundary)
+		if err != nil {
+			return 0, fmt.Errorf("failed to remove purged runs from search index: %w", err)
+		}
+	}
+
+	result, err := tx.Exec("DELETE FROM runs WHERE id < ?", boundary)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge runs: %w", err)
+	}
+	deleted, _ := result.RowsAffected()
+
+	if _, err := tx.Exec("INSERT OR REPLACE INTO chain_anchor (id, hash) VALUES (1, ?)", anchor); err != nil {
+		return 0, fmt.Errorf("failed to update chain anchor: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit purge: %w", err)
+	}
+
+	// VACUUM can't run inside a transaction
+	if _, err := s.db.Exec("VACUUM"); err != nil {
+		return int(deleted), fmt.Errorf("failed to vacuum audit database: %w", err)
+	}
+
+	return int(deleted), nil
+}
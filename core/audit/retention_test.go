@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// remainingIDs returns the IDs of every run left in store, oldest first
+func remainingIDs(t *testing.T, store *SQLiteStore) []int64 {
+	t.Helper()
+
+	page, err := store.GetHistoryPage(HistoryQuery{Limit: 100, Order: OldestFirst})
+	if err != nil {
+		t.Fatalf("GetHistoryPage() error: %v", err)
+	}
+	return recordIDs(page.Records)
+}
+
+// assertChainIntact fails t if VerifyChain reports any broken runs
+func assertChainIntact(t *testing.T, store *SQLiteStore) {
+	t.Helper()
+
+	broken, err := store.VerifyChain()
+	if err != nil {
+		t.Fatalf("VerifyChain() error: %v", err)
+	}
+	if len(broken) != 0 {
+		t.Errorf("VerifyChain() = %v, want none", broken)
+	}
+}
+
+func TestSQLiteStore_Purge(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test_audit.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	records := seedHistory(t, store)
+
+	// The first two runs were logged 100 days ago
+	_, err = store.db.Exec(
+		"UPDATE runs SET created_at = datetime('now', '-100 days') WHERE id <= ?", records[1].ID,
+	)
+	if err != nil {
+		t.Fatalf("Failed to age runs: %v", err)
+	}
+
+	deleted, err := store.Purge(90 * 24 * time.Hour)
+	if err != nil {
+		t.Fatalf("Purge() error: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Purge() deleted %d runs, want 2", deleted)
+	}
+	if got, want := remainingIDs(t, store), recordIDs(records[2:]); !reflect.DeepEqual(got, want) {
+		t.Errorf("runs after Purge() = %v, want %v", got, want)
+	}
+
+	// The oldest remaining run chains from the purged ones
+	assertChainIntact(t, store)
+
+	if deleted, err := store.Purge(90 * 24 * time.Hour); err != nil || deleted != 0 {
+		t.Errorf("second Purge() = %d, %v, want nothing deleted", deleted, err)
+	}
+}
+
+func TestSQLiteStore_PurgeKeepLast(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test_audit.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	records := seedHistory(t, store)
+
+	if deleted, err := store.PurgeKeepLast(10); err != nil || deleted != 0 {
+		t.Errorf("PurgeKeepLast(10) = %d, %v, want nothing deleted", deleted, err)
+	}
+
+	deleted, err := store.PurgeKeepLast(2)
+	if err != nil {
+		t.Fatalf("PurgeKeepLast(2) error: %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("PurgeKeepLast(2) deleted %d runs, want 3", deleted)
+	}
+	if got, want := remainingIDs(t, store), recordIDs(records[3:]); !reflect.DeepEqual(got, want) {
+		t.Errorf("runs after PurgeKeepLast(2) = %v, want %v", got, want)
+	}
+	assertChainIntact(t, store)
+
+	// Runs logged after purging everything continue the chain
+	if deleted, err := store.PurgeKeepLast(0); err != nil || deleted != 2 {
+		t.Fatalf("PurgeKeepLast(0) = %d, %v, want 2 deleted", deleted, err)
+	}
+	record := &RunRecord{Prompt: "run", SelectedCommand: "echo again", RiskLevel: "safe"}
+	if err := store.LogExecution(record); err != nil {
+		t.Fatalf("LogExecution() error: %v", err)
+	}
+	if record.PrevHash != records[4].RecordHash {
+		t.Errorf("PrevHash after purging everything = %q, want last purged hash %q", record.PrevHash, records[4].RecordHash)
+	}
+	assertChainIntact(t, store)
+
+	if _, err := store.PurgeKeepLast(-1); err == nil {
+		t.Error("PurgeKeepLast(-1) expected error")
+	}
+}
+
+func TestSQLiteStore_PurgeRemovesFromSearch(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test_audit.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	for _, command := range []string{"echo purged", "echo kept"} {
+		record := &RunRecord{Prompt: "say", SelectedCommand: command, RiskLevel: "safe"}
+		if err := store.LogExecution(record); err != nil {
+			t.Fatalf("LogExecution() error: %v", err)
+		}
+	}
+
+	if _, err := store.PurgeKeepLast(1); err != nil {
+		t.Fatalf("PurgeKeepLast(1) error: %v", err)
+	}
+
+	results, err := store.Search("echo", 10)
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(results) != 1 || results[0].SelectedCommand != "echo kept" {
+		t.Errorf("Search() after purge = %v, want only the kept run", results)
+	}
+}
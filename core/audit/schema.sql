@@ -15,7 +15,9 @@ CREATE TABLE IF NOT EXISTS runs (
     snapshot TEXT,
     executed BOOLEAN DEFAULT 0,
     duration_ms INTEGER,
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    prev_hash TEXT,
+    record_hash TEXT
 );
 
 CREATE INDEX IF NOT EXISTS idx_runs_timestamp ON runs(timestamp);
@@ -23,6 +25,12 @@ CREATE INDEX IF NOT EXISTS idx_runs_user ON runs(user);
 CREATE INDEX IF NOT EXISTS idx_runs_executed ON runs(executed);
 CREATE INDEX IF NOT EXISTS idx_runs_risk_level ON runs(risk_level);
 
+-- Hash of the last purged run, which the oldest remaining run chains from
+CREATE TABLE IF NOT EXISTS chain_anchor (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    hash TEXT NOT NULL
+);
+
 -- Metadata table for schema versioning
 CREATE TABLE IF NOT EXISTS schema_version (
     version INTEGER PRIMARY KEY,
@@ -3,7 +3,6 @@ package audit
 import (
 	"database/sql"
 	"fmt"
-	"strings"
 	"time"
 )
 
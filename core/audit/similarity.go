@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// similarityScanLimit is how many recent records FindSimilar compares against
+const similarityScanLimit = 1000
+
+// SimilarMatch is a past run ranked by similarity to a query command
+type SimilarMatch struct {
+	Record *RunRecord
+	Score  float64 // 0.0 (nothing in common) to 1.0 (same tokens)
+}
+
+// CommandSimilarity returns the Jaccard similarity of the token sets of two
+// commands. Tokens are whitespace separated and compared case-sensitively,
+// so argument order does not affect the score.
+func CommandSimilarity(a, b string) float64 {
+	tokensA := tokenSet(a)
+	tokensB := tokenSet(b)
+
+	if len(tokensA) == 0 && len(tokensB) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for token := range tokensA {
+		if tokensB[token] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(tokensA)+len(tokensB)-shared)
+}
+
+// FindSimilar returns past runs whose command resembles the given one, most
+// similar first. Repeated commands are reported once, using the latest run.
+func (s *SQLiteStore) FindSimilar(command string, limit int) ([]*SimilarMatch, error) {
+	records, err := s.GetHistory(similarityScanLimit, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+
+	return rankSimilar(command, records, limit), nil
+}
+
+// rankSimilar scores records against command. Records are expected newest
+// first, as returned by GetHistory.
+func rankSimilar(command string, records []*RunRecord, limit int) []*SimilarMatch {
+	seen := make(map[string]bool)
+	var matches []*SimilarMatch
+
+	for _, record := range records {
+		if seen[record.SelectedCommand] {
+			continue
+		}
+		seen[record.SelectedCommand] = true
+
+		score := CommandSimilarity(command, record.SelectedCommand)
+		if score == 0 {
+			continue
+		}
+		matches = append(matches, &SimilarMatch{Record: record, Score: score})
+	}
+
+	// Stable sort keeps newer runs ahead on equal scores
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches
+}
+
+// tokenSet splits a command into its distinct tokens
+func tokenSet(command string) map[string]bool {
+	set := make(map[string]bool)
+	for _, token := range strings.Fields(command) {
+		set[token] = true
+	}
+	return set
+}
@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCommandSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want float64
+	}{
+		{"identical", "ls -la /tmp", "ls -la /tmp", 1},
+		{"reordered", "ls -la /tmp", "ls /tmp -la", 1},
+		{"disjoint", "ls -la", "git status", 0},
+		{"half shared", "git status", "git log", 1.0 / 3.0},
+		{"both empty", "", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CommandSimilarity(tt.a, tt.b); got != tt.want {
+				t.Errorf("CommandSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLiteStore_FindSimilar(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test_audit.db")
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	commands := []string{
+		"docker ps -a",
+		"find . -name '*.log' -mtime +7",
+		"find . -name '*.log' -mtime +30 -delete",
+		"git status",
+		"find . -name '*.tmp'",
+		"git status",
+	}
+
+	for _, command := range commands {
+		record := &RunRecord{
+			Timestamp:       time.Now().Format(time.RFC3339),
+			User:            "testuser",
+			SelectedCommand: command,
+			RiskLevel:       "safe",
+		}
+		if err := store.LogExecution(record); err != nil {
+			t.Fatalf("LogExecution() error: %v", err)
+		}
+	}
+
+	matches, err := store.FindSimilar("find . -name '*.log' -mtime +7 -print", 10)
+	if err != nil {
+		t.Fatalf("FindSimilar() error: %v", err)
+	}
+
+	if len(matches) != 3 {
+		t.Fatalf("FindSimilar() returned %d matches, want 3", len(matches))
+	}
+
+	if got := matches[0].Record.SelectedCommand; got != "find . -name '*.log' -mtime +7" {
+		t.Errorf("Most similar = %q, want %q", got, "find . -name '*.log' -mtime +7")
+	}
+
+	for i := 1; i < len(matches); i++ {
+		if matches[i].Score > matches[i-1].Score {
+			t.Errorf("Matches not ranked: %v before %v", matches[i-1].Score, matches[i].Score)
+		}
+	}
+
+	// Repeated commands appear once
+	matches, err = store.FindSimilar("git status", 10)
+	if err != nil {
+		t.Fatalf("FindSimilar() error: %v", err)
+	}
+	if len(matches) != 1 {
+		t.Errorf("FindSimilar() returned %d matches, want 1", len(matches))
+	}
+
+	matches, err = store.FindSimilar("find . -name", 1)
+	if err != nil {
+		t.Fatalf("FindSimilar() error: %v", err)
+	}
+	if len(matches) != 1 {
+		t.Errorf("FindSimilar() with limit 1 returned %d matches", len(matches))
+	}
+}
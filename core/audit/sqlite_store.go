@@ -8,11 +8,12 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"sync"
 	"time"
 	
+	"github.com/SagheerAkram/QuickCmd/core/executor"
+	"github.com/SagheerAkram/QuickCmd/core/policy"
 	_ "github.com/mattn/go-sqlite3"
-	"github.com/yourusername/quickcmd/core/executor"
-	"github.com/yourusername/quickcmd/core/policy"
 )
 
 //go:embed schema.sql
@@ -34,12 +35,29 @@ type RunRecord struct {
 	Executed        bool
 	DurationMs      int64
 	CreatedAt       time.Time
+	
+	// Hash chain linking each record to the one before it (see VerifyChain).
+	// Both are empty for records logged before the chain existed.
+	PrevHash   string
+	RecordHash string
 }
 
+// runColumns are the columns scanned into a RunRecord, qualified so they
+// can be selected from joins
+const runColumns = `runs.id, runs.timestamp, runs.user, runs.prompt, runs.selected_command,
+	runs.sandbox_id, runs.exit_code, runs.stdout, runs.stderr, runs.risk_level,
+	runs.snapshot, runs.executed, runs.duration_ms, runs.created_at,
+	COALESCE(runs.prev_hash, ''), COALESCE(runs.record_hash, '')`
+
 // SQLiteStore manages audit log storage
 type SQLiteStore struct {
 	db       *sql.DB
 	redactor *policy.SecretRedactor
+	fts      bool // whether SQLite has FTS5 and runs_fts is in use
+	
+	// logMu makes reading the chain's last hash and inserting the next
+	// record atomic within the process
+	logMu sync.Mutex
 }
 
 // NewSQLiteStore creates a new SQLite audit store
@@ -50,12 +68,18 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("failed to create audit directory: %w", err)
 	}
 	
-	// Open database
-	db, err := sql.Open("sqlite3", dbPath)
+	// Open database. Transactions take the write lock when they begin, so
+	// two processes logging at once can't both chain from the same run.
+	db, err := sql.Open("sqlite3", dbPath+"?_txlock=immediate")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open audit database: %w", err)
 	}
 	
+	// Every connection to ":memory:" opens a separate, empty database
+	if dbPath == ":memory:" {
+		db.SetMaxOpenConns(1)
+	}
+	
 	// Enable WAL mode for better concurrency
 	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
 		db.Close()
@@ -78,8 +102,16 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 
 // migrate runs database migrations
 func (s *SQLiteStore) migrate() error {
-	_, err := s.db.Exec(schemaSQL)
-	return err
+	if _, err := s.db.Exec(schemaSQL); err != nil {
+		return err
+	}
+	if err := s.migrateHashChain(); err != nil {
+		return err
+	}
+	
+	// Full-text search is optional; Search falls back to LIKE without it
+	s.fts = s.migrateFTS() == nil
+	return nil
 }
 
 // LogExecution logs a command execution
@@ -110,16 +142,32 @@ func (s *SQLiteStore) LogExecution(record *RunRecord) error {
 		record.Timestamp = time.Now().Format(time.RFC3339)
 	}
 	
+	// Chain the record to the last one. The hash covers the redacted
+	// record, exactly as stored.
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin audit transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	if record.PrevHash, err = lastRecordHash(tx); err != nil {
+		return err
+	}
+	record.RecordHash = hashRecord(record.PrevHash, record)
+	
 	// Insert record
 	query := `
 		INSERT INTO runs (
 			timestamp, user, prompt, selected_command, sandbox_id,
 			exit_code, stdout, stderr, risk_level, snapshot,
-			executed, duration_ms
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			executed, duration_ms, prev_hash, record_hash
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	
-	result, err := s.db.Exec(query,
+	result, err := tx.Exec(query,
 		record.Timestamp,
 		record.User,
 		record.Prompt,
@@ -132,25 +180,31 @@ func (s *SQLiteStore) LogExecution(record *RunRecord) error {
 		record.Snapshot,
 		record.Executed,
 		record.DurationMs,
+		record.PrevHash,
+		record.RecordHash,
 	)
 	
 	if err != nil {
 		return fmt.Errorf("failed to insert audit record: %w", err)
 	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit audit record: %w", err)
+	}
 	
 	record.ID, _ = result.LastInsertId()
+	
+	// A run missing from the index is added the next time the store is
+	// opened, so this doesn't fail the log
+	if s.fts {
+		s.indexRun(record)
+	}
+	
 	return nil
 }
 
 // GetHistory retrieves execution history
 func (s *SQLiteStore) GetHistory(limit int, filter string) ([]*RunRecord, error) {
-	query := `
-		SELECT id, timestamp, user, prompt, selected_command, sandbox_id,
-		       exit_code, stdout, stderr, risk_level, snapshot, executed,
-		       duration_ms, created_at
-		FROM runs
-		WHERE 1=1
-	`
+	query := "SELECT " + runColumns + " FROM runs WHERE 1=1"
 	
 	args := []interface{}{}
 	
@@ -163,6 +217,11 @@ func (s *SQLiteStore) GetHistory(limit int, filter string) ([]*RunRecord, error)
 	query += " ORDER BY id DESC LIMIT ?"
 	args = append(args, limit)
 	
+	return s.queryRecords(query, args...)
+}
+
+// queryRecords runs a query selecting runColumns and scans the results
+func (s *SQLiteStore) queryRecords(query string, args ...interface{}) ([]*RunRecord, error) {
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query history: %w", err)
@@ -171,44 +230,20 @@ func (s *SQLiteStore) GetHistory(limit int, filter string) ([]*RunRecord, error)
 	
 	var records []*RunRecord
 	for rows.Next() {
-		record := &RunRecord{}
-		err := rows.Scan(
-			&record.ID,
-			&record.Timestamp,
-			&record.User,
-			&record.Prompt,
-			&record.SelectedCommand,
-			&record.SandboxID,
-			&record.ExitCode,
-			&record.Stdout,
-			&record.Stderr,
-			&record.RiskLevel,
-			&record.Snapshot,
-			&record.Executed,
-			&record.DurationMs,
-			&record.CreatedAt,
-		)
+		record, err := scanRecord(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan record: %w", err)
 		}
 		records = append(records, record)
 	}
 	
-	return records, nil
+	return records, rows.Err()
 }
 
-// GetRecordByID retrieves a specific record
-func (s *SQLiteStore) GetRecordByID(id int64) (*RunRecord, error) {
-	query := `
-		SELECT id, timestamp, user, prompt, selected_command, sandbox_id,
-		       exit_code, stdout, stderr, risk_level, snapshot, executed,
-		       duration_ms, created_at
-		FROM runs
-		WHERE id = ?
-	`
-	
+// scanRecord scans a row of runColumns
+func scanRecord(row interface{ Scan(dest ...interface{}) error }) (*RunRecord, error) {
 	record := &RunRecord{}
-	err := s.db.QueryRow(query, id).Scan(
+	err := row.Scan(
 		&record.ID,
 		&record.Timestamp,
 		&record.User,
@@ -223,7 +258,20 @@ func (s *SQLiteStore) GetRecordByID(id int64) (*RunRecord, error) {
 		&record.Executed,
 		&record.DurationMs,
 		&record.CreatedAt,
+		&record.PrevHash,
+		&record.RecordHash,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// GetRecordByID retrieves a specific record
+func (s *SQLiteStore) GetRecordByID(id int64) (*RunRecord, error) {
+	query := "SELECT " + runColumns + " FROM runs WHERE id = ?"
+	
+	record, err := scanRecord(s.db.QueryRow(query, id))
 	
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("record not found")
@@ -1,12 +1,11 @@
 package audit
 
 import (
-	"os"
 	"path/filepath"
 	"testing"
 	"time"
 	
-	"github.com/yourusername/quickcmd/core/executor"
+	"github.com/SagheerAkram/QuickCmd/core/executor"
 )
 
 func TestSQLiteStore_LogExecution(t *testing.T) {
@@ -286,3 +285,96 @@ func indexOfSubstring(s, substr string) int {
 	}
 	return -1
 }
+
+func TestSQLiteStore_Search(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test_audit.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	
+	records := []*RunRecord{
+		{Prompt: "check disk usage", SelectedCommand: "df -h", Stdout: []byte("/dev/sda1 40G")},
+		{Prompt: "verify backup", SelectedCommand: "sha256sum backup.tar", Stdout: []byte("checksum mismatch in segment-42\n")},
+		{Prompt: "list files", SelectedCommand: "ls", Stderr: []byte("permission denied")},
+	}
+	for _, record := range records {
+		record.RiskLevel = "safe"
+		record.Executed = true
+		if err := store.LogExecution(record); err != nil {
+			t.Fatalf("LogExecution() error: %v", err)
+		}
+	}
+	
+	// The old filter only looks at prompts and commands
+	if history, _ := store.GetHistory(10, "segment-42"); len(history) != 0 {
+		t.Errorf("GetHistory() matched stdout, got %d records", len(history))
+	}
+	
+	search := func(query string) []*RunRecord {
+		t.Helper()
+		results, err := store.Search(query, 10)
+		if err != nil {
+			t.Fatalf("Search(%q) error: %v", query, err)
+		}
+		return results
+	}
+	
+	for _, fts := range []bool{store.fts, false} {
+		store.fts = fts
+	
+		results := search("segment-42")
+		if len(results) != 1 || results[0].ID != records[1].ID {
+			t.Errorf("Search() stdout token (fts %v) = %d records, want the backup run", fts, len(results))
+		}
+		if results := search("permission denied"); len(results) != 1 || results[0].ID != records[2].ID {
+			t.Errorf("Search() stderr words (fts %v) = %d records, want the ls run", fts, len(results))
+		}
+		if results := search("backup mismatch"); len(results) != 1 {
+			t.Errorf("Search() words across columns (fts %v) = %d records, want 1", fts, len(results))
+		}
+		if results := search("checksum nowhere"); len(results) != 0 {
+			t.Errorf("Search() with an unmatched word (fts %v) = %d records, want 0", fts, len(results))
+		}
+	}
+}
+
+func TestSQLiteStore_SearchIndexesExistingRuns(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test_audit.db")
+	
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	if !store.fts {
+		store.Close()
+		t.Skip("SQLite was built without FTS5 (build with -tags sqlite_fts5)")
+	}
+	
+	// A run logged before the index existed, or by a build without FTS5
+	_, err = store.db.Exec(`INSERT INTO runs (timestamp, user, prompt, selected_command, sandbox_id, exit_code, stdout, stderr,
+		                  risk_level, snapshot, executed, duration_ms)
+		VALUES (?, 'alice', 'run tests', 'go test ./...', '', 1, ?, '', 'safe', '', 1, 0)`, time.Now().Format(time.RFC3339), []byte("FAIL TestFlakyTimeout"))
+	if err != nil {
+		t.Fatalf("Failed to insert run: %v", err)
+	}
+	// Runs indexed after it don't hide it
+	if err := store.LogExecution(&RunRecord{Prompt: "list files", SelectedCommand: "ls", RiskLevel: "safe"}); err != nil {
+		t.Fatalf("LogExecution() error: %v", err)
+	}
+	store.Close()
+	
+	store, err = NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+	
+	results, err := store.Search("TestFlakyTimeout", 10)
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(results) != 1 || results[0].Prompt != "run tests" {
+		t.Errorf("Search() after reopening = %d records, want the unindexed run", len(results))
+	}
+}
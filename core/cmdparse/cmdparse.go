@@ -0,0 +1,317 @@
+// Package cmdparse splits shell command lines into words and extracts a
+// command's name, subcommand, flags and positional arguments, so analysers
+// agree on how quotes and flags are read.
+package cmdparse
+
+import (
+	"strings"
+)
+
+// Token is a word or control operator of a command line
+type Token struct {
+	Value    string
+	Operator bool // an unquoted |, ||, &&, ;, & or newline
+}
+
+// Flag is a command line option
+type Flag struct {
+	Name     string // with its dashes, e.g. "-l" or "--namespace"
+	Value    string // the value given as --name=value
+	HasValue bool   // whether the flag was given as --name=value
+}
+
+// Command is a parsed simple command
+type Command struct {
+	Env        []string // leading VAR=value assignments
+	Name       string   // the command run, e.g. "git"
+	Subcommand string   // e.g. "commit" in "git commit -m x", for tools with subcommands
+	Flags      []Flag   // options in the order given, combined short flags expanded
+	Args       []string // positional arguments, after the subcommand
+	Words      []string // every word from Name on, unquoted
+}
+
+// subcommandTools are commands whose first argument selects a subcommand
+var subcommandTools = map[string]bool{
+	"git": true, "docker": true, "kubectl": true, "aws": true, "gcloud": true, "az": true,
+	"helm": true, "terraform": true, "npm": true, "yarn": true, "go": true,
+	"cargo": true, "pip": true, "brew": true, "apt": true, "systemctl": true,
+}
+
+// wrapperValueFlags lists, for commands that run the command following
+// them, the options that take the next word as their value
+var wrapperValueFlags = map[string]map[string]bool{
+	"sudo":    {"-u": true, "-g": true, "-h": true, "-p": true, "-C": true, "-D": true, "-r": true, "-t": true, "-U": true},
+	"env":     {"-u": true, "-C": true, "-S": true},
+	"nice":    {"-n": true},
+	"time":    {"-f": true, "-o": true},
+	"exec":    {"-a": true},
+	"nohup":   {},
+	"command": {},
+}
+
+// Tokenize splits command into words and control operators like a POSIX
+// shell: quotes group words and are removed, and a backslash escapes the
+// next character outside single quotes. Expansions such as $VAR are kept
+// as written.
+func Tokenize(command string) []Token {
+	var (
+		tokens  []Token
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+
+	flush := func() {
+		if inWord {
+			tokens = append(tokens, Token{Value: word.String()})
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case escaped:
+			escaped = false
+			if r != '\n' { // a backslash-newline continues the line
+				word.WriteRune(r)
+				inWord = true
+			}
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`", runes[i+1]) {
+				i++
+				word.WriteRune(runes[i])
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			flush()
+		case r == '|' || r == '&' || r == ';' || r == '\n':
+			flush()
+			op := string(r)
+			if (r == '|' || r == '&') && i+1 < len(runes) && runes[i+1] == r {
+				op += string(r)
+				i++
+			}
+			tokens = append(tokens, Token{Value: op, Operator: true})
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// Split returns the words and control operators of command as strings
+func Split(command string) []string {
+	tokens := Tokenize(command)
+	words := make([]string, len(tokens))
+	for i, token := range tokens {
+		words[i] = token.Value
+	}
+	return words
+}
+
+// Pipeline splits command on unquoted "|" into the words of each stage.
+// Other control operators are kept as words.
+func Pipeline(command string) [][]string {
+	stages := [][]string{{}}
+	for _, token := range Tokenize(command) {
+		if token.Operator && token.Value == "|" {
+			stages = append(stages, []string{})
+			continue
+		}
+		stages[len(stages)-1] = append(stages[len(stages)-1], token.Value)
+	}
+	return stages
+}
+
+// Parse parses the first simple command of command, up to the first
+// control operator. A flag's value is only recognised in the --name=value
+// form: "--name value" can't be told apart from a flag followed by an
+// argument without knowing the flag, so value appears in Args; use
+// FlagValue for flags known to take one.
+func Parse(command string) *Command {
+	tokens := Tokenize(command)
+	for i, token := range tokens {
+		if token.Operator {
+			tokens = tokens[:i]
+			break
+		}
+	}
+	return parseTokens(tokens)
+}
+
+// Commands parses every simple command of command, such as both sides of
+// "make && git push". Empty commands, e.g. after a trailing ";", are left
+// out.
+func Commands(command string) []*Command {
+	var commands []*Command
+	start := 0
+	tokens := Tokenize(command)
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) && !tokens[i].Operator {
+			continue
+		}
+		if c := parseTokens(tokens[start:i]); c.Name != "" {
+			commands = append(commands, c)
+		}
+		start = i + 1
+	}
+	return commands
+}
+
+// parseTokens parses the words of a simple command
+func parseTokens(tokens []Token) *Command {
+	c := &Command{}
+
+	for _, token := range tokens {
+		if len(c.Words) == 0 && isAssignment(token.Value) {
+			c.Env = append(c.Env, token.Value)
+			continue
+		}
+		c.Words = append(c.Words, token.Value)
+	}
+	if len(c.Words) == 0 {
+		return c
+	}
+
+	c.Name = c.Words[0]
+	args := c.Words[1:]
+	if subcommandTools[c.Name] && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		c.Subcommand = args[0]
+		args = args[1:]
+	}
+
+	flagsDone := false
+	for _, arg := range args {
+		switch {
+		case flagsDone || arg == "-" || !strings.HasPrefix(arg, "-"):
+			c.Args = append(c.Args, arg)
+		case arg == "--":
+			flagsDone = true
+		default:
+			c.Flags = append(c.Flags, parseFlag(arg)...)
+		}
+	}
+
+	return c
+}
+
+// parseFlag parses an option word, expanding combined short flags: "-la" is
+// -l and -a
+func parseFlag(arg string) []Flag {
+	name, value, hasValue := arg, "", false
+	if i := strings.Index(arg, "="); i > 0 {
+		name, value, hasValue = arg[:i], arg[i+1:], true
+	}
+
+	if strings.HasPrefix(name, "--") || len(name) <= 2 {
+		return []Flag{{Name: name, Value: value, HasValue: hasValue}}
+	}
+
+	// The value belongs to the last of the combined flags
+	letters := []rune(name[1:])
+	flags := make([]Flag, len(letters))
+	for i, letter := range letters {
+		flags[i] = Flag{Name: "-" + string(letter)}
+	}
+	flags[len(flags)-1].Value = value
+	flags[len(flags)-1].HasValue = hasValue
+	return flags
+}
+
+// HasFlag reports whether the flag name, e.g. "-l" or "--force", was given
+func (c *Command) HasFlag(name string) bool {
+	for _, flag := range c.Flags {
+		if flag.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FlagValue returns the value of the flag name given as "--name value" or
+// "--name=value"
+func (c *Command) FlagValue(name string) (string, bool) {
+	for i, word := range c.Words {
+		if word == "--" {
+			break
+		}
+		if word == name && i+1 < len(c.Words) {
+			return c.Words[i+1], true
+		}
+		if strings.HasPrefix(word, name+"=") {
+			return strings.TrimPrefix(word, name+"="), true
+		}
+	}
+	return "", false
+}
+
+// Executable returns the base name of the program c runs, looking through
+// wrappers like sudo and env and their options, so "sudo -u bob
+// /usr/bin/systemctl restart nginx" runs "systemctl". It is "" if only
+// wrappers are given.
+func (c *Command) Executable() string {
+	words := c.Words
+	for len(words) > 0 {
+		name := words[0][strings.LastIndex(words[0], "/")+1:]
+		valueFlags, wrapper := wrapperValueFlags[name]
+		if !wrapper {
+			return name
+		}
+
+		words = words[1:]
+		for len(words) > 0 {
+			word := words[0]
+			if word == "--" {
+				words = words[1:]
+				break
+			}
+			if name == "env" && isAssignment(word) {
+				words = words[1:]
+				continue
+			}
+			if !strings.HasPrefix(word, "-") || word == "-" {
+				break
+			}
+			words = words[1:]
+			if valueFlags[word] && len(words) > 0 {
+				words = words[1:]
+			}
+		}
+	}
+	return ""
+}
+
+// isAssignment reports whether word is a VAR=value assignment
+func isAssignment(word string) bool {
+	i := strings.Index(word, "=")
+	if i <= 0 {
+		return false
+	}
+	for j, r := range word[:i] {
+		if r != '_' && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && (j == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
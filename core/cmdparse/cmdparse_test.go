@@ -0,0 +1,229 @@
+package cmdparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"ls -la /tmp", []string{"ls", "-la", "/tmp"}},
+		{"  ls\t-la  ", []string{"ls", "-la"}},
+		{`git commit -m "fix the build"`, []string{"git", "commit", "-m", "fix the build"}},
+		{`echo 'it''s' "a \"b\" \$c"`, []string{"echo", "its", `a "b" $c`}},
+		{`echo 'a \"b\"'`, []string{"echo", `a \"b\"`}},
+		{`touch my\ file ""`, []string{"touch", "my file", ""}},
+		{`grep --include="*.go" -r TODO .`, []string{"grep", "--include=*.go", "-r", "TODO", "."}},
+		{"ps aux | grep 'a|b'", []string{"ps", "aux", "|", "grep", "a|b"}},
+		{"make && make test || echo failed; date &", []string{"make", "&&", "make", "test", "||", "echo", "failed", ";", "date", "&"}},
+		{"", []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := Split(tt.command); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Split(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestTokenize_QuotedOperator(t *testing.T) {
+	tokens := Tokenize(`echo "|" | wc`)
+	want := []Token{{Value: "echo"}, {Value: "|"}, {Value: "|", Operator: true}, {Value: "wc"}}
+	if !reflect.DeepEqual(tokens, want) {
+		t.Errorf("Tokenize() = %+v, want %+v", tokens, want)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	got := Pipeline(`find . -name "*.log" | grep -i 'error|warn' | head`)
+	want := [][]string{
+		{"find", ".", "-name", "*.log"},
+		{"grep", "-i", "error|warn"},
+		{"head"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Pipeline() = %q, want %q", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    *Command
+	}{
+		{
+			name:    "combined short flags",
+			command: "ls -la /tmp",
+			want: &Command{
+				Name:  "ls",
+				Flags: []Flag{{Name: "-l"}, {Name: "-a"}},
+				Args:  []string{"/tmp"},
+				Words: []string{"ls", "-la", "/tmp"},
+			},
+		},
+		{
+			name:    "subcommand and quoted argument",
+			command: `git commit -m "fix the build"`,
+			want: &Command{
+				Name:       "git",
+				Subcommand: "commit",
+				Flags:      []Flag{{Name: "-m"}},
+				Args:       []string{"fix the build"},
+				Words:      []string{"git", "commit", "-m", "fix the build"},
+			},
+		},
+		{
+			name:    "long flag with value",
+			command: `kubectl get pods --namespace=dev -o "wide"`,
+			want: &Command{
+				Name:       "kubectl",
+				Subcommand: "get",
+				Flags:      []Flag{{Name: "--namespace", Value: "dev", HasValue: true}, {Name: "-o"}},
+				Args:       []string{"pods", "wide"},
+				Words:      []string{"kubectl", "get", "pods", "--namespace=dev", "-o", "wide"},
+			},
+		},
+		{
+			name:    "quoted flag value",
+			command: `grep --include='*.go' -rn TODO`,
+			want: &Command{
+				Name:  "grep",
+				Flags: []Flag{{Name: "--include", Value: "*.go", HasValue: true}, {Name: "-r"}, {Name: "-n"}},
+				Args:  []string{"TODO"},
+				Words: []string{"grep", "--include=*.go", "-rn", "TODO"},
+			},
+		},
+		{
+			name:    "combined short flags with value",
+			command: "tar -xzf=backup.tgz",
+			want: &Command{
+				Name:  "tar",
+				Flags: []Flag{{Name: "-x"}, {Name: "-z"}, {Name: "-f", Value: "backup.tgz", HasValue: true}},
+				Words: []string{"tar", "-xzf=backup.tgz"},
+			},
+		},
+		{
+			name:    "end of flags",
+			command: "rm -f -- -file -",
+			want: &Command{
+				Name:  "rm",
+				Flags: []Flag{{Name: "-f"}},
+				Args:  []string{"-file", "-"},
+				Words: []string{"rm", "-f", "--", "-file", "-"},
+			},
+		},
+		{
+			name:    "environment assignments",
+			command: "GOOS=linux CGO_ENABLED=0 go build -o bin/app",
+			want: &Command{
+				Env:        []string{"GOOS=linux", "CGO_ENABLED=0"},
+				Name:       "go",
+				Subcommand: "build",
+				Flags:      []Flag{{Name: "-o"}},
+				Args:       []string{"bin/app"},
+				Words:      []string{"go", "build", "-o", "bin/app"},
+			},
+		},
+		{
+			name:    "flag before subcommand",
+			command: "git --no-pager log",
+			want: &Command{
+				Name:  "git",
+				Flags: []Flag{{Name: "--no-pager"}},
+				Args:  []string{"log"},
+				Words: []string{"git", "--no-pager", "log"},
+			},
+		},
+		{
+			name:    "first command of a pipeline",
+			command: "ps aux | grep nginx",
+			want: &Command{
+				Name:  "ps",
+				Args:  []string{"aux"},
+				Words: []string{"ps", "aux"},
+			},
+		},
+		{
+			name:    "empty",
+			command: "   ",
+			want:    &Command{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.command); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) =\n%+v\nwant\n%+v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommand_FlagValue(t *testing.T) {
+	c := Parse(`aws ec2 run-instances --instance-type t3.large --count=2 -- --image-id x`)
+
+	if value, ok := c.FlagValue("--instance-type"); !ok || value != "t3.large" {
+		t.Errorf("FlagValue(--instance-type) = %q, %v", value, ok)
+	}
+	if value, ok := c.FlagValue("--count"); !ok || value != "2" {
+		t.Errorf("FlagValue(--count) = %q, %v", value, ok)
+	}
+	if _, ok := c.FlagValue("--image-id"); ok {
+		t.Error("FlagValue() found a flag after --")
+	}
+	if _, ok := c.FlagValue("--region"); ok {
+		t.Error("FlagValue() found a missing flag")
+	}
+
+	if !c.HasFlag("--count") || c.HasFlag("--region") {
+		t.Errorf("HasFlag() with flags %+v", c.Flags)
+	}
+}
+
+func TestCommand_Executable(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"git status", "git"},
+		{"/usr/bin/git log", "git"},
+		{"AWS_PROFILE=prod aws s3 ls", "aws"},
+		{"sudo systemctl restart nginx", "systemctl"},
+		{"sudo -u bob systemctl restart nginx", "systemctl"},
+		{"sudo -E -u bob -- /usr/sbin/service nginx reload", "service"},
+		{"env -u HOME FOO=1 kubectl get pods", "kubectl"},
+		{"nice -n 10 nohup tar -czf backup.tgz .", "tar"},
+		{"time -f %e docker build .", "docker"},
+		{"sudo -u bob", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Parse(tt.command).Executable(); got != tt.want {
+			t.Errorf("Parse(%q).Executable() = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestCommands(t *testing.T) {
+	commands := Commands(`make build && FOO=1 git push origin main | tee log; ;`)
+
+	var names []string
+	for _, c := range commands {
+		names = append(names, c.Name)
+	}
+	if want := []string{"make", "git", "tee"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Commands() names = %q, want %q", names, want)
+	}
+	if commands[1].Subcommand != "push" || !reflect.DeepEqual(commands[1].Env, []string{"FOO=1"}) {
+		t.Errorf("second command = %+v", commands[1])
+	}
+
+	if commands := Commands("  "); len(commands) != 0 {
+		t.Errorf("Commands() of a blank line = %+v, want none", commands)
+	}
+}
@@ -0,0 +1,397 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/SagheerAkram/QuickCmd/core/suggestions"
+	"gopkg.in/yaml.v3"
+)
+
+// Config represents the user configuration for the quickcmd CLI
+type Config struct {
+	// Policy file to load instead of the built-in default policy
+	PolicyFile string `yaml:"policy_file"`
+
+	// External decision point asked about commands the policy allows
+	PolicyWebhook PolicyWebhookConfig `yaml:"policy_webhook"`
+
+	// Audit database location
+	AuditDBPath string `yaml:"audit_db_path"`
+
+	// Approval database shared with the web UI
+	ApprovalDBPath string `yaml:"approval_db_path"`
+
+	// Phrases approvers must type, by risk level; "{id}" is the approval ID.
+	// Leave unset to use the built-in phrases.
+	ConfirmationPhrases map[string]string `yaml:"confirmation_phrases"`
+
+	// Rules for approving safe, cheap commands without human review
+	AutoApprovalRules []AutoApprovalRule `yaml:"auto_approval_rules"`
+
+	// Where requesters are told that their approval was granted or rejected
+	Notifications NotificationsConfig `yaml:"notifications"`
+
+	// Sandbox image for every run. Leave unset to pick an image from the
+	// command's executable, e.g. python:3-slim for python.
+	SandboxImage string `yaml:"sandbox_image"`
+
+	// Images to use instead of the built-in choice, by executable
+	SandboxImages map[string]string `yaml:"sandbox_images"`
+
+	// Named sandbox resource profiles, and the profile used for each risk
+	// level. Profiles listed in the file are added to the built-in
+	// "permissive" and "restrictive" profiles, replacing any of the same name.
+	SandboxProfiles map[string]SandboxProfile `yaml:"sandbox_profiles"`
+	RiskProfiles    map[string]string         `yaml:"risk_profiles"`
+
+	// Estimated cost in USD above which cloud commands need approval
+	CostThreshold float64 `yaml:"cost_threshold"`
+
+	// Translate with core templates only, skipping all plugins
+	NoPlugins bool `yaml:"no_plugins"`
+
+	// Directory of external plugin .so files loaded at startup. Needs a
+	// build with the dynamicplugins tag.
+	PluginDir string `yaml:"plugin_dir"`
+
+	// Icon theme: "emoji" (default) or "ascii" for terminals and logs
+	// without emoji support
+	Theme string `yaml:"theme"`
+
+	// Where undo backups of deleted and moved files are stored
+	Backup BackupConfig `yaml:"backup"`
+
+	// When to stop executing destructive commands that come in too fast
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
+
+	// Deployment targets selectable with --env, by name
+	Environments map[string]Environment `yaml:"environments"`
+
+	// Which suggestions are shown after candidates and by `quickcmd suggestions`
+	Suggestions suggestions.Config `yaml:"suggestions"`
+}
+
+// Environment holds the defaults injected into candidates run with --env,
+// e.g. pinning kubectl to a cluster. Empty fields leave the tool's own
+// default. Production environments escalate the risk of every candidate
+// that isn't read-only, so changes need typed confirmation.
+type Environment struct {
+	KubeContext string `yaml:"kube_context"`
+	Namespace   string `yaml:"namespace"`
+	AWSProfile  string `yaml:"aws_profile"`
+	Production  bool   `yaml:"production"`
+}
+
+// environmentValue matches the values an Environment may inject into
+// commands, keeping shell syntax out of them
+var environmentValue = regexp.MustCompile(`^[\w.:@/-]*$`)
+
+// PolicyWebhookConfig sets up an external policy decision point. Before a
+// command runs it is POSTed to URL, signed with Secret, and the webhook's
+// deny or require_approval answer applies on top of the local policy. An
+// empty URL disables the webhook.
+type PolicyWebhookConfig struct {
+	URL            string `yaml:"url"`
+	Secret         string `yaml:"secret"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
+}
+
+// NotificationsConfig sends approval outcomes to the requester through a
+// Slack incoming webhook. An empty SlackWebhookURL disables notifications.
+type NotificationsConfig struct {
+	SlackWebhookURL string `yaml:"slack_webhook_url"`
+	SlackBotToken   string `yaml:"slack_bot_token"`
+}
+
+// CircuitBreakerConfig trips the destructive command circuit breaker after
+// Threshold destructive executions within WindowSeconds. Further destructive
+// runs then need typed confirmation, and are refused with --yes, for
+// CooldownSeconds. A zero Threshold disables the breaker.
+type CircuitBreakerConfig struct {
+	Threshold       int `yaml:"threshold"`
+	WindowSeconds   int `yaml:"window_seconds"`
+	CooldownSeconds int `yaml:"cooldown_seconds"`
+}
+
+// BackupConfig selects the undo backup backend. The "local" backend keeps
+// backups in Dir; "s3" uploads them to Bucket under Prefix so they survive
+// the host, e.g. on ephemeral CI runners.
+type BackupConfig struct {
+	Backend string `yaml:"backend"`
+	Dir     string `yaml:"dir"`
+	Bucket  string `yaml:"bucket"`
+	Prefix  string `yaml:"prefix"`
+}
+
+// SandboxProfile is a set of sandbox resource limits. Zero CPUs, MemoryMB,
+// Pids or TimeoutSeconds keep the sandbox runner's default for that limit.
+// Without Network, commands that name hosts may only reach them through the
+// egress proxy if NetworkAllowlist is set.
+type SandboxProfile struct {
+	CPUs             float64 `yaml:"cpus"`
+	MemoryMB         int64   `yaml:"memory_mb"`
+	Pids             int64   `yaml:"pids"`
+	Network          bool    `yaml:"network"`
+	NetworkAllowlist bool    `yaml:"network_allowlist"`
+	ReadOnly         bool    `yaml:"read_only"`
+	TimeoutSeconds   int     `yaml:"timeout_seconds"`
+}
+
+// AutoApprovalRule auto-approves non-destructive commands at the listed risk
+// levels whose estimated cost is within MaxCost, or that have no estimate if
+// AllowUnestimated is set, and which match an allow pattern in full
+type AutoApprovalRule struct {
+	Name             string   `yaml:"name"`
+	RiskLevels       []string `yaml:"risk_levels"`
+	MaxCost          float64  `yaml:"max_cost"`
+	AllowUnestimated bool     `yaml:"allow_unestimated"`
+	AllowPatterns    []string `yaml:"allow_patterns"`
+}
+
+// DefaultConfig returns the configuration used when no config file exists
+func DefaultConfig() *Config {
+	return &Config{
+		AuditDBPath:    filepath.Join(quickcmdDir(), "audit.db"),
+		ApprovalDBPath: filepath.Join(quickcmdDir(), "approvals.db"),
+		SandboxProfiles: map[string]SandboxProfile{
+			"permissive": {
+				CPUs:             0.5,
+				MemoryMB:         256,
+				Pids:             64,
+				NetworkAllowlist: true,
+				TimeoutSeconds:   300,
+			},
+			"restrictive": {
+				CPUs:           0.25,
+				MemoryMB:       128,
+				Pids:           32,
+				TimeoutSeconds: 60,
+			},
+		},
+		RiskProfiles: map[string]string{
+			"safe":     "permissive",
+			"low":      "permissive",
+			"medium":   "permissive",
+			"high":     "restrictive",
+			"critical": "restrictive",
+		},
+		CostThreshold: 10.0,
+		Theme:         "emoji",
+		Backup: BackupConfig{
+			Backend: "local",
+			Dir:     filepath.Join(quickcmdDir(), "backups"),
+		},
+		CircuitBreaker: CircuitBreakerConfig{
+			Threshold:       5,
+			WindowSeconds:   60,
+			CooldownSeconds: 600,
+		},
+	}
+}
+
+// DefaultPath returns the default config file location
+func DefaultPath() string {
+	return filepath.Join(quickcmdDir(), "config.yaml")
+}
+
+// Load reads a config file. A leading ~ in the path, or in any path inside
+// the file, is expanded to the user's home directory. Fields missing from
+// the file keep their defaults.
+func Load(path string) (*Config, error) {
+	path, err := ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	config := DefaultConfig()
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	if config.PolicyFile, err = ExpandPath(config.PolicyFile); err != nil {
+		return nil, err
+	}
+	if config.AuditDBPath, err = ExpandPath(config.AuditDBPath); err != nil {
+		return nil, err
+	}
+	if config.ApprovalDBPath, err = ExpandPath(config.ApprovalDBPath); err != nil {
+		return nil, err
+	}
+	if config.Backup.Dir, err = ExpandPath(config.Backup.Dir); err != nil {
+		return nil, err
+	}
+	if config.PluginDir, err = ExpandPath(config.PluginDir); err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Validate validates the configuration
+func (c *Config) Validate() error {
+	if c.AuditDBPath == "" {
+		return fmt.Errorf("audit_db_path must not be empty")
+	}
+
+	if c.ApprovalDBPath == "" {
+		return fmt.Errorf("approval_db_path must not be empty")
+	}
+
+	if c.CostThreshold < 0 {
+		return fmt.Errorf("invalid cost_threshold: %.2f", c.CostThreshold)
+	}
+
+	for name, profile := range c.SandboxProfiles {
+		if profile.CPUs < 0 || profile.MemoryMB < 0 || profile.Pids < 0 || profile.TimeoutSeconds < 0 {
+			return fmt.Errorf("sandbox_profiles.%s: limits must not be negative", name)
+		}
+	}
+	for risk, name := range c.RiskProfiles {
+		if _, ok := c.SandboxProfiles[name]; !ok {
+			return fmt.Errorf("risk_profiles.%s: unknown sandbox profile %q", risk, name)
+		}
+	}
+
+	switch c.Theme {
+	case "emoji", "ascii":
+	default:
+		return fmt.Errorf("invalid theme: %q (use emoji or ascii)", c.Theme)
+	}
+
+	switch c.Backup.Backend {
+	case "local":
+		if c.Backup.Dir == "" {
+			return fmt.Errorf("backup.dir must not be empty for the local backend")
+		}
+	case "s3":
+		if c.Backup.Bucket == "" {
+			return fmt.Errorf("backup.bucket must not be empty for the s3 backend")
+		}
+	default:
+		return fmt.Errorf("invalid backup.backend: %q (use local or s3)", c.Backup.Backend)
+	}
+
+	if webhook := c.PolicyWebhook; webhook.URL != "" {
+		parsed, err := url.Parse(webhook.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("policy_webhook.url: invalid URL %q", webhook.URL)
+		}
+		if webhook.Secret == "" {
+			return fmt.Errorf("policy_webhook.secret must not be empty")
+		}
+	}
+	if c.PolicyWebhook.TimeoutSeconds < 0 {
+		return fmt.Errorf("policy_webhook.timeout_seconds must not be negative")
+	}
+
+	if webhook := c.Notifications.SlackWebhookURL; webhook != "" {
+		parsed, err := url.Parse(webhook)
+		if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return fmt.Errorf("notifications.slack_webhook_url: invalid URL %q", webhook)
+		}
+	}
+
+	if cb := c.CircuitBreaker; cb.Threshold < 0 || cb.WindowSeconds < 0 || cb.CooldownSeconds < 0 {
+		return fmt.Errorf("circuit_breaker: values must not be negative")
+	}
+	if cb := c.CircuitBreaker; cb.Threshold > 0 && (cb.WindowSeconds == 0 || cb.CooldownSeconds == 0) {
+		return fmt.Errorf("circuit_breaker: window_seconds and cooldown_seconds are required with a threshold")
+	}
+
+	for name, env := range c.Environments {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("environments: name must not be empty")
+		}
+		for field, value := range map[string]string{"kube_context": env.KubeContext, "namespace": env.Namespace, "aws_profile": env.AWSProfile} {
+			if !environmentValue.MatchString(value) {
+				return fmt.Errorf("environments.%s.%s: invalid value %q", name, field, value)
+			}
+		}
+	}
+
+	for i, rule := range c.AutoApprovalRules {
+		if rule.Name == "" {
+			return fmt.Errorf("auto_approval_rules[%d]: name must not be empty", i)
+		}
+		if len(rule.AllowPatterns) == 0 {
+			return fmt.Errorf("auto_approval_rules[%d]: allow_patterns must not be empty", i)
+		}
+		if rule.MaxCost < 0 {
+			return fmt.Errorf("auto_approval_rules[%d]: invalid max_cost: %.2f", i, rule.MaxCost)
+		}
+		for _, pattern := range rule.AllowPatterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("auto_approval_rules[%d]: invalid pattern %q: %w", i, pattern, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Environment returns the environment named name, or an error listing the
+// configured ones if there is none
+func (c *Config) Environment(name string) (Environment, error) {
+	if env, ok := c.Environments[name]; ok {
+		return env, nil
+	}
+
+	names := make([]string, 0, len(c.Environments))
+	for configured := range c.Environments {
+		names = append(names, configured)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return Environment{}, fmt.Errorf("unknown environment %q: no environments are configured", name)
+	}
+	return Environment{}, fmt.Errorf("unknown environment %q (configured: %s)", name, strings.Join(names, ", "))
+}
+
+// SandboxProfileFor returns the name and limits of the sandbox profile used
+// for a risk level, or false if the risk level has no profile
+func (c *Config) SandboxProfileFor(risk string) (string, SandboxProfile, bool) {
+	name, ok := c.RiskProfiles[strings.ToLower(risk)]
+	if !ok {
+		return "", SandboxProfile{}, false
+	}
+	profile, ok := c.SandboxProfiles[name]
+	return name, profile, ok
+}
+
+// ExpandPath replaces a leading ~ with the user's home directory
+func ExpandPath(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to expand %s: %w", path, err)
+	}
+
+	return filepath.Join(homeDir, path[1:]), nil
+}
+
+// quickcmdDir returns the directory holding quickcmd's user files
+func quickcmdDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "quickcmd")
+	}
+	return filepath.Join(homeDir, ".quickcmd")
+}
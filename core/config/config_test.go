@@ -0,0 +1,242 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	configPath := filepath.Join(homeDir, "config.yaml")
+	sample := `
+policy_file: "~/policies/team.yaml"
+audit_db_path: "/var/lib/quickcmd/audit.db"
+approval_db_path: "~/approvals.db"
+sandbox_image: "ubuntu:22.04"
+cost_threshold: 25.5
+theme: "ascii"
+plugin_dir: "~/.quickcmd/plugins"
+backup:
+  backend: "s3"
+  bucket: "ci-backups"
+  prefix: "undo"
+sandbox_profiles:
+  offline:
+    memory_mb: 64
+    timeout_seconds: 10
+risk_profiles:
+  high: "offline"
+`
+	if err := os.WriteFile(configPath, []byte(sample), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	config, err := Load("~/config.yaml")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	if want := filepath.Join(homeDir, "policies", "team.yaml"); config.PolicyFile != want {
+		t.Errorf("PolicyFile = %q, want %q", config.PolicyFile, want)
+	}
+	if config.AuditDBPath != "/var/lib/quickcmd/audit.db" {
+		t.Errorf("AuditDBPath = %q", config.AuditDBPath)
+	}
+	if want := filepath.Join(homeDir, "approvals.db"); config.ApprovalDBPath != want {
+		t.Errorf("ApprovalDBPath = %q, want %q", config.ApprovalDBPath, want)
+	}
+	if config.SandboxImage != "ubuntu:22.04" {
+		t.Errorf("SandboxImage = %q", config.SandboxImage)
+	}
+	if config.CostThreshold != 25.5 {
+		t.Errorf("CostThreshold = %v", config.CostThreshold)
+	}
+	if config.Theme != "ascii" {
+		t.Errorf("Theme = %q", config.Theme)
+	}
+	if want := filepath.Join(homeDir, ".quickcmd", "plugins"); config.PluginDir != want {
+		t.Errorf("PluginDir = %q, want %q", config.PluginDir, want)
+	}
+	if config.Backup.Backend != "s3" || config.Backup.Bucket != "ci-backups" || config.Backup.Prefix != "undo" {
+		t.Errorf("Backup = %+v", config.Backup)
+	}
+
+	// Listed profiles are added to the built-in ones
+	if name, profile, _ := config.SandboxProfileFor("HIGH"); name != "offline" || profile.MemoryMB != 64 || profile.TimeoutSeconds != 10 {
+		t.Errorf("SandboxProfileFor(high) = %q, %+v", name, profile)
+	}
+	if name, _, _ := config.SandboxProfileFor("critical"); name != "restrictive" {
+		t.Errorf("SandboxProfileFor(critical) = %q, want restrictive", name)
+	}
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	configPath := filepath.Join(homeDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("sandbox_image: \"python:3.11-slim\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	config, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	defaults := DefaultConfig()
+	if config.PolicyFile != "" {
+		t.Errorf("PolicyFile = %q, want empty", config.PolicyFile)
+	}
+	if config.AuditDBPath != defaults.AuditDBPath {
+		t.Errorf("AuditDBPath = %q, want %q", config.AuditDBPath, defaults.AuditDBPath)
+	}
+	if config.ApprovalDBPath != defaults.ApprovalDBPath {
+		t.Errorf("ApprovalDBPath = %q, want %q", config.ApprovalDBPath, defaults.ApprovalDBPath)
+	}
+	if config.CostThreshold != defaults.CostThreshold {
+		t.Errorf("CostThreshold = %v, want %v", config.CostThreshold, defaults.CostThreshold)
+	}
+	if config.Backup != defaults.Backup {
+		t.Errorf("Backup = %+v, want %+v", config.Backup, defaults.Backup)
+	}
+	if config.CircuitBreaker != defaults.CircuitBreaker {
+		t.Errorf("CircuitBreaker = %+v, want %+v", config.CircuitBreaker, defaults.CircuitBreaker)
+	}
+}
+
+func TestLoad_Example(t *testing.T) {
+	if _, err := Load(filepath.Join("..", "..", "examples", "config.yaml")); err != nil {
+		t.Errorf("Load() example config error: %v", err)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if _, err := Load(filepath.Join(tmpDir, "missing.yaml")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+
+	badPath := filepath.Join(tmpDir, "bad.yaml")
+	if err := os.WriteFile(badPath, []byte("cost_threshold: -1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := Load(badPath); err == nil {
+		t.Error("Expected error for negative cost threshold")
+	}
+
+	rulePath := filepath.Join(tmpDir, "rules.yaml")
+	rules := "auto_approval_rules:\n  - name: reads\n    allow_patterns: ['(']\n"
+	if err := os.WriteFile(rulePath, []byte(rules), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := Load(rulePath); err == nil {
+		t.Error("Expected error for invalid auto-approval pattern")
+	}
+
+	backupPath := filepath.Join(tmpDir, "backup.yaml")
+	if err := os.WriteFile(backupPath, []byte("backup:\n  backend: s3\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := Load(backupPath); err == nil {
+		t.Error("Expected error for s3 backup without a bucket")
+	}
+
+	profilePath := filepath.Join(tmpDir, "profiles.yaml")
+	if err := os.WriteFile(profilePath, []byte("risk_profiles:\n  high: locked-down\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := Load(profilePath); err == nil {
+		t.Error("Expected error for risk level mapped to an unknown profile")
+	}
+
+	breakerPath := filepath.Join(tmpDir, "breaker.yaml")
+	if err := os.WriteFile(breakerPath, []byte("circuit_breaker:\n  threshold: 3\n  window_seconds: 0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := Load(breakerPath); err == nil {
+		t.Error("Expected error for circuit breaker threshold without a window")
+	}
+
+	envPath := filepath.Join(tmpDir, "environments.yaml")
+	if err := os.WriteFile(envPath, []byte("environments:\n  prod:\n    kube_context: \"prod; rm -rf /\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := Load(envPath); err == nil {
+		t.Error("Expected error for shell syntax in an environment's kube context")
+	}
+
+	webhookPath := filepath.Join(tmpDir, "webhook.yaml")
+	if err := os.WriteFile(webhookPath, []byte("policy_webhook:\n  url: https://pdp.example.com/decide\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := Load(webhookPath); err == nil {
+		t.Error("Expected error for a policy webhook without a signing secret")
+	}
+
+	slackPath := filepath.Join(tmpDir, "slack.yaml")
+	if err := os.WriteFile(slackPath, []byte("notifications:\n  slack_webhook_url: http://hooks.example.com/x\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := Load(slackPath); err == nil {
+		t.Error("Expected error for a Slack webhook without https")
+	}
+}
+
+func TestConfig_Environment(t *testing.T) {
+	config := DefaultConfig()
+	if _, err := config.Environment("prod"); err == nil {
+		t.Error("Environment() expected error with no environments configured")
+	}
+
+	config.Environments = map[string]Environment{
+		"dev":  {KubeContext: "dev-cluster"},
+		"prod": {KubeContext: "arn:aws:eks:us-east-1:123456789012:cluster/prod", Production: true},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+
+	env, err := config.Environment("prod")
+	if err != nil || !env.Production {
+		t.Errorf("Environment(prod) = %+v, %v, want the production environment", env, err)
+	}
+
+	_, err = config.Environment("staging")
+	if err == nil || !strings.Contains(err.Error(), "dev, prod") {
+		t.Errorf("Environment(staging) error = %v, want it to list dev, prod", err)
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"~", homeDir},
+		{"~/.quickcmd/audit.db", filepath.Join(homeDir, ".quickcmd", "audit.db")},
+		{"/etc/quickcmd.yaml", "/etc/quickcmd.yaml"},
+		{"relative/path", "relative/path"},
+		{"~other/path", "~other/path"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		got, err := ExpandPath(tt.path)
+		if err != nil {
+			t.Errorf("ExpandPath(%q) error: %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ExpandPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package executor
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// BackupBackend stores undo backups. Put returns a URI identifying the
+// stored backup, which Get and Delete accept; UndoRecord.BackupLocation
+// holds it, so records don't depend on where backups live.
+type BackupBackend interface {
+	Put(key string, r io.Reader) (string, error)
+	Get(uri string) (io.ReadCloser, error)
+	Delete(uri string) error
+}
+
+// LocalBackend stores backups as files in a directory. Its URIs are
+// file:// URLs.
+type LocalBackend struct {
+	dir string
+}
+
+// NewLocalBackend creates a backend storing backups in dir
+func NewLocalBackend(dir string) *LocalBackend {
+	return &LocalBackend{dir: dir}
+}
+
+func (b *LocalBackend) Put(key string, r io.Reader) (string, error) {
+	if err := os.MkdirAll(b.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	filename := filepath.Join(b.dir, filepath.Base(key))
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup file: %w", err)
+	}
+
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		os.Remove(filename)
+		return "", fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(filename)
+		return "", fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return "", err
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String(), nil
+}
+
+func (b *LocalBackend) Get(uri string) (io.ReadCloser, error) {
+	filename, err := localPath(uri)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(filename)
+}
+
+func (b *LocalBackend) Delete(uri string) error {
+	filename, err := localPath(uri)
+	if err != nil {
+		return err
+	}
+	return os.Remove(filename)
+}
+
+// localPath returns the file a file:// URI points to. Plain paths, as
+// stored by records created before backends existed, are returned as is.
+func localPath(uri string) (string, error) {
+	if !strings.Contains(uri, "://") {
+		return uri, nil
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid backup location %q: %w", uri, err)
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("backup location %q is not a local file", uri)
+	}
+	return filepath.FromSlash(u.Path), nil
+}
+
+// S3Client is the subset of S3 operations S3Backend needs
+type S3Client interface {
+	PutObject(bucket, key string, body io.Reader) error
+	GetObject(bucket, key string) (io.ReadCloser, error)
+	DeleteObject(bucket, key string) error
+}
+
+// S3Backend stores backups in an S3 bucket under a key prefix. Its URIs are
+// s3://bucket/key URLs.
+type S3Backend struct {
+	bucket string
+	prefix string
+	client S3Client
+}
+
+// NewS3Backend creates a backend storing backups in bucket under prefix,
+// using the aws CLI and its usual credential chain
+func NewS3Backend(bucket, prefix string) *S3Backend {
+	return NewS3BackendWithClient(bucket, prefix, &awsCLIClient{aws: "aws"})
+}
+
+// NewS3BackendWithClient creates an S3 backend that uses client
+func NewS3BackendWithClient(bucket, prefix string, client S3Client) *S3Backend {
+	return &S3Backend{
+		bucket: bucket,
+		prefix: strings.Trim(prefix, "/"),
+		client: client,
+	}
+}
+
+func (b *S3Backend) Put(key string, r io.Reader) (string, error) {
+	objectKey := path.Join(b.prefix, path.Base(key))
+	if err := b.client.PutObject(b.bucket, objectKey, r); err != nil {
+		return "", fmt.Errorf("failed to upload backup to s3://%s/%s: %w", b.bucket, objectKey, err)
+	}
+	return s3URI(b.bucket, objectKey), nil
+}
+
+func (b *S3Backend) Get(uri string) (io.ReadCloser, error) {
+	bucket, key, err := parseS3URI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := b.client.GetObject(bucket, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download backup %s: %w", uri, err)
+	}
+	return body, nil
+}
+
+func (b *S3Backend) Delete(uri string) error {
+	bucket, key, err := parseS3URI(uri)
+	if err != nil {
+		return err
+	}
+
+	if err := b.client.DeleteObject(bucket, key); err != nil {
+		return fmt.Errorf("failed to delete backup %s: %w", uri, err)
+	}
+	return nil
+}
+
+func s3URI(bucket, key string) string {
+	return fmt.Sprintf("s3://%s/%s", bucket, key)
+}
+
+// parseS3URI splits an s3://bucket/key URI
+func parseS3URI(uri string) (bucket, key string, err error) {
+	rest, ok := strings.CutPrefix(uri, "s3://")
+	if !ok {
+		return "", "", fmt.Errorf("backup location %q is not an s3:// URI", uri)
+	}
+
+	bucket, key, _ = strings.Cut(rest, "/")
+	if bucket == "" || key == "" {
+		return "", "", fmt.Errorf("backup location %q needs a bucket and key", uri)
+	}
+	return bucket, key, nil
+}
+
+// awsCLIClient implements S3Client with `aws s3 cp` and `aws s3 rm`,
+// streaming through stdin and stdout
+type awsCLIClient struct {
+	aws string // aws executable
+}
+
+func (c *awsCLIClient) PutObject(bucket, key string, body io.Reader) error {
+	cmd := exec.Command(c.aws, "s3", "cp", "-", s3URI(bucket, key))
+	cmd.Stdin = body
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w\n%s", err, output)
+	}
+	return nil
+}
+
+func (c *awsCLIClient) GetObject(bucket, key string) (io.ReadCloser, error) {
+	cmd := exec.Command(c.aws, "s3", "cp", s3URI(bucket, key), "-")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &cmdReader{ReadCloser: stdout, cmd: cmd, stderr: &stderr}, nil
+}
+
+func (c *awsCLIClient) DeleteObject(bucket, key string) error {
+	cmd := exec.Command(c.aws, "s3", "rm", s3URI(bucket, key))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w\n%s", err, output)
+	}
+	return nil
+}
+
+// cmdReader streams a command's stdout and reports the command's failure
+// on Close
+type cmdReader struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	stderr *strings.Builder
+}
+
+func (r *cmdReader) Close() error {
+	r.ReadCloser.Close()
+	if err := r.cmd.Wait(); err != nil {
+		return fmt.Errorf("%w\n%s", err, r.stderr.String())
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// memoryS3 is an in-memory S3Client
+type memoryS3 struct {
+	objects map[string][]byte
+}
+
+func newMemoryS3() *memoryS3 {
+	return &memoryS3{objects: make(map[string][]byte)}
+}
+
+func (m *memoryS3) PutObject(bucket, key string, body io.Reader) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	m.objects[bucket+"/"+key] = data
+	return nil
+}
+
+func (m *memoryS3) GetObject(bucket, key string) (io.ReadCloser, error) {
+	data, ok := m.objects[bucket+"/"+key]
+	if !ok {
+		return nil, fmt.Errorf("NoSuchKey: %s", key)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *memoryS3) DeleteObject(bucket, key string) error {
+	delete(m.objects, bucket+"/"+key)
+	return nil
+}
+
+// testFileUndo backs up a file through backend, deletes it, undoes the
+// deletion and checks the content is restored. It returns the record.
+func testFileUndo(t *testing.T, backend BackupBackend) *UndoRecord {
+	t.Helper()
+
+	target := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(target, []byte("keep me"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ue := NewUndoEngineWithBackend(t.TempDir(), backend)
+	record, err := ue.CreateUndo("rm "+target, []string{target})
+	if err != nil {
+		t.Fatalf("CreateUndo() error = %v", err)
+	}
+	if !record.CanUndo || record.BackupSize == 0 {
+		t.Fatalf("record = %+v, want undoable record with a backup", record)
+	}
+
+	if err := os.Remove(target); err != nil {
+		t.Fatal(err)
+	}
+	if err := ue.Undo(record); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("file not restored: %v", err)
+	}
+	if string(data) != "keep me" {
+		t.Errorf("restored content = %q, want %q", data, "keep me")
+	}
+
+	return record
+}
+
+func TestFileUndo_LocalBackend(t *testing.T) {
+	backupDir := t.TempDir()
+	record := testFileUndo(t, NewLocalBackend(backupDir))
+
+	if !strings.HasPrefix(record.BackupLocation, "file://") {
+		t.Errorf("BackupLocation = %q, want a file:// URI", record.BackupLocation)
+	}
+	path, err := localPath(record.BackupLocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(path) != backupDir {
+		t.Errorf("backup stored at %s, want it in %s", path, backupDir)
+	}
+	if record.UndoCommand != "tar -xzf "+path+" -C /" {
+		t.Errorf("UndoCommand = %q", record.UndoCommand)
+	}
+
+	ue := NewUndoEngineWithBackend(backupDir, NewLocalBackend(backupDir))
+	if err := ue.DeleteBackup(record); err != nil {
+		t.Fatalf("DeleteBackup() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("backup still exists after DeleteBackup: %v", err)
+	}
+}
+
+func TestFileUndo_S3Backend(t *testing.T) {
+	s3 := newMemoryS3()
+	record := testFileUndo(t, NewS3BackendWithClient("ci-backups", "/undo/runner-1/", s3))
+
+	bucket, key, err := parseS3URI(record.BackupLocation)
+	if err != nil {
+		t.Fatalf("BackupLocation %q: %v", record.BackupLocation, err)
+	}
+	if bucket != "ci-backups" || !strings.HasPrefix(key, "undo/runner-1/backup-") {
+		t.Errorf("backup stored at bucket %q key %q", bucket, key)
+	}
+	if int64(len(s3.objects[bucket+"/"+key])) != record.BackupSize {
+		t.Errorf("BackupSize = %d, uploaded %d bytes", record.BackupSize, len(s3.objects[bucket+"/"+key]))
+	}
+	if !strings.HasPrefix(record.UndoCommand, "aws s3 cp "+record.BackupLocation) {
+		t.Errorf("UndoCommand = %q", record.UndoCommand)
+	}
+
+	backend := NewS3BackendWithClient("ci-backups", "undo/runner-1", s3)
+	if err := backend.Delete(record.BackupLocation); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if len(s3.objects) != 0 {
+		t.Errorf("objects after Delete = %d, want 0", len(s3.objects))
+	}
+	if _, err := backend.Get(record.BackupLocation); err == nil {
+		t.Error("Get() of deleted backup should fail")
+	}
+}
+
+func TestLocalBackend_PlainPath(t *testing.T) {
+	// Records created before backends existed store a plain path
+	backupFile := filepath.Join(t.TempDir(), "backup.tar.gz")
+	if err := os.WriteFile(backupFile, []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewLocalBackend(t.TempDir()).Get(backupFile)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer r.Close()
+
+	data, _ := io.ReadAll(r)
+	if string(data) != "data" {
+		t.Errorf("Get() = %q, want %q", data, "data")
+	}
+}
+
+func TestParseS3URI(t *testing.T) {
+	bucket, key, err := parseS3URI("s3://bucket/a/b.tar.gz")
+	if err != nil || bucket != "bucket" || key != "a/b.tar.gz" {
+		t.Errorf("parseS3URI() = %q, %q, %v", bucket, key, err)
+	}
+
+	for _, uri := range []string{"file:///tmp/x", "s3://bucket", "s3:///key", "bucket/key"} {
+		if _, _, err := parseS3URI(uri); err == nil {
+			t.Errorf("parseS3URI(%q) should fail", uri)
+		}
+	}
+}
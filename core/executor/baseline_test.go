@@ -0,0 +1,90 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiffOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		want     string
+	}{
+		{
+			name:     "match",
+			expected: "a\nb\n",
+			actual:   "a\nb\n",
+		},
+		{
+			name:     "missing final newline",
+			expected: "a\nb\n",
+			actual:   "a\nb",
+		},
+		{
+			name:     "changed line",
+			expected: "a\nb\nc\n",
+			actual:   "a\nB\nc\n",
+			want:     "--- expected\n+++ actual\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:     "added line",
+			expected: "",
+			actual:   "new\n",
+			want:     "--- expected\n+++ actual\n@@ -0,0 +1 @@\n+new\n",
+		},
+		{
+			name:     "moved line",
+			expected: "a\nb\nc\nd\n",
+			actual:   "b\nc\na\nd\n",
+			want:     "--- expected\n+++ actual\n@@ -1,4 +1,4 @@\n-a\n b\n c\n+a\n d\n",
+		},
+		{
+			name:     "separate hunks",
+			expected: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			actual:   "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			want: "--- expected\n+++ actual\n" +
+				"@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n" +
+				"@@ -9,4 +9,3 @@\n 9\n 10\n 11\n-12\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffOutput([]byte(tt.expected), []byte(tt.actual)); got != tt.want {
+				t.Errorf("DiffOutput() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffOutput_Large(t *testing.T) {
+	var expected, actual strings.Builder
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&expected, "expected %d\n", i)
+		fmt.Fprintf(&actual, "actual %d\n", i)
+	}
+
+	// Entirely different outputs are too far apart for a minimal diff, so
+	// every line is replaced
+	diff := DiffOutput([]byte(expected.String()), []byte(actual.String()))
+	if !strings.HasPrefix(diff, "--- expected\n+++ actual\n@@ -1,20000 +1,20000 @@\n-expected 0\n") {
+		t.Errorf("diff of different outputs starts %q", diff[:min(len(diff), 80)])
+	}
+	if removed := strings.Count(diff, "\n-expected "); removed != 20000 {
+		t.Errorf("diff removes %d lines, want 20000", removed)
+	}
+
+	// A change in the middle of long outputs still gets a small hunk
+	lines := strings.Split(expected.String(), "\n")
+	lines[10000] = "changed"
+	diff = DiffOutput([]byte(expected.String()), []byte(strings.Join(lines, "\n")))
+	want := "--- expected\n+++ actual\n@@ -9998,7 +9998,7 @@\n" +
+		" expected 9997\n expected 9998\n expected 9999\n-expected 10000\n+changed\n" +
+		" expected 10001\n expected 10002\n expected 10003\n"
+	if diff != want {
+		t.Errorf("DiffOutput() =\n%s\nwant\n%s", diff, want)
+	}
+}
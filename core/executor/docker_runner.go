@@ -1,11 +1,12 @@
 package executor
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"os"
-	"strings"
 	"time"
 	
 	"github.com/docker/docker/api/types"
@@ -16,15 +17,18 @@ import (
 
 // SandboxOptions configures sandbox execution
 type SandboxOptions struct {
-	WorkingDir    string        // Working directory inside container
-	Mounts        []Mount       // Volume mounts
-	NetworkAccess bool          // Enable network access
-	CPULimit      float64       // CPU limit (cores, e.g., 0.5)
-	MemoryLimit   int64         // Memory limit in bytes
-	PidsLimit     int64         // Max number of processes
-	Timeout       time.Duration // Execution timeout
-	Image         string        // Docker image to use
-	ReadOnly      bool          // Mount filesystem as read-only
+	WorkingDir       string            // Working directory inside container
+	Mounts           []Mount           // Volume mounts
+	NetworkAccess    bool              // Enable network access
+	NetworkAllowlist []string          // Hosts reachable via egress proxy; overrides NetworkAccess
+	CPULimit         float64           // CPU limit (cores, e.g., 0.5)
+	MemoryLimit      int64             // Memory limit in bytes
+	PidsLimit        int64             // Max number of processes
+	Timeout          time.Duration     // Execution timeout
+	Image            string            // Docker image to use (default: SelectImage)
+	Images           map[string]string // Image overrides by executable, see SelectImage
+	ReadOnly         bool              // Mount filesystem as read-only
+	Security         *SecurityProfile  // Privilege restrictions (default: DefaultSecurityProfile)
 }
 
 // Mount represents a volume mount
@@ -34,24 +38,45 @@ type Mount struct {
 	ReadOnly bool
 }
 
+// SecurityProfile restricts the privileges of a sandboxed command beyond
+// running it as a non-root user
+type SecurityProfile struct {
+	NoNewPrivileges  bool     // Stop setuid binaries and file capabilities from raising privileges
+	DropCapabilities []string // Linux capabilities to drop, "ALL" for every one
+	SeccompProfile   string   // Path to a seccomp profile (default: the runtime's own)
+}
+
+// DefaultSecurityProfile returns the locked-down profile sandboxes use
+// unless told otherwise: no capabilities and no privilege escalation
+func DefaultSecurityProfile() *SecurityProfile {
+	return &SecurityProfile{
+		NoNewPrivileges:  true,
+		DropCapabilities: []string{"ALL"},
+	}
+}
+
 // SandboxResult contains execution results
 type SandboxResult struct {
-	Stdout     []byte
-	Stderr     []byte
-	ExitCode   int
-	SandboxID  string
-	StartTime  time.Time
-	EndTime    time.Time
-	Error      error
+	Stdout         []byte
+	Stderr         []byte
+	ExitCode       int
+	SandboxID      string
+	StartTime      time.Time
+	EndTime        time.Time
+	MaxMemoryBytes int64 // Peak memory use, 0 if unknown
+	CPUTimeMs      int64 // CPU time used, 0 if unknown
+	Error          error
 }
 
 // DockerRunner executes commands in Docker containers
 type DockerRunner struct {
-	client *client.Client
+	client       *client.Client
+	pool         *ContainerPool     // optional, see WithPool
+	pullProgress func(PullProgress) // optional, see WithPullProgress
 }
 
 // NewDockerRunner creates a new Docker runner
-func NewDockerRunner() (*DockerRunner, error) {
+func NewDockerRunner(options ...DockerRunnerOption) (*DockerRunner, error) {
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Docker client: %w (is Docker installed and running?)", err)
@@ -65,33 +90,35 @@ func NewDockerRunner() (*DockerRunner, error) {
 		return nil, fmt.Errorf("Docker daemon not accessible: %w", err)
 	}
 	
-	return &DockerRunner{client: cli}, nil
+	dr := &DockerRunner{client: cli}
+	for _, option := range options {
+		option(dr)
+	}
+	
+	return dr, nil
 }
 
 // RunInSandbox executes a command in an isolated Docker container
 func (dr *DockerRunner) RunInSandbox(cmd string, opts SandboxOptions) (*SandboxResult, error) {
+	return dr.RunInSandboxStreaming(cmd, opts, nil, nil)
+}
+
+// RunInSandboxStreaming executes a command in an isolated Docker container,
+// copying its stdout and stderr to out and errw as they arrive. Either
+// writer may be nil. The result still holds the complete output.
+func (dr *DockerRunner) RunInSandboxStreaming(cmd string, opts SandboxOptions, out io.Writer, errw io.Writer) (*SandboxResult, error) {
 	result := &SandboxResult{
 		StartTime: time.Now(),
 	}
 	
-	// Set defaults
-	if opts.Image == "" {
-		opts.Image = "alpine:latest"
-	}
-	if opts.CPULimit == 0 {
-		opts.CPULimit = 0.5
-	}
-	if opts.MemoryLimit == 0 {
-		opts.MemoryLimit = 256 * 1024 * 1024 // 256MB
-	}
-	if opts.PidsLimit == 0 {
-		opts.PidsLimit = 64
-	}
-	if opts.Timeout == 0 {
-		opts.Timeout = 5 * time.Minute
-	}
-	if opts.WorkingDir == "" {
-		opts.WorkingDir = "/workspace"
+	applySandboxDefaults(&opts, cmd)
+	
+	// A warm container skips the create and start
+	if dr.pool != nil && dr.pool.accepts(opts) {
+		defer dr.pool.fill(opts)
+		if id := dr.pool.take(opts); id != "" {
+			return dr.runInPooledContainer(id, cmd, opts, out, errw)
+		}
 	}
 	
 	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
@@ -103,10 +130,118 @@ func (dr *DockerRunner) RunInSandbox(cmd string, opts SandboxOptions) (*SandboxR
 		return result, err
 	}
 	
-	// Create container config
+	containerConfig, hostConfig, err := sandboxConfig([]string{"/bin/sh", "-c", cmd}, opts)
+	if err != nil {
+		result.Error = err
+		return result, err
+	}
+	
+	// An allowlist restricts egress to the listed hosts through a proxy,
+	// whatever NetworkAccess says
+	if len(opts.NetworkAllowlist) > 0 {
+		egress, err := dr.createEgressNetwork(ctx, opts.NetworkAllowlist)
+		if err != nil {
+			result.Error = err
+			return result, err
+		}
+		defer egress.Close()
+		
+		hostConfig.NetworkMode = container.NetworkMode(egress.name)
+		containerConfig.Env = append(containerConfig.Env, egress.proxyEnv()...)
+	}
+	
+	// Create container
+	resp, err := dr.client.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, "")
+	if err != nil {
+		result.Error = fmt.Errorf("failed to create container: %w", err)
+		return result, result.Error
+	}
+	
+	result.SandboxID = resp.ID[:12] // Short ID for display
+	
+	// Attach before starting so no output is missed
+	attach, err := dr.client.ContainerAttach(ctx, resp.ID, types.ContainerAttachOptions{
+		Stream: true,
+		Stdout: true,
+		Stderr: true,
+	})
+	if err != nil {
+		result.Error = fmt.Errorf("failed to attach to container: %w", err)
+		return result, result.Error
+	}
+	defer attach.Close()
+	
+	// Separate stdout and stderr as the output arrives
+	var stdout, stderr bytes.Buffer
+	copyDone := make(chan error, 1)
+	go func() {
+		_, err := stdcopy.StdCopy(teeWriter(&stdout, out), teeWriter(&stderr, errw), attach.Reader)
+		copyDone <- err
+	}()
+	
+	// Start container
+	var runErr error
+	var usage <-chan resourceUsage
+	if err := dr.client.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		runErr = fmt.Errorf("failed to start container: %w", err)
+	} else {
+		usage = dr.collectResourceUsage(ctx, resp.ID)
+		
+		// Wait for container to finish
+		statusCh, errCh := dr.client.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
+		select {
+		case err := <-errCh:
+			if err != nil {
+				runErr = fmt.Errorf("container wait error: %w", err)
+			}
+		case status := <-statusCh:
+			result.ExitCode = int(status.StatusCode)
+		case <-ctx.Done():
+			// Timeout - kill container
+			dr.client.ContainerKill(context.Background(), resp.ID, "SIGKILL")
+			runErr = fmt.Errorf("execution timeout after %v", opts.Timeout)
+			result.ExitCode = 124 // Standard timeout exit code
+		}
+	}
+	
+	// The stream ends when the container exits; close it early otherwise
+	if runErr != nil {
+		attach.Close()
+	}
+	copyErr := <-copyDone
+	
+	if usage != nil {
+		select {
+		case u := <-usage:
+			result.MaxMemoryBytes = u.MaxMemoryBytes
+			result.CPUTimeMs = u.CPUTimeMs
+		case <-time.After(statsTimeout):
+			// Leave usage unknown rather than hold up the result
+		}
+	}
+	
+	result.Stdout = stdout.Bytes()
+	result.Stderr = stderr.Bytes()
+	result.EndTime = time.Now()
+	
+	if runErr != nil {
+		result.Error = runErr
+		return result, result.Error
+	}
+	if copyErr != nil {
+		result.Error = fmt.Errorf("failed to read container output: %w", copyErr)
+		return result, result.Error
+	}
+	
+	return result, nil
+}
+
+// sandboxConfig returns the container configuration for running cmd with
+// opts' image, limits, mounts and network isolation
+func sandboxConfig(cmd []string, opts SandboxOptions) (*container.Config, *container.HostConfig, error) {
 	containerConfig := &container.Config{
 		Image:      opts.Image,
-		Cmd:        []string{"/bin/sh", "-c", cmd},
+		Cmd:        cmd,
 		WorkingDir: opts.WorkingDir,
 		User:       "1000:1000", // Run as non-root
 		Tty:        false,
@@ -125,73 +260,144 @@ func (dr *DockerRunner) RunInSandbox(cmd string, opts SandboxOptions) (*SandboxR
 		ReadonlyRootfs: opts.ReadOnly,
 	}
 	
-	// Network isolation
-	if !opts.NetworkAccess {
-		hostConfig.NetworkMode = "none"
-	}
-	
-	// Add mounts
+	// Add mounts, never exposing the Docker socket
 	for _, mount := range opts.Mounts {
+		if _, err := CheckMount(mount, nil); err != nil {
+			return nil, nil, err
+		}
 		hostConfig.Binds = append(hostConfig.Binds, 
 			fmt.Sprintf("%s:%s:%s", mount.Source, mount.Target, mountMode(mount.ReadOnly)))
 	}
 	
-	// Create container
-	resp, err := dr.client.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, "")
-	if err != nil {
-		result.Error = fmt.Errorf("failed to create container: %w", err)
-		return result, result.Error
+	// Network isolation
+	if !opts.NetworkAccess {
+		hostConfig.NetworkMode = "none"
 	}
 	
-	result.SandboxID = resp.ID[:12] // Short ID for display
-	
-	// Start container
-	if err := dr.client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
-		result.Error = fmt.Errorf("failed to start container: %w", err)
-		return result, result.Error
+	// Privilege restrictions
+	if security := opts.Security; security != nil {
+		hostConfig.CapDrop = security.DropCapabilities
+		if security.NoNewPrivileges {
+			hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "no-new-privileges:true")
+		}
+		if security.SeccompProfile != "" {
+			// The API takes the profile itself rather than a path
+			profile, err := os.ReadFile(security.SeccompProfile)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read seccomp profile: %w", err)
+			}
+			hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "seccomp="+string(profile))
+		}
 	}
 	
-	// Wait for container to finish
-	statusCh, errCh := dr.client.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
-	select {
-	case err := <-errCh:
+	return containerConfig, hostConfig, nil
+}
+
+// collectResourceUsage streams the container's stats until it exits. Usage
+// is zero if stats are unavailable, e.g. when the container exits before
+// the first sample.
+func (dr *DockerRunner) collectResourceUsage(ctx context.Context, id string) <-chan resourceUsage {
+	usage := make(chan resourceUsage, 1)
+	go func() {
+		stats, err := dr.client.ContainerStats(ctx, id, true)
 		if err != nil {
-			result.Error = fmt.Errorf("container wait error: %w", err)
-			return result, result.Error
+			usage <- resourceUsage{}
+			return
 		}
-	case status := <-statusCh:
-		result.ExitCode = int(status.StatusCode)
-	case <-ctx.Done():
-		// Timeout - kill container
-		dr.client.ContainerKill(context.Background(), resp.ID, "SIGKILL")
-		result.Error = fmt.Errorf("execution timeout after %v", opts.Timeout)
-		result.ExitCode = 124 // Standard timeout exit code
-		return result, result.Error
+		defer stats.Body.Close()
+		
+		// The stream ends when the container is removed
+		usage <- readResourceUsage(stats.Body)
+	}()
+	return usage
+}
+
+// egressNetwork is an internal Docker network, with no route out, whose
+// containers reach allowed hosts through an egress proxy on the host
+type egressNetwork struct {
+	client *client.Client
+	id     string
+	name   string
+	proxy  *EgressProxy
+}
+
+// createEgressNetwork creates an internal network and starts an egress
+// proxy for the allowlist on the network's gateway address
+func (dr *DockerRunner) createEgressNetwork(ctx context.Context, entries []string) (*egressNetwork, error) {
+	allowlist, err := ParseNetworkAllowlist(entries)
+	if err != nil {
+		return nil, err
+	}
+	
+	name, err := containerName()
+	if err != nil {
+		return nil, err
 	}
+	name += "-egress"
 	
-	// Get logs
-	logReader, err := dr.client.ContainerLogs(ctx, resp.ID, container.LogsOptions{
-		ShowStdout: true,
-		ShowStderr: true,
+	resp, err := dr.client.NetworkCreate(ctx, name, types.NetworkCreate{
+		Driver:   "bridge",
+		Internal: true,
+		Labels:   map[string]string{"quickcmd.egress": "true"},
 	})
 	if err != nil {
-		result.Error = fmt.Errorf("failed to get container logs: %w", err)
-		return result, result.Error
+		return nil, fmt.Errorf("failed to create egress network: %w", err)
 	}
-	defer logReader.Close()
+	egress := &egressNetwork{client: dr.client, id: resp.ID, name: name}
 	
-	// Separate stdout and stderr
-	var stdout, stderr strings.Builder
-	if _, err := stdcopy.StdCopy(&stdout, &stderr, logReader); err != nil {
-		result.Error = fmt.Errorf("failed to read container logs: %w", err)
-		return result, result.Error
+	info, err := dr.client.NetworkInspect(ctx, resp.ID, types.NetworkInspectOptions{})
+	if err != nil {
+		egress.Close()
+		return nil, fmt.Errorf("failed to inspect egress network: %w", err)
 	}
 	
-	result.Stdout = []byte(stdout.String())
-	result.Stderr = []byte(stderr.String())
-	result.EndTime = time.Now()
+	var gateway string
+	for _, ipam := range info.IPAM.Config {
+		if ipam.Gateway != "" {
+			gateway = ipam.Gateway
+			break
+		}
+	}
+	if gateway == "" {
+		egress.Close()
+		return nil, fmt.Errorf("egress network %s has no gateway address", name)
+	}
 	
-	return result, nil
+	// Listening on the gateway keeps the proxy off other interfaces
+	if egress.proxy, err = StartEgressProxy(net.JoinHostPort(gateway, "0"), allowlist); err != nil {
+		egress.Close()
+		return nil, fmt.Errorf("%w (is the Docker daemon running on this host?)", err)
+	}
+	
+	return egress, nil
+}
+
+// proxyEnv returns the environment that points HTTP clients at the proxy
+func (n *egressNetwork) proxyEnv() []string {
+	proxyURL := "http://" + n.proxy.Addr()
+	return []string{
+		"HTTP_PROXY=" + proxyURL,
+		"HTTPS_PROXY=" + proxyURL,
+		"http_proxy=" + proxyURL,
+		"https_proxy=" + proxyURL,
+	}
+}
+
+// Close stops the proxy and removes the network
+func (n *egressNetwork) Close() error {
+	if n.proxy != nil {
+		n.proxy.Close()
+	}
+	
+	// The auto-removed container may still be detaching from the network
+	var err error
+	for attempt := 0; attempt < 10; attempt++ {
+		if err = n.client.NetworkRemove(context.Background(), n.id); err == nil {
+			return nil
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return fmt.Errorf("failed to remove egress network %s: %w", n.name, err)
 }
 
 // ensureImage pulls the image if it doesn't exist
@@ -210,7 +416,7 @@ func (dr *DockerRunner) ensureImage(ctx context.Context, image string) error {
 	defer reader.Close()
 	
 	// Wait for pull to complete
-	if _, err := io.Copy(io.Discard, reader); err != nil {
+	if err := readPullProgress(reader, image, dr.pullProgress); err != nil {
 		return fmt.Errorf("failed to download image %s: %w", image, err)
 	}
 	
@@ -225,8 +431,11 @@ func mountMode(readOnly bool) string {
 	return "rw"
 }
 
-// Close closes the Docker client
+// Close removes pooled containers and closes the Docker client
 func (dr *DockerRunner) Close() error {
+	if dr.pool != nil {
+		dr.pool.Close()
+	}
 	if dr.client != nil {
 		return dr.client.Close()
 	}
@@ -1,10 +1,14 @@
 package executor
 
 import (
-	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -170,6 +174,198 @@ func TestDockerRunner_WithMounts(t *testing.T) {
 	}
 }
 
+// timedWriter records when each write arrives
+type timedWriter struct {
+	mu     sync.Mutex
+	chunks []string
+	times  []time.Time
+}
+
+func (w *timedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.chunks = append(w.chunks, string(p))
+	w.times = append(w.times, time.Now())
+	return len(p), nil
+}
+
+// checkStreaming runs a command that prints over time and checks its first
+// line is written well before the command finishes
+func checkStreaming(t *testing.T, runner SandboxRunner) {
+	t.Helper()
+
+	var out, errw timedWriter
+	result, err := runner.RunInSandboxStreaming("echo first; sleep 2; echo second; echo oops >&2", SandboxOptions{
+		Image: "alpine:latest",
+	}, &out, &errw)
+	if err != nil {
+		t.Fatalf("RunInSandboxStreaming() error: %v", err)
+	}
+	finished := time.Now()
+
+	if len(out.chunks) == 0 || !strings.HasPrefix(out.chunks[0], "first") {
+		t.Fatalf("streamed stdout = %q, want it to start with the first line", out.chunks)
+	}
+	if early := finished.Sub(out.times[0]); early < time.Second {
+		t.Errorf("first line arrived %v before the command finished, want it streamed live", early)
+	}
+	if got := strings.Join(out.chunks, ""); got != "first\nsecond\n" {
+		t.Errorf("streamed stdout = %q", got)
+	}
+	if got := strings.Join(errw.chunks, ""); got != "oops\n" {
+		t.Errorf("streamed stderr = %q", got)
+	}
+
+	// The result still holds all the output
+	if string(result.Stdout) != "first\nsecond\n" || string(result.Stderr) != "oops\n" || result.ExitCode != 0 {
+		t.Errorf("result = stdout %q, stderr %q, exit code %d", result.Stdout, result.Stderr, result.ExitCode)
+	}
+}
+
+func TestDockerRunner_RunInSandboxStreaming(t *testing.T) {
+	if !IsDockerAvailable() {
+		t.Skip("Docker not available, skipping integration tests")
+	}
+
+	runner, err := NewDockerRunner()
+	if err != nil {
+		t.Fatalf("Failed to create Docker runner: %v", err)
+	}
+	defer runner.Close()
+
+	checkStreaming(t, runner)
+}
+
+func TestDockerRunner_NetworkAllowlist(t *testing.T) {
+	if !IsDockerAvailable() {
+		t.Skip("Docker not available, skipping integration tests")
+	}
+
+	runner, err := NewDockerRunner()
+	if err != nil {
+		t.Fatalf("Failed to create Docker runner: %v", err)
+	}
+	defer runner.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "reached upstream")
+	}))
+	defer server.Close()
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+
+	opts := SandboxOptions{
+		Image:            "alpine:latest",
+		NetworkAllowlist: []string{"localhost"},
+		Timeout:          time.Minute,
+	}
+
+	// The proxy resolves localhost on the host, where the server listens
+	result, err := runner.RunInSandbox(fmt.Sprintf("wget -q -O- http://localhost:%d/", port), opts)
+	if err != nil {
+		t.Fatalf("RunInSandbox() error: %v", err)
+	}
+	if result.ExitCode != 0 || !strings.Contains(string(result.Stdout), "reached upstream") {
+		t.Errorf("allowed host: exit code = %d, stdout = %q, stderr = %q", result.ExitCode, result.Stdout, result.Stderr)
+	}
+
+	result, err = runner.RunInSandbox(fmt.Sprintf("wget -q -O- http://127.0.0.1:%d/", port), opts)
+	if err != nil {
+		t.Fatalf("RunInSandbox() error: %v", err)
+	}
+	if result.ExitCode == 0 || strings.Contains(string(result.Stdout), "reached upstream") {
+		t.Errorf("disallowed host: exit code = %d, stdout = %q; want it blocked", result.ExitCode, result.Stdout)
+	}
+}
+
+func TestDockerRunner_ResourceUsage(t *testing.T) {
+	if !IsDockerAvailable() {
+		t.Skip("Docker not available, skipping integration tests")
+	}
+
+	runner, err := NewDockerRunner()
+	if err != nil {
+		t.Fatalf("Failed to create Docker runner: %v", err)
+	}
+	defer runner.Close()
+
+	// Hold about 20 MB in a shell variable long enough to be sampled
+	result, err := runner.RunInSandbox(`x=$(head -c 20000000 /dev/zero | tr '\0' a); sleep 2; echo ${#x}`, SandboxOptions{
+		Image:   "alpine:latest",
+		Timeout: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("RunInSandbox() error: %v", err)
+	}
+
+	if result.MaxMemoryBytes == 0 {
+		t.Error("RunInSandbox() reported no memory use")
+	}
+	t.Logf("Resource usage: %s", result.ResourceUsage())
+}
+
+func TestSandboxConfig_Security(t *testing.T) {
+	opts := SandboxOptions{Image: "alpine:latest"}
+	applySandboxDefaults(&opts, "true")
+
+	_, hostConfig, err := sandboxConfig([]string{"true"}, opts)
+	if err != nil {
+		t.Fatalf("sandboxConfig() error: %v", err)
+	}
+	if strings.Join(hostConfig.CapDrop, ",") != "ALL" || strings.Join(hostConfig.SecurityOpt, ",") != "no-new-privileges:true" {
+		t.Errorf("default profile: CapDrop = %v, SecurityOpt = %v", hostConfig.CapDrop, hostConfig.SecurityOpt)
+	}
+
+	profile := filepath.Join(t.TempDir(), "seccomp.json")
+	if err := os.WriteFile(profile, []byte(`{"defaultAction":"SCMP_ACT_ALLOW"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts.Security = &SecurityProfile{SeccompProfile: profile}
+	_, hostConfig, err = sandboxConfig([]string{"true"}, opts)
+	if err != nil {
+		t.Fatalf("sandboxConfig() error: %v", err)
+	}
+	if len(hostConfig.CapDrop) != 0 || strings.Join(hostConfig.SecurityOpt, ",") != `seccomp={"defaultAction":"SCMP_ACT_ALLOW"}` {
+		t.Errorf("seccomp profile: CapDrop = %v, SecurityOpt = %v", hostConfig.CapDrop, hostConfig.SecurityOpt)
+	}
+
+	opts.Security.SeccompProfile = filepath.Join(t.TempDir(), "missing.json")
+	if _, _, err := sandboxConfig([]string{"true"}, opts); err == nil {
+		t.Error("sandboxConfig() should fail for a missing seccomp profile")
+	}
+}
+
+func TestDockerRunner_SecurityProfile(t *testing.T) {
+	if !IsDockerAvailable() {
+		t.Skip("Docker not available, skipping integration tests")
+	}
+
+	runner, err := NewDockerRunner()
+	if err != nil {
+		t.Fatalf("Failed to create Docker runner: %v", err)
+	}
+	defer runner.Close()
+
+	opts := SandboxOptions{Image: "alpine:latest", Timeout: time.Minute}
+
+	// Mounting needs CAP_SYS_ADMIN, which the default profile drops
+	result, err := runner.RunInSandbox("mount -t tmpfs none /tmp", opts)
+	if err != nil {
+		t.Fatalf("RunInSandbox() error: %v", err)
+	}
+	if result.ExitCode == 0 {
+		t.Error("mount succeeded in the sandbox")
+	}
+
+	result, err = runner.RunInSandbox("grep -E '^(CapBnd|NoNewPrivs):' /proc/self/status", opts)
+	if err != nil {
+		t.Fatalf("RunInSandbox() error: %v", err)
+	}
+	status := string(result.Stdout)
+	if !strings.Contains(status, "CapBnd:\t0000000000000000") || !strings.Contains(status, "NoNewPrivs:\t1") {
+		t.Errorf("sandbox process status = %q, want no capabilities and no new privileges", status)
+	}
+}
+
 func TestIsDockerAvailable(t *testing.T) {
 	available := IsDockerAvailable()
 	
@@ -0,0 +1,309 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// egressDialTimeout bounds how long the egress proxy waits to connect to an
+// allowed host
+const egressDialTimeout = 10 * time.Second
+
+// hopHeaders are connection-specific headers a proxy must not forward
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Keep-Alive",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// NetworkAllowlist is the set of hosts a sandboxed command may reach.
+// Entries are hostnames ("sts.amazonaws.com"), wildcards matching any
+// subdomain ("*.amazonaws.com"), IP addresses or CIDRs ("10.0.0.0/8").
+type NetworkAllowlist struct {
+	hosts    map[string]bool
+	suffixes []string
+	networks []*net.IPNet
+}
+
+// ParseNetworkAllowlist parses allowlist entries
+func ParseNetworkAllowlist(entries []string) (*NetworkAllowlist, error) {
+	allowlist := &NetworkAllowlist{hosts: make(map[string]bool)}
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case strings.Contains(entry, "/"):
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid network allowlist entry %q: %w", entry, err)
+			}
+			allowlist.networks = append(allowlist.networks, network)
+		case net.ParseIP(entry) != nil:
+			ip := net.ParseIP(entry)
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			allowlist.networks = append(allowlist.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		case strings.HasPrefix(entry, "*."):
+			allowlist.suffixes = append(allowlist.suffixes, entry[1:])
+		case strings.ContainsAny(entry, "*:"):
+			return nil, fmt.Errorf("invalid network allowlist entry %q (use host, *.domain, IP or CIDR)", entry)
+		default:
+			allowlist.hosts[entry] = true
+		}
+	}
+	return allowlist, nil
+}
+
+// allowsName reports whether host is allowed by name
+func (a *NetworkAllowlist) allowsName(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if a.hosts[host] {
+		return true
+	}
+	for _, suffix := range a.suffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsIP reports whether ip is in an allowed network
+func (a *NetworkAllowlist) allowsIP(ip net.IP) bool {
+	for _, network := range a.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// dialAddress returns the address to connect to for host and port, or an
+// error if the allowlist doesn't allow host. Hosts allowed by IP range are
+// dialed by the matching IP, so DNS can't redirect them elsewhere.
+func (a *NetworkAllowlist) dialAddress(ctx context.Context, host, port string) (string, error) {
+	if a.allowsName(host) {
+		return net.JoinHostPort(host, port), nil
+	}
+
+	if len(a.networks) > 0 {
+		ips := []net.IP{net.ParseIP(host)}
+		if ips[0] == nil {
+			addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+			if err != nil {
+				return "", fmt.Errorf("failed to resolve %s: %w", host, err)
+			}
+			ips = ips[:0]
+			for _, addr := range addrs {
+				ips = append(ips, addr.IP)
+			}
+		}
+		for _, ip := range ips {
+			if a.allowsIP(ip) {
+				return net.JoinHostPort(ip.String(), port), nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("host %s is not in the network allowlist", host)
+}
+
+// EgressProxy is an HTTP proxy through which sandboxed commands reach the
+// hosts in a NetworkAllowlist. It forwards plain HTTP requests and tunnels
+// HTTPS with CONNECT; requests for any other host get 403 Forbidden.
+type EgressProxy struct {
+	allowlist *NetworkAllowlist
+	listener  net.Listener
+	server    *http.Server
+	transport *http.Transport
+
+	mu      sync.Mutex
+	tunnels map[net.Conn]bool // open tunnel connections
+	wg      sync.WaitGroup
+}
+
+// StartEgressProxy starts an egress proxy listening on addr
+func StartEgressProxy(addr string, allowlist *NetworkAllowlist) (*EgressProxy, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start egress proxy: %w", err)
+	}
+
+	p := &EgressProxy{allowlist: allowlist, listener: listener, tunnels: make(map[net.Conn]bool)}
+	p.transport = &http.Transport{
+		DialContext: p.dial,
+		Proxy:       nil, // never chain to the host's proxy
+	}
+	p.server = &http.Server{Handler: p, ReadHeaderTimeout: egressDialTimeout}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.server.Serve(listener)
+	}()
+
+	return p, nil
+}
+
+// Addr returns the proxy's listening address
+func (p *EgressProxy) Addr() string {
+	return p.listener.Addr().String()
+}
+
+// Close stops the proxy and closes open tunnels
+func (p *EgressProxy) Close() error {
+	err := p.server.Close()
+	p.transport.CloseIdleConnections()
+
+	// Hijacked tunnel connections aren't closed by the server
+	p.mu.Lock()
+	for conn := range p.tunnels {
+		conn.Close()
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	return err
+}
+
+// dial connects to an allowed host
+func (p *EgressProxy) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := p.allowlist.dialAddress(ctx, host, port)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: egressDialTimeout}
+	return dialer.DialContext(ctx, network, target)
+}
+
+// ServeHTTP handles a proxied request
+func (p *EgressProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+
+	if !r.URL.IsAbs() {
+		http.Error(w, "this is a proxy; send absolute URLs", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := p.allowlist.dialAddress(r.Context(), r.URL.Hostname(), "80"); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	for _, h := range hopHeaders {
+		out.Header.Del(h)
+	}
+
+	resp, err := p.transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	for _, h := range hopHeaders {
+		resp.Header.Del(h)
+	}
+	for key, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// tunnel handles a CONNECT request by splicing the client connection to
+// the allowed host
+func (p *EgressProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	host, port, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		http.Error(w, "CONNECT needs host:port", http.StatusBadRequest)
+		return
+	}
+	if _, err := p.allowlist.dialAddress(r.Context(), host, port); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	upstream, err := p.dial(r.Context(), "tcp", r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "tunneling not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buf, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		client.Close()
+		upstream.Close()
+		return
+	}
+
+	// Bytes the client sent after the CONNECT request
+	if buf.Reader.Buffered() > 0 {
+		if _, err := io.CopyN(upstream, buf, int64(buf.Reader.Buffered())); err != nil {
+			client.Close()
+			upstream.Close()
+			return
+		}
+	}
+
+	p.mu.Lock()
+	p.tunnels[client] = true
+	p.tunnels[upstream] = true
+	p.mu.Unlock()
+
+	p.wg.Add(2)
+	go p.splice(upstream, client)
+	go p.splice(client, upstream)
+}
+
+// splice copies src to dst, closing both when either side is done
+func (p *EgressProxy) splice(dst, src net.Conn) {
+	defer p.wg.Done()
+	io.Copy(dst, src)
+	dst.Close()
+	src.Close()
+
+	p.mu.Lock()
+	delete(p.tunnels, dst)
+	delete(p.tunnels, src)
+	p.mu.Unlock()
+}
@@ -0,0 +1,136 @@
+package executor
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseNetworkAllowlist(t *testing.T) {
+	allowlist, err := ParseNetworkAllowlist([]string{"sts.amazonaws.com", "*.eks.amazonaws.com", "10.0.0.0/8", "192.168.1.5", " "})
+	if err != nil {
+		t.Fatalf("ParseNetworkAllowlist() error: %v", err)
+	}
+
+	names := map[string]bool{
+		"sts.amazonaws.com":          true,
+		"STS.amazonaws.com.":         true,
+		"abc.gr7.eks.amazonaws.com":  true,
+		"eks.amazonaws.com":          false,
+		"s3.amazonaws.com":           false,
+		"sts.amazonaws.com.evil.com": false,
+	}
+	for host, want := range names {
+		if got := allowlist.allowsName(host); got != want {
+			t.Errorf("allowsName(%q) = %v, want %v", host, got, want)
+		}
+	}
+
+	ips := map[string]bool{
+		"10.1.2.3":    true,
+		"192.168.1.5": true,
+		"192.168.1.6": false,
+		"8.8.8.8":     false,
+	}
+	for ip, want := range ips {
+		if got := allowlist.allowsIP(net.ParseIP(ip)); got != want {
+			t.Errorf("allowsIP(%s) = %v, want %v", ip, got, want)
+		}
+	}
+
+	for _, bad := range []string{"10.0.0.0/33", "api.*.com", "host:443"} {
+		if _, err := ParseNetworkAllowlist([]string{bad}); err == nil {
+			t.Errorf("ParseNetworkAllowlist(%q) should fail", bad)
+		}
+	}
+}
+
+// proxyClient returns an HTTP client that sends everything through proxy
+func proxyClient(t *testing.T, proxy *EgressProxy) *http.Client {
+	t.Helper()
+	proxyURL, err := url.Parse("http://" + proxy.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+}
+
+func TestEgressProxy(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello from "+r.Host)
+	})
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	secure := httptest.NewTLSServer(handler)
+	defer secure.Close()
+
+	// Both servers listen on 127.0.0.1; only the name localhost is allowed
+	allowlist, err := ParseNetworkAllowlist([]string{"localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy, err := StartEgressProxy("127.0.0.1:0", allowlist)
+	if err != nil {
+		t.Fatalf("StartEgressProxy() error: %v", err)
+	}
+	defer proxy.Close()
+	client := proxyClient(t, proxy)
+
+	for _, server := range []*httptest.Server{plain, secure} {
+		allowed := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+		resp, err := client.Get(allowed)
+		if err != nil {
+			t.Fatalf("GET %s through proxy: %v", allowed, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.HasPrefix(string(body), "hello from localhost") {
+			t.Errorf("GET %s = %d %q, want the allowed host to connect", allowed, resp.StatusCode, body)
+		}
+
+		// The same server by a name that isn't allowed is blocked
+		resp, err = client.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusForbidden {
+				t.Errorf("GET %s = %d, want 403 for a disallowed host", server.URL, resp.StatusCode)
+			}
+		} else if !strings.Contains(err.Error(), "Forbidden") {
+			t.Errorf("GET %s error = %v, want Forbidden", server.URL, err)
+		}
+	}
+}
+
+func TestEgressProxy_CIDR(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	allowlist, err := ParseNetworkAllowlist([]string{"127.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy, err := StartEgressProxy("127.0.0.1:0", allowlist)
+	if err != nil {
+		t.Fatalf("StartEgressProxy() error: %v", err)
+	}
+	defer proxy.Close()
+
+	resp, err := proxyClient(t, proxy).Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET through proxy: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET %s = %d, want an address in an allowed range to connect", server.URL, resp.StatusCode)
+	}
+}
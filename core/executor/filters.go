@@ -0,0 +1,169 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OutputFilter transforms a command's captured stdout before it is shown and
+// audited
+type OutputFilter interface {
+	Name() string
+	Description() string
+	Apply(output []byte) ([]byte, error)
+}
+
+// outputFilters holds the registered filters by name
+var outputFilters = map[string]OutputFilter{}
+
+func init() {
+	RegisterOutputFilter(extractPathsFilter{})
+	RegisterOutputFilter(lineCountFilter{})
+	RegisterOutputFilter(jsonPrettyFilter{})
+}
+
+// RegisterOutputFilter makes a filter available by name, replacing any
+// filter registered under the same name
+func RegisterOutputFilter(filter OutputFilter) {
+	outputFilters[filter.Name()] = filter
+}
+
+// OutputFilters returns the registered filters sorted by name
+func OutputFilters() []OutputFilter {
+	filters := make([]OutputFilter, 0, len(outputFilters))
+	for _, filter := range outputFilters {
+		filters = append(filters, filter)
+	}
+	sort.Slice(filters, func(i, j int) bool { return filters[i].Name() < filters[j].Name() })
+	return filters
+}
+
+// LookupOutputFilters returns the filters with the given names, in order
+func LookupOutputFilters(names []string) ([]OutputFilter, error) {
+	filters := make([]OutputFilter, 0, len(names))
+	for _, name := range names {
+		filter, ok := outputFilters[name]
+		if !ok {
+			available := make([]string, 0, len(outputFilters))
+			for _, f := range OutputFilters() {
+				available = append(available, f.Name())
+			}
+			return nil, fmt.Errorf("unknown output filter %q (available: %s)", name, strings.Join(available, ", "))
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// ApplyOutputFilters runs output through filters in order, each filter
+// receiving the previous one's output
+func ApplyOutputFilters(filters []OutputFilter, output []byte) ([]byte, error) {
+	for _, filter := range filters {
+		filtered, err := filter.Apply(output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply output filter %s: %w", filter.Name(), err)
+		}
+		output = filtered
+	}
+	return output, nil
+}
+
+// extractPathsFilter reduces output to the file paths it mentions, e.g.
+// the files in a find or grep -n result
+type extractPathsFilter struct{}
+
+func (extractPathsFilter) Name() string { return "extract-paths" }
+
+func (extractPathsFilter) Description() string {
+	return "keep only the file paths in the output, one per line"
+}
+
+func (extractPathsFilter) Apply(output []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(output), "\n") {
+		for _, field := range strings.Fields(line) {
+			// grep -n style "path:line:match"
+			if i := strings.Index(field, ":"); i > 0 {
+				field = field[:i]
+			}
+			if !looksLikePath(field) || seen[field] {
+				continue
+			}
+			seen[field] = true
+			buf.WriteString(field)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// looksLikePath reports whether s looks like a file path rather than a
+// word or number
+func looksLikePath(s string) bool {
+	if strings.Contains(s, "/") {
+		return strings.Trim(s, "/") != "" // not a // comment
+	}
+	ext := filepath.Ext(s)
+	if ext == "" || ext == s {
+		return false
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err != nil
+}
+
+// lineCountFilter replaces output with its number of non-empty lines
+type lineCountFilter struct{}
+
+func (lineCountFilter) Name() string { return "line-count" }
+
+func (lineCountFilter) Description() string {
+	return "replace the output with its number of non-empty lines"
+}
+
+func (lineCountFilter) Apply(output []byte) ([]byte, error) {
+	count := 0
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.TrimSpace(line) != "" {
+			count++
+		}
+	}
+	return []byte(strconv.Itoa(count) + "\n"), nil
+}
+
+// jsonPrettyFilter indents JSON output, including JSON lines output with
+// one value per line
+type jsonPrettyFilter struct{}
+
+func (jsonPrettyFilter) Name() string { return "json-pretty" }
+
+func (jsonPrettyFilter) Description() string {
+	return "indent JSON output"
+}
+
+func (jsonPrettyFilter) Apply(output []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var value json.RawMessage
+		err := decoder.Decode(&value)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("output is not JSON: %w", err)
+		}
+
+		if err := json.Indent(&buf, value, "", "  "); err != nil {
+			return nil, err
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,63 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+)
+
+func applyFilters(t *testing.T, output string, names ...string) string {
+	t.Helper()
+	filters, err := LookupOutputFilters(names)
+	if err != nil {
+		t.Fatalf("LookupOutputFilters(%v) error: %v", names, err)
+	}
+	filtered, err := ApplyOutputFilters(filters, []byte(output))
+	if err != nil {
+		t.Fatalf("ApplyOutputFilters(%v) error: %v", names, err)
+	}
+	return string(filtered)
+}
+
+func TestLineCountFilter(t *testing.T) {
+	output := "main.go:12:// TODO: refactor\nutil.go:3:// TODO: test\n\nserver.go:40:// TODO: auth\n"
+	if got := applyFilters(t, output, "line-count"); got != "3\n" {
+		t.Errorf("line-count = %q, want %q", got, "3\n")
+	}
+
+	if got := applyFilters(t, "", "line-count"); got != "0\n" {
+		t.Errorf("line-count of empty output = %q, want %q", got, "0\n")
+	}
+}
+
+func TestExtractPathsFilter(t *testing.T) {
+	output := "./src/main.go:12:// TODO: refactor\n./src/main.go:30:// TODO: test\nREADME.md:3:TODO 1.5 release\n"
+	want := "./src/main.go\nREADME.md\n"
+	if got := applyFilters(t, output, "extract-paths"); got != want {
+		t.Errorf("extract-paths = %q, want %q", got, want)
+	}
+
+	// Filters chain in order
+	if got := applyFilters(t, output, "extract-paths", "line-count"); got != "2\n" {
+		t.Errorf("extract-paths, line-count = %q, want %q", got, "2\n")
+	}
+}
+
+func TestJSONPrettyFilter(t *testing.T) {
+	got := applyFilters(t, `{"name":"web","replicas":2}`+"\n"+`{"name":"db"}`, "json-pretty")
+	want := "{\n  \"name\": \"web\",\n  \"replicas\": 2\n}\n{\n  \"name\": \"db\"\n}\n"
+	if got != want {
+		t.Errorf("json-pretty = %q, want %q", got, want)
+	}
+
+	filters, _ := LookupOutputFilters([]string{"json-pretty"})
+	if _, err := ApplyOutputFilters(filters, []byte("not json")); err == nil {
+		t.Error("json-pretty should fail on non-JSON output")
+	}
+}
+
+func TestLookupOutputFilters_Unknown(t *testing.T) {
+	_, err := LookupOutputFilters([]string{"line-count", "sort"})
+	if err == nil || !strings.Contains(err.Error(), "json-pretty") {
+		t.Errorf("LookupOutputFilters() error = %v, want unknown filter listing the available ones", err)
+	}
+}
@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// defaultSandboxImage is used for commands that need no language runtime
+const defaultSandboxImage = "alpine:latest"
+
+// defaultImages maps executables to images that provide them. Version
+// suffixes are ignored, so python3.11 and pip3 match python and pip.
+var defaultImages = map[string]string{
+	"python": "python:3-slim",
+	"pip":    "python:3-slim",
+	"pytest": "python:3-slim",
+	"poetry": "python:3-slim",
+	"node":   "node:alpine",
+	"npm":    "node:alpine",
+	"npx":    "node:alpine",
+	"yarn":   "node:alpine",
+	"pnpm":   "node:alpine",
+	"go":     "golang:alpine",
+	"gofmt":  "golang:alpine",
+	"ruby":   "ruby:alpine",
+	"gem":    "ruby:alpine",
+	"bundle": "ruby:alpine",
+	"cargo":  "rust:alpine",
+	"rustc":  "rust:alpine",
+}
+
+// commandSeparator splits a shell command line into simple commands
+var commandSeparator = regexp.MustCompile(`&&|\|\||[;|]`)
+
+// commandWrappers run the executable that follows them
+var commandWrappers = map[string]bool{
+	"sudo": true,
+	"env":  true,
+	"time": true,
+	"exec": true,
+	"nice": true,
+}
+
+// SelectImage picks a sandbox image for command from the executables it
+// runs, so that e.g. "python script.py" gets an image with Python. The first
+// executable with a known image decides, checking Images before the
+// built-in mapping. Commands without one get alpine:latest.
+func (o *SandboxOptions) SelectImage(command string) string {
+	for _, segment := range commandSeparator.Split(command, -1) {
+		name := baseExecutable(segment)
+		if name == "" {
+			continue
+		}
+
+		for _, images := range []map[string]string{o.Images, defaultImages} {
+			if image, ok := images[name]; ok {
+				return image
+			}
+			if image, ok := images[strings.TrimRight(name, "0123456789.")]; ok {
+				return image
+			}
+		}
+	}
+
+	return defaultSandboxImage
+}
+
+// baseExecutable returns the name of the executable a simple command runs,
+// skipping environment assignments and wrappers like sudo
+func baseExecutable(command string) string {
+	for _, field := range strings.Fields(command) {
+		if strings.Contains(field, "=") && !strings.HasPrefix(field, "=") {
+			continue // VAR=value
+		}
+		if strings.HasPrefix(field, "-") {
+			continue // wrapper flag, e.g. sudo -E
+		}
+
+		name := filepath.Base(field)
+		if commandWrappers[name] {
+			continue
+		}
+		return name
+	}
+
+	return ""
+}
@@ -0,0 +1,60 @@
+package executor
+
+import "testing"
+
+func TestSelectImage(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"python script.py", "python:3-slim"},
+		{"python3.11 -m pytest tests/", "python:3-slim"},
+		{"/usr/bin/python3 manage.py migrate", "python:3-slim"},
+		{"pip3 install -r requirements.txt", "python:3-slim"},
+		{"node server.js", "node:alpine"},
+		{"cd web && npm test", "node:alpine"},
+		{"NODE_ENV=production npx webpack", "node:alpine"},
+		{"go test ./...", "golang:alpine"},
+		{"sudo -E bundle exec rake", "ruby:alpine"},
+		{"cargo build --release | tee build.log", "rust:alpine"},
+		{"ls -la", "alpine:latest"},
+		{"find . -name '*.py' -delete", "alpine:latest"},
+		{"", "alpine:latest"},
+	}
+
+	var opts SandboxOptions
+	for _, tt := range tests {
+		if got := opts.SelectImage(tt.command); got != tt.want {
+			t.Errorf("SelectImage(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestSelectImage_Overrides(t *testing.T) {
+	opts := SandboxOptions{Images: map[string]string{
+		"python": "python:3.12-bookworm",
+		"make":   "gcc:13",
+	}}
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"python3 script.py", "python:3.12-bookworm"},
+		{"make all", "gcc:13"},
+		{"npm ci", "node:alpine"},
+		{"echo hi", "alpine:latest"},
+	}
+	for _, tt := range tests {
+		if got := opts.SelectImage(tt.command); got != tt.want {
+			t.Errorf("SelectImage(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+
+	// An explicit image is kept
+	opts.Image = "custom:1"
+	applySandboxDefaults(&opts, "python script.py")
+	if opts.Image != "custom:1" {
+		t.Errorf("applySandboxDefaults() replaced explicit image with %q", opts.Image)
+	}
+}
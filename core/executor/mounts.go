@@ -0,0 +1,126 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrInvalidMount      = errors.New("invalid mount")
+	ErrDockerSocketMount = errors.New("mounting the Docker socket is not allowed")
+)
+
+// dockerSockets are socket paths that would give the sandbox control of the
+// host's Docker daemon or Podman service
+var dockerSockets = []string{
+	"/var/run/docker.sock",
+	"/run/docker.sock",
+	"/var/run/podman/podman.sock",
+	"/run/podman/podman.sock",
+}
+
+// MountCheck is the result of checking a mount against the sensitive paths
+type MountCheck struct {
+	Sensitive bool   // Mount exposes a sensitive host path and needs confirmation
+	Reason    string // Why the mount is sensitive
+}
+
+// DefaultSensitiveMounts returns the host paths whose mounting requires
+// confirmation: the root, /etc, and the user's home directory
+func DefaultSensitiveMounts() []string {
+	paths := []string{"/", "/etc"}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, homeDir)
+	}
+	return paths
+}
+
+// ParseMount parses a mount spec of the form source:target[:ro|rw]. A
+// relative source is resolved against the current directory.
+func ParseMount(spec string) (Mount, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return Mount{}, fmt.Errorf("%w: %q (expected source:target[:ro])", ErrInvalidMount, spec)
+	}
+
+	source, err := filepath.Abs(parts[0])
+	if err != nil {
+		return Mount{}, fmt.Errorf("%w: %q: %v", ErrInvalidMount, spec, err)
+	}
+
+	if !strings.HasPrefix(parts[1], "/") {
+		return Mount{}, fmt.Errorf("%w: %q: target must be an absolute path", ErrInvalidMount, spec)
+	}
+
+	mount := Mount{Source: source, Target: parts[1]}
+	if len(parts) == 3 {
+		switch parts[2] {
+		case "ro":
+			mount.ReadOnly = true
+		case "rw":
+		default:
+			return Mount{}, fmt.Errorf("%w: %q: unknown mode %q", ErrInvalidMount, spec, parts[2])
+		}
+	}
+
+	return mount, nil
+}
+
+// CheckMount checks a mount source against the Docker socket and the given
+// sensitive paths. Mounting the Docker socket, or a directory containing it
+// such as /run or /, is always an error; mounting a sensitive path, or a
+// path inside /etc, is reported so the caller can ask for confirmation.
+func CheckMount(mount Mount, sensitivePaths []string) (*MountCheck, error) {
+	source := resolvePath(mount.Source)
+
+	// Compare both the path as given and with symlinks followed
+	sources := []string{filepath.Clean(mount.Source), source}
+	for _, socket := range dockerSockets {
+		for _, socketPath := range []string{socket, resolvePath(socket)} {
+			for _, s := range sources {
+				if containsPath(s, socketPath) {
+					return nil, fmt.Errorf("%w: %s exposes %s", ErrDockerSocketMount, mount.Source, socket)
+				}
+			}
+		}
+	}
+
+	for _, sensitive := range sensitivePaths {
+		sensitive = resolvePath(sensitive)
+		if source == sensitive {
+			return &MountCheck{
+				Sensitive: true,
+				Reason:    fmt.Sprintf("%s is a sensitive host path", mount.Source),
+			}, nil
+		}
+	}
+
+	etc := resolvePath("/etc")
+	if strings.HasPrefix(source, etc+string(filepath.Separator)) {
+		return &MountCheck{
+			Sensitive: true,
+			Reason:    fmt.Sprintf("%s is inside /etc", mount.Source),
+		}, nil
+	}
+
+	return &MountCheck{}, nil
+}
+
+// containsPath reports whether path is dir or inside it
+func containsPath(dir, path string) bool {
+	return dir == path || dir == string(filepath.Separator) ||
+		strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// resolvePath cleans a path and follows symlinks where the path exists, so
+// /var/run/docker.sock and /run/docker.sock compare equal
+func resolvePath(path string) string {
+	path = filepath.Clean(path)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseMount(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    Mount
+		wantErr bool
+	}{
+		{"read-write", "/data:/data", Mount{Source: "/data", Target: "/data"}, false},
+		{"read-only", "/data:/mnt/data:ro", Mount{Source: "/data", Target: "/mnt/data", ReadOnly: true}, false},
+		{"missing target", "/data", Mount{}, true},
+		{"relative target", "/data:data", Mount{}, true},
+		{"unknown mode", "/data:/data:rx", Mount{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMount(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMount(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseMount(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckMount(t *testing.T) {
+	projectDir := t.TempDir()
+	homeDir := t.TempDir()
+	sensitive := []string{"/", "/etc", homeDir}
+
+	// A link to the socket's directory, which needn't exist on this host
+	socketLink := filepath.Join(t.TempDir(), "run")
+	if err := os.Symlink("/run", socketLink); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		source        string
+		wantSensitive bool
+		wantErr       error
+	}{
+		{"docker socket", "/var/run/docker.sock", false, ErrDockerSocketMount},
+		{"docker socket via /run", "/run/docker.sock", false, ErrDockerSocketMount},
+		{"docker socket dir", "/var/run", false, ErrDockerSocketMount},
+		{"docker socket parent", "/run/", false, ErrDockerSocketMount},
+		{"podman socket dir", "/run/podman", false, ErrDockerSocketMount},
+		{"docker socket via symlink", socketLink, false, ErrDockerSocketMount},
+		{"project dir", projectDir, false, nil},
+		{"project subdir", filepath.Join(projectDir, "src"), false, nil},
+		{"host root", "/", false, ErrDockerSocketMount},
+		{"etc", "/etc", true, nil},
+		{"inside etc", "/etc/ssh", true, nil},
+		{"home dir", homeDir, true, nil},
+		{"home dir with trailing slash", homeDir + "/", true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, err := CheckMount(Mount{Source: tt.source, Target: "/mnt"}, sensitive)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CheckMount(%q) error = %v, want %v", tt.source, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckMount(%q) unexpected error: %v", tt.source, err)
+			}
+			if check.Sensitive != tt.wantSensitive {
+				t.Errorf("CheckMount(%q).Sensitive = %v, want %v", tt.source, check.Sensitive, tt.wantSensitive)
+			}
+		})
+	}
+}
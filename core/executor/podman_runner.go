@@ -0,0 +1,195 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// podmanErrorExitCode is the exit code podman run uses when podman itself
+// fails, as opposed to the command in the container
+const podmanErrorExitCode = 125
+
+// PodmanRunner executes commands in Podman containers by shelling out to
+// the podman CLI, which works rootless and without a daemon
+type PodmanRunner struct {
+	podman string // podman executable
+}
+
+// NewPodmanRunner creates a new Podman runner
+func NewPodmanRunner() (*PodmanRunner, error) {
+	path, err := exec.LookPath("podman")
+	if err != nil {
+		return nil, fmt.Errorf("podman not found: %w (is Podman installed?)", err)
+	}
+
+	// Verify podman can talk to its storage and runtime
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if output, err := exec.CommandContext(ctx, path, "info", "--format", "{{.Host.OS}}").CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("Podman not usable: %w\n%s", err, output)
+	}
+
+	return &PodmanRunner{podman: path}, nil
+}
+
+// RunInSandbox executes a command in an isolated Podman container
+func (pr *PodmanRunner) RunInSandbox(cmd string, opts SandboxOptions) (*SandboxResult, error) {
+	return pr.RunInSandboxStreaming(cmd, opts, nil, nil)
+}
+
+// RunInSandboxStreaming executes a command in an isolated Podman container,
+// copying its stdout and stderr to out and errw as they arrive. Either
+// writer may be nil.
+func (pr *PodmanRunner) RunInSandboxStreaming(cmd string, opts SandboxOptions, out io.Writer, errw io.Writer) (*SandboxResult, error) {
+	result := &SandboxResult{
+		StartTime: time.Now(),
+	}
+
+	applySandboxDefaults(&opts, cmd)
+
+	if len(opts.NetworkAllowlist) > 0 {
+		result.Error = fmt.Errorf("network allowlists need the Docker runner")
+		return result, result.Error
+	}
+
+	// Never expose a container socket
+	for _, mount := range opts.Mounts {
+		if _, err := CheckMount(mount, nil); err != nil {
+			result.Error = err
+			return result, err
+		}
+	}
+
+	name, err := containerName()
+	if err != nil {
+		result.Error = err
+		return result, err
+	}
+	result.SandboxID = name
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	podmanCmd := exec.CommandContext(ctx, pr.podman, podmanRunArgs(name, cmd, opts)...)
+	podmanCmd.Stdout = teeWriter(&stdout, out)
+	podmanCmd.Stderr = teeWriter(&stderr, errw)
+
+	runErr := podmanCmd.Run()
+	result.Stdout = stdout.Bytes()
+	result.Stderr = stderr.Bytes()
+	result.EndTime = time.Now()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		// Timeout - killing the podman client doesn't stop the container
+		exec.Command(pr.podman, "kill", name).Run()
+		result.Error = fmt.Errorf("execution timeout after %v", opts.Timeout)
+		result.ExitCode = 124 // Standard timeout exit code
+		return result, result.Error
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case runErr == nil:
+	case errors.As(runErr, &exitErr) && exitErr.ExitCode() != podmanErrorExitCode:
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		result.Error = fmt.Errorf("failed to run container: %w\n%s", runErr, stderr.String())
+		return result, result.Error
+	}
+
+	return result, nil
+}
+
+// podmanRunArgs builds the podman run arguments, mirroring the limits
+// DockerRunner sets
+func podmanRunArgs(name, cmd string, opts SandboxOptions) []string {
+	args := []string{
+		"run", "--rm",
+		"--name", name,
+		"--user", "1000:1000", // Run as non-root
+		"--workdir", opts.WorkingDir,
+		"--cpus", strconv.FormatFloat(opts.CPULimit, 'f', -1, 64),
+		"--memory", strconv.FormatInt(opts.MemoryLimit, 10),
+		"--pids-limit", strconv.FormatInt(opts.PidsLimit, 10),
+	}
+
+	// Network isolation
+	if !opts.NetworkAccess {
+		args = append(args, "--network", "none")
+	}
+	if opts.ReadOnly {
+		args = append(args, "--read-only")
+	}
+
+	// Privilege restrictions
+	if security := opts.Security; security != nil {
+		for _, capability := range security.DropCapabilities {
+			args = append(args, "--cap-drop", capability)
+		}
+		if security.NoNewPrivileges {
+			args = append(args, "--security-opt", "no-new-privileges")
+		}
+		if security.SeccompProfile != "" {
+			args = append(args, "--security-opt", "seccomp="+security.SeccompProfile)
+		}
+	}
+
+	for _, mount := range opts.Mounts {
+		args = append(args, "--volume",
+			fmt.Sprintf("%s:%s:%s", mount.Source, mount.Target, mountMode(mount.ReadOnly)))
+	}
+
+	return append(args, opts.Image, "/bin/sh", "-c", cmd)
+}
+
+// containerName returns a unique name for a sandbox container
+func containerName() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate container name: %w", err)
+	}
+	return "quickcmd-" + hex.EncodeToString(b), nil
+}
+
+// Close releases runner resources. Podman runs without a daemon, so there
+// is nothing to close.
+func (pr *PodmanRunner) Close() error {
+	return nil
+}
+
+// IsPodmanAvailable checks if Podman is installed and usable
+func IsPodmanAvailable() bool {
+	path, err := exec.LookPath("podman")
+	if err != nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	return exec.CommandContext(ctx, path, "info", "--format", "{{.Host.OS}}").Run() == nil
+}
+
+// GetPodmanInfo returns Podman version information
+func GetPodmanInfo() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "podman", "version", "--format", "{{.Client.Version}}").Output()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Podman %s", strings.TrimSpace(string(output))), nil
+}
@@ -0,0 +1,118 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPodmanRunArgs(t *testing.T) {
+	opts := SandboxOptions{
+		Image: "alpine:3.19",
+		Mounts: []Mount{
+			{Source: "/home/dev/project", Target: "/workspace"},
+			{Source: "/etc/ssl", Target: "/etc/ssl", ReadOnly: true},
+		},
+		ReadOnly: true,
+	}
+	applySandboxDefaults(&opts, "ls -la")
+
+	got := strings.Join(podmanRunArgs("quickcmd-test", "ls -la", opts), " ")
+	want := "run --rm --name quickcmd-test --user 1000:1000 --workdir /workspace" +
+		" --cpus 0.5 --memory 268435456 --pids-limit 64 --network none --read-only" +
+		" --cap-drop ALL --security-opt no-new-privileges" +
+		" --volume /home/dev/project:/workspace:rw --volume /etc/ssl:/etc/ssl:ro" +
+		" alpine:3.19 /bin/sh -c ls -la"
+	if got != want {
+		t.Errorf("podmanRunArgs() =\n%s\nwant\n%s", got, want)
+	}
+
+	opts.NetworkAccess = true
+	opts.ReadOnly = false
+	got = strings.Join(podmanRunArgs("quickcmd-test", "true", opts), " ")
+	if strings.Contains(got, "--network") || strings.Contains(got, "--read-only") {
+		t.Errorf("podmanRunArgs() with network and writable root = %s", got)
+	}
+
+	opts.Security = &SecurityProfile{DropCapabilities: []string{"NET_RAW"}, SeccompProfile: "/etc/quickcmd/seccomp.json"}
+	got = strings.Join(podmanRunArgs("quickcmd-test", "true", opts), " ")
+	if !strings.Contains(got, "--cap-drop NET_RAW --security-opt seccomp=/etc/quickcmd/seccomp.json ") ||
+		strings.Contains(got, "no-new-privileges") {
+		t.Errorf("podmanRunArgs() with a custom security profile = %s", got)
+	}
+}
+
+func TestNewSandboxRunner(t *testing.T) {
+	docker, podman := IsDockerAvailable(), IsPodmanAvailable()
+	if !docker && !podman {
+		if _, err := NewSandboxRunner(); err == nil {
+			t.Error("NewSandboxRunner() should fail without Docker or Podman")
+		}
+		t.Skip("neither Docker nor Podman available")
+	}
+
+	runner, err := NewSandboxRunner()
+	if err != nil {
+		t.Fatalf("NewSandboxRunner() error: %v", err)
+	}
+	defer runner.Close()
+
+	switch runner.(type) {
+	case *DockerRunner:
+		if !docker {
+			t.Error("NewSandboxRunner() chose Docker, which is not available")
+		}
+	case *PodmanRunner:
+		if docker {
+			t.Error("NewSandboxRunner() should prefer Docker when it is available")
+		}
+	}
+
+	info, err := DescribeRunner(runner)
+	if err != nil || info == "" {
+		t.Errorf("DescribeRunner() = %q, %v", info, err)
+	}
+	t.Logf("Sandbox runtime: %s", info)
+}
+
+func TestPodmanRunner_RunInSandbox(t *testing.T) {
+	if !IsPodmanAvailable() {
+		t.Skip("Podman not available")
+	}
+
+	runner, err := NewPodmanRunner()
+	if err != nil {
+		t.Fatalf("Failed to create Podman runner: %v", err)
+	}
+	defer runner.Close()
+
+	result, err := runner.RunInSandbox("echo 'Hello from podman'; ls /nonexistent", SandboxOptions{
+		Image: "alpine:latest",
+	})
+	if err != nil {
+		t.Fatalf("RunInSandbox() error: %v", err)
+	}
+
+	if !strings.Contains(string(result.Stdout), "Hello from podman") {
+		t.Errorf("RunInSandbox() stdout = %q", result.Stdout)
+	}
+	if result.ExitCode == 0 || !strings.Contains(string(result.Stderr), "No such file") {
+		t.Errorf("RunInSandbox() exit code = %d, stderr = %q; want the ls failure", result.ExitCode, result.Stderr)
+	}
+	if !strings.HasPrefix(result.SandboxID, "quickcmd-") {
+		t.Errorf("RunInSandbox() sandbox ID = %q", result.SandboxID)
+	}
+}
+
+func TestPodmanRunner_RunInSandboxStreaming(t *testing.T) {
+	if !IsPodmanAvailable() {
+		t.Skip("Podman not available")
+	}
+
+	runner, err := NewPodmanRunner()
+	if err != nil {
+		t.Fatalf("Failed to create Podman runner: %v", err)
+	}
+	defer runner.Close()
+
+	checkStreaming(t, runner)
+}
@@ -0,0 +1,268 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// poolIdleCommand keeps a pooled container running until a command is
+// executed in it
+var poolIdleCommand = []string{"tail", "-f", "/dev/null"}
+
+// DockerRunnerOption configures a DockerRunner
+type DockerRunnerOption func(*DockerRunner)
+
+// WithPool keeps up to size warm containers per sandbox configuration, so
+// commands are executed in an already running container instead of paying
+// for a container start each time. The first run of a configuration starts
+// cold and warms the pool for the next ones.
+func WithPool(size int) DockerRunnerOption {
+	return func(dr *DockerRunner) {
+		if size > 0 {
+			dr.pool = newContainerPool(dr, size)
+		}
+	}
+}
+
+// ContainerPool holds warm, idle sandbox containers. Each container runs a
+// single command and is then removed, so no files or processes carry over
+// from one run to the next; the pool starts a replacement in the background.
+type ContainerPool struct {
+	runner *DockerRunner
+	size   int
+
+	mu      sync.Mutex
+	idle    map[string][]string // container IDs by poolKey
+	pending map[string]int      // containers being started by poolKey
+	closed  bool
+	warming sync.WaitGroup
+}
+
+// newContainerPool creates an empty pool
+func newContainerPool(runner *DockerRunner, size int) *ContainerPool {
+	return &ContainerPool{
+		runner:  runner,
+		size:    size,
+		idle:    make(map[string][]string),
+		pending: make(map[string]int),
+	}
+}
+
+// poolKey identifies the sandbox configuration a container was started
+// with. Only runs with the same configuration can share containers.
+func poolKey(opts SandboxOptions) string {
+	mounts := make([]string, 0, len(opts.Mounts))
+	for _, mount := range opts.Mounts {
+		mounts = append(mounts, fmt.Sprintf("%s:%s:%s", mount.Source, mount.Target, mountMode(mount.ReadOnly)))
+	}
+	sort.Strings(mounts)
+
+	var security SecurityProfile
+	if opts.Security != nil {
+		security = *opts.Security
+	}
+
+	return fmt.Sprintf("%s|%s|%g|%d|%d|%t|%t|%s|%t|%s|%s", opts.Image, opts.WorkingDir, opts.CPULimit,
+		opts.MemoryLimit, opts.PidsLimit, opts.NetworkAccess, opts.ReadOnly, strings.Join(mounts, ","),
+		security.NoNewPrivileges, strings.Join(security.DropCapabilities, ","), security.SeccompProfile)
+}
+
+// accepts reports whether a run with opts can use a pooled container.
+// Allowlisted runs need a network of their own.
+func (p *ContainerPool) accepts(opts SandboxOptions) bool {
+	return len(opts.NetworkAllowlist) == 0
+}
+
+// take removes an idle container for opts from the pool, returning "" if
+// there is none
+func (p *ContainerPool) take(opts SandboxOptions) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := poolKey(opts)
+	ids := p.idle[key]
+	if len(ids) == 0 {
+		return ""
+	}
+	p.idle[key] = ids[1:]
+	return ids[0]
+}
+
+// fill starts containers in the background until the pool holds size of
+// them for opts
+func (p *ContainerPool) fill(opts SandboxOptions) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	key := poolKey(opts)
+	for n := len(p.idle[key]) + p.pending[key]; n < p.size; n++ {
+		p.pending[key]++
+		p.warming.Add(1)
+		go func() {
+			defer p.warming.Done()
+			id, err := p.start(opts)
+
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.pending[key]--
+			if err != nil {
+				return // the next run starts cold
+			}
+			if p.closed {
+				p.discard(id)
+				return
+			}
+			p.idle[key] = append(p.idle[key], id)
+		}()
+	}
+}
+
+// start creates and starts an idle container for opts
+func (p *ContainerPool) start(opts SandboxOptions) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	if err := p.runner.ensureImage(ctx, opts.Image); err != nil {
+		return "", err
+	}
+
+	containerConfig, hostConfig, err := sandboxConfig(poolIdleCommand, opts)
+	if err != nil {
+		return "", err
+	}
+	containerConfig.Labels = map[string]string{"quickcmd.pool": "true"}
+
+	resp, err := p.runner.client.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to create container: %w", err)
+	}
+	if err := p.runner.client.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		p.discard(resp.ID)
+		return "", fmt.Errorf("failed to start container: %w", err)
+	}
+
+	return resp.ID, nil
+}
+
+// discard kills a container, which removes it
+func (p *ContainerPool) discard(id string) {
+	p.runner.client.ContainerKill(context.Background(), id, "SIGKILL")
+}
+
+// wait blocks until containers being started are in the pool
+func (p *ContainerPool) wait() {
+	p.warming.Wait()
+}
+
+// Close stops filling the pool and removes its idle containers
+func (p *ContainerPool) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	p.warming.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, ids := range p.idle {
+		for _, id := range ids {
+			p.discard(id)
+		}
+		delete(p.idle, key)
+	}
+}
+
+// runInPooledContainer executes cmd in the warm container id, removing the
+// container afterwards
+func (dr *DockerRunner) runInPooledContainer(id, cmd string, opts SandboxOptions, out io.Writer, errw io.Writer) (*SandboxResult, error) {
+	result := &SandboxResult{
+		StartTime: time.Now(),
+		SandboxID: id[:12],
+	}
+	defer dr.pool.discard(id)
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	exec, err := dr.client.ContainerExecCreate(ctx, id, types.ExecConfig{
+		User:         "1000:1000", // Run as non-root
+		WorkingDir:   opts.WorkingDir,
+		Cmd:          []string{"/bin/sh", "-c", cmd},
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		result.Error = fmt.Errorf("failed to create exec: %w", err)
+		return result, result.Error
+	}
+
+	// Attaching starts the command
+	attach, err := dr.client.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{})
+	if err != nil {
+		result.Error = fmt.Errorf("failed to start exec: %w", err)
+		return result, result.Error
+	}
+	defer attach.Close()
+
+	// The container outlives the command, so stop reading stats once it ends
+	usageCtx, stopUsage := context.WithCancel(ctx)
+	usage := dr.collectResourceUsage(usageCtx, id)
+
+	var stdout, stderr bytes.Buffer
+	copyDone := make(chan error, 1)
+	go func() {
+		_, err := stdcopy.StdCopy(teeWriter(&stdout, out), teeWriter(&stderr, errw), attach.Reader)
+		copyDone <- err
+	}()
+
+	var runErr, copyErr error
+	select {
+	case copyErr = <-copyDone:
+		inspect, err := dr.client.ContainerExecInspect(ctx, exec.ID)
+		if err != nil {
+			runErr = fmt.Errorf("failed to inspect exec: %w", err)
+		} else {
+			result.ExitCode = inspect.ExitCode
+		}
+	case <-ctx.Done():
+		// Timeout - killing the container ends the command and its children
+		dr.pool.discard(id)
+		attach.Close()
+		<-copyDone
+		runErr = fmt.Errorf("execution timeout after %v", opts.Timeout)
+		result.ExitCode = 124 // Standard timeout exit code
+	}
+
+	stopUsage()
+	u := <-usage
+	result.MaxMemoryBytes = u.MaxMemoryBytes
+	result.CPUTimeMs = u.CPUTimeMs
+
+	result.Stdout = stdout.Bytes()
+	result.Stderr = stderr.Bytes()
+	result.EndTime = time.Now()
+
+	if runErr != nil {
+		result.Error = runErr
+		return result, result.Error
+	}
+	if copyErr != nil {
+		result.Error = fmt.Errorf("failed to read container output: %w", copyErr)
+		return result, result.Error
+	}
+
+	return result, nil
+}
@@ -0,0 +1,161 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPoolKey(t *testing.T) {
+	opts := SandboxOptions{
+		Image: "alpine:latest",
+		Mounts: []Mount{
+			{Source: "/home/dev/project", Target: "/workspace"},
+			{Source: "/etc/ssl", Target: "/etc/ssl", ReadOnly: true},
+		},
+	}
+	applySandboxDefaults(&opts, "ls")
+
+	reordered := opts
+	reordered.Mounts = []Mount{opts.Mounts[1], opts.Mounts[0]}
+	reordered.Timeout = time.Second
+	if poolKey(opts) != poolKey(reordered) {
+		t.Error("poolKey() should ignore mount order and timeout")
+	}
+
+	for name, change := range map[string]func(*SandboxOptions){
+		"image":   func(o *SandboxOptions) { o.Image = "python:3-slim" },
+		"memory":  func(o *SandboxOptions) { o.MemoryLimit *= 2 },
+		"network": func(o *SandboxOptions) { o.NetworkAccess = true },
+		"mount":   func(o *SandboxOptions) { o.Mounts = o.Mounts[:1] },
+		"ro": func(o *SandboxOptions) {
+			o.Mounts = []Mount{{Source: "/home/dev/project", Target: "/workspace", ReadOnly: true}, o.Mounts[1]}
+		},
+	} {
+		changed := opts
+		change(&changed)
+		if poolKey(opts) == poolKey(changed) {
+			t.Errorf("poolKey() should differ when the %s changes", name)
+		}
+	}
+
+	pool := newContainerPool(nil, 2)
+	allowlisted := opts
+	allowlisted.NetworkAllowlist = []string{"api.github.com"}
+	if !pool.accepts(opts) || pool.accepts(allowlisted) {
+		t.Error("pool should accept runs without a network allowlist only")
+	}
+}
+
+func newPooledDockerRunner(t testing.TB) *DockerRunner {
+	t.Helper()
+	if !IsDockerAvailable() {
+		t.Skip("Docker not available, skipping integration tests")
+	}
+
+	runner, err := NewDockerRunner(WithPool(1))
+	if err != nil {
+		t.Fatalf("Failed to create Docker runner: %v", err)
+	}
+	return runner
+}
+
+func TestDockerRunner_Pool(t *testing.T) {
+	runner := newPooledDockerRunner(t)
+	defer runner.Close()
+
+	opts := SandboxOptions{Image: "alpine:latest", Timeout: time.Minute}
+
+	// The first run starts cold and warms the pool
+	result, err := runner.RunInSandbox("touch /tmp/leftover", opts)
+	if err != nil || result.ExitCode != 0 {
+		t.Fatalf("cold run: exit code = %v, error = %v", result.ExitCode, err)
+	}
+	runner.pool.wait()
+
+	defaults := opts
+	applySandboxDefaults(&defaults, "")
+	warm := runner.pool.idle[poolKey(defaults)]
+	if len(warm) != 1 {
+		t.Fatalf("pool holds %d warm containers, want 1", len(warm))
+	}
+
+	// Pooled runs get a container nothing ran in before
+	result, err = runner.RunInSandbox("test ! -e /tmp/leftover && id -u && touch /tmp/leftover", opts)
+	if err != nil {
+		t.Fatalf("pooled run error: %v", err)
+	}
+	if result.SandboxID != warm[0][:12] {
+		t.Errorf("pooled run used sandbox %s, want warm container %.12s", result.SandboxID, warm[0])
+	}
+	if result.ExitCode != 0 || strings.TrimSpace(string(result.Stdout)) != "1000" {
+		t.Errorf("pooled run: exit code = %d, stdout = %q, stderr = %q", result.ExitCode, result.Stdout, result.Stderr)
+	}
+	runner.pool.wait()
+
+	result, err = runner.RunInSandbox("test ! -e /tmp/leftover", opts)
+	if err != nil || result.ExitCode != 0 {
+		t.Errorf("second pooled run saw the previous run's files: exit code = %d, error = %v", result.ExitCode, err)
+	}
+	runner.pool.wait()
+
+	result, err = runner.RunInSandbox("exit 3", opts)
+	if err != nil || result.ExitCode != 3 {
+		t.Errorf("pooled run exit code = %d, error = %v; want 3", result.ExitCode, err)
+	}
+}
+
+func TestDockerRunner_PoolTimeout(t *testing.T) {
+	runner := newPooledDockerRunner(t)
+	defer runner.Close()
+
+	opts := SandboxOptions{Image: "alpine:latest", Timeout: time.Minute}
+	runner.RunInSandbox("true", opts)
+	runner.pool.wait()
+
+	opts.Timeout = 2 * time.Second
+	result, err := runner.RunInSandbox("sleep 30", opts)
+	if err == nil || result.ExitCode != 124 {
+		t.Errorf("pooled run exit code = %d, error = %v; want a timeout", result.ExitCode, err)
+	}
+}
+
+func BenchmarkDockerRunner_Startup(b *testing.B) {
+	opts := SandboxOptions{Image: "alpine:latest", Timeout: time.Minute}
+
+	b.Run("cold", func(b *testing.B) {
+		if !IsDockerAvailable() {
+			b.Skip("Docker not available")
+		}
+		runner, err := NewDockerRunner()
+		if err != nil {
+			b.Fatalf("Failed to create Docker runner: %v", err)
+		}
+		defer runner.Close()
+
+		for i := 0; i < b.N; i++ {
+			if _, err := runner.RunInSandbox("true", opts); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		runner := newPooledDockerRunner(b)
+		defer runner.Close()
+
+		runner.RunInSandbox("true", opts)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			// Commands typed in a session are seconds apart, long enough
+			// for the replacement container to start
+			b.StopTimer()
+			runner.pool.wait()
+			b.StartTimer()
+
+			if _, err := runner.RunInSandbox("true", opts); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// PullProgress reports how far an image pull has got, combined over the
+// image's layers
+type PullProgress struct {
+	Image   string
+	Status  string // latest status, e.g. "Downloading"
+	Current int64  // bytes downloaded
+	Total   int64  // bytes to download, 0 until a layer size is known
+	Done    bool   // the pull finished
+}
+
+// Percent returns the downloaded share of the image, or -1 if the size is
+// not known yet
+func (p PullProgress) Percent() int {
+	if p.Total <= 0 {
+		return -1
+	}
+	return int(p.Current * 100 / p.Total)
+}
+
+// WithPullProgress reports image pull progress to sink. Without it, pulls
+// are silent.
+func WithPullProgress(sink func(PullProgress)) DockerRunnerOption {
+	return func(dr *DockerRunner) {
+		dr.pullProgress = sink
+	}
+}
+
+// pullMessage is a message of Docker's JSON pull progress stream
+type pullMessage struct {
+	Status         string `json:"status"`
+	ID             string `json:"id"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+	Error string `json:"error"`
+}
+
+// layerProgress is the download progress of one layer
+type layerProgress struct {
+	current, total int64
+}
+
+// readPullProgress reads a pull progress stream to its end, passing the
+// combined progress to sink after each message. sink may be nil.
+func readPullProgress(r io.Reader, image string, sink func(PullProgress)) error {
+	layers := make(map[string]*layerProgress)
+	var order []string // layer IDs in the order they appeared
+
+	progress := PullProgress{Image: image}
+	decoder := json.NewDecoder(r)
+	for {
+		var msg pullMessage
+		if err := decoder.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if msg.Error != "" {
+			return errors.New(msg.Error)
+		}
+
+		if msg.ID != "" {
+			layer, ok := layers[msg.ID]
+			if !ok {
+				layer = &layerProgress{}
+				layers[msg.ID] = layer
+				order = append(order, msg.ID)
+			}
+
+			// Extraction reports progress too, but it isn't download progress
+			switch msg.Status {
+			case "Downloading":
+				layer.current, layer.total = msg.ProgressDetail.Current, msg.ProgressDetail.Total
+			case "Download complete", "Pull complete", "Already exists":
+				layer.current = layer.total
+			}
+		}
+
+		progress.Status = msg.Status
+		progress.Current, progress.Total = 0, 0
+		for _, id := range order {
+			progress.Current += layers[id].current
+			progress.Total += layers[id].total
+		}
+		if sink != nil {
+			sink(progress)
+		}
+	}
+
+	progress.Done = true
+	if sink != nil {
+		sink(progress)
+	}
+	return nil
+}
+
+// String describes p for a one-line progress display
+func (p PullProgress) String() string {
+	if p.Done {
+		return fmt.Sprintf("Pulled %s", p.Image)
+	}
+	if percent := p.Percent(); percent >= 0 {
+		return fmt.Sprintf("Pulling %s: %d%% (%s / %s)", p.Image, percent, formatBytes(p.Current), formatBytes(p.Total))
+	}
+	return fmt.Sprintf("Pulling %s: %s", p.Image, p.Status)
+}
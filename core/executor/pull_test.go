@@ -0,0 +1,63 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadPullProgress(t *testing.T) {
+	stream := `{"status":"Pulling from library/python","id":"3-slim"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"a1"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"b2"}
+{"status":"Downloading","progressDetail":{"current":1000,"total":4000},"id":"a1"}
+{"status":"Downloading","progressDetail":{"current":500,"total":6000},"id":"b2"}
+{"status":"Downloading","progressDetail":{"current":3000,"total":4000},"id":"a1"}
+{"status":"Download complete","progressDetail":{},"id":"a1"}
+{"status":"Extracting","progressDetail":{"current":32768,"total":4000},"id":"a1"}
+{"status":"Download complete","progressDetail":{},"id":"b2"}
+{"status":"Pull complete","progressDetail":{},"id":"a1"}
+{"status":"Status: Downloaded newer image for python:3-slim"}
+`
+	var updates []PullProgress
+	if err := readPullProgress(strings.NewReader(stream), "python:3-slim", func(p PullProgress) {
+		updates = append(updates, p)
+	}); err != nil {
+		t.Fatalf("readPullProgress() error: %v", err)
+	}
+
+	var percents []int
+	for _, p := range updates {
+		if p.Image != "python:3-slim" {
+			t.Errorf("progress image = %q", p.Image)
+		}
+		percents = append(percents, p.Percent())
+	}
+	want := []int{-1, -1, -1, 25, 15, 35, 45, 45, 100, 100, 100, 100}
+	if len(percents) != len(want) {
+		t.Fatalf("got %d progress updates %v, want %d", len(percents), percents, len(want))
+	}
+	for i := range want {
+		if percents[i] != want[i] {
+			t.Errorf("progress percents = %v, want %v", percents, want)
+			break
+		}
+	}
+
+	last := updates[len(updates)-1]
+	if !last.Done || last.Current != 10000 || last.Total != 10000 {
+		t.Errorf("final progress = %+v, want done with 10000 of 10000 bytes", last)
+	}
+	if got := updates[5].String(); got != "Pulling python:3-slim: 35% (3.4 KiB / 9.8 KiB)" {
+		t.Errorf("progress String() = %q", got)
+	}
+}
+
+func TestReadPullProgress_Error(t *testing.T) {
+	stream := `{"status":"Pulling from library/nosuchimage","id":"latest"}
+{"error":"manifest for nosuchimage:latest not found"}
+`
+	err := readPullProgress(strings.NewReader(stream), "nosuchimage:latest", nil)
+	if err == nil || !strings.Contains(err.Error(), "manifest") {
+		t.Errorf("readPullProgress() error = %v, want the stream's error", err)
+	}
+}
@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// SandboxRunner executes commands in isolated containers
+type SandboxRunner interface {
+	RunInSandbox(cmd string, opts SandboxOptions) (*SandboxResult, error)
+	// RunInSandboxStreaming is like RunInSandbox, but also copies stdout and
+	// stderr to out and errw while the command runs
+	RunInSandboxStreaming(cmd string, opts SandboxOptions, out io.Writer, errw io.Writer) (*SandboxResult, error)
+	Close() error
+}
+
+var (
+	_ SandboxRunner = (*DockerRunner)(nil)
+	_ SandboxRunner = (*PodmanRunner)(nil)
+)
+
+// NewSandboxRunner returns a runner for the available container runtime,
+// preferring Docker and falling back to Podman for rootless hosts without
+// a Docker daemon. options apply to a Docker runner only.
+func NewSandboxRunner(options ...DockerRunnerOption) (SandboxRunner, error) {
+	if IsDockerAvailable() {
+		return NewDockerRunner(options...)
+	}
+	if IsPodmanAvailable() {
+		return NewPodmanRunner()
+	}
+	return nil, fmt.Errorf("no container runtime available (install Docker or Podman)")
+}
+
+// DescribeRunner returns the runtime and version behind runner, e.g.
+// "Podman 4.9.3"
+func DescribeRunner(runner SandboxRunner) (string, error) {
+	switch runner.(type) {
+	case *DockerRunner:
+		return GetDockerInfo()
+	case *PodmanRunner:
+		return GetPodmanInfo()
+	default:
+		return "", fmt.Errorf("unknown sandbox runner %T", runner)
+	}
+}
+
+// RunnerBackend returns the container runtime behind runner: "docker" or
+// "podman"
+func RunnerBackend(runner SandboxRunner) string {
+	switch runner.(type) {
+	case *DockerRunner:
+		return "docker"
+	case *PodmanRunner:
+		return "podman"
+	default:
+		return "unknown"
+	}
+}
+
+// applySandboxDefaults fills in unset options for running cmd
+func applySandboxDefaults(opts *SandboxOptions, cmd string) {
+	if opts.Image == "" {
+		opts.Image = opts.SelectImage(cmd)
+	}
+	if opts.CPULimit == 0 {
+		opts.CPULimit = 0.5
+	}
+	if opts.MemoryLimit == 0 {
+		opts.MemoryLimit = 256 * 1024 * 1024 // 256MB
+	}
+	if opts.PidsLimit == 0 {
+		opts.PidsLimit = 64
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+	if opts.WorkingDir == "" {
+		opts.WorkingDir = "/workspace"
+	}
+	if opts.Security == nil {
+		opts.Security = DefaultSecurityProfile()
+	}
+}
+
+// teeWriter returns a writer that writes to buf and, if w is not nil, to w
+func teeWriter(buf io.Writer, w io.Writer) io.Writer {
+	if w == nil {
+		return buf
+	}
+	return io.MultiWriter(buf, w)
+}
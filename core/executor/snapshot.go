@@ -1,10 +1,15 @@
 package executor
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
@@ -17,8 +22,25 @@ type SnapshotMetadata struct {
 	AffectedPaths []string
 	Reversible  bool
 	RestoreCmd  string // Command to restore the snapshot
+	WorkingDir  string // Directory the snapshot was taken in
+	Branch      string // Git branch checked out when the snapshot was taken, if any
+	
+	// Filesystem snapshots store file contents once, by SHA-256, in a
+	// content-addressed object store shared by all snapshots. Files maps
+	// each backed-up path to its content hash.
+	Files        map[string]string
+	StoredBytes  int64 // bytes newly written to the object store
+	DedupedBytes int64 // bytes skipped because the content was already stored
+	
+	// Undo is set, with Type "undo", when the UndoEngine backed up the
+	// command instead: files removed or moved, git resets and force pushes,
+	// and kubectl deletes. It is restored with UndoEngine.Undo.
+	Undo *UndoRecord `json:",omitempty"`
 }
 
+// objectsDir is the content-addressed store under the backup directory
+const objectsDir = "objects"
+
 // Snapshotter creates pre-run snapshots for undo capability
 type Snapshotter struct {
 	backupDir string
@@ -56,10 +78,12 @@ func (s *Snapshotter) createGitSnapshot(workingDir string) (*SnapshotMetadata, e
 		return nil, fmt.Errorf("failed to create backup branch: %w\n%s", err, output)
 	}
 	
-	// Get current branch for restore command
-	currentBranch, err := getCurrentGitBranch(workingDir)
-	if err != nil {
-		currentBranch = "main"
+	// Restoring moves the branch back to the backup rather than leaving the
+	// user on the backup branch. A detached HEAD has no branch to move.
+	current, _ := gitOutput(workingDir, "symbolic-ref", "--short", "-q", "HEAD")
+	restoreCmd := fmt.Sprintf("git checkout %s", branchName)
+	if current != "" {
+		restoreCmd = fmt.Sprintf("git checkout %s && git reset --hard %s", current, branchName)
 	}
 	
 	return &SnapshotMetadata{
@@ -67,65 +91,133 @@ func (s *Snapshotter) createGitSnapshot(workingDir string) (*SnapshotMetadata, e
 		Location:   branchName,
 		Timestamp:  time.Now(),
 		Reversible: true,
-		RestoreCmd: fmt.Sprintf("git checkout %s && git branch -D %s", currentBranch, branchName),
+		RestoreCmd: restoreCmd,
+		WorkingDir: workingDir,
+		Branch:     current,
 	}, nil
 }
 
-// createFilesystemSnapshot creates a filesystem backup
+// createFilesystemSnapshot backs up the affected files into the
+// content-addressed object store and records a manifest of their hashes
 func (s *Snapshotter) createFilesystemSnapshot(workingDir string, affectedPaths []string) (*SnapshotMetadata, error) {
-	timestamp := time.Now().Format("20060102-150405")
-	snapshotID := fmt.Sprintf("snapshot-%s", timestamp)
-	backupPath := filepath.Join(s.backupDir, snapshotID)
-	
-	// Create backup directory
-	if err := os.MkdirAll(backupPath, 0755); err != nil {
+	objects := filepath.Join(s.backupDir, objectsDir)
+	if err := os.MkdirAll(objects, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create backup directory: %w", err)
 	}
 	
-	// Copy affected files
-	copiedPaths := []string{}
+	snapshot := &SnapshotMetadata{
+		Type:       "filesystem",
+		Timestamp:  time.Now(),
+		WorkingDir: workingDir,
+		Files:      make(map[string]string),
+	}
+	
 	for _, path := range affectedPaths {
 		fullPath := filepath.Join(workingDir, path)
 		
-		// Check if file exists
-		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+		// Only regular files are backed up
+		info, err := os.Stat(fullPath)
+		if err != nil || !info.Mode().IsRegular() {
 			continue
 		}
 		
-		// Create destination directory
-		destPath := filepath.Join(backupPath, path)
-		destDir := filepath.Dir(destPath)
-		if err := os.MkdirAll(destDir, 0755); err != nil {
+		hash, stored, err := storeObject(objects, fullPath)
+		if err != nil {
 			continue
 		}
 		
-		// Copy file
-		if err := copyFile(fullPath, destPath); err != nil {
-			continue
+		snapshot.Files[path] = hash
+		snapshot.AffectedPaths = append(snapshot.AffectedPaths, path)
+		if stored {
+			snapshot.StoredBytes += info.Size()
+		} else {
+			snapshot.DedupedBytes += info.Size()
 		}
-		
-		copiedPaths = append(copiedPaths, path)
 	}
 	
-	if len(copiedPaths) == 0 {
-		// No files backed up - remove directory
-		os.RemoveAll(backupPath)
-		return &SnapshotMetadata{
-			Type:       "filesystem",
-			Location:   "",
-			Timestamp:  time.Now(),
-			Reversible: false,
-		}, nil
+	if len(snapshot.Files) == 0 {
+		// No files backed up
+		snapshot.Files = nil
+		return snapshot, nil
 	}
 	
-	return &SnapshotMetadata{
-		Type:          "filesystem",
-		Location:      backupPath,
-		Timestamp:     time.Now(),
-		AffectedPaths: copiedPaths,
-		Reversible:    true,
-		RestoreCmd:    fmt.Sprintf("cp -r %s/* %s/", backupPath, workingDir),
-	}, nil
+	// The manifest keeps the snapshot's objects alive during cleanup
+	manifest := filepath.Join(s.backupDir, fmt.Sprintf("snapshot-%s.json", snapshot.Timestamp.Format("20060102-150405.000000")))
+	data, err := json.Marshal(snapshot.Files)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(manifest, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot manifest: %w", err)
+	}
+	
+	snapshot.Location = objects
+	snapshot.Reversible = true
+	snapshot.RestoreCmd = filesystemRestoreCmd(snapshot)
+	return snapshot, nil
+}
+
+// storeObject copies the file at path into the object store, named by the
+// SHA-256 of its content. It reports whether the content was new.
+func storeObject(objects, path string) (string, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", false, err
+	}
+	defer file.Close()
+	
+	// Hash while copying to a temp file, so the file is only read once
+	tmp, err := os.CreateTemp(objects, ".tmp-")
+	if err != nil {
+		return "", false, err
+	}
+	defer os.Remove(tmp.Name())
+	
+	hasher := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hasher), file); err != nil {
+		tmp.Close()
+		return "", false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", false, err
+	}
+	
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	objectPath := objectPath(objects, hash)
+	if _, err := os.Stat(objectPath); err == nil {
+		return hash, false, nil
+	}
+	
+	if err := os.MkdirAll(filepath.Dir(objectPath), 0755); err != nil {
+		return "", false, err
+	}
+	if err := os.Rename(tmp.Name(), objectPath); err != nil {
+		return "", false, err
+	}
+	return hash, true, nil
+}
+
+// objectPath returns where content with the given hash is stored
+func objectPath(objects, hash string) string {
+	return filepath.Join(objects, hash[:2], hash)
+}
+
+// filesystemRestoreCmd returns a shell command that copies a content-
+// addressed snapshot's files back into place
+func filesystemRestoreCmd(snapshot *SnapshotMetadata) string {
+	paths := make([]string, 0, len(snapshot.Files))
+	for path := range snapshot.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	
+	cmds := make([]string, 0, len(paths))
+	for _, path := range paths {
+		cmds = append(cmds, fmt.Sprintf("cp %s %s",
+			objectPath(snapshot.Location, snapshot.Files[path]),
+			filepath.Join(snapshot.WorkingDir, path)))
+	}
+	return strings.Join(cmds, " && ")
 }
 
 // RestoreSnapshot restores a snapshot
@@ -144,19 +236,43 @@ func (s *Snapshotter) RestoreSnapshot(metadata *SnapshotMetadata, workingDir str
 	}
 }
 
-// restoreGitSnapshot restores a Git snapshot
+// restoreGitSnapshot restores a Git snapshot. The branch it was taken on is
+// checked out and reset to the backup branch; snapshots taken on a detached
+// HEAD check out the backup branch itself.
 func (s *Snapshotter) restoreGitSnapshot(metadata *SnapshotMetadata, workingDir string) error {
-	// Checkout the backup branch
-	cmd := exec.Command("git", "checkout", metadata.Location)
-	cmd.Dir = workingDir
+	for _, branch := range []string{metadata.Location, metadata.Branch} {
+		if branch != "" && !gitBranchPattern.MatchString(branch) {
+			return fmt.Errorf("refusing to restore unrecognized branch: %q", branch)
+		}
+	}
 	
-	if output, err := cmd.CombinedOutput(); err != nil {
+	if metadata.Branch == "" {
+		if output, err := gitCombinedOutput(workingDir, "checkout", metadata.Location); err != nil {
+			return fmt.Errorf("failed to restore Git snapshot: %w\n%s", err, output)
+		}
+		return nil
+	}
+	
+	current, _ := gitOutput(workingDir, "symbolic-ref", "--short", "-q", "HEAD")
+	if current != metadata.Branch {
+		if output, err := gitCombinedOutput(workingDir, "checkout", "-q", metadata.Branch); err != nil {
+			return fmt.Errorf("failed to check out %s: %w\n%s", metadata.Branch, err, output)
+		}
+	}
+	if output, err := gitCombinedOutput(workingDir, "reset", "--hard", metadata.Location); err != nil {
 		return fmt.Errorf("failed to restore Git snapshot: %w\n%s", err, output)
 	}
 	
 	return nil
 }
 
+// gitCombinedOutput runs git in dir and returns its combined output
+func gitCombinedOutput(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	return cmd.CombinedOutput()
+}
+
 // restoreFilesystemSnapshot restores a filesystem snapshot
 func (s *Snapshotter) restoreFilesystemSnapshot(metadata *SnapshotMetadata, workingDir string) error {
 	if metadata.Location == "" {
@@ -166,8 +282,14 @@ func (s *Snapshotter) restoreFilesystemSnapshot(metadata *SnapshotMetadata, work
 	// Copy files back
 	for _, path := range metadata.AffectedPaths {
 		srcPath := filepath.Join(metadata.Location, path)
+		if hash, ok := metadata.Files[path]; ok {
+			srcPath = objectPath(metadata.Location, hash)
+		}
 		destPath := filepath.Join(workingDir, path)
 		
+		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", path, err)
+		}
 		if err := copyFile(srcPath, destPath); err != nil {
 			return fmt.Errorf("failed to restore %s: %w", path, err)
 		}
@@ -176,7 +298,8 @@ func (s *Snapshotter) restoreFilesystemSnapshot(metadata *SnapshotMetadata, work
 	return nil
 }
 
-// CleanupOldSnapshots removes snapshots older than the specified duration
+// CleanupOldSnapshots removes snapshots older than the specified duration,
+// then deletes stored objects no remaining snapshot references
 func (s *Snapshotter) CleanupOldSnapshots(maxAge time.Duration) error {
 	if _, err := os.Stat(s.backupDir); os.IsNotExist(err) {
 		return nil // No backups directory
@@ -189,8 +312,9 @@ func (s *Snapshotter) CleanupOldSnapshots(maxAge time.Duration) error {
 	
 	cutoff := time.Now().Add(-maxAge)
 	
+	referenced := make(map[string]bool)
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		if entry.Name() == objectsDir {
 			continue
 		}
 		
@@ -199,12 +323,68 @@ func (s *Snapshotter) CleanupOldSnapshots(maxAge time.Duration) error {
 			continue
 		}
 		
+		path := filepath.Join(s.backupDir, entry.Name())
 		if info.ModTime().Before(cutoff) {
-			path := filepath.Join(s.backupDir, entry.Name())
 			os.RemoveAll(path)
+			continue
+		}
+		
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			if err := readManifest(path, referenced); err != nil {
+				// Keep every object rather than lose a snapshot's data
+				return nil
+			}
 		}
 	}
 	
+	return removeUnreferencedObjects(filepath.Join(s.backupDir, objectsDir), referenced)
+}
+
+// readManifest adds the hashes listed in a snapshot manifest to referenced
+func readManifest(path string, referenced map[string]bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	
+	var files map[string]string
+	if err := json.Unmarshal(data, &files); err != nil {
+		return err
+	}
+	for _, hash := range files {
+		referenced[hash] = true
+	}
+	return nil
+}
+
+// removeUnreferencedObjects deletes objects whose hash isn't referenced
+func removeUnreferencedObjects(objects string, referenced map[string]bool) error {
+	prefixes, err := os.ReadDir(objects)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	
+	for _, prefix := range prefixes {
+		if !prefix.IsDir() {
+			continue
+		}
+		
+		dir := filepath.Join(objects, prefix.Name())
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !referenced[entry.Name()] {
+				os.Remove(filepath.Join(dir, entry.Name()))
+			}
+		}
+		os.Remove(dir) // only succeeds once empty
+	}
+	
 	return nil
 }
 
@@ -216,18 +396,6 @@ func isGitRepo(dir string) bool {
 	return err == nil && info.IsDir()
 }
 
-func getCurrentGitBranch(dir string) (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	cmd.Dir = dir
-	
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-	
-	return strings.TrimSpace(string(output)), nil
-}
-
 func copyFile(src, dst string) error {
 	sourceData, err := os.ReadFile(src)
 	if err != nil {
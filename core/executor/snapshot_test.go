@@ -0,0 +1,142 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countObjects returns the number of objects in the snapshot store
+func countObjects(t *testing.T, backupDir string) int {
+	t.Helper()
+	count := 0
+	filepath.Walk(filepath.Join(backupDir, objectsDir), func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() && !strings.HasPrefix(info.Name(), ".tmp-") {
+			count++
+		}
+		return nil
+	})
+	return count
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFilesystemSnapshot_Dedup(t *testing.T) {
+	backupDir := t.TempDir()
+	s := &Snapshotter{backupDir: backupDir}
+
+	workingDir := t.TempDir()
+	content := strings.Repeat("config line\n", 100)
+	writeFiles(t, workingDir, map[string]string{
+		"app.conf":      content,
+		"copy/app.conf": content,
+		"other.txt":     "other",
+	})
+
+	first, err := s.CreateSnapshot(workingDir, []string{"app.conf", "copy/app.conf", "other.txt", "missing.txt"})
+	if err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+	if !first.Reversible || len(first.Files) != 3 {
+		t.Fatalf("first snapshot = %+v, want 3 reversible files", first)
+	}
+	if first.Files["app.conf"] != first.Files["copy/app.conf"] {
+		t.Error("identical files should have the same hash")
+	}
+	size := int64(len(content))
+	if first.StoredBytes != size+5 || first.DedupedBytes != size {
+		t.Errorf("first snapshot stored %d, deduped %d; want %d, %d", first.StoredBytes, first.DedupedBytes, size+5, size)
+	}
+
+	// A second snapshot of unchanged files stores nothing new
+	second, err := s.CreateSnapshot(workingDir, []string{"app.conf", "other.txt"})
+	if err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+	if second.StoredBytes != 0 || second.DedupedBytes != size+5 {
+		t.Errorf("second snapshot stored %d, deduped %d; want 0, %d", second.StoredBytes, second.DedupedBytes, size+5)
+	}
+	if second.Files["app.conf"] != first.Files["app.conf"] {
+		t.Error("snapshots of identical content should share a hash")
+	}
+	if got := countObjects(t, backupDir); got != 2 {
+		t.Errorf("object store holds %d objects, want 2", got)
+	}
+
+	// Restore rebuilds the files from their hashes
+	os.Remove(filepath.Join(workingDir, "app.conf"))
+	os.RemoveAll(filepath.Join(workingDir, "copy"))
+	if err := s.RestoreSnapshot(first, workingDir); err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+	for _, name := range []string{"app.conf", "copy/app.conf"} {
+		data, err := os.ReadFile(filepath.Join(workingDir, name))
+		if err != nil || string(data) != content {
+			t.Errorf("%s not restored: %v", name, err)
+		}
+	}
+}
+
+func TestFilesystemSnapshot_NothingToBackUp(t *testing.T) {
+	s := &Snapshotter{backupDir: t.TempDir()}
+
+	snapshot, err := s.CreateSnapshot(t.TempDir(), []string{"missing.txt"})
+	if err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+	if snapshot.Reversible || snapshot.Location != "" {
+		t.Errorf("snapshot = %+v, want irreversible snapshot without a location", snapshot)
+	}
+}
+
+func TestCleanupOldSnapshots_KeepsSharedObjects(t *testing.T) {
+	backupDir := t.TempDir()
+	s := &Snapshotter{backupDir: backupDir}
+
+	workingDir := t.TempDir()
+	writeFiles(t, workingDir, map[string]string{"shared.txt": "shared", "old.txt": "old only"})
+
+	old, err := s.CreateSnapshot(workingDir, []string{"shared.txt", "old.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Age the first snapshot's manifest
+	manifests, _ := filepath.Glob(filepath.Join(backupDir, "snapshot-*.json"))
+	if len(manifests) != 1 {
+		t.Fatalf("manifests = %v, want 1", manifests)
+	}
+	past := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(manifests[0], past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	recent, err := s.CreateSnapshot(workingDir, []string{"shared.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.CleanupOldSnapshots(24 * time.Hour); err != nil {
+		t.Fatalf("CleanupOldSnapshots() error = %v", err)
+	}
+
+	if _, err := os.Stat(objectPath(recent.Location, recent.Files["shared.txt"])); err != nil {
+		t.Errorf("object still referenced by a recent snapshot was removed: %v", err)
+	}
+	if _, err := os.Stat(objectPath(old.Location, old.Files["old.txt"])); !os.IsNotExist(err) {
+		t.Errorf("object only referenced by the expired snapshot was kept: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// statsTimeout bounds how long a runner waits for a container's stats
+// stream to end after the container exits
+const statsTimeout = 2 * time.Second
+
+// containerStats is the part of a Docker stats frame that resource usage
+// is read from
+type containerStats struct {
+	CPUStats struct {
+		CPUUsage struct {
+			TotalUsage uint64 `json:"total_usage"` // nanoseconds
+		} `json:"cpu_usage"`
+	} `json:"cpu_stats"`
+	MemoryStats struct {
+		Usage    uint64 `json:"usage"`
+		MaxUsage uint64 `json:"max_usage"` // cgroup v1 only
+	} `json:"memory_stats"`
+}
+
+// resourceUsage is what a stats stream reports for a container
+type resourceUsage struct {
+	MaxMemoryBytes int64
+	CPUTimeMs      int64
+}
+
+// readResourceUsage reads a stream of stats frames until it ends, keeping the
+// peak memory and the latest CPU time. Frames of a stopped container are
+// zero, so only non-zero readings count.
+func readResourceUsage(r io.Reader) resourceUsage {
+	var usage resourceUsage
+	decoder := json.NewDecoder(r)
+	for {
+		var stats containerStats
+		if err := decoder.Decode(&stats); err != nil {
+			return usage
+		}
+
+		memory := stats.MemoryStats.Usage
+		if stats.MemoryStats.MaxUsage > memory {
+			memory = stats.MemoryStats.MaxUsage
+		}
+		if int64(memory) > usage.MaxMemoryBytes {
+			usage.MaxMemoryBytes = int64(memory)
+		}
+
+		cpu := time.Duration(stats.CPUStats.CPUUsage.TotalUsage).Milliseconds()
+		if cpu > usage.CPUTimeMs {
+			usage.CPUTimeMs = cpu
+		}
+	}
+}
+
+// ResourceUsage describes the peak memory and CPU time the command used, or
+// returns "" if the runner couldn't measure them
+func (r *SandboxResult) ResourceUsage() string {
+	if r.MaxMemoryBytes == 0 && r.CPUTimeMs == 0 {
+		return ""
+	}
+	return fmt.Sprintf("peak memory: %s, cpu time: %v", formatBytes(r.MaxMemoryBytes),
+		time.Duration(r.CPUTimeMs)*time.Millisecond)
+}
+
+// formatBytes formats n bytes with a binary unit, e.g. 1.5 MiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
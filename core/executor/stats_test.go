@@ -0,0 +1,33 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadResourceUsage(t *testing.T) {
+	stream := `{"cpu_stats":{"cpu_usage":{"total_usage":15000000}},"memory_stats":{"usage":4194304}}
+{"cpu_stats":{"cpu_usage":{"total_usage":120000000}},"memory_stats":{"usage":20971520}}
+{"cpu_stats":{"cpu_usage":{"total_usage":130000000}},"memory_stats":{"usage":8388608,"max_usage":25165824}}
+{"cpu_stats":{},"memory_stats":{}}
+`
+	usage := readResourceUsage(strings.NewReader(stream))
+	if usage.MaxMemoryBytes != 25165824 || usage.CPUTimeMs != 130 {
+		t.Errorf("readResourceUsage() = %+v, want 24 MiB peak and 130ms CPU", usage)
+	}
+
+	if usage := readResourceUsage(strings.NewReader("")); usage != (resourceUsage{}) {
+		t.Errorf("readResourceUsage() of an empty stream = %+v, want zero", usage)
+	}
+}
+
+func TestSandboxResult_ResourceUsage(t *testing.T) {
+	result := &SandboxResult{MaxMemoryBytes: 25165824, CPUTimeMs: 1500}
+	if got, want := result.ResourceUsage(), "peak memory: 24.0 MiB, cpu time: 1.5s"; got != want {
+		t.Errorf("ResourceUsage() = %q, want %q", got, want)
+	}
+
+	if got := (&SandboxResult{}).ResourceUsage(); got != "" {
+		t.Errorf("ResourceUsage() without stats = %q, want empty", got)
+	}
+}
@@ -6,14 +6,20 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
+	
+	"github.com/SagheerAkram/QuickCmd/core/cmdparse"
+	"gopkg.in/yaml.v3"
 )
 
 // UndoEngine manages undo/rollback functionality
 type UndoEngine struct {
 	backupDir string
+	backend    BackupBackend
 	strategies map[string]UndoStrategy
 }
 
@@ -34,21 +40,32 @@ type UndoRecord struct {
 	CanUndo         bool
 	Strategy        string
 	AffectedPaths   []string
+	WorkingDir      string // directory the command ran in; UndoCommand runs here too
+	GitBranch       string // branch checked out when a git command ran, if any
+	KubectlArgs     []string // --context and --kubeconfig a kubectl undo applies with
 	CreatedAt       time.Time
 	ExpiresAt       time.Time
 }
 
-// NewUndoEngine creates a new undo engine
+// NewUndoEngine creates a new undo engine that keeps backups in backupDir
 func NewUndoEngine(backupDir string) *UndoEngine {
+	return NewUndoEngineWithBackend(backupDir, NewLocalBackend(backupDir))
+}
+
+// NewUndoEngineWithBackend creates an undo engine that stores filesystem
+// backups in backend. Kubernetes manifests are still kept in backupDir, since
+// their undo command applies a local file.
+func NewUndoEngineWithBackend(backupDir string, backend BackupBackend) *UndoEngine {
 	ue := &UndoEngine{
 		backupDir:  backupDir,
+		backend:    backend,
 		strategies: make(map[string]UndoStrategy),
 	}
 	
 	// Register strategies
-	ue.strategies["file"] = &FileUndoStrategy{backupDir: backupDir}
+	ue.strategies["file"] = &FileUndoStrategy{backend: backend}
 	ue.strategies["git"] = &GitUndoStrategy{}
-	ue.strategies["kubectl"] = &KubectlUndoStrategy{}
+	ue.strategies["kubectl"] = &KubectlUndoStrategy{backupDir: backupDir, kubectl: "kubectl"}
 	
 	return ue
 }
@@ -105,9 +122,19 @@ func (ue *UndoEngine) Undo(record *UndoRecord) error {
 	return strategy.Undo(record)
 }
 
-// FileUndoStrategy handles file operation undos
+// DeleteBackup removes a filesystem backup from the backend, e.g. once the
+// record has expired
+func (ue *UndoEngine) DeleteBackup(record *UndoRecord) error {
+	if record.Strategy != "file" || record.BackupLocation == "" {
+		return nil
+	}
+	return ue.backend.Delete(record.BackupLocation)
+}
+
+// FileUndoStrategy handles file operation undos by storing a tar.gz of the
+// affected paths in a BackupBackend
 type FileUndoStrategy struct {
-	backupDir string
+	backend BackupBackend
 }
 
 func (s *FileUndoStrategy) CanUndo(command string) bool {
@@ -115,38 +142,87 @@ func (s *FileUndoStrategy) CanUndo(command string) bool {
 }
 
 func (s *FileUndoStrategy) CreateBackup(command string, affectedPaths []string) (*UndoRecord, error) {
-	if len(affectedPaths) == 0 {
+	// The backup is restored at /, so it holds absolute paths. As with
+	// filesystem snapshots, only regular files are backed up.
+	var paths []string
+	for _, path := range affectedPaths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(abs); err == nil && info.Mode().IsRegular() {
+			paths = append(paths, abs)
+		}
+	}
+	if len(paths) == 0 {
 		return &UndoRecord{CanUndo: false}, nil
 	}
 	
-	// Create backup tar.gz
-	timestamp := time.Now().Format("20060102-150405")
-	backupFile := filepath.Join(s.backupDir, fmt.Sprintf("backup-%s.tar.gz", timestamp))
+	timestamp := time.Now().Format("20060102-150405.000000")
+	key := fmt.Sprintf("backup-%s.tar.gz", timestamp)
 	
-	// Ensure backup directory exists
-	os.MkdirAll(s.backupDir, 0755)
+	// Stream the tar.gz to the backend as it is written
+	pr, pw := io.Pipe()
+	counter := &countingWriter{w: pw}
+	go func() {
+		pw.CloseWithError(writeTarGz(counter, paths))
+	}()
 	
-	// Create tar.gz
-	size, err := createTarGz(backupFile, affectedPaths)
+	location, err := s.backend.Put(key, pr)
+	pr.Close()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create backup: %w", err)
 	}
 	
 	return &UndoRecord{
 		CanUndo:        true,
-		BackupLocation: backupFile,
-		BackupSize:     size,
-		AffectedPaths:  affectedPaths,
-		UndoCommand:    fmt.Sprintf("tar -xzf %s -C /", backupFile),
+		BackupLocation: location,
+		BackupSize:     counter.n,
+		AffectedPaths:  paths,
+		UndoCommand:    restoreCommand(location),
 	}, nil
 }
 
 func (s *FileUndoStrategy) Undo(record *UndoRecord) error {
-	// Extract backup
-	return extractTarGz(record.BackupLocation, "/")
+	backup, err := s.backend.Get(record.BackupLocation)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	
+	if err := extractTarGz(backup, "/"); err != nil {
+		backup.Close()
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+	return backup.Close()
 }
 
-// GitUndoStrategy handles git operation undos
+// restoreCommand returns a shell command that restores the backup at
+// location by hand
+func restoreCommand(location string) string {
+	if strings.HasPrefix(location, "s3://") {
+		return fmt.Sprintf("aws s3 cp %s - | tar -xzf - -C /", location)
+	}
+	if path, err := localPath(location); err == nil {
+		return fmt.Sprintf("tar -xzf %s -C /", path)
+	}
+	return ""
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// GitUndoStrategy handles git operation undos. Before the command runs it
+// records where the repository was: HEAD for a reset, and the pushed
+// branch's remote-tracking ref for a force push.
 type GitUndoStrategy struct{}
 
 func (s *GitUndoStrategy) CanUndo(command string) bool {
@@ -155,75 +231,373 @@ func (s *GitUndoStrategy) CanUndo(command string) bool {
 }
 
 func (s *GitUndoStrategy) CreateBackup(command string, affectedPaths []string) (*UndoRecord, error) {
-	// For git operations, save reflog state
-	timestamp := time.Now().Format("20060102-150405")
-	backupBranch := fmt.Sprintf("backup-%s", timestamp)
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	
+	// Empty when HEAD is detached
+	branch, _ := gitOutput(workingDir, "symbolic-ref", "--short", "-q", "HEAD")
+	record := &UndoRecord{
+		CanUndo:    true,
+		WorkingDir: workingDir,
+		GitBranch:  branch,
+	}
 	
-	undoCmd := ""
 	if strings.Contains(command, "git reset") {
-		undoCmd = "git reset --hard ORIG_HEAD"
-	} else if strings.Contains(command, "git push --force") {
-		undoCmd = fmt.Sprintf("git push --force origin %s:main", backupBranch)
+		head, err := gitOutput(workingDir, "rev-parse", "--verify", "HEAD")
+		if err != nil {
+			return nil, fmt.Errorf("failed to record HEAD: %w", err)
+		}
+		record.UndoCommand = "git reset --hard " + head
+		return record, nil
 	}
 	
-	return &UndoRecord{
-		CanUndo:     true,
-		UndoCommand: undoCmd,
-	}, nil
+	remote, target := pushTarget(command, branch)
+	if target == "" {
+		return nil, fmt.Errorf("can't tell which branch %q pushes", command)
+	}
+	previous, err := gitOutput(workingDir, "rev-parse", "--verify", "refs/remotes/"+remote+"/"+target)
+	if err != nil {
+		return nil, fmt.Errorf("no remote-tracking branch %s/%s to restore: %w", remote, target, err)
+	}
+	record.UndoCommand = fmt.Sprintf("git push --force %s %s:refs/heads/%s", remote, previous, target)
+	
+	return record, nil
+}
+
+// pushTarget returns the remote and branch a git push updates: those given
+// in command, or origin and currentBranch
+func pushTarget(command, currentBranch string) (remote, branch string) {
+	c := cmdparse.Parse(command)
+	remote, branch = "origin", currentBranch
+	if len(c.Args) > 0 {
+		remote = c.Args[0]
+	}
+	if len(c.Args) > 1 {
+		ref := c.Args[1]
+		if i := strings.LastIndex(ref, ":"); i >= 0 {
+			ref = ref[i+1:]
+		}
+		ref = strings.TrimPrefix(strings.TrimPrefix(ref, "+"), "refs/heads/")
+		if ref != "HEAD" {
+			branch = ref
+		}
+	}
+	return remote, branch
+}
+
+// gitOutput runs git in dir and returns its trimmed output
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// gitUndoCommands are the undo commands GitUndoStrategy.CreateBackup
+// produces. Undo refuses anything else, so a tampered record can't run
+// arbitrary commands.
+var gitUndoCommands = []*regexp.Regexp{
+	regexp.MustCompile(`^git reset --hard [0-9a-f]{40,64}$`),
+	regexp.MustCompile(`^git push --force [A-Za-z0-9_][A-Za-z0-9._-]* [0-9a-f]{40,64}:refs/heads/[A-Za-z0-9_][A-Za-z0-9._/-]*$`),
 }
 
+// gitBranchPattern matches the branch names Undo will check out
+var gitBranchPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._/-]*$`)
+
 func (s *GitUndoStrategy) Undo(record *UndoRecord) error {
-	// Execute undo command
-	// TODO: Implement command execution
+	if !isGitUndoCommand(record.UndoCommand) {
+		return fmt.Errorf("refusing to run unrecognized undo command: %q", record.UndoCommand)
+	}
+	
+	// A reset is undone on the branch it moved
+	if strings.HasPrefix(record.UndoCommand, "git reset") && record.GitBranch != "" {
+		if !gitBranchPattern.MatchString(record.GitBranch) {
+			return fmt.Errorf("refusing to check out unrecognized branch: %q", record.GitBranch)
+		}
+		current, _ := gitOutput(record.WorkingDir, "symbolic-ref", "--short", "-q", "HEAD")
+		if current != record.GitBranch {
+			if _, err := gitOutput(record.WorkingDir, "checkout", "-q", record.GitBranch); err != nil {
+				return fmt.Errorf("failed to check out %s: %w", record.GitBranch, err)
+			}
+		}
+	}
+	
+	// Run git directly rather than through a shell
+	args := strings.Fields(record.UndoCommand)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = record.WorkingDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to run %q: %w\n%s", record.UndoCommand, err, output)
+	}
+	
 	return nil
 }
 
-// KubectlUndoStrategy handles kubectl operation undos
-type KubectlUndoStrategy struct{}
+func isGitUndoCommand(command string) bool {
+	for _, pattern := range gitUndoCommands {
+		if pattern.MatchString(command) {
+			return true
+		}
+	}
+	return false
+}
+
+// KubectlUndoStrategy undoes `kubectl delete TYPE NAME` by saving the
+// resource's manifest before deletion and re-applying it
+type KubectlUndoStrategy struct {
+	backupDir string
+	kubectl   string // kubectl executable
+}
+
+// kubectlRuntimeFields are metadata fields set by the API server. They are
+// stripped from backups so the manifest can be applied to recreate the
+// resource.
+var kubectlRuntimeFields = []string{
+	"resourceVersion", "uid", "creationTimestamp", "generation",
+	"managedFields", "selfLink", "deletionTimestamp", "deletionGracePeriodSeconds",
+}
+
+// kubectlTarget is the resource a kubectl delete command removes
+type kubectlTarget struct {
+	Kind       string
+	Name       string
+	Namespace  string
+	Context    string
+	Kubeconfig string
+}
+
+// kubectlLocationFlags are the flags selecting the cluster and namespace a
+// resource lives in
+var kubectlLocationFlags = map[string]bool{
+	"-n": true, "--namespace": true, "--context": true, "--kubeconfig": true,
+}
+
+// setFlag records the value of one of kubectlLocationFlags
+func (t *kubectlTarget) setFlag(name, value string) {
+	switch name {
+	case "-n", "--namespace":
+		t.Namespace = value
+	case "--context":
+		t.Context = value
+	case "--kubeconfig":
+		t.Kubeconfig = value
+	}
+}
+
+// clusterArgs returns the --context and --kubeconfig flags reaching the
+// cluster the resource was deleted from
+func (t *kubectlTarget) clusterArgs() []string {
+	var args []string
+	if t.Context != "" {
+		args = append(args, "--context", t.Context)
+	}
+	if t.Kubeconfig != "" {
+		args = append(args, "--kubeconfig", t.Kubeconfig)
+	}
+	return args
+}
+
+// parseKubectlDelete parses `kubectl delete TYPE NAME [-n NS]` or
+// `kubectl delete TYPE/NAME [-n NS]`, with optional --context and
+// --kubeconfig before or after delete. Deletes by file, label selector,
+// --all or of several resources are not supported.
+func parseKubectlDelete(command string) (*kubectlTarget, error) {
+	fields := strings.Fields(command)
+	if len(fields) < 2 || fields[0] != "kubectl" {
+		return nil, fmt.Errorf("not a kubectl delete command")
+	}
+	
+	target := &kubectlTarget{}
+	var args []string
+	for i := 1; i < len(fields); i++ {
+		field := fields[i]
+		name, value, hasValue := strings.Cut(field, "=")
+		switch {
+		case kubectlLocationFlags[field]:
+			if i+1 >= len(fields) {
+				return nil, fmt.Errorf("%s requires a value", field)
+			}
+			i++
+			target.setFlag(field, fields[i])
+		case hasValue && strings.HasPrefix(name, "--") && kubectlLocationFlags[name]:
+			target.setFlag(name, value)
+		case field == "-f" || field == "--filename" || field == "-l" || field == "--selector" ||
+			field == "--all" || field == "-A" || field == "--all-namespaces" ||
+			strings.HasPrefix(field, "--filename=") || strings.HasPrefix(field, "--selector="):
+			return nil, fmt.Errorf("undo of kubectl delete %s is not supported", field)
+		case strings.HasPrefix(field, "-"):
+			// Flags like --now, --force and --grace-period=0 don't change the target
+		default:
+			args = append(args, field)
+		}
+	}
+	if len(args) == 0 || args[0] != "delete" {
+		return nil, fmt.Errorf("not a kubectl delete command")
+	}
+	args = args[1:]
+	
+	switch {
+	case len(args) == 1 && strings.Count(args[0], "/") == 1:
+		target.Kind, target.Name, _ = strings.Cut(args[0], "/")
+	case len(args) == 2 && !strings.Contains(args[0], "/"):
+		target.Kind, target.Name = args[0], args[1]
+	default:
+		return nil, fmt.Errorf("undo needs exactly one resource, got %q", strings.Join(args, " "))
+	}
+	if target.Kind == "" || target.Name == "" {
+		return nil, fmt.Errorf("undo needs exactly one resource, got %q", strings.Join(args, " "))
+	}
+	
+	return target, nil
+}
 
 func (s *KubectlUndoStrategy) CanUndo(command string) bool {
-	return strings.Contains(command, "kubectl delete")
+	_, err := parseKubectlDelete(command)
+	return err == nil
 }
 
 func (s *KubectlUndoStrategy) CreateBackup(command string, affectedPaths []string) (*UndoRecord, error) {
-	// Save YAML manifest before deletion
-	// TODO: Implement kubectl get -o yaml
+	target, err := parseKubectlDelete(command)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Save the manifest before deletion
+	args := []string{"get", target.Kind, target.Name, "-o", "yaml"}
+	if target.Namespace != "" {
+		args = append(args, "-n", target.Namespace)
+	}
+	args = append(args, target.clusterArgs()...)
+	cmd := exec.Command(s.kubectl, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s/%s: %w\n%s", target.Kind, target.Name, err, stderr.String())
+	}
+	
+	manifest, err := stripRuntimeFields(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s/%s manifest: %w", target.Kind, target.Name, err)
+	}
+	
+	if err := os.MkdirAll(s.backupDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	
+	timestamp := time.Now().Format("20060102-150405")
+	name := strings.NewReplacer("/", "_", ".", "_").Replace(fmt.Sprintf("%s-%s", target.Kind, target.Name))
+	backupFile := filepath.Join(s.backupDir, fmt.Sprintf("kubectl-%s-%s.yaml", name, timestamp))
+	if err := os.WriteFile(backupFile, manifest, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
 	
 	return &UndoRecord{
-		CanUndo:     true,
-		UndoCommand: "kubectl apply -f backup.yaml",
+		CanUndo:        true,
+		BackupLocation: backupFile,
+		BackupSize:     int64(len(manifest)),
+		UndoCommand:    strings.Join(append([]string{"kubectl", "apply", "-f", backupFile}, target.clusterArgs()...), " "),
+		KubectlArgs:    target.clusterArgs(),
 	}, nil
 }
 
 func (s *KubectlUndoStrategy) Undo(record *UndoRecord) error {
-	// Apply saved manifest
-	// TODO: Implement kubectl apply
+	if record.BackupLocation == "" {
+		return fmt.Errorf("no backup location")
+	}
+	
+	// Only apply manifests CreateBackup wrote, with the flags it records, so
+	// a tampered record can't apply arbitrary files or pass arbitrary flags
+	if !s.isBackupFile(record.BackupLocation) {
+		return fmt.Errorf("refusing to apply %q: not in the backup directory %s", record.BackupLocation, s.backupDir)
+	}
+	if !isKubectlClusterArgs(record.KubectlArgs) {
+		return fmt.Errorf("refusing to run kubectl with unrecognized arguments: %q", record.KubectlArgs)
+	}
+	
+	// Apply to the cluster the resource was deleted from
+	args := append([]string{"apply", "-f", record.BackupLocation}, record.KubectlArgs...)
+	cmd := exec.Command(s.kubectl, args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to apply %s: %w\n%s", record.BackupLocation, err, output)
+	}
+	
 	return nil
 }
 
-// Helper functions
-
-func createTarGz(filename string, paths []string) (int64, error) {
-	file, err := os.Create(filename)
+// isBackupFile reports whether path is a file directly in the backup
+// directory, where CreateBackup writes manifests
+func (s *KubectlUndoStrategy) isBackupFile(path string) bool {
+	dir, err := filepath.Abs(s.backupDir)
 	if err != nil {
-		return 0, err
+		return false
+	}
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	return filepath.Dir(path) == dir
+}
+
+// isKubectlClusterArgs reports whether args are only the --context and
+// --kubeconfig pairs clusterArgs produces
+func isKubectlClusterArgs(args []string) bool {
+	if len(args)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(args); i += 2 {
+		if args[i] != "--context" && args[i] != "--kubeconfig" {
+			return false
+		}
+		if args[i+1] == "" || strings.HasPrefix(args[i+1], "-") {
+			return false
+		}
+	}
+	return true
+}
+
+// stripRuntimeFields removes status and server-set metadata from a
+// resource manifest
+func stripRuntimeFields(manifest []byte) ([]byte, error) {
+	var resource map[string]interface{}
+	if err := yaml.Unmarshal(manifest, &resource); err != nil {
+		return nil, err
+	}
+	if resource == nil {
+		return nil, fmt.Errorf("empty manifest")
 	}
-	defer file.Close()
 	
-	gzWriter := gzip.NewWriter(file)
-	defer gzWriter.Close()
+	delete(resource, "status")
+	if metadata, ok := resource["metadata"].(map[string]interface{}); ok {
+		for _, field := range kubectlRuntimeFields {
+			delete(metadata, field)
+		}
+	}
 	
+	return yaml.Marshal(resource)
+}
+
+// Helper functions
+
+func writeTarGz(w io.Writer, paths []string) error {
+	gzWriter := gzip.NewWriter(w)
 	tarWriter := tar.NewWriter(gzWriter)
-	defer tarWriter.Close()
 	
 	for _, path := range paths {
 		if err := addToTar(tarWriter, path); err != nil {
-			return 0, err
+			return err
 		}
 	}
 	
-	info, _ := file.Stat()
-	return info.Size(), nil
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzWriter.Close()
 }
 
 func addToTar(tw *tar.Writer, path string) error {
@@ -257,14 +631,8 @@ func addToTar(tw *tar.Writer, path string) error {
 	return nil
 }
 
-func extractTarGz(filename, dest string) error {
-	file, err := os.Open(filename)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-	
-	gzReader, err := gzip.NewReader(file)
+func extractTarGz(r io.Reader, dest string) error {
+	gzReader, err := gzip.NewReader(r)
 	if err != nil {
 		return err
 	}
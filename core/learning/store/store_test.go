@@ -0,0 +1,92 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStore_NamespacesRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "learning.json")
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+
+	timings := map[string][]int64{"find": {120, 80}}
+	feedback := map[string]string{"alias": "rejected"}
+	if err := s.Namespace("timings").Put("history", timings); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if err := s.Namespace("suggestions").Put("history", feedback); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+
+	// Reopen to read back what was persisted
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() existing store error: %v", err)
+	}
+
+	var gotTimings map[string][]int64
+	if found, err := reopened.Namespace("timings").Get("history", &gotTimings); !found || err != nil {
+		t.Fatalf("Get() timings = %v, %v", found, err)
+	}
+	if !reflect.DeepEqual(gotTimings, timings) {
+		t.Errorf("timings = %v, want %v", gotTimings, timings)
+	}
+
+	// The same key in another namespace holds that namespace's value
+	var gotFeedback map[string]string
+	if found, err := reopened.Namespace("suggestions").Get("history", &gotFeedback); !found || err != nil {
+		t.Fatalf("Get() feedback = %v, %v", found, err)
+	}
+	if !reflect.DeepEqual(gotFeedback, feedback) {
+		t.Errorf("feedback = %v, want %v", gotFeedback, feedback)
+	}
+
+	if err := reopened.Namespace("suggestions").Delete("history"); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if keys := reopened.Namespace("suggestions").Keys(); len(keys) != 0 {
+		t.Errorf("Keys() after Delete() = %v, want none", keys)
+	}
+	if keys := reopened.Namespace("timings").Keys(); !reflect.DeepEqual(keys, []string{"history"}) {
+		t.Errorf("Delete() in one namespace changed another: Keys() = %v", keys)
+	}
+
+	if err := reopened.Namespace("timings").Clear(); err != nil {
+		t.Fatalf("Clear() error: %v", err)
+	}
+	if keys := reopened.Namespace("timings").Keys(); len(keys) != 0 {
+		t.Errorf("Keys() after Clear() = %v, want none", keys)
+	}
+
+	var missing map[string]string
+	if found, err := reopened.Namespace("unknown").Get("history", &missing); found || err != nil {
+		t.Errorf("Get() in unknown namespace = %v, %v, want not found", found, err)
+	}
+}
+
+func TestOpen_Versions(t *testing.T) {
+	dir := t.TempDir()
+
+	if s, err := Open(filepath.Join(dir, "missing.json")); err != nil || len(s.Namespace("timings").Keys()) != 0 {
+		t.Errorf("Open() missing file = %v, want an empty store", err)
+	}
+
+	newer := filepath.Join(dir, "newer.json")
+	os.WriteFile(newer, []byte(`{"version": 99, "namespaces": {}}`), 0600)
+	if _, err := Open(newer); err == nil || !strings.Contains(err.Error(), "version 99") {
+		t.Errorf("Open() newer version error = %v, want a version error", err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	os.WriteFile(corrupt, []byte("{"), 0600)
+	if _, err := Open(corrupt); err == nil {
+		t.Error("Open() accepted a corrupt file")
+	}
+}
@@ -0,0 +1,50 @@
+package plugins
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Config returns the settings for the named plugin, or nil if none were
+// supplied
+func (c Context) Config(plugin string) map[string]interface{} {
+	return c.PluginConfig[plugin]
+}
+
+// ConfigFloat returns a numeric setting for plugin. ok is false if the key is
+// missing or not a number.
+func (c Context) ConfigFloat(plugin, key string) (value float64, ok bool) {
+	switch v := c.Config(plugin)[key].(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// ConfigStrings returns a list setting for plugin. A single string is split
+// on commas. ok is false if the key is missing or not a list of strings.
+func (c Context) ConfigStrings(plugin, key string) (values []string, ok bool) {
+	switch v := c.Config(plugin)[key].(type) {
+	case []string:
+		return v, true
+	case []interface{}:
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values, true
+	case string:
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		return values, true
+	}
+	return nil, false
+}
@@ -0,0 +1,47 @@
+package plugins
+
+import "strings"
+
+// Environment is a deployment target selected with --env, such as dev or
+// prod. Plugins pin their candidates to it, e.g. the k8s plugin adds
+// --context and --namespace to kubectl commands. Empty fields leave the
+// tool's own default in place.
+type Environment struct {
+	Name        string
+	KubeContext string
+	Namespace   string
+	AWSProfile  string
+
+	// Production environments get stricter checks: candidates that change
+	// anything require approval
+	Production bool
+}
+
+// IsProduction reports whether ctx targets a production environment
+func (c Context) IsProduction() bool {
+	return c.Environment != nil && c.Environment.Production
+}
+
+// PinCommands inserts flags straight after executable in every command of
+// the candidate that runs it: Command, SimulateCommand and the breakdown
+// steps. For example, pinning "kubectl get pods" with "--context", "prod"
+// gives "kubectl --context prod get pods". Other commands are unchanged.
+func (c *Candidate) PinCommands(executable string, flags ...string) {
+	if len(flags) == 0 {
+		return
+	}
+
+	pin := func(command string) string {
+		rest, ok := strings.CutPrefix(command, executable+" ")
+		if !ok {
+			return command
+		}
+		return executable + " " + strings.Join(flags, " ") + " " + rest
+	}
+
+	c.Command = pin(c.Command)
+	c.SimulateCommand = pin(c.SimulateCommand)
+	for i := range c.Breakdown {
+		c.Breakdown[i].Command = pin(c.Breakdown[i].Command)
+	}
+}
@@ -1,11 +1,15 @@
 package plugins
 
 import (
-	"context"
 	"fmt"
+	"sort"
+	"strings"
+	
+	"github.com/SagheerAkram/QuickCmd/core/cmdparse"
 )
 
-// TranslateWithPlugins translates a prompt using both core templates and plugins
+// TranslateWithPlugins translates a prompt using both core templates and
+// plugins. It returns ctx.Err() if ctx.Ctx is cancelled between plugins.
 func TranslateWithPlugins(ctx Context, prompt string, coreCandidates []*Candidate) ([]*Candidate, error) {
 	// Execute pre-translate hooks
 	hookData := &TranslateHookData{
@@ -25,6 +29,10 @@ func TranslateWithPlugins(ctx Context, prompt string, coreCandidates []*Candidat
 	allCandidates = append(allCandidates, coreCandidates...)
 	
 	for _, plugin := range plugins {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		
 		candidates, err := plugin.Translate(ctx, prompt)
 		if err != nil {
 			// Log error but continue with other plugins
@@ -34,11 +42,18 @@ func TranslateWithPlugins(ctx Context, prompt string, coreCandidates []*Candidat
 		// Add plugin name to each candidate
 		for _, candidate := range candidates {
 			candidate.PluginName = plugin.Name()
+			if candidate.Category == "" {
+				candidate.Category = plugin.Name()
+			}
 		}
 		
 		allCandidates = append(allCandidates, candidates...)
 	}
 	
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	
 	// Execute post-translate hooks
 	hookData.Candidates = allCandidates
 	if err := DefaultRegistry().ExecuteHooks(HookPostTranslate, ctx, hookData); err != nil {
@@ -48,53 +63,126 @@ func TranslateWithPlugins(ctx Context, prompt string, coreCandidates []*Candidat
 	return allCandidates, nil
 }
 
-// PreRunCheckWithPlugins performs pre-run checks using plugins
+// PreRunCheckWithPlugins runs the PreRunCheck of the candidate's own plugin
+// and of the other enabled plugins that handle its command (see
+// CommandHandler), then combines the results with the most restrictive one
+// winning: any denial denies, any approval requirement requires approval,
+// and reasons, approval messages and additional checks are unioned. The
+// candidate's plugin runs first and the rest in name order, so the combined
+// messages are stable. A plugin whose check fails denies the command.
 func PreRunCheckWithPlugins(ctx Context, candidate *Candidate) (*CheckResult, error) {
-	// If candidate has a plugin, use that plugin's PreRunCheck
+	var owner Plugin
 	if candidate.PluginName != "" {
 		plugin, err := Get(candidate.PluginName)
 		if err != nil {
 			return nil, err
 		}
-		
-		return plugin.PreRunCheck(ctx, candidate)
+		owner = plugin
+	}
+	
+	checkers := make([]Plugin, 0, 2)
+	if owner != nil {
+		checkers = append(checkers, owner)
+	}
+	for _, plugin := range handlers(candidate.Command) {
+		if owner == nil || plugin.Name() != owner.Name() {
+			checkers = append(checkers, plugin)
+		}
 	}
 	
-	// Otherwise, run checks from all enabled plugins
 	result := &CheckResult{
 		Allowed:  true,
 		Metadata: make(map[string]interface{}),
 	}
 	
-	plugins := ListEnabled()
-	for _, plugin := range plugins {
+	for _, plugin := range checkers {
 		checkResult, err := plugin.PreRunCheck(ctx, candidate)
 		if err != nil {
+			checkResult = &CheckResult{
+				Allowed: false,
+				Reason:  fmt.Sprintf("%s plugin check failed: %v", plugin.Name(), err),
+			}
+		}
+		if checkResult == nil {
 			continue
 		}
 		
-		// If any plugin denies, deny overall
-		if !checkResult.Allowed {
-			result.Allowed = false
-			result.Reason = checkResult.Reason
-			return result, nil
+		mergeCheckResult(result, checkResult)
+	}
+	
+	return result, nil
+}
+
+// handlers returns the enabled plugins that handle command, in name order
+func handlers(command string) []Plugin {
+	var matched []Plugin
+	for _, plugin := range ListEnabled() {
+		if handler, ok := plugin.(CommandHandler); ok && handler.CanHandle(command) {
+			matched = append(matched, plugin)
 		}
-		
-		// Accumulate approval requirements
-		if checkResult.RequiresApproval {
-			result.RequiresApproval = true
-			if result.ApprovalMessage == "" {
-				result.ApprovalMessage = checkResult.ApprovalMessage
-			}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Name() < matched[j].Name() })
+	return matched
+}
+
+// RunsExecutable reports whether any simple command of command runs
+// executable, e.g. "git" in "make && git push". Plugins use it to
+// implement CommandHandler.
+func RunsExecutable(command, executable string) bool {
+	for _, c := range cmdparse.Commands(command) {
+		if c.Name == executable {
+			return true
 		}
-		
-		// Merge metadata
-		for k, v := range checkResult.Metadata {
-			result.Metadata[k] = v
+	}
+	return false
+}
+
+// mergeCheckResult folds next into result with most-restrictive-wins
+// semantics
+func mergeCheckResult(result, next *CheckResult) {
+	if !next.Allowed {
+		result.Allowed = false
+		result.Reason = joinUnique(result.Reason, next.Reason)
+	}
+	
+	if next.RequiresApproval {
+		result.RequiresApproval = true
+		result.ApprovalMessage = joinUnique(result.ApprovalMessage, next.ApprovalMessage)
+	}
+	
+	for _, check := range next.AdditionalChecks {
+		if !containsString(result.AdditionalChecks, check) {
+			result.AdditionalChecks = append(result.AdditionalChecks, check)
 		}
 	}
 	
-	return result, nil
+	for k, v := range next.Metadata {
+		result.Metadata[k] = v
+	}
+}
+
+// joinUnique appends msg to a "; "-separated list unless it is empty or
+// already present
+func joinUnique(list, msg string) string {
+	if msg == "" {
+		return list
+	}
+	if list == "" {
+		return msg
+	}
+	if containsString(strings.Split(list, "; "), msg) {
+		return list
+	}
+	return list + "; " + msg
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
 }
 
 // ExecutePreExecutionHooks runs pre-execution hooks
@@ -129,24 +217,33 @@ func AugmentAuditMetadata(ctx Context, candidate *Candidate, metadata map[string
 	return DefaultRegistry().ExecuteHooks(HookAuditMetadata, ctx, hookData)
 }
 
-// CheckApprovalRequired checks if any plugin requires approval for a candidate
-func CheckApprovalRequired(candidate *Candidate) bool {
+// CheckApprovalRequired checks if the candidate's plugin, or for a candidate
+// no plugin produced any plugin handling its command, requires approval
+func CheckApprovalRequired(ctx Context, candidate *Candidate) bool {
 	if candidate.PluginName != "" {
 		plugin, err := Get(candidate.PluginName)
 		if err != nil {
 			return false
 		}
 		
-		return plugin.RequiresApproval(candidate)
+		return requiresApproval(plugin, ctx, candidate)
 	}
 	
-	// Check all enabled plugins
-	plugins := ListEnabled()
-	for _, plugin := range plugins {
-		if plugin.RequiresApproval(candidate) {
+	// Check the plugins that handle the command
+	for _, plugin := range handlers(candidate.Command) {
+		if requiresApproval(plugin, ctx, candidate) {
 			return true
 		}
 	}
 	
 	return false
 }
+
+// requiresApproval asks plugin whether candidate needs approval, passing ctx
+// to plugins that implement ContextApprover
+func requiresApproval(plugin Plugin, ctx Context, candidate *Candidate) bool {
+	if approver, ok := plugin.(ContextApprover); ok {
+		return approver.RequiresApprovalWithContext(ctx, candidate)
+	}
+	return plugin.RequiresApproval(candidate)
+}
@@ -1,9 +1,34 @@
 package plugins
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
+var (
+	// ErrDynamicPluginsUnsupported is returned by LoadFromDir when the binary
+	// was built without the dynamicplugins build tag or for a platform
+	// without Go plugin support
+	ErrDynamicPluginsUnsupported = errors.New("external plugins are not supported by this build")
+
+	// ErrIncompatiblePlugin is returned for a plugin file that does not export
+	// a usable Plugin symbol
+	ErrIncompatiblePlugin = errors.New("incompatible plugin")
+)
+
+// ExternalPlugin is implemented by the Plugin symbol exported from a plugin
+// .so file loaded with LoadFromDir
+type ExternalPlugin interface {
+	Plugin
+	
+	// Metadata describes the plugin for the registry
+	Metadata() *PluginMetadata
+}
+
 // Loader handles plugin loading and initialization
 type Loader struct {
 	registry *Registry
@@ -40,6 +65,56 @@ func (l *Loader) LoadPlugin(plugin Plugin, metadata *PluginMetadata) error {
 	return l.registry.Register(plugin, metadata)
 }
 
+// LoadFromDir loads every Go plugin (.so) file in dir and registers the
+// plugins they export. Each file must export a Plugin symbol implementing
+// ExternalPlugin. Valid plugins are registered even if others fail; the
+// failures are returned together.
+func (l *Loader) LoadFromDir(dir string) error {
+	if !dynamicPluginsSupported {
+		return ErrDynamicPluginsUnsupported
+	}
+	
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read plugin directory: %w", err)
+	}
+	
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".so") {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(paths)
+	
+	var errs []error
+	for _, path := range paths {
+		if err := l.loadFile(path); err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: %w", filepath.Base(path), err))
+		}
+	}
+	
+	return errors.Join(errs...)
+}
+
+// loadFile opens a single plugin file and registers its plugin
+func (l *Loader) loadFile(path string) error {
+	plugin, err := openPlugin(path)
+	if err != nil {
+		return err
+	}
+	
+	metadata := plugin.Metadata()
+	if metadata == nil {
+		return fmt.Errorf("%w: Metadata() returned nil", ErrIncompatiblePlugin)
+	}
+	if metadata.Name != "" && metadata.Name != plugin.Name() {
+		return fmt.Errorf("%w: metadata name %q does not match plugin name %q", ErrIncompatiblePlugin, metadata.Name, plugin.Name())
+	}
+	
+	return l.LoadPlugin(plugin, metadata)
+}
+
 // UnloadPlugin unloads a plugin by name
 func (l *Loader) UnloadPlugin(name string) error {
 	return l.registry.Unregister(name)
@@ -66,3 +141,8 @@ func DefaultLoader() *Loader {
 func LoadBuiltins() error {
 	return DefaultLoader().LoadBuiltins()
 }
+
+// LoadFromDir loads external plugins from dir into the default registry
+func LoadFromDir(dir string) error {
+	return DefaultLoader().LoadFromDir(dir)
+}
@@ -0,0 +1,40 @@
+//go:build dynamicplugins && cgo && (linux || darwin || freebsd)
+
+package plugins
+
+import (
+	"fmt"
+	"plugin"
+)
+
+const dynamicPluginsSupported = true
+
+// openPlugin opens a Go plugin file and returns its exported Plugin symbol
+func openPlugin(path string) (ExternalPlugin, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIncompatiblePlugin, err)
+	}
+
+	sym, err := p.Lookup("Plugin")
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing exported Plugin symbol", ErrIncompatiblePlugin)
+	}
+
+	// Lookup returns a pointer to exported variables; accept either a
+	// variable of interface type or a concrete value implementing it
+	switch v := sym.(type) {
+	case ExternalPlugin:
+		return v, nil
+	case *ExternalPlugin:
+		if *v != nil {
+			return *v, nil
+		}
+	case *Plugin:
+		if ext, ok := (*v).(ExternalPlugin); ok {
+			return ext, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: Plugin symbol of type %T does not implement plugins.Plugin and Metadata() *PluginMetadata", ErrIncompatiblePlugin, sym)
+}
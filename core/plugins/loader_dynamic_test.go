@@ -0,0 +1,91 @@
+//go:build dynamicplugins && cgo && (linux || darwin || freebsd)
+
+package plugins
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildPluginFixtures compiles the sample plugin into pluginDir and returns
+// the path of a host binary that loads it. Both are built with the same
+// flags so the shared plugins package matches.
+func buildPluginFixtures(t *testing.T, pluginDir string) string {
+	t.Helper()
+	
+	host := filepath.Join(t.TempDir(), "pluginhost")
+	builds := [][]string{
+		{"build", "-tags", "dynamicplugins", "-buildmode=plugin", "-o", filepath.Join(pluginDir, "sample.so"), "./testdata/sampleplugin"},
+		{"build", "-tags", "dynamicplugins", "-o", host, "./testdata/pluginhost"},
+	}
+	for _, args := range builds {
+		if output, err := exec.Command("go", args...).CombinedOutput(); err != nil {
+			t.Skipf("cannot build plugin fixtures: %v\n%s", err, output)
+		}
+	}
+	
+	return host
+}
+
+// runPluginHost loads pluginDir with the host binary and returns its output
+func runPluginHost(t *testing.T, host, pluginDir string) string {
+	t.Helper()
+	
+	output, err := exec.Command(host, pluginDir).CombinedOutput()
+	if err != nil {
+		t.Fatalf("plugin host failed: %v\n%s", err, output)
+	}
+	
+	return string(output)
+}
+
+func TestLoader_LoadFromDir(t *testing.T) {
+	dir := t.TempDir()
+	host := buildPluginFixtures(t, dir)
+	
+	// Non-plugin files are ignored
+	os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a plugin"), 0644)
+	
+	output := runPluginHost(t, host, dir)
+	
+	if strings.Contains(output, "error:") {
+		t.Fatalf("LoadFromDir() returned an error:\n%s", output)
+	}
+	if !strings.Contains(output, "loaded sample 0.1.0") {
+		t.Errorf("sample plugin not registered:\n%s", output)
+	}
+	if !strings.Contains(output, "candidate echo hello") {
+		t.Errorf("sample plugin did not translate prompt:\n%s", output)
+	}
+}
+
+func TestLoader_LoadFromDir_Incompatible(t *testing.T) {
+	dir := t.TempDir()
+	host := buildPluginFixtures(t, dir)
+	
+	if err := os.WriteFile(filepath.Join(dir, "broken.so"), []byte("not a shared object"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	output := runPluginHost(t, host, dir)
+	
+	if !strings.Contains(output, "incompatible") || !strings.Contains(output, "broken.so") {
+		t.Errorf("expected ErrIncompatiblePlugin for broken.so:\n%s", output)
+	}
+	
+	// The valid plugin is still registered
+	if !strings.Contains(output, "loaded sample 0.1.0") {
+		t.Errorf("valid plugin not registered:\n%s", output)
+	}
+}
+
+func TestLoader_LoadFromDir_MissingDir(t *testing.T) {
+	loader := NewLoader(NewRegistry())
+	
+	if err := loader.LoadFromDir(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("LoadFromDir() should fail for a missing directory")
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !(dynamicplugins && cgo && (linux || darwin || freebsd))
+
+package plugins
+
+const dynamicPluginsSupported = false
+
+func openPlugin(path string) (ExternalPlugin, error) {
+	return nil, ErrDynamicPluginsUnsupported
+}
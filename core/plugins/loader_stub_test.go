@@ -0,0 +1,17 @@
+//go:build !(dynamicplugins && cgo && (linux || darwin || freebsd))
+
+package plugins
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLoader_LoadFromDir_Unsupported(t *testing.T) {
+	loader := NewLoader(NewRegistry())
+	
+	err := loader.LoadFromDir(t.TempDir())
+	if !errors.Is(err, ErrDynamicPluginsUnsupported) {
+		t.Errorf("LoadFromDir() error = %v, want ErrDynamicPluginsUnsupported", err)
+	}
+}
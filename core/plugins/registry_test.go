@@ -1,6 +1,9 @@
 package plugins
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 )
@@ -186,6 +189,38 @@ func TestTranslateWithPlugins(t *testing.T) {
 	}
 }
 
+func TestTranslateWithPlugins_Cancelled(t *testing.T) {
+	goCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	
+	// Each plugin cancels the context; only the first to run should be called
+	calls := 0
+	translate := func(ctx Context, prompt string) ([]*Candidate, error) {
+		calls++
+		cancel()
+		return []*Candidate{{Command: "slow command", RiskLevel: RiskSafe}}, nil
+	}
+	
+	registry := NewRegistry()
+	registry.Register(&mockPlugin{name: "one", translateFunc: translate}, &PluginMetadata{Name: "one", Enabled: true})
+	registry.Register(&mockPlugin{name: "two", translateFunc: translate}, &PluginMetadata{Name: "two", Enabled: true})
+	
+	oldRegistry := globalRegistry
+	globalRegistry = registry
+	defer func() { globalRegistry = oldRegistry }()
+	
+	candidates, err := TranslateWithPlugins(Context{Ctx: goCtx}, "test prompt", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("TranslateWithPlugins() error = %v, want context.Canceled", err)
+	}
+	if candidates != nil {
+		t.Errorf("TranslateWithPlugins() = %v, want no candidates", candidates)
+	}
+	if calls != 1 {
+		t.Errorf("plugins called %d times after cancellation, want 1", calls)
+	}
+}
+
 func TestPreRunCheckWithPlugins(t *testing.T) {
 	registry := NewRegistry()
 	
@@ -231,11 +266,149 @@ func TestPreRunCheckWithPlugins(t *testing.T) {
 	}
 }
 
+func TestPreRunCheckWithPlugins_MostRestrictiveWins(t *testing.T) {
+	registry := NewRegistry()
+	
+	git := &mockPlugin{
+		name: "git",
+		preRunCheckFunc: func(ctx Context, candidate *Candidate) (*CheckResult, error) {
+			return &CheckResult{
+				Allowed:          true,
+				RequiresApproval: true,
+				ApprovalMessage:  "Force push rewrites history",
+				AdditionalChecks: []string{"branch-protection"},
+				Metadata:         map[string]interface{}{"branch": "main"},
+			}, nil
+		},
+	}
+	security := &mockPlugin{
+		name:    "security",
+		handles: func(string) bool { return true },
+		preRunCheckFunc: func(ctx Context, candidate *Candidate) (*CheckResult, error) {
+			return &CheckResult{
+				Allowed:          false,
+				Reason:           "Pushes to main are blocked",
+				AdditionalChecks: []string{"branch-protection", "audit"},
+			}, nil
+		},
+	}
+	// Doesn't handle git commands, so it isn't asked
+	broken := &mockPlugin{
+		name:    "broken",
+		handles: func(command string) bool { return RunsExecutable(command, "terraform") },
+		preRunCheckFunc: func(ctx Context, candidate *Candidate) (*CheckResult, error) {
+			return nil, errors.New("unavailable")
+		},
+	}
+	
+	registry.Register(git, &PluginMetadata{Name: "git", Enabled: true})
+	registry.Register(security, &PluginMetadata{Name: "security", Enabled: true})
+	registry.Register(broken, &PluginMetadata{Name: "broken", Enabled: true})
+	
+	oldRegistry := globalRegistry
+	globalRegistry = registry
+	defer func() { globalRegistry = oldRegistry }()
+	
+	candidate := &Candidate{
+		Command:    "git push --force origin main",
+		PluginName: "git",
+	}
+	
+	result, err := PreRunCheckWithPlugins(Context{Timestamp: time.Now()}, candidate)
+	if err != nil {
+		t.Fatalf("PreRunCheckWithPlugins() error = %v", err)
+	}
+	
+	if result.Allowed {
+		t.Error("PreRunCheckWithPlugins() should deny when any plugin denies")
+	}
+	if result.Reason != "Pushes to main are blocked" {
+		t.Errorf("Reason = %q, want the security plugin's reason", result.Reason)
+	}
+	if !result.RequiresApproval || result.ApprovalMessage != "Force push rewrites history" {
+		t.Errorf("RequiresApproval = %v, ApprovalMessage = %q, want the git plugin's approval",
+			result.RequiresApproval, result.ApprovalMessage)
+	}
+	wantChecks := []string{"branch-protection", "audit"}
+	if len(result.AdditionalChecks) != len(wantChecks) {
+		t.Fatalf("AdditionalChecks = %v, want %v", result.AdditionalChecks, wantChecks)
+	}
+	for i, check := range wantChecks {
+		if result.AdditionalChecks[i] != check {
+			t.Errorf("AdditionalChecks = %v, want %v", result.AdditionalChecks, wantChecks)
+			break
+		}
+	}
+	if result.Metadata["branch"] != "main" {
+		t.Errorf("Metadata = %v, want git plugin metadata merged", result.Metadata)
+	}
+}
+
+func TestPreRunCheckWithPlugins_ErrorDenies(t *testing.T) {
+	registry := NewRegistry()
+	
+	registry.Register(&mockPlugin{
+		name:    "git",
+		handles: func(command string) bool { return RunsExecutable(command, "git") },
+		preRunCheckFunc: func(ctx Context, candidate *Candidate) (*CheckResult, error) {
+			return nil, errors.New("not a repository")
+		},
+	}, &PluginMetadata{Name: "git", Enabled: true})
+	
+	oldRegistry := globalRegistry
+	globalRegistry = registry
+	defer func() { globalRegistry = oldRegistry }()
+	
+	// Whether the failing plugin owns the candidate or only handles its command
+	for _, candidate := range []*Candidate{
+		{Command: "git status", PluginName: "git"},
+		{Command: "make && git status"},
+	} {
+		result, err := PreRunCheckWithPlugins(Context{}, candidate)
+		if err != nil {
+			t.Fatalf("PreRunCheckWithPlugins() error = %v", err)
+		}
+		if result.Allowed || !strings.Contains(result.Reason, "not a repository") {
+			t.Errorf("PreRunCheckWithPlugins(%q) = %+v, want a denial with the plugin's error", candidate.Command, result)
+		}
+	}
+}
+
+func TestPreRunCheckWithPlugins_OnlyHandlers(t *testing.T) {
+	registry := NewRegistry()
+	
+	deny := func(ctx Context, candidate *Candidate) (*CheckResult, error) {
+		return &CheckResult{Allowed: false, Reason: "not a git repository"}, nil
+	}
+	registry.Register(&mockPlugin{
+		name:            "git",
+		handles:         func(command string) bool { return RunsExecutable(command, "git") },
+		preRunCheckFunc: deny,
+	}, &PluginMetadata{Name: "git", Enabled: true})
+	registry.Register(&mockPlugin{name: "legacy", preRunCheckFunc: deny}, &PluginMetadata{Name: "legacy", Enabled: true})
+	
+	oldRegistry := globalRegistry
+	globalRegistry = registry
+	defer func() { globalRegistry = oldRegistry }()
+	
+	// Neither plugin handles ls, so neither checks it
+	result, err := PreRunCheckWithPlugins(Context{}, &Candidate{Command: "ls -la"})
+	if err != nil || !result.Allowed {
+		t.Errorf("PreRunCheckWithPlugins(ls -la) = %+v, %v, want allowed", result, err)
+	}
+	
+	result, err = PreRunCheckWithPlugins(Context{}, &Candidate{Command: "git status"})
+	if err != nil || result.Allowed {
+		t.Errorf("PreRunCheckWithPlugins(git status) = %+v, %v, want the git plugin's denial", result, err)
+	}
+}
+
 // Mock plugin for testing
 type mockPlugin struct {
 	name            string
 	translateFunc   func(Context, string) ([]*Candidate, error)
 	preRunCheckFunc func(Context, *Candidate) (*CheckResult, error)
+	handles         func(command string) bool
 }
 
 func (m *mockPlugin) Name() string {
@@ -256,6 +429,10 @@ func (m *mockPlugin) PreRunCheck(ctx Context, candidate *Candidate) (*CheckResul
 	return &CheckResult{Allowed: true}, nil
 }
 
+func (m *mockPlugin) CanHandle(command string) bool {
+	return m.handles != nil && m.handles(command)
+}
+
 func (m *mockPlugin) RequiresApproval(candidate *Candidate) bool {
 	return false
 }
@@ -0,0 +1,34 @@
+// Command pluginhost loads plugins from a directory and reports the result.
+// The loader tests run it as a separate binary because a Go plugin cannot
+// be opened by a test binary whose copy of the plugins package differs.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
+)
+
+func main() {
+	registry := plugins.NewRegistry()
+	loader := plugins.NewLoader(registry)
+	
+	if err := loader.LoadFromDir(os.Args[1]); err != nil {
+		fmt.Printf("error: %v\n", err)
+		if errors.Is(err, plugins.ErrIncompatiblePlugin) {
+			fmt.Println("incompatible")
+		}
+	}
+	
+	for _, plugin := range registry.List() {
+		metadata, _ := registry.GetMetadata(plugin.Name())
+		fmt.Printf("loaded %s %s\n", plugin.Name(), metadata.Version)
+		
+		candidates, _ := plugin.Translate(plugins.Context{}, "say hello")
+		for _, candidate := range candidates {
+			fmt.Printf("candidate %s\n", candidate.Command)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Command sampleplugin is built with -buildmode=plugin by the loader tests
+package main
+
+import (
+	"strings"
+	
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
+)
+
+// SamplePlugin translates "say hello" prompts
+type SamplePlugin struct{}
+
+// Plugin is the symbol looked up by plugins.LoadFromDir
+var Plugin SamplePlugin
+
+func (p *SamplePlugin) Name() string {
+	return "sample"
+}
+
+func (p *SamplePlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Candidate, error) {
+	if !strings.Contains(strings.ToLower(prompt), "say hello") {
+		return nil, nil
+	}
+	
+	return []*plugins.Candidate{
+		{
+			Command:     "echo hello",
+			Explanation: "Print hello",
+			Confidence:  90,
+			RiskLevel:   plugins.RiskSafe,
+			PluginName:  p.Name(),
+		},
+	}, nil
+}
+
+func (p *SamplePlugin) PreRunCheck(ctx plugins.Context, candidate *plugins.Candidate) (*plugins.CheckResult, error) {
+	return &plugins.CheckResult{Allowed: true}, nil
+}
+
+func (p *SamplePlugin) RequiresApproval(candidate *plugins.Candidate) bool {
+	return false
+}
+
+func (p *SamplePlugin) Scopes() []string {
+	return []string{"shell:read"}
+}
+
+func (p *SamplePlugin) Metadata() *plugins.PluginMetadata {
+	return &plugins.PluginMetadata{
+		Name:        "sample",
+		Version:     "0.1.0",
+		Description: "Sample external plugin used in tests",
+		Author:      "QuickCMD Team",
+		Enabled:     true,
+	}
+}
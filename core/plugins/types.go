@@ -25,12 +25,47 @@ type Plugin interface {
 	Scopes() []string
 }
 
+// ContextApprover is implemented by plugins whose approval decision depends
+// on the Context, such as their PluginConfig. CheckApprovalRequired prefers
+// it over Plugin.RequiresApproval.
+type ContextApprover interface {
+	RequiresApprovalWithContext(ctx Context, candidate *Candidate) bool
+}
+
+// CommandHandler is implemented by plugins that can tell whether a command
+// is theirs, such as the git plugin for git commands. Only these plugins
+// are asked to check candidates that another plugin, or no plugin,
+// produced.
+type CommandHandler interface {
+	CanHandle(command string) bool
+}
+
 // Context provides execution context to plugins
 type Context struct {
 	WorkingDir string
 	User       string
 	Timestamp  time.Time
 	Metadata   map[string]interface{}
+	
+	// Per-plugin settings keyed by plugin name, e.g.
+	// PluginConfig["aws"]["cost_threshold"]
+	PluginConfig map[string]map[string]interface{}
+	
+	// Environment is the target environment selected with --env, or nil
+	Environment *Environment
+	
+	// Ctx carries cancellation and deadlines for the call. Plugins doing
+	// slow work (network calls, subprocesses) should honor it. May be nil.
+	Ctx context.Context
+}
+
+// Err returns the error of the Go context carried by c, or nil if there is
+// none or it is not done
+func (c Context) Err() error {
+	if c.Ctx == nil {
+		return nil
+	}
+	return c.Ctx.Err()
 }
 
 // Candidate represents a command candidate with plugin metadata
@@ -46,10 +81,18 @@ type Candidate struct {
 	RequiresConfirm bool
 	DocLinks       []string
 	
+	// Category is the command family, e.g. git or k8s, used to filter
+	// candidates. Untagged candidates take their plugin's name.
+	Category       string
+	
 	// Plugin-specific metadata
 	PluginName     string
 	PluginMetadata map[string]interface{}
 	UndoStrategy   *UndoStrategy
+	
+	// SimulateCommand is a safe rehearsal of Command, such as a server-side
+	// dry run, whose output is shown before the real command is confirmed
+	SimulateCommand string
 }
 
 // Step represents a single step in command breakdown
@@ -0,0 +1,262 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is returned, wrapped in a *BreakerOpenError, for a
+// destructive run while the user's circuit breaker is open
+var ErrBreakerOpen = errors.New("destructive command circuit breaker is open")
+
+// BreakerConfig sets when the circuit breaker trips
+type BreakerConfig struct {
+	Threshold int           // destructive runs within Window that trip the breaker; 0 disables it
+	Window    time.Duration // how far back runs are counted
+	Cooldown  time.Duration // how long the breaker stays open once tripped
+}
+
+// DefaultBreakerConfig trips after 5 destructive runs within a minute and
+// stays open for 10 minutes
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		Threshold: 5,
+		Window:    time.Minute,
+		Cooldown:  10 * time.Minute,
+	}
+}
+
+// CircuitBreaker counts destructive executions per user. After Threshold
+// of them within Window, e.g. from a script gone haywire, the breaker opens
+// and Check fails for Cooldown. State is persisted as JSON so it holds
+// across CLI invocations.
+type CircuitBreaker struct {
+	mu     sync.Mutex
+	path   string // "" keeps state in memory
+	config BreakerConfig
+	now    func() time.Time
+	state  breakerState
+}
+
+// breakerState is the persisted breaker state
+type breakerState struct {
+	Users map[string]*breakerUser `json:"users"`
+}
+
+// breakerUser is one user's recent destructive runs
+type breakerUser struct {
+	Runs      []time.Time `json:"runs"`
+	OpenUntil time.Time   `json:"open_until,omitempty"`
+}
+
+// BreakerOpenError is returned by Check while a user's breaker is open
+type BreakerOpenError struct {
+	User  string
+	Until time.Time
+}
+
+func (e *BreakerOpenError) Error() string {
+	return fmt.Sprintf("too many destructive commands by %s; blocked until %s", e.User, e.Until.Format("15:04:05"))
+}
+
+func (e *BreakerOpenError) Unwrap() error {
+	return ErrBreakerOpen
+}
+
+// NewCircuitBreaker creates a breaker persisted at path, loading any state
+// already recorded there. An empty path keeps state in memory.
+func NewCircuitBreaker(path string, config BreakerConfig) (*CircuitBreaker, error) {
+	cb := &CircuitBreaker{
+		path:   path,
+		config: config,
+		now:    time.Now,
+		state:  breakerState{Users: make(map[string]*breakerUser)},
+	}
+
+	if err := cb.load(); err != nil {
+		return nil, err
+	}
+
+	return cb, nil
+}
+
+// load replaces the in-memory state with what is recorded on disk, so runs
+// recorded by other invocations since this breaker was opened are counted.
+// Callers must hold cb.mu.
+func (cb *CircuitBreaker) load() error {
+	state := breakerState{Users: make(map[string]*breakerUser)}
+	if cb.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(cb.path)
+	if errors.Is(err, os.ErrNotExist) {
+		cb.state = state
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read circuit breaker file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse circuit breaker file: %w", err)
+	}
+	if state.Users == nil {
+		state.Users = make(map[string]*breakerUser)
+	}
+	cb.state = state
+
+	return nil
+}
+
+// lock takes an exclusive lock on the breaker file, shared with every other
+// invocation, so a read-modify-write of the state can't lose their runs.
+// It returns the function that releases it.
+func (cb *CircuitBreaker) lock() (func(), error) {
+	if cb.path == "" {
+		return func() {}, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cb.path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create circuit breaker directory: %w", err)
+	}
+	f, err := os.OpenFile(cb.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open circuit breaker lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock circuit breaker file: %w", err)
+	}
+
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// Check returns a *BreakerOpenError if user's breaker is open
+func (cb *CircuitBreaker) Check(user string) error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	// The file is replaced atomically, so it can be read without the lock
+	if err := cb.load(); err != nil {
+		return err
+	}
+	if u := cb.state.Users[user]; u != nil && cb.now().Before(u.OpenUntil) {
+		return &BreakerOpenError{User: user, Until: u.OpenUntil}
+	}
+	return nil
+}
+
+// Record counts a destructive execution by user, opening the breaker if it
+// reaches the threshold. It reports whether the breaker tripped.
+func (cb *CircuitBreaker) Record(user string) (bool, error) {
+	if cb.config.Threshold <= 0 {
+		return false, nil
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	unlock, err := cb.lock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	if err := cb.load(); err != nil {
+		return false, err
+	}
+
+	now := cb.now()
+	u := cb.state.Users[user]
+	if u == nil {
+		u = &breakerUser{}
+		cb.state.Users[user] = u
+	}
+
+	// Only runs within the window count
+	runs := []time.Time{now}
+	for _, run := range u.Runs {
+		if now.Sub(run) < cb.config.Window {
+			runs = append(runs, run)
+		}
+	}
+	u.Runs = runs
+
+	tripped := false
+	if len(u.Runs) >= cb.config.Threshold && !now.Before(u.OpenUntil) {
+		u.OpenUntil = now.Add(cb.config.Cooldown)
+		u.Runs = nil
+		tripped = true
+	}
+
+	return tripped, cb.save()
+}
+
+// Reset closes user's breaker and forgets their recent runs
+func (cb *CircuitBreaker) Reset(user string) error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	unlock, err := cb.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := cb.load(); err != nil {
+		return err
+	}
+
+	delete(cb.state.Users, user)
+	return cb.save()
+}
+
+// save writes the breaker state to disk, dropping users with nothing left
+// to track. Callers must hold cb.mu and the file lock.
+func (cb *CircuitBreaker) save() error {
+	now := cb.now()
+	for name, u := range cb.state.Users {
+		recent := false
+		for _, run := range u.Runs {
+			if now.Sub(run) < cb.config.Window {
+				recent = true
+			}
+		}
+		if !recent && !now.Before(u.OpenUntil) {
+			delete(cb.state.Users, name)
+		}
+	}
+
+	if cb.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(cb.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal circuit breaker state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cb.path), 0755); err != nil {
+		return fmt.Errorf("failed to create circuit breaker directory: %w", err)
+	}
+
+	// Write to a temporary file and rename it, so a crash mid-write or a
+	// concurrent Check never sees a truncated file
+	tmp := cb.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write circuit breaker file: %w", err)
+	}
+	if err := os.Rename(tmp, cb.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write circuit breaker file: %w", err)
+	}
+
+	return nil
+}
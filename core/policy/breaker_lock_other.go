@@ -0,0 +1,16 @@
+//go:build !unix
+
+package policy
+
+import "os"
+
+// lockFile is a no-op where flock isn't available. Writes are still atomic,
+// but concurrent invocations may lose each other's runs.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile releases the lock taken by lockFile
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,151 @@
+package policy
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_Trips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breaker.json")
+	config := BreakerConfig{Threshold: 3, Window: time.Minute, Cooldown: 10 * time.Minute}
+
+	cb, err := NewCircuitBreaker(path, config)
+	if err != nil {
+		t.Fatalf("NewCircuitBreaker() error: %v", err)
+	}
+	now := time.Date(2025, 1, 7, 10, 0, 0, 0, time.UTC)
+	cb.now = func() time.Time { return now }
+
+	// Rapid destructive commands: the breaker trips on the third
+	for i := 1; i <= config.Threshold; i++ {
+		if err := cb.Check("alice"); err != nil {
+			t.Fatalf("run %d: Check() error before the threshold: %v", i, err)
+		}
+		tripped, err := cb.Record("alice")
+		if err != nil {
+			t.Fatalf("Record() error: %v", err)
+		}
+		if tripped != (i == config.Threshold) {
+			t.Errorf("run %d: tripped = %v", i, tripped)
+		}
+		now = now.Add(5 * time.Second)
+	}
+
+	err = cb.Check("alice")
+	var open *BreakerOpenError
+	if !errors.Is(err, ErrBreakerOpen) || !errors.As(err, &open) {
+		t.Fatalf("Check() after the threshold = %v, want ErrBreakerOpen", err)
+	}
+	if want := time.Date(2025, 1, 7, 10, 10, 10, 0, time.UTC); !open.Until.Equal(want) {
+		t.Errorf("open until %v, want %v", open.Until, want)
+	}
+
+	// Other users are unaffected
+	if err := cb.Check("bob"); err != nil {
+		t.Errorf("Check(bob) = %v", err)
+	}
+
+	// The open breaker survives a restart
+	reloaded, err := NewCircuitBreaker(path, config)
+	if err != nil {
+		t.Fatalf("NewCircuitBreaker() reload error: %v", err)
+	}
+	reloaded.now = cb.now
+	if err := reloaded.Check("alice"); !errors.Is(err, ErrBreakerOpen) {
+		t.Errorf("Check() after reload = %v, want ErrBreakerOpen", err)
+	}
+
+	// It closes after the cool-down
+	now = now.Add(config.Cooldown)
+	if err := reloaded.Check("alice"); err != nil {
+		t.Errorf("Check() after the cool-down = %v", err)
+	}
+}
+
+func TestCircuitBreaker_Window(t *testing.T) {
+	cb, err := NewCircuitBreaker("", BreakerConfig{Threshold: 3, Window: time.Minute, Cooldown: time.Hour})
+	if err != nil {
+		t.Fatalf("NewCircuitBreaker() error: %v", err)
+	}
+	now := time.Now()
+	cb.now = func() time.Time { return now }
+
+	// Runs spread wider than the window never trip the breaker
+	for i := 0; i < 10; i++ {
+		if tripped, _ := cb.Record("alice"); tripped {
+			t.Fatalf("run %d tripped the breaker", i+1)
+		}
+		now = now.Add(31 * time.Second)
+	}
+	if err := cb.Check("alice"); err != nil {
+		t.Errorf("Check() = %v", err)
+	}
+
+	cb.Record("alice")
+	cb.Record("alice")
+	if err := cb.Check("alice"); !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("Check() = %v, want ErrBreakerOpen", err)
+	}
+	if err := cb.Reset("alice"); err != nil {
+		t.Fatalf("Reset() error: %v", err)
+	}
+	if err := cb.Check("alice"); err != nil {
+		t.Errorf("Check() after Reset() = %v", err)
+	}
+}
+
+func TestCircuitBreaker_Disabled(t *testing.T) {
+	cb, _ := NewCircuitBreaker("", BreakerConfig{})
+	for i := 0; i < 100; i++ {
+		cb.Record("alice")
+	}
+	if err := cb.Check("alice"); err != nil {
+		t.Errorf("disabled breaker Check() = %v", err)
+	}
+}
+
+func TestCircuitBreaker_ConcurrentInvocations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breaker.json")
+	config := BreakerConfig{Threshold: 40, Window: time.Hour, Cooldown: time.Hour}
+
+	// Two invocations opened before either records a run, each recording
+	// concurrently: no run may be lost, so the breaker trips exactly once
+	var breakers []*CircuitBreaker
+	for i := 0; i < 2; i++ {
+		cb, err := NewCircuitBreaker(path, config)
+		if err != nil {
+			t.Fatalf("NewCircuitBreaker() error: %v", err)
+		}
+		breakers = append(breakers, cb)
+	}
+
+	var wg sync.WaitGroup
+	var trips atomic.Int32
+	for i := 0; i < config.Threshold; i++ {
+		wg.Add(1)
+		go func(cb *CircuitBreaker) {
+			defer wg.Done()
+			tripped, err := cb.Record("alice")
+			if err != nil {
+				t.Errorf("Record() error: %v", err)
+			}
+			if tripped {
+				trips.Add(1)
+			}
+		}(breakers[i%2])
+	}
+	wg.Wait()
+
+	if got := trips.Load(); got != 1 {
+		t.Errorf("breaker tripped %d times, want 1", got)
+	}
+	for i, cb := range breakers {
+		if err := cb.Check("alice"); !errors.Is(err, ErrBreakerOpen) {
+			t.Errorf("breaker %d: Check() = %v, want ErrBreakerOpen", i, err)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package policy
 import (
 	"fmt"
 	"os"
+	"time"
 	
 	"gopkg.in/yaml.v3"
 )
@@ -10,12 +11,17 @@ import (
 // Engine handles policy enforcement
 type Engine struct {
 	policy *Policy
+	now    func() time.Time // clock for scheduled patterns
+	
+	// decisionPoint, if set, is consulted for commands local policy allows
+	decisionPoint *DecisionPoint
 }
 
 // NewEngine creates a new policy engine with default policy
 func NewEngine() *Engine {
 	return &Engine{
 		policy: DefaultPolicy(),
+		now:    time.Now,
 	}
 }
 
@@ -42,15 +48,41 @@ func NewEngineFromFile(path string) (*Engine, error) {
 			return nil, err
 		}
 	}
+	for i := range policy.ApprovalRequired {
+		if err := policy.ApprovalRequired[i].Compile(); err != nil {
+			return nil, err
+		}
+	}
 	
-	return &Engine{policy: &policy}, nil
+	return &Engine{policy: &policy, now: time.Now}, nil
 }
 
-// Validate checks if a command is allowed by the policy
+// Validate checks if a command is allowed by the policy and, if one is set,
+// by the external decision point. The most restrictive answer wins.
 func (e *Engine) Validate(command string, riskLevel string, destructive bool) *ValidationResult {
+	result := e.validateLocal(command, riskLevel, destructive)
+	if e.decisionPoint != nil && result.Allowed {
+		e.decisionPoint.apply(result, command, riskLevel, destructive)
+	}
+	return result
+}
+
+// SetDecisionPoint makes Validate also ask d about commands the policy
+// allows. A nil d removes the decision point.
+func (e *Engine) SetDecisionPoint(d *DecisionPoint) {
+	e.decisionPoint = d
+}
+
+// validateLocal checks a command against the policy alone
+func (e *Engine) validateLocal(command string, riskLevel string, destructive bool) *ValidationResult {
+	now := time.Now()
+	if e.now != nil {
+		now = e.now()
+	}
+	
 	// Check denylist first (highest priority)
 	for _, pattern := range e.policy.Denylist {
-		if pattern.Matches(command) {
+		if pattern.MatchesAt(command, now) {
 			return &ValidationResult{
 				Allowed:     false,
 				Reason:      fmt.Sprintf("Command blocked by denylist: %s", pattern.Description),
@@ -65,7 +97,7 @@ func (e *Engine) Validate(command string, riskLevel string, destructive bool) *V
 		var matchedRule string
 		
 		for _, pattern := range e.policy.Allowlist {
-			if pattern.Matches(command) {
+			if pattern.MatchesAt(command, now) {
 				allowed = true
 				matchedRule = pattern.Pattern
 				break
@@ -85,7 +117,7 @@ func (e *Engine) Validate(command string, riskLevel string, destructive bool) *V
 			MatchedRule: matchedRule,
 		}
 		
-		e.applyApprovalRules(result, riskLevel, destructive)
+		e.applyApprovalRules(result, command, riskLevel, destructive, now)
 		return result
 	}
 	
@@ -94,12 +126,23 @@ func (e *Engine) Validate(command string, riskLevel string, destructive bool) *V
 		Allowed: true,
 	}
 	
-	e.applyApprovalRules(result, riskLevel, destructive)
+	e.applyApprovalRules(result, command, riskLevel, destructive, now)
 	return result
 }
 
-// applyApprovalRules applies approval requirements based on risk and destructiveness
-func (e *Engine) applyApprovalRules(result *ValidationResult, riskLevel string, destructive bool) {
+// applyApprovalRules applies approval requirements based on risk,
+// destructiveness and the approval_required patterns
+func (e *Engine) applyApprovalRules(result *ValidationResult, command string, riskLevel string, destructive bool, now time.Time) {
+	for _, pattern := range e.policy.ApprovalRequired {
+		if pattern.MatchesAt(command, now) {
+			result.RequiresConfirm = true
+			result.RequiresApproval = true
+			result.MatchedRule = pattern.Pattern
+			result.ConfirmMessage = fmt.Sprintf("Command requires approval (%s). Type 'I UNDERSTAND' to proceed", pattern.Description)
+			return
+		}
+	}
+	
 	// Check if high-risk commands require confirmation
 	if e.policy.Approval.HighRisk && riskLevel == "high" {
 		result.RequiresConfirm = true
@@ -0,0 +1,105 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Validate checks that the schedule's hours and days are well formed
+func (s *Schedule) Validate() error {
+	if s.ActiveHours != "" {
+		if _, _, err := parseHours(s.ActiveHours); err != nil {
+			return err
+		}
+	}
+	
+	for _, day := range s.ActiveDays {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("unknown weekday %q", day)
+		}
+	}
+	
+	return nil
+}
+
+// ActiveAt reports whether the schedule applies at the given time. The time
+// must fall on one of ActiveDays and within ActiveHours; an empty field places
+// no restriction. Windows that wrap midnight (e.g. "22:00-06:00") use the
+// weekday of the evaluated time. Invert flips the result.
+func (s *Schedule) ActiveAt(t time.Time) bool {
+	inWindow := s.inDays(t) && s.inHours(t)
+	if s.Invert {
+		return !inWindow
+	}
+	return inWindow
+}
+
+func (s *Schedule) inDays(t time.Time) bool {
+	if len(s.ActiveDays) == 0 {
+		return true
+	}
+	
+	for _, day := range s.ActiveDays {
+		if weekday, ok := weekdays[strings.ToLower(day)]; ok && weekday == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Schedule) inHours(t time.Time) bool {
+	if s.ActiveHours == "" {
+		return true
+	}
+	
+	start, end, err := parseHours(s.ActiveHours)
+	if err != nil {
+		return false
+	}
+	
+	minute := t.Hour()*60 + t.Minute()
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	// Window wraps midnight
+	return minute >= start || minute < end
+}
+
+// parseHours parses "HH:MM-HH:MM" into start and end minutes of the day. The
+// start is inclusive and the end exclusive.
+func parseHours(hours string) (int, int, error) {
+	parts := strings.Split(hours, "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid active hours %q, expected HH:MM-HH:MM", hours)
+	}
+	
+	start, err := parseClock(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid active hours %q: %w", hours, err)
+	}
+	end, err := parseClock(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid active hours %q: %w", hours, err)
+	}
+	
+	return start, end, nil
+}
+
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
@@ -3,12 +3,14 @@ package policy
 import (
 	"fmt"
 	"regexp"
+	"time"
 )
 
 // Policy represents the security policy configuration
 type Policy struct {
 	Allowlist []Pattern        `yaml:"allowlist"`
 	Denylist  []Pattern        `yaml:"denylist"`
+	ApprovalRequired []Pattern `yaml:"approval_required"` // allowed only with approval
 	Approval  ApprovalConfig   `yaml:"approval"`
 	Secrets   SecretsConfig    `yaml:"secrets"`
 	Sandbox   SandboxConfig    `yaml:"sandbox"`
@@ -16,11 +18,19 @@ type Policy struct {
 
 // Pattern represents a command pattern for matching
 type Pattern struct {
-	Pattern     string `yaml:"pattern"`
-	Description string `yaml:"description"`
+	Pattern     string    `yaml:"pattern"`
+	Description string    `yaml:"description"`
+	Schedule    *Schedule `yaml:"schedule,omitempty"`
 	compiled    *regexp.Regexp
 }
 
+// Schedule restricts when a pattern applies
+type Schedule struct {
+	ActiveHours string   `yaml:"active_hours,omitempty"` // "09:00-18:00", may wrap midnight
+	ActiveDays  []string `yaml:"active_days,omitempty"`  // weekday names, e.g. "monday"
+	Invert      bool     `yaml:"invert,omitempty"`       // apply outside the window instead
+}
+
 // ApprovalConfig defines approval requirements
 type ApprovalConfig struct {
 	HighRisk          bool     `yaml:"high_risk"`
@@ -54,6 +64,16 @@ type ValidationResult struct {
 	RequiresConfirm bool
 	ConfirmMessage  string
 	MatchedRule     string
+	
+	// RequiresApproval is set when an approval_required pattern matches or
+	// the external decision point asks for approval. Unlike other
+	// confirmations, --yes doesn't skip it.
+	RequiresApproval bool
+	
+	// RequiresApprover is set when the external decision point asks for
+	// approval. The user can't confirm it themselves; an approver has to
+	// grant it through the approvals workflow.
+	RequiresApprover bool
 }
 
 // Compile compiles the regex pattern
@@ -67,6 +87,12 @@ func (p *Pattern) Compile() error {
 		return fmt.Errorf("failed to compile pattern %q: %w", p.Pattern, err)
 	}
 	
+	if p.Schedule != nil {
+		if err := p.Schedule.Validate(); err != nil {
+			return fmt.Errorf("invalid schedule for pattern %q: %w", p.Pattern, err)
+		}
+	}
+	
 	p.compiled = regex
 	return nil
 }
@@ -82,6 +108,15 @@ func (p *Pattern) Matches(command string) bool {
 	return p.compiled.MatchString(command)
 }
 
+// MatchesAt checks if the command matches this pattern and the pattern's
+// schedule is active at the given time
+func (p *Pattern) MatchesAt(command string, at time.Time) bool {
+	if p.Schedule != nil && !p.Schedule.ActiveAt(at) {
+		return false
+	}
+	return p.Matches(command)
+}
+
 // DefaultPolicy returns a sensible default policy
 func DefaultPolicy() *Policy {
 	return &Policy{
@@ -3,8 +3,14 @@ package policy
 import (
 	"regexp"
 	"strings"
+	"unicode"
 )
 
+// tokenPattern matches long opaque strings that may be API tokens. Matches
+// are only reported by Scan if they mix upper case, lower case and digits,
+// which rules out words, hex hashes and UUIDs.
+var tokenPattern = regexp.MustCompile(`[A-Za-z0-9+_\-]{32,}={0,2}`)
+
 // SecretRedactor handles redaction of sensitive information
 type SecretRedactor struct {
 	patterns []*regexp.Regexp
@@ -26,6 +32,10 @@ func NewSecretRedactor() *SecretRedactor {
 		// AWS credentials
 		`(?i)(aws[_-]?access[_-]?key[_-]?id)\s*=\s*[A-Z0-9]{20}`,
 		`(?i)(aws[_-]?secret[_-]?access[_-]?key)\s*=\s*[A-Za-z0-9/+=]{40}`,
+		`\b(AKIA|ASIA)[0-9A-Z]{16}\b`,
+		
+		// Password flags
+		`(?i)--password[=\s]+[^\s]+`,
 		
 		// Private keys
 		`-----BEGIN\s+(?:RSA\s+)?PRIVATE\s+KEY-----[\s\S]*?-----END\s+(?:RSA\s+)?PRIVATE\s+KEY-----`,
@@ -66,6 +76,62 @@ func (sr *SecretRedactor) Redact(text string) string {
 	return result
 }
 
+// Scan returns the likely credentials in text, masked so they can be shown
+// to the user. It checks the redaction patterns plus long token-like strings,
+// reporting overlapping matches once.
+func (sr *SecretRedactor) Scan(text string) []string {
+	var findings []string
+	var spans [][]int
+	add := func(span []int) {
+		for _, existing := range spans {
+			if span[0] < existing[1] && existing[0] < span[1] {
+				return
+			}
+		}
+		spans = append(spans, span)
+		findings = append(findings, maskSecret(text[span[0]:span[1]]))
+	}
+	
+	for _, pattern := range sr.patterns {
+		for _, span := range pattern.FindAllStringIndex(text, -1) {
+			add(span)
+		}
+	}
+	
+	for _, span := range tokenPattern.FindAllStringIndex(text, -1) {
+		if isTokenLike(text[span[0]:span[1]]) {
+			add(span)
+		}
+	}
+	
+	return findings
+}
+
+// isTokenLike reports whether s mixes upper case, lower case and digits
+func isTokenLike(s string) bool {
+	var upper, lower, digit bool
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return upper && lower && digit
+}
+
+// maskSecret keeps the start of a match, enough to locate it, and hides the rest
+func maskSecret(match string) string {
+	const visible = 6
+	if len(match) <= visible {
+		return strings.Repeat("*", len(match))
+	}
+	return match[:visible] + strings.Repeat("*", 6)
+}
+
 // RedactEnvVars redacts environment variables from a command
 func (sr *SecretRedactor) RedactEnvVars(command string) string {
 	// Pattern for environment variable assignments
@@ -0,0 +1,170 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/user"
+	"strconv"
+	"time"
+)
+
+// Decision is an external decision point's answer for a command
+type Decision string
+
+const (
+	DecisionAllow           Decision = "allow"
+	DecisionDeny            Decision = "deny"
+	DecisionRequireApproval Decision = "require_approval"
+)
+
+// Headers carrying the signature of a decision request
+const (
+	SignatureHeader = "X-QuickCmd-Signature"
+	TimestampHeader = "X-QuickCmd-Timestamp"
+)
+
+// defaultDecisionTimeout bounds a decision request without a timeout
+const defaultDecisionTimeout = 5 * time.Second
+
+// DecisionRequest is the JSON body POSTed to a policy webhook
+type DecisionRequest struct {
+	Command     string    `json:"command"`
+	RiskLevel   string    `json:"risk_level"`
+	Destructive bool      `json:"destructive"`
+	User        string    `json:"user"`
+	Host        string    `json:"host"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// DecisionResponse is the JSON body a policy webhook answers with
+type DecisionResponse struct {
+	Decision Decision `json:"decision"`
+	Reason   string   `json:"reason,omitempty"`
+}
+
+// DecisionPoint asks an external policy webhook whether a command may run,
+// so organizations can authorize commands centrally. Requests are signed
+// with HMAC-SHA256 over the timestamp and body (see SignDecisionRequest).
+type DecisionPoint struct {
+	url    string
+	secret string
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewDecisionPoint creates a decision point POSTing to url and signing with
+// secret. A zero timeout uses a default of 5 seconds.
+func NewDecisionPoint(url, secret string, timeout time.Duration) *DecisionPoint {
+	if timeout == 0 {
+		timeout = defaultDecisionTimeout
+	}
+	return &DecisionPoint{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: timeout},
+		now:    time.Now,
+	}
+}
+
+// SignDecisionRequest returns the hex HMAC-SHA256 of "<timestamp>.<body>",
+// as sent in SignatureHeader. Webhooks verify requests by recomputing it
+// with the shared secret and rejecting stale timestamps.
+func SignDecisionRequest(secret string, timestamp int64, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Decide POSTs req to the webhook and returns its decision
+func (d *DecisionPoint) Decide(ctx context.Context, req *DecisionRequest) (*DecisionResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode decision request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create decision request: %w", err)
+	}
+	timestamp := d.now().Unix()
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(SignatureHeader, "sha256="+SignDecisionRequest(d.secret, timestamp, body))
+
+	resp, err := d.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach policy webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("policy webhook returned status %d", resp.StatusCode)
+	}
+
+	var decision DecisionResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&decision); err != nil {
+		return nil, fmt.Errorf("failed to parse policy webhook response: %w", err)
+	}
+	switch decision.Decision {
+	case DecisionAllow, DecisionDeny, DecisionRequireApproval:
+	default:
+		return nil, fmt.Errorf("policy webhook returned unknown decision %q", decision.Decision)
+	}
+
+	return &decision, nil
+}
+
+// apply narrows result by the webhook's decision for command. The most
+// restrictive answer wins: a deny blocks the command and require_approval
+// needs an approver. If the webhook can't be asked the command is blocked,
+// so an outage never bypasses central policy.
+func (d *DecisionPoint) apply(result *ValidationResult, command, riskLevel string, destructive bool) {
+	req := &DecisionRequest{
+		Command:     command,
+		RiskLevel:   riskLevel,
+		Destructive: destructive,
+		User:        currentUsername(),
+		Timestamp:   d.now().UTC(),
+	}
+	req.Host, _ = os.Hostname()
+
+	decision, err := d.Decide(context.Background(), req)
+	if err != nil {
+		result.Allowed = false
+		result.Reason = fmt.Sprintf("Policy webhook unavailable: %v", err)
+		return
+	}
+
+	switch decision.Decision {
+	case DecisionDeny:
+		result.Allowed = false
+		result.Reason = "Command blocked by policy webhook"
+		if decision.Reason != "" {
+			result.Reason += ": " + decision.Reason
+		}
+	case DecisionRequireApproval:
+		result.RequiresApproval = true
+		result.RequiresApprover = true
+		result.Reason = "The policy webhook requires approval"
+		if decision.Reason != "" {
+			result.Reason += " (" + decision.Reason + ")"
+		}
+	}
+}
+
+// currentUsername returns the name of the user running quickcmd
+func currentUsername() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return "unknown"
+}
@@ -0,0 +1,128 @@
+package policy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeWebhook answers every decision request with decision, failing the
+// test if a request isn't correctly signed with secret
+func fakeWebhook(t *testing.T, secret string, decision DecisionResponse) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		if err != nil {
+			t.Errorf("bad %s header: %v", TimestampHeader, err)
+		}
+		if got, want := r.Header.Get(SignatureHeader), "sha256="+SignDecisionRequest(secret, timestamp, body); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+
+		var req DecisionRequest
+		if err := json.Unmarshal(body, &req); err != nil || req.Command == "" {
+			t.Errorf("bad decision request %s: %v", body, err)
+		}
+
+		json.NewEncoder(w).Encode(decision)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEngine_Validate_DecisionPoint(t *testing.T) {
+	tests := []struct {
+		name         string
+		decision     DecisionResponse
+		wantAllowed  bool
+		wantApproval bool
+	}{
+		{"allow", DecisionResponse{Decision: DecisionAllow}, true, false},
+		{"deny", DecisionResponse{Decision: DecisionDeny, Reason: "change freeze"}, false, false},
+		{"require approval", DecisionResponse{Decision: DecisionRequireApproval, Reason: "production change"}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook := fakeWebhook(t, "s3cret", tt.decision)
+
+			engine := NewEngine()
+			if local := engine.Validate("ls -la", "safe", false); !local.Allowed || local.RequiresApproval {
+				t.Fatalf("local policy should allow ls -la: %+v", local)
+			}
+
+			engine.SetDecisionPoint(NewDecisionPoint(webhook.URL, "s3cret", time.Second))
+			result := engine.Validate("ls -la", "safe", false)
+
+			if result.Allowed != tt.wantAllowed {
+				t.Errorf("Allowed = %v, want %v (%s)", result.Allowed, tt.wantAllowed, result.Reason)
+			}
+			if result.RequiresApproval != tt.wantApproval {
+				t.Errorf("RequiresApproval = %v, want %v", result.RequiresApproval, tt.wantApproval)
+			}
+			if result.RequiresApprover != tt.wantApproval {
+				t.Errorf("RequiresApprover = %v, want %v: the user can't approve it themselves", result.RequiresApprover, tt.wantApproval)
+			}
+			if tt.decision.Reason != "" && !strings.Contains(result.Reason, tt.decision.Reason) {
+				t.Errorf("Reason = %q, want the webhook's reason", result.Reason)
+			}
+		})
+	}
+}
+
+func TestEngine_Validate_DecisionPointLocalDeny(t *testing.T) {
+	called := false
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		json.NewEncoder(w).Encode(DecisionResponse{Decision: DecisionAllow})
+	}))
+	defer webhook.Close()
+
+	engine := NewEngine()
+	engine.SetDecisionPoint(NewDecisionPoint(webhook.URL, "s3cret", time.Second))
+
+	// The webhook can't allow what local policy denies
+	if result := engine.Validate("rm -rf /", "high", true); result.Allowed {
+		t.Error("expected local denylist to block the command")
+	}
+	if called {
+		t.Error("webhook was asked about a command local policy already denied")
+	}
+}
+
+func TestEngine_Validate_DecisionPointUnavailable(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"server error", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusInternalServerError) }},
+		{"unknown decision", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{"decision":"maybe"}`)) }},
+		{"not JSON", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook := httptest.NewServer(tt.handler)
+			defer webhook.Close()
+
+			engine := NewEngine()
+			engine.SetDecisionPoint(NewDecisionPoint(webhook.URL, "s3cret", time.Second))
+
+			// Fail closed
+			result := engine.Validate("ls -la", "safe", false)
+			if result.Allowed {
+				t.Error("expected the command to be blocked when the webhook fails")
+			}
+			if !strings.Contains(result.Reason, "Policy webhook unavailable") {
+				t.Errorf("Reason = %q", result.Reason)
+			}
+		})
+	}
+}
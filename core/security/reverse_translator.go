@@ -2,7 +2,6 @@ package security
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 
 	"github.com/SagheerAkram/QuickCmd/core/policy"
@@ -130,8 +129,27 @@ func (rt *ReverseTranslator) loadDangerousCommands() {
 		},
 	}
 
-	for _, cmd := range dangerous {
-		rt.dangerousCommands[cmd.Command] = &cmd
+	for i := range dangerous {
+		rt.dangerousCommands[dangerous[i].Command] = &dangerous[i]
+	}
+	
+	// Commands the translator refuses outright are dangerous too, and their
+	// example prompts are known ways of asking for them
+	for _, blocked := range translator.BlockedCommands() {
+		cmd, exists := rt.dangerousCommands[blocked.Command]
+		if !exists {
+			cmd = &DangerousCommand{
+				Command:           blocked.Command,
+				RiskLevel:         "critical",
+				ImpactDescription: blocked.SecurityNote,
+			}
+			rt.dangerousCommands[blocked.Command] = cmd
+		}
+		for _, prompt := range blocked.Examples {
+			if !containsString(cmd.NaturalPrompts, prompt) {
+				cmd.NaturalPrompts = append(cmd.NaturalPrompts, prompt)
+			}
+		}
 	}
 }
 
@@ -215,8 +233,8 @@ func (rt *ReverseTranslator) generateBlockRule(command string) *policy.Pattern {
 	pattern = strings.ReplaceAll(pattern, " ", "\\s+")
 	
 	return &policy.Pattern{
-		Pattern: pattern,
-		Reason:  fmt.Sprintf("Blocks dangerous command: %s", command),
+		Pattern:     pattern,
+		Description: fmt.Sprintf("Blocks dangerous command: %s", command),
 	}
 }
 
@@ -355,6 +373,15 @@ type SecurityReport struct {
 
 // Helper functions
 
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
 func escapeRegex(s string) string {
 	// Escape special regex characters
 	special := []string{".", "*", "+", "?", "^", "$", "(", ")", "[", "]", "{", "}", "|", "\\"}
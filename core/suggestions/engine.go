@@ -1,17 +1,82 @@
 package suggestions
 
 import (
+	"bufio"
+	"container/list"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/SagheerAkram/QuickCmd/core/translator"
+	"github.com/SagheerAkram/QuickCmd/core/cmdparse"
+	"github.com/SagheerAkram/QuickCmd/core/learning/store"
 )
 
-// SuggestionEngine analyzes command patterns and provides intelligent suggestions
+// DefaultMaxPatterns is the default number of command patterns tracked per
+// user before the least recently seen ones are evicted
+const DefaultMaxPatterns = 1000
+
+// DefaultMaxUsers is the default number of users whose patterns and
+// preferences are kept before the least recently active ones are evicted
+const DefaultMaxUsers = 1000
+
+// maxPreferredDirectories is how many directories are remembered per user
+const maxPreferredDirectories = 50
+
+// DefaultSuppressWindow is how long a rejected suggestion type stays hidden
+const DefaultSuppressWindow = 7 * 24 * time.Hour
+
+// frequentPatternThreshold is the frequency at which a pattern becomes an alias
+// candidate; such patterns are preferred over infrequent ones during eviction
+const frequentPatternThreshold = 5
+
+// feedbackKey is the learning store key holding suggestion suppressions
+const feedbackKey = "feedback"
+
+// SuggestionEngine analyzes command patterns and provides intelligent suggestions.
+// It is safe for concurrent use.
 type SuggestionEngine struct {
-	patterns map[string]*CommandPattern
-	prefs    map[string]*UserPreferences
+	mu sync.RWMutex // guards all fields below
+
+	patterns    map[string]*patternStore // userID -> that user's patterns
+	prefs       map[string]*UserPreferences
+	maxPatterns int // per user
+	workDir     string
+
+	users    *list.List // user IDs, most recently active first
+	usersIdx map[string]*list.Element
+	maxUsers int
+
+	suppressWindow time.Duration
+	now            func() time.Time
+	config         Config
+}
+
+// patternStore holds one user's command patterns, bounded with
+// least-recently-seen eviction
+type patternStore struct {
+	byHash     map[string]*CommandPattern
+	recency    *list.List // pattern hashes, most recently seen first
+	recencyIdx map[string]*list.Element
+}
+
+func newPatternStore() *patternStore {
+	return &patternStore{
+		byHash:     make(map[string]*CommandPattern),
+		recency:    list.New(),
+		recencyIdx: make(map[string]*list.Element),
+	}
+}
+
+// Config controls which suggestions the engine returns. The zero value
+// enables every suggestion type.
+type Config struct {
+	Disabled      bool     `yaml:"disabled"`       // turn suggestions off entirely
+	DisabledTypes []string `yaml:"disabled_types"` // e.g. "optimization", "context"
 }
 
 // CommandPattern represents a detected command pattern
@@ -30,8 +95,9 @@ type UserPreferences struct {
 	UserID              string
 	PreferredDirectories []string
 	CommonFlags         map[string]int
-	IgnoredSuggestions  []string
+	IgnoredSuggestions  map[string]time.Time // suggestion type -> suppression expiry
 	CommandHistory      []string
+	Transitions         map[string]map[string]int // command key -> next command key -> count
 }
 
 // Suggestion represents a command suggestion
@@ -46,22 +112,111 @@ type Suggestion struct {
 
 // NewSuggestionEngine creates a new suggestion engine
 func NewSuggestionEngine() *SuggestionEngine {
+	return NewSuggestionEngineWithLimit(DefaultMaxPatterns)
+}
+
+// NewSuggestionEngineWithLimit creates a suggestion engine that tracks at most
+// maxPatterns command patterns per user
+func NewSuggestionEngineWithLimit(maxPatterns int) *SuggestionEngine {
+	if maxPatterns <= 0 {
+		maxPatterns = DefaultMaxPatterns
+	}
+
 	return &SuggestionEngine{
-		patterns: make(map[string]*CommandPattern),
-		prefs:    make(map[string]*UserPreferences),
+		patterns:    make(map[string]*patternStore),
+		prefs:       make(map[string]*UserPreferences),
+		maxPatterns: maxPatterns,
+
+		users:    list.New(),
+		usersIdx: make(map[string]*list.Element),
+		maxUsers: DefaultMaxUsers,
+
+		suppressWindow: DefaultSuppressWindow,
+		now:            time.Now,
+	}
+}
+
+// SetConfig sets which suggestions the engine returns
+func (se *SuggestionEngine) SetConfig(config Config) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	se.config = config
+}
+
+// SetSuppressWindow sets how long a rejected suggestion type stays hidden.
+// It applies to rejections recorded afterwards.
+func (se *SuggestionEngine) SetSuppressWindow(window time.Duration) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	se.suppressWindow = window
+}
+
+// SetMaxUsers sets how many users' patterns and preferences are kept. When
+// more users are active, the least recently active ones are forgotten.
+func (se *SuggestionEngine) SetMaxUsers(maxUsers int) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	if maxUsers <= 0 {
+		maxUsers = DefaultMaxUsers
+	}
+	se.maxUsers = maxUsers
+	for se.users.Len() > se.maxUsers {
+		se.evictUser()
 	}
 }
 
+// userPrefs returns the preferences for a user, creating them if needed,
+// and marks the user as the most recently active. The caller must hold the
+// write lock.
+func (se *SuggestionEngine) userPrefs(userID string) *UserPreferences {
+	if e := se.usersIdx[userID]; e != nil {
+		se.users.MoveToFront(e)
+		return se.prefs[userID]
+	}
+
+	se.prefs[userID] = &UserPreferences{
+		UserID:             userID,
+		CommonFlags:        make(map[string]int),
+		IgnoredSuggestions: make(map[string]time.Time),
+		Transitions:        make(map[string]map[string]int),
+	}
+	se.usersIdx[userID] = se.users.PushFront(userID)
+	if se.users.Len() > se.maxUsers {
+		se.evictUser()
+	}
+	return se.prefs[userID]
+}
+
+// evictUser forgets the least recently active user
+func (se *SuggestionEngine) evictUser() {
+	userID := se.users.Remove(se.users.Back()).(string)
+	delete(se.usersIdx, userID)
+	delete(se.prefs, userID)
+	delete(se.patterns, userID)
+}
+
 // AnalyzeCommand analyzes a command and updates patterns
 func (se *SuggestionEngine) AnalyzeCommand(userID, command string) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
 	// Get or create user preferences
-	if se.prefs[userID] == nil {
-		se.prefs[userID] = &UserPreferences{
-			UserID:      userID,
-			CommonFlags: make(map[string]int),
+	prefs := se.userPrefs(userID)
+
+	// Learn which command tends to follow the previous one
+	if n := len(prefs.CommandHistory); n > 0 && strings.TrimSpace(command) != "" {
+		prevKey := commandKey(cmdparse.Parse(prefs.CommandHistory[n-1]))
+		nextKey := commandKey(cmdparse.Parse(command))
+		if prevKey != "" {
+			if prefs.Transitions[prevKey] == nil {
+				prefs.Transitions[prevKey] = make(map[string]int)
+			}
+			prefs.Transitions[prevKey][nextKey]++
 		}
 	}
-	prefs := se.prefs[userID]
 
 	// Add to history
 	prefs.CommandHistory = append(prefs.CommandHistory, command)
@@ -70,28 +225,40 @@ func (se *SuggestionEngine) AnalyzeCommand(userID, command string) {
 	}
 
 	// Extract base command
-	parts := strings.Fields(command)
-	if len(parts) == 0 {
+	parsed := cmdparse.Parse(command)
+	if parsed.Name == "" {
 		return
 	}
-	baseCmd := parts[0]
+	baseCmd := parsed.Name
 
 	// Create pattern hash
 	hash := generatePatternHash(command)
 
-	// Update or create pattern
-	if se.patterns[hash] == nil {
-		se.patterns[hash] = &CommandPattern{
+	// Update or create the user's pattern
+	store := se.patterns[userID]
+	if store == nil {
+		store = newPatternStore()
+		se.patterns[userID] = store
+	}
+
+	if store.byHash[hash] == nil {
+		store.byHash[hash] = &CommandPattern{
 			Hash:        hash,
 			BaseCommand: baseCmd,
 			Frequency:   1,
 			LastSeen:    time.Now(),
 			Variations:  []string{command},
 		}
+		store.recencyIdx[hash] = store.recency.PushFront(hash)
+
+		if len(store.byHash) > se.maxPatterns {
+			store.evict()
+		}
 	} else {
-		pattern := se.patterns[hash]
+		pattern := store.byHash[hash]
 		pattern.Frequency++
 		pattern.LastSeen = time.Now()
+		store.recency.MoveToFront(store.recencyIdx[hash])
 		
 		// Add variation if unique
 		if !contains(pattern.Variations, command) {
@@ -100,34 +267,62 @@ func (se *SuggestionEngine) AnalyzeCommand(userID, command string) {
 	}
 
 	// Track common flags
-	for _, part := range parts[1:] {
-		if strings.HasPrefix(part, "-") {
-			prefs.CommonFlags[part]++
-		}
+	for _, flag := range parsed.Flags {
+		prefs.CommonFlags[flag.Name]++
 	}
 
 	// Detect preferred directories
-	for _, part := range parts {
+	for _, part := range parsed.Args {
 		if strings.HasPrefix(part, "/") || strings.HasPrefix(part, "./") {
 			if !contains(prefs.PreferredDirectories, part) {
 				prefs.PreferredDirectories = append(prefs.PreferredDirectories, part)
+				if len(prefs.PreferredDirectories) > maxPreferredDirectories {
+					prefs.PreferredDirectories = prefs.PreferredDirectories[1:]
+				}
 			}
 		}
 	}
 }
 
+// evict removes the least recently seen infrequent pattern. If every older
+// pattern is frequent, the least recently seen one is removed instead. The
+// most recently seen pattern is never evicted.
+func (ps *patternStore) evict() {
+	var victim *list.Element
+	for e := ps.recency.Back(); e != nil && e != ps.recency.Front(); e = e.Prev() {
+		if ps.byHash[e.Value.(string)].Frequency < frequentPatternThreshold {
+			victim = e
+			break
+		}
+	}
+	if victim == nil {
+		victim = ps.recency.Back()
+	}
+
+	hash := ps.recency.Remove(victim).(string)
+	delete(ps.recencyIdx, hash)
+	delete(ps.byHash, hash)
+}
+
 // GetSuggestions returns personalized suggestions for a user
 func (se *SuggestionEngine) GetSuggestions(userID, currentPrompt string) []Suggestion {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
 	suggestions := []Suggestion{}
 
 	prefs := se.prefs[userID]
-	if prefs == nil {
+	if prefs == nil || se.config.Disabled {
 		return suggestions
 	}
 
-	// 1. Alias suggestions based on frequency
-	for _, pattern := range se.patterns {
-		if pattern.Frequency >= 5 && pattern.SuggestedAlias == "" {
+	// 1. Alias suggestions based on the user's own frequent patterns
+	var patterns map[string]*CommandPattern
+	if store := se.patterns[userID]; store != nil {
+		patterns = store.byHash
+	}
+	for _, pattern := range patterns {
+		if pattern.Frequency >= frequentPatternThreshold && pattern.SuggestedAlias == "" {
 			suggestions = append(suggestions, Suggestion{
 				Type:        "alias",
 				Title:       "Create Alias",
@@ -150,21 +345,29 @@ func (se *SuggestionEngine) GetSuggestions(userID, currentPrompt string) []Sugge
 	suggestions = append(suggestions, optSuggestions...)
 
 	// 4. Pattern-based predictions
-	if len(prefs.CommandHistory) > 0 {
-		predicted := se.predictNextCommand(prefs.CommandHistory)
-		if predicted != "" {
-			suggestions = append(suggestions, Suggestion{
-				Type:        "pattern",
-				Title:       "Predicted Next Command",
-				Description: "Based on your command history",
-				Command:     predicted,
-				Confidence:  70,
-				Reason:      "Common command sequence detected",
-			})
+	suggestions = append(suggestions, se.predictNextCommands(prefs, 1)...)
+
+	// Drop disabled suggestion types and ones the user recently rejected
+	filtered := []Suggestion{}
+	for _, suggestion := range suggestions {
+		if contains(se.config.DisabledTypes, suggestion.Type) {
+			continue
+		}
+		if se.shouldSuggest(userID, suggestion.Type) {
+			filtered = append(filtered, suggestion)
 		}
 	}
 
-	return suggestions
+	return filtered
+}
+
+// SetWorkDir sets the directory inspected for context suggestions. An empty
+// dir means the process working directory.
+func (se *SuggestionEngine) SetWorkDir(dir string) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	se.workDir = dir
 }
 
 // getContextSuggestions provides context-aware suggestions
@@ -200,6 +403,80 @@ func (se *SuggestionEngine) getContextSuggestions(userID, prompt string) []Sugge
 		})
 	}
 
+	// Suggest commands based on what is in the working directory
+	suggestions = append(suggestions, se.getDirectorySuggestions()...)
+
+	return suggestions
+}
+
+// getDirectorySuggestions inspects the working directory for project markers
+// such as a git repository, Dockerfile or Makefile
+func (se *SuggestionEngine) getDirectorySuggestions() []Suggestion {
+	suggestions := []Suggestion{}
+
+	dir := se.workDir
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return suggestions
+		}
+		dir = wd
+	}
+
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	if exists(".git") {
+		suggestions = append(suggestions, Suggestion{
+			Type:        "context",
+			Title:       "Check Repository Status",
+			Description: "This directory is a git repository",
+			Command:     "git status",
+			Confidence:  70,
+			Reason:      "Git repository detected",
+		})
+	}
+
+	for _, name := range []string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"} {
+		if exists(name) {
+			suggestions = append(suggestions, Suggestion{
+				Type:        "context",
+				Title:       "Start Compose Services",
+				Description: fmt.Sprintf("Found %s in this directory", name),
+				Command:     "docker compose up -d",
+				Confidence:  70,
+				Reason:      "Docker Compose file detected",
+			})
+			break
+		}
+	}
+
+	if exists("Dockerfile") {
+		suggestions = append(suggestions, Suggestion{
+			Type:        "context",
+			Title:       "Build Docker Image",
+			Description: "Found Dockerfile in this directory",
+			Command:     fmt.Sprintf("docker build -t %s .", strings.ToLower(filepath.Base(dir))),
+			Confidence:  70,
+			Reason:      "Dockerfile detected",
+		})
+	}
+
+	if exists("Makefile") {
+		for _, target := range makeTargets(filepath.Join(dir, "Makefile"), 3) {
+			suggestions = append(suggestions, Suggestion{
+				Type:        "context",
+				Title:       "Run Make Target",
+				Description: fmt.Sprintf("Makefile defines target %q", target),
+				Command:     "make " + target,
+				Confidence:  65,
+				Reason:      "Makefile detected",
+			})
+		}
+	}
+
 	return suggestions
 }
 
@@ -211,14 +488,14 @@ func (se *SuggestionEngine) getOptimizationSuggestions(userID string) []Suggesti
 	// Analyze recent commands for optimization opportunities
 	for _, cmd := range prefs.CommandHistory {
 		// Suggest using grep instead of find + grep
-		if strings.Contains(cmd, "find") && strings.Contains(cmd, "grep") {
+		if optimized, ok := optimizeFindGrep(cmd); ok {
 			suggestions = append(suggestions, Suggestion{
 				Type:        "optimization",
 				Title:       "Optimize Search",
 				Description: "Use grep -r for faster recursive search",
-				Command:     optimizeFindGrep(cmd),
+				Command:     optimized,
 				Confidence:  85,
-				Reason:      "grep -r is faster than find | grep",
+				Reason:      "grep -r is faster than find | xargs grep",
 			})
 		}
 
@@ -237,8 +514,92 @@ func (se *SuggestionEngine) getOptimizationSuggestions(userID string) []Suggesti
 	return suggestions
 }
 
-// predictNextCommand predicts the next command based on history
-func (se *SuggestionEngine) predictNextCommand(history []string) string {
+// TopPredictions returns up to n predicted next commands for a user, most
+// likely first
+func (se *SuggestionEngine) TopPredictions(userID string, n int) []Suggestion {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	prefs := se.prefs[userID]
+	if prefs == nil || se.config.Disabled || contains(se.config.DisabledTypes, "pattern") || !se.shouldSuggest(userID, "pattern") {
+		return []Suggestion{}
+	}
+
+	return se.predictNextCommands(prefs, n)
+}
+
+// predictNextCommands predicts up to n next commands from the transitions
+// learned from the user's history. Confidence is the share of times each
+// command followed the last one. Without learned data it falls back to
+// common workflows.
+func (se *SuggestionEngine) predictNextCommands(prefs *UserPreferences, n int) []Suggestion {
+	predictions := []Suggestion{}
+	if len(prefs.CommandHistory) == 0 || n <= 0 {
+		return predictions
+	}
+
+	lastCmd := prefs.CommandHistory[len(prefs.CommandHistory)-1]
+	lastKey := commandKey(cmdparse.Parse(lastCmd))
+
+	successors := prefs.Transitions[lastKey]
+	if len(successors) == 0 {
+		if predicted := predictNextCommand(prefs.CommandHistory); predicted != "" {
+			predictions = append(predictions, Suggestion{
+				Type:        "pattern",
+				Title:       "Predicted Next Command",
+				Description: "Based on common workflows",
+				Command:     predicted,
+				Confidence:  70,
+				Reason:      "Common command sequence detected",
+			})
+		}
+		return predictions
+	}
+
+	total := 0
+	keys := make([]string, 0, len(successors))
+	for key, count := range successors {
+		keys = append(keys, key)
+		total += count
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if successors[keys[i]] != successors[keys[j]] {
+			return successors[keys[i]] > successors[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	for _, key := range keys {
+		if len(predictions) == n {
+			break
+		}
+		count := successors[key]
+		predictions = append(predictions, Suggestion{
+			Type:        "pattern",
+			Title:       "Predicted Next Command",
+			Description: fmt.Sprintf("You ran %s after %s %d times", key, lastKey, count),
+			Command:     key,
+			Confidence:  transitionConfidence(count, total),
+			Reason:      "Learned from your command history",
+		})
+	}
+
+	return predictions
+}
+
+// transitionConfidence converts a transition's share of all transitions from
+// the same command into a confidence, capped below certainty
+func transitionConfidence(count, total int) int {
+	confidence := count * 100 / total
+	if confidence > 95 {
+		confidence = 95
+	}
+	return confidence
+}
+
+// predictNextCommand predicts the next command from common workflows when
+// nothing has been learned yet
+func predictNextCommand(history []string) string {
 	if len(history) < 2 {
 		return ""
 	}
@@ -281,15 +642,32 @@ func (se *SuggestionEngine) SuggestAlias(pattern *CommandPattern) string {
 
 // Helper functions
 
+// commandKey returns the base command, plus the subcommand for tools like
+// git and kubectl
+func commandKey(parsed *cmdparse.Command) string {
+	if parsed.Subcommand != "" {
+		return parsed.Name + " " + parsed.Subcommand
+	}
+	return parsed.Name
+}
+
 func generatePatternHash(command string) string {
-	// Simple hash based on base command and structure
-	parts := strings.Fields(command)
-	if len(parts) == 0 {
+	parsed := cmdparse.Parse(command)
+	key := commandKey(parsed)
+	if key == "" {
 		return ""
 	}
-	
-	// Use base command + number of args as hash
-	return fmt.Sprintf("%s-%d", parts[0], len(parts))
+
+	// Normalized flag set: values stripped, sorted, deduplicated
+	flags := []string{}
+	for _, flag := range parsed.Flags {
+		if !contains(flags, flag.Name) {
+			flags = append(flags, flag.Name)
+		}
+	}
+	sort.Strings(flags)
+
+	return key + " " + strings.Join(flags, " ")
 }
 
 func generateAliasName(baseCmd string) string {
@@ -323,17 +701,169 @@ func extractBaseCommand(prompt string) string {
 }
 
 func extractDirName(cmd string) string {
-	parts := strings.Fields(cmd)
-	if len(parts) >= 2 {
-		return parts[1]
+	if args := cmdparse.Parse(cmd).Args; len(args) > 0 {
+		return args[0]
 	}
 	return ""
 }
 
-func optimizeFindGrep(cmd string) string {
-	// Convert "find . -name '*.log' | grep error" to "grep -r error --include='*.log' ."
-	// Simplified version
-	return "grep -r [pattern] --include='*.log' ."
+// optimizeFindGrep rewrites a
+// "find DIR -type f [-name GLOB] | xargs grep [-i] [-l] PATTERN" pipeline as
+// "grep -r PATTERN --include=GLOB DIR", which searches the contents of the
+// same files. Greps after the first are kept as a pipe. It reports false if
+// cmd is not such a pipeline or the rewrite would search different files:
+// "find | grep" without xargs matches file names, not contents, and without
+// -type f find also lists symlinks, which grep -r skips.
+func optimizeFindGrep(cmd string) (string, bool) {
+	stages := cmdparse.Pipeline(cmd)
+	if len(stages) < 2 || len(stages[0]) == 0 || stages[0][0] != "find" {
+		return "", false
+	}
+
+	dir, glob, print0, ok := parseFindStage(stages[0][1:])
+	if !ok {
+		return "", false
+	}
+
+	// xargs splits find's output into file names: with -0 only if find
+	// printed them with -print0
+	grepArgs := stages[1]
+	if len(grepArgs) == 0 || grepArgs[0] != "xargs" {
+		return "", false
+	}
+	grepArgs = grepArgs[1:]
+	if len(grepArgs) > 0 && grepArgs[0] == "-0" {
+		if !print0 {
+			return "", false
+		}
+		grepArgs = grepArgs[1:]
+	} else if print0 {
+		return "", false
+	}
+	if len(grepArgs) == 0 || grepArgs[0] != "grep" {
+		return "", false
+	}
+
+	flags, pattern, ok := parseGrepStage(grepArgs[1:])
+	if !ok {
+		return "", false
+	}
+
+	parts := []string{"grep", "-r" + flags, shellQuote(pattern)}
+	if glob != "" {
+		parts = append(parts, "--include="+shellQuote(glob))
+	}
+	parts = append(parts, shellQuote(dir))
+	optimized := strings.Join(parts, " ")
+
+	for _, stage := range stages[2:] {
+		if len(stage) == 0 || stage[0] != "grep" {
+			return "", false
+		}
+		quoted := make([]string, len(stage))
+		for i, arg := range stage {
+			quoted[i] = shellQuote(arg)
+		}
+		optimized += " | " + strings.Join(quoted, " ")
+	}
+
+	return optimized, true
+}
+
+// parseFindStage extracts the search directory, -name glob and whether
+// -print0 is used from find's arguments. Only -name, -print0 and the
+// required -type f are understood.
+func parseFindStage(args []string) (dir, glob string, print0, ok bool) {
+	dir = "."
+	files := false
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-name":
+			if i+1 >= len(args) || glob != "" {
+				return "", "", false, false
+			}
+			glob = args[i+1]
+			i++
+		case arg == "-type":
+			if i+1 >= len(args) || args[i+1] != "f" {
+				return "", "", false, false
+			}
+			files = true
+			i++
+		case arg == "-print0":
+			print0 = true
+		case strings.HasPrefix(arg, "-"):
+			return "", "", false, false
+		case i == 0:
+			dir = arg
+		default:
+			return "", "", false, false
+		}
+	}
+	return dir, glob, print0, files
+}
+
+// parseGrepStage extracts the -i and -l flags and the pattern from grep's
+// arguments
+func parseGrepStage(args []string) (flags, pattern string, ok bool) {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") && pattern == "" && len(arg) > 1 {
+			for _, f := range arg[1:] {
+				if f != 'i' && f != 'l' {
+					return "", "", false
+				}
+				if !strings.ContainsRune(flags, f) {
+					flags += string(f)
+				}
+			}
+			continue
+		}
+		if pattern != "" {
+			return "", "", false
+		}
+		pattern = arg
+	}
+	return flags, pattern, pattern != ""
+}
+
+// shellQuote single-quotes s if it contains characters the shell would
+// interpret
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`|&;<>()*?[]{}!#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// makeTargets returns up to max target names defined in a Makefile
+func makeTargets(path string, max int) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	targets := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() && len(targets) < max {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "\t") || strings.HasPrefix(line, ".") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		idx := strings.Index(line, ":")
+		if idx <= 0 || strings.HasPrefix(line[idx:], ":=") {
+			continue
+		}
+
+		name := strings.TrimSpace(line[:idx])
+		if name == "" || strings.ContainsAny(name, " $%=") || contains(targets, name) {
+			continue
+		}
+		targets = append(targets, name)
+	}
+
+	return targets
 }
 
 func contains(slice []string, item string) bool {
@@ -356,26 +886,153 @@ const (
 
 // RecordFeedback records user feedback on a suggestion
 func (se *SuggestionEngine) RecordFeedback(userID, suggestionType string, feedback FeedbackType) {
-	prefs := se.prefs[userID]
-	if prefs == nil {
-		return
-	}
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	prefs := se.userPrefs(userID)
 
-	// If rejected multiple times, add to ignored list
-	if feedback == FeedbackRejected {
-		key := fmt.Sprintf("%s-%s", suggestionType, time.Now().Format("2006-01-02"))
-		prefs.IgnoredSuggestions = append(prefs.IgnoredSuggestions, key)
+	switch feedback {
+	case FeedbackRejected:
+		// Suppress this suggestion type for the suppress window
+		prefs.IgnoredSuggestions[suggestionType] = se.now().Add(se.suppressWindow)
+	case FeedbackAccepted:
+		// Accepting a suggestion lifts any earlier suppression
+		delete(prefs.IgnoredSuggestions, suggestionType)
 	}
 }
 
 // ShouldSuggest checks if a suggestion should be shown based on feedback
 func (se *SuggestionEngine) ShouldSuggest(userID, suggestionType string) bool {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	return se.shouldSuggest(userID, suggestionType)
+}
+
+// shouldSuggest is ShouldSuggest for callers already holding the lock
+func (se *SuggestionEngine) shouldSuggest(userID, suggestionType string) bool {
 	prefs := se.prefs[userID]
 	if prefs == nil {
 		return true
 	}
 
-	// Check if recently ignored
-	key := fmt.Sprintf("%s-%s", suggestionType, time.Now().Format("2006-01-02"))
-	return !contains(prefs.IgnoredSuggestions, key)
+	// Check if a rejection is still suppressing this type
+	expiry, ok := prefs.IgnoredSuggestions[suggestionType]
+	if !ok {
+		return true
+	}
+	return !se.now().Before(expiry)
+}
+
+// PruneExpired drops suppressions whose cooldown has passed and returns how
+// many were removed
+func (se *SuggestionEngine) PruneExpired() int {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	now := se.now()
+	pruned := 0
+	for _, prefs := range se.prefs {
+		for suggestionType, expiry := range prefs.IgnoredSuggestions {
+			if !now.Before(expiry) {
+				delete(prefs.IgnoredSuggestions, suggestionType)
+				pruned++
+			}
+		}
+	}
+
+	return pruned
+}
+
+// SaveFeedback writes active suggestion suppressions to a JSON file
+func (se *SuggestionEngine) SaveFeedback(path string) error {
+	data, err := json.MarshalIndent(se.activeFeedback(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal feedback: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create feedback directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write feedback file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadFeedback restores suggestion suppressions saved by SaveFeedback, skipping
+// any that have since expired. A missing file is not an error.
+func (se *SuggestionEngine) LoadFeedback(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read feedback file: %w", err)
+	}
+
+	var feedback map[string]map[string]time.Time
+	if err := json.Unmarshal(data, &feedback); err != nil {
+		return fmt.Errorf("failed to parse feedback file: %w", err)
+	}
+
+	se.restoreFeedback(feedback)
+	return nil
+}
+
+// SaveFeedbackTo persists active suggestion suppressions in ns
+func (se *SuggestionEngine) SaveFeedbackTo(ns *store.Namespace) error {
+	return ns.Put(feedbackKey, se.activeFeedback())
+}
+
+// LoadFeedbackFrom restores suggestion suppressions saved by SaveFeedbackTo,
+// skipping any that have since expired. An empty namespace is not an error.
+func (se *SuggestionEngine) LoadFeedbackFrom(ns *store.Namespace) error {
+	var feedback map[string]map[string]time.Time
+	if _, err := ns.Get(feedbackKey, &feedback); err != nil {
+		return err
+	}
+
+	se.restoreFeedback(feedback)
+	return nil
+}
+
+// activeFeedback returns the unexpired suppressions by user and suggestion
+// type
+func (se *SuggestionEngine) activeFeedback() map[string]map[string]time.Time {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	now := se.now()
+	feedback := make(map[string]map[string]time.Time)
+	for userID, prefs := range se.prefs {
+		ignored := make(map[string]time.Time, len(prefs.IgnoredSuggestions))
+		for suggestionType, expiry := range prefs.IgnoredSuggestions {
+			if now.Before(expiry) {
+				ignored[suggestionType] = expiry
+			}
+		}
+		if len(ignored) > 0 {
+			feedback[userID] = ignored
+		}
+	}
+
+	return feedback
+}
+
+// restoreFeedback adds the unexpired suppressions in feedback
+func (se *SuggestionEngine) restoreFeedback(feedback map[string]map[string]time.Time) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	now := se.now()
+	for userID, ignored := range feedback {
+		for suggestionType, expiry := range ignored {
+			if now.Before(expiry) {
+				se.userPrefs(userID).IgnoredSuggestions[suggestionType] = expiry
+			}
+		}
+	}
 }
@@ -0,0 +1,613 @@
+package suggestions
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SagheerAkram/QuickCmd/core/learning/store"
+)
+
+func TestSuggestionEngine_PatternEviction(t *testing.T) {
+	engine := NewSuggestionEngineWithLimit(3)
+
+	// Make one pattern frequent so it survives eviction
+	for i := 0; i < frequentPatternThreshold; i++ {
+		engine.AnalyzeCommand("alice", "git status")
+	}
+
+	engine.AnalyzeCommand("alice", "ls -la")
+	engine.AnalyzeCommand("alice", "docker ps")
+
+	// Exceeds the bound: oldest infrequent pattern ("ls") must go
+	engine.AnalyzeCommand("alice", "kubectl get pods")
+
+	patterns := engine.patterns["alice"].byHash
+	if len(patterns) != 3 {
+		t.Fatalf("pattern count = %d, want 3", len(patterns))
+	}
+
+	for _, cmd := range []string{"git status", "docker ps", "kubectl get pods"} {
+		if patterns[generatePatternHash(cmd)] == nil {
+			t.Errorf("pattern for %q was evicted", cmd)
+		}
+	}
+	if patterns[generatePatternHash("ls -la")] != nil {
+		t.Error("least recently seen infrequent pattern was not evicted")
+	}
+}
+
+func TestSuggestionEngine_EvictionPrefersRecent(t *testing.T) {
+	engine := NewSuggestionEngineWithLimit(2)
+
+	engine.AnalyzeCommand("alice", "ls -la")
+	engine.AnalyzeCommand("alice", "docker ps")
+	engine.AnalyzeCommand("alice", "ls -la") // refresh "ls"
+	engine.AnalyzeCommand("alice", "kubectl get pods")
+
+	patterns := engine.patterns["alice"].byHash
+	if patterns[generatePatternHash("ls -la")] == nil {
+		t.Error("recently seen pattern was evicted")
+	}
+	if patterns[generatePatternHash("docker ps")] != nil {
+		t.Error("least recently seen pattern was not evicted")
+	}
+}
+
+func TestSuggestionEngine_PatternBound(t *testing.T) {
+	engine := NewSuggestionEngineWithLimit(10)
+
+	for i := 0; i < 100; i++ {
+		engine.AnalyzeCommand("alice", fmt.Sprintf("cmd%d", i))
+	}
+
+	store := engine.patterns["alice"]
+	if len(store.byHash) != 10 {
+		t.Errorf("pattern count = %d, want 10", len(store.byHash))
+	}
+	if store.recency.Len() != len(store.byHash) || len(store.recencyIdx) != len(store.byHash) {
+		t.Error("recency tracking out of sync with patterns")
+	}
+}
+
+func TestSuggestionEngine_UserBound(t *testing.T) {
+	engine := NewSuggestionEngine()
+	engine.SetMaxUsers(3)
+
+	for i := 0; i < 100; i++ {
+		engine.AnalyzeCommand(fmt.Sprintf("user-%d", i), "git status")
+		// alice stays active, so she is never evicted
+		engine.RecordFeedback("alice", "context", FeedbackRejected)
+	}
+
+	if len(engine.prefs) != 3 || len(engine.patterns) != 2 || engine.users.Len() != 3 || len(engine.usersIdx) != 3 {
+		t.Fatalf("tracking %d prefs, %d pattern stores, %d users; want 3, 2, 3",
+			len(engine.prefs), len(engine.patterns), engine.users.Len())
+	}
+	for _, userID := range []string{"alice", "user-99", "user-98"} {
+		if engine.prefs[userID] == nil {
+			t.Errorf("%s evicted", userID)
+		}
+	}
+	if engine.ShouldSuggest("alice", "context") {
+		t.Error("alice's feedback lost")
+	}
+
+	for i := 0; i < 200; i++ {
+		engine.AnalyzeCommand("alice", fmt.Sprintf("cd ./dir%d", i))
+	}
+	if n := len(engine.prefs["alice"].PreferredDirectories); n != maxPreferredDirectories {
+		t.Errorf("preferred directories = %d, want %d", n, maxPreferredDirectories)
+	}
+}
+
+func TestGeneratePatternHash(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{"different git subcommands", "git commit -m x", "git push origin main", false},
+		{"same subcommand different values", "git commit -m first", "git commit -m second", true},
+		{"flag values stripped", "kubectl get pods --namespace=dev", "kubectl get pods --namespace=prod", true},
+		{"flag order ignored", "ls -l -a /tmp", "ls -a -l /var", true},
+		{"different flags", "ls -la", "ls -R", false},
+		{"different kubectl subcommands", "kubectl get pods", "kubectl delete pods", false},
+		{"combined short flags", "ls -la", "ls -l -a", true},
+		{"quoted argument", `git commit -m "fix the build"`, "git commit -m fix", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ha, hb := generatePatternHash(tt.a), generatePatternHash(tt.b)
+			if (ha == hb) != tt.same {
+				t.Errorf("hash(%q) = %q, hash(%q) = %q, want same = %v", tt.a, ha, tt.b, hb, tt.same)
+			}
+		})
+	}
+}
+
+func TestSuggestionEngine_DirectorySuggestions(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	makefile := ".PHONY: build\nVERSION := 1.0\n\nbuild:\n\tgo build ./...\n\ntest: build\n\tgo test ./...\n"
+	if err := os.WriteFile(filepath.Join(dir, "Makefile"), []byte(makefile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := NewSuggestionEngine()
+	engine.SetWorkDir(dir)
+	engine.AnalyzeCommand("alice", "ls -la")
+
+	commands := map[string]bool{}
+	for _, s := range engine.GetSuggestions("alice", "build the project") {
+		if s.Type == "context" {
+			commands[s.Command] = true
+		}
+	}
+
+	dockerBuild := fmt.Sprintf("docker build -t %s .", strings.ToLower(filepath.Base(dir)))
+	for _, want := range []string{dockerBuild, "make build", "make test"} {
+		if !commands[want] {
+			t.Errorf("missing context suggestion %q, got %v", want, commands)
+		}
+	}
+	if commands["git status"] {
+		t.Error("unexpected git suggestion without .git directory")
+	}
+}
+
+func TestSuggestionEngine_RejectionWindow(t *testing.T) {
+	now := time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC)
+
+	engine := NewSuggestionEngine()
+	engine.SetSuppressWindow(48 * time.Hour)
+	engine.now = func() time.Time { return now }
+
+	if !engine.ShouldSuggest("alice", "optimization") {
+		t.Fatal("suggestion suppressed before any feedback")
+	}
+
+	engine.RecordFeedback("alice", "optimization", FeedbackRejected)
+
+	// Still suppressed after the calendar day changes
+	now = now.Add(time.Hour)
+	if engine.ShouldSuggest("alice", "optimization") {
+		t.Error("rejected suggestion shown on the next day within the window")
+	}
+	if !engine.ShouldSuggest("alice", "alias") {
+		t.Error("unrelated suggestion type suppressed")
+	}
+	if !engine.ShouldSuggest("bob", "optimization") {
+		t.Error("rejection leaked to another user")
+	}
+
+	// Shown again once the window has passed
+	now = now.Add(48 * time.Hour)
+	if !engine.ShouldSuggest("alice", "optimization") {
+		t.Error("rejected suggestion still suppressed after the window")
+	}
+}
+
+func TestSuggestionEngine_DefaultCooldown(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	engine := NewSuggestionEngine()
+	engine.now = func() time.Time { return now }
+	engine.RecordFeedback("alice", "alias", FeedbackRejected)
+
+	now = now.Add(DefaultSuppressWindow - time.Minute)
+	if engine.ShouldSuggest("alice", "alias") {
+		t.Error("rejected suggestion shown before the cooldown ended")
+	}
+
+	now = now.Add(time.Minute)
+	if !engine.ShouldSuggest("alice", "alias") {
+		t.Error("rejected suggestion still suppressed after the cooldown")
+	}
+}
+
+func TestSuggestionEngine_PruneExpired(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	engine := NewSuggestionEngine()
+	engine.now = func() time.Time { return now }
+	engine.RecordFeedback("alice", "alias", FeedbackRejected)
+	engine.RecordFeedback("bob", "alias", FeedbackRejected)
+
+	now = now.Add(24 * time.Hour)
+	engine.RecordFeedback("alice", "optimization", FeedbackRejected)
+
+	if pruned := engine.PruneExpired(); pruned != 0 {
+		t.Errorf("PruneExpired() = %d before any cooldown ended, want 0", pruned)
+	}
+
+	now = now.Add(DefaultSuppressWindow - time.Hour)
+	if pruned := engine.PruneExpired(); pruned != 2 {
+		t.Errorf("PruneExpired() = %d, want 2", pruned)
+	}
+	if _, ok := engine.prefs["alice"].IgnoredSuggestions["optimization"]; !ok {
+		t.Error("active suppression was pruned")
+	}
+	if len(engine.prefs["bob"].IgnoredSuggestions) != 0 {
+		t.Errorf("expired suppressions kept: %v", engine.prefs["bob"].IgnoredSuggestions)
+	}
+}
+
+func TestSuggestionEngine_RejectedTypeFiltered(t *testing.T) {
+	engine := NewSuggestionEngine()
+	engine.SetWorkDir(t.TempDir())
+	engine.AnalyzeCommand("alice", "find . -type f -name '*.log' | xargs grep error")
+
+	hasType := func(suggestionType string) bool {
+		for _, s := range engine.GetSuggestions("alice", "") {
+			if s.Type == suggestionType {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !hasType("optimization") {
+		t.Fatal("expected an optimization suggestion")
+	}
+
+	engine.RecordFeedback("alice", "optimization", FeedbackRejected)
+	if hasType("optimization") {
+		t.Error("rejected suggestion type still returned")
+	}
+
+	engine.RecordFeedback("alice", "optimization", FeedbackAccepted)
+	if !hasType("optimization") {
+		t.Error("accepted suggestion type still suppressed")
+	}
+}
+
+func TestSuggestionEngine_FeedbackPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feedback.json")
+
+	engine := NewSuggestionEngine()
+	engine.RecordFeedback("alice", "alias", FeedbackRejected)
+	if err := engine.SaveFeedback(path); err != nil {
+		t.Fatalf("SaveFeedback() error = %v", err)
+	}
+
+	restored := NewSuggestionEngine()
+	if err := restored.LoadFeedback(path); err != nil {
+		t.Fatalf("LoadFeedback() error = %v", err)
+	}
+	if restored.ShouldSuggest("alice", "alias") {
+		t.Error("rejection not restored from file")
+	}
+
+	if err := NewSuggestionEngine().LoadFeedback(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("LoadFeedback() on missing file error = %v", err)
+	}
+}
+
+func TestSuggestionEngine_FeedbackStore(t *testing.T) {
+	learned, err := store.Open(filepath.Join(t.TempDir(), "learning.json"))
+	if err != nil {
+		t.Fatalf("store.Open() error = %v", err)
+	}
+	ns := learned.Namespace("suggestions")
+
+	engine := NewSuggestionEngine()
+	engine.RecordFeedback("alice", "alias", FeedbackRejected)
+	if err := engine.SaveFeedbackTo(ns); err != nil {
+		t.Fatalf("SaveFeedbackTo() error = %v", err)
+	}
+
+	restored := NewSuggestionEngine()
+	if err := restored.LoadFeedbackFrom(ns); err != nil {
+		t.Fatalf("LoadFeedbackFrom() error = %v", err)
+	}
+	if restored.ShouldSuggest("alice", "alias") {
+		t.Error("rejection not restored from learning store")
+	}
+
+	if err := NewSuggestionEngine().LoadFeedbackFrom(learned.Namespace("empty")); err != nil {
+		t.Errorf("LoadFeedbackFrom() on empty namespace error = %v", err)
+	}
+}
+
+func TestSuggestionEngine_Config(t *testing.T) {
+	engine := NewSuggestionEngine()
+	engine.SetWorkDir(t.TempDir())
+	engine.AnalyzeCommand("alice", "git add .")
+	engine.AnalyzeCommand("alice", "find . -type f -name '*.log' | xargs grep error")
+	engine.AnalyzeCommand("alice", "git add README.md")
+
+	types := func() map[string]bool {
+		seen := map[string]bool{}
+		for _, s := range engine.GetSuggestions("alice", "") {
+			seen[s.Type] = true
+		}
+		return seen
+	}
+
+	if seen := types(); !seen["optimization"] || !seen["pattern"] {
+		t.Fatalf("expected optimization and pattern suggestions, got %v", seen)
+	}
+
+	engine.SetConfig(Config{DisabledTypes: []string{"optimization"}})
+	seen := types()
+	if seen["optimization"] {
+		t.Error("disabled optimization suggestions still returned")
+	}
+	if !seen["pattern"] {
+		t.Error("enabled pattern suggestions filtered out")
+	}
+
+	engine.SetConfig(Config{Disabled: true})
+	if got := engine.GetSuggestions("alice", ""); len(got) != 0 {
+		t.Errorf("suggestions disabled but got %d", len(got))
+	}
+}
+
+func TestSuggestionEngine_ConcurrentAccess(t *testing.T) {
+	engine := NewSuggestionEngineWithLimit(20)
+	engine.SetWorkDir(t.TempDir())
+
+	commands := []string{"git add .", "git commit -m wip", "find . -type f -name '*.go' | xargs grep main", "ls -la /tmp"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			userID := fmt.Sprintf("user-%d", i%5)
+			for j := 0; j < 50; j++ {
+				engine.AnalyzeCommand(userID, fmt.Sprintf("%s %d", commands[j%len(commands)], j%30))
+				engine.GetSuggestions(userID, "find files")
+				if j%10 == 0 {
+					engine.RecordFeedback(userID, "optimization", FeedbackRejected)
+					engine.ShouldSuggest(userID, "optimization")
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for userID, store := range engine.patterns {
+		if len(store.byHash) > 20 {
+			t.Errorf("Tracked %d patterns for %s, want at most 20", len(store.byHash), userID)
+		}
+	}
+}
+
+func TestSuggestionEngine_LearnedPrediction(t *testing.T) {
+	engine := NewSuggestionEngine()
+
+	// A repeated workflow: build, test, then usually deploy
+	for i := 0; i < 3; i++ {
+		engine.AnalyzeCommand("alice", "make build")
+		engine.AnalyzeCommand("alice", "go test ./...")
+		engine.AnalyzeCommand("alice", "kubectl apply -f deploy.yaml")
+	}
+	engine.AnalyzeCommand("alice", "go test -run Foo")
+	engine.AnalyzeCommand("alice", "git status")
+	engine.AnalyzeCommand("alice", "go test ./core/...")
+
+	predictions := engine.TopPredictions("alice", 3)
+	if len(predictions) != 2 {
+		t.Fatalf("TopPredictions() returned %d predictions, want 2: %v", len(predictions), predictions)
+	}
+
+	if predictions[0].Command != "kubectl apply" {
+		t.Errorf("top prediction = %q, want %q", predictions[0].Command, "kubectl apply")
+	}
+	if predictions[1].Command != "git status" {
+		t.Errorf("second prediction = %q, want %q", predictions[1].Command, "git status")
+	}
+	if predictions[0].Confidence != 75 || predictions[1].Confidence != 25 {
+		t.Errorf("confidences = %d, %d; want 75, 25", predictions[0].Confidence, predictions[1].Confidence)
+	}
+
+	if got := engine.TopPredictions("alice", 1); len(got) != 1 {
+		t.Errorf("TopPredictions(1) returned %d predictions", len(got))
+	}
+
+	// GetSuggestions includes the learned prediction, not the fallback
+	found := false
+	for _, s := range engine.GetSuggestions("alice", "") {
+		if s.Type == "pattern" {
+			found = true
+			if s.Command != "kubectl apply" {
+				t.Errorf("GetSuggestions() prediction = %q", s.Command)
+			}
+		}
+	}
+	if !found {
+		t.Error("GetSuggestions() has no prediction")
+	}
+}
+
+func TestSuggestionEngine_PredictionFallback(t *testing.T) {
+	engine := NewSuggestionEngine()
+	engine.AnalyzeCommand("alice", "ls")
+	engine.AnalyzeCommand("alice", "git add .")
+
+	predictions := engine.TopPredictions("alice", 3)
+	if len(predictions) != 1 || predictions[0].Command != "git commit -m '...'" {
+		t.Errorf("TopPredictions() = %v, want the git commit fallback", predictions)
+	}
+
+	// Once learned, the user's own habit wins over the fallback
+	engine.AnalyzeCommand("alice", "git diff --cached")
+	engine.AnalyzeCommand("alice", "git add main.go")
+
+	predictions = engine.TopPredictions("alice", 3)
+	if len(predictions) != 1 || predictions[0].Command != "git diff" {
+		t.Errorf("TopPredictions() = %v, want learned git diff", predictions)
+	}
+
+	if got := engine.TopPredictions("bob", 3); len(got) != 0 {
+		t.Errorf("TopPredictions() for unknown user = %v", got)
+	}
+}
+
+func TestSuggestionEngine_AliasesPerUser(t *testing.T) {
+	engine := NewSuggestionEngine()
+
+	for i := 0; i < frequentPatternThreshold; i++ {
+		engine.AnalyzeCommand("alice", "kubectl get pods")
+		engine.AnalyzeCommand("bob", "docker ps")
+	}
+
+	aliases := func(userID string) []string {
+		var commands []string
+		for _, s := range engine.GetSuggestions(userID, "") {
+			if s.Type == "alias" {
+				commands = append(commands, s.Command)
+			}
+		}
+		return commands
+	}
+
+	alice := aliases("alice")
+	if len(alice) != 1 || !strings.Contains(alice[0], "kubectl get pods") {
+		t.Errorf("alice aliases = %v, want only kubectl get pods", alice)
+	}
+
+	bob := aliases("bob")
+	if len(bob) != 1 || !strings.Contains(bob[0], "docker ps") {
+		t.Errorf("bob aliases = %v, want only docker ps", bob)
+	}
+}
+
+func TestOptimizeFindGrep(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     string
+		want    string
+		wantOK  bool
+		matches []string // files the rewritten command should list with -l
+	}{
+		{
+			name:   "name glob",
+			cmd:    "find . -type f -name '*.log' | xargs grep error",
+			want:   "grep -r error --include='*.log' .",
+			wantOK: true,
+		},
+		{
+			name:    "xargs with flags",
+			cmd:     `find logs -type f -name "*.log" | xargs grep -il "connection refused"`,
+			want:    "grep -ril 'connection refused' --include='*.log' logs",
+			wantOK:  true,
+			matches: []string{"logs/app.log"},
+		},
+		{
+			name:    "no name",
+			cmd:     "find logs -type f | xargs grep -l ERROR",
+			want:    "grep -rl ERROR logs",
+			wantOK:  true,
+			matches: []string{"logs/app.log", "logs/notes.txt"},
+		},
+		{
+			name:    "multiple greps",
+			cmd:     "find . -name '*.txt' -type f | xargs grep -l ERROR | grep -v tmp",
+			want:    "grep -rl ERROR --include='*.txt' . | grep -v tmp",
+			wantOK:  true,
+			matches: []string{"./logs/notes.txt"},
+		},
+		{
+			name:    "print0",
+			cmd:     "find logs -type f -print0 | xargs -0 grep -l ERROR",
+			want:    "grep -rl ERROR logs",
+			wantOK:  true,
+			matches: []string{"logs/app.log", "logs/notes.txt"},
+		},
+		{
+			// Matches file names, not contents
+			name: "grep without xargs",
+			cmd:  "find . -type f -name '*.log' | grep error",
+		},
+		{
+			// Also lists symlinks, which grep -r skips
+			name: "without type f",
+			cmd:  "find . -name '*.log' | xargs grep error",
+		},
+		{
+			name: "print0 without xargs -0",
+			cmd:  "find . -type f -print0 | xargs grep error",
+		},
+		{
+			name: "xargs -0 without print0",
+			cmd:  "find . -type f | xargs -0 grep error",
+		},
+		{
+			name: "unsupported xargs flag",
+			cmd:  "find . -type f | xargs -n 1 grep error",
+		},
+		{
+			name: "unsupported grep flag",
+			cmd:  "find . -type f -name '*.log' | xargs grep -v error",
+		},
+		{
+			name: "unsupported find predicate",
+			cmd:  "find . -type f -mtime -1 | xargs grep error",
+		},
+		{
+			name: "not a find pipeline",
+			cmd:  "grep -r error . | sort",
+		},
+		{
+			name: "find without grep",
+			cmd:  "find . -name '*.go'",
+		},
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"logs/app.log":   "Connection refused\nERROR: retry\n",
+		"logs/notes.txt": "ERROR in notes\n",
+		"tmp/ERROR.txt":  "ERROR\n",
+		"tmp/other.txt":  "fine\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := optimizeFindGrep(tt.cmd)
+			if ok != tt.wantOK || got != tt.want {
+				t.Fatalf("optimizeFindGrep(%q) = %q, %v; want %q, %v", tt.cmd, got, ok, tt.want, tt.wantOK)
+			}
+			if tt.matches == nil {
+				return
+			}
+
+			if _, err := exec.LookPath("sh"); err != nil {
+				t.Skip("sh not available")
+			}
+			run := exec.Command("sh", "-c", got)
+			run.Dir = dir
+			out, err := run.Output()
+			if err != nil {
+				t.Fatalf("rewritten command %q failed: %v", got, err)
+			}
+
+			listed := strings.Fields(string(out))
+			sort.Strings(listed)
+			if strings.Join(listed, " ") != strings.Join(tt.matches, " ") {
+				t.Errorf("rewritten command listed %v, want %v", listed, tt.matches)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrBlockedPrompt is returned, wrapped in a *BlockedPromptError, when a
+// prompt asks for a command QuickCMD never suggests
+var ErrBlockedPrompt = errors.New("prompt asks for a forbidden command")
+
+// BlockedCommand is a command that is refused before translation, whatever
+// the policy allows
+type BlockedCommand struct {
+	Name         string   // e.g. "fork bomb"
+	Command      string   // canonical form of the command
+	Examples     []string // prompts that ask for it
+	SecurityNote string   // why it is refused
+
+	literal *regexp.Regexp   // the command itself, typed into the prompt
+	intents []*regexp.Regexp // wording that unambiguously asks for it
+}
+
+// blocklist holds the commands refused before translation. Intent patterns
+// only match wording with no harmless reading, e.g. "wipe the disk" but not
+// "delete all files in this directory".
+var blocklist = []*BlockedCommand{
+	{
+		Name:    "fork bomb",
+		Command: ":(){ :|:& };:",
+		Examples: []string{
+			"fork bomb",
+			"make a fork bomb",
+			"spawn processes forever until the machine crashes",
+		},
+		SecurityNote: "A fork bomb replicates until the process table is full, making the machine unusable until reboot.",
+		literal:      regexp.MustCompile(`:\s*\(\s*\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`),
+		intents: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)\bfork[\s-]*bomb`),
+			regexp.MustCompile(`(?i)\bspawn\s+(processes|itself)\s+(forever|endlessly|infinitely)`),
+		},
+	},
+	{
+		Name:    "root filesystem deletion",
+		Command: "rm -rf /",
+		Examples: []string{
+			"wipe root directory",
+			"delete everything on the system",
+			"delete the entire filesystem",
+		},
+		SecurityNote: "Deleting from / destroys the operating system and every mounted volume.",
+		literal:      regexp.MustCompile(`\brm\s+(-[a-zA-Z]*r[a-zA-Z]*f[a-zA-Z]*|-[a-zA-Z]*f[a-zA-Z]*r[a-zA-Z]*)\s+(--no-preserve-root\s+)?/(\*)?(\s|$)`),
+		intents: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)\b(delete|remove|wipe|erase|destroy)\s+(the\s+)?(root\s+(directory|filesystem|file\s+system)|(entire|whole)\s+(filesystem|file\s+system|system))`),
+			regexp.MustCompile(`(?i)\b(delete|remove|wipe|erase|destroy)\s+everything\s+on\s+(the|this)\s+(system|machine|server|computer)`),
+		},
+	},
+	{
+		Name:    "disk overwrite",
+		Command: "dd if=/dev/zero of=/dev/sda",
+		Examples: []string{
+			"wipe disk",
+			"erase the hard drive",
+			"overwrite the whole disk with zeros",
+		},
+		SecurityNote: "Writing to a raw disk device destroys its partition table and every file on it.",
+		literal:      regexp.MustCompile(`\bdd\s+.*\bof=/dev/(sd|hd|vd|xvd|nvme|disk)`),
+		intents: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)\b(wipe|erase|overwrite|zero)\s+(out\s+)?(the\s+)?((whole|entire|primary|main)\s+)?(hard\s+)?(disk|drive)\b`),
+		},
+	},
+}
+
+// BlockedCommands returns the commands refused before translation
+func BlockedCommands() []*BlockedCommand {
+	return blocklist
+}
+
+// CheckBlocklist returns the blocked command prompt asks for, or nil
+func CheckBlocklist(prompt string) *BlockedCommand {
+	for _, blocked := range blocklist {
+		if blocked.literal.MatchString(prompt) {
+			return blocked
+		}
+		for _, intent := range blocked.intents {
+			if intent.MatchString(prompt) {
+				return blocked
+			}
+		}
+	}
+	return nil
+}
+
+// BlockedPromptError is returned for a prompt that asks for a blocked
+// command
+type BlockedPromptError struct {
+	Blocked *BlockedCommand
+}
+
+func (e *BlockedPromptError) Error() string {
+	return fmt.Sprintf("refusing to suggest a %s (%s)", e.Blocked.Name, e.Blocked.Command)
+}
+
+func (e *BlockedPromptError) Unwrap() error {
+	return ErrBlockedPrompt
+}
@@ -0,0 +1,58 @@
+package translator
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestTranslator_BlockedPrompt(t *testing.T) {
+	// A template that would happily produce a fork bomb is never reached
+	called := false
+	translator := NewWithTemplates([]*Template{{
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`.*`)},
+		Generator: func(matches []string) *Candidate {
+			called = true
+			return &Candidate{Command: ":(){ :|:& };:", Confidence: 100}
+		},
+	}})
+
+	_, err := translator.Translate("write me a fork bomb to stress test the box")
+	if !errors.Is(err, ErrBlockedPrompt) {
+		t.Fatalf("Translate() error = %v, want ErrBlockedPrompt", err)
+	}
+	var blocked *BlockedPromptError
+	if !errors.As(err, &blocked) || blocked.Blocked.Name != "fork bomb" || blocked.Blocked.SecurityNote == "" {
+		t.Errorf("Translate() error = %#v, want a fork bomb refusal with a security note", err)
+	}
+	if called {
+		t.Error("Translate() ran templates for a blocked prompt")
+	}
+}
+
+func TestCheckBlocklist(t *testing.T) {
+	for _, blocked := range BlockedCommands() {
+		if got := CheckBlocklist("run " + blocked.Command); got != blocked {
+			t.Errorf("CheckBlocklist() of the %s command = %v", blocked.Name, got)
+		}
+		for _, example := range blocked.Examples {
+			if got := CheckBlocklist(example); got != blocked {
+				t.Errorf("CheckBlocklist(%q) = %v, want %s", example, got, blocked.Name)
+			}
+		}
+	}
+
+	for _, prompt := range []string{
+		"delete all files in this directory",
+		"remove node_modules",
+		"rm -rf ./build",
+		"check disk usage",
+		"find files larger than 100MB",
+		"dd if=disk.img of=backup.img",
+		"show process tree",
+	} {
+		if got := CheckBlocklist(prompt); got != nil {
+			t.Errorf("CheckBlocklist(%q) = %s, want nil", prompt, got.Name)
+		}
+	}
+}
@@ -14,6 +14,18 @@ const (
 	RiskHigh   Risk = "high"
 )
 
+// Candidate sources, recording where a candidate was generated
+const (
+	SourceCoreTemplate = "core-template"
+	SourceLLM          = "llm"
+	SourceAlias        = "alias"
+)
+
+// PluginSource returns the source label for candidates generated by a plugin
+func PluginSource(name string) string {
+	return "plugin:" + name
+}
+
 // Step represents a single step in command breakdown
 type Step struct {
 	Description string
@@ -30,8 +42,12 @@ type Candidate struct {
 	AffectedPaths  []string // Paths that will be affected
 	NetworkTargets []string // Network endpoints accessed
 	Destructive    bool     // Whether this is a destructive operation
+	Destructiveness int     // 0-100 estimate of potential damage
 	RequiresConfirm bool    // Whether typed confirmation is needed
 	DocLinks       []string // Links to documentation
+	Source         string   // Where the candidate came from (see Source* constants)
+	Category       string   // Command family, e.g. file or git (see Template.Category)
+	SimulateCommand string  // Safe rehearsal run before the command, e.g. a dry run
 }
 
 // String returns a formatted string representation of the candidate
@@ -42,9 +58,12 @@ func (c *Candidate) String() string {
 	sb.WriteString(fmt.Sprintf("Explanation: %s\n", c.Explanation))
 	sb.WriteString(fmt.Sprintf("Confidence: %d%%\n", c.Confidence))
 	sb.WriteString(fmt.Sprintf("Risk: %s\n", c.RiskLevel))
+	if c.Source != "" {
+		sb.WriteString(fmt.Sprintf("Source: %s\n", c.Source))
+	}
 	
 	if c.Destructive {
-		sb.WriteString("⚠️  DESTRUCTIVE OPERATION\n")
+		sb.WriteString(Symbol(IconWarning) + "  DESTRUCTIVE OPERATION\n")
 	}
 	
 	if len(c.AffectedPaths) > 0 {
@@ -60,7 +79,7 @@ func (c *Candidate) String() string {
 		for i, step := range c.Breakdown {
 			sb.WriteString(fmt.Sprintf("  %d. %s\n", i+1, step.Description))
 			if step.Command != "" {
-				sb.WriteString(fmt.Sprintf("     → %s\n", step.Command))
+				sb.WriteString(fmt.Sprintf("     %s %s\n", Symbol(IconArrow), step.Command))
 			}
 		}
 	}
@@ -68,17 +87,89 @@ func (c *Candidate) String() string {
 	return sb.String()
 }
 
-// RiskIcon returns an emoji icon for the risk level
+// Validate checks that the candidate is well formed: a single-line command
+// with balanced shell quoting, an explanation, a known risk level, and
+// scores within 0-100
+func (c *Candidate) Validate() error {
+	if strings.TrimSpace(c.Command) == "" {
+		return fmt.Errorf("command is empty")
+	}
+	if strings.ContainsAny(c.Command, "\x00\r\n") {
+		return fmt.Errorf("command contains NUL or line break characters")
+	}
+	if !balancedQuotes(c.Command) {
+		return fmt.Errorf("command has unbalanced quotes: %s", c.Command)
+	}
+	if strings.TrimSpace(c.Explanation) == "" {
+		return fmt.Errorf("explanation is empty")
+	}
+	switch c.RiskLevel {
+	case RiskSafe, RiskMedium, RiskHigh:
+	default:
+		return fmt.Errorf("unknown risk level %q", c.RiskLevel)
+	}
+	if c.Confidence < 0 || c.Confidence > 100 {
+		return fmt.Errorf("confidence %d out of range [0, 100]", c.Confidence)
+	}
+	if c.Destructiveness < 0 || c.Destructiveness > 100 {
+		return fmt.Errorf("destructiveness %d out of range [0, 100]", c.Destructiveness)
+	}
+	return nil
+}
+
+// balancedQuotes reports whether every single and double quote in command
+// is closed, following POSIX shell quoting and backslash escapes
+func balancedQuotes(command string) bool {
+	var quote rune
+	escaped := false
+	
+	for _, r := range command {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			}
+		case r == '\\':
+			escaped = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		}
+	}
+	
+	return quote == 0 && !escaped
+}
+
+// escapeDoubleQuoted escapes s for use inside a double-quoted shell string,
+// so user text can't close the quotes or expand variables and commands
+func escapeDoubleQuoted(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\', '"', '$', '`':
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// RiskIcon returns the current theme's icon for the risk level
 func (c *Candidate) RiskIcon() string {
 	switch c.RiskLevel {
 	case RiskSafe:
-		return "✅"
+		return Symbol(IconSafe)
 	case RiskMedium:
-		return "⚠️"
+		return Symbol(IconMedium)
 	case RiskHigh:
-		return "🔴"
+		return Symbol(IconHigh)
 	default:
-		return "❓"
+		return Symbol(IconUnknown)
 	}
 }
 
@@ -0,0 +1,59 @@
+package translator
+
+import (
+	"github.com/SagheerAkram/QuickCmd/core/cmdparse"
+)
+
+// executableCategories maps executables to the categories templates and
+// plugins give their candidates
+var executableCategories = map[string]string{
+	// file
+	"find": "file", "ls": "file", "cp": "file", "mv": "file", "rm": "file",
+	"mkdir": "file", "rmdir": "file", "touch": "file", "cat": "file",
+	"chmod": "file", "chown": "file", "ln": "file", "tar": "file",
+	"zip": "file", "unzip": "file", "gzip": "file", "gunzip": "file",
+	"sed": "file", "awk": "file", "head": "file", "tail": "file",
+
+	// search
+	"grep": "search", "rg": "search", "ag": "search",
+
+	// git
+	"git": "git", "gh": "git",
+
+	// docker
+	"docker": "docker", "docker-compose": "docker", "podman": "docker",
+
+	// k8s
+	"kubectl": "k8s", "helm": "k8s", "kustomize": "k8s", "k9s": "k8s",
+
+	// aws
+	"aws": "aws", "sam": "aws", "eksctl": "aws",
+
+	// system
+	"du": "system", "df": "system", "ps": "system", "top": "system",
+	"kill": "system", "killall": "system", "pkill": "system",
+	"systemctl": "system", "service": "system", "journalctl": "system",
+	"shutdown": "system", "reboot": "system", "mount": "system",
+	"umount": "system", "crontab": "system", "apt": "system",
+	"apt-get": "system", "yum": "system", "dnf": "system", "brew": "system",
+	"useradd": "system", "userdel": "system", "mkfs": "system", "dd": "system",
+
+	// network
+	"curl": "network", "wget": "network", "ssh": "network", "scp": "network",
+	"rsync": "network", "ping": "network", "nc": "network", "netstat": "network",
+	"ss": "network", "dig": "network", "nslookup": "network",
+	"traceroute": "network", "iptables": "network", "ufw": "network",
+}
+
+// CommandCategory returns the category of a shell command, such as file,
+// git or k8s, from the first of its commands whose executable has one, or
+// "other". Wrappers like sudo are looked through, so "cd repo && sudo -u
+// bob systemctl restart api" is system.
+func CommandCategory(command string) string {
+	for _, c := range cmdparse.Commands(command) {
+		if category, ok := executableCategories[c.Executable()]; ok {
+			return category
+		}
+	}
+	return "other"
+}
@@ -0,0 +1,24 @@
+package translator
+
+import "testing"
+
+func TestCommandCategory(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"ls -la", "file"},
+		{`grep -rn "TODO" .`, "search"},
+		{"sudo -u bob systemctl restart nginx", "system"},
+		{"cd repo && git pull --rebase", "git"},
+		{"FOO=1 kubectl get pods | grep api", "k8s"},
+		{"terraform apply", "other"},
+		{"", "other"},
+	}
+
+	for _, tt := range tests {
+		if got := CommandCategory(tt.command); got != tt.want {
+			t.Errorf("CommandCategory(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
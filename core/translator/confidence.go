@@ -71,7 +71,7 @@ func (cb *ConfidenceBreakdown) Visualize() string {
 	var sb strings.Builder
 
 	// Overall confidence with progress bar
-	sb.WriteString(fmt.Sprintf("\n✨ Confidence: %d%% %s\n\n", cb.Overall, progressBar(cb.Overall)))
+	sb.WriteString(fmt.Sprintf("\n%s Confidence: %d%% %s\n\n", Symbol(IconConfidence), cb.Overall, progressBar(cb.Overall)))
 
 	// Component breakdown
 	sb.WriteString("Breakdown:\n")
@@ -95,7 +95,7 @@ func (cb *ConfidenceBreakdown) Visualize() string {
 	if len(cb.Reasons) > 0 {
 		sb.WriteString("\nWhy this command?\n")
 		for _, reason := range cb.Reasons {
-			sb.WriteString(fmt.Sprintf("  ✓ %s\n", reason))
+			sb.WriteString(fmt.Sprintf("  %s %s\n", Symbol(IconCheck), reason))
 		}
 	}
 
@@ -103,7 +103,7 @@ func (cb *ConfidenceBreakdown) Visualize() string {
 	if len(cb.Warnings) > 0 {
 		sb.WriteString("\nWarnings:\n")
 		for _, warning := range cb.Warnings {
-			sb.WriteString(fmt.Sprintf("  ⚠ %s\n", warning))
+			sb.WriteString(fmt.Sprintf("  %s %s\n", Symbol(IconWarning), warning))
 		}
 	}
 
@@ -111,7 +111,7 @@ func (cb *ConfidenceBreakdown) Visualize() string {
 	if len(cb.Tips) > 0 {
 		sb.WriteString("\nTips:\n")
 		for _, tip := range cb.Tips {
-			sb.WriteString(fmt.Sprintf("  💡 %s\n", tip))
+			sb.WriteString(fmt.Sprintf("  %s %s\n", Symbol(IconTip), tip))
 		}
 	}
 
@@ -120,17 +120,7 @@ func (cb *ConfidenceBreakdown) Visualize() string {
 
 // progressBar creates a visual progress bar
 func progressBar(percentage int) string {
-	if percentage < 0 {
-		percentage = 0
-	}
-	if percentage > 100 {
-		percentage = 100
-	}
-
-	filled := percentage / 5 // 20 chars total
-	empty := 20 - filled
-
-	return strings.Repeat("█", filled) + strings.Repeat("░", empty)
+	return Bar(percentage/5, 20) // 20 chars total
 }
 
 // ExplainChoice provides detailed explanation of why a command was chosen
@@ -141,21 +131,21 @@ func (c *Candidate) ExplainChoice(prompt string) string {
 
 	// Pattern matching explanation
 	if c.Confidence >= 90 {
-		sb.WriteString("✓ Exact match to known pattern\n")
+		sb.WriteString(Symbol(IconCheck) + " Exact match to known pattern\n")
 	} else if c.Confidence >= 70 {
-		sb.WriteString("✓ Close match to similar patterns\n")
+		sb.WriteString(Symbol(IconCheck) + " Close match to similar patterns\n")
 	} else {
-		sb.WriteString("⚠ Fuzzy match - verify before executing\n")
+		sb.WriteString(Symbol(IconWarning) + " Fuzzy match - verify before executing\n")
 	}
 
 	// Risk explanation
 	switch c.RiskLevel {
 	case RiskSafe:
-		sb.WriteString("✓ Safe operation (read-only)\n")
+		sb.WriteString(Symbol(IconCheck) + " Safe operation (read-only)\n")
 	case RiskMedium:
-		sb.WriteString("⚠ Medium risk (modifies files/state)\n")
+		sb.WriteString(Symbol(IconWarning) + " Medium risk (modifies files/state)\n")
 	case RiskHigh:
-		sb.WriteString("🔴 High risk (destructive operation)\n")
+		sb.WriteString(Symbol(IconHigh) + " High risk (destructive operation)\n")
 	}
 
 	// Command breakdown
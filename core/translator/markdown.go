@@ -0,0 +1,81 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markdown renders the candidate as a runbook section: the command, its
+// explanation and risk, the numbered breakdown steps with their
+// sub-commands, and documentation links
+func (c *Candidate) Markdown() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("## %s\n\n", markdownCode(c.Command)))
+	if c.Explanation != "" {
+		sb.WriteString(c.Explanation + "\n\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("- **Risk:** %s\n", c.RiskLevel))
+	sb.WriteString(fmt.Sprintf("- **Confidence:** %d%%\n", c.Confidence))
+	if c.Destructive {
+		sb.WriteString("- **Destructive:** yes\n")
+	}
+	if len(c.AffectedPaths) > 0 {
+		sb.WriteString(fmt.Sprintf("- **Affected paths:** %s\n", markdownCodeList(c.AffectedPaths)))
+	}
+	if len(c.NetworkTargets) > 0 {
+		sb.WriteString(fmt.Sprintf("- **Network targets:** %s\n", markdownCodeList(c.NetworkTargets)))
+	}
+
+	if len(c.Breakdown) > 0 {
+		sb.WriteString("\n### Steps\n\n")
+		for i, step := range c.Breakdown {
+			sb.WriteString(fmt.Sprintf("%d. %s", i+1, step.Description))
+			if step.Command != "" {
+				sb.WriteString(": " + markdownCode(step.Command))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	if len(c.DocLinks) > 0 {
+		sb.WriteString("\n### References\n\n")
+		for _, link := range c.DocLinks {
+			sb.WriteString(fmt.Sprintf("- <%s>\n", link))
+		}
+	}
+
+	return sb.String()
+}
+
+// markdownCode formats s as inline code, using a longer fence than any run
+// of backticks in s so that command substitutions render intact
+func markdownCode(s string) string {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	if longest == 0 {
+		return "`" + s + "`"
+	}
+
+	fence := strings.Repeat("`", longest+1)
+	return fence + " " + s + " " + fence
+}
+
+// markdownCodeList formats items as comma-separated inline code
+func markdownCodeList(items []string) string {
+	formatted := make([]string, len(items))
+	for i, item := range items {
+		formatted[i] = markdownCode(item)
+	}
+	return strings.Join(formatted, ", ")
+}
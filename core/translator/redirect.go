@@ -0,0 +1,258 @@
+package translator
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Redirection is an output redirection to a file, e.g. `> out.txt`
+type Redirection struct {
+	Target   string // the file written to, unquoted
+	Append   bool   // `>>`: the file is appended to, not overwritten
+	Truncate bool   // the redirection is the whole command, e.g. `: > file`, so it only empties the file
+}
+
+// harmlessTargets are redirection targets that hold no data
+var harmlessTargets = map[string]bool{
+	"/dev/null":   true,
+	"/dev/stdout": true,
+	"/dev/stderr": true,
+	"/dev/tty":    true,
+}
+
+// systemPaths are directories whose files a redirection should never
+// silently overwrite
+var systemPaths = []string{"/etc", "/boot", "/bin", "/sbin", "/lib", "/lib64", "/usr", "/var", "/dev", "/sys", "/proc", "/root"}
+
+// AnalyzeRedirections returns the output redirections to files in command.
+// Redirections to /dev/null and between file descriptors (`2>&1`) are
+// skipped, as are quoted `>` characters.
+func AnalyzeRedirections(command string) []Redirection {
+	var (
+		redirections []Redirection
+		segment      []Redirection // redirections of the current simple command
+		words        []string      // words of the current simple command, without redirection targets
+		word         strings.Builder
+		inWord       bool
+		quote        rune
+		escaped      bool
+		pending      *Redirection // redirection waiting for its target
+		skipNext     bool         // the next word is an input redirection source
+	)
+
+	endWord := func() {
+		if !inWord {
+			return
+		}
+		w := word.String()
+		word.Reset()
+		inWord = false
+
+		switch {
+		case pending != nil:
+			pending.Target = w
+			if !harmlessTargets[w] && !strings.HasPrefix(w, "/dev/fd/") {
+				segment = append(segment, *pending)
+			}
+			pending = nil
+		case skipNext:
+			skipNext = false
+		default:
+			words = append(words, w)
+		}
+	}
+
+	endCommand := func() {
+		endWord()
+		truncate := len(words) == 0 || (len(words) == 1 && (words[0] == ":" || words[0] == "true"))
+		for _, r := range segment {
+			r.Truncate = truncate && !r.Append
+			redirections = append(redirections, r)
+		}
+		segment, words, pending, skipNext = nil, nil, nil, false
+	}
+
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		next := func() rune {
+			if i+1 < len(runes) {
+				return runes[i+1]
+			}
+			return 0
+		}
+
+		switch {
+		case escaped:
+			escaped = false
+			word.WriteRune(r)
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else if r == '\\' && quote == '"' && strings.ContainsRune(`"\$`+"`", next()) {
+				i++
+				word.WriteRune(runes[i])
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			endWord()
+		case r == '>' || (r == '&' && next() == '>'):
+			// A word of digits right before `>` is the file descriptor
+			if inWord && r == '>' && isDigits(word.String()) {
+				word.Reset()
+				inWord = false
+			}
+			endWord()
+
+			if r == '&' {
+				i++ // `&>`: stdout and stderr
+			}
+			redirection := &Redirection{}
+			switch next() {
+			case '>':
+				i++
+				redirection.Append = true
+			case '|':
+				i++
+			case '&':
+				// `>&2` duplicates a descriptor, `>&file` writes to file
+				i++
+				if d := next(); d == '-' || (d >= '0' && d <= '9') {
+					for i+1 < len(runes) && (runes[i+1] == '-' || (runes[i+1] >= '0' && runes[i+1] <= '9')) {
+						i++
+					}
+					continue
+				}
+			}
+			pending = redirection
+		case r == '<':
+			endWord()
+			for next() == '<' {
+				i++
+			}
+			skipNext = true
+		case r == ';' || r == '|' || r == '&' || r == '(' || r == ')' || r == '\n':
+			endCommand()
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	endCommand()
+
+	return redirections
+}
+
+// AssessRedirections raises the candidate's risk for the files its command
+// redirects output to: overwriting an existing file is destructive, emptying
+// one or writing under a system directory is high risk. Creating a file that
+// doesn't exist yet destroys nothing. The targets are added to AffectedPaths.
+func (c *Candidate) AssessRedirections() {
+	for _, r := range AnalyzeRedirections(c.Command) {
+		if !containsPath(c.AffectedPaths, r.Target) {
+			c.AffectedPaths = append(c.AffectedPaths, r.Target)
+		}
+
+		overwrites := !r.Append && targetExists(r.Target)
+		risk, destructiveness := RiskMedium, 0
+		if overwrites {
+			destructiveness = 60
+			if r.Truncate {
+				risk, destructiveness = RiskHigh, 80
+			}
+		}
+		if isSystemPath(r.Target) {
+			risk = RiskHigh
+			if overwrites {
+				destructiveness = 90
+			}
+		}
+
+		if destructiveness > 0 {
+			c.Destructive = true
+		}
+		if destructiveness > c.Destructiveness {
+			c.Destructiveness = destructiveness
+		}
+		if riskRank(risk) > riskRank(c.RiskLevel) {
+			c.RiskLevel = risk
+		}
+		if c.RiskLevel == RiskHigh {
+			c.RequiresConfirm = true
+		}
+	}
+}
+
+// targetExists reports whether a redirection target already exists,
+// relative to the current directory. Targets the shell expands, such as
+// $HOME/out or *.log, can't be checked and are assumed to exist.
+func targetExists(target string) bool {
+	if strings.HasPrefix(target, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return true
+		}
+		target = filepath.Join(home, target[2:])
+	}
+	if strings.ContainsAny(target, "$`*?[~") {
+		return true
+	}
+
+	_, err := os.Stat(target)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// riskRank orders risk levels from safe to high
+func riskRank(risk Risk) int {
+	switch risk {
+	case RiskMedium:
+		return 1
+	case RiskHigh:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// isSystemPath reports whether path is in a system directory
+func isSystemPath(path string) bool {
+	for _, dir := range systemPaths {
+		if path == dir || strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// containsPath reports whether paths contains path
+func containsPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
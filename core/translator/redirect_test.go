@@ -0,0 +1,86 @@
+package translator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeRedirections(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []Redirection
+	}{
+		{"echo x > /etc/hosts", []Redirection{{Target: "/etc/hosts"}}},
+		{"echo x >> important.conf", []Redirection{{Target: "important.conf", Append: true}}},
+		{": > app.log", []Redirection{{Target: "app.log", Truncate: true}}},
+		{"> app.log", []Redirection{{Target: "app.log", Truncate: true}}},
+		{"true >| app.log", []Redirection{{Target: "app.log", Truncate: true}}},
+		{"ls >out.txt 2>errors.txt", []Redirection{{Target: "out.txt"}, {Target: "errors.txt"}}},
+		{"make &> 'build log.txt'", []Redirection{{Target: "build log.txt"}}},
+		{"cat a > b; : > c", []Redirection{{Target: "b"}, {Target: "c", Truncate: true}}},
+		{"sort < in.txt > out.txt", []Redirection{{Target: "out.txt"}}},
+		{"cmd > /dev/null 2>&1", nil},
+		{"echo 'a > b' \"c >> d\" e\\>f", nil},
+		{"du -sh * | sort -h", nil},
+	}
+
+	for _, tt := range tests {
+		if got := AnalyzeRedirections(tt.command); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("AnalyzeRedirections(%q) = %+v, want %+v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestCandidate_AssessRedirections(t *testing.T) {
+	c := &Candidate{Command: "echo x > /etc/hosts", RiskLevel: RiskSafe}
+	c.AssessRedirections()
+	if !c.Destructive || c.RiskLevel != RiskHigh || !c.RequiresConfirm {
+		t.Errorf("echo x > /etc/hosts: destructive = %v, risk = %s, confirm = %v", c.Destructive, c.RiskLevel, c.RequiresConfirm)
+	}
+	if !reflect.DeepEqual(c.AffectedPaths, []string{"/etc/hosts"}) {
+		t.Errorf("echo x > /etc/hosts: affected paths = %v", c.AffectedPaths)
+	}
+
+	c = &Candidate{Command: "echo done >> build.log", RiskLevel: RiskSafe}
+	c.AssessRedirections()
+	if c.Destructive || c.RiskLevel != RiskMedium {
+		t.Errorf("append: destructive = %v, risk = %s", c.Destructive, c.RiskLevel)
+	}
+
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(existing, []byte("log"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "new.log")
+
+	c = &Candidate{Command: ": > " + existing, RiskLevel: RiskSafe}
+	c.AssessRedirections()
+	if !c.Destructive || c.RiskLevel != RiskHigh {
+		t.Errorf("truncation: destructive = %v, risk = %s", c.Destructive, c.RiskLevel)
+	}
+
+	c = &Candidate{Command: "date > " + existing, RiskLevel: RiskSafe}
+	c.AssessRedirections()
+	if !c.Destructive || c.Destructiveness != 60 {
+		t.Errorf("overwrite: destructive = %v, destructiveness = %d", c.Destructive, c.Destructiveness)
+	}
+
+	// Creating a file destroys nothing
+	for _, command := range []string{": > " + missing, "date > " + missing, "make 2> /dev/null"} {
+		c = &Candidate{Command: command, RiskLevel: RiskSafe}
+		c.AssessRedirections()
+		if c.Destructive || c.RiskLevel == RiskHigh {
+			t.Errorf("%s: destructive = %v, risk = %s", command, c.Destructive, c.RiskLevel)
+		}
+	}
+
+	// Risk is only ever raised
+	c = &Candidate{Command: "rm -rf build > removed.txt", RiskLevel: RiskHigh, Destructive: true, Destructiveness: 95}
+	c.AssessRedirections()
+	if c.RiskLevel != RiskHigh || c.Destructiveness != 95 {
+		t.Errorf("high risk command: risk = %s, destructiveness = %d", c.RiskLevel, c.Destructiveness)
+	}
+}
@@ -9,7 +9,7 @@ import (
 // Template represents a command template with pattern matching
 type Template struct {
 	Patterns    []*regexp.Regexp // Regex patterns to match user input
-	Generator   func(matches []string) *Candidate
+	Generator   func(matches []string) *Candidate // Returns nil if matches can't produce a command
 	Keywords    []string // Keywords that boost confidence
 	Category    string   // Category (file, git, docker, etc.)
 	Description string   // Template description
@@ -27,26 +27,25 @@ var CommandTemplates = []*Template{
 		Category:    "file",
 		Description: "Find files larger than specified size",
 		Generator: func(matches []string) *Candidate {
-			size := matches[1]
+			size := group(matches, 1, "100")
+			label := group(matches, 2, "MB")
 			unit := "M"
-			if len(matches) > 2 && matches[2] != "" {
-				switch strings.ToLower(matches[2]) {
-				case "kb":
-					unit = "k"
-				case "gb":
-					unit = "G"
-				}
+			switch strings.ToLower(label) {
+			case "kb":
+				unit = "k"
+			case "gb":
+				unit = "G"
 			}
 			
 			cmd := fmt.Sprintf("find . -type f -size +%s%s", size, unit)
 			
 			return &Candidate{
 				Command:     cmd,
-				Explanation: fmt.Sprintf("Finds all files in the current directory and subdirectories larger than %s%s", size, matches[2]),
+				Explanation: fmt.Sprintf("Finds all files in the current directory and subdirectories larger than %s%s", size, label),
 				Breakdown: []Step{
 					{Description: "Search current directory recursively", Command: "find ."},
 					{Description: "Filter for regular files only", Command: "-type f"},
-					{Description: fmt.Sprintf("Match files larger than %s%s", size, matches[2]), Command: fmt.Sprintf("-size +%s%s", size, unit)},
+					{Description: fmt.Sprintf("Match files larger than %s%s", size, label), Command: fmt.Sprintf("-size +%s%s", size, unit)},
 				},
 				Confidence:  95,
 				RiskLevel:   RiskSafe,
@@ -66,7 +65,11 @@ var CommandTemplates = []*Template{
 		Category:    "file",
 		Description: "Find and delete files by pattern",
 		Generator: func(matches []string) *Candidate {
-			pattern := matches[1]
+			// There is no safe default for what to delete
+			pattern := group(matches, 1, "")
+			if pattern == "" {
+				return nil
+			}
 			if !strings.HasPrefix(pattern, ".") && !strings.Contains(pattern, "*") {
 				pattern = "*." + pattern
 			}
@@ -101,7 +104,7 @@ var CommandTemplates = []*Template{
 		Category:    "file",
 		Description: "Archive old log files",
 		Generator: func(matches []string) *Candidate {
-			days := matches[1]
+			days := group(matches, 1, "30")
 			
 			cmd := fmt.Sprintf("find . -name \"*.log\" -type f -mtime +%s -print0 | tar -czvf logs_archive_$(date +%%Y%%m%%d).tar.gz --null -T -", days)
 			
@@ -157,17 +160,15 @@ var CommandTemplates = []*Template{
 		Category:    "git",
 		Description: "Commit changes to git",
 		Generator: func(matches []string) *Candidate {
-			message := "Update files"
-			if len(matches) > 1 && matches[1] != "" {
-				message = matches[1]
-			}
+			message := group(matches, 1, "Update files")
+			quoted := escapeDoubleQuoted(message)
 			
 			return &Candidate{
-				Command:     fmt.Sprintf("git add -A && git commit -m \"%s\"", message),
+				Command:     fmt.Sprintf("git add -A && git commit -m \"%s\"", quoted),
 				Explanation: fmt.Sprintf("Stages all changes and commits them with message: '%s'", message),
 				Breakdown: []Step{
 					{Description: "Stage all changes (new, modified, deleted)", Command: "git add -A"},
-					{Description: fmt.Sprintf("Commit with message '%s'", message), Command: fmt.Sprintf("git commit -m \"%s\"", message)},
+					{Description: fmt.Sprintf("Commit with message '%s'", message), Command: fmt.Sprintf("git commit -m \"%s\"", quoted)},
 				},
 				Confidence:  92,
 				RiskLevel:   RiskMedium,
@@ -258,6 +259,16 @@ var CommandTemplates = []*Template{
 	},
 }
 
+// group returns capture group i of matches, or def if the group is missing
+// or empty. Generators use it so a pattern change or an optional group that
+// didn't participate can't index out of range.
+func group(matches []string, i int, def string) string {
+	if i < len(matches) && matches[i] != "" {
+		return matches[i]
+	}
+	return def
+}
+
 // MatchTemplate attempts to match a prompt against a template
 func (t *Template) Match(prompt string) ([]string, bool) {
 	for _, pattern := range t.Patterns {
@@ -0,0 +1,104 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Theme controls how icons and progress bars are rendered
+type Theme string
+
+const (
+	// ThemeEmoji renders emoji icons and block progress bars (default)
+	ThemeEmoji Theme = "emoji"
+	
+	// ThemeASCII renders plain ASCII for terminals and logs without emoji
+	// support
+	ThemeASCII Theme = "ascii"
+)
+
+// Icon names a symbol that is rendered differently per theme
+type Icon string
+
+const (
+	IconSafe       Icon = "safe"
+	IconMedium     Icon = "medium"
+	IconHigh       Icon = "high"
+	IconUnknown    Icon = "unknown"
+	IconWarning    Icon = "warning"
+	IconTip        Icon = "tip"
+	IconCheck      Icon = "check"
+	IconLock       Icon = "lock"
+	IconConfidence Icon = "confidence"
+	IconArrow      Icon = "arrow"
+)
+
+// themeIcons maps each theme to its icons
+var themeIcons = map[Theme]map[Icon]string{
+	ThemeEmoji: {
+		IconSafe:       "✅",
+		IconMedium:     "⚠️",
+		IconHigh:       "🔴",
+		IconUnknown:    "❓",
+		IconWarning:    "⚠️",
+		IconTip:        "💡",
+		IconCheck:      "✓",
+		IconLock:       "🔒",
+		IconConfidence: "✨",
+		IconArrow:      "→",
+	},
+	ThemeASCII: {
+		IconSafe:       "[SAFE]",
+		IconMedium:     "[MEDIUM]",
+		IconHigh:       "[HIGH]",
+		IconUnknown:    "[?]",
+		IconWarning:    "[!]",
+		IconTip:        "[tip]",
+		IconCheck:      "[ok]",
+		IconLock:       "[locked]",
+		IconConfidence: "*",
+		IconArrow:      "->",
+	},
+}
+
+// themeBars maps each theme to its filled and empty progress bar cells
+var themeBars = map[Theme][2]string{
+	ThemeEmoji: {"█", "░"},
+	ThemeASCII: {"#", "-"},
+}
+
+// currentTheme is the theme used for rendering
+var currentTheme = ThemeEmoji
+
+// SetTheme sets the theme used for rendering icons and progress bars
+func SetTheme(theme Theme) error {
+	if _, ok := themeIcons[theme]; !ok {
+		return fmt.Errorf("unknown theme %q (use %s or %s)", theme, ThemeEmoji, ThemeASCII)
+	}
+	currentTheme = theme
+	return nil
+}
+
+// CurrentTheme returns the theme used for rendering
+func CurrentTheme() Theme {
+	return currentTheme
+}
+
+// Symbol returns icon rendered in the current theme
+func Symbol(icon Icon) string {
+	return themeIcons[currentTheme][icon]
+}
+
+// Bar renders a progress bar of width cells with filled cells filled in the
+// current theme
+func Bar(filled, width int) string {
+	if filled < 0 {
+		filled = 0
+	}
+	if filled > width {
+		filled = width
+	}
+	
+	cells := themeBars[currentTheme]
+	return strings.Repeat(cells[0], filled) + strings.Repeat(cells[1], width-filled)
+}
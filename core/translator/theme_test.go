@@ -0,0 +1,52 @@
+package translator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTheme_ASCII(t *testing.T) {
+	assert.NoError(t, SetTheme(ThemeASCII))
+	t.Cleanup(func() { SetTheme(ThemeEmoji) })
+
+	tests := []struct {
+		risk Risk
+		want string
+	}{
+		{RiskSafe, "[SAFE]"},
+		{RiskMedium, "[MEDIUM]"},
+		{RiskHigh, "[HIGH]"},
+		{Risk("unknown"), "[?]"},
+	}
+
+	for _, tt := range tests {
+		c := &Candidate{RiskLevel: tt.risk}
+		assert.Equal(t, tt.want, c.RiskIcon())
+	}
+
+	assert.Equal(t, "#####-----", Bar(5, 10))
+
+	breakdown := (&Candidate{Command: "rm -rf /data", Confidence: 60, RiskLevel: RiskHigh}).CalculateConfidenceBreakdown("delete data")
+	visual := breakdown.Visualize()
+	assert.Contains(t, visual, "[!] ")
+	assert.Contains(t, visual, "[tip] ")
+
+	for _, r := range visual {
+		if r > 127 {
+			t.Fatalf("ascii theme output contains non-ASCII rune %q:\n%s", r, visual)
+		}
+	}
+}
+
+func TestTheme_DefaultEmoji(t *testing.T) {
+	assert.Equal(t, ThemeEmoji, CurrentTheme())
+	assert.Equal(t, "✅", (&Candidate{RiskLevel: RiskSafe}).RiskIcon())
+	assert.True(t, strings.HasPrefix(Bar(1, 2), "█"))
+}
+
+func TestSetTheme_Unknown(t *testing.T) {
+	assert.Error(t, SetTheme(Theme("neon")))
+	assert.Equal(t, ThemeEmoji, CurrentTheme())
+}
@@ -1,16 +1,47 @@
 package translator
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"sort"
 	"strings"
+	"unicode"
 )
 
 var (
 	// ErrNoMatch is returned when no templates match the prompt
 	ErrNoMatch = errors.New("no matching command templates found")
+
+	// ErrEmptyPrompt is returned when the prompt is empty or only whitespace
+	ErrEmptyPrompt = errors.New("prompt is empty")
+	
+	// ErrControlCharacters is returned when the prompt contains control
+	// characters other than tabs and line breaks
+	ErrControlCharacters = errors.New("prompt contains control characters")
+	
+	// ErrInvalidCandidate is returned, wrapped in an *InvalidCandidateError,
+	// when a template generates a malformed command
+	ErrInvalidCandidate = errors.New("template generated an invalid command")
 )
 
+// InvalidCandidateError is returned when a template turns a prompt into a
+// malformed command. It is a bug in the template, so it is reported rather
+// than the candidate being quietly dropped.
+type InvalidCandidateError struct {
+	Template string // the template's description
+	Prompt   string
+	Err      error // why the candidate is invalid
+}
+
+func (e *InvalidCandidateError) Error() string {
+	return fmt.Sprintf("%v: %q template for %q: %v", ErrInvalidCandidate, e.Template, e.Prompt, e.Err)
+}
+
+func (e *InvalidCandidateError) Unwrap() error {
+	return ErrInvalidCandidate
+}
+
 // Translator handles natural language to command translation
 type Translator struct {
 	templates []*Template
@@ -32,16 +63,42 @@ func NewWithTemplates(templates []*Template) *Translator {
 
 // Translate converts a natural language prompt into command candidates
 func (t *Translator) Translate(prompt string) ([]*Candidate, error) {
-	if strings.TrimSpace(prompt) == "" {
-		return nil, errors.New("empty prompt")
+	return t.TranslateContext(context.Background(), prompt)
+}
+
+// TranslateContext is Translate with cancellation: it stops between
+// templates and returns ctx.Err() once ctx is done
+func (t *Translator) TranslateContext(ctx context.Context, prompt string) ([]*Candidate, error) {
+	// A prompt pasted over several lines is one request
+	prompt = strings.TrimSpace(strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(prompt))
+	if prompt == "" {
+		return nil, ErrEmptyPrompt
+	}
+	if strings.IndexFunc(prompt, func(r rune) bool { return r != '\t' && unicode.IsControl(r) }) >= 0 {
+		return nil, ErrControlCharacters
+	}
+	
+	// Some commands are never suggested, whatever the policy allows
+	if blocked := CheckBlocklist(prompt); blocked != nil {
+		return nil, &BlockedPromptError{Blocked: blocked}
 	}
 	
 	var candidates []*Candidate
 	
 	// Try to match against all templates
 	for _, template := range t.templates {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		
 		if matches, ok := template.Match(prompt); ok {
 			candidate := template.Generator(matches)
+			if candidate == nil {
+				continue
+			}
+			candidate.Source = SourceCoreTemplate
+			candidate.Category = template.Category
+			candidate.AssessRedirections()
 			
 			// Apply keyword bonus
 			keywordBonus := template.CalculateKeywordBonus(prompt)
@@ -52,10 +109,18 @@ func (t *Translator) Translate(prompt string) ([]*Candidate, error) {
 				candidate.Confidence = 100
 			}
 			
+			if err := candidate.Validate(); err != nil {
+				return nil, &InvalidCandidateError{Template: template.Description, Prompt: prompt, Err: err}
+			}
+			
 			candidates = append(candidates, candidate)
 		}
 	}
 	
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	
 	if len(candidates) == 0 {
 		return nil, ErrNoMatch
 	}
@@ -1,7 +1,12 @@
 package translator
 
 import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestTranslator_Translate(t *testing.T) {
@@ -79,6 +84,11 @@ func TestTranslator_Translate(t *testing.T) {
 			prompt:  "",
 			wantErr: true,
 		},
+		{
+			name:    "Whitespace-only prompt",
+			prompt:  " \t\n ",
+			wantErr: true,
+		},
 		{
 			name:    "No match",
 			prompt:  "xyzabc123 nonsense prompt that should not match anything",
@@ -205,6 +215,33 @@ func TestCandidate_String(t *testing.T) {
 	}
 }
 
+func TestCandidate_Markdown(t *testing.T) {
+	candidates, err := New().Translate("find files larger than 100MB")
+	if err != nil {
+		t.Fatalf("Translate() error: %v", err)
+	}
+	
+	md := candidates[0].Markdown()
+	for _, want := range []string{
+		"## `find . -type f -size +100M`\n",
+		"- **Risk:** safe\n",
+		"### Steps\n\n1. Search current directory recursively: `find .`\n" +
+			"2. Filter for regular files only: `-type f`\n" +
+			"3. Match files larger than 100MB: `-size +100M`\n",
+		"### References\n\n- <https://man7.org/linux/man-pages/man1/find.1.html>\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown() missing %q:\n%s", want, md)
+		}
+	}
+	
+	// Backticks in a command don't end its code span early
+	c := &Candidate{Command: "echo `date`", RiskLevel: RiskSafe}
+	if md := c.Markdown(); !strings.HasPrefix(md, "## `` echo `date` ``\n") {
+		t.Errorf("Markdown() of a command with backticks:\n%s", md)
+	}
+}
+
 func TestCandidate_RiskIcon(t *testing.T) {
 	tests := []struct {
 		risk Risk
@@ -225,6 +262,256 @@ func TestCandidate_RiskIcon(t *testing.T) {
 	}
 }
 
+func TestTranslator_EmptyPrompt(t *testing.T) {
+	translator := New()
+	
+	for _, prompt := range []string{"", "   ", "\t\n"} {
+		if _, err := translator.Translate(prompt); err != ErrEmptyPrompt {
+			t.Errorf("Translate(%q) error = %v, want ErrEmptyPrompt", prompt, err)
+		}
+	}
+}
+
+func TestTranslator_ControlCharacters(t *testing.T) {
+	translator := New()
+	
+	if _, err := translator.Translate("commit changes \x00"); err != ErrControlCharacters {
+		t.Errorf("Translate() with NUL error = %v, want ErrControlCharacters", err)
+	}
+	
+	// Line breaks are spaces
+	candidates, err := translator.Translate("show disk\r\nusage")
+	if err != nil || len(candidates) == 0 {
+		t.Errorf("Translate() over two lines = %v, %v", candidates, err)
+	}
+}
+
+func TestTranslator_InvalidCandidate(t *testing.T) {
+	broken := &Template{
+		Patterns:    []*regexp.Regexp{regexp.MustCompile(`(?i)say (.+)`)},
+		Description: "Echo a message",
+		Generator: func(matches []string) *Candidate {
+			return &Candidate{Command: "echo '" + matches[1], Explanation: "Echo", RiskLevel: RiskSafe, Confidence: 90}
+		},
+	}
+	
+	_, err := NewWithTemplates([]*Template{broken}).Translate("say hello")
+	var invalid *InvalidCandidateError
+	if !errors.Is(err, ErrInvalidCandidate) || !errors.As(err, &invalid) {
+		t.Fatalf("Translate() error = %v, want ErrInvalidCandidate", err)
+	}
+	if invalid.Template != "Echo a message" || invalid.Prompt != "say hello" {
+		t.Errorf("error = %+v, want the template and prompt", invalid)
+	}
+}
+
+func TestTranslator_TranslateContext_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	
+	// The first template cancels mid-translation, as a slow backend would
+	// see; later templates must not run
+	later := false
+	translator := NewWithTemplates([]*Template{
+		{
+			Patterns: []*regexp.Regexp{regexp.MustCompile(`slow`)},
+			Generator: func([]string) *Candidate {
+				cancel()
+				return &Candidate{Command: "sleep 1", Explanation: "Waits", Confidence: 50, RiskLevel: RiskSafe}
+			},
+		},
+		{
+			Patterns: []*regexp.Regexp{regexp.MustCompile(`slow`)},
+			Generator: func([]string) *Candidate {
+				later = true
+				return nil
+			},
+		},
+	})
+	
+	start := time.Now()
+	candidates, err := translator.TranslateContext(ctx, "slow prompt")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("TranslateContext() error = %v, want context.Canceled", err)
+	}
+	if candidates != nil {
+		t.Errorf("TranslateContext() = %v, want no candidates", candidates)
+	}
+	if later {
+		t.Error("templates ran after the context was cancelled")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("TranslateContext() took %v after cancellation", elapsed)
+	}
+	
+	// An expired deadline fails before any matching
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	if _, err := New().TranslateContext(expired, "show git changes"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("TranslateContext() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestCandidate_Validate(t *testing.T) {
+	valid := func() *Candidate {
+		return &Candidate{
+			Command:     `git commit -m "it's done"`,
+			Explanation: "Commits changes",
+			Confidence:  90,
+			RiskLevel:   RiskMedium,
+		}
+	}
+	
+	if err := valid().Validate(); err != nil {
+		t.Fatalf("Validate() error = %v for valid candidate", err)
+	}
+	
+	tests := []struct {
+		name   string
+		modify func(c *Candidate)
+	}{
+		{"empty command", func(c *Candidate) { c.Command = "  " }},
+		{"multi-line command", func(c *Candidate) { c.Command = "ls\nrm -rf ~" }},
+		{"unbalanced double quote", func(c *Candidate) { c.Command = `git commit -m "oops` }},
+		{"unbalanced single quote", func(c *Candidate) { c.Command = `echo 'oops` }},
+		{"trailing backslash", func(c *Candidate) { c.Command = `echo oops\` }},
+		{"empty explanation", func(c *Candidate) { c.Explanation = "" }},
+		{"unknown risk", func(c *Candidate) { c.RiskLevel = "extreme" }},
+		{"confidence too high", func(c *Candidate) { c.Confidence = 101 }},
+		{"negative confidence", func(c *Candidate) { c.Confidence = -1 }},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.modify(c)
+			if err := c.Validate(); err == nil {
+				t.Errorf("Validate() = nil, want error for %+v", c)
+			}
+		})
+	}
+}
+
+func TestTranslator_CommitMessageEscaped(t *testing.T) {
+	translator := New()
+	
+	candidates, err := translator.Translate(`commit all changes with message $(rm -rf ~) "quoted" \back`)
+	if err != nil {
+		t.Fatalf("Translate() error: %v", err)
+	}
+	
+	want := `git add -A && git commit -m "\$(rm -rf ~) \"quoted\" \\back"`
+	if candidates[0].Command != want {
+		t.Errorf("Command = %s, want %s", candidates[0].Command, want)
+	}
+}
+
+func TestTemplates_MissingGroups(t *testing.T) {
+	// Every generator must survive missing or empty capture groups
+	for i, template := range CommandTemplates {
+		for _, matches := range [][]string{nil, {""}, {"", ""}, {"", "", ""}} {
+			c := template.Generator(matches)
+			if c == nil {
+				continue
+			}
+			if err := c.Validate(); err != nil {
+				t.Errorf("template %d (%s) with %q: invalid candidate: %v", i, template.Description, matches, err)
+			}
+		}
+	}
+	
+	translator := New()
+	tests := []struct {
+		name    string
+		prompt  string
+		wantCmd string
+	}{
+		{
+			name:    "Size without unit defaults to MB",
+			prompt:  "find files larger than 250",
+			wantCmd: "find . -type f -size +250M",
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates, err := translator.Translate(tt.prompt)
+			if err != nil {
+				t.Fatalf("Translate() error: %v", err)
+			}
+			if candidates[0].Command != tt.wantCmd {
+				t.Errorf("Command = %q, want %q", candidates[0].Command, tt.wantCmd)
+			}
+		})
+	}
+	
+	// Defaults when a required group is missing
+	defaults := map[string]string{
+		"Commit changes to git":            `git add -A && git commit -m "Update files"`,
+		"Archive old log files":            "-mtime +30 ",
+		"Find and delete files by pattern": "",
+	}
+	for _, template := range CommandTemplates {
+		want, ok := defaults[template.Description]
+		if !ok {
+			continue
+		}
+		c := template.Generator([]string{"whole match"})
+		switch {
+		case want == "" && c != nil:
+			t.Errorf("%s: Generator() = %q, want nil", template.Description, c.Command)
+		case want != "" && (c == nil || !containsHelper(c.Command, want)):
+			t.Errorf("%s: Generator() = %v, want command containing %q", template.Description, c, want)
+		}
+	}
+}
+
+// FuzzTranslate checks that arbitrary prompts never panic the translator and
+// that every candidate it returns is well formed
+func FuzzTranslate(f *testing.F) {
+	seeds := []string{
+		"find files larger than 100MB",
+		"list files over 5 gb",
+		"delete all .DS_Store files",
+		"archive logs older than 7 days",
+		"show git changes",
+		"commit all changes with message \"Fix bug\"",
+		"git commit -m 'wip'",
+		"cleanup docker containers",
+		"show disk usage",
+		"find all TODO comments",
+		"",
+		" \t\n ",
+		"xyzabc123 nonsense prompt that should not match anything",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	
+	translator := New()
+	f.Fuzz(func(t *testing.T, prompt string) {
+		candidates, err := translator.Translate(prompt)
+		if errors.Is(err, ErrInvalidCandidate) {
+			t.Fatalf("Translate(%q) error: %v", prompt, err)
+		}
+		if err != nil {
+			if len(candidates) != 0 {
+				t.Errorf("Translate(%q) returned candidates with error %v", prompt, err)
+			}
+			return
+		}
+		
+		if len(candidates) == 0 || len(candidates) > 3 {
+			t.Errorf("Translate(%q) returned %d candidates", prompt, len(candidates))
+		}
+		for _, c := range candidates {
+			if err := c.Validate(); err != nil {
+				t.Errorf("Translate(%q) returned invalid candidate: %v", prompt, err)
+			}
+		}
+	})
+}
+
 func contains(s, substr string) bool {
 	return len(s) > 0 && len(substr) > 0 && s != substr && 
 		(s[:len(substr)] == substr || s[len(s)-len(substr):] == substr || 
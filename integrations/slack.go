@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // SlackIntegration provides Slack bot functionality
 type SlackIntegration struct {
 	webhookURL string
 	botToken   string
+	client     *http.Client
 }
 
 // NewSlackIntegration creates a new Slack integration
@@ -18,6 +20,7 @@ func NewSlackIntegration(webhookURL, botToken string) *SlackIntegration {
 	return &SlackIntegration{
 		webhookURL: webhookURL,
 		botToken:   botToken,
+		client:     &http.Client{Timeout: 10 * time.Second},
 	}
 }
 
@@ -29,7 +32,7 @@ func (si *SlackIntegration) SendMessage(channel, text string) error {
 	}
 	
 	jsonData, _ := json.Marshal(message)
-	resp, err := http.Post(si.webhookURL, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := si.client.Post(si.webhookURL, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return err
 	}
@@ -5,7 +5,7 @@ import (
 	"regexp"
 	"strings"
 	
-	"github.com/yourusername/quickcmd/core/plugins"
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
 )
 
 // AWSPlugin handles AWS CLI command translations
@@ -13,10 +13,13 @@ type AWSPlugin struct {
 	costThreshold float64 // Cost threshold for approval (in USD)
 }
 
+// defaultPlugin is the registered AWS plugin instance
+var defaultPlugin = &AWSPlugin{
+	costThreshold: 10.0, // Default $10 threshold
+}
+
 func init() {
-	plugin := &AWSPlugin{
-		costThreshold: 10.0, // Default $10 threshold
-	}
+	plugin := defaultPlugin
 	
 	metadata := &plugins.PluginMetadata{
 		Name:        "aws",
@@ -30,11 +33,31 @@ func init() {
 	plugins.Register(plugin, metadata)
 }
 
+// SetCostThreshold sets the estimated cost (in USD) above which the
+// registered plugin requires approval
+func SetCostThreshold(threshold float64) {
+	defaultPlugin.costThreshold = threshold
+}
+
+// threshold returns the cost threshold for ctx: the plugin config's
+// cost_threshold if set, otherwise the plugin default
+func (p *AWSPlugin) threshold(ctx plugins.Context) float64 {
+	if threshold, ok := ctx.ConfigFloat(p.Name(), "cost_threshold"); ok {
+		return threshold
+	}
+	return p.costThreshold
+}
+
 // Name returns the plugin name
 func (p *AWSPlugin) Name() string {
 	return "aws"
 }
 
+// CanHandle reports whether command runs aws
+func (p *AWSPlugin) CanHandle(command string) bool {
+	return plugins.RunsExecutable(command, "aws")
+}
+
 // Translate translates AWS-related prompts into AWS CLI commands
 func (p *AWSPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Candidate, error) {
 	promptLower := strings.ToLower(prompt)
@@ -45,6 +68,7 @@ func (p *AWSPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 	if matched, _ := regexp.MatchString(`(?i)list\s+(?:ec2\s+)?instances?`, promptLower); matched {
 		candidates = append(candidates, &plugins.Candidate{
 			Command:     "aws ec2 describe-instances --query 'Reservations[*].Instances[*].[InstanceId,State.Name,InstanceType]' --output table",
+			Category:    "aws",
 			Explanation: "Lists all EC2 instances with their ID, state, and type",
 			Breakdown: []plugins.Step{
 				{Description: "Query EC2 instances", Command: "aws ec2 describe-instances"},
@@ -79,6 +103,7 @@ func (p *AWSPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 		
 		candidates = append(candidates, &plugins.Candidate{
 			Command:         fmt.Sprintf("aws autoscaling set-desired-capacity --auto-scaling-group-name %s --desired-capacity %s", asgName, desiredCapacity),
+			Category:        "aws",
 			Explanation:     fmt.Sprintf("Sets Auto Scaling Group '%s' desired capacity to %s instances", asgName, desiredCapacity),
 			Breakdown:       []plugins.Step{{Description: "Update ASG capacity", Command: fmt.Sprintf("aws autoscaling set-desired-capacity --auto-scaling-group-name %s --desired-capacity %s", asgName, desiredCapacity)}},
 			Confidence:      88,
@@ -101,6 +126,7 @@ func (p *AWSPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 	if matched, _ := regexp.MatchString(`(?i)list\s+(?:s3\s+)?buckets?`, promptLower); matched {
 		candidates = append(candidates, &plugins.Candidate{
 			Command:        "aws s3 ls",
+			Category:       "aws",
 			Explanation:    "Lists all S3 buckets in the account",
 			Breakdown:      []plugins.Step{{Description: "List S3 buckets", Command: "aws s3 ls"}},
 			Confidence:     95,
@@ -127,6 +153,7 @@ func (p *AWSPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 		
 		candidates = append(candidates, &plugins.Candidate{
 			Command:         fmt.Sprintf("aws s3 mb s3://%s", bucketName),
+			Category:        "aws",
 			Explanation:     fmt.Sprintf("Creates a new S3 bucket named '%s'", bucketName),
 			Breakdown:       []plugins.Step{{Description: "Make S3 bucket", Command: fmt.Sprintf("aws s3 mb s3://%s", bucketName)}},
 			Confidence:      90,
@@ -156,6 +183,7 @@ func (p *AWSPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 		
 		candidates = append(candidates, &plugins.Candidate{
 			Command:        fmt.Sprintf("aws cloudformation describe-stacks --stack-name %s", stackName),
+			Category:       "aws",
 			Explanation:    fmt.Sprintf("Describes CloudFormation stack '%s'", stackName),
 			Breakdown:      []plugins.Step{{Description: "Describe stack", Command: fmt.Sprintf("aws cloudformation describe-stacks --stack-name %s", stackName)}},
 			Confidence:     93,
@@ -170,6 +198,14 @@ func (p *AWSPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 		})
 	}
 	
+	// Run against the selected environment's profile
+	if env := ctx.Environment; env != nil && env.AWSProfile != "" {
+		for _, candidate := range candidates {
+			candidate.PinCommands("aws", "--profile", env.AWSProfile)
+			candidate.PluginMetadata["aws_profile"] = env.AWSProfile
+		}
+	}
+	
 	return candidates, nil
 }
 
@@ -181,13 +217,14 @@ func (p *AWSPlugin) PreRunCheck(ctx plugins.Context, candidate *plugins.Candidat
 	}
 	
 	// Check for cost threshold
+	threshold := p.threshold(ctx)
 	if candidate.PluginMetadata != nil {
 		if cost, ok := candidate.PluginMetadata["estimated_cost"].(float64); ok {
 			result.Metadata["estimated_cost"] = cost
 			
-			if cost > p.costThreshold {
+			if cost > threshold {
 				result.RequiresApproval = true
-				result.ApprovalMessage = fmt.Sprintf("Estimated cost $%.2f exceeds threshold $%.2f. Type 'APPROVE COST' to confirm", cost, p.costThreshold)
+				result.ApprovalMessage = fmt.Sprintf("Estimated cost $%.2f exceeds threshold $%.2f. Type 'APPROVE COST' to confirm", cost, threshold)
 				result.AdditionalChecks = append(result.AdditionalChecks, "cost_threshold")
 			}
 		}
@@ -220,8 +257,20 @@ func (p *AWSPlugin) PreRunCheck(ctx plugins.Context, candidate *plugins.Candidat
 	return result, nil
 }
 
-// RequiresApproval checks if the candidate requires approval
+// RequiresApproval checks if the candidate requires approval using the
+// default cost threshold
 func (p *AWSPlugin) RequiresApproval(candidate *plugins.Candidate) bool {
+	return p.RequiresApprovalWithContext(plugins.Context{}, candidate)
+}
+
+// RequiresApprovalWithContext checks if the candidate requires approval using
+// the cost threshold configured in ctx. Anything beyond reads in a
+// production environment requires approval.
+func (p *AWSPlugin) RequiresApprovalWithContext(ctx plugins.Context, candidate *plugins.Candidate) bool {
+	if ctx.IsProduction() && candidate.RiskLevel != plugins.RiskSafe {
+		return true
+	}
+	
 	// Operations that create resources require approval
 	if candidate.PluginMetadata != nil {
 		if operation, ok := candidate.PluginMetadata["operation"].(string); ok {
@@ -235,7 +284,7 @@ func (p *AWSPlugin) RequiresApproval(candidate *plugins.Candidate) bool {
 		
 		// Check cost threshold
 		if cost, ok := candidate.PluginMetadata["estimated_cost"].(float64); ok {
-			if cost > p.costThreshold {
+			if cost > p.threshold(ctx) {
 				return true
 			}
 		}
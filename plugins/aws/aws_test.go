@@ -4,7 +4,7 @@ import (
 	"testing"
 	"time"
 	
-	"github.com/yourusername/quickcmd/core/plugins"
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
 )
 
 func TestAWSPlugin_Translate(t *testing.T) {
@@ -167,6 +167,60 @@ func TestAWSPlugin_PreRunCheck(t *testing.T) {
 	}
 }
 
+func TestAWSPlugin_PreRunCheck_ConfiguredThreshold(t *testing.T) {
+	plugin := &AWSPlugin{costThreshold: 10.0}
+	candidate := &plugins.Candidate{
+		Command: "aws s3 mb s3://my-bucket",
+		PluginMetadata: map[string]interface{}{
+			"operation":      "create-bucket",
+			"estimated_cost": 5.0,
+		},
+	}
+	
+	tests := []struct {
+		name         string
+		config       map[string]interface{}
+		wantApproval bool
+	}{
+		{"default threshold", nil, false},
+		{"lower threshold", map[string]interface{}{"cost_threshold": 2.5}, true},
+		{"integer threshold", map[string]interface{}{"cost_threshold": 1}, true},
+		{"higher threshold", map[string]interface{}{"cost_threshold": 100.0}, false},
+		{"non-numeric threshold ignored", map[string]interface{}{"cost_threshold": "cheap"}, false},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := plugins.Context{
+				PluginConfig: map[string]map[string]interface{}{"aws": tt.config},
+			}
+			
+			result, err := plugin.PreRunCheck(ctx, candidate)
+			if err != nil {
+				t.Fatalf("PreRunCheck() error = %v", err)
+			}
+			
+			if result.RequiresApproval != tt.wantApproval {
+				t.Errorf("PreRunCheck() requires approval = %v, want %v", result.RequiresApproval, tt.wantApproval)
+			}
+		})
+	}
+	
+	ctx := plugins.Context{
+		PluginConfig: map[string]map[string]interface{}{"aws": {"cost_threshold": 2.5}},
+	}
+	readOnly := &plugins.Candidate{
+		Command:        "aws s3 ls",
+		PluginMetadata: map[string]interface{}{"operation": "list-buckets", "estimated_cost": 5.0},
+	}
+	if !plugin.RequiresApprovalWithContext(ctx, readOnly) {
+		t.Error("RequiresApprovalWithContext() should use the configured threshold")
+	}
+	if plugin.RequiresApproval(readOnly) {
+		t.Error("RequiresApproval() should use the default threshold")
+	}
+}
+
 func TestAWSPlugin_RequiresApproval(t *testing.T) {
 	plugin := &AWSPlugin{costThreshold: 10.0}
 	
@@ -7,7 +7,7 @@ import (
 	"strings"
 	"time"
 	
-	"github.com/yourusername/quickcmd/core/plugins"
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
 )
 
 // GitPlugin handles Git-related command translations
@@ -32,6 +32,11 @@ func (p *GitPlugin) Name() string {
 	return "git"
 }
 
+// CanHandle reports whether command runs git
+func (p *GitPlugin) CanHandle(command string) bool {
+	return plugins.RunsExecutable(command, "git")
+}
+
 // Translate translates Git-related prompts into commands
 func (p *GitPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Candidate, error) {
 	promptLower := strings.ToLower(prompt)
@@ -45,6 +50,7 @@ func (p *GitPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 		
 		candidates = append(candidates, &plugins.Candidate{
 			Command:     fmt.Sprintf("git checkout -b %s && git add -A && git commit -m 'Backup commit'", branchName),
+			Category:    "git",
 			Explanation: fmt.Sprintf("Creates a new backup branch '%s' and commits all changes", branchName),
 			Breakdown: []plugins.Step{
 				{Description: "Create and switch to new branch", Command: fmt.Sprintf("git checkout -b %s", branchName)},
@@ -75,6 +81,7 @@ func (p *GitPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 		
 		candidates = append(candidates, &plugins.Candidate{
 			Command:     fmt.Sprintf("git add -A && git commit -m \"%s\"", message),
+			Category:    "git",
 			Explanation: fmt.Sprintf("Stages all changes and commits with message: '%s'", message),
 			Breakdown: []plugins.Step{
 				{Description: "Stage all changes", Command: "git add -A"},
@@ -98,6 +105,7 @@ func (p *GitPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 		
 		candidates = append(candidates, &plugins.Candidate{
 			Command:     fmt.Sprintf("git checkout -b %s", branchName),
+			Category:    "git",
 			Explanation: fmt.Sprintf("Creates and switches to new branch '%s'", branchName),
 			Breakdown: []plugins.Step{
 				{Description: "Create and checkout new branch", Command: fmt.Sprintf("git checkout -b %s", branchName)},
@@ -113,6 +121,7 @@ func (p *GitPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 	if matched, _ := regexp.MatchString(`(?i)revert\s+last\s+commit`, promptLower); matched {
 		candidates = append(candidates, &plugins.Candidate{
 			Command:     "git reset --soft HEAD~1",
+			Category:    "git",
 			Explanation: "Reverts the last commit but keeps changes staged",
 			Breakdown: []plugins.Step{
 				{Description: "Reset to previous commit, keep changes", Command: "git reset --soft HEAD~1"},
@@ -136,6 +145,7 @@ func (p *GitPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 		
 		candidates = append(candidates, &plugins.Candidate{
 			Command:         fmt.Sprintf("git branch -D %s", branchName),
+			Category:        "git",
 			Explanation:     fmt.Sprintf("Force deletes branch '%s'", branchName),
 			Breakdown:       []plugins.Step{{Description: "Delete branch", Command: fmt.Sprintf("git branch -D %s", branchName)}},
 			Confidence:      85,
@@ -4,7 +4,7 @@ import (
 	"testing"
 	"time"
 	
-	"github.com/yourusername/quickcmd/core/plugins"
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
 )
 
 func TestGitPlugin_Translate(t *testing.T) {
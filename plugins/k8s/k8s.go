@@ -5,12 +5,26 @@ import (
 	"regexp"
 	"strings"
 	
-	"github.com/yourusername/quickcmd/core/plugins"
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
 )
 
 // K8sPlugin handles Kubernetes-related command translations
 type K8sPlugin struct{}
 
+// namespaceFlagPattern extracts the namespace from a kubectl command
+var namespaceFlagPattern = regexp.MustCompile(`(?:^|\s)(?:-n|--namespace)(?:\s+|=)(\S+)`)
+
+// allNamespacesPattern matches kubectl flags that target every namespace
+var allNamespacesPattern = regexp.MustCompile(`(?:^|\s)(?:-A|--all-namespaces)(?:\s|$)`)
+
+// resourceNamePattern matches a valid Kubernetes resource or namespace name
+// (an RFC 1123 subdomain). Names taken from a prompt must match it before
+// they are put in a command, so a prompt can't smuggle in shell syntax.
+var resourceNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]{0,251}[a-z0-9])?$`)
+
+// manifestPathPattern matches a plain manifest path
+var manifestPathPattern = regexp.MustCompile(`^[A-Za-z0-9_./-]+\.ya?ml$`)
+
 func init() {
 	plugin := &K8sPlugin{}
 	metadata := &plugins.PluginMetadata{
@@ -30,9 +44,15 @@ func (p *K8sPlugin) Name() string {
 	return "k8s"
 }
 
+// CanHandle reports whether command runs kubectl
+func (p *K8sPlugin) CanHandle(command string) bool {
+	return plugins.RunsExecutable(command, "kubectl")
+}
+
 // Translate translates Kubernetes-related prompts into kubectl commands
 func (p *K8sPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Candidate, error) {
 	promptLower := strings.ToLower(prompt)
+	dryRun := p.dryRunMode(ctx)
 	
 	var candidates []*plugins.Candidate
 	
@@ -43,13 +63,14 @@ func (p *K8sPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 		
 		deploymentName := "deployment-name"
 		replicas := "3"
-		if len(matches) > 2 {
+		if len(matches) > 2 && resourceNamePattern.MatchString(matches[1]) {
 			deploymentName = matches[1]
 			replicas = matches[2]
 		}
 		
 		candidates = append(candidates, &plugins.Candidate{
 			Command:     fmt.Sprintf("kubectl scale deployment %s --replicas=%s", deploymentName, replicas),
+			Category:    "k8s",
 			Explanation: fmt.Sprintf("Scales deployment '%s' to %s replicas", deploymentName, replicas),
 			Breakdown: []plugins.Step{
 				{Description: "Scale deployment", Command: fmt.Sprintf("kubectl scale deployment %s --replicas=%s", deploymentName, replicas)},
@@ -71,7 +92,7 @@ func (p *K8sPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 	if matched, _ := regexp.MatchString(`(?i)(?:get|list|show)\s+pods?`, promptLower); matched {
 		namespace := "default"
 		nsPattern := regexp.MustCompile(`(?i)(?:in|from)\s+namespace\s+(\S+)`)
-		if matches := nsPattern.FindStringSubmatch(prompt); len(matches) > 1 {
+		if matches := nsPattern.FindStringSubmatch(prompt); len(matches) > 1 && resourceNamePattern.MatchString(matches[1]) {
 			namespace = matches[1]
 		}
 		
@@ -82,6 +103,7 @@ func (p *K8sPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 		
 		candidates = append(candidates, &plugins.Candidate{
 			Command:     cmd,
+			Category:    "k8s",
 			Explanation: fmt.Sprintf("Lists all pods in namespace '%s'", namespace),
 			Breakdown: []plugins.Step{
 				{Description: "Get pods", Command: cmd},
@@ -103,14 +125,22 @@ func (p *K8sPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 		matches := podPattern.FindStringSubmatch(prompt)
 		
 		podName := "pod-name"
-		if len(matches) > 1 {
+		if len(matches) > 1 && resourceNamePattern.MatchString(matches[1]) {
 			podName = matches[1]
 		}
 		
+		cmd := fmt.Sprintf("kubectl delete pod %s", podName)
+		simulate := dryRunCommand(cmd, dryRun)
+		
 		candidates = append(candidates, &plugins.Candidate{
-			Command:         fmt.Sprintf("kubectl delete pod %s", podName),
-			Explanation:     fmt.Sprintf("Deletes pod '%s'", podName),
-			Breakdown:       []plugins.Step{{Description: "Delete pod", Command: fmt.Sprintf("kubectl delete pod %s", podName)}},
+			Command:     cmd,
+			Category:    "k8s",
+			Explanation: fmt.Sprintf("Deletes pod '%s'", podName),
+			Breakdown: []plugins.Step{
+				{Description: "Check what would be deleted (dry run)", Command: simulate},
+				{Description: "Delete pod", Command: cmd},
+			},
+			SimulateCommand: simulate,
 			Confidence:      88,
 			RiskLevel:       plugins.RiskHigh,
 			Destructive:     true,
@@ -129,14 +159,22 @@ func (p *K8sPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 		matches := filePattern.FindStringSubmatch(prompt)
 		
 		fileName := "manifest.yaml"
-		if len(matches) > 1 {
+		if len(matches) > 1 && manifestPathPattern.MatchString(matches[1]) {
 			fileName = matches[1]
 		}
 		
+		cmd := fmt.Sprintf("kubectl apply -f %s", fileName)
+		simulate := dryRunCommand(cmd, dryRun)
+		
 		candidates = append(candidates, &plugins.Candidate{
-			Command:         fmt.Sprintf("kubectl apply -f %s", fileName),
-			Explanation:     fmt.Sprintf("Applies Kubernetes manifest from '%s'", fileName),
-			Breakdown:       []plugins.Step{{Description: "Apply manifest", Command: fmt.Sprintf("kubectl apply -f %s", fileName)}},
+			Command:     cmd,
+			Category:    "k8s",
+			Explanation: fmt.Sprintf("Applies Kubernetes manifest from '%s'", fileName),
+			Breakdown: []plugins.Step{
+				{Description: "Preview changes (dry run)", Command: simulate},
+				{Description: "Apply manifest", Command: cmd},
+			},
+			SimulateCommand: simulate,
 			Confidence:      92,
 			RiskLevel:       plugins.RiskHigh,
 			Destructive:     false,
@@ -157,13 +195,14 @@ func (p *K8sPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 		
 		resourceType := "pod"
 		resourceName := "resource-name"
-		if len(matches) > 2 {
+		if len(matches) > 2 && resourceNamePattern.MatchString(matches[2]) {
 			resourceType = matches[1]
 			resourceName = matches[2]
 		}
 		
 		candidates = append(candidates, &plugins.Candidate{
 			Command:     fmt.Sprintf("kubectl describe %s %s", resourceType, resourceName),
+			Category:    "k8s",
 			Explanation: fmt.Sprintf("Shows detailed information about %s '%s'", resourceType, resourceName),
 			Breakdown:   []plugins.Step{{Description: "Describe resource", Command: fmt.Sprintf("kubectl describe %s %s", resourceType, resourceName)}},
 			Confidence:  94,
@@ -176,6 +215,8 @@ func (p *K8sPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Ca
 		})
 	}
 	
+	pinEnvironment(ctx.Environment, candidates)
+	
 	return candidates, nil
 }
 
@@ -189,6 +230,15 @@ func (p *K8sPlugin) PreRunCheck(ctx plugins.Context, candidate *plugins.Candidat
 	// Check if kubectl is available
 	// (In production, you'd actually check this)
 	
+	// Restrict commands to the configured namespaces
+	namespace := candidateNamespace(candidate)
+	result.Metadata["namespace"] = namespace
+	if !p.namespaceAllowed(ctx, namespace) {
+		result.Allowed = false
+		result.Reason = fmt.Sprintf("Namespace %q is not in the allowed namespaces for the k8s plugin", namespace)
+		return result, nil
+	}
+	
 	// Flag operations that alter cluster state as high-risk
 	if candidate.PluginMetadata != nil {
 		if operation, ok := candidate.PluginMetadata["operation"].(string); ok {
@@ -205,15 +255,40 @@ func (p *K8sPlugin) PreRunCheck(ctx plugins.Context, candidate *plugins.Candidat
 		}
 	}
 	
+	// Changes to a production environment always need approval
+	if ctx.IsProduction() && !result.RequiresApproval && candidate.RiskLevel != plugins.RiskSafe {
+		result.RequiresApproval = true
+		result.ApprovalMessage = fmt.Sprintf("Kubernetes change in production environment %q requires approval. Type 'K8S PROD' to confirm", ctx.Environment.Name)
+	}
+	
 	// Add RBAC hint
 	result.Metadata["rbac_required"] = true
 	result.Metadata["kube_context"] = "current" // In production, get actual context
+	if ctx.Environment != nil && ctx.Environment.KubeContext != "" {
+		result.Metadata["kube_context"] = ctx.Environment.KubeContext
+	}
 	
 	return result, nil
 }
 
 // RequiresApproval checks if the candidate requires approval
 func (p *K8sPlugin) RequiresApproval(candidate *plugins.Candidate) bool {
+	return p.RequiresApprovalWithContext(plugins.Context{}, candidate)
+}
+
+// RequiresApprovalWithContext checks if the candidate requires approval,
+// additionally requiring it for namespaces outside the configured
+// allowed_namespaces
+func (p *K8sPlugin) RequiresApprovalWithContext(ctx plugins.Context, candidate *plugins.Candidate) bool {
+	if !p.namespaceAllowed(ctx, candidateNamespace(candidate)) {
+		return true
+	}
+	
+	// Anything beyond reads in production requires approval
+	if ctx.IsProduction() && candidate.RiskLevel != plugins.RiskSafe {
+		return true
+	}
+	
 	// All destructive operations require approval
 	if candidate.Destructive {
 		return true
@@ -238,3 +313,76 @@ func (p *K8sPlugin) RequiresApproval(candidate *plugins.Candidate) bool {
 func (p *K8sPlugin) Scopes() []string {
 	return []string{"k8s:read", "k8s:write", "k8s:admin"}
 }
+
+// namespaceAllowed reports whether namespace is permitted by the plugin
+// config's allowed_namespaces. Every namespace is allowed if none are
+// configured; "*" allows commands across all namespaces.
+func (p *K8sPlugin) namespaceAllowed(ctx plugins.Context, namespace string) bool {
+	allowed, ok := ctx.ConfigStrings(p.Name(), "allowed_namespaces")
+	if !ok {
+		return true
+	}
+	
+	for _, ns := range allowed {
+		if ns == namespace || ns == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// dryRunMode returns the kubectl --dry-run strategy from the plugin
+// config's dry_run: "server" (the default), which validates against the
+// cluster, or "client", which works without cluster access
+func (p *K8sPlugin) dryRunMode(ctx plugins.Context) string {
+	if values, ok := ctx.ConfigStrings(p.Name(), "dry_run"); ok && len(values) == 1 && values[0] == "client" {
+		return "client"
+	}
+	return "server"
+}
+
+// pinEnvironment points kubectl candidates at env's kube context and, unless
+// the command already names one, its namespace. A nil env changes nothing.
+func pinEnvironment(env *plugins.Environment, candidates []*plugins.Candidate) {
+	if env == nil {
+		return
+	}
+	
+	for _, candidate := range candidates {
+		if candidate.PluginMetadata == nil {
+			candidate.PluginMetadata = make(map[string]interface{})
+		}
+		
+		var flags []string
+		if env.KubeContext != "" {
+			flags = append(flags, "--context", env.KubeContext)
+			candidate.PluginMetadata["kube_context"] = env.KubeContext
+		}
+		if env.Namespace != "" && !namespaceFlagPattern.MatchString(candidate.Command) && !allNamespacesPattern.MatchString(candidate.Command) {
+			flags = append(flags, "-n", env.Namespace)
+			candidate.PluginMetadata["namespace"] = env.Namespace
+		}
+		candidate.PinCommands("kubectl", flags...)
+	}
+}
+
+// dryRunCommand returns command as a kubectl dry run that reports what would
+// change without changing it
+func dryRunCommand(command, mode string) string {
+	return command + " --dry-run=" + mode
+}
+
+// candidateNamespace returns the namespace a candidate targets, "*" for
+// --all-namespaces, or "default"
+func candidateNamespace(candidate *plugins.Candidate) string {
+	if allNamespacesPattern.MatchString(candidate.Command) {
+		return "*"
+	}
+	if matches := namespaceFlagPattern.FindStringSubmatch(candidate.Command); len(matches) > 1 {
+		return matches[1]
+	}
+	if namespace, ok := candidate.PluginMetadata["namespace"].(string); ok && namespace != "" {
+		return namespace
+	}
+	return "default"
+}
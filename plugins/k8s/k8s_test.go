@@ -4,7 +4,7 @@ import (
 	"testing"
 	"time"
 	
-	"github.com/yourusername/quickcmd/core/plugins"
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
 )
 
 func TestK8sPlugin_Translate(t *testing.T) {
@@ -91,6 +91,75 @@ func TestK8sPlugin_Translate(t *testing.T) {
 	}
 }
 
+func TestK8sPlugin_Translate_DryRun(t *testing.T) {
+	plugin := &K8sPlugin{}
+	
+	tests := []struct {
+		name         string
+		prompt       string
+		config       map[string]interface{}
+		wantSimulate string
+	}{
+		{"apply", "apply manifest deployment.yaml", nil, "kubectl apply -f deployment.yaml --dry-run=server"},
+		{"delete", "delete pod nginx-123", nil, "kubectl delete pod nginx-123 --dry-run=server"},
+		{"client dry run", "apply manifest deployment.yaml", map[string]interface{}{"dry_run": "client"}, "kubectl apply -f deployment.yaml --dry-run=client"},
+		{"read-only command", "get pods", nil, ""},
+		{"injected pod name", "delete pod x;touch${IFS}/tmp/pwned;#", nil, "kubectl delete pod pod-name --dry-run=server"},
+		{"pod name comments out dry run", "delete pod x;#", nil, "kubectl delete pod pod-name --dry-run=server"},
+		{"injected manifest path", "apply manifest $(reboot).yaml", nil, "kubectl apply -f manifest.yaml --dry-run=server"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := plugins.Context{PluginConfig: map[string]map[string]interface{}{"k8s": tt.config}}
+			
+			candidates, err := plugin.Translate(ctx, tt.prompt)
+			if err != nil || len(candidates) != 1 {
+				t.Fatalf("Translate() = %d candidates, error %v", len(candidates), err)
+			}
+			
+			candidate := candidates[0]
+			if candidate.SimulateCommand != tt.wantSimulate {
+				t.Errorf("SimulateCommand = %q, want %q", candidate.SimulateCommand, tt.wantSimulate)
+			}
+			if tt.wantSimulate != "" && candidate.Breakdown[0].Command != tt.wantSimulate {
+				t.Errorf("first step = %q, want the dry run", candidate.Breakdown[0].Command)
+			}
+		})
+	}
+}
+
+func TestK8sPlugin_Translate_Environment(t *testing.T) {
+	plugin := &K8sPlugin{}
+	
+	tests := []struct {
+		name        string
+		prompt      string
+		wantCommand string
+	}{
+		{"pins context and namespace", "scale deployment api to 5 replicas", "kubectl --context prod-cluster -n prod scale deployment api --replicas=5"},
+		{"keeps explicit namespace", "get pods in namespace kube-system", "kubectl --context prod-cluster get pods -n kube-system"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := plugins.Context{Environment: &plugins.Environment{Name: "prod", KubeContext: "prod-cluster", Namespace: "prod", Production: true}}
+			
+			candidates, err := plugin.Translate(ctx, tt.prompt)
+			if err != nil || len(candidates) == 0 {
+				t.Fatalf("Translate() = %d candidates, error %v", len(candidates), err)
+			}
+			
+			if candidates[0].Command != tt.wantCommand {
+				t.Errorf("Command = %q, want %q", candidates[0].Command, tt.wantCommand)
+			}
+			if candidates[0].PluginMetadata["kube_context"] != "prod-cluster" {
+				t.Errorf("kube_context = %v, want prod-cluster", candidates[0].PluginMetadata["kube_context"])
+			}
+		})
+	}
+}
+
 func TestK8sPlugin_PreRunCheck(t *testing.T) {
 	plugin := &K8sPlugin{}
 	
@@ -170,6 +239,52 @@ func TestK8sPlugin_PreRunCheck(t *testing.T) {
 	}
 }
 
+func TestK8sPlugin_PreRunCheck_AllowedNamespaces(t *testing.T) {
+	plugin := &K8sPlugin{}
+	ctx := plugins.Context{
+		PluginConfig: map[string]map[string]interface{}{
+			"k8s": {"allowed_namespaces": []interface{}{"default", "staging"}},
+		},
+	}
+	
+	tests := []struct {
+		name        string
+		command     string
+		wantAllowed bool
+	}{
+		{"implicit default namespace", "kubectl get pods", true},
+		{"allowed namespace", "kubectl get pods -n staging", true},
+		{"namespace with equals", "kubectl get pods --namespace=staging", true},
+		{"disallowed namespace", "kubectl delete pod api -n production", false},
+		{"all namespaces", "kubectl get pods --all-namespaces", false},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidate := &plugins.Candidate{Command: tt.command}
+			
+			result, err := plugin.PreRunCheck(ctx, candidate)
+			if err != nil {
+				t.Fatalf("PreRunCheck() error = %v", err)
+			}
+			
+			if result.Allowed != tt.wantAllowed {
+				t.Errorf("PreRunCheck() allowed = %v, want %v", result.Allowed, tt.wantAllowed)
+			}
+			
+			if !tt.wantAllowed && !plugin.RequiresApprovalWithContext(ctx, candidate) {
+				t.Error("RequiresApprovalWithContext() should require approval for a disallowed namespace")
+			}
+		})
+	}
+	
+	// Without configuration every namespace is allowed
+	result, _ := plugin.PreRunCheck(plugins.Context{}, &plugins.Candidate{Command: "kubectl get pods -n production"})
+	if !result.Allowed {
+		t.Error("PreRunCheck() should allow any namespace without allowed_namespaces")
+	}
+}
+
 func TestK8sPlugin_RequiresApproval(t *testing.T) {
 	plugin := &K8sPlugin{}
 	
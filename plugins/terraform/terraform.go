@@ -0,0 +1,224 @@
+package terraform
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
+)
+
+// TerraformPlugin handles Terraform command translations
+type TerraformPlugin struct{}
+
+// targetPattern extracts a resource address such as aws_instance.web or
+// module.network.aws_vpc.main from a prompt
+var targetPattern = regexp.MustCompile(`(?i)(?:target|resource|only)\s+((?:module\.[\w-]+\.)*[a-z0-9_]+\.[\w\-\[\]"]+)`)
+
+func init() {
+	plugin := &TerraformPlugin{}
+	metadata := &plugins.PluginMetadata{
+		Name:        "terraform",
+		Version:     "1.0.0",
+		Description: "Terraform operations with blast radius checks for apply and destroy",
+		Author:      "QuickCMD Team",
+		Scopes:      []string{"terraform:read", "terraform:write", "terraform:admin"},
+		Enabled:     true,
+	}
+
+	plugins.Register(plugin, metadata)
+}
+
+// Name returns the plugin name
+func (p *TerraformPlugin) Name() string {
+	return "terraform"
+}
+
+// CanHandle reports whether command runs terraform
+func (p *TerraformPlugin) CanHandle(command string) bool {
+	return plugins.RunsExecutable(command, "terraform")
+}
+
+// Translate translates Terraform-related prompts into terraform commands
+func (p *TerraformPlugin) Translate(ctx plugins.Context, prompt string) ([]*plugins.Candidate, error) {
+	promptLower := strings.ToLower(prompt)
+
+	target := ""
+	if matches := targetPattern.FindStringSubmatch(prompt); len(matches) > 1 {
+		target = matches[1]
+	}
+
+	var candidates []*plugins.Candidate
+
+	// Pattern: plan changes
+	if matched, _ := regexp.MatchString(`(?i)(?:terraform\s+plan|plan\s+(?:the\s+)?(?:terraform|infrastructure|infra|changes))`, promptLower); matched {
+		cmd := withTarget("terraform plan", target)
+
+		candidates = append(candidates, &plugins.Candidate{
+			Command:     cmd,
+			Category:    "terraform",
+			Explanation: "Shows the changes Terraform would make" + targetSuffix(target),
+			Breakdown:   []plugins.Step{{Description: "Compute execution plan", Command: cmd}},
+			Confidence:  92,
+			RiskLevel:   plugins.RiskSafe,
+			DocLinks:    []string{"https://developer.hashicorp.com/terraform/cli/commands/plan"},
+			PluginMetadata: map[string]interface{}{
+				"operation": "plan",
+				"target":    target,
+			},
+		})
+	}
+
+	// Pattern: apply changes
+	if matched, _ := regexp.MatchString(`(?i)(?:terraform\s+apply|apply\s+(?:the\s+)?(?:terraform|infrastructure|infra|changes|plan))`, promptLower); matched {
+		cmd := withTarget("terraform apply", target)
+
+		candidates = append(candidates, &plugins.Candidate{
+			Command:     cmd,
+			Category:    "terraform",
+			Explanation: "Applies Terraform changes to real infrastructure" + targetSuffix(target),
+			Breakdown: []plugins.Step{
+				{Description: "Compute and show execution plan", Command: withTarget("terraform plan", target)},
+				{Description: "Apply changes after confirmation", Command: cmd},
+			},
+			Confidence:      90,
+			RiskLevel:       plugins.RiskHigh,
+			Destructive:     true,
+			RequiresConfirm: true,
+			DocLinks:        []string{"https://developer.hashicorp.com/terraform/cli/commands/apply"},
+			PluginMetadata: map[string]interface{}{
+				"operation": "apply",
+				"target":    target,
+			},
+		})
+	}
+
+	// Pattern: destroy resources
+	if matched, _ := regexp.MatchString(`(?i)(?:terraform\s+destroy|destroy\s+(?:the\s+)?(?:terraform|infrastructure|infra|resources?|workspace))`, promptLower); matched {
+		cmd := withTarget("terraform destroy", target)
+
+		candidates = append(candidates, &plugins.Candidate{
+			Command:         cmd,
+			Category:        "terraform",
+			Explanation:     "Destroys Terraform-managed infrastructure" + targetSuffix(target),
+			Breakdown:       []plugins.Step{{Description: "Destroy managed resources", Command: cmd}},
+			Confidence:      90,
+			RiskLevel:       plugins.RiskHigh,
+			Destructive:     true,
+			RequiresConfirm: true,
+			DocLinks:        []string{"https://developer.hashicorp.com/terraform/cli/commands/destroy"},
+			PluginMetadata: map[string]interface{}{
+				"operation": "destroy",
+				"target":    target,
+			},
+		})
+	}
+
+	// Pattern: show state
+	if matched, _ := regexp.MatchString(`(?i)(?:show|list)\s+(?:the\s+)?(?:terraform\s+)?state`, promptLower); matched {
+		cmd := "terraform state list"
+		explanation := "Lists all resources in the Terraform state"
+		operation := "state-list"
+		if target != "" {
+			cmd = fmt.Sprintf("terraform state show '%s'", target)
+			explanation = fmt.Sprintf("Shows the state of resource '%s'", target)
+			operation = "state-show"
+		}
+
+		candidates = append(candidates, &plugins.Candidate{
+			Command:     cmd,
+			Category:    "terraform",
+			Explanation: explanation,
+			Breakdown:   []plugins.Step{{Description: "Read Terraform state", Command: cmd}},
+			Confidence:  93,
+			RiskLevel:   plugins.RiskSafe,
+			DocLinks:    []string{"https://developer.hashicorp.com/terraform/cli/commands/state"},
+			PluginMetadata: map[string]interface{}{
+				"operation": operation,
+				"target":    target,
+			},
+		})
+	}
+
+	return candidates, nil
+}
+
+// PreRunCheck performs safety checks before Terraform command execution
+func (p *TerraformPlugin) PreRunCheck(ctx plugins.Context, candidate *plugins.Candidate) (*plugins.CheckResult, error) {
+	result := &plugins.CheckResult{
+		Allowed:  true,
+		Metadata: make(map[string]interface{}),
+	}
+
+	if !changesInfrastructure(candidate) {
+		return result, nil
+	}
+
+	operation := strings.ToUpper(operationOf(candidate))
+	result.RequiresApproval = true
+	result.ApprovalMessage = fmt.Sprintf("Terraform %s changes real infrastructure. Type 'TERRAFORM %s' to confirm", operation, operation)
+	result.AdditionalChecks = append(result.AdditionalChecks, "infrastructure_change")
+
+	// Without -target the whole workspace is affected
+	if !strings.Contains(candidate.Command, "-target") {
+		result.ApprovalMessage = fmt.Sprintf("Terraform %s without -target affects every resource in the workspace. Type 'TERRAFORM %s' to confirm", operation, operation)
+		result.AdditionalChecks = append(result.AdditionalChecks, "workspace_blast_radius")
+		result.Metadata["blast_radius"] = "workspace"
+	} else {
+		result.Metadata["blast_radius"] = "targeted"
+	}
+
+	// Skipping Terraform's own confirmation removes the last chance to review the plan
+	if strings.Contains(candidate.Command, "-auto-approve") {
+		result.AdditionalChecks = append(result.AdditionalChecks, "auto_approve")
+		result.Metadata["auto_approve"] = true
+	}
+
+	return result, nil
+}
+
+// RequiresApproval checks if the candidate requires approval
+func (p *TerraformPlugin) RequiresApproval(candidate *plugins.Candidate) bool {
+	return candidate.Destructive || changesInfrastructure(candidate)
+}
+
+// Scopes returns required scopes
+func (p *TerraformPlugin) Scopes() []string {
+	return []string{"terraform:read", "terraform:write", "terraform:admin"}
+}
+
+// Helper functions
+
+func operationOf(candidate *plugins.Candidate) string {
+	if candidate.PluginMetadata != nil {
+		if operation, ok := candidate.PluginMetadata["operation"].(string); ok {
+			return operation
+		}
+	}
+
+	// Fall back to the terraform subcommand
+	fields := strings.Fields(candidate.Command)
+	if len(fields) > 1 && fields[0] == "terraform" {
+		return fields[1]
+	}
+	return ""
+}
+
+func changesInfrastructure(candidate *plugins.Candidate) bool {
+	operation := operationOf(candidate)
+	return operation == "apply" || operation == "destroy"
+}
+
+func withTarget(cmd, target string) string {
+	if target == "" {
+		return cmd
+	}
+	return fmt.Sprintf("%s -target='%s'", cmd, target)
+}
+
+func targetSuffix(target string) string {
+	if target == "" {
+		return " across the whole workspace"
+	}
+	return fmt.Sprintf(" for '%s' only", target)
+}
@@ -0,0 +1,225 @@
+package terraform
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SagheerAkram/QuickCmd/core/plugins"
+)
+
+func TestTerraformPlugin_Translate(t *testing.T) {
+	plugin := &TerraformPlugin{}
+	ctx := plugins.Context{
+		WorkingDir: "/test",
+		User:       "testuser",
+		Timestamp:  time.Now(),
+	}
+
+	tests := []struct {
+		name           string
+		prompt         string
+		wantCandidates int
+		checkCommand   func(*plugins.Candidate) bool
+	}{
+		{
+			name:           "Plan",
+			prompt:         "terraform plan",
+			wantCandidates: 1,
+			checkCommand: func(c *plugins.Candidate) bool {
+				return c.Command == "terraform plan" && c.RiskLevel == plugins.RiskSafe
+			},
+		},
+		{
+			name:           "Plan with target",
+			prompt:         "plan changes for resource aws_instance.web",
+			wantCandidates: 1,
+			checkCommand: func(c *plugins.Candidate) bool {
+				return c.Command == "terraform plan -target='aws_instance.web'"
+			},
+		},
+		{
+			name:           "Apply",
+			prompt:         "apply the terraform changes",
+			wantCandidates: 1,
+			checkCommand: func(c *plugins.Candidate) bool {
+				return c.Command == "terraform apply" && c.Destructive && c.RequiresConfirm && c.RiskLevel == plugins.RiskHigh
+			},
+		},
+		{
+			name:           "Destroy module resource",
+			prompt:         "terraform destroy target module.network.aws_vpc.main",
+			wantCandidates: 1,
+			checkCommand: func(c *plugins.Candidate) bool {
+				return c.Command == "terraform destroy -target='module.network.aws_vpc.main'" && c.Destructive && c.RequiresConfirm
+			},
+		},
+		{
+			name:           "Show state",
+			prompt:         "show terraform state",
+			wantCandidates: 1,
+			checkCommand: func(c *plugins.Candidate) bool {
+				return c.Command == "terraform state list" && c.RiskLevel == plugins.RiskSafe
+			},
+		},
+		{
+			name:           "Show state of resource",
+			prompt:         "show state for resource aws_s3_bucket.logs",
+			wantCandidates: 1,
+			checkCommand: func(c *plugins.Candidate) bool {
+				return c.Command == "terraform state show 'aws_s3_bucket.logs'" && c.PluginMetadata["operation"] == "state-show"
+			},
+		},
+		{
+			name:           "Unrelated prompt",
+			prompt:         "list s3 buckets",
+			wantCandidates: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates, err := plugin.Translate(ctx, tt.prompt)
+			if err != nil {
+				t.Fatalf("Translate() error = %v", err)
+			}
+
+			if len(candidates) != tt.wantCandidates {
+				t.Errorf("Translate() returned %d candidates, want %d", len(candidates), tt.wantCandidates)
+			}
+
+			if tt.checkCommand != nil && len(candidates) > 0 {
+				if !tt.checkCommand(candidates[0]) {
+					t.Errorf("Translate() candidate check failed for: %s", candidates[0].Command)
+				}
+			}
+		})
+	}
+}
+
+func TestTerraformPlugin_PreRunCheck(t *testing.T) {
+	plugin := &TerraformPlugin{}
+
+	tests := []struct {
+		name            string
+		candidate       *plugins.Candidate
+		wantApproval    bool
+		wantBlastRadius interface{}
+	}{
+		{
+			name: "Plan",
+			candidate: &plugins.Candidate{
+				Command:        "terraform plan",
+				PluginMetadata: map[string]interface{}{"operation": "plan"},
+			},
+			wantApproval: false,
+		},
+		{
+			name: "Apply whole workspace",
+			candidate: &plugins.Candidate{
+				Command:        "terraform apply",
+				PluginMetadata: map[string]interface{}{"operation": "apply"},
+			},
+			wantApproval:    true,
+			wantBlastRadius: "workspace",
+		},
+		{
+			name: "Targeted destroy",
+			candidate: &plugins.Candidate{
+				Command:        "terraform destroy -target='aws_instance.web'",
+				PluginMetadata: map[string]interface{}{"operation": "destroy"},
+			},
+			wantApproval:    true,
+			wantBlastRadius: "targeted",
+		},
+		{
+			name: "Destroy without metadata",
+			candidate: &plugins.Candidate{
+				Command: "terraform destroy -auto-approve",
+			},
+			wantApproval:    true,
+			wantBlastRadius: "workspace",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := plugins.Context{
+				WorkingDir: "/test",
+				User:       "testuser",
+				Timestamp:  time.Now(),
+			}
+
+			result, err := plugin.PreRunCheck(ctx, tt.candidate)
+			if err != nil {
+				t.Fatalf("PreRunCheck() error = %v", err)
+			}
+
+			if !result.Allowed {
+				t.Errorf("PreRunCheck() allowed = false, want true")
+			}
+
+			if result.RequiresApproval != tt.wantApproval {
+				t.Errorf("PreRunCheck() requires approval = %v, want %v", result.RequiresApproval, tt.wantApproval)
+			}
+
+			if result.Metadata["blast_radius"] != tt.wantBlastRadius {
+				t.Errorf("PreRunCheck() blast radius = %v, want %v", result.Metadata["blast_radius"], tt.wantBlastRadius)
+			}
+
+			flagged := false
+			for _, check := range result.AdditionalChecks {
+				if check == "workspace_blast_radius" {
+					flagged = true
+				}
+			}
+			if flagged != (tt.wantBlastRadius == "workspace") {
+				t.Errorf("PreRunCheck() workspace_blast_radius flagged = %v, checks = %v", flagged, result.AdditionalChecks)
+			}
+		})
+	}
+}
+
+func TestTerraformPlugin_RequiresApproval(t *testing.T) {
+	plugin := &TerraformPlugin{}
+
+	tests := []struct {
+		name      string
+		candidate *plugins.Candidate
+		want      bool
+	}{
+		{
+			name: "Apply",
+			candidate: &plugins.Candidate{
+				Command:        "terraform apply",
+				PluginMetadata: map[string]interface{}{"operation": "apply"},
+			},
+			want: true,
+		},
+		{
+			name: "Destroy",
+			candidate: &plugins.Candidate{
+				Command:        "terraform destroy",
+				Destructive:    true,
+				PluginMetadata: map[string]interface{}{"operation": "destroy"},
+			},
+			want: true,
+		},
+		{
+			name: "State list",
+			candidate: &plugins.Candidate{
+				Command:        "terraform state list",
+				PluginMetadata: map[string]interface{}{"operation": "state-list"},
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := plugin.RequiresApproval(tt.candidate)
+			if got != tt.want {
+				t.Errorf("RequiresApproval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,12 +3,19 @@ package web
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 	
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// ErrSelfApproval is returned when a requester tries to approve their own
+// request
+var ErrSelfApproval = errors.New("requesters can't approve their own requests")
+
 // ApprovalStatus represents the status of an approval
 type ApprovalStatus string
 
@@ -37,11 +44,48 @@ type Approval struct {
 	RejectionReason  string         `json:"rejection_reason,omitempty"`
 	Confirmation     string         `json:"confirmation,omitempty"`
 	ApprovalNote     string         `json:"approval_note,omitempty"`
+	AutoApproved     bool           `json:"auto_approved,omitempty"`
+	UsedAt           *time.Time     `json:"used_at,omitempty"`
+}
+
+// DefaultConfirmationPhrase is required for risk levels without a configured phrase
+const DefaultConfirmationPhrase = "APPROVE {id}"
+
+// ConfirmationPhrases maps risk levels to the phrase an approver must type to
+// approve a request. "{id}" in a phrase is replaced with the approval ID.
+type ConfirmationPhrases map[string]string
+
+// DefaultConfirmationPhrases returns the built-in phrases. Critical approvals
+// need a stricter phrase than the default.
+func DefaultConfirmationPhrases() ConfirmationPhrases {
+	return ConfirmationPhrases{
+		"critical": "APPROVE CRITICAL {id}",
+	}
+}
+
+// WithDefaults returns the default phrases overridden by p, so configuring
+// one risk level keeps the built-in phrases for the others
+func (p ConfirmationPhrases) WithDefaults() ConfirmationPhrases {
+	merged := DefaultConfirmationPhrases()
+	for riskLevel, phrase := range p {
+		merged[strings.ToLower(riskLevel)] = phrase
+	}
+	return merged
+}
+
+// Phrase returns the phrase required to approve id at the given risk level
+func (p ConfirmationPhrases) Phrase(riskLevel string, id int) string {
+	phrase := p[strings.ToLower(riskLevel)]
+	if phrase == "" {
+		phrase = DefaultConfirmationPhrase
+	}
+	return strings.ReplaceAll(phrase, "{id}", strconv.Itoa(id))
 }
 
 // ApprovalStore manages approval records
 type ApprovalStore struct {
-	db *sql.DB
+	db        *sql.DB
+	autoRules []*compiledAutoApprovalRule
 }
 
 // NewApprovalStore creates a new approval store
@@ -57,6 +101,12 @@ func NewApprovalStore(dbPath string) (*ApprovalStore, error) {
 	if err := store.createTable(); err != nil {
 		return nil, err
 	}
+	if err := store.migrateAutoApproved(); err != nil {
+		return nil, err
+	}
+	if _, err := store.addColumn("used_at", "TEXT"); err != nil {
+		return nil, err
+	}
 	
 	return store, nil
 }
@@ -82,6 +132,8 @@ func (s *ApprovalStore) createTable() error {
 		rejection_reason TEXT,
 		confirmation TEXT,
 		approval_note TEXT,
+		auto_approved INTEGER NOT NULL DEFAULT 0,
+		used_at TEXT,
 		FOREIGN KEY (run_id) REFERENCES runs(id)
 	);
 	
@@ -93,26 +145,99 @@ func (s *ApprovalStore) createTable() error {
 	return err
 }
 
-// CreateApproval creates a new pending approval
+// migrateAutoApproved adds the auto_approved column to databases created
+// before it existed. Auto-approvals used to be recorded with the approver
+// name "auto-approval", so those rows are marked and lose the name.
+func (s *ApprovalStore) migrateAutoApproved() error {
+	added, err := s.addColumn("auto_approved", "INTEGER NOT NULL DEFAULT 0")
+	if err != nil || !added {
+		return err
+	}
+	if _, err := s.db.Exec(`UPDATE approvals SET auto_approved = 1, approved_by = NULL WHERE approved_by = 'auto-approval'`); err != nil {
+		return fmt.Errorf("failed to migrate auto-approvals: %w", err)
+	}
+	return nil
+}
+
+// addColumn adds a column to databases created before it existed and
+// reports whether it had to
+func (s *ApprovalStore) addColumn(name, definition string) (bool, error) {
+	var exists int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('approvals') WHERE name = ?`, name).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to read approvals schema: %w", err)
+	}
+	if exists > 0 {
+		return false, nil
+	}
+	
+	if _, err := s.db.Exec(fmt.Sprintf(`ALTER TABLE approvals ADD COLUMN %s %s`, name, definition)); err != nil {
+		return false, fmt.Errorf("failed to add %s column: %w", name, err)
+	}
+	return true, nil
+}
+
+// SetAutoApprovalRules sets the rules under which new approvals are granted
+// without human review
+func (s *ApprovalStore) SetAutoApprovalRules(rules []AutoApprovalRule) error {
+	compiled, err := compileAutoApprovalRules(rules)
+	if err != nil {
+		return err
+	}
+	
+	s.autoRules = compiled
+	return nil
+}
+
+// CreateApproval creates a new pending approval. If an auto-approval rule
+// matches, it is recorded as approved with AutoApproved set and no approver
+// instead, and approval is updated to reflect it.
 func (s *ApprovalStore) CreateApproval(approval *Approval) (int, error) {
 	scopes, _ := json.Marshal(approval.RequiredScopes)
 	metadata, _ := json.Marshal(approval.PluginMetadata)
 	
+	status := ApprovalStatusPending
+	autoApproved := false
+	var approvedAt, note sql.NullString
+	
+	for _, rule := range s.autoRules {
+		if rule.matches(approval) {
+			status = ApprovalStatusApproved
+			autoApproved = true
+			approvedAt = sql.NullString{String: time.Now().Format(time.RFC3339), Valid: true}
+			note = sql.NullString{String: "auto-approved by rule " + rule.rule.Name, Valid: true}
+			break
+		}
+	}
+	
 	result, err := s.db.Exec(`
 		INSERT INTO approvals (
 			run_id, prompt, command, risk_level, required_scopes, plugin_metadata,
-			requested_by, requested_at, status
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			requested_by, requested_at, status, approved_at, approval_note, auto_approved
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, approval.RunID, approval.Prompt, approval.Command, approval.RiskLevel,
 		string(scopes), string(metadata), approval.RequestedBy,
-		approval.RequestedAt.Format(time.RFC3339), ApprovalStatusPending)
+		approval.RequestedAt.Format(time.RFC3339), status,
+		approvedAt, note, autoApproved)
 	
 	if err != nil {
 		return 0, err
 	}
 	
 	id, err := result.LastInsertId()
-	return int(id), err
+	if err != nil {
+		return 0, err
+	}
+	
+	approval.ID = int(id)
+	approval.Status = status
+	if autoApproved {
+		t, _ := time.Parse(time.RFC3339, approvedAt.String)
+		approval.ApprovedAt = &t
+		approval.ApprovalNote = note.String
+		approval.AutoApproved = true
+	}
+	
+	return int(id), nil
 }
 
 // GetPendingApprovals retrieves all pending approvals
@@ -147,7 +272,8 @@ func (s *ApprovalStore) GetApproval(id int) (*Approval, error) {
 	row := s.db.QueryRow(`
 		SELECT id, run_id, prompt, command, risk_level, required_scopes, plugin_metadata,
 		       requested_by, requested_at, status, approved_by, approved_at,
-		       rejected_by, rejected_at, rejection_reason, confirmation, approval_note
+		       rejected_by, rejected_at, rejection_reason, confirmation, approval_note,
+		       auto_approved, used_at
 		FROM approvals
 		WHERE id = ?
 	`, id)
@@ -155,15 +281,50 @@ func (s *ApprovalStore) GetApproval(id int) (*Approval, error) {
 	return s.scanFullApproval(row)
 }
 
-// ApproveApproval approves a pending approval
+// ListApprovals retrieves all approvals requested at or after since,
+// whatever their status, oldest first
+func (s *ApprovalStore) ListApprovals(since time.Time) ([]*Approval, error) {
+	rows, err := s.db.Query(`
+		SELECT id, run_id, prompt, command, risk_level, required_scopes, plugin_metadata,
+		       requested_by, requested_at, status, approved_by, approved_at,
+		       rejected_by, rejected_at, rejection_reason, confirmation, approval_note,
+		       auto_approved, used_at
+		FROM approvals
+		ORDER BY id ASC
+	`)
+	
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var approvals []*Approval
+	for rows.Next() {
+		approval, err := s.scanFullApproval(rows)
+		if err != nil {
+			return nil, err
+		}
+		// requested_at is stored as text with a local offset, so filter
+		// after parsing rather than comparing strings in SQL
+		if approval.RequestedAt.Before(since) {
+			continue
+		}
+		approvals = append(approvals, approval)
+	}
+	
+	return approvals, rows.Err()
+}
+
+// ApproveApproval approves a pending approval. The requester can't approve
+// their own request.
 func (s *ApprovalStore) ApproveApproval(id int, approvedBy, confirmation, note string) error {
 	now := time.Now()
 	
 	result, err := s.db.Exec(`
 		UPDATE approvals
 		SET status = ?, approved_by = ?, approved_at = ?, confirmation = ?, approval_note = ?
-		WHERE id = ? AND status = ?
-	`, ApprovalStatusApproved, approvedBy, now.Format(time.RFC3339), confirmation, note, id, ApprovalStatusPending)
+		WHERE id = ? AND status = ? AND requested_by != ?
+	`, ApprovalStatusApproved, approvedBy, now.Format(time.RFC3339), confirmation, note, id, ApprovalStatusPending, approvedBy)
 	
 	if err != nil {
 		return err
@@ -175,6 +336,11 @@ func (s *ApprovalStore) ApproveApproval(id int, approvedBy, confirmation, note s
 	}
 	
 	if rows == 0 {
+		var requestedBy string
+		err := s.db.QueryRow(`SELECT requested_by FROM approvals WHERE id = ? AND status = ?`, id, ApprovalStatusPending).Scan(&requestedBy)
+		if err == nil && requestedBy == approvedBy {
+			return ErrSelfApproval
+		}
 		return fmt.Errorf("approval not found or already processed")
 	}
 	
@@ -207,8 +373,70 @@ func (s *ApprovalStore) RejectApproval(id int, rejectedBy, reason string) error
 	return nil
 }
 
+// FindPendingApproval returns the oldest pending approval requestedBy has
+// for command, or nil if there is none
+func (s *ApprovalStore) FindPendingApproval(command, requestedBy string) (*Approval, error) {
+	var id int
+	err := s.db.QueryRow(`
+		SELECT id FROM approvals
+		WHERE command = ? AND requested_by = ? AND status = ?
+		ORDER BY id ASC LIMIT 1
+	`, command, requestedBy, ApprovalStatusPending).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	return s.GetApproval(id)
+}
+
+// ConsumeApproval marks the oldest unused approval granted to requestedBy for
+// command as used and returns it, or nil if there is none. Each approval
+// allows a single run.
+func (s *ApprovalStore) ConsumeApproval(command, requestedBy string) (*Approval, error) {
+	for {
+		var id int
+		err := s.db.QueryRow(`
+			SELECT id FROM approvals
+			WHERE command = ? AND requested_by = ? AND status = ? AND used_at IS NULL
+			ORDER BY id ASC LIMIT 1
+		`, command, requestedBy, ApprovalStatusApproved).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		
+		result, err := s.db.Exec(`
+			UPDATE approvals SET used_at = ?
+			WHERE id = ? AND used_at IS NULL
+		`, time.Now().Format(time.RFC3339), id)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		// Another run used it first; try the next one
+		if rows == 0 {
+			continue
+		}
+		
+		return s.GetApproval(id)
+	}
+}
+
 // Helper functions
 
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
 func (s *ApprovalStore) scanApproval(rows *sql.Rows) (*Approval, error) {
 	var approval Approval
 	var requestedAt string
@@ -231,18 +459,18 @@ func (s *ApprovalStore) scanApproval(rows *sql.Rows) (*Approval, error) {
 	return &approval, nil
 }
 
-func (s *ApprovalStore) scanFullApproval(row *sql.Row) (*Approval, error) {
+func (s *ApprovalStore) scanFullApproval(row rowScanner) (*Approval, error) {
 	var approval Approval
 	var requestedAt, approvedAt, rejectedAt sql.NullString
 	var scopesJSON, metadataJSON string
-	var approvedBy, rejectedBy, rejectionReason, confirmation, note sql.NullString
+	var approvedBy, rejectedBy, rejectionReason, confirmation, note, usedAt sql.NullString
 	
 	err := row.Scan(
 		&approval.ID, &approval.RunID, &approval.Prompt, &approval.Command,
 		&approval.RiskLevel, &scopesJSON, &metadataJSON,
 		&approval.RequestedBy, &requestedAt, &approval.Status,
 		&approvedBy, &approvedAt, &rejectedBy, &rejectedAt,
-		&rejectionReason, &confirmation, &note,
+		&rejectionReason, &confirmation, &note, &approval.AutoApproved, &usedAt,
 	)
 	
 	if err != nil {
@@ -253,7 +481,7 @@ func (s *ApprovalStore) scanFullApproval(row *sql.Row) (*Approval, error) {
 	json.Unmarshal([]byte(scopesJSON), &approval.RequiredScopes)
 	json.Unmarshal([]byte(metadataJSON), &approval.PluginMetadata)
 	
-	if approvedBy.Valid {
+	if approval.Status == ApprovalStatusApproved {
 		approval.ApprovedBy = approvedBy.String
 		if approvedAt.Valid {
 			t, _ := time.Parse(time.RFC3339, approvedAt.String)
@@ -261,6 +489,10 @@ func (s *ApprovalStore) scanFullApproval(row *sql.Row) (*Approval, error) {
 		}
 		approval.Confirmation = confirmation.String
 		approval.ApprovalNote = note.String
+		if usedAt.Valid {
+			t, _ := time.Parse(time.RFC3339, usedAt.String)
+			approval.UsedAt = &t
+		}
 	}
 	
 	if rejectedBy.Valid {
@@ -42,6 +42,7 @@ func (s *AuthService) addDefaultUsers() {
 		{"approver", "approver", []Role{RoleApprover, RoleOperator}},
 		{"operator", "operator", []Role{RoleOperator}},
 		{"viewer", "viewer", []Role{RoleViewer}},
+		{"auditor", "auditor", []Role{RoleAuditor, RoleViewer}},
 	}
 	
 	for _, u := range defaultUsers {
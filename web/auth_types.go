@@ -16,6 +16,7 @@ const (
 	RoleViewer   Role = "viewer"
 	RoleOperator Role = "operator"
 	RoleApprover Role = "approver"
+	RoleAuditor  Role = "auditor" // may export the full history
 	RoleAdmin    Role = "admin"
 )
 
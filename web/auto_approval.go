@@ -0,0 +1,130 @@
+package web
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultAutoApprovalRiskLevels are the risk levels a rule covers when it
+// does not list any
+var defaultAutoApprovalRiskLevels = []string{"safe", "low"}
+
+// destructiveCommandPattern matches commands that are never auto-approved,
+// whatever the rules say
+var destructiveCommandPattern = regexp.MustCompile(`(?i)(^|[\s;&|(])(rm|rmdir|shred|mkfs(\.\w+)?|dd|kill|pkill|killall|truncate|chmod|chown)(\s|$)|\b(delete|drop|destroy|terminate|purge|prune|uninstall|remove|reset|scale)\b|--force\b`)
+
+// shellMetacharacters are never allowed in auto-approved commands, so a
+// pattern for a read-only command can't be satisfied by chaining another
+// command after it
+const shellMetacharacters = ";&|<>`$(){}\\\n\r"
+
+// AutoApprovalRule grants approvals without human review. An approval
+// matches when it is non-destructive, free of shell metacharacters, its risk
+// level is listed, its estimated cost is within MaxCost and its whole
+// command matches an allow pattern.
+type AutoApprovalRule struct {
+	Name string `json:"name"`
+	
+	// Risk levels the rule applies to (default: safe and low)
+	RiskLevels []string `json:"risk_levels,omitempty"`
+	
+	// Highest estimated cost in USD, read from the plugin metadata's
+	// estimated_cost. Approvals without an estimate don't match unless
+	// AllowUnestimated is set.
+	MaxCost float64 `json:"max_cost"`
+	
+	// Match approvals no plugin put a cost on, e.g. kubectl reads
+	AllowUnestimated bool `json:"allow_unestimated,omitempty"`
+	
+	// Regular expressions the whole command must match; at least one
+	AllowPatterns []string `json:"allow_patterns"`
+}
+
+// compiledAutoApprovalRule is an AutoApprovalRule with its patterns compiled
+type compiledAutoApprovalRule struct {
+	rule     AutoApprovalRule
+	patterns []*regexp.Regexp
+}
+
+// compileAutoApprovalRules validates rules and compiles their patterns
+func compileAutoApprovalRules(rules []AutoApprovalRule) ([]*compiledAutoApprovalRule, error) {
+	var compiled []*compiledAutoApprovalRule
+	
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("auto-approval rule %d: name is required", i)
+		}
+		if len(rule.AllowPatterns) == 0 {
+			return nil, fmt.Errorf("auto-approval rule %s: at least one allow pattern is required", rule.Name)
+		}
+		if rule.MaxCost < 0 {
+			return nil, fmt.Errorf("auto-approval rule %s: invalid max cost: %.2f", rule.Name, rule.MaxCost)
+		}
+		
+		c := &compiledAutoApprovalRule{rule: rule}
+		for _, pattern := range rule.AllowPatterns {
+			re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+			if err != nil {
+				return nil, fmt.Errorf("auto-approval rule %s: invalid pattern %q: %w", rule.Name, pattern, err)
+			}
+			c.patterns = append(c.patterns, re)
+		}
+		compiled = append(compiled, c)
+	}
+	
+	return compiled, nil
+}
+
+// matches reports whether the rule auto-approves approval
+func (c *compiledAutoApprovalRule) matches(approval *Approval) bool {
+	if isDestructiveApproval(approval) || strings.ContainsAny(approval.Command, shellMetacharacters) {
+		return false
+	}
+	
+	riskLevels := c.rule.RiskLevels
+	if len(riskLevels) == 0 {
+		riskLevels = defaultAutoApprovalRiskLevels
+	}
+	if !containsFold(riskLevels, approval.RiskLevel) {
+		return false
+	}
+	
+	cost, ok := estimatedCost(approval)
+	if !ok && !c.rule.AllowUnestimated || cost > c.rule.MaxCost {
+		return false
+	}
+	
+	for _, re := range c.patterns {
+		if re.MatchString(approval.Command) {
+			return true
+		}
+	}
+	
+	return false
+}
+
+// isDestructiveApproval reports whether a plugin flagged the approval as
+// destructive or its command looks destructive
+func isDestructiveApproval(approval *Approval) bool {
+	if destructive, ok := approval.PluginMetadata["destructive"].(bool); ok && destructive {
+		return true
+	}
+	return destructiveCommandPattern.MatchString(approval.Command)
+}
+
+// estimatedCost returns the plugin's estimated cost for the approval, and
+// whether there is one
+func estimatedCost(approval *Approval) (float64, bool) {
+	cost, ok := approval.PluginMetadata["estimated_cost"].(float64)
+	return cost, ok
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -12,20 +12,34 @@ interface RunRecord {
     exit_code?: number;
 }
 
+const PAGE_SIZE = 50;
+
 export default function History() {
     const [records, setRecords] = useState<RunRecord[]>([]);
+    const [total, setTotal] = useState(0);
+    const [offset, setOffset] = useState(0);
     const [loading, setLoading] = useState(true);
     const [filter, setFilter] = useState('');
+    const [risk, setRisk] = useState('');
+    const [from, setFrom] = useState('');
+    const [to, setTo] = useState('');
     const { fetchApi } = useApi();
 
+    // Changing a filter starts again from the first page
+    useEffect(() => {
+        setOffset(0);
+    }, [filter, risk, from, to]);
+
     useEffect(() => {
         loadHistory();
-    }, [filter]);
+    }, [filter, risk, from, to, offset]);
 
     const loadHistory = async () => {
+        const params = new URLSearchParams({ limit: String(PAGE_SIZE), offset: String(offset), filter, risk, from, to });
         try {
-            const data = await fetchApi(`/api/v1/history?limit=50&filter=${filter}`);
+            const data = await fetchApi(`/api/v1/history?${params}`);
             setRecords(data.records || []);
+            setTotal(data.total || 0);
         } catch (err) {
             console.error('Failed to load history:', err);
         } finally {
@@ -67,6 +81,15 @@ export default function History() {
                     onChange={(e) => setFilter(e.target.value)}
                     style={{ padding: '8px', width: '300px', marginRight: '10px' }}
                 />
+                <select value={risk} onChange={(e) => setRisk(e.target.value)} style={{ padding: '8px', marginRight: '10px' }}>
+                    <option value="">All risks</option>
+                    <option value="safe">Safe</option>
+                    <option value="medium">Medium</option>
+                    <option value="high">High</option>
+                </select>
+                <input type="date" value={from} onChange={(e) => setFrom(e.target.value)} style={{ padding: '8px', marginRight: '4px' }} />
+                to
+                <input type="date" value={to} onChange={(e) => setTo(e.target.value)} style={{ padding: '8px', margin: '0 10px 0 4px' }} />
                 <button onClick={loadHistory}>Refresh</button>
             </div>
 
@@ -116,6 +139,20 @@ export default function History() {
                     No execution history found
                 </div>
             )}
+
+            {total > 0 && (
+                <div style={{ display: 'flex', justifyContent: 'space-between', alignItems: 'center', marginTop: '20px' }}>
+                    <button onClick={() => setOffset(Math.max(0, offset - PAGE_SIZE))} disabled={offset === 0}>
+                        Previous
+                    </button>
+                    <span style={{ color: '#6b7280' }}>
+                        {offset + 1}–{offset + records.length} of {total}
+                    </span>
+                    <button onClick={() => setOffset(offset + PAGE_SIZE)} disabled={offset + PAGE_SIZE >= total}>
+                        Next
+                    </button>
+                </div>
+            )}
         </div>
     );
 }
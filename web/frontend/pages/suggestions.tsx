@@ -0,0 +1,93 @@
+import { useEffect, useState } from 'react';
+import { useApi } from '../hooks/useAuth';
+
+interface Suggestion {
+    type: string;
+    title: string;
+    description: string;
+    command?: string;
+    confidence: number;
+    reason: string;
+}
+
+export default function Suggestions() {
+    const [suggestions, setSuggestions] = useState<Suggestion[]>([]);
+    const [loading, setLoading] = useState(true);
+    const [prompt, setPrompt] = useState('');
+    const { fetchApi } = useApi();
+
+    useEffect(() => {
+        loadSuggestions();
+    }, [prompt]);
+
+    const loadSuggestions = async () => {
+        try {
+            const data = await fetchApi(`/api/v1/suggestions?prompt=${encodeURIComponent(prompt)}`);
+            setSuggestions(data.suggestions || []);
+        } catch (err) {
+            console.error('Failed to load suggestions:', err);
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    const sendFeedback = async (suggestion: Suggestion, feedback: 'accepted' | 'rejected') => {
+        try {
+            await fetchApi('/api/v1/suggestions/feedback', {
+                method: 'POST',
+                body: JSON.stringify({ type: suggestion.type, feedback }),
+            });
+            loadSuggestions();
+        } catch (err) {
+            alert('Failed to record feedback: ' + err);
+        }
+    };
+
+    if (loading) return <div>Loading...</div>;
+
+    return (
+        <div style={{ padding: '20px' }}>
+            <h1>Suggestions</h1>
+
+            <div style={{ marginBottom: '20px' }}>
+                <input
+                    type="text"
+                    placeholder="What are you working on?"
+                    value={prompt}
+                    onChange={(e) => setPrompt(e.target.value)}
+                    style={{ padding: '8px', width: '300px', marginRight: '10px' }}
+                />
+                <button onClick={loadSuggestions}>Refresh</button>
+            </div>
+
+            {suggestions.map((suggestion, i) => (
+                <div key={i} style={{ border: '1px solid #e5e7eb', borderRadius: '8px', padding: '16px', marginBottom: '12px' }}>
+                    <div style={{ display: 'flex', justifyContent: 'space-between' }}>
+                        <strong>{suggestion.title}</strong>
+                        <span style={{ color: '#6b7280', fontSize: '14px' }}>
+                            {suggestion.type} · {suggestion.confidence}%
+                        </span>
+                    </div>
+                    <p>{suggestion.description}</p>
+                    {suggestion.command && (
+                        <pre style={{ backgroundColor: '#f3f4f6', padding: '8px', borderRadius: '4px' }}>
+                            {suggestion.command}
+                        </pre>
+                    )}
+                    <button onClick={() => sendFeedback(suggestion, 'accepted')} style={{ marginRight: '10px' }}>
+                        Accept
+                    </button>
+                    <button onClick={() => sendFeedback(suggestion, 'rejected')}>
+                        Hide {suggestion.type} suggestions
+                    </button>
+                </div>
+            ))}
+
+            {suggestions.length === 0 && (
+                <div style={{ textAlign: 'center', padding: '40px', color: '#6b7280' }}>
+                    No suggestions right now
+                </div>
+            )}
+        </div>
+    );
+}
@@ -0,0 +1,187 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SagheerAkram/QuickCmd/agent"
+)
+
+// Notifier sends a message to a user or channel.
+// *integrations.SlackIntegration satisfies it.
+type Notifier interface {
+	SendMessage(recipient, text string) error
+}
+
+// JobSubmitter submits an approved job for execution and returns its job ID
+type JobSubmitter interface {
+	Submit(approval *Approval) (string, error)
+}
+
+// SetNotifier sets the notifier used to tell requesters when their approval
+// is resolved
+func (s *Server) SetNotifier(notifier Notifier) {
+	s.notifier = notifier
+}
+
+// SetJobSubmitter sets the submitter used to run jobs as soon as they are
+// approved. Without one, requesters run approved jobs themselves.
+func (s *Server) SetJobSubmitter(submitter JobSubmitter) {
+	s.jobSubmitter = submitter
+}
+
+// resolveApprovalAsync runs resolveApproval in the background, so a slow
+// notifier or agent doesn't hold up the approver's request
+func (s *Server) resolveApprovalAsync(id int) {
+	s.resolving.Add(1)
+	go func() {
+		defer s.resolving.Done()
+		s.resolveApproval(id)
+	}()
+}
+
+// resolveApproval notifies the requester of an approval's outcome, submitting
+// the job first if it was approved and a submitter is set. Failures are logged
+// rather than returned, since the decision itself has already been recorded.
+func (s *Server) resolveApproval(id int) {
+	if s.notifier == nil && s.jobSubmitter == nil {
+		return
+	}
+
+	approval, err := s.approvalStore.GetApproval(id)
+	if err != nil {
+		log.Printf("failed to load approval %d for notification: %v", id, err)
+		return
+	}
+
+	jobID := ""
+	if approval.Status == ApprovalStatusApproved && s.jobSubmitter != nil {
+		if jobID, err = s.jobSubmitter.Submit(approval); err != nil {
+			log.Printf("failed to submit approved job for approval %d: %v", id, err)
+		} else if s.suggestions != nil {
+			s.suggestions.AnalyzeCommand(approval.RequestedBy, approval.Command)
+		}
+	}
+
+	if s.notifier == nil {
+		return
+	}
+	if err := NotifyRequester(s.notifier, approval, jobID); err != nil {
+		log.Printf("failed to notify %s about approval %d: %v", approval.RequestedBy, id, err)
+	}
+}
+
+// NotifyRequester sends the outcome of a decided approval to its requester,
+// with the ID of the job submitted for it, if any. Approvals without a
+// requester are skipped.
+func NotifyRequester(notifier Notifier, approval *Approval, jobID string) error {
+	if approval.RequestedBy == "" {
+		return nil
+	}
+	return notifier.SendMessage(approval.RequestedBy, resolutionMessage(approval, jobID))
+}
+
+// resolutionMessage describes an approval's outcome to its requester
+func resolutionMessage(approval *Approval, jobID string) string {
+	var sb strings.Builder
+
+	switch {
+	case approval.Status == ApprovalStatusApproved && approval.AutoApproved:
+		sb.WriteString(fmt.Sprintf("✅ Approval #%d auto-approved\n", approval.ID))
+	case approval.Status == ApprovalStatusApproved:
+		sb.WriteString(fmt.Sprintf("✅ Approval #%d approved by %s\n", approval.ID, approval.ApprovedBy))
+	case approval.Status == ApprovalStatusRejected:
+		sb.WriteString(fmt.Sprintf("❌ Approval #%d rejected by %s\n", approval.ID, approval.RejectedBy))
+	default:
+		sb.WriteString(fmt.Sprintf("Approval #%d is %s\n", approval.ID, approval.Status))
+	}
+
+	sb.WriteString(fmt.Sprintf("Command: `%s`\n", approval.Command))
+
+	switch {
+	case approval.Status == ApprovalStatusRejected:
+		sb.WriteString(fmt.Sprintf("Reason: %s\n", approval.RejectionReason))
+	case jobID != "":
+		sb.WriteString(fmt.Sprintf("Job %s submitted for execution\n", jobID))
+	case approval.Status == ApprovalStatusApproved:
+		sb.WriteString("Your job can now proceed\n")
+	}
+
+	if approval.ApprovalNote != "" {
+		sb.WriteString(fmt.Sprintf("Note: %s\n", approval.ApprovalNote))
+	}
+
+	return sb.String()
+}
+
+// AgentSubmitter submits approved jobs to a remote agent as signed payloads
+type AgentSubmitter struct {
+	URL          string // agent base URL, e.g. https://agent:8443
+	HMACSecret   string
+	ControllerID string
+	TTL          time.Duration
+
+	client *http.Client
+}
+
+// NewAgentSubmitter creates a submitter for the agent at url
+func NewAgentSubmitter(url, hmacSecret, controllerID string) *AgentSubmitter {
+	return &AgentSubmitter{
+		URL:          strings.TrimSuffix(url, "/"),
+		HMACSecret:   hmacSecret,
+		ControllerID: controllerID,
+		TTL:          5 * time.Minute,
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Submit signs the approved job and posts it to the agent
+func (a *AgentSubmitter) Submit(approval *Approval) (string, error) {
+	now := time.Now()
+	payload := agent.JobPayload{
+		JobID:   fmt.Sprintf("approval-%d", approval.ID),
+		Prompt:  approval.Prompt,
+		Command: approval.Command,
+		CandidateMetadata: map[string]interface{}{
+			"risk_level":    approval.RiskLevel,
+			"approval_id":   approval.ID,
+			"approved_by":   approval.ApprovedBy,
+			"auto_approved": approval.AutoApproved,
+		},
+		PluginMetadata: approval.PluginMetadata,
+		RequiredScopes: approval.RequiredScopes,
+		TTL:            now.Add(a.TTL).Unix(),
+		Timestamp:      now.Unix(),
+		ControllerID:   a.ControllerID,
+	}
+
+	signature, err := agent.SignPayload(&payload, a.HMACSecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign job: %w", err)
+	}
+
+	body, err := json.Marshal(agent.SignedJob{
+		Payload:   payload,
+		Signature: agent.JobSignature{Signature: signature, Algorithm: "HMAC-SHA256"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	resp, err := a.client.Post(a.URL+"/api/v1/jobs", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to submit job: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return "", fmt.Errorf("agent rejected job: status %d", resp.StatusCode)
+	}
+
+	return payload.JobID, nil
+}
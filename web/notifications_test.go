@@ -0,0 +1,173 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SagheerAkram/QuickCmd/agent"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedMessage struct {
+	recipient string
+	text      string
+}
+
+type fakeNotifier struct {
+	messages []recordedMessage
+}
+
+func (n *fakeNotifier) SendMessage(recipient, text string) error {
+	n.messages = append(n.messages, recordedMessage{recipient, text})
+	return nil
+}
+
+func newNotificationTestServer(t *testing.T) (*Server, *fakeNotifier) {
+	store, err := NewApprovalStore(filepath.Join(t.TempDir(), "approvals.db"))
+	assert.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	notifier := &fakeNotifier{}
+	server := &Server{
+		approvalStore: store,
+		config:        &Config{ConfirmationPhrases: DefaultConfirmationPhrases()},
+	}
+	server.SetNotifier(notifier)
+
+	return server, notifier
+}
+
+func createTestApproval(t *testing.T, store *ApprovalStore) int {
+	id, err := store.CreateApproval(&Approval{
+		Prompt:      "restart the api",
+		Command:     "kubectl rollout restart deployment api",
+		RiskLevel:   "high",
+		RequestedBy: "alice",
+		RequestedAt: time.Now(),
+	})
+	assert.NoError(t, err)
+	return id
+}
+
+func decide(server *Server, handler http.HandlerFunc, id int, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/approvals/"+strconv.Itoa(id), strings.NewReader(body))
+	req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(id)})
+	req = req.WithContext(context.WithValue(req.Context(), "claims", &Claims{Username: "bob"}))
+
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestApprovalResolution_NotifiesRequester(t *testing.T) {
+	t.Run("approved", func(t *testing.T) {
+		server, notifier := newNotificationTestServer(t)
+		id := createTestApproval(t, server.approvalStore)
+
+		rec := decide(server, server.handleApprove, id, `{"confirmation":"APPROVE `+strconv.Itoa(id)+`","note":"go ahead"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		server.resolving.Wait()
+
+		if assert.Len(t, notifier.messages, 1) {
+			msg := notifier.messages[0]
+			assert.Equal(t, "alice", msg.recipient)
+			assert.Contains(t, msg.text, "approved by bob")
+			assert.Contains(t, msg.text, "kubectl rollout restart deployment api")
+			assert.Contains(t, msg.text, "go ahead")
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		server, notifier := newNotificationTestServer(t)
+		id := createTestApproval(t, server.approvalStore)
+
+		rec := decide(server, server.handleReject, id, `{"reason":"outside change window"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		server.resolving.Wait()
+
+		if assert.Len(t, notifier.messages, 1) {
+			assert.Equal(t, "alice", notifier.messages[0].recipient)
+			assert.Contains(t, notifier.messages[0].text, "rejected by bob")
+			assert.Contains(t, notifier.messages[0].text, "outside change window")
+		}
+	})
+
+	t.Run("failed confirmation sends nothing", func(t *testing.T) {
+		server, notifier := newNotificationTestServer(t)
+		id := createTestApproval(t, server.approvalStore)
+
+		rec := decide(server, server.handleApprove, id, `{"confirmation":"APPROVE"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		server.resolving.Wait()
+		assert.Empty(t, notifier.messages)
+	})
+}
+
+func TestApprovalResolution_AutoSubmit(t *testing.T) {
+	const secret = "test-secret"
+
+	var received agent.SignedJob
+	agentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/jobs", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer agentServer.Close()
+
+	server, notifier := newNotificationTestServer(t)
+	server.SetJobSubmitter(NewAgentSubmitter(agentServer.URL, secret, "web-1"))
+	id := createTestApproval(t, server.approvalStore)
+
+	rec := decide(server, server.handleApprove, id, `{"confirmation":"APPROVE `+strconv.Itoa(id)+`"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	server.resolving.Wait()
+
+	assert.Equal(t, "kubectl rollout restart deployment api", received.Payload.Command)
+	assert.Equal(t, "web-1", received.Payload.ControllerID)
+	assert.NoError(t, agent.ValidateSignature(&received, secret))
+	assert.NoError(t, agent.ValidateTTL(&received.Payload))
+
+	if assert.Len(t, notifier.messages, 1) {
+		assert.Contains(t, notifier.messages[0].text, "Job approval-"+strconv.Itoa(id)+" submitted")
+	}
+}
+
+// blockingNotifier holds every message until release is closed
+type blockingNotifier struct {
+	release chan struct{}
+}
+
+func (n *blockingNotifier) SendMessage(recipient, text string) error {
+	<-n.release
+	return nil
+}
+
+func TestApprovalResolution_DoesNotBlock(t *testing.T) {
+	server, _ := newNotificationTestServer(t)
+	notifier := &blockingNotifier{release: make(chan struct{})}
+	server.SetNotifier(notifier)
+	id := createTestApproval(t, server.approvalStore)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- decide(server, server.handleApprove, id, `{"confirmation":"APPROVE `+strconv.Itoa(id)+`"}`)
+	}()
+
+	select {
+	case rec := <-done:
+		assert.Equal(t, http.StatusOK, rec.Code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("approval waited for the notifier")
+	}
+
+	close(notifier.release)
+	server.resolving.Wait()
+}
@@ -1,9 +1,15 @@
 package web
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/SagheerAkram/QuickCmd/core/policy"
 	"gopkg.in/yaml.v3"
@@ -22,8 +28,17 @@ type VisualRule struct {
 	Priority    int      `json:"priority"`
 	Enabled     bool     `json:"enabled"`
 	Examples    []string `json:"examples,omitempty"`
+
+	// Optional schedule: the rule only applies inside ActiveHours/ActiveDays,
+	// or outside them when InvertSchedule is set
+	ActiveHours    string   `json:"active_hours,omitempty"` // "09:00-18:00"
+	ActiveDays     []string `json:"active_days,omitempty"`  // weekday names
+	InvertSchedule bool     `json:"invert_schedule,omitempty"`
 }
 
+// ErrRuleExists is returned when adding a rule whose ID is already in use
+var ErrRuleExists = errors.New("rule already exists")
+
 // PolicyBuilder helps build and test policy rules visually
 type PolicyBuilder struct {
 	rules map[string]*VisualRule
@@ -56,9 +71,25 @@ func (pb *PolicyBuilder) AddRule(rule *VisualRule) error {
 		}
 	}
 
-	// Generate ID if not provided
+	// Validate schedule if specified
+	if schedule := rule.schedule(); schedule != nil {
+		if err := schedule.Validate(); err != nil {
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+	}
+
+	// Generate ID if not provided, otherwise refuse to replace an existing rule
 	if rule.ID == "" {
-		rule.ID = generateRuleID(rule.Name)
+		id, err := generateRuleID()
+		for err == nil && pb.rules[id] != nil {
+			id, err = generateRuleID()
+		}
+		if err != nil {
+			return err
+		}
+		rule.ID = id
+	} else if pb.rules[rule.ID] != nil {
+		return fmt.Errorf("%w: %s", ErrRuleExists, rule.ID)
 	}
 
 	pb.rules[rule.ID] = rule
@@ -84,8 +115,8 @@ func (pb *PolicyBuilder) ListRules() []*VisualRule {
 	return rules
 }
 
-// TestRule tests a rule against a command
-func (pb *PolicyBuilder) TestRule(ruleID string, command string) (*TestResult, error) {
+// TestRule tests a rule against a command as if it ran at the given time
+func (pb *PolicyBuilder) TestRule(ruleID string, command string, at time.Time) (*TestResult, error) {
 	rule := pb.rules[ruleID]
 	if rule == nil {
 		return nil, fmt.Errorf("rule not found: %s", ruleID)
@@ -99,18 +130,18 @@ func (pb *PolicyBuilder) TestRule(ruleID string, command string) (*TestResult, e
 	}
 
 	// Test pattern match
-	if rule.IsRegex {
-		re, err := regexp.Compile(rule.Pattern)
-		if err != nil {
-			return nil, fmt.Errorf("invalid regex: %w", err)
-		}
-		result.Matched = re.MatchString(command)
-	} else {
-		// Simple substring match
-		result.Matched = contains(command, rule.Pattern)
+	matched, err := matchRule(rule, command)
+	if err != nil {
+		return nil, err
 	}
+	result.Matched = matched && rule.activeAt(at)
 
 	if result.Matched {
+		// Expose regex captures to help debug patterns
+		if rule.IsRegex {
+			result.Groups, result.NamedGroups = captureGroups(rule.Pattern, command)
+		}
+
 		result.Message = fmt.Sprintf("Command matches rule '%s'", rule.Name)
 		switch rule.Action {
 		case "block":
@@ -120,6 +151,8 @@ func (pb *PolicyBuilder) TestRule(ruleID string, command string) (*TestResult, e
 		case "allow":
 			result.Message += " - ALLOWED"
 		}
+	} else if matched {
+		result.Message = "Command matches but the rule is not active at this time"
 	} else {
 		result.Message = "Command does not match this rule"
 	}
@@ -129,11 +162,13 @@ func (pb *PolicyBuilder) TestRule(ruleID string, command string) (*TestResult, e
 
 // TestResult represents the result of testing a rule
 type TestResult struct {
-	RuleID  string `json:"rule_id"`
-	Command string `json:"command"`
-	Matched bool   `json:"matched"`
-	Action  string `json:"action"`
-	Message string `json:"message"`
+	RuleID      string            `json:"rule_id"`
+	Command     string            `json:"command"`
+	Matched     bool              `json:"matched"`
+	Action      string            `json:"action"`
+	Message     string            `json:"message"`
+	Groups      []string          `json:"groups,omitempty"`       // regex submatches, excluding the full match
+	NamedGroups map[string]string `json:"named_groups,omitempty"` // submatches of named groups
 }
 
 // ImpactAnalysis analyzes the impact of a rule on historical commands
@@ -159,13 +194,15 @@ func (pb *PolicyBuilder) AnalyzeImpact(ruleID string, commands []string) (*Impac
 		MatchedExamples: []string{},
 	}
 
+	// Historical commands are checked against the pattern only, regardless
+	// of the rule's schedule
 	for _, cmd := range commands {
-		result, err := pb.TestRule(ruleID, cmd)
+		matched, err := matchRule(rule, cmd)
 		if err != nil {
 			continue
 		}
 
-		if result.Matched {
+		if matched {
 			analysis.MatchedCount++
 			
 			// Track action counts
@@ -186,6 +223,99 @@ func (pb *PolicyBuilder) AnalyzeImpact(ruleID string, commands []string) (*Impac
 	return analysis, nil
 }
 
+// Evaluate checks all enabled rules active at the given time in descending
+// priority order and returns the action of the first matching rule. Rules with
+// equal priority are checked in ID order. If no rule matches, an empty action
+// and nil rule are returned.
+func (pb *PolicyBuilder) Evaluate(command string, at time.Time) (string, *VisualRule) {
+	for _, rule := range pb.rulesByPriority() {
+		if !rule.Enabled || !rule.activeAt(at) {
+			continue
+		}
+		if matched, _ := matchRule(rule, command); matched {
+			return rule.Action, rule
+		}
+	}
+	return "", nil
+}
+
+// rulesByPriority returns all rules sorted by descending priority, then ID
+func (pb *PolicyBuilder) rulesByPriority() []*VisualRule {
+	rules := pb.ListRules()
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority > rules[j].Priority
+		}
+		return rules[i].ID < rules[j].ID
+	})
+	return rules
+}
+
+// RuleConflict describes a command that one rule allows while another blocks
+// or sends for approval. ResolvedAction is what Evaluate does, going by
+// priority; ExportedAction is what the core engine does with the exported
+// policy, where the restrictive rule always wins. They differ when a
+// higher-priority allow overrides a block or approval.
+type RuleConflict struct {
+	AllowRuleID    string `json:"allow_rule_id"`
+	RestrictRuleID string `json:"restrict_rule_id"`
+	Command        string `json:"command"`
+	ResolvedAction string `json:"resolved_action"`
+	WinningRuleID  string `json:"winning_rule_id"`
+	ExportedAction string `json:"exported_action"`
+}
+
+// FindConflicts runs every enabled rule against the sample commands and reports
+// each allow rule and block/approve rule that both match the same command.
+// The higher-priority rule wins; on a tie the restrictive rule wins.
+func (pb *PolicyBuilder) FindConflicts(sampleCommands []string) []RuleConflict {
+	conflicts := []RuleConflict{}
+
+	var allows, restricts []*VisualRule
+	for _, rule := range pb.rules {
+		if !rule.Enabled {
+			continue
+		}
+		switch rule.Action {
+		case "allow":
+			allows = append(allows, rule)
+		case "block", "approve":
+			restricts = append(restricts, rule)
+		}
+	}
+	sort.Slice(allows, func(i, j int) bool { return allows[i].ID < allows[j].ID })
+	sort.Slice(restricts, func(i, j int) bool { return restricts[i].ID < restricts[j].ID })
+
+	for _, cmd := range sampleCommands {
+		for _, allow := range allows {
+			if matched, _ := matchRule(allow, cmd); !matched {
+				continue
+			}
+			for _, restrict := range restricts {
+				if matched, _ := matchRule(restrict, cmd); !matched {
+					continue
+				}
+
+				winner := restrict
+				if allow.Priority > restrict.Priority {
+					winner = allow
+				}
+
+				conflicts = append(conflicts, RuleConflict{
+					AllowRuleID:    allow.ID,
+					RestrictRuleID: restrict.ID,
+					Command:        cmd,
+					ResolvedAction: winner.Action,
+					WinningRuleID:  winner.ID,
+					ExportedAction: restrict.Action,
+				})
+			}
+		}
+	}
+
+	return conflicts
+}
+
 // ConvertToYAML converts visual rules to policy YAML
 func (pb *PolicyBuilder) ConvertToYAML() (string, error) {
 	policyConfig := &policy.Policy{
@@ -194,15 +324,26 @@ func (pb *PolicyBuilder) ConvertToYAML() (string, error) {
 		ApprovalRequired: []policy.Pattern{},
 	}
 
-	// Group rules by type
-	for _, rule := range pb.rules {
+	// Group rules by type, highest priority first within each list. The core
+	// engine checks the whole denylist before the allowlist and approval
+	// rules after it, so priorities across lists aren't kept; FindConflicts
+	// reports where that changes the outcome.
+	for _, rule := range pb.rulesByPriority() {
 		if !rule.Enabled {
 			continue
 		}
 
+		// The core engine compiles every pattern as a regex, so substring
+		// rules are exported with their metacharacters escaped
+		expr := rule.Pattern
+		if !rule.IsRegex {
+			expr = regexp.QuoteMeta(rule.Pattern)
+		}
+
 		pattern := policy.Pattern{
-			Pattern: rule.Pattern,
-			Reason:  rule.Description,
+			Pattern:     expr,
+			Description: rule.Description,
+			Schedule:    rule.schedule(),
 		}
 
 		switch rule.RuleType {
@@ -236,14 +377,17 @@ func (pb *PolicyBuilder) ImportFromYAML(yamlContent string) error {
 		rule := &VisualRule{
 			ID:          fmt.Sprintf("allowlist-%d", i),
 			Name:        fmt.Sprintf("Allowlist Rule %d", i+1),
-			Description: pattern.Reason,
+			Description: pattern.Description,
 			RuleType:    "allowlist",
 			Pattern:     pattern.Pattern,
 			IsRegex:     true,
 			Action:      "allow",
 			Enabled:     true,
 		}
-		pb.AddRule(rule)
+		rule.setSchedule(pattern.Schedule)
+		if err := pb.AddRule(rule); err != nil {
+			return err
+		}
 	}
 
 	// Import denylist rules
@@ -251,14 +395,17 @@ func (pb *PolicyBuilder) ImportFromYAML(yamlContent string) error {
 		rule := &VisualRule{
 			ID:          fmt.Sprintf("denylist-%d", i),
 			Name:        fmt.Sprintf("Denylist Rule %d", i+1),
-			Description: pattern.Reason,
+			Description: pattern.Description,
 			RuleType:    "denylist",
 			Pattern:     pattern.Pattern,
 			IsRegex:     true,
 			Action:      "block",
 			Enabled:     true,
 		}
-		pb.AddRule(rule)
+		rule.setSchedule(pattern.Schedule)
+		if err := pb.AddRule(rule); err != nil {
+			return err
+		}
 	}
 
 	// Import approval rules
@@ -266,14 +413,17 @@ func (pb *PolicyBuilder) ImportFromYAML(yamlContent string) error {
 		rule := &VisualRule{
 			ID:          fmt.Sprintf("approval-%d", i),
 			Name:        fmt.Sprintf("Approval Rule %d", i+1),
-			Description: pattern.Reason,
+			Description: pattern.Description,
 			RuleType:    "approval",
 			Pattern:     pattern.Pattern,
 			IsRegex:     true,
 			Action:      "approve",
 			Enabled:     true,
 		}
-		pb.AddRule(rule)
+		rule.setSchedule(pattern.Schedule)
+		if err := pb.AddRule(rule); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -370,13 +520,81 @@ func GetTemplates() []RuleTemplate {
 
 // Helper functions
 
-func generateRuleID(name string) string {
-	// Simple ID generation - in production, use UUID
-	return fmt.Sprintf("rule-%d", len(name))
+// generateRuleID generates a random rule ID
+func generateRuleID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate rule ID: %w", err)
+	}
+	return "rule-" + hex.EncodeToString(b), nil
+}
+
+// schedule returns the rule's schedule, or nil if it always applies
+func (vr *VisualRule) schedule() *policy.Schedule {
+	if vr.ActiveHours == "" && len(vr.ActiveDays) == 0 {
+		return nil
+	}
+	return &policy.Schedule{
+		ActiveHours: vr.ActiveHours,
+		ActiveDays:  vr.ActiveDays,
+		Invert:      vr.InvertSchedule,
+	}
+}
+
+// setSchedule copies a policy schedule onto the rule
+func (vr *VisualRule) setSchedule(schedule *policy.Schedule) {
+	if schedule == nil {
+		return
+	}
+	vr.ActiveHours = schedule.ActiveHours
+	vr.ActiveDays = schedule.ActiveDays
+	vr.InvertSchedule = schedule.Invert
 }
 
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && s[:len(substr)] == substr
+// activeAt reports whether the rule's schedule applies at the given time
+func (vr *VisualRule) activeAt(at time.Time) bool {
+	schedule := vr.schedule()
+	return schedule == nil || schedule.ActiveAt(at)
+}
+
+// matchRule reports whether a rule's pattern matches the command
+func matchRule(rule *VisualRule, command string) (bool, error) {
+	if rule.IsRegex {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return false, fmt.Errorf("invalid regex: %w", err)
+		}
+		return re.MatchString(command), nil
+	}
+
+	// Simple substring match
+	return strings.Contains(command, rule.Pattern), nil
+}
+
+// captureGroups returns the submatches of a regex pattern against the command
+func captureGroups(pattern, command string) ([]string, map[string]string) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, nil
+	}
+
+	match := re.FindStringSubmatch(command)
+	if len(match) < 2 {
+		return nil, nil
+	}
+
+	var named map[string]string
+	for i, name := range re.SubexpNames() {
+		if i == 0 || name == "" {
+			continue
+		}
+		if named == nil {
+			named = make(map[string]string)
+		}
+		named[name] = match[i]
+	}
+
+	return match[1:], named
 }
 
 // ToJSON converts visual rule to JSON
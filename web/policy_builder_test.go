@@ -1,8 +1,14 @@
 package web
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/SagheerAkram/QuickCmd/core/policy"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -72,6 +78,32 @@ func TestPolicyBuilder(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("generates unique IDs", func(t *testing.T) {
+		pb := NewPolicyBuilder()
+
+		for i := 0; i < 100; i++ {
+			err := pb.AddRule(&VisualRule{
+				Name:     fmt.Sprintf("Rule %03d", i),
+				Pattern:  "test",
+				RuleType: "denylist",
+			})
+			assert.NoError(t, err)
+		}
+
+		assert.Len(t, pb.ListRules(), 100)
+	})
+
+	t.Run("rejects duplicate explicit IDs", func(t *testing.T) {
+		pb := NewPolicyBuilder()
+
+		err := pb.AddRule(&VisualRule{ID: "dup", Name: "First", Pattern: "a", RuleType: "denylist"})
+		assert.NoError(t, err)
+
+		err = pb.AddRule(&VisualRule{ID: "dup", Name: "Second", Pattern: "b", RuleType: "denylist"})
+		assert.ErrorIs(t, err, ErrRuleExists)
+		assert.Equal(t, "First", pb.GetRule("dup").Name)
+	})
+
 	t.Run("removes rules", func(t *testing.T) {
 		pb := NewPolicyBuilder()
 		
@@ -103,7 +135,7 @@ func TestRuleTesting(t *testing.T) {
 	pb.AddRule(rule)
 
 	t.Run("matches dangerous command", func(t *testing.T) {
-		result, err := pb.TestRule("test-1", "rm -rf /data")
+		result, err := pb.TestRule("test-1", "rm -rf /data", time.Now())
 		assert.NoError(t, err)
 		assert.True(t, result.Matched)
 		assert.Equal(t, "block", result.Action)
@@ -111,17 +143,52 @@ func TestRuleTesting(t *testing.T) {
 	})
 
 	t.Run("does not match safe command", func(t *testing.T) {
-		result, err := pb.TestRule("test-1", "ls -la")
+		result, err := pb.TestRule("test-1", "ls -la", time.Now())
 		assert.NoError(t, err)
 		assert.False(t, result.Matched)
 	})
 
 	t.Run("handles non-existent rule", func(t *testing.T) {
-		_, err := pb.TestRule("non-existent", "test")
+		_, err := pb.TestRule("non-existent", "test", time.Now())
 		assert.Error(t, err)
 	})
 }
 
+func TestSubstringRuleMatching(t *testing.T) {
+	pb := NewPolicyBuilder()
+	pb.AddRule(&VisualRule{
+		ID:       "substr-1",
+		Name:     "Block delete anywhere",
+		Pattern:  "delete",
+		RuleType: "denylist",
+		Action:   "block",
+		IsRegex:  false,
+		Enabled:  true,
+	})
+
+	tests := []struct {
+		name    string
+		command string
+		matched bool
+	}{
+		{"prefix", "delete-all-the-things", true},
+		{"middle", "kubectl delete pod api", true},
+		{"suffix", "aws s3api delete", true},
+		{"exact", "delete", true},
+		{"no match", "kubectl get pods", false},
+		{"case sensitive", "kubectl DELETE pod", false},
+		{"shorter than pattern", "del", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := pb.TestRule("substr-1", tt.command, time.Now())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.matched, result.Matched)
+		})
+	}
+}
+
 func TestImpactAnalysis(t *testing.T) {
 	pb := NewPolicyBuilder()
 
@@ -152,6 +219,19 @@ func TestImpactAnalysis(t *testing.T) {
 		assert.Equal(t, 3, analysis.BlockedCount)
 		assert.Len(t, analysis.MatchedExamples, 3)
 	})
+
+	t.Run("counts substring matches at any position", func(t *testing.T) {
+		analysis, err := pb.AnalyzeImpact("test-1", []string{
+			"delete everything",
+			"kubectl delete pod api",
+			"aws s3api delete",
+			"kubectl get pods",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, analysis.MatchedCount)
+		assert.Equal(t, 3, analysis.BlockedCount)
+		assert.Equal(t, []string{"delete everything", "kubectl delete pod api", "aws s3api delete"}, analysis.MatchedExamples)
+	})
 }
 
 func TestYAMLConversion(t *testing.T) {
@@ -191,10 +271,10 @@ func TestYAMLConversion(t *testing.T) {
 		yamlContent := `
 allowlist:
   - pattern: "^cat"
-    reason: "Allow cat commands"
+    description: "Allow cat commands"
 denylist:
   - pattern: "rm -rf /"
-    reason: "Prevent root deletion"
+    description: "Prevent root deletion"
 `
 		pb2 := NewPolicyBuilder()
 		err := pb2.ImportFromYAML(yamlContent)
@@ -205,6 +285,45 @@ denylist:
 	})
 }
 
+func TestYAMLConversion_SubstringRoundTrip(t *testing.T) {
+	pb := NewPolicyBuilder()
+	pb.AddRule(&VisualRule{
+		ID:       "deny-tmp",
+		Name:     "Block wildcard tmp deletes",
+		Pattern:  "rm -rf /tmp/*",
+		RuleType: "denylist",
+		Action:   "block",
+		IsRegex:  false,
+		Enabled:  true,
+	})
+	pb.AddRule(&VisualRule{
+		ID:       "deny-dot",
+		Name:     "Block hidden file listings",
+		Pattern:  "ls .",
+		RuleType: "denylist",
+		Action:   "block",
+		IsRegex:  false,
+		Enabled:  true,
+	})
+
+	yamlContent, err := pb.ConvertToYAML()
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(yamlContent), 0644))
+	engine, err := policy.NewEngineFromFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The deployed policy agrees with the builder's substring matching
+	for _, command := range []string{"rm -rf /tmp/*", "rm -rf /tmp/cache", "ls .", "ls -la", "echo hello"} {
+		action, _ := pb.Evaluate(command, time.Now())
+		result := engine.Validate(command, "low", false)
+		assert.Equal(t, action == "block", !result.Allowed, "command %q", command)
+	}
+}
+
 func TestRuleTemplates(t *testing.T) {
 	t.Run("returns templates", func(t *testing.T) {
 		templates := GetTemplates()
@@ -253,3 +372,284 @@ func TestVisualRuleJSON(t *testing.T) {
 		assert.Contains(t, json, "denylist")
 	})
 }
+
+func TestFindConflicts(t *testing.T) {
+	pb := NewPolicyBuilder()
+
+	pb.AddRule(&VisualRule{
+		ID:       "allow-kubectl",
+		Name:     "Allow kubectl",
+		Pattern:  "^kubectl",
+		RuleType: "allowlist",
+		Action:   "allow",
+		IsRegex:  true,
+		Priority: 10,
+		Enabled:  true,
+	})
+	pb.AddRule(&VisualRule{
+		ID:       "deny-delete",
+		Name:     "Block delete",
+		Pattern:  "delete",
+		RuleType: "denylist",
+		Action:   "block",
+		Priority: 5,
+		Enabled:  true,
+	})
+	pb.AddRule(&VisualRule{
+		ID:       "approve-prod",
+		Name:     "Approve production",
+		Pattern:  "-n production",
+		RuleType: "approval",
+		Action:   "approve",
+		Priority: 10,
+		Enabled:  true,
+	})
+	pb.AddRule(&VisualRule{
+		ID:       "deny-disabled",
+		Name:     "Disabled rule",
+		Pattern:  "kubectl",
+		RuleType: "denylist",
+		Action:   "block",
+		Enabled:  false,
+	})
+
+	t.Run("reports allow/restrict overlaps", func(t *testing.T) {
+		conflicts := pb.FindConflicts([]string{
+			"kubectl delete pod api",
+			"kubectl get pods -n production",
+			"rm delete.txt",
+			"ls -la",
+		})
+
+		assert.Equal(t, []RuleConflict{
+			{
+				AllowRuleID:    "allow-kubectl",
+				RestrictRuleID: "deny-delete",
+				Command:        "kubectl delete pod api",
+				ResolvedAction: "allow",
+				WinningRuleID:  "allow-kubectl",
+				ExportedAction: "block",
+			},
+			{
+				AllowRuleID:    "allow-kubectl",
+				RestrictRuleID: "approve-prod",
+				Command:        "kubectl get pods -n production",
+				ResolvedAction: "approve",
+				WinningRuleID:  "approve-prod",
+				ExportedAction: "approve",
+			},
+		}, conflicts)
+	})
+
+	t.Run("exported action matches the core engine", func(t *testing.T) {
+		yamlContent, err := pb.ConvertToYAML()
+		assert.NoError(t, err)
+		path := filepath.Join(t.TempDir(), "policy.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte(yamlContent), 0644))
+		engine, err := policy.NewEngineFromFile(path)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// The higher-priority allow doesn't survive export
+		assert.False(t, engine.Validate("kubectl delete pod api", "low", false).Allowed)
+		assert.True(t, engine.Validate("kubectl get pods -n production", "low", false).RequiresApproval)
+	})
+
+	t.Run("no conflicts without overlap", func(t *testing.T) {
+		conflicts := pb.FindConflicts([]string{"ls -la", "rm delete.txt"})
+		assert.Empty(t, conflicts)
+	})
+}
+
+func TestEvaluatePriority(t *testing.T) {
+	pb := NewPolicyBuilder()
+
+	pb.AddRule(&VisualRule{
+		ID:       "deny-delete",
+		Name:     "Block delete",
+		Pattern:  "delete",
+		RuleType: "denylist",
+		Action:   "block",
+		Priority: 1,
+		Enabled:  true,
+	})
+	pb.AddRule(&VisualRule{
+		ID:       "allow-staging",
+		Name:     "Allow staging deletes",
+		Pattern:  "delete.*-n staging",
+		RuleType: "allowlist",
+		Action:   "allow",
+		IsRegex:  true,
+		Priority: 10,
+		Enabled:  true,
+	})
+
+	t.Run("high-priority allow overrides lower-priority deny", func(t *testing.T) {
+		action, rule := pb.Evaluate("kubectl delete pod api -n staging", time.Now())
+		assert.Equal(t, "allow", action)
+		assert.Equal(t, "allow-staging", rule.ID)
+	})
+
+	t.Run("falls through to lower-priority rule", func(t *testing.T) {
+		action, rule := pb.Evaluate("kubectl delete pod api -n production", time.Now())
+		assert.Equal(t, "block", action)
+		assert.Equal(t, "deny-delete", rule.ID)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		action, rule := pb.Evaluate("ls -la", time.Now())
+		assert.Empty(t, action)
+		assert.Nil(t, rule)
+	})
+
+	t.Run("YAML lists patterns in priority order", func(t *testing.T) {
+		pb.AddRule(&VisualRule{
+			ID:       "deny-low",
+			Name:     "Block drop",
+			Pattern:  "drop table",
+			RuleType: "denylist",
+			Action:   "block",
+			Priority: 0,
+			Enabled:  true,
+		})
+		pb.AddRule(&VisualRule{
+			ID:       "deny-high",
+			Name:     "Block truncate",
+			Pattern:  "truncate",
+			RuleType: "denylist",
+			Action:   "block",
+			Priority: 50,
+			Enabled:  true,
+		})
+
+		out, err := pb.ConvertToYAML()
+		assert.NoError(t, err)
+
+		high := strings.Index(out, "truncate")
+		mid := strings.Index(out, "pattern: delete\n")
+		low := strings.Index(out, "drop table")
+		assert.True(t, high < mid && mid < low, "denylist not in priority order:\n%s", out)
+	})
+}
+
+func TestScheduledRules(t *testing.T) {
+	// 2024-01-01 is a Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	pb := NewPolicyBuilder()
+	pb.AddRule(&VisualRule{
+		ID:             "after-hours",
+		Name:           "Block production deletes after hours",
+		Pattern:        "delete.*-n production",
+		RuleType:       "denylist",
+		Action:         "block",
+		IsRegex:        true,
+		Enabled:        true,
+		ActiveHours:    "09:00-18:00",
+		ActiveDays:     []string{"monday", "tuesday", "wednesday", "thursday", "friday"},
+		InvertSchedule: true,
+	})
+	pb.AddRule(&VisualRule{
+		ID:          "maintenance",
+		Name:        "Allow restarts in maintenance window",
+		Pattern:     "rollout restart",
+		RuleType:    "allowlist",
+		Action:      "allow",
+		Enabled:     true,
+		ActiveHours: "22:00-02:00",
+	})
+
+	command := "kubectl delete pod api -n production"
+
+	tests := []struct {
+		name    string
+		command string
+		time    time.Time
+		action  string
+	}{
+		{"weekday start of business hours", command, at(1, 9, 0), ""},
+		{"weekday just before close", command, at(1, 17, 59), ""},
+		{"weekday at close", command, at(1, 18, 0), "block"},
+		{"weekday early morning", command, at(1, 8, 59), "block"},
+		{"weekend during business hours", command, at(6, 12, 0), "block"},
+		{"maintenance before midnight", "kubectl rollout restart deploy/api", at(1, 23, 0), "allow"},
+		{"maintenance after midnight", "kubectl rollout restart deploy/api", at(2, 1, 59), "allow"},
+		{"maintenance window end", "kubectl rollout restart deploy/api", at(2, 2, 0), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, _ := pb.Evaluate(tt.command, tt.time)
+			assert.Equal(t, tt.action, action)
+		})
+	}
+
+	t.Run("TestRule reports inactive schedule", func(t *testing.T) {
+		result, err := pb.TestRule("after-hours", command, at(1, 12, 0))
+		assert.NoError(t, err)
+		assert.False(t, result.Matched)
+		assert.Contains(t, result.Message, "not active")
+
+		result, err = pb.TestRule("after-hours", command, at(1, 20, 0))
+		assert.NoError(t, err)
+		assert.True(t, result.Matched)
+	})
+
+	t.Run("rejects invalid schedules", func(t *testing.T) {
+		err := pb.AddRule(&VisualRule{
+			Name:        "Bad hours",
+			Pattern:     "x",
+			RuleType:    "denylist",
+			ActiveHours: "9am-5pm",
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("schedule round-trips through YAML", func(t *testing.T) {
+		out, err := pb.ConvertToYAML()
+		assert.NoError(t, err)
+		assert.Contains(t, out, "schedule:")
+		assert.Contains(t, out, "active_hours: 09:00-18:00")
+		assert.Contains(t, out, "invert: true")
+
+		pb2 := NewPolicyBuilder()
+		assert.NoError(t, pb2.ImportFromYAML(out))
+
+		action, _ := pb2.Evaluate(command, at(1, 20, 0))
+		assert.Equal(t, "block", action)
+		action, _ = pb2.Evaluate(command, at(1, 12, 0))
+		assert.Empty(t, action)
+	})
+}
+
+func TestRuleCaptureGroups(t *testing.T) {
+	pb := NewPolicyBuilder()
+	pb.AddRule(&VisualRule{
+		ID:       "kubectl-delete",
+		Name:     "Capture kubectl delete",
+		Pattern:  `kubectl delete (\w+) (?P<name>\S+) -n (?P<namespace>\S+)`,
+		RuleType: "denylist",
+		Action:   "block",
+		IsRegex:  true,
+		Enabled:  true,
+	})
+
+	t.Run("returns groups for a match", func(t *testing.T) {
+		result, err := pb.TestRule("kubectl-delete", "kubectl delete pod api-7f9 -n production", time.Now())
+		assert.NoError(t, err)
+		assert.True(t, result.Matched)
+		assert.Equal(t, []string{"pod", "api-7f9", "production"}, result.Groups)
+		assert.Equal(t, map[string]string{"name": "api-7f9", "namespace": "production"}, result.NamedGroups)
+	})
+
+	t.Run("no groups without a match", func(t *testing.T) {
+		result, err := pb.TestRule("kubectl-delete", "kubectl get pods", time.Now())
+		assert.NoError(t, err)
+		assert.False(t, result.Matched)
+		assert.Empty(t, result.Groups)
+		assert.Empty(t, result.NamedGroups)
+	})
+}
@@ -2,12 +2,19 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 	
 	"github.com/gorilla/mux"
 	"github.com/SagheerAkram/QuickCmd/core/audit"
+	"github.com/SagheerAkram/QuickCmd/core/policy"
+	"github.com/SagheerAkram/QuickCmd/core/suggestions"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
 )
 
 // Server represents the web API server
@@ -16,9 +23,29 @@ type Server struct {
 	authService    *AuthService
 	approvalStore  *ApprovalStore
 	auditStore     *audit.SQLiteStore
+	suggestions    *suggestions.SuggestionEngine
+	translator     *translator.Translator
+	policy         *policy.Engine
 	config         *Config
+	notifier       Notifier
+	jobSubmitter   JobSubmitter
+	
+	// learnedRunID is the newest audit record fed to the suggestion engine
+	learnMu      sync.Mutex
+	learnedRunID int64
+	
+	// resolving tracks notifications and job submissions still in flight
+	resolving sync.WaitGroup
 }
 
+// suggestionHistoryLimit is how many recent runs are read when feeding the
+// suggestion engine
+const suggestionHistoryLimit = 500
+
+// suggestionPruneInterval is how often suggestion suppressions whose
+// cooldown has passed are dropped
+const suggestionPruneInterval = time.Hour
+
 // Config represents server configuration
 type Config struct {
 	Port          int
@@ -26,10 +53,26 @@ type Config struct {
 	AuditDBPath   string
 	ApprovalDBPath string
 	CORSOrigins   []string
+	
+	// Policy applied when replays re-translate a prompt (default: built-in policy)
+	PolicyFile string
+	
+	// External policy decision point consulted by replays (default: none)
+	PolicyWebhookURL     string
+	PolicyWebhookSecret  string
+	PolicyWebhookTimeout time.Duration
+	
+	// Phrases approvers must type, by risk level, merged over DefaultConfirmationPhrases
+	ConfirmationPhrases ConfirmationPhrases
+	
+	// Rules for granting approvals without human review (default: none)
+	AutoApprovalRules []AutoApprovalRule
 }
 
 // NewServer creates a new web server
 func NewServer(config *Config) (*Server, error) {
+	config.ConfirmationPhrases = config.ConfirmationPhrases.WithDefaults()
+	
 	// Create auth service
 	authService := NewAuthService(config.AuthConfig)
 	
@@ -38,6 +81,9 @@ func NewServer(config *Config) (*Server, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := approvalStore.SetAutoApprovalRules(config.AutoApprovalRules); err != nil {
+		return nil, err
+	}
 	
 	// Open audit store
 	auditStore, err := audit.NewSQLiteStore(config.AuditDBPath)
@@ -45,11 +91,24 @@ func NewServer(config *Config) (*Server, error) {
 		return nil, err
 	}
 	
+	policyEngine := policy.NewEngine()
+	if config.PolicyFile != "" {
+		if policyEngine, err = policy.NewEngineFromFile(config.PolicyFile); err != nil {
+			return nil, err
+		}
+	}
+	if config.PolicyWebhookURL != "" {
+		policyEngine.SetDecisionPoint(policy.NewDecisionPoint(config.PolicyWebhookURL, config.PolicyWebhookSecret, config.PolicyWebhookTimeout))
+	}
+	
 	server := &Server{
 		router:        mux.NewRouter(),
 		authService:   authService,
 		approvalStore: approvalStore,
 		auditStore:    auditStore,
+		suggestions:   suggestions.NewSuggestionEngine(),
+		translator:    translator.New(),
+		policy:        policyEngine,
 		config:        config,
 	}
 	
@@ -69,9 +128,14 @@ func (s *Server) setupRoutes() {
 	protected := api.PathPrefix("").Subrouter()
 	protected.Use(s.authMiddleware)
 	
+	protected.HandleFunc("/translate", s.handleTranslate).Methods("POST")
 	protected.HandleFunc("/history", s.handleHistory).Methods("GET")
+	protected.Handle("/history/export", s.requireRole(RoleAuditor, http.HandlerFunc(s.handleHistoryExport))).Methods("GET")
 	protected.HandleFunc("/run/{id}", s.handleRunDetail).Methods("GET")
 	protected.HandleFunc("/run/{id}/replay", s.handleReplay).Methods("POST")
+	protected.HandleFunc("/suggestions", s.handleGetSuggestions).Methods("GET")
+	protected.HandleFunc("/suggestions/feedback", s.handleSuggestionFeedback).Methods("POST")
+	protected.HandleFunc("/policy/test", s.handlePolicyTest).Methods("POST")
 	
 	// Approval endpoints (require approver role)
 	protected.HandleFunc("/approvals", s.handleGetApprovals).Methods("GET")
@@ -107,33 +171,187 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
-	// Parse query parameters
-	limit := 20
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil {
-			limit = parsed
-		}
+// translatedCandidate is a candidate in the translate response, with the
+// confidence breakdown that explains its ranking
+type translatedCandidate struct {
+	Command             string                          `json:"command"`
+	Explanation         string                          `json:"explanation"`
+	Confidence          int                             `json:"confidence"`
+	RiskLevel           translator.Risk                 `json:"risk_level"`
+	Destructive         bool                            `json:"destructive"`
+	RequiresConfirm     bool                            `json:"requires_confirm"`
+	AffectedPaths       []string                        `json:"affected_paths,omitempty"`
+	Source              string                          `json:"source"`
+	ConfidenceBreakdown *translator.ConfidenceBreakdown `json:"confidence_breakdown"`
+}
+
+// newTranslatedCandidate converts a translator candidate for prompt into
+// its response form
+func newTranslatedCandidate(c *translator.Candidate, prompt string) *translatedCandidate {
+	return &translatedCandidate{
+		Command:             c.Command,
+		Explanation:         c.Explanation,
+		Confidence:          c.Confidence,
+		RiskLevel:           c.RiskLevel,
+		Destructive:         c.Destructive,
+		RequiresConfirm:     c.RequiresConfirm,
+		AffectedPaths:       c.AffectedPaths,
+		Source:              c.Source,
+		ConfidenceBreakdown: c.CalculateConfidenceBreakdown(prompt),
+	}
+}
+
+func (s *Server) handleTranslate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Prompt string `json:"prompt"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	
+	candidates, err := s.translator.TranslateContext(r.Context(), req.Prompt)
+	if errors.Is(err, translator.ErrEmptyPrompt) {
+		s.writeError(w, http.StatusBadRequest, "Prompt required")
+		return
+	}
+	if errors.Is(err, translator.ErrControlCharacters) {
+		s.writeError(w, http.StatusBadRequest, "Prompt contains control characters")
+		return
+	}
+	var blocked *translator.BlockedPromptError
+	if errors.As(err, &blocked) {
+		s.writeError(w, http.StatusUnprocessableEntity, "Refused: "+blocked.Error()+". "+blocked.Blocked.SecurityNote)
+		return
+	}
+	if err != nil && !errors.Is(err, translator.ErrNoMatch) {
+		s.writeError(w, http.StatusInternalServerError, "Failed to translate prompt")
+		return
 	}
 	
-	filter := r.URL.Query().Get("filter")
+	results := make([]*translatedCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		results = append(results, newTranslatedCandidate(c, req.Prompt))
+	}
+	
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"candidates": results,
+		"count":      len(results),
+	})
+}
+
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	query, err := parseHistoryQuery(r.URL.Query())
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	
 	// Get history from audit store
-	records, err := s.auditStore.GetHistory(limit, filter)
+	page, err := s.auditStore.GetHistoryPage(query)
 	if err != nil {
 		s.writeError(w, http.StatusInternalServerError, "Failed to fetch history")
 		return
 	}
 	
 	s.writeJSON(w, http.StatusOK, map[string]interface{}{
-		"records": records,
-		"count":   len(records),
+		"records": page.Records,
+		"count":   len(page.Records),
+		"total":   page.Total,
+		"offset":  query.Offset,
 	})
 }
 
+// handleHistoryExport streams the history matching the history filters as a
+// csv or jsonl download. Without a limit every matching run is exported.
+func (s *Server) handleHistoryExport(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query, err := parseHistoryQuery(params)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if query.Limit < 0 {
+		s.writeError(w, http.StatusBadRequest, "Invalid limit")
+		return
+	}
+	
+	export := s.auditStore.ExportCSV
+	contentType := "text/csv"
+	format := params.Get("format")
+	switch format {
+	case "csv", "":
+		format = "csv"
+	case "jsonl":
+		export = s.auditStore.ExportJSONL
+		contentType = "application/x-ndjson"
+	default:
+		s.writeError(w, http.StatusBadRequest, "Unknown export format")
+		return
+	}
+	
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="quickcmd-history.`+format+`"`)
+	
+	// The status is sent with the first record, so a failure part way
+	// through can only cut the download short
+	export(w, query)
+}
+
+// parseHistoryQuery parses the filter, risk, limit, offset, from and to
+// history parameters. The error is a message for the client.
+func parseHistoryQuery(params url.Values) (audit.HistoryQuery, error) {
+	query := audit.HistoryQuery{
+		Filter:    params.Get("filter"),
+		RiskLevel: params.Get("risk"),
+	}
+	if l := params.Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			query.Limit = parsed
+		}
+	}
+	if o := params.Get("offset"); o != "" {
+		parsed, err := strconv.Atoi(o)
+		if err != nil || parsed < 0 {
+			return query, errors.New("Invalid offset")
+		}
+		query.Offset = parsed
+	}
+	
+	var err error
+	if query.From, err = parseHistoryTime(params.Get("from"), false); err != nil {
+		return query, errors.New("Invalid from time")
+	}
+	if query.To, err = parseHistoryTime(params.Get("to"), true); err != nil {
+		return query, errors.New("Invalid to time")
+	}
+	return query, nil
+}
+
+// parseHistoryTime parses a from or to history parameter, either an RFC 3339
+// time or a date. A date as the end of a range includes that whole day.
+func parseHistoryTime(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
 func (s *Server) handleRunDetail(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
 		s.writeError(w, http.StatusBadRequest, "Invalid run ID")
 		return
@@ -148,9 +366,30 @@ func (s *Server) handleRunDetail(w http.ResponseWriter, r *http.Request) {
 	s.writeJSON(w, http.StatusOK, record)
 }
 
+// replayModeRetranslate re-runs a replayed prompt through the current
+// translator and policy instead of replaying the stored command
+const replayModeRetranslate = "retranslate"
+
+// policyDecision is the policy engine's verdict on a command
+type policyDecision struct {
+	Allowed         bool   `json:"allowed"`
+	Reason          string `json:"reason,omitempty"`
+	RequiresConfirm bool   `json:"requires_confirm"`
+	ConfirmMessage  string `json:"confirm_message,omitempty"`
+	RequiresApproval bool  `json:"requires_approval"`
+	MatchedRule     string `json:"matched_rule,omitempty"`
+}
+
+// retranslatedCandidate is a candidate for a replayed prompt with the
+// decision today's policy makes on it
+type retranslatedCandidate struct {
+	*translatedCandidate
+	Policy *policyDecision `json:"policy"`
+}
+
 func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
 		s.writeError(w, http.StatusBadRequest, "Invalid run ID")
 		return
@@ -163,10 +402,20 @@ func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "":
+	case replayModeRetranslate:
+		s.retranslateReplay(w, r, original)
+		return
+	default:
+		s.writeError(w, http.StatusBadRequest, "Invalid replay mode: "+mode)
+		return
+	}
+	
 	// Create new audit record for replay (dry-run)
 	claims := r.Context().Value("claims").(*Claims)
 	replayRecord := &audit.RunRecord{
-		Timestamp:       audit.Now(),
+		Timestamp:       time.Now().Format(time.RFC3339),
 		User:            claims.Username,
 		Prompt:          original.Prompt + " (REPLAY DRY-RUN)",
 		SelectedCommand: original.SelectedCommand,
@@ -185,6 +434,171 @@ func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// retranslateReplay shows how the current templates and policy handle the
+// original run's prompt. Nothing is run or logged, so the result can be
+// compared with the historical command.
+func (s *Server) retranslateReplay(w http.ResponseWriter, r *http.Request, original *audit.RunRecord) {
+	candidates, err := s.translator.TranslateContext(r.Context(), original.Prompt)
+	if errors.Is(err, translator.ErrEmptyPrompt) {
+		s.writeError(w, http.StatusBadRequest, "Run has no prompt to re-translate")
+		return
+	}
+	var blocked *translator.BlockedPromptError
+	if errors.As(err, &blocked) {
+		s.writeError(w, http.StatusUnprocessableEntity, "Refused: "+blocked.Error()+". "+blocked.Blocked.SecurityNote)
+		return
+	}
+	if err != nil && !errors.Is(err, translator.ErrNoMatch) {
+		s.writeError(w, http.StatusInternalServerError, "Failed to translate prompt")
+		return
+	}
+	
+	results := make([]*retranslatedCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		result := s.policy.Validate(c.Command, string(c.RiskLevel), c.Destructive)
+		results = append(results, &retranslatedCandidate{
+			translatedCandidate: newTranslatedCandidate(c, original.Prompt),
+			Policy: &policyDecision{
+				Allowed:         result.Allowed,
+				Reason:          result.Reason,
+				RequiresConfirm: result.RequiresConfirm,
+				ConfirmMessage:  result.ConfirmMessage,
+				RequiresApproval: result.RequiresApproval,
+				MatchedRule:     result.MatchedRule,
+			},
+		})
+	}
+	
+	// The top candidate is what the run would select today
+	changed := len(results) == 0 || results[0].Command != original.SelectedCommand
+	
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"mode":             replayModeRetranslate,
+		"dry_run":          true,
+		"prompt":           original.Prompt,
+		"original_command": original.SelectedCommand,
+		"original_risk":    original.RiskLevel,
+		"candidates":       results,
+		"count":            len(results),
+		"changed":          changed,
+	})
+}
+
+// handleGetSuggestions returns the authenticated user's personalized
+// suggestions, optionally related to the prompt query parameter
+func (s *Server) handleGetSuggestions(w http.ResponseWriter, r *http.Request) {
+	if err := s.learnFromHistory(); err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to load command history")
+		return
+	}
+	
+	claims := r.Context().Value("claims").(*Claims)
+	prompt := strings.TrimSpace(r.URL.Query().Get("prompt"))
+	results := s.suggestions.GetSuggestions(claims.Username, prompt)
+	
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"suggestions": results,
+		"count":       len(results),
+	})
+}
+
+// learnFromHistory feeds commands executed since the last call into the
+// suggestion engine, oldest first, so it learns from runs logged by the CLI
+// and agents as well as from the web UI
+func (s *Server) learnFromHistory() error {
+	s.learnMu.Lock()
+	defer s.learnMu.Unlock()
+	
+	records, err := s.auditStore.GetHistory(suggestionHistoryLimit, "")
+	if err != nil {
+		return err
+	}
+	
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		if record.ID <= s.learnedRunID {
+			continue
+		}
+		s.learnedRunID = record.ID
+		if record.Executed {
+			s.suggestions.AnalyzeCommand(record.User, record.SelectedCommand)
+		}
+	}
+	
+	return nil
+}
+
+func (s *Server) handleSuggestionFeedback(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Type     string `json:"type"`
+		Feedback string `json:"feedback"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	
+	switch req.Type {
+	case "alias", "optimization", "pattern", "context":
+	default:
+		s.writeError(w, http.StatusBadRequest, "Invalid suggestion type")
+		return
+	}
+	
+	feedback := suggestions.FeedbackType(req.Feedback)
+	switch feedback {
+	case suggestions.FeedbackAccepted, suggestions.FeedbackRejected, suggestions.FeedbackIgnored:
+	default:
+		s.writeError(w, http.StatusBadRequest, "Invalid feedback. Use: accepted, rejected, ignored")
+		return
+	}
+	
+	claims := r.Context().Value("claims").(*Claims)
+	s.suggestions.RecordFeedback(claims.Username, req.Type, feedback)
+	
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message":  "Feedback recorded",
+		"type":     req.Type,
+		"feedback": req.Feedback,
+	})
+}
+
+func (s *Server) handlePolicyTest(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Rule    VisualRule `json:"rule"`
+		Command string     `json:"command"`
+		At      *time.Time `json:"at,omitempty"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	
+	// Test the rule in a scratch builder so the playground has no side effects
+	pb := NewPolicyBuilder()
+	req.Rule.ID = ""
+	req.Rule.Enabled = true
+	if err := pb.AddRule(&req.Rule); err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	
+	at := time.Now()
+	if req.At != nil {
+		at = *req.At
+	}
+	
+	result, err := pb.TestRule(req.Rule.ID, req.Command, at)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	
+	s.writeJSON(w, http.StatusOK, result)
+}
+
 func (s *Server) handleGetApprovals(w http.ResponseWriter, r *http.Request) {
 	approvals, err := s.approvalStore.GetPendingApprovals()
 	if err != nil {
@@ -216,8 +630,14 @@ func (s *Server) handleApprove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	approval, err := s.approvalStore.GetApproval(id)
+	if err != nil {
+		s.writeError(w, http.StatusNotFound, "Approval not found")
+		return
+	}
+	
 	// Validate confirmation
-	expectedConfirmation := "APPROVE " + vars["id"]
+	expectedConfirmation := s.config.ConfirmationPhrases.Phrase(approval.RiskLevel, id)
 	if req.Confirmation != expectedConfirmation {
 		s.writeError(w, http.StatusBadRequest, "Invalid confirmation. Type: "+expectedConfirmation)
 		return
@@ -226,10 +646,16 @@ func (s *Server) handleApprove(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value("claims").(*Claims)
 	
 	if err := s.approvalStore.ApproveApproval(id, claims.Username, req.Confirmation, req.Note); err != nil {
+		if errors.Is(err, ErrSelfApproval) {
+			s.writeError(w, http.StatusForbidden, "You can't approve your own request")
+			return
+		}
 		s.writeError(w, http.StatusInternalServerError, "Failed to approve")
 		return
 	}
 	
+	s.resolveApprovalAsync(id)
+	
 	s.writeJSON(w, http.StatusOK, map[string]interface{}{
 		"message": "Approval granted",
 		"approved_by": claims.Username,
@@ -265,6 +691,8 @@ func (s *Server) handleReject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	s.resolveApprovalAsync(id)
+	
 	s.writeJSON(w, http.StatusOK, map[string]interface{}{
 		"message": "Approval rejected",
 		"rejected_by": claims.Username,
@@ -275,7 +703,7 @@ func (s *Server) handleReject(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteStatus(status)
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(data)
 }
 
@@ -287,6 +715,26 @@ func (s *Server) writeError(w http.ResponseWriter, status int, message string) {
 
 // Start starts the HTTP server
 func (s *Server) Start() error {
+	done := make(chan struct{})
+	defer close(done)
+	go s.pruneSuggestions(suggestionPruneInterval, done)
+	
 	addr := ":" + strconv.Itoa(s.config.Port)
 	return http.ListenAndServe(addr, s.router)
 }
+
+// pruneSuggestions drops expired suggestion suppressions every interval
+// until done is closed, so rejections don't pile up in a long-running server
+func (s *Server) pruneSuggestions(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-ticker.C:
+			s.suggestions.PruneExpired()
+		case <-done:
+			return
+		}
+	}
+}
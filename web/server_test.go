@@ -0,0 +1,517 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SagheerAkram/QuickCmd/core/audit"
+	"github.com/SagheerAkram/QuickCmd/core/policy"
+	"github.com/SagheerAkram/QuickCmd/core/suggestions"
+	"github.com/SagheerAkram/QuickCmd/core/translator"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfirmationPhrases(t *testing.T) {
+	phrases := DefaultConfirmationPhrases()
+
+	assert.Equal(t, "APPROVE 7", phrases.Phrase("high", 7))
+	assert.Equal(t, "APPROVE CRITICAL 7", phrases.Phrase("critical", 7))
+	assert.Equal(t, "APPROVE CRITICAL 7", phrases.Phrase("CRITICAL", 7))
+
+	custom := ConfirmationPhrases{"high": "I APPROVE RUN {id}"}
+	assert.Equal(t, "I APPROVE RUN 7", custom.Phrase("high", 7))
+	assert.Equal(t, "APPROVE 7", custom.Phrase("critical", 7))
+
+	// Configured phrases keep the defaults for other risk levels
+	merged := custom.WithDefaults()
+	assert.Equal(t, "I APPROVE RUN 7", merged.Phrase("high", 7))
+	assert.Equal(t, "APPROVE CRITICAL 7", merged.Phrase("critical", 7))
+	assert.Equal(t, "CONFIRM 7", ConfirmationPhrases{"Critical": "CONFIRM {id}"}.WithDefaults().Phrase("critical", 7))
+	assert.Equal(t, DefaultConfirmationPhrases(), ConfirmationPhrases(nil).WithDefaults())
+}
+
+func TestHandleApprove_Confirmation(t *testing.T) {
+	store, err := NewApprovalStore(filepath.Join(t.TempDir(), "approvals.db"))
+	assert.NoError(t, err)
+	defer store.Close()
+
+	server := &Server{
+		approvalStore: store,
+		config:        &Config{ConfirmationPhrases: DefaultConfirmationPhrases()},
+	}
+
+	approve := func(id int, confirmation string) *httptest.ResponseRecorder {
+		body := `{"confirmation":"` + confirmation + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/approvals/"+strconv.Itoa(id)+"/approve", strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(id)})
+		req = req.WithContext(context.WithValue(req.Context(), "claims", &Claims{Username: "bob"}))
+
+		rec := httptest.NewRecorder()
+		server.handleApprove(rec, req)
+		return rec
+	}
+
+	create := func(risk string) int {
+		id, err := store.CreateApproval(&Approval{
+			Command:     "terraform destroy",
+			RiskLevel:   risk,
+			RequestedBy: "alice",
+			RequestedAt: time.Now(),
+		})
+		assert.NoError(t, err)
+		return id
+	}
+
+	t.Run("critical approval rejects generic phrase", func(t *testing.T) {
+		id := create("critical")
+
+		rec := approve(id, "APPROVE "+strconv.Itoa(id))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var resp map[string]interface{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Contains(t, resp["error"], "APPROVE CRITICAL "+strconv.Itoa(id))
+
+		approval, err := store.GetApproval(id)
+		assert.NoError(t, err)
+		assert.Equal(t, ApprovalStatusPending, approval.Status)
+	})
+
+	t.Run("critical approval accepts strict phrase", func(t *testing.T) {
+		id := create("critical")
+
+		rec := approve(id, "APPROVE CRITICAL "+strconv.Itoa(id))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		approval, err := store.GetApproval(id)
+		assert.NoError(t, err)
+		assert.Equal(t, ApprovalStatusApproved, approval.Status)
+		assert.Equal(t, "bob", approval.ApprovedBy)
+	})
+
+	t.Run("high approval accepts generic phrase", func(t *testing.T) {
+		id := create("high")
+
+		rec := approve(id, "APPROVE "+strconv.Itoa(id))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("missing approval", func(t *testing.T) {
+		rec := approve(9999, "APPROVE 9999")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("requester can't approve their own request", func(t *testing.T) {
+		id, err := store.CreateApproval(&Approval{
+			Command:     "terraform destroy",
+			RiskLevel:   "high",
+			RequestedBy: "bob",
+			RequestedAt: time.Now(),
+		})
+		assert.NoError(t, err)
+
+		rec := approve(id, "APPROVE "+strconv.Itoa(id))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.ErrorIs(t, store.ApproveApproval(id, "bob", "APPROVE "+strconv.Itoa(id), ""), ErrSelfApproval)
+
+		approval, err := store.GetApproval(id)
+		assert.NoError(t, err)
+		assert.Equal(t, ApprovalStatusPending, approval.Status)
+
+		// Anyone else still can
+		assert.NoError(t, store.ApproveApproval(id, "carol", "APPROVE "+strconv.Itoa(id), ""))
+	})
+}
+
+func TestHandleTranslate_ConfidenceBreakdown(t *testing.T) {
+	server := &Server{translator: translator.New()}
+
+	translate := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/translate", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		server.handleTranslate(rec, req)
+		return rec
+	}
+
+	t.Run("matched prompt includes breakdown", func(t *testing.T) {
+		rec := translate(`{"prompt":"find files larger than 100MB"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var resp struct {
+			Candidates []struct {
+				Command             string `json:"command"`
+				Confidence          int    `json:"confidence"`
+				ConfidenceBreakdown struct {
+					Overall    int            `json:"overall"`
+					Components map[string]int `json:"components"`
+					Reasons    []string       `json:"reasons"`
+				} `json:"confidence_breakdown"`
+			} `json:"candidates"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+		if assert.NotEmpty(t, resp.Candidates) {
+			candidate := resp.Candidates[0]
+			breakdown := candidate.ConfidenceBreakdown
+
+			assert.NotEmpty(t, candidate.Command)
+			assert.Equal(t, candidate.Confidence, breakdown.Components["pattern"])
+			assert.Contains(t, breakdown.Components, "context")
+			assert.Contains(t, breakdown.Components, "risk")
+			assert.Len(t, breakdown.Reasons, len(breakdown.Components))
+			assert.Greater(t, breakdown.Overall, 0)
+		}
+	})
+
+	t.Run("unmatched prompt returns no candidates", func(t *testing.T) {
+		rec := translate(`{"prompt":"xyzabc123 nonsense prompt"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"count":0`)
+	})
+
+	t.Run("empty prompt is rejected", func(t *testing.T) {
+		rec := translate(`{"prompt":"   "}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestHandleReplay_Retranslate(t *testing.T) {
+	store, err := audit.NewSQLiteStore(filepath.Join(t.TempDir(), "audit.db"))
+	assert.NoError(t, err)
+	defer store.Close()
+
+	// Today's policy blocks the command the prompt now translates to
+	engine := policy.NewEngine()
+	assert.NoError(t, engine.AddDenyPattern(`^find\b`, "no filesystem scans"))
+
+	server := &Server{auditStore: store, translator: translator.New(), policy: engine}
+
+	original := &audit.RunRecord{
+		Prompt:          "find files larger than 100MB",
+		SelectedCommand: "du -ah . | sort -rh | head",
+		RiskLevel:       "safe",
+		Executed:        true,
+	}
+	assert.NoError(t, store.LogExecution(original))
+	id := strconv.FormatInt(original.ID, 10)
+
+	replay := func(mode string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/run/"+id+"/replay?mode="+mode, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		req = req.WithContext(context.WithValue(req.Context(), "claims", &Claims{Username: "bob"}))
+
+		rec := httptest.NewRecorder()
+		server.handleReplay(rec, req)
+		return rec
+	}
+
+	t.Run("retranslate returns the current command and policy decision", func(t *testing.T) {
+		rec := replay("retranslate")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var resp struct {
+			OriginalCommand string `json:"original_command"`
+			Changed         bool   `json:"changed"`
+			Candidates      []struct {
+				Command string `json:"command"`
+				Policy  struct {
+					Allowed     bool   `json:"allowed"`
+					Reason      string `json:"reason"`
+					MatchedRule string `json:"matched_rule"`
+				} `json:"policy"`
+			} `json:"candidates"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+		assert.Equal(t, original.SelectedCommand, resp.OriginalCommand)
+		assert.True(t, resp.Changed)
+		if assert.NotEmpty(t, resp.Candidates) {
+			candidate := resp.Candidates[0]
+			assert.True(t, strings.HasPrefix(candidate.Command, "find "), candidate.Command)
+			assert.False(t, candidate.Policy.Allowed)
+			assert.Contains(t, candidate.Policy.Reason, "no filesystem scans")
+			assert.Equal(t, `^find\b`, candidate.Policy.MatchedRule)
+		}
+
+		// Re-translating is a preview and logs nothing
+		history, err := store.GetHistory(10, "")
+		assert.NoError(t, err)
+		assert.Len(t, history, 1)
+	})
+
+	t.Run("default replay logs a dry run of the stored command", func(t *testing.T) {
+		rec := replay("")
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		history, err := store.GetHistory(10, "")
+		assert.NoError(t, err)
+		if assert.Len(t, history, 2) {
+			assert.Equal(t, original.SelectedCommand, history[0].SelectedCommand)
+			assert.False(t, history[0].Executed)
+		}
+	})
+
+	t.Run("unknown mode is rejected", func(t *testing.T) {
+		rec := replay("bogus")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestHandleGetSuggestions(t *testing.T) {
+	store, err := audit.NewSQLiteStore(filepath.Join(t.TempDir(), "audit.db"))
+	assert.NoError(t, err)
+	defer store.Close()
+
+	server := &Server{auditStore: store, suggestions: suggestions.NewSuggestionEngine()}
+
+	logRun := func(user, command string, executed bool) {
+		assert.NoError(t, store.LogExecution(&audit.RunRecord{
+			User:            user,
+			Prompt:          "seeded",
+			SelectedCommand: command,
+			RiskLevel:       "safe",
+			Executed:        executed,
+		}))
+	}
+	for i := 0; i < 5; i++ {
+		logRun("alice", "docker ps -a", true)
+		logRun("alice", "rm -rf build", false) // dry runs don't count
+	}
+	logRun("alice", `find . -type f -name "*.go" | xargs grep TODO`, true)
+	logRun("bob", "docker ps -a", true)
+
+	get := func(user, prompt string) []suggestions.Suggestion {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/suggestions?prompt="+prompt, nil)
+		req = req.WithContext(context.WithValue(req.Context(), "claims", &Claims{Username: user}))
+
+		rec := httptest.NewRecorder()
+		server.handleGetSuggestions(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var resp struct {
+			Suggestions []suggestions.Suggestion `json:"suggestions"`
+			Count       int                      `json:"count"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, len(resp.Suggestions), resp.Count)
+		return resp.Suggestions
+	}
+
+	byType := func(results []suggestions.Suggestion) map[string][]suggestions.Suggestion {
+		grouped := make(map[string][]suggestions.Suggestion)
+		for _, s := range results {
+			grouped[s.Type] = append(grouped[s.Type], s)
+		}
+		return grouped
+	}
+
+	alice := byType(get("alice", "list+containers"))
+	if assert.Len(t, alice["alias"], 1) {
+		assert.Equal(t, "alias doc='docker ps -a'", alice["alias"][0].Command)
+		assert.Contains(t, alice["alias"][0].Description, "5 times")
+	}
+	if assert.NotEmpty(t, alice["optimization"]) {
+		assert.Equal(t, "grep -r TODO --include='*.go' .", alice["optimization"][0].Command)
+	}
+
+	// History is only learned once
+	alice = byType(get("alice", ""))
+	if assert.Len(t, alice["alias"], 1) {
+		assert.Contains(t, alice["alias"][0].Description, "5 times")
+	}
+
+	// Suggestions are personal
+	bob := byType(get("bob", ""))
+	assert.Empty(t, bob["alias"])
+	assert.Empty(t, bob["optimization"])
+}
+
+func TestHandleHistory_Pagination(t *testing.T) {
+	store, err := audit.NewSQLiteStore(filepath.Join(t.TempDir(), "audit.db"))
+	assert.NoError(t, err)
+	defer store.Close()
+
+	server := &Server{auditStore: store}
+
+	// One run a day from March 1st; the 4th is high risk
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		risk := "safe"
+		if i == 3 {
+			risk = "high"
+		}
+		assert.NoError(t, store.LogExecution(&audit.RunRecord{
+			Timestamp:       start.AddDate(0, 0, i).Format(time.RFC3339),
+			Prompt:          "day " + strconv.Itoa(i+1),
+			SelectedCommand: "date",
+			RiskLevel:       risk,
+			Executed:        true,
+		}))
+	}
+
+	type historyResponse struct {
+		Records []*audit.RunRecord `json:"records"`
+		Count   int                `json:"count"`
+		Total   int                `json:"total"`
+	}
+	get := func(query string) (int, historyResponse) {
+		rec := httptest.NewRecorder()
+		server.handleHistory(rec, httptest.NewRequest(http.MethodGet, "/api/v1/history?"+query, nil))
+
+		var resp historyResponse
+		if rec.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		}
+		return rec.Code, resp
+	}
+	prompts := func(resp historyResponse) []string {
+		var out []string
+		for _, record := range resp.Records {
+			out = append(out, record.Prompt)
+		}
+		return out
+	}
+
+	code, resp := get("limit=4&offset=4")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 6, resp.Total)
+	assert.Equal(t, []string{"day 2", "day 1"}, prompts(resp))
+
+	// A date as the end of a range includes that day
+	_, resp = get("from=2024-03-02&to=2024-03-04")
+	assert.Equal(t, 3, resp.Total)
+	assert.Equal(t, []string{"day 4", "day 3", "day 2"}, prompts(resp))
+
+	_, resp = get("from=2024-03-02T00:00:00Z&to=2024-03-04T00:00:00Z&risk=safe&limit=1")
+	assert.Equal(t, 2, resp.Total)
+	assert.Equal(t, []string{"day 3"}, prompts(resp))
+
+	for _, bad := range []string{"offset=-1", "from=yesterday", "to=2024-13-01"} {
+		code, _ := get(bad)
+		assert.Equal(t, http.StatusBadRequest, code, bad)
+	}
+}
+
+func TestHandleHistoryExport(t *testing.T) {
+	store, err := audit.NewSQLiteStore(filepath.Join(t.TempDir(), "audit.db"))
+	assert.NoError(t, err)
+	defer store.Close()
+
+	server := &Server{auditStore: store}
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, store.LogExecution(&audit.RunRecord{
+			Prompt:          "run " + strconv.Itoa(i+1),
+			SelectedCommand: "date",
+			RiskLevel:       "safe",
+			Executed:        true,
+		}))
+	}
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.handleHistoryExport(rec, httptest.NewRequest(http.MethodGet, "/api/v1/history/export?"+query, nil))
+		return rec
+	}
+
+	rec := get("format=csv")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "quickcmd-history.csv")
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	assert.Len(t, lines, 4)
+	assert.True(t, strings.HasPrefix(lines[0], "id,timestamp,user,prompt,command"))
+
+	rec = get("format=jsonl&limit=2")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	lines = strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	assert.Len(t, lines, 2)
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "run 3", record["prompt"])
+
+	for _, bad := range []string{"format=xml", "offset=-1", "limit=-1"} {
+		assert.Equal(t, http.StatusBadRequest, get(bad).Code, bad)
+	}
+}
+
+func TestHandleHistoryExport_RequiresAuditor(t *testing.T) {
+	store, err := audit.NewSQLiteStore(filepath.Join(t.TempDir(), "audit.db"))
+	assert.NoError(t, err)
+	defer store.Close()
+
+	server := &Server{auditStore: store, authService: NewAuthService(&AuthConfig{})}
+	handler := server.requireRole(RoleAuditor, http.HandlerFunc(server.handleHistoryExport))
+
+	for _, tc := range []struct {
+		roles []Role
+		want  int
+	}{
+		{[]Role{RoleViewer}, http.StatusForbidden},
+		{[]Role{RoleOperator, RoleApprover}, http.StatusForbidden},
+		{[]Role{RoleAuditor}, http.StatusOK},
+		{[]Role{RoleAdmin}, http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/history/export", nil)
+		req = req.WithContext(context.WithValue(req.Context(), "claims", &Claims{Username: "bob", Roles: tc.roles}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, tc.want, rec.Code, "roles %v", tc.roles)
+	}
+}
+
+func TestPruneSuggestions(t *testing.T) {
+	engine := suggestions.NewSuggestionEngine()
+	engine.SetSuppressWindow(time.Millisecond)
+	engine.RecordFeedback("alice", "alias", suggestions.FeedbackRejected)
+	engine.RecordFeedback("bob", "context", suggestions.FeedbackRejected)
+	time.Sleep(5 * time.Millisecond)
+
+	server := &Server{suggestions: engine}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		server.pruneSuggestions(time.Millisecond, done)
+		close(stopped)
+	}()
+
+	// Give the ticker time to fire; nothing should be left to prune after
+	time.Sleep(100 * time.Millisecond)
+	close(done)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("pruning didn't stop")
+	}
+	assert.Equal(t, 0, engine.PruneExpired())
+}
+
+func TestNewServer_PolicyWebhook(t *testing.T) {
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(policy.DecisionResponse{Decision: policy.DecisionRequireApproval})
+	}))
+	defer webhook.Close()
+
+	dir := t.TempDir()
+	server, err := NewServer(&Config{
+		AuthConfig:       &AuthConfig{},
+		AuditDBPath:      filepath.Join(dir, "audit.db"),
+		ApprovalDBPath:   filepath.Join(dir, "approvals.db"),
+		PolicyWebhookURL: webhook.URL,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	result := server.policy.Validate("kubectl get pods", "safe", false)
+	assert.True(t, result.RequiresApprover)
+}